@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"agricultural_product_gin/config"
+	"agricultural_product_gin/service"
+	"agricultural_product_gin/utils"
+)
+
+// certificateUsage certificate子命令用法
+const certificateUsage = `用法:
+  certificate keygen                        生成签名私钥(写入certificate.signingKey)
+  certificate pubkey                        输出签名公钥(PEM)
+  certificate verify [-key 公钥文件] 证书文件   校验JSON证书，未指定-key时读取配置中签名私钥对应的公钥`
+
+// runCertificate 执行certificate子命令，verify在加载配置之前由main单独处理
+func runCertificate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(certificateUsage)
+	}
+
+	switch args[0] {
+	case "keygen":
+		privateKey, err := utils.GenerateSigningKey(cfg.Certificate.SigningKey)
+		if err != nil {
+			return fmt.Errorf("生成签名私钥失败: %w", err)
+		}
+		fmt.Printf("已生成签名私钥%s，公钥指纹%s\n", cfg.Certificate.SigningKey,
+			utils.SigningKeyID(privateKey.Public().(ed25519.PublicKey)))
+
+	case "pubkey":
+		privateKey, err := utils.LoadSigningKey(cfg.Certificate.SigningKey)
+		if err != nil {
+			return err
+		}
+		pemData, err := utils.EncodePublicKeyPEM(privateKey.Public().(ed25519.PublicKey))
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(pemData)
+
+	default:
+		return errors.New(certificateUsage)
+	}
+
+	return nil
+}
+
+// verifyCertificate 校验证书文件，证书无效时返回错误
+// 指定-key时只读取公钥和证书文件，不加载配置
+func verifyCertificate(args []string) error {
+	flags := flag.NewFlagSet("certificate verify", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	keyFile := flags.String("key", "", "公钥文件(PEM)")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errors.New(certificateUsage)
+	}
+
+	var publicKey ed25519.PublicKey
+	if *keyFile != "" {
+		data, err := os.ReadFile(*keyFile)
+		if err != nil {
+			return err
+		}
+		if publicKey, err = utils.ParsePublicKeyPEM(data); err != nil {
+			return err
+		}
+	} else {
+		cfg, err := config.Load("")
+		if err != nil {
+			return fmt.Errorf("未指定-key，加载配置失败: %w", err)
+		}
+		privateKey, err := utils.LoadSigningKey(cfg.Certificate.SigningKey)
+		if err != nil {
+			return err
+		}
+		publicKey = privateKey.Public().(ed25519.PublicKey)
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	certificate, err := service.VerifyCertificate(publicKey, data)
+	if err != nil {
+		return fmt.Errorf("证书校验失败: %w", err)
+	}
+
+	fmt.Println("证书有效")
+	fmt.Printf("  证书编号  %s\n", certificate.SerialNo)
+	fmt.Printf("  签发方    %s\n", certificate.Issuer)
+	fmt.Printf("  签发时间  %s\n", certificate.IssuedAt.Format("2006-01-02 15:04:05 MST"))
+	if chain := certificate.Chain; chain != nil {
+		if chain.Production != nil && chain.Production.Info != nil {
+			fmt.Printf("  批次号    %s\n", chain.Production.Info.BatchNo)
+		}
+		if chain.Sale != nil && chain.Sale.Info != nil {
+			fmt.Printf("  销售时间  %s\n", chain.Sale.Info.SaleTime.Format("2006-01-02 15:04"))
+		}
+	}
+	return nil
+}
+
+// mustLoadSigningKey 加载溯源证书签名私钥，dev环境私钥文件不存在时自动生成
+func mustLoadSigningKey(cfg *config.Config) ed25519.PrivateKey {
+	path := cfg.Certificate.SigningKey
+	privateKey, err := utils.LoadSigningKey(path)
+	if errors.Is(err, os.ErrNotExist) && cfg.Profile == config.ProfileDev {
+		privateKey, err = utils.GenerateSigningKey(path)
+		if err == nil {
+			log.Printf("已生成溯源证书签名私钥%s", path)
+		}
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("溯源证书签名私钥%s不存在，请先运行 certificate keygen", path)
+	}
+	if err != nil {
+		log.Fatal("加载溯源证书签名私钥失败: ", err)
+	}
+	return privateKey
+}
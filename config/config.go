@@ -2,31 +2,321 @@ package config
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"gopkg.in/yaml.v3"
+	_ "modernc.org/sqlite"
 )
 
-// 数据库配置
+// 运行环境
 const (
-	DBUsername = "root"
-	DBPassword = "123456"
-	DBHost     = "localhost"
-	DBPort     = "3306"
-	DBName     = "traceability"
+	ProfileDev  = "dev"
+	ProfileProd = "prod"
 )
 
-// GetDB 获取数据库连接
-func GetDB() *sql.DB {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		DBUsername, DBPassword, DBHost, DBPort, DBName)
+// DefaultJWTSecret 开发环境默认的JWT密钥，非dev环境禁止使用
+const DefaultJWTSecret = "123456"
+
+// DefaultConfigFile 默认配置文件路径，可通过环境变量APP_CONFIG指定
+const DefaultConfigFile = "config.yaml"
+
+// Config 应用配置
+// 加载顺序：默认值 -> 配置文件 -> 环境变量(优先级最高)
+type Config struct {
+	Profile     string            `yaml:"profile"`
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	JWT         JWTConfig         `yaml:"jwt"`
+	Upload      UploadConfig      `yaml:"upload"`
+	Certificate CertificateConfig `yaml:"certificate"`
+}
+
+// ServerConfig 服务配置
+type ServerConfig struct {
+	Addr          string   `yaml:"addr"`          // 监听地址
+	PublicBaseURL string   `yaml:"publicBaseUrl"` // 对外访问地址，用于生成图片及溯源码链接
+	CORSOrigins   []string `yaml:"corsOrigins"`   // 允许跨域的前端地址
+}
+
+// 支持的数据库驱动
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// DatabaseConfig 数据库配置，DSN不为空时忽略其余连接参数
+// SQLite使用Name作为数据库文件名，Username、Password、Host、Port不生效
+type DatabaseConfig struct {
+	Driver          string        `yaml:"driver"` // mysql或sqlite
+	DSN             string        `yaml:"dsn"`
+	Username        string        `yaml:"username"`
+	Password        string        `yaml:"password"`
+	Host            string        `yaml:"host"`
+	Port            string        `yaml:"port"`
+	Name            string        `yaml:"name"`
+	MaxOpenConns    int           `yaml:"maxOpenConns"`
+	MaxIdleConns    int           `yaml:"maxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"`
+}
+
+// JWTConfig 令牌配置
+type JWTConfig struct {
+	Secret             string        `yaml:"secret"`
+	AccessTokenExpire  time.Duration `yaml:"accessTokenExpire"`
+	RefreshTokenExpire time.Duration `yaml:"refreshTokenExpire"`
+}
+
+// UploadConfig 文件上传配置
+type UploadConfig struct {
+	Dir string `yaml:"dir"` // 上传文件存储目录
+}
+
+// CertificateConfig 溯源证书配置
+type CertificateConfig struct {
+	SigningKey string `yaml:"signingKey"` // Ed25519签名私钥文件(PKCS#8 PEM)
+}
+
+// Default 默认配置(本地开发环境)
+func Default() *Config {
+	return &Config{
+		Profile: ProfileDev,
+		Server: ServerConfig{
+			Addr:          ":8080",
+			PublicBaseURL: "http://localhost:8080",
+			CORSOrigins:   []string{"http://localhost:5173", "http://localhost:3030"},
+		},
+		Database: DatabaseConfig{
+			Driver:          DriverMySQL,
+			Username:        "root",
+			Password:        "123456",
+			Host:            "localhost",
+			Port:            "3306",
+			Name:            "traceability",
+			MaxOpenConns:    20,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: time.Hour,
+		},
+		JWT: JWTConfig{
+			Secret:             DefaultJWTSecret,
+			AccessTokenExpire:  15 * time.Minute,
+			RefreshTokenExpire: 7 * 24 * time.Hour,
+		},
+		Upload: UploadConfig{
+			Dir: "resources/images",
+		},
+		Certificate: CertificateConfig{
+			SigningKey: "certificate_ed25519.pem",
+		},
+	}
+}
+
+// Load 加载配置：path为空时使用APP_CONFIG或默认配置文件，默认配置文件不存在时仅使用默认值与环境变量
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv("APP_CONFIG")
+		explicit = path != ""
+	}
+	if path == "" {
+		path = DefaultConfigFile
+	}
 
-	db, err := sql.Open("mysql", dsn)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("解析配置文件%s失败: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// 未指定配置文件时允许只使用环境变量
+	default:
+		return nil, fmt.Errorf("读取配置文件%s失败: %w", path, err)
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// MustLoad 加载配置，失败时输出原因并退出
+func MustLoad() *Config {
+	cfg, err := Load("")
+	if err != nil {
+		log.Fatal("加载配置失败: ", err)
+	}
+	return cfg
+}
+
+// Validate 校验配置，返回所有问题
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.Profile == "" {
+		problems = append(problems, "profile不能为空")
+	}
+	if c.Server.Addr == "" {
+		problems = append(problems, "server.addr不能为空")
+	}
+	if u, err := url.Parse(c.Server.PublicBaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		problems = append(problems, "server.publicBaseUrl必须是完整的URL，如https://trace.example.com")
+	}
+	switch c.Database.Driver {
+	case DriverMySQL:
+		if c.Database.DSN == "" && (c.Database.Host == "" || c.Database.Name == "" || c.Database.Username == "") {
+			problems = append(problems, "database需要配置dsn，或host、name、username")
+		}
+	case DriverSQLite:
+		if c.Database.DSN == "" && c.Database.Name == "" {
+			problems = append(problems, "database需要配置dsn或name(SQLite数据库文件名)")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("database.driver只支持%s或%s: %q", DriverMySQL, DriverSQLite, c.Database.Driver))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 || c.Database.ConnMaxLifetime < 0 {
+		problems = append(problems, "database连接池参数不能为负数")
+	}
+	if c.JWT.Secret == "" {
+		problems = append(problems, "jwt.secret不能为空")
+	}
+	if c.JWT.Secret == DefaultJWTSecret && c.Profile != ProfileDev {
+		problems = append(problems, fmt.Sprintf("profile为%s时不能使用默认的jwt.secret，请通过APP_JWT_SECRET设置", c.Profile))
+	}
+	if c.JWT.AccessTokenExpire <= 0 || c.JWT.RefreshTokenExpire <= 0 {
+		problems = append(problems, "jwt令牌有效期必须大于0")
+	}
+	if c.JWT.RefreshTokenExpire < c.JWT.AccessTokenExpire {
+		problems = append(problems, "jwt.refreshTokenExpire不能小于jwt.accessTokenExpire")
+	}
+	if c.Upload.Dir == "" {
+		problems = append(problems, "upload.dir不能为空")
+	}
+	if c.Certificate.SigningKey == "" {
+		problems = append(problems, "certificate.signingKey不能为空")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("配置无效:\n  - " + strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// BuildDSN 数据库连接串
+// SQLite默认开启外键约束，事务开始即获取写锁(写事务串行执行)，并在数据库被锁定时等待而不是立即失败
+// MySQL按匹配的行数返回RowsAffected(与SQLite一致)，条件更新未改变任何值时不会被误判为并发修改
+func (d *DatabaseConfig) BuildDSN() string {
+	if d.DSN != "" {
+		return d.DSN
+	}
+	if d.Driver == DriverSQLite {
+		return fmt.Sprintf("file:%s.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate", d.Name)
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local&clientFoundRows=true",
+		d.Username, d.Password, d.Host, d.Port, d.Name)
+}
+
+// PublicURL 拼接对外访问地址
+func (s *ServerConfig) PublicURL(path string) string {
+	return strings.TrimRight(s.PublicBaseURL, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// applyEnv 使用环境变量覆盖配置
+func (c *Config) applyEnv() error {
+	var problems []string
+
+	setString := func(key string, target *string) {
+		if value, ok := os.LookupEnv(key); ok {
+			*target = value
+		}
+	}
+	setInt := func(key string, target *int) {
+		if value, ok := os.LookupEnv(key); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s必须是整数: %q", key, value))
+				return
+			}
+			*target = n
+		}
+	}
+	setDuration := func(key string, target *time.Duration) {
+		if value, ok := os.LookupEnv(key); ok {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s必须是时长(如15m、24h): %q", key, value))
+				return
+			}
+			*target = d
+		}
+	}
+
+	setString("APP_PROFILE", &c.Profile)
+	setString("APP_SERVER_ADDR", &c.Server.Addr)
+	setString("APP_PUBLIC_BASE_URL", &c.Server.PublicBaseURL)
+	if value, ok := os.LookupEnv("APP_CORS_ORIGINS"); ok {
+		c.Server.CORSOrigins = splitList(value)
+	}
+
+	setString("APP_DB_DRIVER", &c.Database.Driver)
+	setString("APP_DB_DSN", &c.Database.DSN)
+	setString("APP_DB_USERNAME", &c.Database.Username)
+	setString("APP_DB_PASSWORD", &c.Database.Password)
+	setString("APP_DB_HOST", &c.Database.Host)
+	setString("APP_DB_PORT", &c.Database.Port)
+	setString("APP_DB_NAME", &c.Database.Name)
+	setInt("APP_DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	setInt("APP_DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	setDuration("APP_DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+
+	setString("APP_JWT_SECRET", &c.JWT.Secret)
+	setDuration("APP_JWT_ACCESS_EXPIRE", &c.JWT.AccessTokenExpire)
+	setDuration("APP_JWT_REFRESH_EXPIRE", &c.JWT.RefreshTokenExpire)
+
+	setString("APP_UPLOAD_DIR", &c.Upload.Dir)
+
+	setString("APP_CERT_SIGNING_KEY", &c.Certificate.SigningKey)
+
+	if len(problems) > 0 {
+		return errors.New("环境变量无效:\n  - " + strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// splitList 解析逗号分隔的列表
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetDB 获取数据库连接
+func GetDB(cfg *DatabaseConfig) *sql.DB {
+	db, err := sql.Open(cfg.Driver, cfg.BuildDSN())
 	if err != nil {
 		log.Fatal("连接数据库失败:", err)
 	}
 
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
 	err = db.Ping()
 	if err != nil {
 		log.Fatal("数据库Ping失败:", err)
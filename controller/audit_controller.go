@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/service"
+)
+
+// AuditController 审计日志控制器
+type AuditController struct {
+	AuditService *service.AuditService
+}
+
+// NewAuditController 创建审计日志控制器
+func NewAuditController(service *service.AuditService) *AuditController {
+	return &AuditController{AuditService: service}
+}
+
+// PageQuery 分页查询审计日志
+// @Summary 按实体、操作人、操作及时间范围分页查询审计日志
+// @Router /audit/page [post]
+func (c *AuditController) PageQuery(ctx *gin.Context) {
+	var queryDTO dto.AuditPageQueryDTO
+	if err := ctx.ShouldBindJSON(&queryDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	query := &model.AuditLogPageQueryDTO{
+		Page:     queryDTO.Page,
+		Size:     queryDTO.Size,
+		Entity:   queryDTO.Entity,
+		EntityID: queryDTO.EntityID,
+		Actor:    queryDTO.Actor,
+		Action:   queryDTO.Action,
+	}
+	if queryDTO.Start != "" {
+		start, err := parseQueryTime(queryDTO.Start, false)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "开始时间格式错误", "data": nil})
+			return
+		}
+		query.Start = &start
+	}
+	if queryDTO.End != "" {
+		end, err := parseQueryTime(queryDTO.End, true)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "结束时间格式错误", "data": nil})
+			return
+		}
+		query.End = &end
+	}
+
+	result := c.AuditService.PageQuery(query)
+	ctx.JSON(result.Code, result)
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/service"
+)
+
+// BatchController 生产批次拆分、合并及谱系控制器
+type BatchController struct {
+	BatchService *service.BatchService
+}
+
+// NewBatchController 创建生产批次控制器
+func NewBatchController(service *service.BatchService) *BatchController {
+	return &BatchController{BatchService: service}
+}
+
+// Split 拆分批次
+// @Summary 将批次拆分为多个新批次
+// @Router /productinfo/{id}/split [post]
+func (c *BatchController) Split(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误", "data": nil})
+		return
+	}
+
+	var splitDTO dto.BatchSplitDTO
+	if err := ctx.ShouldBindJSON(&splitDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	result := c.BatchService.Split(currentScope(ctx), id, &splitDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// Merge 合并批次
+// @Summary 将同一产品、同一生产地的多个批次合并为一个新批次
+// @Router /productinfo/merge [post]
+func (c *BatchController) Merge(ctx *gin.Context) {
+	var mergeDTO dto.BatchMergeDTO
+	if err := ctx.ShouldBindJSON(&mergeDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	result := c.BatchService.Merge(currentScope(ctx), &mergeDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// Lineage 查询批次谱系
+// @Summary 查询批次的来源批次、产出批次及数量去向
+// @Router /productinfo/{id}/lineage [get]
+func (c *BatchController) Lineage(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误", "data": nil})
+		return
+	}
+
+	result := c.BatchService.Lineage(currentScope(ctx), id)
+	ctx.JSON(result.Code, result)
+}
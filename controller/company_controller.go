@@ -34,7 +34,7 @@ func (c *CompanyController) Save(ctx *gin.Context) {
 	}
 
 	log.Printf("新增公司：%+v", company)
-	result := c.CompanyService.CreateCompany(&dto.CompanyDTO{
+	result := c.CompanyService.CreateCompany(currentScope(ctx), &dto.CompanyDTO{
 		Name:          company.Name,
 		Address:       company.Address,
 		Administrator: company.Administrator,
@@ -55,7 +55,7 @@ func (c *CompanyController) Update(ctx *gin.Context) {
 	}
 
 	log.Printf("修改公司：%+v", company)
-	result := c.CompanyService.UpdateCompany(&dto.CompanyDTO{
+	result := c.CompanyService.UpdateCompany(currentScope(ctx), &dto.CompanyDTO{
 		ID:            company.ID,
 		Name:          company.Name,
 		Address:       company.Address,
@@ -78,7 +78,24 @@ func (c *CompanyController) Delete(ctx *gin.Context) {
 	}
 
 	log.Printf("删除公司，ID：%d", id)
-	result := c.CompanyService.DeleteCompany(id)
+	result := c.CompanyService.DeleteCompany(currentScope(ctx), id)
+	ctx.JSON(http.StatusOK, result)
+}
+
+// Restore 恢复已删除的公司
+func (c *CompanyController) Restore(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "ID参数错误",
+		})
+		return
+	}
+
+	log.Printf("恢复公司，ID：%d", id)
+	result := c.CompanyService.RestoreCompany(currentScope(ctx), id)
 	ctx.JSON(http.StatusOK, result)
 }
 
@@ -110,6 +127,7 @@ func (c *CompanyController) PageQuery(ctx *gin.Context) {
 	}
 
 	log.Printf("分页查询公司，条件：%+v", queryDTO)
+	queryDTO.IncludeDeleted = includeDeleted(ctx, queryDTO.IncludeDeleted)
 	result := c.CompanyService.PageQueryCompanies(&queryDTO)
 	ctx.JSON(http.StatusOK, result)
 }
@@ -117,6 +135,6 @@ func (c *CompanyController) PageQuery(ctx *gin.Context) {
 // ListAll 查询所有公司
 func (c *CompanyController) ListAll(ctx *gin.Context) {
 	log.Println("查询所有公司")
-	result := c.CompanyService.GetAllCompanies()
+	result := c.CompanyService.GetAllCompanies(includeDeletedQuery(ctx))
 	ctx.JSON(http.StatusOK, result)
 }
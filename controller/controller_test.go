@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"agricultural_product_gin/middleware"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+	"agricultural_product_gin/repository/repotest"
+	"agricultural_product_gin/service"
+	"agricultural_product_gin/utils"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	log.SetOutput(io.Discard)
+	utils.InitJWT("test-secret", time.Hour, 24*time.Hour)
+	os.Exit(m.Run())
+}
+
+// testServer 使用内存仓库的测试服务，路由与main.go一致
+type testServer struct {
+	engine      *gin.Engine
+	users       *repotest.UserRepository
+	products    *repotest.ProductRepository
+	userService *service.UserService
+}
+
+// farmerPasswordHash farmer用户的密码哈希(farmer123)，只计算一次
+var farmerPasswordHash string
+
+// newTestServer 管理员admin(1)、生产地2的农场用户farmer(2)、销售地5的零售商retailer(3)、未分配角色的guest(4)；产品1为苹果
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	if farmerPasswordHash == "" {
+		hash, err := utils.HashPassword("farmer123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		farmerPasswordHash = hash
+	}
+
+	users := repotest.NewUserRepository(
+		&model.User{ID: 1, Username: "admin", Role: model.RoleAdmin},
+		&model.User{ID: 2, Username: "farmer", Password: farmerPasswordHash, Role: model.RoleFarmer, ProductPlaceID: 2},
+		&model.User{ID: 3, Username: "retailer", Role: model.RoleRetailer, SalePlaceID: 5},
+		&model.User{ID: 4, Username: "guest"},
+	)
+	products := repotest.NewProductRepository(&model.Product{ID: 1, Name: "苹果", Type: "水果"})
+	auditRepo := repotest.NewAuditRepository()
+	tokenRepo := repotest.NewTokenRepository(users)
+	uow := repotest.NewUnitOfWork(&repository.Repositories{User: users, Token: tokenRepo, Product: products, Audit: auditRepo})
+	tokenService := service.NewTokenService(tokenRepo, users)
+	userService := service.NewUserService(users, tokenService, uow)
+	userController := NewUserController(userService)
+	productController := NewProductController(service.NewProductService(products, uow))
+
+	r := gin.New()
+	readRoles := []string{model.RoleFarmer, model.RoleLogistics, model.RoleRetailer, model.RoleAuditor}
+	auth := func(permissions middleware.Permissions) []gin.HandlerFunc {
+		return []gin.HandlerFunc{middleware.JWTMiddleware(tokenService), middleware.RoleMiddleware(permissions)}
+	}
+
+	userGroup := r.Group("/user")
+	{
+		userGroup.POST("/login", userController.Login)
+		authGroup := userGroup.Group("/")
+		authGroup.Use(middleware.JWTMiddleware(tokenService))
+		{
+			authGroup.POST("/logout", userController.Logout)
+			authGroup.GET("/userInfo", userController.GetUserInfo)
+		}
+	}
+
+	productGroup := r.Group("/product", auth(middleware.Permissions{
+		"GET":                      readRoles,
+		"PUT /product/restore/:id": {},
+	})...)
+	{
+		productGroup.POST("", productController.Save)
+		productGroup.DELETE("/:id", productController.Delete)
+		productGroup.GET("/:id", productController.GetById)
+		productGroup.PUT("/restore/:id", productController.Restore)
+	}
+
+	return &testServer{engine: r, users: users, products: products, userService: userService}
+}
+
+// token 为用户签发访问令牌
+func (s *testServer) token(t *testing.T, userID int) string {
+	t.Helper()
+	token, _, err := utils.GenerateToken(s.users.Users[userID])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
+
+// response 接口响应
+type response struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// do 发送请求，authorization为空时不带认证头
+func (s *testServer) do(t *testing.T, method, path, authorization, body string) (int, *response) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+
+	var resp response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s 响应不是JSON: %s", method, path, w.Body.String())
+	}
+	return w.Code, &resp
+}
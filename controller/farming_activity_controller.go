@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/service"
+)
+
+// FarmingActivityController 农事活动控制器
+type FarmingActivityController struct {
+	FarmingActivityService *service.FarmingActivityService
+}
+
+// NewFarmingActivityController 创建农事活动控制器
+func NewFarmingActivityController(service *service.FarmingActivityService) *FarmingActivityController {
+	return &FarmingActivityController{FarmingActivityService: service}
+}
+
+// Save 新增农事活动
+// @Summary 登记施肥、施药、灌溉等农事活动
+// @Router /productinfo/activity [post]
+func (c *FarmingActivityController) Save(ctx *gin.Context) {
+	var activityDTO dto.FarmingActivityDTO
+	if err := ctx.ShouldBindJSON(&activityDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	if activityDTO.ProductInfoID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "生产信息ID不能为空", "data": nil})
+		return
+	}
+
+	result := c.FarmingActivityService.Save(currentScope(ctx), &activityDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// Update 修改农事活动
+// @Summary 修改农事活动
+// @Router /productinfo/activity [put]
+func (c *FarmingActivityController) Update(ctx *gin.Context) {
+	var activityDTO dto.FarmingActivityDTO
+	if err := ctx.ShouldBindJSON(&activityDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	if activityDTO.ID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "农事活动ID不能为空", "data": nil})
+		return
+	}
+
+	result := c.FarmingActivityService.Update(currentScope(ctx), &activityDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// Delete 删除农事活动
+// @Summary 删除农事活动
+// @Router /productinfo/activity/{id} [delete]
+func (c *FarmingActivityController) Delete(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误", "data": nil})
+		return
+	}
+
+	result := c.FarmingActivityService.Delete(currentScope(ctx), id)
+	ctx.JSON(result.Code, result)
+}
+
+// GetByID 根据ID获取农事活动
+// @Summary 查询农事活动
+// @Router /productinfo/activity/{id} [get]
+func (c *FarmingActivityController) GetByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误", "data": nil})
+		return
+	}
+
+	result := c.FarmingActivityService.GetByID(currentScope(ctx), id)
+	ctx.JSON(result.Code, result)
+}
+
+// List 查询生产信息的农事活动
+// @Summary 按作业时间查询生产信息的全部农事活动
+// @Router /productinfo/{id}/activities [get]
+func (c *FarmingActivityController) List(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误", "data": nil})
+		return
+	}
+
+	result := c.FarmingActivityService.ListByProductInfo(currentScope(ctx), id)
+	ctx.JSON(result.Code, result)
+}
@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"agricultural_product_gin/service"
+)
+
+// HashChainController 哈希链控制器
+type HashChainController struct {
+	HashChainService *service.HashChainService
+}
+
+// NewHashChainController 创建哈希链控制器
+func NewHashChainController(service *service.HashChainService) *HashChainController {
+	return &HashChainController{HashChainService: service}
+}
+
+// Verify 校验哈希链
+// @Summary 复算整条哈希链并与当前数据比对，报告第一个断裂处
+// @Router /hashchain/verify [get]
+func (c *HashChainController) Verify(ctx *gin.Context) {
+	result := c.HashChainService.Verify()
+	ctx.JSON(result.Code, result)
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"agricultural_product_gin/model"
+)
+
+// currentIdentity 获取JWTMiddleware保存在请求上下文中的当前用户身份，未登录时返回nil
+func currentIdentity(ctx *gin.Context) *model.Identity {
+	return model.IdentityFrom(ctx.Request.Context())
+}
+
+// currentScope 当前用户的数据权限范围
+func currentScope(ctx *gin.Context) model.DataScope {
+	return currentIdentity(ctx).Scope()
+}
+
+// includeDeleted 列表查询是否包含已删除的记录，仅管理员的请求生效
+func includeDeleted(ctx *gin.Context, requested bool) bool {
+	identity := currentIdentity(ctx)
+	return requested && identity != nil && identity.Role == model.RoleAdmin
+}
+
+// includeDeletedQuery 读取查询参数includeDeleted，仅管理员的请求生效
+func includeDeletedQuery(ctx *gin.Context) bool {
+	requested, _ := strconv.ParseBool(ctx.Query("includeDeleted"))
+	return includeDeleted(ctx, requested)
+}
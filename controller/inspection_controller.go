@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/service"
+)
+
+// InspectionController 质量检测控制器
+type InspectionController struct {
+	InspectionService *service.InspectionService
+}
+
+// NewInspectionController 创建质量检测控制器
+func NewInspectionController(service *service.InspectionService) *InspectionController {
+	return &InspectionController{InspectionService: service}
+}
+
+// Save 新增检测记录
+// @Summary 登记农残检测、质量分级或认证证书，检测报告PDF需先通过/upload上传
+// @Router /inspection [post]
+func (c *InspectionController) Save(ctx *gin.Context) {
+	var inspectionDTO dto.InspectionDTO
+	if err := ctx.ShouldBindJSON(&inspectionDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	result := c.InspectionService.Save(currentScope(ctx), &inspectionDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// Update 修改检测记录
+// @Summary 修改检测记录，检测项目整体替换
+// @Router /inspection [put]
+func (c *InspectionController) Update(ctx *gin.Context) {
+	var inspectionDTO dto.InspectionDTO
+	if err := ctx.ShouldBindJSON(&inspectionDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	if inspectionDTO.ID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "检测记录ID不能为空", "data": nil})
+		return
+	}
+
+	result := c.InspectionService.Update(currentScope(ctx), &inspectionDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// Delete 删除检测记录
+// @Summary 删除检测记录
+// @Router /inspection/{id} [delete]
+func (c *InspectionController) Delete(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误", "data": nil})
+		return
+	}
+
+	result := c.InspectionService.Delete(currentScope(ctx), id)
+	ctx.JSON(result.Code, result)
+}
+
+// GetByID 根据ID获取检测记录
+// @Summary 查询检测记录及检测项目
+// @Router /inspection/{id} [get]
+func (c *InspectionController) GetByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误", "data": nil})
+		return
+	}
+
+	result := c.InspectionService.GetByID(currentScope(ctx), id)
+	ctx.JSON(result.Code, result)
+}
+
+// PageQuery 分页查询检测记录
+// @Summary 按批次、物流信息、检测类型、结论、检测机构分页查询
+// @Router /inspection/page [post]
+func (c *InspectionController) PageQuery(ctx *gin.Context) {
+	var queryDTO model.InspectionPageQueryDTO
+	if err := ctx.ShouldBindJSON(&queryDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	result := c.InspectionService.PageQuery(currentScope(ctx), &queryDTO)
+	ctx.JSON(result.Code, result)
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"agricultural_product_gin/model"
 	"agricultural_product_gin/service"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -13,23 +14,26 @@ import (
 
 // LogisticsController 物流控制器
 type LogisticsController struct {
-	service *service.LogisticsService
+	service          *service.LogisticsService
+	coldChainService *service.ColdChainService
 }
 
 // NewLogisticsController 创建物流控制器
-func NewLogisticsController(service *service.LogisticsService) *LogisticsController {
-	return &LogisticsController{service: service}
+func NewLogisticsController(service *service.LogisticsService, coldChainService *service.ColdChainService) *LogisticsController {
+	return &LogisticsController{service: service, coldChainService: coldChainService}
 }
 
 // Save 保存物流信息
 func (c *LogisticsController) Save(ctx *gin.Context) {
 	var request struct {
-		ProductInfoID int    `json:"productInfoId"`
-		CompanyID     int    `json:"companyId"`
-		StartLocation string `json:"startLocation"`
-		Destination   string `json:"destination"`
-		StartTime     string `json:"startTime"`
-		EndTime       string `json:"endTime"`
+		ProductInfoID int      `json:"productInfoId"`
+		CompanyID     int      `json:"companyId"`
+		PrevID        *int     `json:"prevLogId"`
+		StartLocation string   `json:"startLocation"`
+		Destination   string   `json:"destination"`
+		StartTime     string   `json:"startTime"`
+		EndTime       string   `json:"endTime"`
+		Quantity      *float64 `json:"quantity"`
 	}
 
 	if err := ctx.ShouldBindJSON(&request); err != nil {
@@ -76,18 +80,17 @@ func (c *LogisticsController) Save(ctx *gin.Context) {
 	logistics := &model.Logistics{
 		ProductInfoID: request.ProductInfoID,
 		CompanyID:     request.CompanyID,
+		PrevID:        request.PrevID,
 		StartLocation: request.StartLocation,
 		Destination:   request.Destination,
 		StartTime:     startTime,
 		EndTime:       endTime,
+		Quantity:      request.Quantity,
 	}
 
-	id, err := c.service.Save(logistics)
+	id, err := c.service.Save(currentScope(ctx), logistics)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"code": 500,
-			"msg":  "保存物流信息失败: " + err.Error(),
-		})
+		respondLogisticsError(ctx, err, "保存物流信息失败")
 		return
 	}
 
@@ -110,12 +113,9 @@ func (c *LogisticsController) Delete(ctx *gin.Context) {
 		return
 	}
 
-	err = c.service.Delete(id)
+	err = c.service.Delete(currentScope(ctx), id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"code": 500,
-			"msg":  "删除物流信息失败: " + err.Error(),
-		})
+		respondLogisticsError(ctx, err, "删除物流信息失败")
 		return
 	}
 
@@ -137,7 +137,7 @@ func (c *LogisticsController) GetById(ctx *gin.Context) {
 		return
 	}
 
-	logistics, err := c.service.GetByID(id)
+	logistics, err := c.service.GetByID(currentScope(ctx), id)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
@@ -154,6 +154,16 @@ func (c *LogisticsController) GetById(ctx *gin.Context) {
 		return
 	}
 
+	// 附加冷链温湿度汇总
+	logistics.ColdChain, err = c.coldChainService.Summary(logistics)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取冷链数据失败: " + err.Error(),
+		})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"code": 200,
 		"msg":  "查询成功",
@@ -173,7 +183,7 @@ func (c *LogisticsController) PageQuery(ctx *gin.Context) {
 		return
 	}
 
-	pageResult, err := c.service.PageQuery(&dto)
+	pageResult, err := c.service.PageQuery(currentScope(ctx), &dto)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
@@ -209,12 +219,9 @@ func (c *LogisticsController) Update(ctx *gin.Context) {
 		return
 	}
 
-	err := c.service.Update(&logistics)
+	err := c.service.Update(currentScope(ctx), &logistics)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"code": 500,
-			"msg":  "更新物流信息失败: " + err.Error(),
-		})
+		respondLogisticsError(ctx, err, "更新物流信息失败")
 		return
 	}
 
@@ -226,7 +233,7 @@ func (c *LogisticsController) Update(ctx *gin.Context) {
 
 // List 查询所有物流信息
 func (c *LogisticsController) List(ctx *gin.Context) {
-	logisticsList, err := c.service.FindAll()
+	logisticsList, err := c.service.FindAll(currentScope(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
@@ -254,40 +261,339 @@ func (c *LogisticsController) ConfirmReceipt(ctx *gin.Context) {
 		return
 	}
 
-	// 获取当前物流信息
-	logistics, err := c.service.GetByID(id)
+	// 备注为可选参数
+	var request struct {
+		Remark string `json:"remark"`
+	}
+	_ = ctx.ShouldBindJSON(&request)
+
+	err = c.service.ConfirmReceipt(currentScope(ctx), id, request.Remark)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"code": 500,
-			"msg":  "获取物流信息失败: " + err.Error(),
+		respondLogisticsError(ctx, err, "确认收货失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "确认收货成功",
+	})
+}
+
+// Transition 变更物流状态
+func (c *LogisticsController) Transition(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "无效的ID",
 		})
 		return
 	}
 
-	if logistics == nil {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"code": 404,
-			"msg":  "未找到对应的物流信息",
+	var request struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	log.Printf("物流状态变更，ID：%d，状态：%s，原因：%s", id, request.Status, request.Reason)
+	err = c.service.Transition(currentScope(ctx), id, request.Status, request.Reason)
+	if err != nil {
+		respondLogisticsError(ctx, err, "变更物流状态失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "状态变更成功",
+	})
+}
+
+// AddEvent 追加物流运输事件
+func (c *LogisticsController) AddEvent(ctx *gin.Context) {
+	var request struct {
+		LogisticsID int    `json:"logId"`
+		EventType   string `json:"eventType"`
+		Location    string `json:"location"`
+		CompanyID   int    `json:"companyId"`
+		EventTime   string `json:"eventTime"`
+		Remark      string `json:"remark"`
+	}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if request.LogisticsID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "物流ID不能为空",
+		})
+		return
+	}
+
+	// 转换时间(为空时使用当前时间)
+	var eventTime time.Time
+	if request.EventTime != "" {
+		et, err := time.Parse(time.RFC3339, request.EventTime)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  "事件时间格式错误",
+			})
+			return
+		}
+		eventTime = et
+	}
+
+	event := &model.LogisticsEvent{
+		LogisticsID: request.LogisticsID,
+		EventType:   request.EventType,
+		Location:    request.Location,
+		CompanyID:   request.CompanyID,
+		EventTime:   eventTime,
+		Remark:      request.Remark,
+	}
+
+	id, err := c.service.AddEvent(currentScope(ctx), event)
+	if err != nil {
+		respondLogisticsError(ctx, err, "保存物流事件失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "保存成功",
+		"data": id,
+	})
+}
+
+// DeleteEvent 删除物流运输事件
+func (c *LogisticsController) DeleteEvent(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "无效的ID",
+		})
+		return
+	}
+
+	err = c.service.DeleteEvent(currentScope(ctx), id)
+	if err != nil {
+		respondLogisticsError(ctx, err, "删除物流事件失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "删除成功",
+	})
+}
+
+// ListEvents 查询物流事件时间线
+func (c *LogisticsController) ListEvents(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "无效的ID",
+		})
+		return
+	}
+
+	events, err := c.service.ListEvents(currentScope(ctx), id)
+	if err != nil {
+		respondLogisticsError(ctx, err, "查询物流事件失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "查询成功",
+		"data": events,
+	})
+}
+
+// IngestReadings 批量上报冷链传感器读数
+func (c *LogisticsController) IngestReadings(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "无效的ID",
 		})
 		return
 	}
 
-	// 设置收货时间为当前时间
-	now := time.Now()
-	logistics.EndTime = &now
+	var request struct {
+		Readings []*model.SensorReading `json:"readings"`
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请求参数错误: " + err.Error(),
+		})
+		return
+	}
 
-	// 更新物流信息
-	err = c.service.Update(logistics)
+	count, excursions, err := c.coldChainService.IngestJSON(currentScope(ctx), id, request.Readings)
+	if err != nil {
+		respondLogisticsError(ctx, err, "保存冷链读数失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "上报成功",
+		"data": gin.H{"count": count, "excursions": excursions},
+	})
+}
+
+// ImportReadingsCSV 上传记录仪导出的CSV文件
+func (c *LogisticsController) ImportReadingsCSV(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "无效的ID",
+		})
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "获取文件失败: " + err.Error(),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
-			"msg":  "确认收货失败: " + err.Error(),
+			"msg":  "读取文件失败: " + err.Error(),
 		})
 		return
 	}
+	defer file.Close()
+
+	count, excursions, err := c.coldChainService.IngestCSV(currentScope(ctx), id, file)
+	if err != nil {
+		respondLogisticsError(ctx, err, "导入冷链读数失败")
+		return
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"code": 200,
-		"msg":  "确认收货成功",
+		"msg":  "导入成功",
+		"data": gin.H{"count": count, "excursions": excursions},
 	})
 }
+
+// GetColdChain 查询冷链温湿度汇总
+func (c *LogisticsController) GetColdChain(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "无效的ID",
+		})
+		return
+	}
+
+	summary, err := c.coldChainService.ScopedSummary(currentScope(ctx), id)
+	if err != nil {
+		respondLogisticsError(ctx, err, "查询冷链数据失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "查询成功",
+		"data": summary,
+	})
+}
+
+// respondLogisticsError 将物流服务错误转换为响应
+func respondLogisticsError(ctx *gin.Context, err error, msg string) {
+	var referenced *service.ReferencedError
+	var invalid *service.InvalidReferenceError
+	switch {
+	case errors.As(err, &referenced):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"code": 409,
+			"msg":  err.Error(),
+			"data": referenced.References,
+		})
+	case errors.As(err, &invalid):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"code": 422,
+			"msg":  err.Error(),
+			"data": invalid.References,
+		})
+	case errors.Is(err, service.ErrReferenced):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"code": 409,
+			"msg":  service.ErrReferenced.Error(),
+		})
+	case errors.Is(err, service.ErrReferenceNotFound):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"code": 422,
+			"msg":  service.ErrReferenceNotFound.Error(),
+		})
+	case errors.Is(err, service.ErrLogisticsNotFound), errors.Is(err, service.ErrLogisticsEventNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+	case errors.Is(err, service.ErrBatchNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+	case errors.Is(err, service.ErrLogisticsForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"code": 403,
+			"msg":  err.Error(),
+		})
+	case errors.Is(err, service.ErrLogisticsFinalized), errors.Is(err, service.ErrLogisticsTransition),
+		errors.Is(err, service.ErrLogisticsConflict), errors.Is(err, service.ErrBatchQuantityExceeded):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"code": 409,
+			"msg":  err.Error(),
+		})
+	case errors.As(err, new(*service.ReadingFormatError)),
+		errors.Is(err, service.ErrReadingsEmpty),
+		errors.Is(err, service.ErrReadingsTooMany),
+		errors.Is(err, service.ErrReadingTimeEmpty),
+		errors.Is(err, service.ErrLogisticsStatus),
+		errors.Is(err, service.ErrLogisticsReason),
+		errors.Is(err, service.ErrLogisticsEventType),
+		errors.Is(err, service.ErrLogisticsEventDelivery),
+		errors.Is(err, service.ErrLogisticsEventCompany),
+		errors.Is(err, service.ErrLogisticsPrev),
+		errors.Is(err, service.ErrBatchQuantityInvalid):
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  msg + ": " + err.Error(),
+		})
+	}
+}
@@ -34,11 +34,16 @@ func (c *ProductController) Save(ctx *gin.Context) {
 	}
 
 	log.Printf("新增产品：%+v", product)
-	result := c.ProductService.CreateProduct(&dto.ProductDTO{
+	result := c.ProductService.CreateProduct(currentScope(ctx), &dto.ProductDTO{
 		Name:        product.Name,
 		Type:        product.Type,
 		Image:       product.Image,
 		Description: product.Description,
+
+		MinTemperature: product.MinTemperature,
+		MaxTemperature: product.MaxTemperature,
+		MinHumidity:    product.MinHumidity,
+		MaxHumidity:    product.MaxHumidity,
 	})
 	ctx.JSON(http.StatusOK, result)
 }
@@ -55,12 +60,17 @@ func (c *ProductController) Update(ctx *gin.Context) {
 	}
 
 	log.Printf("修改产品：%+v", product)
-	result := c.ProductService.UpdateProduct(&dto.ProductDTO{
+	result := c.ProductService.UpdateProduct(currentScope(ctx), &dto.ProductDTO{
 		ID:          product.ID,
 		Name:        product.Name,
 		Type:        product.Type,
 		Image:       product.Image,
 		Description: product.Description,
+
+		MinTemperature: product.MinTemperature,
+		MaxTemperature: product.MaxTemperature,
+		MinHumidity:    product.MinHumidity,
+		MaxHumidity:    product.MaxHumidity,
 	})
 	ctx.JSON(http.StatusOK, result)
 }
@@ -78,7 +88,24 @@ func (c *ProductController) Delete(ctx *gin.Context) {
 	}
 
 	log.Printf("删除产品，ID：%d", id)
-	result := c.ProductService.DeleteProduct(id)
+	result := c.ProductService.DeleteProduct(currentScope(ctx), id)
+	ctx.JSON(http.StatusOK, result)
+}
+
+// Restore 恢复已删除的产品
+func (c *ProductController) Restore(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "ID参数错误",
+		})
+		return
+	}
+
+	log.Printf("恢复产品，ID：%d", id)
+	result := c.ProductService.RestoreProduct(currentScope(ctx), id)
 	ctx.JSON(http.StatusOK, result)
 }
 
@@ -110,6 +137,7 @@ func (c *ProductController) PageQuery(ctx *gin.Context) {
 	}
 
 	log.Printf("分页查询产品，条件：%+v", queryDTO)
+	queryDTO.IncludeDeleted = includeDeleted(ctx, queryDTO.IncludeDeleted)
 	result := c.ProductService.PageQueryProducts(&queryDTO)
 	ctx.JSON(http.StatusOK, result)
 }
@@ -117,7 +145,7 @@ func (c *ProductController) PageQuery(ctx *gin.Context) {
 // List 查询所有产品
 func (c *ProductController) List(ctx *gin.Context) {
 	log.Println("查询所有产品")
-	result := c.ProductService.GetAllProducts()
+	result := c.ProductService.GetAllProducts(includeDeletedQuery(ctx))
 	ctx.JSON(http.StatusOK, result)
 }
 
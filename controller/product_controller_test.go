@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+func TestProductController_Auth(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name          string
+		method, path  string
+		authorization string
+		body          string
+		wantStatus    int
+		wantCode      int
+		wantMsg       string
+	}{
+		{name: "未提供令牌", method: http.MethodGet, path: "/product/1", wantStatus: 401, wantCode: 401, wantMsg: "未提供认证令牌"},
+		{name: "令牌格式错误", method: http.MethodGet, path: "/product/1", authorization: "Token abc", wantStatus: 401, wantCode: 401, wantMsg: "令牌格式错误"},
+		{name: "无效的令牌", method: http.MethodGet, path: "/product/1", authorization: "Bearer abc", wantStatus: 401, wantCode: 401, wantMsg: "无效的令牌"},
+		{name: "农场用户查询", method: http.MethodGet, path: "/product/1", authorization: s.token(t, 2), wantStatus: 200, wantCode: 200},
+		{name: "未分配角色的用户查询", method: http.MethodGet, path: "/product/1", authorization: s.token(t, 4), wantStatus: 403, wantCode: 403, wantMsg: "没有操作权限"},
+		{
+			name: "农场用户新增", method: http.MethodPost, path: "/product", authorization: s.token(t, 2),
+			body: `{"pdName":"梨","type":"水果"}`, wantStatus: 403, wantCode: 403,
+		},
+		{name: "零售商删除", method: http.MethodDelete, path: "/product/1", authorization: s.token(t, 3), wantStatus: 403, wantCode: 403},
+		{name: "恢复仅限管理员", method: http.MethodPut, path: "/product/restore/1", authorization: s.token(t, 2), wantStatus: 403, wantCode: 403},
+		{name: "请求体无效", method: http.MethodPost, path: "/product", authorization: s.token(t, 1), body: `{"pdName":`, wantStatus: 400, wantCode: 400, wantMsg: "请求参数错误"},
+		{name: "ID参数错误", method: http.MethodGet, path: "/product/abc", authorization: s.token(t, 1), wantStatus: 400, wantCode: 400, wantMsg: "ID参数错误"},
+		{name: "产品不存在", method: http.MethodGet, path: "/product/99", authorization: s.token(t, 1), wantStatus: 200, wantCode: 404, wantMsg: "产品不存在"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := s.do(t, tt.method, tt.path, tt.authorization, tt.body)
+			if status != tt.wantStatus || resp.Code != tt.wantCode {
+				t.Fatalf("状态码 = %d, code = %d(%s), 期望 %d, %d", status, resp.Code, resp.Msg, tt.wantStatus, tt.wantCode)
+			}
+			if tt.wantMsg != "" && resp.Msg != tt.wantMsg {
+				t.Fatalf("msg = %q, 期望 %q", resp.Msg, tt.wantMsg)
+			}
+		})
+	}
+	if len(s.products.Products) != 1 || s.products.Products[1].Deleted() {
+		t.Fatalf("未授权的请求修改了产品: %+v", s.products.Products)
+	}
+}
+
+func TestProductController_SaveAndGet(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.token(t, 1)
+
+	status, resp := s.do(t, http.MethodPost, "/product", admin, `{"pdName":"草莓","type":"水果","minTemperature":0,"maxTemperature":4}`)
+	if status != 200 || resp.Code != 200 || resp.Msg != "添加成功" {
+		t.Fatalf("新增产品 = %d %+v", status, resp)
+	}
+	var id int
+	if err := json.Unmarshal(resp.Data, &id); err != nil || id != 2 {
+		t.Fatalf("新增产品ID = %s", resp.Data)
+	}
+
+	// 其他角色可以查询管理员新增的产品
+	status, resp = s.do(t, http.MethodGet, "/product/2", s.token(t, 3), "")
+	var product model.Product
+	if status != 200 || resp.Code != 200 || json.Unmarshal(resp.Data, &product) != nil {
+		t.Fatalf("查询产品 = %d %+v", status, resp)
+	}
+	if product.Name != "草莓" || product.MaxTemperature == nil || *product.MaxTemperature != 4 {
+		t.Fatalf("查询产品 = %+v", product)
+	}
+
+	status, resp = s.do(t, http.MethodPost, "/product", admin, `{"pdName":"草莓","type":"水果","minTemperature":8,"maxTemperature":4}`)
+	if status != 200 || resp.Code != 400 || resp.Msg != "最低温度不能高于最高温度" {
+		t.Fatalf("阈值无效 = %d %+v", status, resp)
+	}
+}
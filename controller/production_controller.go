@@ -40,10 +40,13 @@ func (c *ProductionController) Save(ctx *gin.Context) {
 		Description:    production.Description,
 		PlantingDate:   production.PlantingDate,
 		HarvestDate:    production.HarvestDate,
+		BatchNo:        production.BatchNo,
+		Quantity:       production.Quantity,
+		Unit:           production.Unit,
 	}
 
-	result := c.ProductionService.CreateProduction(dto)
-	ctx.JSON(http.StatusOK, result)
+	result := c.ProductionService.CreateProduction(currentScope(ctx), dto)
+	ctx.JSON(result.Code, result)
 }
 
 // Update 修改生产信息
@@ -66,10 +69,12 @@ func (c *ProductionController) Update(ctx *gin.Context) {
 		Description:    production.Description,
 		PlantingDate:   production.PlantingDate,
 		HarvestDate:    production.HarvestDate,
+		Quantity:       production.Quantity,
+		Unit:           production.Unit,
 	}
 
-	result := c.ProductionService.UpdateProduction(dto)
-	ctx.JSON(http.StatusOK, result)
+	result := c.ProductionService.UpdateProduction(currentScope(ctx), dto)
+	ctx.JSON(result.Code, result)
 }
 
 // Delete 删除生产信息
@@ -84,8 +89,8 @@ func (c *ProductionController) Delete(ctx *gin.Context) {
 		return
 	}
 
-	result := c.ProductionService.DeleteProduction(id)
-	ctx.JSON(http.StatusOK, result)
+	result := c.ProductionService.DeleteProduction(currentScope(ctx), id)
+	ctx.JSON(result.Code, result)
 }
 
 // GetById 根据ID获取生产信息
@@ -100,7 +105,7 @@ func (c *ProductionController) GetById(ctx *gin.Context) {
 		return
 	}
 
-	result := c.ProductionService.GetProductionByID(id)
+	result := c.ProductionService.GetProductionByID(currentScope(ctx), id)
 	ctx.JSON(http.StatusOK, result)
 }
 
@@ -115,12 +120,12 @@ func (c *ProductionController) PageQuery(ctx *gin.Context) {
 		return
 	}
 
-	result := c.ProductionService.PageQueryProductions(&queryDTO)
+	result := c.ProductionService.PageQueryProductions(currentScope(ctx), &queryDTO)
 	ctx.JSON(http.StatusOK, result)
 }
 
 // List 查询所有生产信息
 func (c *ProductionController) List(ctx *gin.Context) {
-	result := c.ProductionService.GetAllProductions()
+	result := c.ProductionService.GetAllProductions(currentScope(ctx))
 	ctx.JSON(http.StatusOK, result)
 }
@@ -39,7 +39,7 @@ func (c *ProductionPlaceController) Save(ctx *gin.Context) {
 		Phone:         place.Phone,
 	}
 
-	result := c.ProductionPlaceService.CreateProductionPlace(dto)
+	result := c.ProductionPlaceService.CreateProductionPlace(currentScope(ctx), dto)
 	ctx.JSON(http.StatusOK, result)
 }
 
@@ -62,7 +62,7 @@ func (c *ProductionPlaceController) Update(ctx *gin.Context) {
 		Phone:         place.Phone,
 	}
 
-	result := c.ProductionPlaceService.UpdateProductionPlace(dto)
+	result := c.ProductionPlaceService.UpdateProductionPlace(currentScope(ctx), dto)
 	ctx.JSON(http.StatusOK, result)
 }
 
@@ -78,7 +78,23 @@ func (c *ProductionPlaceController) Delete(ctx *gin.Context) {
 		return
 	}
 
-	result := c.ProductionPlaceService.DeleteProductionPlace(id)
+	result := c.ProductionPlaceService.DeleteProductionPlace(currentScope(ctx), id)
+	ctx.JSON(http.StatusOK, result)
+}
+
+// Restore 恢复已删除的生产地信息
+func (c *ProductionPlaceController) Restore(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "ID参数错误",
+		})
+		return
+	}
+
+	result := c.ProductionPlaceService.RestoreProductionPlace(currentScope(ctx), id)
 	ctx.JSON(http.StatusOK, result)
 }
 
@@ -109,12 +125,13 @@ func (c *ProductionPlaceController) PageQuery(ctx *gin.Context) {
 		return
 	}
 
+	queryDTO.IncludeDeleted = includeDeleted(ctx, queryDTO.IncludeDeleted)
 	result := c.ProductionPlaceService.PageQueryProductionPlaces(&queryDTO)
 	ctx.JSON(http.StatusOK, result)
 }
 
 // List 查询所有生产地信息
 func (c *ProductionPlaceController) List(ctx *gin.Context) {
-	result := c.ProductionPlaceService.GetAllProductionPlaces()
+	result := c.ProductionPlaceService.GetAllProductionPlaces(includeDeletedQuery(ctx))
 	ctx.JSON(http.StatusOK, result)
 }
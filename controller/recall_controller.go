@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/service"
+)
+
+// RecallController 产品召回控制器
+type RecallController struct {
+	RecallService *service.RecallService
+}
+
+// NewRecallController 创建产品召回控制器
+func NewRecallController(service *service.RecallService) *RecallController {
+	return &RecallController{RecallService: service}
+}
+
+// Open 发起召回
+// @Summary 对生产批次或产品发起召回，返回受影响的物流、销售记录及销售地联系方式
+// @Router /recall [post]
+func (c *RecallController) Open(ctx *gin.Context) {
+	var recallDTO dto.RecallDTO
+	if err := ctx.ShouldBindJSON(&recallDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	result := c.RecallService.Open(currentIdentity(ctx), &recallDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// GetImpact 查询召回的影响范围
+// @Summary 查询召回批次、下游物流、销售记录及各销售地的处理状态
+// @Router /recall/{id} [get]
+func (c *RecallController) GetImpact(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误", "data": nil})
+		return
+	}
+
+	result := c.RecallService.GetImpact(currentScope(ctx), id)
+	ctx.JSON(result.Code, result)
+}
+
+// PageQuery 分页查询召回
+// @Summary 按批次、产品、状态分页查询召回
+// @Router /recall/page [post]
+func (c *RecallController) PageQuery(ctx *gin.Context) {
+	var queryDTO model.RecallPageQueryDTO
+	if err := ctx.ShouldBindJSON(&queryDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	result := c.RecallService.PageQuery(currentScope(ctx), &queryDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// UpdateSalePlace 更新销售地的召回处理状态
+// @Summary 登记已通知或已下架/退回
+// @Router /recall/{id}/saleplace/{salePlaceId} [put]
+func (c *RecallController) UpdateSalePlace(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误", "data": nil})
+		return
+	}
+	salePlaceID, err := strconv.Atoi(ctx.Param("salePlaceId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "销售地ID参数错误", "data": nil})
+		return
+	}
+
+	var statusDTO dto.RecallSalePlaceDTO
+	if err := ctx.ShouldBindJSON(&statusDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	result := c.RecallService.UpdateSalePlace(currentScope(ctx), id, salePlaceID, &statusDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// Close 结束召回
+// @Summary 所有销售地完成处理后结束召回
+// @Router /recall/{id}/close [put]
+func (c *RecallController) Close(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误", "data": nil})
+		return
+	}
+
+	var closeDTO dto.RecallCloseDTO
+	if err := ctx.ShouldBindJSON(&closeDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	result := c.RecallService.Close(currentScope(ctx), id, &closeDTO)
+	ctx.JSON(result.Code, result)
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"agricultural_product_gin/dto"
 	"agricultural_product_gin/service"
@@ -32,7 +33,7 @@ func (c *SaleInfoController) Save(ctx *gin.Context) {
 	}
 
 	log.Printf("新增销售信息：%+v", saleInfoDTO)
-	result := c.service.Save(&saleInfoDTO)
+	result := c.service.Save(currentScope(ctx), &saleInfoDTO)
 	ctx.JSON(result.Code, result)
 }
 
@@ -48,7 +49,7 @@ func (c *SaleInfoController) Update(ctx *gin.Context) {
 	}
 
 	log.Printf("修改销售信息：%+v", saleInfoDTO)
-	result := c.service.Update(&saleInfoDTO)
+	result := c.service.Update(currentScope(ctx), &saleInfoDTO)
 	ctx.JSON(result.Code, result)
 }
 
@@ -65,7 +66,7 @@ func (c *SaleInfoController) Delete(ctx *gin.Context) {
 	}
 
 	log.Printf("删除销售信息，ID：%d", id)
-	result := c.service.Delete(id)
+	result := c.service.Delete(currentScope(ctx), id)
 	ctx.JSON(result.Code, result)
 }
 
@@ -81,14 +82,14 @@ func (c *SaleInfoController) GetByID(ctx *gin.Context) {
 		return
 	}
 
-	result := c.service.GetByID(id)
+	result := c.service.GetByID(currentScope(ctx), id)
 	ctx.JSON(result.Code, result)
 }
 
 // ListAll 查询所有销售信息
 func (c *SaleInfoController) ListAll(ctx *gin.Context) {
 	log.Println("查询所有销售信息")
-	result := c.service.GetAll()
+	result := c.service.GetAll(currentScope(ctx))
 	ctx.JSON(result.Code, result)
 }
 
@@ -104,6 +105,65 @@ func (c *SaleInfoController) PageQuery(ctx *gin.Context) {
 	}
 
 	log.Printf("分页查询销售信息，条件：%+v", queryDTO)
-	result := c.service.PageQuery(&queryDTO)
+	result := c.service.PageQuery(currentScope(ctx), &queryDTO)
 	ctx.JSON(result.Code, result)
 }
+
+// reverseTraceDays 反向溯源未指定开始时间时默认查询的天数
+const reverseTraceDays = 30
+
+// ReverseTrace 反向溯源
+// @Summary 查询销售地在时间段内的供货生产地、产品及物流公司(含计数)
+// @Param salePlaceId query int false "销售地ID，零售商默认为绑定的销售地"
+// @Param start query string false "开始时间(2006-01-02或RFC3339)，默认为结束时间前30天"
+// @Param end query string false "结束时间(2006-01-02表示含当天，或RFC3339)，默认为当前时间"
+// @Router /saleinfo/reverse [get]
+func (c *SaleInfoController) ReverseTrace(ctx *gin.Context) {
+	salePlaceID := 0
+	if value := ctx.Query("salePlaceId"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "销售地ID参数错误", "data": nil})
+			return
+		}
+		salePlaceID = id
+	}
+
+	end := time.Now()
+	if value := ctx.Query("end"); value != "" {
+		t, err := parseQueryTime(value, true)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "结束时间格式错误", "data": nil})
+			return
+		}
+		end = t
+	}
+	start := end.AddDate(0, 0, -reverseTraceDays)
+	if value := ctx.Query("start"); value != "" {
+		t, err := parseQueryTime(value, false)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "开始时间格式错误", "data": nil})
+			return
+		}
+		start = t
+	}
+
+	result := c.service.ReverseTrace(currentScope(ctx), salePlaceID, start, end)
+	ctx.JSON(result.Code, result)
+}
+
+// parseQueryTime 解析查询参数中的时间，支持日期(2006-01-02)和RFC3339；
+// 日期作为结束时间时取次日零点，即包含当天
+func parseQueryTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
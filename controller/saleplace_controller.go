@@ -34,7 +34,7 @@ func (c *SalePlaceController) Save(ctx *gin.Context) {
 	}
 
 	log.Printf("新增销售地：%+v", salePlace)
-	result := c.SalePlaceService.CreateSalePlace(&dto.SalePlaceDTO{
+	result := c.SalePlaceService.CreateSalePlace(currentScope(ctx), &dto.SalePlaceDTO{
 		ID:            salePlace.ID,
 		Address:       salePlace.Address,
 		Administrator: salePlace.Administrator,
@@ -55,7 +55,7 @@ func (c *SalePlaceController) Update(ctx *gin.Context) {
 	}
 
 	log.Printf("修改销售地：%+v", salePlace)
-	result := c.SalePlaceService.UpdateSalePlace(&dto.SalePlaceDTO{
+	result := c.SalePlaceService.UpdateSalePlace(currentScope(ctx), &dto.SalePlaceDTO{
 		ID:            salePlace.ID,
 		Address:       salePlace.Address,
 		Administrator: salePlace.Administrator,
@@ -77,7 +77,24 @@ func (c *SalePlaceController) Delete(ctx *gin.Context) {
 	}
 
 	log.Printf("删除销售地，ID：%d", id)
-	result := c.SalePlaceService.DeleteSalePlace(id)
+	result := c.SalePlaceService.DeleteSalePlace(currentScope(ctx), id)
+	ctx.JSON(http.StatusOK, result)
+}
+
+// Restore 恢复已删除的销售地
+func (c *SalePlaceController) Restore(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "ID参数错误",
+		})
+		return
+	}
+
+	log.Printf("恢复销售地，ID：%d", id)
+	result := c.SalePlaceService.RestoreSalePlace(currentScope(ctx), id)
 	ctx.JSON(http.StatusOK, result)
 }
 
@@ -109,6 +126,7 @@ func (c *SalePlaceController) PageQuery(ctx *gin.Context) {
 	}
 
 	log.Printf("分页查询销售地，条件：%+v", queryDTO)
+	queryDTO.IncludeDeleted = includeDeleted(ctx, queryDTO.IncludeDeleted)
 	result := c.SalePlaceService.PageQuerySalePlaces(&queryDTO)
 	ctx.JSON(http.StatusOK, result)
 }
@@ -116,6 +134,6 @@ func (c *SalePlaceController) PageQuery(ctx *gin.Context) {
 // ListAll 查询所有销售地
 func (c *SalePlaceController) ListAll(ctx *gin.Context) {
 	log.Println("查询所有销售地")
-	result := c.SalePlaceService.GetAllSalePlaces()
+	result := c.SalePlaceService.GetAllSalePlaces(includeDeletedQuery(ctx))
 	ctx.JSON(http.StatusOK, result)
 }
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/service"
+)
+
+// TraceRecordController 完整溯源记录控制器
+type TraceRecordController struct {
+	TraceRecordService *service.TraceRecordService
+}
+
+// NewTraceRecordController 创建完整溯源记录控制器
+func NewTraceRecordController(service *service.TraceRecordService) *TraceRecordController {
+	return &TraceRecordController{TraceRecordService: service}
+}
+
+// Create 一次性创建生产信息、物流运输段及销售信息
+// @Summary 创建完整溯源记录(生产 -> 物流 -> 销售)，失败时全部回滚
+// @Router /record [post]
+func (c *TraceRecordController) Create(ctx *gin.Context) {
+	var recordDTO dto.TraceRecordDTO
+	if err := ctx.ShouldBindJSON(&recordDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	result := c.TraceRecordService.Create(currentScope(ctx), &recordDTO)
+	ctx.JSON(result.Code, result)
+}
@@ -1,9 +1,12 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
+	"agricultural_product_gin/model"
 	"agricultural_product_gin/service"
 	"github.com/gin-gonic/gin"
 )
@@ -14,6 +17,12 @@ type TraceabilityController struct {
 	productService    *service.ProductService
 	logisticsService  *service.LogisticsService
 	saleInfoService   service.SaleInfoService // 注意这里是接口类型，不是指针
+	traceService      *service.TraceabilityService
+	traceCodeService  *service.TraceCodeService
+	batchService      *service.BatchService
+	inspectionService *service.InspectionService
+	hashChainService  *service.HashChainService
+	certService       *service.CertificateService
 }
 
 // NewTraceabilityController 创建一个新的溯源控制器实例
@@ -22,12 +31,24 @@ func NewTraceabilityController(
 	productService *service.ProductService,
 	logisticsService *service.LogisticsService,
 	saleInfoService service.SaleInfoService,
+	traceService *service.TraceabilityService,
+	traceCodeService *service.TraceCodeService,
+	batchService *service.BatchService,
+	inspectionService *service.InspectionService,
+	hashChainService *service.HashChainService,
+	certService *service.CertificateService,
 ) *TraceabilityController {
 	return &TraceabilityController{
 		productionService: productionService,
 		productService:    productService,
 		logisticsService:  logisticsService,
 		saleInfoService:   saleInfoService,
+		traceService:      traceService,
+		traceCodeService:  traceCodeService,
+		batchService:      batchService,
+		inspectionService: inspectionService,
+		hashChainService:  hashChainService,
+		certService:       certService,
 	}
 }
 
@@ -39,6 +60,14 @@ func (tc *TraceabilityController) RegisterRoutes(router *gin.RouterGroup) {
 		traceabilityGroup.GET("/saleinfo/:id", tc.GetSaleInfo)
 		traceabilityGroup.GET("/logistics/:id", tc.GetLogistics)
 		traceabilityGroup.GET("/product/:id", tc.GetProduct)
+		traceabilityGroup.GET("/chain/:saleInfoId", tc.GetChain)
+		traceabilityGroup.GET("/chain/:saleInfoId/certificate", tc.GetCertificate)
+		traceabilityGroup.GET("/certificate/publickey", tc.GetCertificatePublicKey)
+		traceabilityGroup.GET("/batch/:id/lineage", tc.GetBatchLineage)
+		traceabilityGroup.GET("/inspection/:id", tc.GetInspection)
+		traceabilityGroup.GET("/code/:code", tc.ResolveCode)
+		traceabilityGroup.GET("/code/:code/qrcode", tc.GetQRCode)
+		traceabilityGroup.GET("/hashchain", tc.GetHashChain)
 	}
 }
 
@@ -52,7 +81,10 @@ func (tc *TraceabilityController) GetProductInfo(c *gin.Context) {
 		return
 	}
 
-	result := tc.productionService.GetProductionByID(id)
+	result := tc.productionService.GetProductionByID(model.DataScope{}, id)
+	if production, ok := result.Data.(*model.ProductionInfoWithDetails); ok {
+		production.Proof, _ = tc.hashChainService.Proof(model.HashChainProductInfo, production.ID)
+	}
 	c.JSON(http.StatusOK, result)
 }
 
@@ -66,7 +98,10 @@ func (tc *TraceabilityController) GetSaleInfo(c *gin.Context) {
 		return
 	}
 
-	result := tc.saleInfoService.GetByID(id)
+	result := tc.saleInfoService.GetByID(model.DataScope{}, id)
+	if saleInfo, ok := result.Data.(*model.SaleInfoVO); ok {
+		saleInfo.Proof, _ = tc.hashChainService.Proof(model.HashChainSaleInfo, saleInfo.ID)
+	}
 	c.JSON(http.StatusOK, result)
 }
 
@@ -80,7 +115,7 @@ func (tc *TraceabilityController) GetLogistics(c *gin.Context) {
 		return
 	}
 
-	logistics, err := tc.logisticsService.GetByID(id)
+	logistics, err := tc.logisticsService.GetByID(model.DataScope{}, id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": err.Error(), "data": nil})
 		return
@@ -90,6 +125,7 @@ func (tc *TraceabilityController) GetLogistics(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "物流信息不存在", "data": nil})
 		return
 	}
+	logistics.Proof, _ = tc.hashChainService.Proof(model.HashChainLogistics, logistics.ID)
 
 	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "成功", "data": logistics})
 }
@@ -107,3 +143,141 @@ func (tc *TraceabilityController) GetProduct(c *gin.Context) {
 	result := tc.productService.GetProductByID(id)
 	c.JSON(http.StatusOK, result)
 }
+
+// GetChain 通过销售信息ID获取完整溯源链
+// @Summary 查询完整溯源链(生产 -> 物流 -> 销售)
+// @Router /traceability/chain/{saleInfoId} [get]
+func (tc *TraceabilityController) GetChain(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("saleInfoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID格式无效", "data": nil})
+		return
+	}
+
+	result := tc.traceService.GetChain(id)
+	c.JSON(result.Code, result)
+}
+
+// GetCertificate 签发销售记录的溯源证书
+// @Summary 溯源证书(format=json|pdf)：JSON带Ed25519分离式签名，PDF为打印版本
+// @Router /traceability/chain/{saleInfoId}/certificate [get]
+func (tc *TraceabilityController) GetCertificate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("saleInfoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID格式无效", "data": nil})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	var data []byte
+	var contentType string
+	switch format {
+	case "json":
+		data, err = tc.certService.Issue(id)
+		contentType = "application/json; charset=utf-8"
+	case "pdf":
+		data, err = tc.certService.IssuePDF(id)
+		contentType = "application/pdf"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "format只支持json或pdf", "data": nil})
+		return
+	}
+
+	if errors.Is(err, service.ErrCertificateNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": err.Error(), "data": nil})
+		return
+	}
+	if errors.Is(err, service.ErrCertificateIncomplete) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"code": 422, "msg": err.Error(), "data": nil})
+		return
+	}
+	if errors.Is(err, service.ErrCertificateTampered) {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": err.Error(), "data": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "签发证书失败", "data": nil})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="certificate-%d.%s"`, id, format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// GetCertificatePublicKey 获取校验溯源证书的公钥
+// @Summary 溯源证书签名公钥(Ed25519)
+// @Router /traceability/certificate/publickey [get]
+func (tc *TraceabilityController) GetCertificatePublicKey(c *gin.Context) {
+	result := tc.certService.PublicKey()
+	c.JSON(result.Code, result)
+}
+
+// GetBatchLineage 通过生产信息ID获取批次谱系
+// @Summary 查询批次的来源批次、产出批次及数量去向
+// @Router /traceability/batch/{id}/lineage [get]
+func (tc *TraceabilityController) GetBatchLineage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID格式无效", "data": nil})
+		return
+	}
+
+	result := tc.batchService.Lineage(model.DataScope{}, id)
+	c.JSON(result.Code, result)
+}
+
+// GetInspection 通过ID获取检测记录
+// @Summary 查询检测记录及检测项目
+// @Router /traceability/inspection/{id} [get]
+func (tc *TraceabilityController) GetInspection(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID格式无效", "data": nil})
+		return
+	}
+
+	result := tc.inspectionService.GetByID(model.DataScope{}, id)
+	c.JSON(result.Code, result)
+}
+
+// ResolveCode 通过公开溯源码查询溯源信息
+// @Summary 扫码溯源(不返回内部ID)
+// @Router /traceability/code/{code} [get]
+func (tc *TraceabilityController) ResolveCode(c *gin.Context) {
+	result := tc.traceCodeService.Resolve(c.Param("code"))
+	c.JSON(result.Code, result)
+}
+
+// GetQRCode 获取溯源码对应的二维码图片
+// @Summary 溯源二维码(format=png|svg, size=像素)
+// @Router /traceability/code/{code}/qrcode [get]
+func (tc *TraceabilityController) GetQRCode(c *gin.Context) {
+	size, _ := strconv.Atoi(c.Query("size"))
+
+	data, contentType, err := tc.traceCodeService.QRCode(c.Param("code"), c.DefaultQuery("format", "png"), size)
+	if errors.Is(err, service.ErrTraceCodeNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": err.Error(), "data": nil})
+		return
+	}
+	if errors.Is(err, service.ErrTraceCodeRevoked) {
+		c.JSON(http.StatusGone, gin.H{"code": 410, "msg": err.Error(), "data": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "生成二维码失败", "data": nil})
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// GetHashChain 按顺序查询哈希链条目
+// @Summary 哈希链条目(from=起始位置, limit=条数)，不含记录内容，用于离线核对溯源信息中的proof
+// @Router /traceability/hashchain [get]
+func (tc *TraceabilityController) GetHashChain(c *gin.Context) {
+	from, _ := strconv.Atoi(c.Query("from"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	result := tc.hashChainService.Proofs(from, limit)
+	c.JSON(result.Code, result)
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/service"
+)
+
+// TraceCodeController 溯源码控制器
+type TraceCodeController struct {
+	TraceCodeService *service.TraceCodeService
+}
+
+// NewTraceCodeController 创建溯源码控制器
+func NewTraceCodeController(traceCodeService *service.TraceCodeService) *TraceCodeController {
+	return &TraceCodeController{TraceCodeService: traceCodeService}
+}
+
+// Save 生成溯源码
+func (c *TraceCodeController) Save(ctx *gin.Context) {
+	var codeDTO dto.TraceCodeDTO
+	if err := ctx.ShouldBindJSON(&codeDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请求参数错误",
+		})
+		return
+	}
+
+	log.Printf("生成溯源码：%+v", codeDTO)
+	result := c.TraceCodeService.Generate(currentScope(ctx), &codeDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// Revoke 作废溯源码
+func (c *TraceCodeController) Revoke(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "ID参数错误",
+		})
+		return
+	}
+
+	var revokeDTO dto.TraceCodeRevokeDTO
+	if err := ctx.ShouldBindJSON(&revokeDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请求参数错误",
+		})
+		return
+	}
+
+	log.Printf("作废溯源码，ID：%d，原因：%s", id, revokeDTO.Reason)
+	result := c.TraceCodeService.Revoke(currentScope(ctx), id, &revokeDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// GetByID 根据ID获取溯源码
+func (c *TraceCodeController) GetByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "ID参数错误",
+		})
+		return
+	}
+
+	result := c.TraceCodeService.GetByID(id)
+	ctx.JSON(result.Code, result)
+}
+
+// PageQuery 分页查询溯源码
+func (c *TraceCodeController) PageQuery(ctx *gin.Context) {
+	var queryDTO dto.TraceCodePageQueryDTO
+	if err := ctx.ShouldBindJSON(&queryDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请求参数错误",
+		})
+		return
+	}
+
+	log.Printf("分页查询溯源码，条件：%+v", queryDTO)
+	result := c.TraceCodeService.PageQuery(&queryDTO)
+	ctx.JSON(result.Code, result)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -12,16 +13,18 @@ import (
 
 // UploadController 处理文件上传相关逻辑
 type UploadController struct {
-	uploadPath string
+	uploadPath   string
+	imageBaseURL string
 }
 
 // NewUploadController 创建一个新的上传控制器
-func NewUploadController() *UploadController {
-	// 获取项目根目录
-	projectRoot, _ := os.Getwd()
-
-	// 设置上传路径 - 与Java版本保持一致
-	uploadPath := filepath.Join(projectRoot, "resources", "images")
+// uploadDir为存储目录(相对路径基于工作目录)，imageBaseURL为图片对外访问地址前缀
+func NewUploadController(uploadDir, imageBaseURL string) *UploadController {
+	uploadPath, err := filepath.Abs(uploadDir)
+	if err != nil {
+		log.Printf("解析上传目录失败: %v", err)
+		uploadPath = uploadDir
+	}
 
 	// 确保目录存在
 	if err := os.MkdirAll(uploadPath, 0755); err != nil {
@@ -31,7 +34,8 @@ func NewUploadController() *UploadController {
 	log.Printf("文件存储路径：%s", uploadPath)
 
 	return &UploadController{
-		uploadPath: uploadPath,
+		uploadPath:   uploadPath,
+		imageBaseURL: strings.TrimRight(imageBaseURL, "/") + "/",
 	}
 }
 
@@ -75,7 +79,7 @@ func (uc *UploadController) Upload(c *gin.Context) {
 	}
 
 	// 返回相对访问路径（与Java版本对应）
-	fileURL := "http://localhost:8080/images/" + fileName
+	fileURL := uc.imageBaseURL + fileName
 
 	c.JSON(http.StatusOK, Result{
 		Code: 200,
@@ -31,7 +31,7 @@ func (c *UserController) Register(ctx *gin.Context) {
 		return
 	}
 
-	log.Printf("用户名：%s，密码：%s", userDTO.Username, userDTO.Password)
+	log.Printf("用户注册：%s", userDTO.Username)
 	result := c.UserService.Register(&userDTO)
 	ctx.JSON(http.StatusOK, result)
 }
@@ -47,20 +47,41 @@ func (c *UserController) Login(ctx *gin.Context) {
 		return
 	}
 
-	log.Printf("用户名：%s，密码：%s", userDTO.Username, userDTO.Password)
+	log.Printf("用户登录：%s", userDTO.Username)
 	result := c.UserService.Login(&userDTO)
 	ctx.JSON(http.StatusOK, result)
 }
 
+// Refresh 刷新令牌
+func (c *UserController) Refresh(ctx *gin.Context) {
+	var refreshDTO dto.RefreshTokenDTO
+	if err := ctx.ShouldBindJSON(&refreshDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请求参数错误",
+		})
+		return
+	}
+
+	result := c.UserService.Refresh(&refreshDTO)
+	ctx.JSON(http.StatusOK, result)
+}
+
+// LogoutAll 退出所有会话
+func (c *UserController) LogoutAll(ctx *gin.Context) {
+	result := c.UserService.LogoutAll(currentIdentity(ctx))
+	ctx.JSON(http.StatusOK, result)
+}
+
 // Logout 退出登录
 func (c *UserController) Logout(ctx *gin.Context) {
-	result := c.UserService.Logout()
+	result := c.UserService.Logout(currentIdentity(ctx))
 	ctx.JSON(http.StatusOK, result)
 }
 
 // GetUserInfo 获取用户信息
 func (c *UserController) GetUserInfo(ctx *gin.Context) {
-	user, err := c.UserService.GetUserInfo()
+	user, err := c.UserService.GetUserInfo(currentIdentity(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"code": 401,
@@ -88,7 +109,7 @@ func (c *UserController) Update(ctx *gin.Context) {
 	}
 
 	log.Printf("编辑用户信息：%+v", userDTO)
-	result := c.UserService.Update(&userDTO)
+	result := c.UserService.Update(currentIdentity(ctx), &userDTO)
 	ctx.JSON(http.StatusOK, result)
 }
 
@@ -103,7 +124,44 @@ func (c *UserController) EditPassword(ctx *gin.Context) {
 		return
 	}
 
-	log.Printf("修改密码：%+v", passwordDTO)
-	result := c.UserService.EditPassword(&passwordDTO)
+	result := c.UserService.EditPassword(currentIdentity(ctx), &passwordDTO)
+	ctx.JSON(http.StatusOK, result)
+}
+
+// AssignRole 分配用户角色
+func (c *UserController) AssignRole(ctx *gin.Context) {
+	var roleDTO dto.UserRoleDTO
+	if err := ctx.ShouldBindJSON(&roleDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请求参数错误",
+		})
+		return
+	}
+
+	log.Printf("分配用户角色：%+v", roleDTO)
+	result := c.UserService.AssignRole(currentIdentity(ctx), &roleDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// Bind 绑定用户所属的公司、生产地、销售地
+func (c *UserController) Bind(ctx *gin.Context) {
+	var bindingDTO dto.UserBindingDTO
+	if err := ctx.ShouldBindJSON(&bindingDTO); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请求参数错误",
+		})
+		return
+	}
+
+	log.Printf("绑定用户：%+v", bindingDTO)
+	result := c.UserService.BindUser(currentIdentity(ctx), &bindingDTO)
+	ctx.JSON(result.Code, result)
+}
+
+// List 查询所有用户
+func (c *UserController) List(ctx *gin.Context) {
+	result := c.UserService.ListUsers()
 	ctx.JSON(http.StatusOK, result)
 }
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+func TestUserController_LoginAndUserInfo(t *testing.T) {
+	s := newTestServer(t)
+
+	status, resp := s.do(t, http.MethodPost, "/user/login", "", `{"username":"farmer"`)
+	if status != 400 || resp.Code != 400 {
+		t.Fatalf("请求体无效 = %d %+v", status, resp)
+	}
+	status, resp = s.do(t, http.MethodPost, "/user/login", "", `{"username":"farmer","password":"farmer000"}`)
+	if status != 200 || resp.Code != 400 {
+		t.Fatalf("密码错误 = %d %+v", status, resp)
+	}
+
+	status, resp = s.do(t, http.MethodPost, "/user/login", "", `{"username":"farmer","password":"farmer123"}`)
+	var tokens struct {
+		Token string `json:"token"`
+	}
+	if status != 200 || resp.Code != 200 || json.Unmarshal(resp.Data, &tokens) != nil || tokens.Token == "" {
+		t.Fatalf("登录 = %d %+v", status, resp)
+	}
+	authorization := "Bearer " + tokens.Token
+
+	status, resp = s.do(t, http.MethodGet, "/user/userInfo", authorization, "")
+	var user model.User
+	if status != 200 || resp.Code != 200 || json.Unmarshal(resp.Data, &user) != nil {
+		t.Fatalf("获取用户信息 = %d %+v", status, resp)
+	}
+	if user.ID != 2 || user.Username != "farmer" || user.Role != model.RoleFarmer || user.ProductPlaceID != 2 || user.Password != "******" {
+		t.Fatalf("用户信息 = %+v", user)
+	}
+
+	// 退出登录后令牌失效
+	if status, resp = s.do(t, http.MethodPost, "/user/logout", authorization, ""); status != 200 || resp.Code != 200 {
+		t.Fatalf("退出登录 = %d %+v", status, resp)
+	}
+	status, resp = s.do(t, http.MethodGet, "/user/userInfo", authorization, "")
+	if status != 401 || resp.Msg != "令牌已失效，请重新登录" {
+		t.Fatalf("退出后获取用户信息 = %d %+v", status, resp)
+	}
+}
+
+// TestUserController_UserInfoConcurrent 并发请求时每个响应只能是自己令牌对应的身份(配合 go test -race 运行)
+func TestUserController_UserInfoConcurrent(t *testing.T) {
+	s := newTestServer(t)
+	const requestsPerUser = 50
+
+	var wg sync.WaitGroup
+	for userID, want := range s.users.Users {
+		authorization := s.token(t, userID)
+		for i := 0; i < requestsPerUser; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodGet, "/user/userInfo", nil)
+				req.Header.Set("Authorization", authorization)
+				w := httptest.NewRecorder()
+				s.engine.ServeHTTP(w, req)
+
+				var resp struct {
+					Code int        `json:"code"`
+					Data model.User `json:"data"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != 200 {
+					t.Errorf("用户%d获取用户信息 = %d %s", userID, w.Code, w.Body.String())
+					return
+				}
+				if resp.Data.ID != want.ID || resp.Data.Username != want.Username || resp.Data.Role != want.Role {
+					t.Errorf("用户%d的令牌返回了用户%d(%s)", want.ID, resp.Data.ID, resp.Data.Username)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
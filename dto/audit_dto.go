@@ -0,0 +1,14 @@
+package dto
+
+// AuditPageQueryDTO 审计日志分页查询DTO
+// 时间支持日期(2006-01-02)和RFC3339，结束时间为日期时包含当天
+type AuditPageQueryDTO struct {
+	Page     int    `json:"page"`
+	Size     int    `json:"size"`
+	Entity   string `json:"entity"`
+	EntityID int    `json:"entityId"`
+	Actor    string `json:"actor"`
+	Action   string `json:"action"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+}
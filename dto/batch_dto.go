@@ -0,0 +1,34 @@
+package dto
+
+// BatchSplitDTO 批次拆分DTO
+type BatchSplitDTO struct {
+	Children []BatchSplitChildDTO `json:"children" binding:"required,min=1,dive"`
+}
+
+// BatchSplitChildDTO 拆分出的批次
+type BatchSplitChildDTO struct {
+	Quantity    float64 `json:"quantity" binding:"required,gt=0"`
+	BatchNo     string  `json:"batchNo"`       // 为空时自动生成
+	Description string  `json:"piDescription"` // 为空时沿用来源批次的描述
+}
+
+// BatchMergeDTO 批次合并DTO，来源批次须为同一产品、同一生产地、同一单位
+type BatchMergeDTO struct {
+	Sources     []BatchMergeSourceDTO `json:"sources" binding:"required,min=2,dive"`
+	BatchNo     string                `json:"batchNo"` // 为空时自动生成
+	Description string                `json:"piDescription"`
+}
+
+// BatchMergeSourceDTO 合并的来源批次
+type BatchMergeSourceDTO struct {
+	ProductInfoID int      `json:"productInfoId" binding:"required"`
+	Quantity      *float64 `json:"quantity"` // 为空时转入来源批次的全部剩余数量
+}
+
+// BatchVO 拆分/合并产生的批次
+type BatchVO struct {
+	ProductInfoID int     `json:"productInfoId"`
+	BatchNo       string  `json:"batchNo"`
+	Quantity      float64 `json:"quantity"`
+	Unit          string  `json:"unit"`
+}
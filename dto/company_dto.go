@@ -11,10 +11,11 @@ type CompanyDTO struct {
 
 // CompanyPageQueryDTO 公司分页查询DTO
 type CompanyPageQueryDTO struct {
-	Page          int    `json:"page" binding:"required"`
-	PageSize      int    `json:"size" binding:"required"`
-	Name          string `json:"comName"`
-	Address       string `json:"comAddress"`
-	Administrator string `json:"comAdministrator"`
-	Phone         string `json:"comPhone"`
+	Page           int    `json:"page" binding:"required"`
+	PageSize       int    `json:"size" binding:"required"`
+	Name           string `json:"comName"`
+	Address        string `json:"comAddress"`
+	Administrator  string `json:"comAdministrator"`
+	Phone          string `json:"comPhone"`
+	IncludeDeleted bool   `json:"includeDeleted"` // 包含已删除的记录(仅管理员有效)
 }
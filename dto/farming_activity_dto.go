@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+// FarmingActivityDTO 农事活动DTO
+type FarmingActivityDTO struct {
+	ID              int       `json:"faId"`
+	ProductInfoID   int       `json:"productInfoId"` // 新增时必填，修改时忽略
+	ActivityType    string    `json:"activityType" binding:"required"`
+	Material        string    `json:"material"`
+	Dosage          *float64  `json:"dosage"`
+	DosageUnit      string    `json:"dosageUnit"`
+	Operator        string    `json:"operator"`
+	ActivityDate    time.Time `json:"activityDate" binding:"required"`
+	WithholdingDays *int      `json:"withholdingDays"` // 安全间隔期(天)
+	Remark          string    `json:"remark"`
+}
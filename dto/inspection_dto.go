@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// InspectionDTO 质量检测DTO，生产信息与物流信息至少填写一个
+// 只填写物流信息时检测对象为该物流运输的批次
+type InspectionDTO struct {
+	ID             int                 `json:"insId"`
+	ProductInfoID  int                 `json:"productInfoId"`
+	LogisticsID    int                 `json:"logisticsId"`
+	InspectionType string              `json:"inspectionType" binding:"required"`
+	LabName        string              `json:"labName" binding:"required"`
+	ReportNo       string              `json:"reportNo"`
+	InspectionDate time.Time           `json:"inspectionDate" binding:"required"`
+	Verdict        string              `json:"verdict"`   // 没有检测项目时必填，有检测项目时按项目结果判定
+	ReportURL      string              `json:"reportUrl"` // 通过/upload上传的PDF地址
+	Remark         string              `json:"remark"`
+	Items          []InspectionItemDTO `json:"items" binding:"dive"`
+}
+
+// InspectionItemDTO 检测项目，填写检测值时按限值判定是否合格，否则需填写判定结果
+type InspectionItemDTO struct {
+	ItemName   string   `json:"itemName" binding:"required"`
+	Value      *float64 `json:"value"`
+	ResultText string   `json:"resultText"`
+	Unit       string   `json:"unit"`
+	LimitMin   *float64 `json:"limitMin"`
+	LimitMax   *float64 `json:"limitMax"`
+	Passed     *bool    `json:"passed"`
+}
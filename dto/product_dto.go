@@ -10,6 +10,11 @@ type ProductDTO struct {
 	Image       string          `json:"image"`
 	Description string          `json:"pdDescription"`
 	UnitPrice   sql.NullFloat64 `json:"unitPrice"`
+
+	MinTemperature *float64 `json:"minTemperature"`
+	MaxTemperature *float64 `json:"maxTemperature"`
+	MinHumidity    *float64 `json:"minHumidity"`
+	MaxHumidity    *float64 `json:"maxHumidity"`
 }
 
 // ProductQueryDTO 产品查询DTO
@@ -2,10 +2,11 @@ package dto
 
 // ProductPageQueryDTO 产品分页查询DTO
 type ProductPageQueryDTO struct {
-	Page     int    `json:"page"`
-	PageSize int    `json:"size"`
-	Name     string `json:"productName"`
-	Type     string `json:"type"`
+	Page           int    `json:"page"`
+	PageSize       int    `json:"size"`
+	Name           string `json:"productName"`
+	Type           string `json:"type"`
+	IncludeDeleted bool   `json:"includeDeleted"` // 包含已删除的记录(仅管理员有效)
 }
 
 // PageResult 分页结果
@@ -11,6 +11,9 @@ type ProductionDTO struct {
 	Description    string    `json:"piDescription"`
 	PlantingDate   time.Time `json:"plantingDate" binding:"required"`
 	HarvestDate    time.Time `json:"harvestDate" binding:"required"`
+	BatchNo        string    `json:"batchNo"`  // 为空时自动生成，创建后不可修改
+	Quantity       float64   `json:"quantity"` // 批次数量
+	Unit           string    `json:"unit"`     // 数量单位，默认kg
 }
 
 // ProductionPageQueryDTO 生产信息分页查询DTO
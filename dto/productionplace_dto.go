@@ -10,9 +10,10 @@ type ProductionPlaceDTO struct {
 
 // ProductionPlacePageQueryDTO 生产地分页查询DTO
 type ProductionPlacePageQueryDTO struct {
-	Page          int    `json:"page"`
-	PageSize      int    `json:"size"`
-	ID            string `json:"ppId"`            // 生产地编号
-	Address       string `json:"ppAddress"`       // 生产地地址
-	Administrator string `json:"ppAdministrator"` // 负责人
+	Page           int    `json:"page"`
+	PageSize       int    `json:"size"`
+	ID             string `json:"ppId"`            // 生产地编号
+	Address        string `json:"ppAddress"`       // 生产地地址
+	Administrator  string `json:"ppAdministrator"` // 负责人
+	IncludeDeleted bool   `json:"includeDeleted"`  // 包含已删除的记录(仅管理员有效)
 }
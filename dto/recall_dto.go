@@ -0,0 +1,21 @@
+package dto
+
+// RecallDTO 发起召回DTO，生产批次与产品填写其一；
+// 由不合格检测记录发起时可只填写检测记录，召回对象为被检批次
+type RecallDTO struct {
+	ProductInfoID int    `json:"productInfoId"`
+	ProductID     int    `json:"productId"`
+	InspectionID  int    `json:"inspectionId"`
+	Reason        string `json:"reason" binding:"required"`
+}
+
+// RecallSalePlaceDTO 更新销售地召回处理状态DTO
+type RecallSalePlaceDTO struct {
+	Status string `json:"status" binding:"required"` // notified/completed
+	Remark string `json:"remark"`
+}
+
+// RecallCloseDTO 结束召回DTO
+type RecallCloseDTO struct {
+	Remark string `json:"remark"`
+}
@@ -10,10 +10,11 @@ type SalePlaceDTO struct {
 
 // SalePlacePageQueryDTO 销售地分页查询DTO
 type SalePlacePageQueryDTO struct {
-	Page          int    `json:"page" binding:"required"`
-	PageSize      int    `json:"size" binding:"required"`
-	ID            string `json:"spId"`
-	Address       string `json:"spAddress"`
-	Administrator string `json:"spAdministrator"`
-	Phone         string `json:"spPhone"`
+	Page           int    `json:"page" binding:"required"`
+	PageSize       int    `json:"size" binding:"required"`
+	ID             string `json:"spId"`
+	Address        string `json:"spAddress"`
+	Administrator  string `json:"spAdministrator"`
+	Phone          string `json:"spPhone"`
+	IncludeDeleted bool   `json:"includeDeleted"` // 包含已删除的记录(仅管理员有效)
 }
@@ -0,0 +1,34 @@
+package dto
+
+import "time"
+
+// TraceRecordDTO 完整溯源记录DTO，在一个事务中依次创建生产信息、物流运输段和销售信息
+type TraceRecordDTO struct {
+	Production ProductionDTO          `json:"production" binding:"required"`
+	Logistics  []TraceRecordLegDTO    `json:"logistics" binding:"required,min=1,dive"`
+	Sale       TraceRecordSaleInfoDTO `json:"sale" binding:"required"`
+}
+
+// TraceRecordLegDTO 物流运输段，按运输顺序排列，最后一段为销售信息关联的物流
+type TraceRecordLegDTO struct {
+	CompanyID     int        `json:"companyId"`
+	StartLocation string     `json:"startLocation" binding:"required"`
+	Destination   string     `json:"destination" binding:"required"`
+	StartTime     time.Time  `json:"startTime" binding:"required"`
+	EndTime       *time.Time `json:"endTime"`  // 已填写时视为已送达
+	Quantity      *float64   `json:"quantity"` // 分配的批次数量，为空表示不分配(同一批货物的后续转运)
+}
+
+// TraceRecordSaleInfoDTO 销售信息
+type TraceRecordSaleInfoDTO struct {
+	SalePlaceID int       `json:"salePlaceId"`
+	Description string    `json:"siDescription"`
+	SaleTime    time.Time `json:"saleTime" binding:"required"`
+}
+
+// TraceRecordVO 创建完整溯源记录后生成的ID
+type TraceRecordVO struct {
+	ProductInfoID int   `json:"productInfoId"`
+	LogisticsIDs  []int `json:"logisticsIds"`
+	SaleInfoID    int   `json:"saleInfoId"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+// TraceCodeDTO 溯源码生成DTO(销售信息与生产批次二选一)
+type TraceCodeDTO struct {
+	SaleInfoID    int `json:"saleInfoId"`
+	ProductInfoID int `json:"productInfoId"`
+}
+
+// TraceCodeRevokeDTO 溯源码作废DTO
+type TraceCodeRevokeDTO struct {
+	Reason string `json:"reason"`
+}
+
+// TraceCodePageQueryDTO 溯源码分页查询DTO
+type TraceCodePageQueryDTO struct {
+	Page          int    `json:"page"`
+	Size          int    `json:"size"`
+	Code          string `json:"code"`
+	SaleInfoID    int    `json:"saleInfoId"`
+	ProductInfoID int    `json:"productInfoId"`
+	Revoked       *bool  `json:"revoked"`
+}
@@ -22,6 +22,32 @@ type UserEditPasswordDTO struct {
 	ConfirmPassword string `json:"confirmPassword" binding:"required"`
 }
 
+// UserRoleDTO 用户角色分配DTO
+type UserRoleDTO struct {
+	UserID int    `json:"userId" binding:"required"`
+	Role   string `json:"role" binding:"required"`
+}
+
+// UserBindingDTO 用户业务主体绑定DTO，ID为0表示解除绑定
+type UserBindingDTO struct {
+	UserID         int `json:"userId" binding:"required"`
+	CompanyID      int `json:"companyId"`
+	ProductPlaceID int `json:"productPlaceId"`
+	SalePlaceID    int `json:"salePlaceId"`
+}
+
+// TokenDTO 登录/刷新返回的令牌对
+type TokenDTO struct {
+	Token        string `json:"token"`        // 访问令牌
+	RefreshToken string `json:"refreshToken"` // 刷新令牌
+	ExpiresIn    int64  `json:"expiresIn"`    // 访问令牌有效期(秒)
+}
+
+// RefreshTokenDTO 刷新令牌请求DTO
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
 // 返回结果结构
 type Result struct {
 	Code int         `json:"code"`
@@ -2,41 +2,100 @@ package main
 
 import (
 	"log"
+	"os"
 	"time"
 
 	"agricultural_product_gin/config"
 	"agricultural_product_gin/controller"
 	"agricultural_product_gin/middleware"
+	"agricultural_product_gin/model"
 	"agricultural_product_gin/repository"
 	"agricultural_product_gin/service"
+	"agricultural_product_gin/utils"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// 校验溯源证书只需要公钥和证书文件，在加载配置之前执行，验证方不需要服务端的配置
+	if len(os.Args) > 2 && os.Args[1] == "certificate" && os.Args[2] == "verify" {
+		if err := verifyCertificate(os.Args[3:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// 加载配置(配置文件 + 环境变量)
+	cfg := config.MustLoad()
+	log.Printf("运行环境：%s", cfg.Profile)
+
+	// 溯源证书子命令：certificate keygen/pubkey，不需要连接数据库
+	if len(os.Args) > 1 && os.Args[1] == "certificate" {
+		if err := runCertificate(cfg, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	utils.InitJWT(cfg.JWT.Secret, cfg.JWT.AccessTokenExpire, cfg.JWT.RefreshTokenExpire)
+
 	// 初始化数据库
-	db := config.GetDB()
-	defer db.Close()
+	sqlDB := config.GetDB(&cfg.Database)
+	defer sqlDB.Close()
+	db, err := repository.NewDB(sqlDB, cfg.Database.Driver)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 数据库迁移子命令：migrate up/down/status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(db, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	warnPendingMigrations(db)
+
+	// 用户管理子命令：user promote
+	if len(os.Args) > 1 && os.Args[1] == "user" {
+		if err := runUser(db, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	warnNoAdmin(db)
+	uow := repository.NewUnitOfWork(db)
+
+	// 首次启用哈希链时将已有的生产、物流、销售记录入链
+	hashChainRepo := repository.NewHashChainRepository(db)
+	if sealed, err := hashChainRepo.Seal(); err != nil {
+		log.Println("已有溯源记录入链失败:", err)
+	} else if sealed > 0 {
+		log.Printf("已有溯源记录入链：%d条", sealed)
+	}
+	hashChainService := service.NewHashChainService(hashChainRepo)
 
 	// 创建用户相关依赖
 	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo)
+	tokenRepo := repository.NewTokenRepository(db)
+	tokenService := service.NewTokenService(tokenRepo, userRepo)
+	userService := service.NewUserService(userRepo, tokenService, uow)
 	userController := controller.NewUserController(userService)
 
 	// 创建产品相关依赖
 	productRepo := repository.NewProductRepository(db)
-	productService := service.NewProductService(productRepo)
+	productService := service.NewProductService(productRepo, uow)
 	productController := controller.NewProductController(productService)
 
 	// 创建文件上传控制器
-	uploadController := controller.NewUploadController()
+	uploadController := controller.NewUploadController(cfg.Upload.Dir, cfg.Server.PublicURL("/images/"))
 
 	// 创建Gin引擎
 	r := gin.Default()
 
 	// 配置CORS中间件
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3030"},
+		AllowOrigins:     cfg.Server.CORSOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization"},
 		ExposeHeaders:    []string{"Content-Length"},
@@ -47,145 +106,346 @@ func main() {
 	// 注册静态文件路由 - 用于访问上传的图片
 	uploadController.RegisterStaticRoutes(r)
 
+	// 管理类路由需要登录，并按角色和HTTP方法授权(管理员拥有全部权限)
+	readRoles := []string{model.RoleFarmer, model.RoleLogistics, model.RoleRetailer, model.RoleAuditor}
+	auth := func(permissions middleware.Permissions) []gin.HandlerFunc {
+		return []gin.HandlerFunc{middleware.JWTMiddleware(tokenService), middleware.RoleMiddleware(permissions)}
+	}
+
 	// 用户相关路由
 	userGroup := r.Group("/user")
 	{
 		// 公开API
 		userGroup.POST("/register", userController.Register)
 		userGroup.POST("/login", userController.Login)
+		userGroup.POST("/refresh", userController.Refresh)
 
 		// 需要认证的API
 		authGroup := userGroup.Group("/")
-		authGroup.Use(middleware.JWTMiddleware())
+		authGroup.Use(middleware.JWTMiddleware(tokenService))
 		{
 			authGroup.POST("/logout", userController.Logout)
+			authGroup.POST("/logoutAll", userController.LogoutAll)
 			authGroup.GET("/userInfo", userController.GetUserInfo)
 			authGroup.PUT("/update", userController.Update)
 			authGroup.PUT("/editPassword", userController.EditPassword)
 		}
+
+		// 管理员API
+		adminGroup := userGroup.Group("/")
+		adminGroup.Use(auth(middleware.Permissions{})...)
+		{
+			adminGroup.GET("/list", userController.List)
+			adminGroup.PUT("/role", userController.AssignRole)
+			adminGroup.PUT("/binding", userController.Bind)
+		}
 	}
 
-	productGroup := r.Group("/product")
+	productGroup := r.Group("/product", auth(middleware.Permissions{
+		"GET":                      readRoles,
+		"PUT /product/restore/:id": {}, // 仅管理员
+	})...)
 	{
 		// 路由映射
-		productGroup.POST("", productController.Save)           // 新增
-		productGroup.DELETE("/:id", productController.Delete)   // 删除
-		productGroup.GET("/:id", productController.GetById)     // 根据id查询
-		productGroup.POST("/page", productController.PageQuery) // 分页查询
-		productGroup.PUT("", productController.Update)          // 修改
-		productGroup.GET("/list", productController.List)       // 查询所有
-		productGroup.GET("/types", productController.GetTypes)  // 获取所有产品类型
+		productGroup.POST("", productController.Save)               // 新增
+		productGroup.DELETE("/:id", productController.Delete)       // 删除
+		productGroup.GET("/:id", productController.GetById)         // 根据id查询
+		productGroup.POST("/page", productController.PageQuery)     // 分页查询
+		productGroup.PUT("", productController.Update)              // 修改
+		productGroup.GET("/list", productController.List)           // 查询所有
+		productGroup.GET("/types", productController.GetTypes)      // 获取所有产品类型
+		productGroup.PUT("/restore/:id", productController.Restore) // 恢复已删除的产品
 	}
 
 	// 创建生产信息相关依赖
 	productionRepo := repository.NewProductionRepository(db)
-	productionService := service.NewProductionService(productionRepo)
+	productionService := service.NewProductionService(productionRepo, uow)
 	productionController := controller.NewProductionController(productionService)
+	batchService := service.NewBatchService(repository.NewRepositories(db), uow)
+	batchController := controller.NewBatchController(batchService)
+	farmingActivityRepo := repository.NewFarmingActivityRepository(db)
+	farmingActivityService := service.NewFarmingActivityService(farmingActivityRepo, productionRepo, uow)
+	farmingActivityController := controller.NewFarmingActivityController(farmingActivityService)
 
 	// 生产信息路由组
-	productionGroup := r.Group("/productinfo")
+	productionGroup := r.Group("/productinfo", auth(middleware.Permissions{
+		"GET":    readRoles,
+		"POST":   {model.RoleFarmer},
+		"PUT":    {model.RoleFarmer},
+		"DELETE": {model.RoleFarmer},
+	})...)
 	{
-		productionGroup.POST("", productionController.Save)           // 新增
-		productionGroup.DELETE("/:id", productionController.Delete)   // 删除
-		productionGroup.GET("/:id", productionController.GetById)     // 根据id查询
-		productionGroup.POST("/page", productionController.PageQuery) // 分页查询
-		productionGroup.PUT("", productionController.Update)          // 修改
-		productionGroup.GET("/list", productionController.List)       // 查询所有
+		productionGroup.POST("", productionController.Save)                       // 新增
+		productionGroup.DELETE("/:id", productionController.Delete)               // 删除
+		productionGroup.GET("/:id", productionController.GetById)                 // 根据id查询
+		productionGroup.POST("/page", productionController.PageQuery)             // 分页查询
+		productionGroup.PUT("", productionController.Update)                      // 修改
+		productionGroup.GET("/list", productionController.List)                   // 查询所有
+		productionGroup.POST("/:id/split", batchController.Split)                 // 拆分批次
+		productionGroup.POST("/merge", batchController.Merge)                     // 合并批次
+		productionGroup.GET("/:id/lineage", batchController.Lineage)              // 批次谱系
+		productionGroup.GET("/:id/activities", farmingActivityController.List)    // 农事活动记录
+		productionGroup.POST("/activity", farmingActivityController.Save)         // 新增农事活动
+		productionGroup.PUT("/activity", farmingActivityController.Update)        // 修改农事活动
+		productionGroup.GET("/activity/:id", farmingActivityController.GetByID)   // 查询农事活动
+		productionGroup.DELETE("/activity/:id", farmingActivityController.Delete) // 删除农事活动
 	}
 	// 在main.go中添加以下代码
 
 	// 创建生产地相关依赖
 	productionPlaceRepo := repository.NewProductionPlaceRepository(db)
-	productionPlaceService := service.NewProductionPlaceService(productionPlaceRepo)
+	productionPlaceService := service.NewProductionPlaceService(productionPlaceRepo, uow)
 	productionPlaceController := controller.NewProductionPlaceController(productionPlaceService)
 
 	// 生产地路由组
-	productionPlaceGroup := r.Group("/productplace")
+	productionPlaceGroup := r.Group("/productplace", auth(middleware.Permissions{
+		"GET":    readRoles,
+		"POST":   {model.RoleFarmer},
+		"PUT":    {model.RoleFarmer},
+		"DELETE": {model.RoleFarmer},
+
+		"PUT /productplace/restore/:id": {}, // 仅管理员
+	})...)
 	{
-		productionPlaceGroup.POST("", productionPlaceController.Save)           // 新增
-		productionPlaceGroup.DELETE("/:id", productionPlaceController.Delete)   // 删除
-		productionPlaceGroup.GET("/:id", productionPlaceController.GetById)     // 根据id查询
-		productionPlaceGroup.POST("/page", productionPlaceController.PageQuery) // 分页查询
-		productionPlaceGroup.PUT("", productionPlaceController.Update)          // 修改
-		productionPlaceGroup.GET("/list", productionPlaceController.List)       // 查询所有
+		productionPlaceGroup.POST("", productionPlaceController.Save)               // 新增
+		productionPlaceGroup.DELETE("/:id", productionPlaceController.Delete)       // 删除
+		productionPlaceGroup.GET("/:id", productionPlaceController.GetById)         // 根据id查询
+		productionPlaceGroup.POST("/page", productionPlaceController.PageQuery)     // 分页查询
+		productionPlaceGroup.PUT("", productionPlaceController.Update)              // 修改
+		productionPlaceGroup.GET("/list", productionPlaceController.List)           // 查询所有
+		productionPlaceGroup.PUT("/restore/:id", productionPlaceController.Restore) // 恢复已删除的生产地
 	}
 
 	// 创建公司相关依赖
 	companyRepo := repository.NewCompanyRepository(db)
-	companyService := service.NewCompanyService(companyRepo)
+	companyService := service.NewCompanyService(companyRepo, uow)
 	companyController := controller.NewCompanyController(companyService)
 
 	// 公司路由组
-	companyGroup := r.Group("/company")
+	companyGroup := r.Group("/company", auth(middleware.Permissions{
+		"GET":  readRoles,
+		"POST": {model.RoleLogistics},
+		"PUT":  {model.RoleLogistics},
+
+		"PUT /company/restore/:id": {}, // 仅管理员
+	})...)
 	{
-		companyGroup.POST("", companyController.Save)           // 新增
-		companyGroup.DELETE("/:id", companyController.Delete)   // 删除
-		companyGroup.GET("/:id", companyController.GetByID)     // 根据id查询
-		companyGroup.POST("/page", companyController.PageQuery) // 分页查询
-		companyGroup.PUT("", companyController.Update)          // 修改
-		companyGroup.GET("/list", companyController.ListAll)    // 查询所有
+		companyGroup.POST("", companyController.Save)               // 新增
+		companyGroup.DELETE("/:id", companyController.Delete)       // 删除
+		companyGroup.GET("/:id", companyController.GetByID)         // 根据id查询
+		companyGroup.POST("/page", companyController.PageQuery)     // 分页查询
+		companyGroup.PUT("", companyController.Update)              // 修改
+		companyGroup.GET("/list", companyController.ListAll)        // 查询所有
+		companyGroup.PUT("/restore/:id", companyController.Restore) // 恢复已删除的公司
 	}
 
 	// 创建物流相关依赖
 	logisticsRepo := repository.NewLogisticsRepository(db)
-	logisticsService := service.NewLogisticsService(logisticsRepo)
-	logisticsController := controller.NewLogisticsController(logisticsService)
+	logisticsEventRepo := repository.NewLogisticsEventRepository(db)
+	logisticsService := service.NewLogisticsService(logisticsRepo, logisticsEventRepo, uow)
+	sensorReadingRepo := repository.NewSensorReadingRepository(db)
+	coldChainService := service.NewColdChainService(sensorReadingRepo, logisticsRepo, productionRepo, productRepo, uow)
+	logisticsController := controller.NewLogisticsController(logisticsService, coldChainService)
 
 	// 物流路由组
-	logisticsGroup := r.Group("/logistics")
+	logisticsGroup := r.Group("/logistics", auth(middleware.Permissions{
+		"GET":                        readRoles,
+		"POST":                       {model.RoleFarmer, model.RoleLogistics},
+		"PUT":                        {model.RoleLogistics},
+		"DELETE":                     {model.RoleLogistics},
+		"PUT /logistics/confirm/:id": {model.RoleLogistics, model.RoleRetailer},
+	})...)
 	{
-		logisticsGroup.POST("", logisticsController.Save)                      // 新增
-		logisticsGroup.DELETE("/:id", logisticsController.Delete)              // 删除
-		logisticsGroup.GET("/:id", logisticsController.GetById)                // 根据id查询
-		logisticsGroup.POST("/page", logisticsController.PageQuery)            // 分页查询
-		logisticsGroup.PUT("", logisticsController.Update)                     // 修改
-		logisticsGroup.GET("/list", logisticsController.List)                  // 查询所有
-		logisticsGroup.PUT("/confirm/:id", logisticsController.ConfirmReceipt) // 确认收货
+		logisticsGroup.POST("", logisticsController.Save)                               // 新增
+		logisticsGroup.DELETE("/:id", logisticsController.Delete)                       // 删除
+		logisticsGroup.GET("/:id", logisticsController.GetById)                         // 根据id查询
+		logisticsGroup.POST("/page", logisticsController.PageQuery)                     // 分页查询
+		logisticsGroup.PUT("", logisticsController.Update)                              // 修改
+		logisticsGroup.GET("/list", logisticsController.List)                           // 查询所有
+		logisticsGroup.PUT("/confirm/:id", logisticsController.ConfirmReceipt)          // 确认收货
+		logisticsGroup.PUT("/status/:id", logisticsController.Transition)               // 状态流转
+		logisticsGroup.GET("/:id/events", logisticsController.ListEvents)               // 查询事件时间线
+		logisticsGroup.POST("/event", logisticsController.AddEvent)                     // 追加运输事件
+		logisticsGroup.DELETE("/event/:id", logisticsController.DeleteEvent)            // 删除运输事件
+		logisticsGroup.POST("/:id/readings", logisticsController.IngestReadings)        // 上报冷链读数
+		logisticsGroup.POST("/:id/readings/csv", logisticsController.ImportReadingsCSV) // 导入记录仪CSV
+		logisticsGroup.GET("/:id/coldchain", logisticsController.GetColdChain)          // 冷链汇总
+	}
+
+	// 创建质量检测相关依赖
+	inspectionRepo := repository.NewInspectionRepository(db)
+	inspectionService := service.NewInspectionService(inspectionRepo, productionRepo, logisticsRepo, uow,
+		cfg.Server.PublicURL("/images/"))
+	inspectionController := controller.NewInspectionController(inspectionService)
+
+	// 质量检测路由组，农场登记产地检测，物流公司登记运输环节抽检
+	inspectionGroup := r.Group("/inspection", auth(middleware.Permissions{
+		"GET":    readRoles,
+		"POST":   {model.RoleFarmer, model.RoleLogistics},
+		"PUT":    {model.RoleFarmer, model.RoleLogistics},
+		"DELETE": {model.RoleFarmer, model.RoleLogistics},
+	})...)
+	{
+		inspectionGroup.POST("", inspectionController.Save)           // 新增
+		inspectionGroup.PUT("", inspectionController.Update)          // 修改
+		inspectionGroup.DELETE("/:id", inspectionController.Delete)   // 删除
+		inspectionGroup.GET("/:id", inspectionController.GetByID)     // 根据id查询
+		inspectionGroup.POST("/page", inspectionController.PageQuery) // 分页查询
 	}
 
 	// 创建销售地相关依赖
 	salePlaceRepo := repository.NewSalePlaceRepository(db)
-	salePlaceService := service.NewSalePlaceService(salePlaceRepo)
+	salePlaceService := service.NewSalePlaceService(salePlaceRepo, uow)
 	salePlaceController := controller.NewSalePlaceController(salePlaceService)
 
 	// 销售地路由组
-	salePlaceGroup := r.Group("/saleplace")
+	salePlaceGroup := r.Group("/saleplace", auth(middleware.Permissions{
+		"GET":  readRoles,
+		"POST": {model.RoleRetailer},
+		"PUT":  {model.RoleRetailer},
+
+		"PUT /saleplace/restore/:id": {}, // 仅管理员
+	})...)
 	{
-		salePlaceGroup.POST("", salePlaceController.Save)           // 新增
-		salePlaceGroup.DELETE("/:id", salePlaceController.Delete)   // 删除
-		salePlaceGroup.GET("/:id", salePlaceController.GetByID)     // 根据id查询
-		salePlaceGroup.POST("/page", salePlaceController.PageQuery) // 分页查询
-		salePlaceGroup.PUT("", salePlaceController.Update)          // 修改
-		salePlaceGroup.GET("/list", salePlaceController.ListAll)    // 查询所有
+		salePlaceGroup.POST("", salePlaceController.Save)               // 新增
+		salePlaceGroup.DELETE("/:id", salePlaceController.Delete)       // 删除
+		salePlaceGroup.GET("/:id", salePlaceController.GetByID)         // 根据id查询
+		salePlaceGroup.POST("/page", salePlaceController.PageQuery)     // 分页查询
+		salePlaceGroup.PUT("", salePlaceController.Update)              // 修改
+		salePlaceGroup.GET("/list", salePlaceController.ListAll)        // 查询所有
+		salePlaceGroup.PUT("/restore/:id", salePlaceController.Restore) // 恢复已删除的销售地
 	}
 	// 创建销售信息相关依赖
 	saleInfoRepo := repository.NewSaleInfoRepository(db)
-	saleInfoService := service.NewSaleInfoService(saleInfoRepo)
+	saleInfoService := service.NewSaleInfoService(saleInfoRepo, uow)
 	saleInfoController := controller.NewSaleInfoController(saleInfoService)
 
 	// 销售信息路由组
-	saleInfoGroup := r.Group("/saleinfo")
+	saleInfoGroup := r.Group("/saleinfo", auth(middleware.Permissions{
+		"GET":    readRoles,
+		"POST":   {model.RoleRetailer},
+		"PUT":    {model.RoleRetailer},
+		"DELETE": {model.RoleRetailer},
+	})...)
+	{
+		saleInfoGroup.POST("", saleInfoController.Save)                // 新增
+		saleInfoGroup.PUT("", saleInfoController.Update)               // 修改
+		saleInfoGroup.DELETE("/:id", saleInfoController.Delete)        // 删除
+		saleInfoGroup.GET("/:id", saleInfoController.GetByID)          // 根据id查询
+		saleInfoGroup.GET("/list", saleInfoController.ListAll)         // 查询所有
+		saleInfoGroup.POST("/page", saleInfoController.PageQuery)      // 分页查询
+		saleInfoGroup.GET("/reverse", saleInfoController.ReverseTrace) // 反向溯源
+	}
+
+	// 创建产品召回相关依赖
+	recallRepo := repository.NewRecallRepository(db)
+	recallService := service.NewRecallService(repository.NewRepositories(db), uow)
+	recallController := controller.NewRecallController(recallService)
+
+	// 召回路由组，农场发起和结束本生产地批次的召回，销售地登记本店的处理状态
+	recallGroup := r.Group("/recall", auth(middleware.Permissions{
+		"GET":                                    readRoles,
+		"POST":                                   {model.RoleFarmer},
+		"PUT /recall/:id/saleplace/:salePlaceId": {model.RoleFarmer, model.RoleRetailer},
+		"PUT /recall/:id/close":                  {model.RoleFarmer},
+	})...)
 	{
-		saleInfoGroup.POST("", saleInfoController.Save)           // 新增
-		saleInfoGroup.PUT("", saleInfoController.Update)          // 修改
-		saleInfoGroup.DELETE("/:id", saleInfoController.Delete)   // 删除
-		saleInfoGroup.GET("/:id", saleInfoController.GetByID)     // 根据id查询
-		saleInfoGroup.GET("/list", saleInfoController.ListAll)    // 查询所有
-		saleInfoGroup.POST("/page", saleInfoController.PageQuery) // 分页查询
-	}
-	r.POST("/upload", uploadController.Upload)
+		recallGroup.POST("", recallController.Open)                                      // 发起召回
+		recallGroup.GET("/:id", recallController.GetImpact)                              // 召回范围
+		recallGroup.POST("/page", recallController.PageQuery)                            // 分页查询
+		recallGroup.PUT("/:id/saleplace/:salePlaceId", recallController.UpdateSalePlace) // 销售地处理状态
+		recallGroup.PUT("/:id/close", recallController.Close)                            // 结束召回
+	}
+	// 创建完整溯源记录相关依赖
+	traceRecordService := service.NewTraceRecordService(uow)
+	traceRecordController := controller.NewTraceRecordController(traceRecordService)
+
+	// 完整溯源记录同时涉及生产、物流、销售三方，仅管理员可用
+	r.POST("/record", append(auth(middleware.Permissions{}), traceRecordController.Create)...)
+
+	uploadHandlers := auth(middleware.Permissions{
+		"POST": {model.RoleFarmer, model.RoleLogistics, model.RoleRetailer},
+	})
+	r.POST("/upload", append(uploadHandlers, uploadController.Upload)...)
+
+	// 创建溯源相关依赖
+	traceabilityService := service.NewTraceabilityService(
+		saleInfoRepo,
+		salePlaceRepo,
+		logisticsRepo,
+		companyRepo,
+		productionRepo,
+		productionPlaceRepo,
+		productRepo,
+		repository.NewBatchRepository(db),
+		farmingActivityRepo,
+		inspectionRepo,
+		recallRepo,
+		coldChainService,
+		hashChainService,
+	)
+
+	// 创建溯源码相关依赖
+	traceCodeRepo := repository.NewTraceCodeRepository(db)
+	traceCodeService := service.NewTraceCodeService(traceCodeRepo, saleInfoRepo, productionRepo, traceabilityService,
+		uow, cfg.Server.PublicURL("/traceability/code/"))
+	traceCodeController := controller.NewTraceCodeController(traceCodeService)
+
+	// 溯源码路由组
+	traceCodeGroup := r.Group("/tracecode", auth(middleware.Permissions{
+		"GET":  readRoles,
+		"POST": {model.RoleFarmer, model.RoleRetailer},
+		"PUT":  {model.RoleFarmer, model.RoleRetailer},
+	})...)
+	{
+		traceCodeGroup.POST("", traceCodeController.Save)             // 生成
+		traceCodeGroup.PUT("/revoke/:id", traceCodeController.Revoke) // 作废
+		traceCodeGroup.GET("/:id", traceCodeController.GetByID)       // 根据id查询
+		traceCodeGroup.POST("/page", traceCodeController.PageQuery)   // 分页查询
+	}
+
+	// 创建审计日志相关依赖
+	auditService := service.NewAuditService(repository.NewAuditRepository(db))
+	auditController := controller.NewAuditController(auditService)
+
+	// 审计日志只读，仅管理员和审计员可查询
+	auditGroup := r.Group("/audit", auth(middleware.Permissions{
+		"GET": {model.RoleAuditor},
+	})...)
+	{
+		auditGroup.POST("/page", auditController.PageQuery) // 分页查询
+	}
+
+	// 哈希链校验需要读取全部记录，仅管理员和审计员可执行
+	hashChainController := controller.NewHashChainController(hashChainService)
+	hashChainGroup := r.Group("/hashchain", auth(middleware.Permissions{
+		"GET": {model.RoleAuditor},
+	})...)
+	{
+		hashChainGroup.GET("/verify", hashChainController.Verify) // 校验
+	}
+
+	// 创建溯源证书相关依赖，证书由对外访问地址签发
+	certificateService := service.NewCertificateService(traceabilityService, mustLoadSigningKey(cfg), cfg.Server.PublicBaseURL)
+
 	traceabilityController := controller.NewTraceabilityController(
 		productionService,
 		productService,
 		logisticsService,
 		saleInfoService,
+		traceabilityService,
+		traceCodeService,
+		batchService,
+		inspectionService,
+		hashChainService,
+		certificateService,
 	)
 
-	// 注册溯源路由到根路由组
+	// 注册溯源路由到根路由组(公开访问，无需登录)
 	traceabilityController.RegisterRoutes(r.Group(""))
 	// 启动服务器
-	log.Println("服务器启动在 :8080 端口")
-	if err := r.Run(":8080"); err != nil {
+	log.Printf("服务器启动在 %s", cfg.Server.Addr)
+	if err := r.Run(cfg.Server.Addr); err != nil {
 		log.Fatal("启动服务器失败:", err)
 	}
 }
@@ -6,11 +6,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"agricultural_product_gin/model"
 	"agricultural_product_gin/utils"
 )
 
-// JWTMiddleware JWT验证中间件
-func JWTMiddleware() gin.HandlerFunc {
+// TokenChecker 令牌吊销检查
+type TokenChecker interface {
+	IsRevoked(claims *utils.Claims) (bool, error)
+}
+
+// JWTMiddleware JWT验证中间件，已退出登录或被吊销的令牌视为无效
+func JWTMiddleware(checker TokenChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 获取Authorization头
 		authHeader := c.GetHeader("Authorization")
@@ -45,10 +51,27 @@ func JWTMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 将用户信息保存到ThreadLocal
-		threadLocal := utils.GetUserLocal()
-		threadLocal.Set("userID", claims.UserID)
-		threadLocal.Set("username", claims.Username)
+		// 检查吊销列表
+		revoked, err := checker.IsRevoked(claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": 500,
+				"msg":  "系统错误",
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code": 401,
+				"msg":  "令牌已失效，请重新登录",
+			})
+			c.Abort()
+			return
+		}
+
+		// 身份保存到本次请求的上下文，供权限校验及后续处理使用
+		c.Request = c.Request.WithContext(model.WithIdentity(c.Request.Context(), claims.Identity()))
 
 		c.Next()
 	}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"agricultural_product_gin/model"
+)
+
+// Permissions 路由组的角色权限
+// 键为HTTP方法(如"GET")，或"方法 完整路由"(如"PUT /logistics/confirm/:id")以单独覆盖某个路由；
+// 以/page结尾的POST分页查询按GET处理。管理员始终放行。
+type Permissions map[string][]string
+
+// RoleMiddleware 角色权限中间件，需在JWTMiddleware之后使用
+func RoleMiddleware(permissions Permissions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := model.IdentityFrom(c.Request.Context())
+		role := ""
+		if identity != nil {
+			role = identity.Role
+		}
+		if role == model.RoleAdmin {
+			c.Next()
+			return
+		}
+
+		method := c.Request.Method
+		if method == http.MethodPost && strings.HasSuffix(c.FullPath(), "/page") {
+			method = http.MethodGet
+		}
+
+		roles, ok := permissions[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			roles = permissions[method]
+		}
+
+		for _, allowed := range roles {
+			if allowed == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"code": 403,
+			"msg":  "没有操作权限",
+		})
+		c.Abort()
+	}
+}
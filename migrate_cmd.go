@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"agricultural_product_gin/migrations"
+	"agricultural_product_gin/repository"
+)
+
+// migrateUsage migrate子命令用法
+const migrateUsage = `用法:
+  migrate up          执行所有未执行的迁移
+  migrate down [N]    回滚最近的N个迁移(默认1个)
+  migrate status      查看迁移执行状态`
+
+// runMigrate 执行migrate子命令
+func runMigrate(db *repository.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := migrations.NewMigrator(db.Pool(), db.Dialect.Name())
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已执行%d个迁移\n", len(done))
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("回滚步数必须是正整数: %s", args[1])
+			}
+		}
+		done, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已回滚%d个迁移\n", len(done))
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "版本\t名称\t状态\t执行时间")
+		for _, status := range statuses {
+			state, appliedAt := "未执行", ""
+			if status.Applied {
+				state, appliedAt = "已执行", status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
+
+// warnPendingMigrations 存在未执行的迁移时提示
+func warnPendingMigrations(db *repository.DB) {
+	migrator, err := migrations.NewMigrator(db.Pool(), db.Dialect.Name())
+	if err != nil {
+		log.Println("加载数据库迁移失败:", err)
+		return
+	}
+
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		log.Println("查询数据库迁移状态失败:", err)
+		return
+	}
+	if pending > 0 {
+		log.Printf("警告：有%d个数据库迁移尚未执行，请先运行 migrate up", pending)
+	}
+}
@@ -0,0 +1,327 @@
+// Package migrations 内嵌在程序中的版本化数据库迁移
+//
+// 迁移文件按数据库方言存放在对应目录下，命名为"版本号_名称.up.sql"与"版本号_名称.down.sql"，
+// 已执行的版本记录在schema_migrations表中。
+//
+// SQLite的DDL支持事务，每个迁移的脚本与版本记录在同一事务中提交，失败时整体回滚；
+// MySQL的DDL会隐式提交，无法回滚，因此MySQL的每个迁移文件只能包含一条语句，失败时不会留下执行了一半的迁移。
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed mysql/*.sql sqlite/*.sql
+var files embed.FS
+
+// migrationFilePattern 迁移文件名格式
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// transactionalDDL DDL可以在事务中执行并回滚的数据库方言
+var transactionalDDL = map[string]bool{"sqlite": true}
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus 迁移执行状态
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Migrator 迁移执行器
+type Migrator struct {
+	db            *sql.DB
+	migrations    []*Migration
+	transactional bool
+}
+
+// NewMigrator 创建迁移执行器，dialect为迁移文件所在目录(mysql或sqlite)
+func NewMigrator(db *sql.DB, dialect string) (*Migrator, error) {
+	migrations, err := load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, transactional: transactionalDDL[dialect]}, nil
+}
+
+// Up 按版本顺序执行所有未执行的迁移，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	conn, applied, err := m.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var done []*Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		log.Printf("执行迁移 %04d_%s", migration.Version, migration.Name)
+		err := m.apply(ctx, conn, migration.Up, "INSERT INTO schema_migrations(version, name, applied_at) VALUES(?, ?, ?)",
+			migration.Version, migration.Name, time.Now())
+		if err != nil {
+			return done, fmt.Errorf("执行迁移%04d_%s失败: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down 回滚最近执行的steps个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	conn, applied, err := m.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var done []*Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		log.Printf("回滚迁移 %04d_%s", migration.Version, migration.Name)
+		err := m.apply(ctx, conn, migration.Down, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+		if err != nil {
+			return done, fmt.Errorf("回滚迁移%04d_%s失败: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Status 查询所有迁移的执行状态
+func (m *Migrator) Status(ctx context.Context) ([]*MigrationStatus, error) {
+	conn, applied, err := m.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	statuses := make([]*MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := &MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending 未执行的迁移数量
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if !status.Applied {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// apply 执行迁移脚本并更新schema_migrations中的版本记录，支持事务的方言在同一事务中执行
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	if !m.transactional {
+		if err := execScript(ctx, conn, script); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, record, args...); err != nil {
+			return fmt.Errorf("更新迁移记录失败: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := execScript(ctx, tx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("更新迁移记录失败: %w", err)
+	}
+	return tx.Commit()
+}
+
+// prepare 获取独占连接(保证会话级设置在同一连接上生效)，并读取已执行的版本
+func (m *Migrator) prepare(ctx context.Context) (*sql.Conn, map[int]time.Time, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version int NOT NULL PRIMARY KEY,
+		name varchar(100) NOT NULL,
+		applied_at datetime NOT NULL
+	)`)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("创建schema_migrations表失败: %w", err)
+	}
+
+	applied, err := m.readApplied(ctx, conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return conn, applied, nil
+}
+
+// readApplied 读取已执行的版本及执行时间
+func (m *Migrator) readApplied(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[int]string, len(m.migrations))
+	for _, migration := range m.migrations {
+		names[migration.Version] = migration.Name
+	}
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var name string
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &name, &appliedAt); err != nil {
+			return nil, err
+		}
+		// 已执行的版本与内置迁移的名称不一致时，继续执行会跳过或重复表结构变更
+		if expected, ok := names[version]; ok && expected != name {
+			return nil, fmt.Errorf("数据库中已执行的迁移%04d_%s与程序内置的迁移%04d_%s不一致", version, name, version, expected)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// load 读取方言目录下的迁移文件并按版本排序
+func load(dialect string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(files, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的数据库方言%s: %w", dialect, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("迁移文件名格式错误: %s", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(files, path.Join(dialect, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("迁移版本%d存在多个名称: %s, %s", version, migration.Name, match[2])
+		}
+
+		// DDL无法回滚的方言，一个文件中有多条语句时执行失败会留下执行了一半的迁移
+		if !transactionalDDL[dialect] && len(splitStatements(string(content))) > 1 {
+			return nil, fmt.Errorf("迁移文件%s包含多条语句，%s的DDL无法回滚，每个迁移文件只能包含一条语句", entry.Name(), dialect)
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("迁移%04d_%s缺少up或down文件", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// execer 可执行SQL语句的连接或事务
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// execScript 逐条执行迁移脚本(以行尾分号分隔语句，忽略--注释行)
+func execScript(ctx context.Context, conn execer, script string) error {
+	for _, statement := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%w\n%s", err, statement)
+		}
+	}
+	return nil
+}
+
+// splitStatements 将脚本拆分为单条语句
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements
+}
@@ -0,0 +1,15 @@
+-- 产品表(对应原traceability.sql，补充unit_price字段)
+
+CREATE TABLE `product` (
+  `pd_id` int NOT NULL AUTO_INCREMENT,
+  `pd_name` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '名称',
+  `type` varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '类别',
+  `image` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '图片',
+  `pd_description` varchar(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '具体描述',
+  `unit_price` decimal(10, 2) NULL DEFAULT NULL COMMENT '单价',
+  `min_temperature` decimal(5, 2) NULL DEFAULT NULL COMMENT '冷链最低温度',
+  `max_temperature` decimal(5, 2) NULL DEFAULT NULL COMMENT '冷链最高温度',
+  `min_humidity` decimal(5, 2) NULL DEFAULT NULL COMMENT '冷链最低湿度',
+  `max_humidity` decimal(5, 2) NULL DEFAULT NULL COMMENT '冷链最高湿度',
+  PRIMARY KEY (`pd_id`) USING BTREE
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
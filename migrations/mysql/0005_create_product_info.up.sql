@@ -0,0 +1,16 @@
+-- 生产信息表
+
+CREATE TABLE `product_info` (
+  `pi_id` int NOT NULL AUTO_INCREMENT,
+  `product_id` int NULL DEFAULT NULL COMMENT '产品id',
+  `product_place_id` int NULL DEFAULT NULL COMMENT '生产地id',
+  `seed` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '种子来源',
+  `pi_description` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '记录生产内容',
+  `planting_date` datetime NULL DEFAULT NULL COMMENT '播种时间',
+  `harvest_date` datetime NULL DEFAULT NULL COMMENT '收获时间',
+  PRIMARY KEY (`pi_id`) USING BTREE,
+  INDEX `product_id`(`product_id`) USING BTREE,
+  INDEX `product_place_id`(`product_place_id`) USING BTREE,
+  CONSTRAINT `product_info_ibfk_1` FOREIGN KEY (`product_id`) REFERENCES `product` (`pd_id`) ON DELETE RESTRICT ON UPDATE RESTRICT,
+  CONSTRAINT `product_info_ibfk_2` FOREIGN KEY (`product_place_id`) REFERENCES `product_place` (`pp_id`) ON DELETE RESTRICT ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
@@ -0,0 +1,20 @@
+-- 物流信息表
+
+CREATE TABLE `logistics` (
+  `log_id` int NOT NULL AUTO_INCREMENT,
+  `product_info_id` int NULL DEFAULT NULL COMMENT '生产信息id',
+  `company_id` int NULL DEFAULT NULL COMMENT '物流公司id',
+  `start_location` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '起点',
+  `destination` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '目的地',
+  `start_time` datetime NULL DEFAULT NULL COMMENT '出发时间',
+  `end_time` datetime NULL DEFAULT NULL COMMENT '到达时间',
+  `status` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'created' COMMENT '运输状态(created/in_transit/delivered/rejected/cancelled)',
+  `status_reason` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '状态变更原因',
+  `status_time` datetime NULL DEFAULT NULL COMMENT '状态变更时间',
+  PRIMARY KEY (`log_id`) USING BTREE,
+  INDEX `product_info_id`(`product_info_id`) USING BTREE,
+  INDEX `company_id`(`company_id`) USING BTREE,
+  INDEX `status`(`status`) USING BTREE,
+  CONSTRAINT `logistics_ibfk_1` FOREIGN KEY (`product_info_id`) REFERENCES `product_info` (`pi_id`) ON DELETE RESTRICT ON UPDATE RESTRICT,
+  CONSTRAINT `logistics_ibfk_2` FOREIGN KEY (`company_id`) REFERENCES `company` (`com_id`) ON DELETE RESTRICT ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
@@ -0,0 +1,16 @@
+-- 物流运输事件表
+
+CREATE TABLE `logistics_event` (
+  `event_id` int NOT NULL AUTO_INCREMENT,
+  `log_id` int NOT NULL COMMENT '物流信息id',
+  `event_type` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '事件类型(pickup/hub_arrival/departure/handover/delivered)',
+  `location` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '发生地点',
+  `company_id` int NULL DEFAULT NULL COMMENT '经手公司id',
+  `event_time` datetime NOT NULL COMMENT '发生时间',
+  `remark` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '备注',
+  PRIMARY KEY (`event_id`) USING BTREE,
+  INDEX `log_id`(`log_id`, `event_time`) USING BTREE,
+  INDEX `company_id`(`company_id`) USING BTREE,
+  CONSTRAINT `logistics_event_ibfk_1` FOREIGN KEY (`log_id`) REFERENCES `logistics` (`log_id`) ON DELETE CASCADE ON UPDATE RESTRICT,
+  CONSTRAINT `logistics_event_ibfk_2` FOREIGN KEY (`company_id`) REFERENCES `company` (`com_id`) ON DELETE RESTRICT ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
@@ -0,0 +1,15 @@
+-- 冷链温湿度读数表
+
+CREATE TABLE `sensor_reading` (
+  `reading_id` bigint NOT NULL AUTO_INCREMENT,
+  `log_id` int NOT NULL COMMENT '物流信息id',
+  `reading_time` datetime NOT NULL COMMENT '采集时间',
+  `temperature` decimal(5, 2) NOT NULL COMMENT '温度',
+  `humidity` decimal(5, 2) NULL DEFAULT NULL COMMENT '相对湿度',
+  `latitude` decimal(9, 6) NULL DEFAULT NULL COMMENT '纬度',
+  `longitude` decimal(9, 6) NULL DEFAULT NULL COMMENT '经度',
+  `excursion` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否超出阈值',
+  PRIMARY KEY (`reading_id`) USING BTREE,
+  INDEX `log_id`(`log_id`, `reading_time`) USING BTREE,
+  CONSTRAINT `sensor_reading_ibfk_1` FOREIGN KEY (`log_id`) REFERENCES `logistics` (`log_id`) ON DELETE CASCADE ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
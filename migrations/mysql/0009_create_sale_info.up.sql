@@ -0,0 +1,14 @@
+-- 销售信息表
+
+CREATE TABLE `sale_info` (
+  `si_id` int NOT NULL AUTO_INCREMENT,
+  `logistics_id` int NULL DEFAULT NULL COMMENT '物流信息id',
+  `sale_place_id` int NULL DEFAULT NULL COMMENT '销售地id',
+  `si_description` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '销售相关内容',
+  `sale_time` datetime NULL DEFAULT NULL COMMENT '销售时间',
+  PRIMARY KEY (`si_id`) USING BTREE,
+  INDEX `logistics_id`(`logistics_id`) USING BTREE,
+  INDEX `sale_place_id`(`sale_place_id`) USING BTREE,
+  CONSTRAINT `sale_info_ibfk_1` FOREIGN KEY (`logistics_id`) REFERENCES `logistics` (`log_id`) ON DELETE RESTRICT ON UPDATE RESTRICT,
+  CONSTRAINT `sale_info_ibfk_2` FOREIGN KEY (`sale_place_id`) REFERENCES `sale_place` (`sp_id`) ON DELETE RESTRICT ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
@@ -0,0 +1,18 @@
+-- 溯源码表
+
+CREATE TABLE `trace_code` (
+  `tc_id` int NOT NULL AUTO_INCREMENT,
+  `code` varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '公开溯源码',
+  `sale_info_id` int NULL DEFAULT NULL COMMENT '销售信息id',
+  `product_info_id` int NULL DEFAULT NULL COMMENT '生产信息id',
+  `revoked` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否作废',
+  `create_time` datetime NOT NULL COMMENT '生成时间',
+  `revoke_time` datetime NULL DEFAULT NULL COMMENT '作废时间',
+  `revoke_reason` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '作废原因',
+  PRIMARY KEY (`tc_id`) USING BTREE,
+  UNIQUE INDEX `code`(`code`) USING BTREE,
+  INDEX `sale_info_id`(`sale_info_id`) USING BTREE,
+  INDEX `product_info_id`(`product_info_id`) USING BTREE,
+  CONSTRAINT `trace_code_ibfk_1` FOREIGN KEY (`sale_info_id`) REFERENCES `sale_info` (`si_id`) ON DELETE RESTRICT ON UPDATE RESTRICT,
+  CONSTRAINT `trace_code_ibfk_2` FOREIGN KEY (`product_info_id`) REFERENCES `product_info` (`pi_id`) ON DELETE RESTRICT ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
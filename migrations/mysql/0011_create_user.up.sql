@@ -0,0 +1,22 @@
+-- 用户表
+
+CREATE TABLE `user` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `username` varchar(30) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '用户名',
+  `password` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '密码',
+  `sex` varchar(2) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '性别',
+  `name` varchar(30) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '昵称',
+  `phone` varchar(11) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '电话',
+  `role` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '角色',
+  `company_id` int NULL DEFAULT NULL COMMENT '绑定的物流公司ID',
+  `product_place_id` int NULL DEFAULT NULL COMMENT '绑定的生产地ID',
+  `sale_place_id` int NULL DEFAULT NULL COMMENT '绑定的销售地ID',
+  `token_version` int NOT NULL DEFAULT 0 COMMENT '令牌版本，递增后已签发的令牌失效',
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `company_id`(`company_id`) USING BTREE,
+  INDEX `product_place_id`(`product_place_id`) USING BTREE,
+  INDEX `sale_place_id`(`sale_place_id`) USING BTREE,
+  CONSTRAINT `user_ibfk_1` FOREIGN KEY (`company_id`) REFERENCES `company` (`com_id`) ON DELETE SET NULL ON UPDATE RESTRICT,
+  CONSTRAINT `user_ibfk_2` FOREIGN KEY (`product_place_id`) REFERENCES `product_place` (`pp_id`) ON DELETE SET NULL ON UPDATE RESTRICT,
+  CONSTRAINT `user_ibfk_3` FOREIGN KEY (`sale_place_id`) REFERENCES `sale_place` (`sp_id`) ON DELETE SET NULL ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
@@ -0,0 +1,16 @@
+-- 刷新令牌表
+
+CREATE TABLE `refresh_token` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL COMMENT '用户ID',
+  `token_hash` char(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '刷新令牌SHA-256哈希',
+  `access_jti` varchar(36) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '配对的访问令牌ID',
+  `expires_at` datetime NOT NULL COMMENT '过期时间',
+  `revoked_at` datetime NULL DEFAULT NULL COMMENT '吊销时间',
+  `created_at` datetime NOT NULL COMMENT '签发时间',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `token_hash`(`token_hash`) USING BTREE,
+  INDEX `user_id`(`user_id`) USING BTREE,
+  INDEX `access_jti`(`access_jti`) USING BTREE,
+  CONSTRAINT `refresh_token_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
@@ -0,0 +1,9 @@
+-- 已吊销的访问令牌表
+
+CREATE TABLE `revoked_token` (
+  `jti` varchar(36) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '访问令牌ID',
+  `user_id` int NOT NULL COMMENT '用户ID',
+  `expires_at` datetime NOT NULL COMMENT '令牌原过期时间，过期后可清理',
+  PRIMARY KEY (`jti`) USING BTREE,
+  INDEX `expires_at`(`expires_at`) USING BTREE
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
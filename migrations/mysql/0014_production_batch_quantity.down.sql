@@ -0,0 +1,5 @@
+ALTER TABLE `product_info`
+  DROP INDEX `batch_no`,
+  DROP COLUMN `batch_no`,
+  DROP COLUMN `quantity`,
+  DROP COLUMN `unit`;
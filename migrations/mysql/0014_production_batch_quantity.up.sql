@@ -0,0 +1,7 @@
+-- 生产信息增加批次号、批次数量及数量单位
+
+ALTER TABLE `product_info`
+  ADD COLUMN `batch_no` varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '批次号' AFTER `pi_id`,
+  ADD COLUMN `quantity` decimal(12, 3) NOT NULL DEFAULT 0 COMMENT '批次数量' AFTER `harvest_date`,
+  ADD COLUMN `unit` varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'kg' COMMENT '数量单位' AFTER `quantity`,
+  ADD UNIQUE INDEX `batch_no`(`batch_no`) USING BTREE;
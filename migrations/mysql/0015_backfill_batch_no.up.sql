@@ -0,0 +1,3 @@
+-- 已有生产信息按ID生成批次号
+
+UPDATE `product_info` SET `batch_no` = CONCAT('PI', LPAD(`pi_id`, 8, '0')) WHERE `batch_no` IS NULL;
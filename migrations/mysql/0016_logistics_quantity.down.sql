@@ -0,0 +1 @@
+ALTER TABLE `logistics` DROP COLUMN `quantity`;
@@ -0,0 +1,4 @@
+-- 物流信息增加本次运输分配的批次数量
+
+ALTER TABLE `logistics`
+  ADD COLUMN `quantity` decimal(12, 3) NULL DEFAULT NULL COMMENT '本次运输分配的批次数量，为空表示未分配(如同一批货物的后续转运)' AFTER `company_id`;
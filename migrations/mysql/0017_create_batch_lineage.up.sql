@@ -0,0 +1,15 @@
+-- 批次拆分/合并谱系
+
+CREATE TABLE `batch_lineage` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `parent_id` int NOT NULL COMMENT '来源批次(生产信息id)',
+  `child_id` int NOT NULL COMMENT '产出批次(生产信息id)',
+  `kind` varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '拆分split/合并merge',
+  `quantity` decimal(12, 3) NOT NULL COMMENT '从来源批次转入产出批次的数量',
+  `create_time` datetime NOT NULL COMMENT '操作时间',
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `parent_id`(`parent_id`) USING BTREE,
+  INDEX `child_id`(`child_id`) USING BTREE,
+  CONSTRAINT `batch_lineage_ibfk_1` FOREIGN KEY (`parent_id`) REFERENCES `product_info` (`pi_id`) ON DELETE RESTRICT ON UPDATE RESTRICT,
+  CONSTRAINT `batch_lineage_ibfk_2` FOREIGN KEY (`child_id`) REFERENCES `product_info` (`pi_id`) ON DELETE RESTRICT ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
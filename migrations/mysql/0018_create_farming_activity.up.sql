@@ -0,0 +1,17 @@
+-- 农事活动记录(施肥、施药、灌溉等)
+
+CREATE TABLE `farming_activity` (
+  `fa_id` int NOT NULL AUTO_INCREMENT,
+  `product_info_id` int NOT NULL COMMENT '生产信息id',
+  `activity_type` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '活动类型(fertilizer/pesticide/irrigation/other)',
+  `material` varchar(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '投入品名称(肥料、农药等)',
+  `dosage` decimal(12, 3) NULL DEFAULT NULL COMMENT '用量',
+  `dosage_unit` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '用量单位',
+  `operator` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '操作人',
+  `activity_date` datetime NOT NULL COMMENT '作业时间',
+  `withholding_days` int NULL DEFAULT NULL COMMENT '安全间隔期(天)，施药后至少间隔该天数才能收获',
+  `remark` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '备注',
+  PRIMARY KEY (`fa_id`) USING BTREE,
+  INDEX `product_info_id`(`product_info_id`, `activity_date`) USING BTREE,
+  CONSTRAINT `farming_activity_ibfk_1` FOREIGN KEY (`product_info_id`) REFERENCES `product_info` (`pi_id`) ON DELETE CASCADE ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
@@ -0,0 +1,19 @@
+-- 质量检测记录
+
+CREATE TABLE `inspection` (
+  `ins_id` int NOT NULL AUTO_INCREMENT,
+  `product_info_id` int NOT NULL COMMENT '被检批次(生产信息id)',
+  `logistics_id` int NULL DEFAULT NULL COMMENT '运输环节抽检时对应的物流信息id',
+  `inspection_type` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '检测类型(residue/grading/certificate/other)',
+  `lab_name` varchar(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '检测机构',
+  `report_no` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '报告编号',
+  `inspection_date` datetime NOT NULL COMMENT '检测时间',
+  `verdict` varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '结论(pass/fail)',
+  `report_url` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '检测报告PDF地址',
+  `remark` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '备注',
+  PRIMARY KEY (`ins_id`) USING BTREE,
+  INDEX `product_info_id`(`product_info_id`, `inspection_date`) USING BTREE,
+  INDEX `logistics_id`(`logistics_id`) USING BTREE,
+  CONSTRAINT `inspection_ibfk_1` FOREIGN KEY (`product_info_id`) REFERENCES `product_info` (`pi_id`) ON DELETE RESTRICT ON UPDATE RESTRICT,
+  CONSTRAINT `inspection_ibfk_2` FOREIGN KEY (`logistics_id`) REFERENCES `logistics` (`log_id`) ON DELETE RESTRICT ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
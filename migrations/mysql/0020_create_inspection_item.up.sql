@@ -0,0 +1,16 @@
+-- 质量检测的检测项目
+
+CREATE TABLE `inspection_item` (
+  `item_id` int NOT NULL AUTO_INCREMENT,
+  `ins_id` int NOT NULL COMMENT '检测id',
+  `item_name` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '检测项目',
+  `value` decimal(14, 4) NULL DEFAULT NULL COMMENT '检测值',
+  `result_text` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '非数值结果(如等级)',
+  `unit` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '单位',
+  `limit_min` decimal(14, 4) NULL DEFAULT NULL COMMENT '下限',
+  `limit_max` decimal(14, 4) NULL DEFAULT NULL COMMENT '上限(如最大残留限量)',
+  `passed` tinyint(1) NOT NULL COMMENT '是否合格',
+  PRIMARY KEY (`item_id`) USING BTREE,
+  INDEX `ins_id`(`ins_id`) USING BTREE,
+  CONSTRAINT `inspection_item_ibfk_1` FOREIGN KEY (`ins_id`) REFERENCES `inspection` (`ins_id`) ON DELETE CASCADE ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
@@ -0,0 +1,22 @@
+-- 产品召回
+
+CREATE TABLE `recall` (
+  `rc_id` int NOT NULL AUTO_INCREMENT,
+  `product_info_id` int NULL DEFAULT NULL COMMENT '召回的生产批次(含其拆分/合并产出的批次)',
+  `product_id` int NULL DEFAULT NULL COMMENT '召回的产品(该产品的全部批次)',
+  `inspection_id` int NULL DEFAULT NULL COMMENT '触发召回的检测记录',
+  `reason` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '召回原因',
+  `status` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '召回状态(open/closed)',
+  `opened_by` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '发起人',
+  `open_time` datetime NOT NULL COMMENT '发起时间',
+  `close_time` datetime NULL DEFAULT NULL COMMENT '结束时间',
+  `close_remark` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '结束说明',
+  PRIMARY KEY (`rc_id`) USING BTREE,
+  INDEX `product_info_id`(`product_info_id`) USING BTREE,
+  INDEX `product_id`(`product_id`) USING BTREE,
+  INDEX `inspection_id`(`inspection_id`) USING BTREE,
+  INDEX `status`(`status`, `open_time`) USING BTREE,
+  CONSTRAINT `recall_ibfk_1` FOREIGN KEY (`product_info_id`) REFERENCES `product_info` (`pi_id`) ON DELETE RESTRICT ON UPDATE RESTRICT,
+  CONSTRAINT `recall_ibfk_2` FOREIGN KEY (`product_id`) REFERENCES `product` (`pd_id`) ON DELETE RESTRICT ON UPDATE RESTRICT,
+  CONSTRAINT `recall_ibfk_3` FOREIGN KEY (`inspection_id`) REFERENCES `inspection` (`ins_id`) ON DELETE SET NULL ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `recall_sale_place`;
@@ -0,0 +1,15 @@
+-- 各销售地的召回处理状态
+
+CREATE TABLE `recall_sale_place` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `rc_id` int NOT NULL COMMENT '召回id',
+  `sale_place_id` int NOT NULL COMMENT '收到召回批次的销售地id',
+  `status` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '处理状态(pending/notified/completed)',
+  `remark` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '处理说明',
+  `update_time` datetime NOT NULL COMMENT '状态更新时间',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `rc_sale_place`(`rc_id`, `sale_place_id`) USING BTREE,
+  INDEX `sale_place_id`(`sale_place_id`) USING BTREE,
+  CONSTRAINT `recall_sale_place_ibfk_1` FOREIGN KEY (`rc_id`) REFERENCES `recall` (`rc_id`) ON DELETE CASCADE ON UPDATE RESTRICT,
+  CONSTRAINT `recall_sale_place_ibfk_2` FOREIGN KEY (`sale_place_id`) REFERENCES `sale_place` (`sp_id`) ON DELETE RESTRICT ON UPDATE RESTRICT
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
@@ -0,0 +1,17 @@
+-- 审计日志：记录业务数据每次变更的操作人、动作及变更前后的快照
+
+CREATE TABLE `audit_log` (
+  `al_id` int NOT NULL AUTO_INCREMENT,
+  `actor_id` int NULL DEFAULT NULL COMMENT '操作人用户id，系统内部操作为空',
+  `actor` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '操作人用户名',
+  `entity` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '实体(数据表名)',
+  `entity_id` int NOT NULL COMMENT '实体id',
+  `action` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '操作(create/update/delete等)',
+  `before_json` json NULL COMMENT '变更前的数据',
+  `after_json` json NULL COMMENT '变更后的数据',
+  `create_time` datetime NOT NULL COMMENT '操作时间',
+  PRIMARY KEY (`al_id`) USING BTREE,
+  INDEX `entity`(`entity`, `entity_id`) USING BTREE,
+  INDEX `actor`(`actor`, `create_time`) USING BTREE,
+  INDEX `create_time`(`create_time`) USING BTREE
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
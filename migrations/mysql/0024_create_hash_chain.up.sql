@@ -0,0 +1,17 @@
+-- 哈希链：生产、物流、销售记录每次提交的变更按顺序追加，每个条目包含前一条目的哈希，用于发现事后篡改
+-- payload使用text而不是json类型，json类型会重新排列键顺序，导致记录哈希无法复算
+
+CREATE TABLE `hash_chain` (
+  `seq` int NOT NULL COMMENT '链上位置，从1开始连续递增',
+  `entity` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '实体(product_info/logistics/sale_info)',
+  `entity_id` int NOT NULL COMMENT '实体id',
+  `action` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '操作(create/update/delete)',
+  `payload` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '变更后的记录内容(规范化JSON)，删除时为空',
+  `record_hash` char(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '记录哈希',
+  `prev_hash` char(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '前一条目的哈希，第一个条目为空',
+  `hash` char(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '条目哈希',
+  `create_time` datetime NOT NULL COMMENT '入链时间(UTC)',
+  PRIMARY KEY (`seq`) USING BTREE,
+  UNIQUE INDEX `prev_hash`(`prev_hash`) USING BTREE,
+  INDEX `entity`(`entity`, `entity_id`, `seq`) USING BTREE
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = Dynamic;
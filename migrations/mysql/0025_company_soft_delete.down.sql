@@ -0,0 +1,4 @@
+ALTER TABLE `company`
+  DROP INDEX `deleted_at`,
+  DROP COLUMN `deleted_at`,
+  DROP COLUMN `deleted_by`;
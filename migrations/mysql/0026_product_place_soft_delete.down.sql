@@ -0,0 +1,4 @@
+ALTER TABLE `product_place`
+  DROP INDEX `deleted_at`,
+  DROP COLUMN `deleted_at`,
+  DROP COLUMN `deleted_by`;
@@ -0,0 +1,6 @@
+-- 生产地改为软删除：删除后仍保留记录，供历史溯源查询
+
+ALTER TABLE `product_place`
+  ADD COLUMN `deleted_at` datetime NULL DEFAULT NULL COMMENT '删除时间，为空表示未删除' AFTER `pp_phone`,
+  ADD COLUMN `deleted_by` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '删除人用户名' AFTER `deleted_at`,
+  ADD INDEX `deleted_at`(`deleted_at`) USING BTREE;
@@ -0,0 +1,4 @@
+ALTER TABLE `sale_place`
+  DROP INDEX `deleted_at`,
+  DROP COLUMN `deleted_at`,
+  DROP COLUMN `deleted_by`;
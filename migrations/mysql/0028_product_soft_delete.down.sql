@@ -0,0 +1,4 @@
+ALTER TABLE `product`
+  DROP INDEX `deleted_at`,
+  DROP COLUMN `deleted_at`,
+  DROP COLUMN `deleted_by`;
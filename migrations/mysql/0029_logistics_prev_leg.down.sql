@@ -0,0 +1,4 @@
+ALTER TABLE `logistics`
+  DROP FOREIGN KEY `logistics_ibfk_3`,
+  DROP INDEX `prev_log_id`,
+  DROP COLUMN `prev_log_id`;
@@ -0,0 +1,6 @@
+-- 物流运输段记录上一段，销售的溯源链沿销售关联的物流逐段回溯，不再包含同批次发往其他买家的物流
+
+ALTER TABLE `logistics`
+  ADD COLUMN `prev_log_id` int NULL DEFAULT NULL COMMENT '上一运输段的物流信息id，为空表示首段' AFTER `company_id`,
+  ADD INDEX `prev_log_id`(`prev_log_id`) USING BTREE,
+  ADD CONSTRAINT `logistics_ibfk_3` FOREIGN KEY (`prev_log_id`) REFERENCES `logistics` (`log_id`) ON DELETE RESTRICT ON UPDATE RESTRICT;
@@ -0,0 +1,9 @@
+-- 物流公司表(与mysql/0001_create_company.up.sql保持一致)
+
+CREATE TABLE company (
+  com_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  com_name varchar(50) NULL DEFAULT NULL,
+  com_address varchar(50) NULL DEFAULT NULL,
+  com_administrator varchar(20) NULL DEFAULT NULL,
+  com_phone varchar(11) NULL DEFAULT NULL
+);
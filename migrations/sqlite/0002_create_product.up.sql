@@ -0,0 +1,14 @@
+-- 产品表(与mysql/0002_create_product.up.sql保持一致)
+
+CREATE TABLE product (
+  pd_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  pd_name varchar(20) NULL DEFAULT NULL,
+  type varchar(10) NULL DEFAULT NULL,
+  image varchar(255) NULL DEFAULT NULL,
+  pd_description varchar(100) NULL DEFAULT NULL,
+  unit_price decimal(10, 2) NULL DEFAULT NULL,
+  min_temperature decimal(5, 2) NULL DEFAULT NULL,
+  max_temperature decimal(5, 2) NULL DEFAULT NULL,
+  min_humidity decimal(5, 2) NULL DEFAULT NULL,
+  max_humidity decimal(5, 2) NULL DEFAULT NULL
+);
@@ -0,0 +1,8 @@
+-- 生产地表(与mysql/0003_create_product_place.up.sql保持一致)
+
+CREATE TABLE product_place (
+  pp_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  pp_address varchar(50) NULL DEFAULT NULL,
+  pp_administrator varchar(20) NULL DEFAULT NULL,
+  pp_phone varchar(11) NULL DEFAULT NULL
+);
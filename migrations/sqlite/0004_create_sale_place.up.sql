@@ -0,0 +1,8 @@
+-- 销售地表(与mysql/0004_create_sale_place.up.sql保持一致)
+
+CREATE TABLE sale_place (
+  sp_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  sp_address varchar(50) NULL DEFAULT NULL,
+  sp_administrator varchar(20) NULL DEFAULT NULL,
+  sp_phone varchar(11) NULL DEFAULT NULL
+);
@@ -0,0 +1,13 @@
+-- 生产信息表(与mysql/0005_create_product_info.up.sql保持一致)
+
+CREATE TABLE product_info (
+  pi_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  product_id int NULL DEFAULT NULL REFERENCES product (pd_id) ON DELETE RESTRICT,
+  product_place_id int NULL DEFAULT NULL REFERENCES product_place (pp_id) ON DELETE RESTRICT,
+  seed varchar(50) NULL DEFAULT NULL,
+  pi_description varchar(255) NULL DEFAULT NULL,
+  planting_date datetime NULL DEFAULT NULL,
+  harvest_date datetime NULL DEFAULT NULL
+);
+CREATE INDEX product_info_product_id ON product_info (product_id);
+CREATE INDEX product_info_product_place_id ON product_info (product_place_id);
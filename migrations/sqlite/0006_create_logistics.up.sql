@@ -0,0 +1,17 @@
+-- 物流信息表(与mysql/0006_create_logistics.up.sql保持一致)
+
+CREATE TABLE logistics (
+  log_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  product_info_id int NULL DEFAULT NULL REFERENCES product_info (pi_id) ON DELETE RESTRICT,
+  company_id int NULL DEFAULT NULL REFERENCES company (com_id) ON DELETE RESTRICT,
+  start_location varchar(50) NULL DEFAULT NULL,
+  destination varchar(50) NULL DEFAULT NULL,
+  start_time datetime NULL DEFAULT NULL,
+  end_time datetime NULL DEFAULT NULL,
+  status varchar(20) NOT NULL DEFAULT 'created',
+  status_reason varchar(255) NULL DEFAULT NULL,
+  status_time datetime NULL DEFAULT NULL
+);
+CREATE INDEX logistics_product_info_id ON logistics (product_info_id);
+CREATE INDEX logistics_company_id ON logistics (company_id);
+CREATE INDEX logistics_status ON logistics (status);
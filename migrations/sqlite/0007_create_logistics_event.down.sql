@@ -0,0 +1 @@
+DROP TABLE IF EXISTS logistics_event;
@@ -0,0 +1,13 @@
+-- 物流运输事件表(与mysql/0007_create_logistics_event.up.sql保持一致)
+
+CREATE TABLE logistics_event (
+  event_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  log_id int NOT NULL REFERENCES logistics (log_id) ON DELETE CASCADE,
+  event_type varchar(20) NOT NULL,
+  location varchar(50) NULL DEFAULT NULL,
+  company_id int NULL DEFAULT NULL REFERENCES company (com_id) ON DELETE RESTRICT,
+  event_time datetime NOT NULL,
+  remark varchar(255) NULL DEFAULT NULL
+);
+CREATE INDEX logistics_event_log_id ON logistics_event (log_id, event_time);
+CREATE INDEX logistics_event_company_id ON logistics_event (company_id);
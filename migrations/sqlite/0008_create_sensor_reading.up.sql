@@ -0,0 +1,13 @@
+-- 冷链温湿度读数表(与mysql/0008_create_sensor_reading.up.sql保持一致)
+
+CREATE TABLE sensor_reading (
+  reading_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  log_id int NOT NULL REFERENCES logistics (log_id) ON DELETE CASCADE,
+  reading_time datetime NOT NULL,
+  temperature decimal(5, 2) NOT NULL,
+  humidity decimal(5, 2) NULL DEFAULT NULL,
+  latitude decimal(9, 6) NULL DEFAULT NULL,
+  longitude decimal(9, 6) NULL DEFAULT NULL,
+  excursion tinyint(1) NOT NULL DEFAULT 0
+);
+CREATE INDEX sensor_reading_log_id ON sensor_reading (log_id, reading_time);
@@ -0,0 +1,11 @@
+-- 销售信息表(与mysql/0009_create_sale_info.up.sql保持一致)
+
+CREATE TABLE sale_info (
+  si_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  logistics_id int NULL DEFAULT NULL REFERENCES logistics (log_id) ON DELETE RESTRICT,
+  sale_place_id int NULL DEFAULT NULL REFERENCES sale_place (sp_id) ON DELETE RESTRICT,
+  si_description varchar(255) NULL DEFAULT NULL,
+  sale_time datetime NULL DEFAULT NULL
+);
+CREATE INDEX sale_info_logistics_id ON sale_info (logistics_id);
+CREATE INDEX sale_info_sale_place_id ON sale_info (sale_place_id);
@@ -0,0 +1,15 @@
+-- 溯源码表(与mysql/0010_create_trace_code.up.sql保持一致)
+
+CREATE TABLE trace_code (
+  tc_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  code varchar(32) NOT NULL,
+  sale_info_id int NULL DEFAULT NULL REFERENCES sale_info (si_id) ON DELETE RESTRICT,
+  product_info_id int NULL DEFAULT NULL REFERENCES product_info (pi_id) ON DELETE RESTRICT,
+  revoked tinyint(1) NOT NULL DEFAULT 0,
+  create_time datetime NOT NULL,
+  revoke_time datetime NULL DEFAULT NULL,
+  revoke_reason varchar(255) NULL DEFAULT NULL
+);
+CREATE UNIQUE INDEX trace_code_code ON trace_code (code);
+CREATE INDEX trace_code_sale_info_id ON trace_code (sale_info_id);
+CREATE INDEX trace_code_product_info_id ON trace_code (product_info_id);
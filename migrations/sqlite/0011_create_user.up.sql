@@ -0,0 +1,18 @@
+-- 用户表(与mysql/0011_create_user.up.sql保持一致)
+
+CREATE TABLE user (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  username varchar(30) NOT NULL,
+  password varchar(255) NOT NULL,
+  sex varchar(2) NULL DEFAULT NULL,
+  name varchar(30) NULL DEFAULT NULL,
+  phone varchar(11) NULL DEFAULT NULL,
+  role varchar(20) NOT NULL DEFAULT '',
+  company_id int NULL DEFAULT NULL REFERENCES company (com_id) ON DELETE SET NULL,
+  product_place_id int NULL DEFAULT NULL REFERENCES product_place (pp_id) ON DELETE SET NULL,
+  sale_place_id int NULL DEFAULT NULL REFERENCES sale_place (sp_id) ON DELETE SET NULL,
+  token_version int NOT NULL DEFAULT 0
+);
+CREATE INDEX user_company_id ON user (company_id);
+CREATE INDEX user_product_place_id ON user (product_place_id);
+CREATE INDEX user_sale_place_id ON user (sale_place_id);
@@ -0,0 +1,14 @@
+-- 刷新令牌表(与mysql/0012_create_refresh_token.up.sql保持一致)
+
+CREATE TABLE refresh_token (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id int NOT NULL REFERENCES user (id) ON DELETE CASCADE,
+  token_hash char(64) NOT NULL,
+  access_jti varchar(36) NOT NULL,
+  expires_at datetime NOT NULL,
+  revoked_at datetime NULL DEFAULT NULL,
+  created_at datetime NOT NULL
+);
+CREATE UNIQUE INDEX refresh_token_token_hash ON refresh_token (token_hash);
+CREATE INDEX refresh_token_user_id ON refresh_token (user_id);
+CREATE INDEX refresh_token_access_jti ON refresh_token (access_jti);
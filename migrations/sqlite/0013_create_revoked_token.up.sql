@@ -0,0 +1,8 @@
+-- 已吊销的访问令牌表(与mysql/0013_create_revoked_token.up.sql保持一致)
+
+CREATE TABLE revoked_token (
+  jti varchar(36) NOT NULL PRIMARY KEY,
+  user_id int NOT NULL,
+  expires_at datetime NOT NULL
+);
+CREATE INDEX revoked_token_expires_at ON revoked_token (expires_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS product_info_batch_no;
+ALTER TABLE product_info DROP COLUMN batch_no;
+ALTER TABLE product_info DROP COLUMN quantity;
+ALTER TABLE product_info DROP COLUMN unit;
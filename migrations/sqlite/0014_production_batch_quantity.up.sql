@@ -0,0 +1,6 @@
+-- 生产信息增加批次号、批次数量及数量单位(与mysql/0014_production_batch_quantity.up.sql保持一致)
+
+ALTER TABLE product_info ADD COLUMN batch_no varchar(32) NULL DEFAULT NULL;
+ALTER TABLE product_info ADD COLUMN quantity decimal(12, 3) NOT NULL DEFAULT 0;
+ALTER TABLE product_info ADD COLUMN unit varchar(10) NOT NULL DEFAULT 'kg';
+CREATE UNIQUE INDEX product_info_batch_no ON product_info (batch_no);
@@ -0,0 +1 @@
+-- 批次号随生产信息的批次数量字段一并回滚，无需处理
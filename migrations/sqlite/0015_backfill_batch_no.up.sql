@@ -0,0 +1,3 @@
+-- 已有生产信息按ID生成批次号(与mysql/0015_backfill_batch_no.up.sql保持一致)
+
+UPDATE product_info SET batch_no = 'PI' || substr('00000000' || pi_id, -8) WHERE batch_no IS NULL;
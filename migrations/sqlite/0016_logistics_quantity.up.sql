@@ -0,0 +1,3 @@
+-- 物流信息增加本次运输分配的批次数量(与mysql/0016_logistics_quantity.up.sql保持一致)
+
+ALTER TABLE logistics ADD COLUMN quantity decimal(12, 3) NULL DEFAULT NULL;
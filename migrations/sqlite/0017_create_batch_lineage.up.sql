@@ -0,0 +1,12 @@
+-- 批次拆分/合并谱系(与mysql/0017_create_batch_lineage.up.sql保持一致)
+
+CREATE TABLE batch_lineage (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  parent_id int NOT NULL REFERENCES product_info (pi_id) ON DELETE RESTRICT,
+  child_id int NOT NULL REFERENCES product_info (pi_id) ON DELETE RESTRICT,
+  kind varchar(10) NOT NULL,
+  quantity decimal(12, 3) NOT NULL,
+  create_time datetime NOT NULL
+);
+CREATE INDEX batch_lineage_parent_id ON batch_lineage (parent_id);
+CREATE INDEX batch_lineage_child_id ON batch_lineage (child_id);
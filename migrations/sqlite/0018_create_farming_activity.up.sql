@@ -0,0 +1,15 @@
+-- 农事活动记录(与mysql/0018_create_farming_activity.up.sql保持一致)
+
+CREATE TABLE farming_activity (
+  fa_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  product_info_id int NOT NULL REFERENCES product_info (pi_id) ON DELETE CASCADE,
+  activity_type varchar(20) NOT NULL,
+  material varchar(100) NULL DEFAULT NULL,
+  dosage decimal(12, 3) NULL DEFAULT NULL,
+  dosage_unit varchar(20) NULL DEFAULT NULL,
+  operator varchar(20) NULL DEFAULT NULL,
+  activity_date datetime NOT NULL,
+  withholding_days int NULL DEFAULT NULL,
+  remark varchar(255) NULL DEFAULT NULL
+);
+CREATE INDEX farming_activity_product_info_id ON farming_activity (product_info_id, activity_date);
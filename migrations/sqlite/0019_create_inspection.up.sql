@@ -0,0 +1,16 @@
+-- 质量检测记录(与mysql/0019_create_inspection.up.sql保持一致)
+
+CREATE TABLE inspection (
+  ins_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  product_info_id int NOT NULL REFERENCES product_info (pi_id) ON DELETE RESTRICT,
+  logistics_id int NULL DEFAULT NULL REFERENCES logistics (log_id) ON DELETE RESTRICT,
+  inspection_type varchar(20) NOT NULL,
+  lab_name varchar(100) NOT NULL,
+  report_no varchar(50) NULL DEFAULT NULL,
+  inspection_date datetime NOT NULL,
+  verdict varchar(10) NOT NULL,
+  report_url varchar(255) NULL DEFAULT NULL,
+  remark varchar(255) NULL DEFAULT NULL
+);
+CREATE INDEX inspection_product_info_id ON inspection (product_info_id, inspection_date);
+CREATE INDEX inspection_logistics_id ON inspection (logistics_id);
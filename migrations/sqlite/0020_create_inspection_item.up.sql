@@ -0,0 +1,14 @@
+-- 质量检测的检测项目(与mysql/0020_create_inspection_item.up.sql保持一致)
+
+CREATE TABLE inspection_item (
+  item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  ins_id int NOT NULL REFERENCES inspection (ins_id) ON DELETE CASCADE,
+  item_name varchar(50) NOT NULL,
+  value decimal(14, 4) NULL DEFAULT NULL,
+  result_text varchar(50) NULL DEFAULT NULL,
+  unit varchar(20) NULL DEFAULT NULL,
+  limit_min decimal(14, 4) NULL DEFAULT NULL,
+  limit_max decimal(14, 4) NULL DEFAULT NULL,
+  passed tinyint(1) NOT NULL
+);
+CREATE INDEX inspection_item_ins_id ON inspection_item (ins_id);
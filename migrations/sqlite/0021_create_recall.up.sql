@@ -0,0 +1,18 @@
+-- 产品召回(与mysql/0021_create_recall.up.sql保持一致)
+
+CREATE TABLE recall (
+  rc_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  product_info_id int NULL DEFAULT NULL REFERENCES product_info (pi_id) ON DELETE RESTRICT,
+  product_id int NULL DEFAULT NULL REFERENCES product (pd_id) ON DELETE RESTRICT,
+  inspection_id int NULL DEFAULT NULL REFERENCES inspection (ins_id) ON DELETE SET NULL,
+  reason varchar(255) NOT NULL,
+  status varchar(20) NOT NULL,
+  opened_by varchar(50) NULL DEFAULT NULL,
+  open_time datetime NOT NULL,
+  close_time datetime NULL DEFAULT NULL,
+  close_remark varchar(255) NULL DEFAULT NULL
+);
+CREATE INDEX recall_product_info_id ON recall (product_info_id);
+CREATE INDEX recall_product_id ON recall (product_id);
+CREATE INDEX recall_inspection_id ON recall (inspection_id);
+CREATE INDEX recall_status ON recall (status, open_time);
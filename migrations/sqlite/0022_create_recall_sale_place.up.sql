@@ -0,0 +1,12 @@
+-- 各销售地的召回处理状态(与mysql/0022_create_recall_sale_place.up.sql保持一致)
+
+CREATE TABLE recall_sale_place (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  rc_id int NOT NULL REFERENCES recall (rc_id) ON DELETE CASCADE,
+  sale_place_id int NOT NULL REFERENCES sale_place (sp_id) ON DELETE RESTRICT,
+  status varchar(20) NOT NULL,
+  remark varchar(255) NULL DEFAULT NULL,
+  update_time datetime NOT NULL
+);
+CREATE UNIQUE INDEX recall_sale_place_rc_sale_place ON recall_sale_place (rc_id, sale_place_id);
+CREATE INDEX recall_sale_place_sale_place_id ON recall_sale_place (sale_place_id);
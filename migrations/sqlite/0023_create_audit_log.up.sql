@@ -0,0 +1,16 @@
+-- 审计日志：记录业务数据每次变更的操作人、动作及变更前后的快照(与mysql/0023_create_audit_log.up.sql保持一致)
+
+CREATE TABLE audit_log (
+  al_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  actor_id int NULL DEFAULT NULL,
+  actor varchar(50) NOT NULL DEFAULT '',
+  entity varchar(50) NOT NULL,
+  entity_id int NOT NULL,
+  action varchar(20) NOT NULL,
+  before_json text NULL,
+  after_json text NULL,
+  create_time datetime NOT NULL
+);
+CREATE INDEX audit_log_entity ON audit_log (entity, entity_id);
+CREATE INDEX audit_log_actor ON audit_log (actor, create_time);
+CREATE INDEX audit_log_create_time ON audit_log (create_time);
@@ -0,0 +1,15 @@
+-- 哈希链：生产、物流、销售记录每次提交的变更按顺序追加，每个条目包含前一条目的哈希(与mysql/0024_create_hash_chain.up.sql保持一致)
+
+CREATE TABLE hash_chain (
+  seq int NOT NULL PRIMARY KEY,
+  entity varchar(50) NOT NULL,
+  entity_id int NOT NULL,
+  action varchar(20) NOT NULL,
+  payload text NULL,
+  record_hash char(64) NOT NULL,
+  prev_hash char(64) NOT NULL DEFAULT '',
+  hash char(64) NOT NULL,
+  create_time datetime NOT NULL
+);
+CREATE UNIQUE INDEX hash_chain_prev_hash ON hash_chain (prev_hash);
+CREATE INDEX hash_chain_entity ON hash_chain (entity, entity_id, seq);
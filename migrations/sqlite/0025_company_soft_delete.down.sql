@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS company_deleted_at;
+ALTER TABLE company DROP COLUMN deleted_at;
+ALTER TABLE company DROP COLUMN deleted_by;
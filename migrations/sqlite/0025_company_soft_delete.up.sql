@@ -0,0 +1,5 @@
+-- 物流公司改为软删除：删除后仍保留记录，供历史溯源查询(与mysql/0025_company_soft_delete.up.sql保持一致)
+
+ALTER TABLE company ADD COLUMN deleted_at datetime NULL DEFAULT NULL;
+ALTER TABLE company ADD COLUMN deleted_by varchar(50) NULL DEFAULT NULL;
+CREATE INDEX company_deleted_at ON company (deleted_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS product_place_deleted_at;
+ALTER TABLE product_place DROP COLUMN deleted_at;
+ALTER TABLE product_place DROP COLUMN deleted_by;
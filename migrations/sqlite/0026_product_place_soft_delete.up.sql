@@ -0,0 +1,5 @@
+-- 生产地改为软删除：删除后仍保留记录，供历史溯源查询(与mysql/0026_product_place_soft_delete.up.sql保持一致)
+
+ALTER TABLE product_place ADD COLUMN deleted_at datetime NULL DEFAULT NULL;
+ALTER TABLE product_place ADD COLUMN deleted_by varchar(50) NULL DEFAULT NULL;
+CREATE INDEX product_place_deleted_at ON product_place (deleted_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS sale_place_deleted_at;
+ALTER TABLE sale_place DROP COLUMN deleted_at;
+ALTER TABLE sale_place DROP COLUMN deleted_by;
@@ -0,0 +1,5 @@
+-- 销售地改为软删除：删除后仍保留记录，供历史溯源查询(与mysql/0027_sale_place_soft_delete.up.sql保持一致)
+
+ALTER TABLE sale_place ADD COLUMN deleted_at datetime NULL DEFAULT NULL;
+ALTER TABLE sale_place ADD COLUMN deleted_by varchar(50) NULL DEFAULT NULL;
+CREATE INDEX sale_place_deleted_at ON sale_place (deleted_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS logistics_prev_log_id;
+ALTER TABLE logistics DROP COLUMN prev_log_id;
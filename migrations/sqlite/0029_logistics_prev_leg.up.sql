@@ -0,0 +1,4 @@
+-- 物流运输段记录上一段，销售的溯源链沿销售关联的物流逐段回溯(与mysql/0029_logistics_prev_leg.up.sql保持一致)
+
+ALTER TABLE logistics ADD COLUMN prev_log_id int NULL DEFAULT NULL REFERENCES logistics (log_id) ON DELETE RESTRICT;
+CREATE INDEX logistics_prev_log_id ON logistics (prev_log_id);
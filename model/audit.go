@@ -0,0 +1,61 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 审计日志记录的实体，取对应的数据表名
+const (
+	AuditEntityUser            = "user"
+	AuditEntityProduct         = "product"
+	AuditEntityProductionPlace = "product_place"
+	AuditEntityProduction      = "product_info"
+	AuditEntityFarmingActivity = "farming_activity"
+	AuditEntityInspection      = "inspection"
+	AuditEntityRecall          = "recall"
+	AuditEntityCompany         = "company"
+	AuditEntityLogistics       = "logistics"
+	AuditEntityLogisticsEvent  = "logistics_event"
+	AuditEntitySensorReading   = "sensor_reading"
+	AuditEntitySalePlace       = "sale_place"
+	AuditEntitySaleInfo        = "sale_info"
+	AuditEntityTraceCode       = "trace_code"
+)
+
+// 审计日志记录的操作
+const (
+	AuditActionCreate  = "create"  // 新增
+	AuditActionUpdate  = "update"  // 修改(含状态变更)
+	AuditActionDelete  = "delete"  // 删除
+	AuditActionSplit   = "split"   // 拆分批次
+	AuditActionMerge   = "merge"   // 合并批次
+	AuditActionImport  = "import"  // 批量导入
+	AuditActionRestore = "restore" // 恢复已删除的记录
+)
+
+// AuditLog 一次业务数据变更的审计记录
+// Before/After为变更前后的数据快照(JSON)，新增时Before为空，删除时After为空
+type AuditLog struct {
+	ID         int             `json:"alId"`
+	ActorID    int             `json:"actorId"` // 0表示系统内部操作
+	Actor      string          `json:"actor"`
+	Entity     string          `json:"entity"`
+	EntityID   int             `json:"entityId"`
+	Action     string          `json:"action"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreateTime time.Time       `json:"createTime"`
+}
+
+// AuditLogPageQueryDTO 审计日志分页查询DTO，时间范围为[Start, End)
+type AuditLogPageQueryDTO struct {
+	Page     int        `json:"page"`
+	Size     int        `json:"size"`
+	Entity   string     `json:"entity"`
+	EntityID int        `json:"entityId"`
+	Actor    string     `json:"actor"`
+	Action   string     `json:"action"`
+	Start    *time.Time `json:"start"`
+	End      *time.Time `json:"end"`
+}
@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// 批次谱系类型
+const (
+	BatchLineageSplit = "split" // 拆分：一个批次拆成多个批次
+	BatchLineageMerge = "merge" // 合并：多个批次合成一个批次
+)
+
+// DefaultBatchUnit 默认的批次数量单位
+const DefaultBatchUnit = "kg"
+
+// quantityTolerance 数量比较的容差(数据库保留3位小数)
+const quantityTolerance = 1e-6
+
+// BatchLineage 批次谱系，记录数量从来源批次转入产出批次
+type BatchLineage struct {
+	ID         int       `json:"id"`
+	ParentID   int       `json:"parentId"` // 来源批次(生产信息ID)
+	ChildID    int       `json:"childId"`  // 产出批次(生产信息ID)
+	Kind       string    `json:"kind"`     // split/merge
+	Quantity   float64   `json:"quantity"` // 转入数量
+	CreateTime time.Time `json:"createTime"`
+}
+
+// BatchUsage 批次数量使用情况
+type BatchUsage struct {
+	Quantity  float64 `json:"quantity"`  // 批次数量
+	Allocated float64 `json:"allocated"` // 已分配给物流的数量(不含已取消、已拒收的物流)
+	Derived   float64 `json:"derived"`   // 已拆分或合并到其他批次的数量
+}
+
+// Used 已使用数量
+func (u *BatchUsage) Used() float64 {
+	return u.Allocated + u.Derived
+}
+
+// Available 剩余可分配数量
+func (u *BatchUsage) Available() float64 {
+	return u.Quantity - u.Used()
+}
+
+// CanUse 剩余数量是否足够
+func (u *BatchUsage) CanUse(quantity float64) bool {
+	return quantity <= u.Available()+quantityTolerance
+}
+
+// CanResize 批次数量调整为quantity后是否仍不少于已使用数量
+func (u *BatchUsage) CanResize(quantity float64) bool {
+	return u.Used() <= quantity+quantityTolerance
+}
+
+// BatchNode 批次谱系中的一个节点
+type BatchNode struct {
+	ProductInfoID   int          `json:"productInfoId"`
+	BatchNo         string       `json:"batchNo"`
+	ProductName     string       `json:"pdName"`
+	ProductionPlace string       `json:"ppAddress"`
+	Quantity        float64      `json:"quantity"` // 批次数量
+	Unit            string       `json:"unit"`
+	Kind            string       `json:"kind"`               // 与上一层节点的关系(split/merge)
+	LinkQuantity    float64      `json:"linkQuantity"`       // 与上一层节点之间转移的数量
+	Parents         []*BatchNode `json:"parents,omitempty"`  // 来源批次(查询祖先时)
+	Children        []*BatchNode `json:"children,omitempty"` // 产出批次(查询后代时)
+}
+
+// BatchLineageView 批次的完整谱系及数量去向
+type BatchLineageView struct {
+	Batch       *ProductionInfoWithDetails `json:"batch"`
+	Usage       *BatchUsage                `json:"usage"`
+	Available   float64                    `json:"available"`   // 剩余可分配数量
+	Ancestors   []*BatchNode               `json:"ancestors"`   // 来源批次(逐层向上)
+	Descendants []*BatchNode               `json:"descendants"` // 产出批次(逐层向下)
+	Shipments   []*Logistics               `json:"shipments"`   // 分配了数量的物流
+}
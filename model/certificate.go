@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CertificateVersion 溯源证书格式版本
+const CertificateVersion = 1
+
+// CertificateAlgorithm 溯源证书的签名算法
+const CertificateAlgorithm = "Ed25519"
+
+// Certificate 溯源证书：单条销售记录从生产、运输到销售的完整溯源链
+type Certificate struct {
+	Version    int                `json:"version"`
+	SerialNo   string             `json:"serialNo"` // 证书编号
+	Issuer     string             `json:"issuer"`   // 签发方(对外访问地址)
+	IssuedAt   time.Time          `json:"issuedAt"`
+	SaleInfoID int                `json:"siId"`
+	Chain      *TraceabilityChain `json:"chain"`
+}
+
+// SignedCertificate 带分离式签名的溯源证书
+// 签名针对certificate字段的规范化JSON(对象的键按字典序排列、去除空白)，certificate内容任何改动都会导致校验失败
+type SignedCertificate struct {
+	Certificate json.RawMessage      `json:"certificate"`
+	Signature   CertificateSignature `json:"signature"`
+}
+
+// CertificateSignature 证书签名
+type CertificateSignature struct {
+	Algorithm string `json:"algorithm"` // Ed25519
+	KeyID     string `json:"keyId"`     // 签名公钥指纹
+	Value     string `json:"value"`     // Base64编码的签名
+}
+
+// CertificatePublicKey 校验证书所用的公钥
+type CertificatePublicKey struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"keyId"`
+	PublicKey string `json:"publicKey"` // Base64编码的32字节公钥
+	PEM       string `json:"pem"`       // PKIX PEM格式，可直接保存为文件用于命令行校验
+}
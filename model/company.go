@@ -7,4 +7,6 @@ type Company struct {
 	Address       string `json:"comAddress"`
 	Administrator string `json:"comAdministrator"`
 	Phone         string `json:"comPhone"`
+
+	SoftDelete
 }
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// 农事活动类型
+const (
+	FarmingActivityFertilizer = "fertilizer" // 施肥
+	FarmingActivityPesticide  = "pesticide"  // 施药
+	FarmingActivityIrrigation = "irrigation" // 灌溉
+	FarmingActivityOther      = "other"      // 其他(除草、修剪等)
+)
+
+// FarmingActivityTypes 所有合法的农事活动类型
+var FarmingActivityTypes = []string{
+	FarmingActivityFertilizer,
+	FarmingActivityPesticide,
+	FarmingActivityIrrigation,
+	FarmingActivityOther,
+}
+
+// FarmingActivity 农事活动记录
+type FarmingActivity struct {
+	ID              int       `json:"faId"`
+	ProductInfoID   int       `json:"productInfoId"`
+	ActivityType    string    `json:"activityType"`    // 活动类型
+	Material        string    `json:"material"`        // 投入品名称
+	Dosage          *float64  `json:"dosage"`          // 用量
+	DosageUnit      string    `json:"dosageUnit"`      // 用量单位
+	Operator        string    `json:"operator"`        // 操作人
+	ActivityDate    time.Time `json:"activityDate"`    // 作业时间
+	WithholdingDays *int      `json:"withholdingDays"` // 安全间隔期(天)
+	Remark          string    `json:"remark"`          // 备注
+
+	// 关联信息 (用于数据权限校验)
+	ProductPlaceID int `json:"productPlaceId,omitempty"`
+}
+
+// EarliestHarvest 按安全间隔期计算的最早收获日期(按自然日计算)，没有安全间隔期时返回nil
+func (a *FarmingActivity) EarliestHarvest() *time.Time {
+	if a.WithholdingDays == nil || *a.WithholdingDays <= 0 {
+		return nil
+	}
+	y, m, d := a.ActivityDate.Date()
+	t := time.Date(y, m, d+*a.WithholdingDays, 0, 0, 0, 0, a.ActivityDate.Location())
+	return &t
+}
+
+// AllowsHarvest 在harvestDate收获是否满足安全间隔期
+func (a *FarmingActivity) AllowsHarvest(harvestDate time.Time) bool {
+	earliest := a.EarliestHarvest()
+	return earliest == nil || !harvestDate.Before(*earliest)
+}
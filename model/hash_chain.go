@@ -0,0 +1,88 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 纳入哈希链的溯源记录
+const (
+	HashChainProductInfo = "product_info"
+	HashChainLogistics   = "logistics"
+	HashChainSaleInfo    = "sale_info"
+)
+
+// 哈希链条目的操作
+const (
+	HashChainCreate = "create"
+	HashChainUpdate = "update"
+	HashChainDelete = "delete"
+)
+
+// HashChainProof 记录在哈希链中的位置及哈希，随溯源信息返回，供第三方离线校验
+// Hash = SHA-256(Seq|PrevHash|RecordHash|CreateTime)，CreateTime为UTC的RFC3339格式；第一个条目的PrevHash为空
+// 将相邻条目按Seq排列，逐一复算Hash并核对PrevHash即可确认记录入链后未被改写
+type HashChainProof struct {
+	Seq        int       `json:"seq"`
+	RecordHash string    `json:"recordHash"`
+	PrevHash   string    `json:"prevHash"`
+	Hash       string    `json:"hash"`
+	CreateTime time.Time `json:"createTime"`
+}
+
+// ComputeHash 计算条目哈希
+func (p *HashChainProof) ComputeHash() string {
+	return sha256Hex(strings.Join([]string{
+		strconv.Itoa(p.Seq), p.PrevHash, p.RecordHash, p.CreateTime.UTC().Format(time.RFC3339),
+	}, "|"))
+}
+
+// HashChainEntry 哈希链条目：溯源记录每次提交的变更
+// RecordHash = SHA-256(Entity|EntityID|Action|Payload)，Payload为变更后的记录内容(规范化JSON)，删除时为空
+type HashChainEntry struct {
+	HashChainProof
+	Entity   string `json:"entity"`
+	EntityID int    `json:"entityId"`
+	Action   string `json:"action"`
+	Payload  string `json:"payload"`
+}
+
+// ComputeRecordHash 计算记录哈希
+func (e *HashChainEntry) ComputeRecordHash() string {
+	return HashChainRecordHash(e.Entity, e.EntityID, e.Action, e.Payload)
+}
+
+// HashChainRecordHash 计算记录哈希
+func HashChainRecordHash(entity string, entityID int, action, payload string) string {
+	return sha256Hex(strings.Join([]string{entity, strconv.Itoa(entityID), action, payload}, "|"))
+}
+
+// HashChainRef 引用一条溯源记录
+type HashChainRef struct {
+	Entity   string `json:"entity"`
+	EntityID int    `json:"entityId"`
+}
+
+// HashChainVerification 哈希链校验结果
+type HashChainVerification struct {
+	Valid  bool            `json:"valid"`
+	Length int             `json:"length"` // 链上条目数
+	Head   string          `json:"head"`   // 最后一个条目的哈希
+	Broken *HashChainBreak `json:"broken"` // 第一个断裂处，链完整时为nil
+}
+
+// HashChainBreak 哈希链断裂处
+type HashChainBreak struct {
+	Seq      int    `json:"seq"` // 断裂的条目，为0表示记录未入链
+	Entity   string `json:"entity"`
+	EntityID int    `json:"entityId"`
+	Reason   string `json:"reason"`
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
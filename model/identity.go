@@ -0,0 +1,101 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// Identity 当前登录用户的身份，以及账号绑定的业务主体(ID为0表示未绑定)
+type Identity struct {
+	UserID         int
+	Username       string
+	Role           string
+	CompanyID      int // 物流公司
+	ProductPlaceID int // 生产地(农场)
+	SalePlaceID    int // 销售地
+
+	// 本次请求使用的访问令牌
+	TokenID        string
+	TokenExpiresAt time.Time
+}
+
+// identityKey 请求上下文中保存身份的键
+type identityKey struct{}
+
+// WithIdentity 将当前用户身份保存到请求上下文
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom 从请求上下文获取当前用户身份，未登录时返回nil
+func IdentityFrom(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
+
+// Actor 发起操作的用户，用于记录审计日志；零值表示系统内部操作
+type Actor struct {
+	UserID   int
+	Username string
+}
+
+// Actor 当前用户作为操作人，未登录(nil)时为系统内部操作
+func (i *Identity) Actor() Actor {
+	if i == nil {
+		return Actor{}
+	}
+	return Actor{UserID: i.UserID, Username: i.Username}
+}
+
+// DataScope 数据权限范围，字段为nil表示该维度不受限制
+type DataScope struct {
+	CompanyID      *int
+	ProductPlaceID *int
+	SalePlaceID    *int
+
+	// Actor 本次操作的发起人，不参与数据权限判断
+	Actor Actor
+}
+
+// Scope 按角色得到数据权限范围：
+// 物流用户限于绑定的公司，农场用户限于绑定的生产地，零售商限于绑定的销售地；
+// 管理员、审计员以及系统内部调用(nil)不受限制
+func (i *Identity) Scope() DataScope {
+	if i == nil {
+		return DataScope{}
+	}
+
+	scope := DataScope{Actor: i.Actor()}
+	switch i.Role {
+	case RoleLogistics:
+		scope.CompanyID = &i.CompanyID
+	case RoleFarmer:
+		scope.ProductPlaceID = &i.ProductPlaceID
+	case RoleRetailer:
+		scope.SalePlaceID = &i.SalePlaceID
+	}
+	return scope
+}
+
+// AllowCompany 公司是否在数据范围内
+func (s DataScope) AllowCompany(id int) bool {
+	return allowScopeID(s.CompanyID, id)
+}
+
+// AllowProductPlace 生产地是否在数据范围内
+func (s DataScope) AllowProductPlace(id int) bool {
+	return allowScopeID(s.ProductPlaceID, id)
+}
+
+// AllowSalePlace 销售地是否在数据范围内
+func (s DataScope) AllowSalePlace(id int) bool {
+	return allowScopeID(s.SalePlaceID, id)
+}
+
+// allowScopeID 未限制时放行；限制时未绑定(0)的账号不能访问任何数据
+func allowScopeID(scope *int, id int) bool {
+	if scope == nil {
+		return true
+	}
+	return *scope > 0 && *scope == id
+}
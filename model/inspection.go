@@ -0,0 +1,85 @@
+package model
+
+import "time"
+
+// 检测类型
+const (
+	InspectionTypeResidue     = "residue"     // 农药残留检测
+	InspectionTypeGrading     = "grading"     // 质量分级
+	InspectionTypeCertificate = "certificate" // 认证证书(有机、绿色食品等)
+	InspectionTypeOther       = "other"       // 其他
+)
+
+// InspectionTypes 所有合法的检测类型
+var InspectionTypes = []string{
+	InspectionTypeResidue,
+	InspectionTypeGrading,
+	InspectionTypeCertificate,
+	InspectionTypeOther,
+}
+
+// 检测结论
+const (
+	InspectionVerdictPass = "pass" // 合格
+	InspectionVerdictFail = "fail" // 不合格
+)
+
+// Inspection 质量检测记录，检测对象为生产批次，运输环节抽检时同时关联物流信息
+type Inspection struct {
+	ID             int               `json:"insId"`
+	ProductInfoID  int               `json:"productInfoId"`
+	LogisticsID    int               `json:"logisticsId"`    // 为0表示不针对具体运输环节
+	InspectionType string            `json:"inspectionType"` // 检测类型
+	LabName        string            `json:"labName"`        // 检测机构
+	ReportNo       string            `json:"reportNo"`       // 报告编号
+	InspectionDate time.Time         `json:"inspectionDate"` // 检测时间
+	Verdict        string            `json:"verdict"`        // 结论
+	ReportURL      string            `json:"reportUrl"`      // 检测报告PDF
+	Remark         string            `json:"remark"`
+	Items          []*InspectionItem `json:"items,omitempty"` // 检测项目
+
+	// 关联信息 (用于展示及数据权限校验)
+	BatchNo        string `json:"batchNo,omitempty"`
+	ProductName    string `json:"pdName,omitempty"`
+	ProductPlaceID int    `json:"productPlaceId,omitempty"`
+	CompanyID      int    `json:"companyId,omitempty"` // 运输环节的承运公司
+}
+
+// InspectionItem 检测项目
+type InspectionItem struct {
+	ID           int      `json:"itemId"`
+	InspectionID int      `json:"insId"`
+	ItemName     string   `json:"itemName"`   // 检测项目，如毒死蜱
+	Value        *float64 `json:"value"`      // 检测值
+	ResultText   string   `json:"resultText"` // 非数值结果，如等级
+	Unit         string   `json:"unit"`       // 单位，如mg/kg
+	LimitMin     *float64 `json:"limitMin"`   // 下限
+	LimitMax     *float64 `json:"limitMax"`   // 上限，如最大残留限量
+	Passed       bool     `json:"passed"`     // 是否合格
+}
+
+// WithinLimits 检测值是否在限值范围内，没有检测值时返回false
+func (i *InspectionItem) WithinLimits() bool {
+	if i.Value == nil {
+		return false
+	}
+	if i.LimitMin != nil && *i.Value < *i.LimitMin {
+		return false
+	}
+	if i.LimitMax != nil && *i.Value > *i.LimitMax {
+		return false
+	}
+	return true
+}
+
+// InspectionPageQueryDTO 检测记录分页查询DTO
+type InspectionPageQueryDTO struct {
+	Page           int    `json:"page"`
+	Size           int    `json:"size"`
+	ProductInfoID  int    `json:"productInfoId"`
+	LogisticsID    int    `json:"logisticsId"`
+	BatchNo        string `json:"batchNo"`
+	InspectionType string `json:"inspectionType"`
+	Verdict        string `json:"verdict"`
+	LabName        string `json:"labName"`
+}
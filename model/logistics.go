@@ -2,21 +2,56 @@ package model
 
 import "time"
 
+// 物流运输状态
+const (
+	LogisticsStatusCreated   = "created"    // 已创建
+	LogisticsStatusInTransit = "in_transit" // 运输中
+	LogisticsStatusDelivered = "delivered"  // 已送达
+	LogisticsStatusRejected  = "rejected"   // 已拒收
+	LogisticsStatusCancelled = "cancelled"  // 已取消
+)
+
+// LogisticsStatusTransitions 允许的状态流转
+var LogisticsStatusTransitions = map[string][]string{
+	LogisticsStatusCreated:   {LogisticsStatusInTransit, LogisticsStatusDelivered, LogisticsStatusCancelled},
+	LogisticsStatusInTransit: {LogisticsStatusDelivered, LogisticsStatusRejected, LogisticsStatusCancelled},
+}
+
 // Logistics 物流信息模型
 type Logistics struct {
 	ID            int        `json:"logId"`
 	ProductInfoID int        `json:"productInfoId"`
 	CompanyID     int        `json:"companyId"`
+	PrevID        *int       `json:"prevLogId"` // 上一运输段的物流ID，为空表示首段
+	Quantity      *float64   `json:"quantity"`  // 分配的批次数量，为空表示未分配(如同一批货物的后续转运)
 	StartLocation string     `json:"startLocation"`
 	Destination   string     `json:"destination"`
 	StartTime     time.Time  `json:"startTime"`
 	EndTime       *time.Time `json:"endTime"`
+	Status        string     `json:"status"`       // 运输状态
+	StatusReason  string     `json:"statusReason"` // 最近一次状态变更原因
+	StatusTime    *time.Time `json:"statusTime"`   // 最近一次状态变更时间
 
 	// 关联信息 (用于展示)
 	ProductName   string `json:"pdName,omitempty"`
 	CompanyName   string `json:"comName,omitempty"`
 	Administrator string `json:"comAdministrator,omitempty"`
 	Phone         string `json:"comPhone,omitempty"`
+
+	// 所属生产地ID(用于数据权限校验)
+	ProductPlaceID int `json:"productPlaceId,omitempty"`
+
+	// 销售信息关联的销售地ID(用于零售商的数据权限校验，仅GetByID加载)
+	SalePlaceIDs []int `json:"-"`
+
+	// 运输事件时间线(按发生时间排序)
+	Events []*LogisticsEvent `json:"events,omitempty"`
+
+	// 冷链温湿度汇总
+	ColdChain *ColdChainSummary `json:"coldChain,omitempty"`
+
+	// 哈希链位置(仅溯源查询返回)
+	Proof *HashChainProof `json:"proof,omitempty"`
 }
 
 // LogisticsPageQueryDTO 物流分页查询DTO
@@ -30,6 +65,7 @@ type LogisticsPageQueryDTO struct {
 	Destination   string `json:"destination"`
 	Administrator string `json:"comAdministrator"`
 	StartTime     string `json:"startTime"`
+	Status        string `json:"status"`
 }
 
 // LogisticsPageResult 物流分页查询结果
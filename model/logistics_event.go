@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// 物流事件类型
+const (
+	LogisticsEventPickup     = "pickup"      // 揽收
+	LogisticsEventHubArrival = "hub_arrival" // 到达中转站
+	LogisticsEventDeparture  = "departure"   // 离开中转站
+	LogisticsEventHandover   = "handover"    // 交接给其他物流公司
+	LogisticsEventDelivered  = "delivered"   // 送达(终止事件)
+)
+
+// LogisticsEventTypes 所有合法的物流事件类型
+var LogisticsEventTypes = []string{
+	LogisticsEventPickup,
+	LogisticsEventHubArrival,
+	LogisticsEventDeparture,
+	LogisticsEventHandover,
+	LogisticsEventDelivered,
+}
+
+// LogisticsEvent 物流运输事件
+type LogisticsEvent struct {
+	ID          int       `json:"eventId"`
+	LogisticsID int       `json:"logId"`
+	EventType   string    `json:"eventType"` // 事件类型
+	Location    string    `json:"location"`  // 发生地点
+	CompanyID   int       `json:"companyId"` // 经手公司(交接事件为接收方公司)
+	EventTime   time.Time `json:"eventTime"` // 发生时间
+	Remark      string    `json:"remark"`    // 备注
+
+	// 关联信息 (用于展示)
+	CompanyName string `json:"comName,omitempty"`
+}
@@ -10,4 +10,22 @@ type Product struct {
 	Image       string          `json:"image"`
 	Description string          `json:"pdDescription"`
 	UnitPrice   sql.NullFloat64 `json:"unitPrice"`
+
+	// 冷链温湿度阈值(为空表示不限制)
+	MinTemperature *float64 `json:"minTemperature"`
+	MaxTemperature *float64 `json:"maxTemperature"`
+	MinHumidity    *float64 `json:"minHumidity"`
+	MaxHumidity    *float64 `json:"maxHumidity"`
+
+	SoftDelete
+}
+
+// Thresholds 获取产品的冷链阈值
+func (p *Product) Thresholds() *ColdChainThresholds {
+	return &ColdChainThresholds{
+		MinTemperature: p.MinTemperature,
+		MaxTemperature: p.MaxTemperature,
+		MinHumidity:    p.MinHumidity,
+		MaxHumidity:    p.MaxHumidity,
+	}
 }
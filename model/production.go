@@ -5,12 +5,15 @@ import "time"
 // ProductionInfo 生产信息实体
 type ProductionInfo struct {
 	ID             int       `json:"piId"`            // 生产信息ID
+	BatchNo        string    `json:"batchNo"`         // 批次号
 	ProductID      int       `json:"productId"`       // 产品ID
 	ProductPlaceID int       `json:"productPlaceId"`  // 生产地ID
 	SeedSource     string    `json:"seed"`            // 种子来源
 	Description    string    `json:"piDescription"`   // 生产描述
 	PlantingDate   time.Time `json:"plantingDate"`    // 播种时间
 	HarvestDate    time.Time `json:"harvestDate"`     // 收获时间
+	Quantity       float64   `json:"quantity"`        // 批次数量
+	Unit           string    `json:"unit"`            // 数量单位
 	Administrator  string    `json:"ppAdministrator"` // 负责人
 	Phone          string    `json:"ppPhone"`         // 联系方式
 }
@@ -20,4 +23,6 @@ type ProductionInfoWithDetails struct {
 	ProductionInfo
 	ProductName     string `json:"pdName"`    // 产品名称
 	ProductionPlace string `json:"ppAddress"` // 生产地地址
+
+	Proof *HashChainProof `json:"proof,omitempty"` // 哈希链位置(仅溯源查询返回)
 }
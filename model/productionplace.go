@@ -6,4 +6,6 @@ type ProductionPlace struct {
 	Address       string `json:"ppAddress"`       // 生产地地址
 	Administrator string `json:"ppAdministrator"` // 负责人
 	Phone         string `json:"ppPhone"`         // 联系电话
+
+	SoftDelete
 }
@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+// 召回状态
+const (
+	RecallStatusOpen   = "open"   // 召回中
+	RecallStatusClosed = "closed" // 已结束
+)
+
+// 销售地的召回处理状态
+const (
+	RecallSalePlacePending   = "pending"   // 待通知
+	RecallSalePlaceNotified  = "notified"  // 已通知
+	RecallSalePlaceCompleted = "completed" // 已下架/退回
+)
+
+// RecallSalePlaceStatuses 所有合法的销售地处理状态
+var RecallSalePlaceStatuses = []string{
+	RecallSalePlacePending,
+	RecallSalePlaceNotified,
+	RecallSalePlaceCompleted,
+}
+
+// Recall 产品召回，召回对象为生产批次(含其拆分/合并产出的批次)或产品的全部批次
+type Recall struct {
+	ID            int        `json:"rcId"`
+	ProductInfoID int        `json:"productInfoId"` // 为0表示按产品召回
+	ProductID     int        `json:"productId"`     // 按批次召回时为批次所属产品
+	InspectionID  int        `json:"inspectionId"`  // 触发召回的检测记录，为0表示无
+	Reason        string     `json:"reason"`
+	Status        string     `json:"status"`
+	OpenedBy      string     `json:"openedBy"`
+	OpenTime      time.Time  `json:"openTime"`
+	CloseTime     *time.Time `json:"closeTime"`
+	CloseRemark   string     `json:"closeRemark"`
+
+	// 关联信息 (用于展示及数据权限校验)
+	BatchNo        string `json:"batchNo,omitempty"`
+	ProductName    string `json:"pdName,omitempty"`
+	ProductPlaceID int    `json:"productPlaceId,omitempty"`
+}
+
+// RecallSalePlace 收到召回批次的销售地及其处理状态
+type RecallSalePlace struct {
+	RecallID    int        `json:"rcId"`
+	SalePlaceID int        `json:"salePlaceId"`
+	Status      string     `json:"status"`
+	Remark      string     `json:"remark"`
+	UpdateTime  *time.Time `json:"updateTime"`
+
+	// 销售地联系方式
+	Address       string `json:"spAddress"`
+	Administrator string `json:"spAdministrator"`
+	Phone         string `json:"spPhone"`
+
+	SaleCount int `json:"saleCount"` // 该销售地涉及的销售记录数
+}
+
+// RecallImpact 召回的影响范围：受影响批次及其下游物流、销售记录和销售地
+type RecallImpact struct {
+	Recall     *Recall            `json:"recall"`
+	Batches    []*BatchNode       `json:"batches"`    // 受影响的批次
+	Logistics  []*Logistics       `json:"logistics"`  // 运输受影响批次的物流(不含已取消)
+	Sales      []*SaleInfoVO      `json:"sales"`      // 经上述物流送达的销售记录
+	SalePlaces []*RecallSalePlace `json:"salePlaces"` // 销售地联系方式及处理状态
+}
+
+// RecallPageQueryDTO 召回分页查询DTO
+type RecallPageQueryDTO struct {
+	Page          int    `json:"page"`
+	Size          int    `json:"size"`
+	ProductInfoID int    `json:"productInfoId"`
+	ProductID     int    `json:"productId"`
+	BatchNo       string `json:"batchNo"`
+	Status        string `json:"status"`
+}
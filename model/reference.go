@@ -0,0 +1,64 @@
+package model
+
+import "fmt"
+
+// EntityBatchLineage 批次拆分/合并谱系(数据表名)，其余实体见AuditEntity*
+const EntityBatchLineage = "batch_lineage"
+
+// EntityNames 实体(数据表名)的名称，用于引用冲突的提示
+var EntityNames = map[string]string{
+	AuditEntityCompany:         "物流公司",
+	AuditEntityProduct:         "产品",
+	AuditEntityProductionPlace: "生产地",
+	AuditEntitySalePlace:       "销售地",
+	AuditEntityProduction:      "生产信息",
+	AuditEntityLogistics:       "物流信息",
+	AuditEntityLogisticsEvent:  "物流事件",
+	AuditEntitySaleInfo:        "销售信息",
+	AuditEntityTraceCode:       "溯源码",
+	AuditEntityInspection:      "质量检测记录",
+	AuditEntityRecall:          "召回记录",
+	EntityBatchLineage:         "批次拆分/合并记录",
+}
+
+// BlockingReference 阻止删除的引用：Count条Entity记录引用了被删除的记录
+type BlockingReference struct {
+	Entity  string `json:"entity"`  // 引用方实体(数据表名)
+	Count   int    `json:"count"`   // 引用的记录数
+	IDs     []int  `json:"ids"`     // 引用记录的ID，最多列出前若干个
+	Message string `json:"message"` // 如"3条物流信息引用了该物流公司"
+}
+
+// NewBlockingReference 创建阻止删除的引用，target为被删除记录的实体
+func NewBlockingReference(entity string, count int, ids []int, target string) *BlockingReference {
+	return &BlockingReference{
+		Entity:  entity,
+		Count:   count,
+		IDs:     ids,
+		Message: fmt.Sprintf("%d条%s引用了该%s", count, EntityNames[entity], EntityNames[target]),
+	}
+}
+
+// InvalidReference 保存时无效的引用：引用的记录不存在或已删除
+type InvalidReference struct {
+	Field   string `json:"field"`   // 请求中的字段，如productInfoId
+	Entity  string `json:"entity"`  // 被引用的实体(数据表名)
+	ID      int    `json:"id"`      // 被引用的记录ID
+	Deleted bool   `json:"deleted"` // true表示记录已删除，false表示不存在
+	Message string `json:"message"` // 如"物流公司(ID 3)已删除"
+}
+
+// NewInvalidReference 创建无效的引用
+func NewInvalidReference(field, entity string, id int, deleted bool) *InvalidReference {
+	state := "不存在"
+	if deleted {
+		state = "已删除"
+	}
+	return &InvalidReference{
+		Field:   field,
+		Entity:  entity,
+		ID:      id,
+		Deleted: deleted,
+		Message: fmt.Sprintf("%s(ID %d)%s", EntityNames[entity], id, state),
+	}
+}
@@ -0,0 +1,23 @@
+package model
+
+// 用户角色
+const (
+	RoleAdmin     = "admin"     // 管理员
+	RoleFarmer    = "farmer"    // 农场操作员
+	RoleLogistics = "logistics" // 物流公司
+	RoleRetailer  = "retailer"  // 零售商
+	RoleAuditor   = "auditor"   // 审计员(只读)
+)
+
+// Roles 所有合法角色
+var Roles = []string{RoleAdmin, RoleFarmer, RoleLogistics, RoleRetailer, RoleAuditor}
+
+// IsValidRole 校验角色是否合法
+func IsValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
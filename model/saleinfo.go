@@ -23,6 +23,8 @@ type SaleInfoVO struct {
 	Administrator string    `json:"spAdministrator"` // 销售地负责人
 	StartLocation string    `json:"startLocation"`   // 物流起始地
 	Destination   string    `json:"destination"`     // 物流目的地
+
+	Proof *HashChainProof `json:"proof,omitempty"` // 哈希链位置(仅溯源查询返回)
 }
 
 // SaleInfoPageQuery 分页查询参数
@@ -34,3 +36,59 @@ type SaleInfoPageQuery struct {
 	SalePlace   string    `json:"salePlace"`   // 销售地
 	SaleTime    time.Time `json:"saleTime"`    // 销售时间
 }
+
+// SaleSupply 销售记录的上游来源(销售 -> 物流 -> 生产 -> 生产地/产品)
+type SaleSupply struct {
+	SaleInfoID     int
+	LogisticsID    int
+	CompanyID      int
+	CompanyName    string
+	CompanyPhone   string
+	ProductInfoID  int
+	ProductPlaceID int
+	PPAddress      string
+	PPAdmin        string
+	PPPhone        string
+	ProductID      int
+	ProductName    string
+	ProductType    string
+}
+
+// ReverseTrace 销售地在时间段内的反向溯源：供货的生产地、产品及物流公司
+type ReverseTrace struct {
+	SalePlaceID      int                            `json:"salePlaceId"`
+	Start            time.Time                      `json:"start"`
+	End              time.Time                      `json:"end"`
+	SaleCount        int                            `json:"saleCount"` // 时间段内的销售记录数
+	ProductionPlaces []*ReverseTraceProductionPlace `json:"productionPlaces"`
+	Products         []*ReverseTraceProduct         `json:"products"`
+	Companies        []*ReverseTraceCompany         `json:"companies"`
+}
+
+// ReverseTraceProductionPlace 反向溯源-供货生产地
+type ReverseTraceProductionPlace struct {
+	ProductPlaceID int    `json:"ppId"`
+	Address        string `json:"ppAddress"`
+	Administrator  string `json:"ppAdministrator"`
+	Phone          string `json:"ppPhone"`
+	SaleCount      int    `json:"saleCount"`  // 涉及的销售记录数
+	BatchCount     int    `json:"batchCount"` // 涉及的生产批次数
+}
+
+// ReverseTraceProduct 反向溯源-产品
+type ReverseTraceProduct struct {
+	ProductID  int    `json:"pdId"`
+	Name       string `json:"pdName"`
+	Type       string `json:"type"`
+	SaleCount  int    `json:"saleCount"`
+	BatchCount int    `json:"batchCount"`
+}
+
+// ReverseTraceCompany 反向溯源-物流公司
+type ReverseTraceCompany struct {
+	CompanyID      int    `json:"comId"`
+	Name           string `json:"comName"`
+	Phone          string `json:"comPhone"`
+	SaleCount      int    `json:"saleCount"`
+	LogisticsCount int    `json:"logisticsCount"` // 涉及的物流记录数
+}
@@ -6,4 +6,6 @@ type SalePlace struct {
 	Address       string `json:"spAddress"`
 	Administrator string `json:"spAdministrator"`
 	Phone         string `json:"spPhone"`
+
+	SoftDelete
 }
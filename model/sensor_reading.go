@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// SensorReading 冷链传感器读数
+type SensorReading struct {
+	ID          int64     `json:"readingId"`
+	LogisticsID int       `json:"logId"`
+	ReadingTime time.Time `json:"time"`        // 采集时间
+	Temperature float64   `json:"temperature"` // 温度(℃)
+	Humidity    *float64  `json:"humidity"`    // 相对湿度(%)
+	Latitude    *float64  `json:"latitude"`    // 纬度
+	Longitude   *float64  `json:"longitude"`   // 经度
+	Excursion   bool      `json:"excursion"`   // 是否超出产品温湿度阈值
+}
+
+// ColdChainThresholds 产品冷链温湿度阈值
+type ColdChainThresholds struct {
+	MinTemperature *float64 `json:"minTemperature"`
+	MaxTemperature *float64 `json:"maxTemperature"`
+	MinHumidity    *float64 `json:"minHumidity"`
+	MaxHumidity    *float64 `json:"maxHumidity"`
+}
+
+// ColdChainSummary 冷链读数汇总
+type ColdChainSummary struct {
+	Count       int                  `json:"count"`      // 读数总数
+	Excursions  int                  `json:"excursions"` // 超限读数数量
+	FirstTime   time.Time            `json:"firstTime"`
+	LastTime    time.Time            `json:"lastTime"`
+	Temperature *ColdChainStat       `json:"temperature"`
+	Humidity    *ColdChainStat       `json:"humidity"`
+	Thresholds  *ColdChainThresholds `json:"thresholds"`
+	Series      []*ColdChainPoint    `json:"series"` // 按时间分桶的汇总序列
+}
+
+// ColdChainStat 读数统计
+type ColdChainStat struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+	Avg float64 `json:"avg"`
+}
+
+// ColdChainPoint 汇总序列中的一个时间桶
+type ColdChainPoint struct {
+	Time           time.Time `json:"time"` // 桶起始时间
+	Count          int       `json:"count"`
+	MinTemperature float64   `json:"minTemperature"`
+	MaxTemperature float64   `json:"maxTemperature"`
+	AvgTemperature float64   `json:"avgTemperature"`
+	AvgHumidity    *float64  `json:"avgHumidity"`
+	Excursion      bool      `json:"excursion"` // 桶内是否存在超限读数
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// SoftDelete 软删除标记：删除后记录仍保留，列表及分页查询默认不再返回，历史溯源仍可按ID查到
+type SoftDelete struct {
+	DeletedAt *time.Time `json:"deletedAt,omitempty"` // 删除时间，为空表示未删除
+	DeletedBy string     `json:"deletedBy,omitempty"` // 删除人用户名
+}
+
+// Deleted 是否已删除
+func (d *SoftDelete) Deleted() bool {
+	return d.DeletedAt != nil
+}
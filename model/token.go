@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// RefreshToken 服务端保存的刷新令牌(只保存哈希值)
+type RefreshToken struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"userId"`
+	TokenHash string     `json:"-"`
+	AccessJTI string     `json:"-"` // 与之配对的访问令牌ID，退出登录时一并吊销
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,189 @@
+package model
+
+import "time"
+
+// 溯源链中可能缺失的环节
+const (
+	ChainLinkSaleInfo        = "saleInfo"
+	ChainLinkSalePlace       = "salePlace"
+	ChainLinkLogistics       = "logistics"
+	ChainLinkCompany         = "company"
+	ChainLinkProductionInfo  = "productionInfo"
+	ChainLinkProduct         = "product"
+	ChainLinkProductionPlace = "productionPlace"
+)
+
+// TraceabilityChain 单条销售记录的完整溯源链
+type TraceabilityChain struct {
+	Production *ChainProduction  `json:"production"` // 生产环节(种子来源、播种、收获)
+	Transport  []*ChainTransport `json:"transport"`  // 运输环节(按出发时间排序)
+	Sale       *ChainSale        `json:"sale"`       // 销售环节
+	Missing    []*ChainMissing   `json:"missing"`    // 缺失的环节
+	Recalls    []*Recall         `json:"recalls"`    // 涉及本批次的进行中召回
+}
+
+// ChainProduction 溯源链-生产环节
+type ChainProduction struct {
+	Info            *ProductionInfoWithDetails `json:"info"`
+	Product         *Product                   `json:"product"`
+	ProductionPlace *ProductionPlace           `json:"productionPlace"`
+	Ancestors       []*BatchNode               `json:"ancestors"`   // 拆分/合并前的来源批次
+	Activities      []*FarmingActivity         `json:"activities"`  // 本批次及来源批次的农事活动
+	Inspections     []*Inspection              `json:"inspections"` // 本批次及来源批次的产地检测
+}
+
+// ChainTransport 溯源链-运输环节
+type ChainTransport struct {
+	Logistics *Logistics `json:"logistics"`
+	Company   *Company   `json:"company"`
+	SaleLeg   bool       `json:"saleLeg"` // 是否为销售记录直接关联的物流
+
+	Inspections []*Inspection `json:"inspections"` // 运输环节抽检
+}
+
+// ChainSale 溯源链-销售环节
+type ChainSale struct {
+	Info      *SaleInfoVO `json:"info"`
+	SalePlace *SalePlace  `json:"salePlace"`
+}
+
+// ChainMissing 溯源链中缺失的环节
+type ChainMissing struct {
+	Link string `json:"link"` // 缺失环节
+	ID   int    `json:"id"`   // 引用的ID(0表示未填写)
+	Msg  string `json:"msg"`  // 说明
+}
+
+// AddMissing 记录一个缺失的环节
+func (c *TraceabilityChain) AddMissing(link string, id int, msg string) {
+	c.Missing = append(c.Missing, &ChainMissing{Link: link, ID: id, Msg: msg})
+}
+
+// PublicTrace 面向消费者的溯源信息(不包含内部ID)
+type PublicTrace struct {
+	Code       string             `json:"code"`
+	Recalled   bool               `json:"recalled"` // 是否处于召回中，消费者应停止食用
+	Recalls    []*PublicRecall    `json:"recalls"`
+	Product    *PublicProduct     `json:"product"`
+	Production *PublicProduction  `json:"production"`
+	Transport  []*PublicTransport `json:"transport"`
+	Sale       *PublicSale        `json:"sale"`
+	Missing    []string           `json:"missing"` // 缺失的环节名称
+}
+
+// PublicRecall 公开溯源-召回
+type PublicRecall struct {
+	BatchNo  string    `json:"batchNo"` // 召回的批次，按产品召回时为空
+	Reason   string    `json:"reason"`
+	OpenTime time.Time `json:"openTime"`
+}
+
+// PublicProduct 公开溯源-产品
+type PublicProduct struct {
+	Name        string `json:"pdName"`
+	Type        string `json:"type"`
+	Image       string `json:"image"`
+	Description string `json:"pdDescription"`
+}
+
+// PublicProduction 公开溯源-生产
+type PublicProduction struct {
+	BatchNo       string    `json:"batchNo"`
+	Quantity      float64   `json:"quantity"`
+	Unit          string    `json:"unit"`
+	SeedSource    string    `json:"seed"`
+	Description   string    `json:"piDescription"`
+	PlantingDate  time.Time `json:"plantingDate"`
+	HarvestDate   time.Time `json:"harvestDate"`
+	Address       string    `json:"ppAddress"`
+	Administrator string    `json:"ppAdministrator"`
+	Phone         string    `json:"ppPhone"`
+
+	Sources     []*PublicBatchNode       `json:"sources"`     // 拆分/合并前的来源批次
+	Activities  []*PublicFarmingActivity `json:"activities"`  // 施肥、施药、灌溉等农事活动
+	Inspections []*PublicInspection      `json:"inspections"` // 农残检测、质量分级、认证证书
+
+	Proof *HashChainProof `json:"proof"` // 生产信息在哈希链中的位置，未入链时为null
+}
+
+// PublicFarmingActivity 公开溯源-农事活动
+type PublicFarmingActivity struct {
+	ActivityType    string    `json:"activityType"`
+	Material        string    `json:"material"`
+	Dosage          *float64  `json:"dosage"`
+	DosageUnit      string    `json:"dosageUnit"`
+	Operator        string    `json:"operator"`
+	ActivityDate    time.Time `json:"activityDate"`
+	WithholdingDays *int      `json:"withholdingDays"`
+}
+
+// PublicInspection 公开溯源-质量检测
+type PublicInspection struct {
+	InspectionType string                  `json:"inspectionType"`
+	LabName        string                  `json:"labName"`
+	ReportNo       string                  `json:"reportNo"`
+	InspectionDate time.Time               `json:"inspectionDate"`
+	Verdict        string                  `json:"verdict"`
+	ReportURL      string                  `json:"reportUrl"`
+	Items          []*PublicInspectionItem `json:"items"`
+}
+
+// PublicInspectionItem 公开溯源-检测项目
+type PublicInspectionItem struct {
+	ItemName   string   `json:"itemName"`
+	Value      *float64 `json:"value"`
+	ResultText string   `json:"resultText"`
+	Unit       string   `json:"unit"`
+	LimitMin   *float64 `json:"limitMin"`
+	LimitMax   *float64 `json:"limitMax"`
+	Passed     bool     `json:"passed"`
+}
+
+// PublicBatchNode 公开溯源-来源批次
+type PublicBatchNode struct {
+	BatchNo     string             `json:"batchNo"`
+	ProductName string             `json:"pdName"`
+	Address     string             `json:"ppAddress"`
+	Kind        string             `json:"kind"`     // split/merge
+	Quantity    float64            `json:"quantity"` // 转入当前批次的数量
+	Unit        string             `json:"unit"`
+	Parents     []*PublicBatchNode `json:"parents,omitempty"`
+}
+
+// PublicTransport 公开溯源-运输
+type PublicTransport struct {
+	CompanyName   string     `json:"comName"`
+	Administrator string     `json:"comAdministrator"`
+	Phone         string     `json:"comPhone"`
+	StartLocation string     `json:"startLocation"`
+	Destination   string     `json:"destination"`
+	StartTime     time.Time  `json:"startTime"`
+	EndTime       *time.Time `json:"endTime"`
+	Status        string     `json:"status"`
+
+	Events      []*PublicTransportEvent `json:"events"`
+	ColdChain   *ColdChainSummary       `json:"coldChain"`
+	Inspections []*PublicInspection     `json:"inspections"` // 运输环节抽检
+
+	Proof *HashChainProof `json:"proof"` // 物流信息在哈希链中的位置，未入链时为null
+}
+
+// PublicTransportEvent 公开溯源-运输事件
+type PublicTransportEvent struct {
+	EventType   string    `json:"eventType"`
+	Location    string    `json:"location"`
+	CompanyName string    `json:"comName"`
+	EventTime   time.Time `json:"eventTime"`
+	Remark      string    `json:"remark"`
+}
+
+// PublicSale 公开溯源-销售
+type PublicSale struct {
+	Address       string    `json:"spAddress"`
+	Administrator string    `json:"spAdministrator"`
+	Phone         string    `json:"spPhone"`
+	Description   string    `json:"siDescription"`
+	SaleTime      time.Time `json:"saleTime"`
+
+	Proof *HashChainProof `json:"proof"` // 销售信息在哈希链中的位置，未入链时为null
+}
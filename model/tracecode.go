@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// TraceCode 公开溯源码实体
+type TraceCode struct {
+	ID            int        `json:"tcId"`
+	Code          string     `json:"code"`          // 公开溯源码
+	SaleInfoID    int        `json:"saleInfoId"`    // 关联的销售信息ID(0表示未关联)
+	ProductInfoID int        `json:"productInfoId"` // 关联的生产批次ID(0表示未关联)
+	Revoked       bool       `json:"revoked"`       // 是否已作废
+	CreateTime    time.Time  `json:"createTime"`    // 生成时间
+	RevokeTime    *time.Time `json:"revokeTime"`    // 作废时间
+	RevokeReason  string     `json:"revokeReason"`  // 作废原因
+	URL           string     `json:"url,omitempty"` // 公开溯源地址
+}
+
+// TraceCodePageQuery 溯源码分页查询参数
+type TraceCodePageQuery struct {
+	Page          int
+	Size          int
+	Code          string
+	SaleInfoID    int
+	ProductInfoID int
+	Revoked       *bool
+}
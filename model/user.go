@@ -10,4 +10,13 @@ type User struct {
 	Sex      sql.NullString `json:"sex"`   // 使用NullString处理NULL值
 	Name     sql.NullString `json:"name"`  // 如果可能为NULL，也使用NullString
 	Phone    sql.NullString `json:"phone"` // 如果可能为NULL，也使用NullString
+	Role     string         `json:"role"`  // 角色，为空表示尚未分配
+
+	// 绑定的业务主体，0表示未绑定
+	CompanyID      int `json:"companyId"`      // 物流公司
+	ProductPlaceID int `json:"productPlaceId"` // 生产地(农场)
+	SalePlaceID    int `json:"salePlaceId"`    // 销售地
+
+	// 令牌版本，退出所有会话或修改密码时递增，使已签发的令牌全部失效
+	TokenVersion int `json:"-"`
 }
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"agricultural_product_gin/model"
+)
+
+// AuditRepository 审计日志仓库接口，审计日志只追加不修改
+type AuditRepository interface {
+	Save(auditLog *model.AuditLog) error
+	PageQuery(dto *model.AuditLogPageQueryDTO) ([]*model.AuditLog, int64, error)
+}
+
+// AuditRepositoryImpl 审计日志仓库的数据库实现
+type AuditRepositoryImpl struct {
+	DB *DB
+}
+
+// NewAuditRepository 创建审计日志仓库
+func NewAuditRepository(db *DB) AuditRepository {
+	return &AuditRepositoryImpl{DB: db}
+}
+
+// Save 保存审计日志
+func (r *AuditRepositoryImpl) Save(auditLog *model.AuditLog) error {
+	query := `INSERT INTO audit_log(actor_id, actor, entity, entity_id, action, before_json, after_json, create_time)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.DB.Exec(query, nullableID(auditLog.ActorID), auditLog.Actor, auditLog.Entity, auditLog.EntityID,
+		auditLog.Action, nullJSON(auditLog.Before), nullJSON(auditLog.After), auditLog.CreateTime)
+	if err != nil {
+		log.Println("保存审计日志失败:", err)
+		return err
+	}
+	return nil
+}
+
+// PageQuery 按实体、操作人、操作及时间范围分页查询审计日志(按时间倒序)
+func (r *AuditRepositoryImpl) PageQuery(dto *model.AuditLogPageQueryDTO) ([]*model.AuditLog, int64, error) {
+	var conditions []string
+	var args []interface{}
+
+	if dto.Entity != "" {
+		conditions = append(conditions, "entity = ?")
+		args = append(args, dto.Entity)
+	}
+
+	if dto.EntityID > 0 {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, dto.EntityID)
+	}
+
+	if dto.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, dto.Actor)
+	}
+
+	if dto.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, dto.Action)
+	}
+
+	if dto.Start != nil {
+		conditions = append(conditions, "create_time >= ?")
+		args = append(args, *dto.Start)
+	}
+
+	if dto.End != nil {
+		conditions = append(conditions, "create_time < ?")
+		args = append(args, *dto.End)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := r.DB.QueryRow("SELECT COUNT(*) FROM audit_log"+whereClause, args...).Scan(&total); err != nil {
+		log.Println("查询审计日志总数失败:", err)
+		return nil, 0, err
+	}
+
+	offset := (dto.Page - 1) * dto.Size
+	query := fmt.Sprintf(`SELECT al_id, actor_id, actor, entity, entity_id, action, before_json, after_json, create_time
+		FROM audit_log%s ORDER BY create_time DESC, al_id DESC LIMIT ? OFFSET ?`, whereClause)
+	rows, err := r.DB.Query(query, append(args, dto.Size, offset)...)
+	if err != nil {
+		log.Println("查询审计日志失败:", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	auditLogs := []*model.AuditLog{}
+	for rows.Next() {
+		auditLog := &model.AuditLog{}
+		var actorID sql.NullInt64
+		var before, after sql.NullString
+		if err := rows.Scan(&auditLog.ID, &actorID, &auditLog.Actor, &auditLog.Entity, &auditLog.EntityID,
+			&auditLog.Action, &before, &after, &auditLog.CreateTime); err != nil {
+			log.Println("读取审计日志失败:", err)
+			return nil, 0, err
+		}
+		auditLog.ActorID = int(actorID.Int64)
+		if before.Valid {
+			auditLog.Before = []byte(before.String)
+		}
+		if after.Valid {
+			auditLog.After = []byte(after.String)
+		}
+		auditLogs = append(auditLogs, auditLog)
+	}
+	return auditLogs, total, rows.Err()
+}
+
+// nullJSON 将空快照转换为NULL
+func nullJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
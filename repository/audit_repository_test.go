@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+func TestAuditRepository(t *testing.T) {
+	db := newTestDB(t)
+	mustNoError(t, NewUserRepository(db).Save("admin", "hash", model.RoleAdmin))
+	admin, err := NewUserRepository(db).FindByUsername("admin")
+	mustNoError(t, err)
+	repo := NewAuditRepository(db)
+
+	logs := []*model.AuditLog{
+		{ActorID: admin.ID, Actor: "admin", Entity: model.AuditEntityProduct, EntityID: 1, Action: model.AuditActionCreate,
+			After: json.RawMessage(`{"pdName":"苹果"}`), CreateTime: testTime},
+		{ActorID: admin.ID, Actor: "admin", Entity: model.AuditEntityProduct, EntityID: 1, Action: model.AuditActionUpdate,
+			Before: json.RawMessage(`{"pdName":"苹果"}`), After: json.RawMessage(`{"pdName":"红富士"}`), CreateTime: testTime.Add(time.Hour)},
+		{Actor: "system", Entity: model.AuditEntityLogistics, EntityID: 2, Action: model.AuditActionUpdate,
+			After: json.RawMessage(`{"status":"delivered"}`), CreateTime: testTime.Add(2 * time.Hour)},
+	}
+	for _, auditLog := range logs {
+		mustNoError(t, repo.Save(auditLog))
+	}
+
+	start, end := testTime.Add(time.Hour), testTime.Add(2*time.Hour)
+	tests := []struct {
+		name      string
+		query     model.AuditLogPageQueryDTO
+		wantTotal int64
+		wantIDs   []int
+	}{
+		{name: "全部按时间倒序", wantTotal: 3, wantIDs: []int{3, 2, 1}},
+		{name: "按实体", query: model.AuditLogPageQueryDTO{Entity: model.AuditEntityProduct}, wantTotal: 2, wantIDs: []int{2, 1}},
+		{name: "按实体ID", query: model.AuditLogPageQueryDTO{EntityID: 2}, wantTotal: 1, wantIDs: []int{3}},
+		{name: "按操作人", query: model.AuditLogPageQueryDTO{Actor: "system"}, wantTotal: 1, wantIDs: []int{3}},
+		{name: "按操作", query: model.AuditLogPageQueryDTO{Action: model.AuditActionCreate}, wantTotal: 1, wantIDs: []int{1}},
+		{name: "时间范围左闭右开", query: model.AuditLogPageQueryDTO{Start: &start, End: &end}, wantTotal: 1, wantIDs: []int{2}},
+		{name: "分页", query: model.AuditLogPageQueryDTO{Page: 2, Size: 2}, wantTotal: 3, wantIDs: []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			if query.Page == 0 {
+				query.Page, query.Size = 1, 10
+			}
+			list, total, err := repo.PageQuery(&query)
+			mustNoError(t, err)
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, 期望 %d", total, tt.wantTotal)
+			}
+			assertIDs(t, list, func(l *model.AuditLog) int { return l.ID }, tt.wantIDs)
+		})
+	}
+
+	list, _, err := repo.PageQuery(&model.AuditLogPageQueryDTO{Page: 1, Size: 10})
+	mustNoError(t, err)
+	system, update := list[0], list[1]
+	if system.ActorID != 0 || system.Before != nil || string(system.After) != `{"status":"delivered"}` {
+		t.Fatalf("系统操作日志 = %+v", system)
+	}
+	if update.ActorID != admin.ID || string(update.Before) != `{"pdName":"苹果"}` || string(update.After) != `{"pdName":"红富士"}` {
+		t.Fatalf("修改日志 = %+v", update)
+	}
+}
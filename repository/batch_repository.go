@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"database/sql"
+	"log"
+
+	"agricultural_product_gin/model"
+)
+
+// BatchRepository 生产批次数量及谱系仓库接口
+type BatchRepository interface {
+	Usage(productInfoID, excludeLogisticsID int) (*model.BatchUsage, error)
+	BatchNoExists(batchNo string) (bool, error)
+	SaveLineage(lineage *model.BatchLineage) (int, error)
+	FindParents(childID int) ([]*model.BatchLineage, error)
+	FindChildren(parentID int) ([]*model.BatchLineage, error)
+}
+
+// BatchRepositoryImpl 生产批次数量及谱系仓库的数据库实现
+type BatchRepositoryImpl struct {
+	DB *DB
+}
+
+// NewBatchRepository 创建生产批次仓库
+func NewBatchRepository(db *DB) BatchRepository {
+	return &BatchRepositoryImpl{DB: db}
+}
+
+// Usage 查询批次数量使用情况，批次不存在时返回nil
+// 在事务中调用时会锁定该批次，直到事务结束，保证校验与写入之间数量不被并发修改
+// 已取消和已拒收的物流不再占用批次数量；excludeLogisticsID不为0时不统计该物流的分配(用于修改物流时重新校验)
+func (r *BatchRepositoryImpl) Usage(productInfoID, excludeLogisticsID int) (*model.BatchUsage, error) {
+	usage := &model.BatchUsage{}
+	err := r.DB.QueryRow("SELECT quantity FROM product_info WHERE pi_id = ?"+r.DB.Dialect.ForUpdate(), productInfoID).
+		Scan(&usage.Quantity)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("查询批次数量失败:", err)
+		return nil, err
+	}
+
+	query := `SELECT COALESCE(SUM(quantity), 0) FROM logistics 
+              WHERE product_info_id = ? AND quantity IS NOT NULL AND status NOT IN (?, ?) AND log_id != ?`
+	err = r.DB.QueryRow(query, productInfoID, model.LogisticsStatusCancelled, model.LogisticsStatusRejected, excludeLogisticsID).Scan(&usage.Allocated)
+	if err != nil {
+		log.Println("统计批次已分配数量失败:", err)
+		return nil, err
+	}
+
+	err = r.DB.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM batch_lineage WHERE parent_id = ?", productInfoID).
+		Scan(&usage.Derived)
+	if err != nil {
+		log.Println("统计批次已拆分/合并数量失败:", err)
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// BatchNoExists 批次号是否已存在
+func (r *BatchRepositoryImpl) BatchNoExists(batchNo string) (bool, error) {
+	var count int
+	err := r.DB.QueryRow("SELECT COUNT(*) FROM product_info WHERE batch_no = ?", batchNo).Scan(&count)
+	if err != nil {
+		log.Println("查询批次号失败:", err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SaveLineage 保存批次谱系
+func (r *BatchRepositoryImpl) SaveLineage(lineage *model.BatchLineage) (int, error) {
+	query := "INSERT INTO batch_lineage(parent_id, child_id, kind, quantity, create_time) VALUES(?, ?, ?, ?, ?)"
+	result, err := r.DB.Exec(query, lineage.ParentID, lineage.ChildID, lineage.Kind, lineage.Quantity, lineage.CreateTime)
+	if err != nil {
+		log.Println("保存批次谱系失败:", err)
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		log.Println("获取批次谱系ID失败:", err)
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// FindParents 查询批次的来源批次
+func (r *BatchRepositoryImpl) FindParents(childID int) ([]*model.BatchLineage, error) {
+	return r.find("child_id", childID)
+}
+
+// FindChildren 查询由批次产出的批次
+func (r *BatchRepositoryImpl) FindChildren(parentID int) ([]*model.BatchLineage, error) {
+	return r.find("parent_id", parentID)
+}
+
+// find 按来源或产出批次查询谱系
+func (r *BatchRepositoryImpl) find(column string, productInfoID int) ([]*model.BatchLineage, error) {
+	query := "SELECT id, parent_id, child_id, kind, quantity, create_time FROM batch_lineage WHERE " + column + " = ? ORDER BY id"
+	rows, err := r.DB.Query(query, productInfoID)
+	if err != nil {
+		log.Println("查询批次谱系失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lineages []*model.BatchLineage
+	for rows.Next() {
+		lineage := &model.BatchLineage{}
+		if err := rows.Scan(&lineage.ID, &lineage.ParentID, &lineage.ChildID, &lineage.Kind,
+			&lineage.Quantity, &lineage.CreateTime); err != nil {
+			log.Println("读取批次谱系失败:", err)
+			return nil, err
+		}
+		lineages = append(lineages, lineage)
+	}
+	return lineages, rows.Err()
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+func TestBatchRepository(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewBatchRepository(db)
+	logistics := NewLogisticsRepository(db)
+
+	// 批次0：物流0分配40，再新增分配30后取消、分配25后拒收的物流，并拆分20到批次1
+	quantity := 30.0
+	cancelled, err := logistics.Save(&model.Logistics{
+		ProductInfoID: f.Productions[0], CompanyID: f.Companies[0], Quantity: &quantity,
+		StartLocation: "农场0", Destination: "超市1", StartTime: testTime, Status: model.LogisticsStatusCreated,
+	})
+	mustNoError(t, err)
+	_, err = logistics.UpdateStatus(cancelled, model.LogisticsStatusCreated, model.LogisticsStatusCancelled, "取消", testTime, nil)
+	mustNoError(t, err)
+	rejectedQuantity := 25.0
+	rejected, err := logistics.Save(&model.Logistics{
+		ProductInfoID: f.Productions[0], CompanyID: f.Companies[0], Quantity: &rejectedQuantity,
+		StartLocation: "农场0", Destination: "超市1", StartTime: testTime, Status: model.LogisticsStatusInTransit,
+	})
+	mustNoError(t, err)
+	_, err = logistics.UpdateStatus(rejected, model.LogisticsStatusInTransit, model.LogisticsStatusRejected, "拒收", testTime, nil)
+	mustNoError(t, err)
+	lineageID, err := repo.SaveLineage(&model.BatchLineage{
+		ParentID: f.Productions[0], ChildID: f.Productions[1], Kind: model.BatchLineageSplit, Quantity: 20, CreateTime: testTime,
+	})
+	mustNoError(t, err)
+
+	tests := []struct {
+		name          string
+		productInfoID int
+		exclude       int
+		want          *model.BatchUsage
+	}{
+		{name: "不含已取消和已拒收的物流", productInfoID: f.Productions[0], want: &model.BatchUsage{Quantity: 100, Allocated: 40, Derived: 20}},
+		{name: "排除正在修改的物流", productInfoID: f.Productions[0], exclude: f.Logistics[0], want: &model.BatchUsage{Quantity: 100, Derived: 20}},
+		{name: "产出批次不计入拆分数量", productInfoID: f.Productions[1], want: &model.BatchUsage{Quantity: 100, Allocated: 40}},
+		{name: "批次不存在", productInfoID: 999},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage, err := repo.Usage(tt.productInfoID, tt.exclude)
+			mustNoError(t, err)
+			if (usage == nil) != (tt.want == nil) || usage != nil && *usage != *tt.want {
+				t.Errorf("Usage = %+v, 期望 %+v", usage, tt.want)
+			}
+		})
+	}
+
+	exists, err := repo.BatchNoExists("B0001")
+	mustNoError(t, err)
+	if !exists {
+		t.Fatal("BatchNoExists(B0001) = false")
+	}
+	exists, err = repo.BatchNoExists("B9999")
+	mustNoError(t, err)
+	if exists {
+		t.Fatal("BatchNoExists(B9999) = true")
+	}
+
+	parents, err := repo.FindParents(f.Productions[1])
+	mustNoError(t, err)
+	assertIDs(t, parents, func(l *model.BatchLineage) int { return l.ID }, []int{lineageID})
+	if p := parents[0]; p.ParentID != f.Productions[0] || p.Kind != model.BatchLineageSplit || p.Quantity != 20 || !p.CreateTime.Equal(testTime) {
+		t.Fatalf("FindParents = %+v", p)
+	}
+	children, err := repo.FindChildren(f.Productions[0])
+	mustNoError(t, err)
+	assertIDs(t, children, func(l *model.BatchLineage) int { return l.ID }, []int{lineageID})
+	children, err = repo.FindChildren(f.Productions[1])
+	mustNoError(t, err)
+	if len(children) != 0 {
+		t.Fatalf("FindChildren(产出批次) = %+v", children)
+	}
+}
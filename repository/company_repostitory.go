@@ -5,22 +5,34 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"agricultural_product_gin/model"
 )
 
-// CompanyRepository 公司数据仓库
-type CompanyRepository struct {
-	DB *sql.DB
+// CompanyRepository 公司数据仓库接口
+type CompanyRepository interface {
+	Save(company *model.Company) (int, error)
+	Update(company *model.Company) error
+	Delete(id int, deletedAt time.Time, deletedBy string) error
+	Restore(id int) error
+	GetByID(id int) (*model.Company, error)
+	FindAll(includeDeleted bool) ([]*model.Company, error)
+	PageQuery(page, pageSize int, name, address, administrator, phone string, includeDeleted bool) ([]*model.Company, int64, error)
+}
+
+// CompanyRepositoryImpl 公司数据仓库的数据库实现
+type CompanyRepositoryImpl struct {
+	DB *DB
 }
 
 // NewCompanyRepository 创建公司仓库
-func NewCompanyRepository(db *sql.DB) *CompanyRepository {
-	return &CompanyRepository{DB: db}
+func NewCompanyRepository(db *DB) CompanyRepository {
+	return &CompanyRepositoryImpl{DB: db}
 }
 
 // Save 保存公司
-func (r *CompanyRepository) Save(company *model.Company) (int, error) {
+func (r *CompanyRepositoryImpl) Save(company *model.Company) (int, error) {
 	query := "INSERT INTO company(com_name, com_address, com_administrator, com_phone) VALUES(?, ?, ?, ?)"
 	result, err := r.DB.Exec(query, company.Name, company.Address, company.Administrator, company.Phone)
 	if err != nil {
@@ -38,7 +50,7 @@ func (r *CompanyRepository) Save(company *model.Company) (int, error) {
 }
 
 // Update 更新公司
-func (r *CompanyRepository) Update(company *model.Company) error {
+func (r *CompanyRepositoryImpl) Update(company *model.Company) error {
 	query := "UPDATE company SET com_name = ?, com_address = ?, com_administrator = ?, com_phone = ? WHERE com_id = ?"
 	_, err := r.DB.Exec(query, company.Name, company.Address, company.Administrator, company.Phone, company.ID)
 	if err != nil {
@@ -48,10 +60,10 @@ func (r *CompanyRepository) Update(company *model.Company) error {
 	return nil
 }
 
-// Delete 删除公司
-func (r *CompanyRepository) Delete(id int) error {
-	query := "DELETE FROM company WHERE com_id = ?"
-	_, err := r.DB.Exec(query, id)
+// Delete 软删除公司，记录删除时间及删除人，已有的物流记录仍可关联到该公司
+func (r *CompanyRepositoryImpl) Delete(id int, deletedAt time.Time, deletedBy string) error {
+	query := "UPDATE company SET deleted_at = ?, deleted_by = ? WHERE com_id = ? AND deleted_at IS NULL"
+	_, err := r.DB.Exec(query, deletedAt, deletedBy, id)
 	if err != nil {
 		log.Println("删除公司失败:", err)
 		return err
@@ -59,13 +71,21 @@ func (r *CompanyRepository) Delete(id int) error {
 	return nil
 }
 
-// GetByID 根据ID获取公司
-func (r *CompanyRepository) GetByID(id int) (*model.Company, error) {
-	query := "SELECT com_id, com_name, com_address, com_administrator, com_phone FROM company WHERE com_id = ?"
-	row := r.DB.QueryRow(query, id)
+// Restore 恢复已删除的公司
+func (r *CompanyRepositoryImpl) Restore(id int) error {
+	query := "UPDATE company SET deleted_at = NULL, deleted_by = NULL WHERE com_id = ?"
+	_, err := r.DB.Exec(query, id)
+	if err != nil {
+		log.Println("恢复公司失败:", err)
+		return err
+	}
+	return nil
+}
 
-	company := &model.Company{}
-	err := row.Scan(&company.ID, &company.Name, &company.Address, &company.Administrator, &company.Phone)
+// GetByID 根据ID获取公司，已删除的公司同样返回(DeletedAt不为空)，供历史溯源使用
+func (r *CompanyRepositoryImpl) GetByID(id int) (*model.Company, error) {
+	query := companySelect + " WHERE com_id = ?"
+	company, err := scanCompany(r.DB.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -77,9 +97,12 @@ func (r *CompanyRepository) GetByID(id int) (*model.Company, error) {
 	return company, nil
 }
 
-// FindAll 查找所有公司
-func (r *CompanyRepository) FindAll() ([]*model.Company, error) {
-	query := "SELECT com_id, com_name, com_address, com_administrator, com_phone FROM company"
+// FindAll 查找所有公司，includeDeleted为false时不含已删除的公司
+func (r *CompanyRepositoryImpl) FindAll(includeDeleted bool) ([]*model.Company, error) {
+	query := companySelect
+	if !includeDeleted {
+		query += " WHERE deleted_at IS NULL"
+	}
 	rows, err := r.DB.Query(query)
 	if err != nil {
 		log.Println("查询公司失败:", err)
@@ -89,8 +112,7 @@ func (r *CompanyRepository) FindAll() ([]*model.Company, error) {
 
 	var companies []*model.Company
 	for rows.Next() {
-		company := &model.Company{}
-		err := rows.Scan(&company.ID, &company.Name, &company.Address, &company.Administrator, &company.Phone)
+		company, err := scanCompany(rows)
 		if err != nil {
 			log.Println("读取公司数据失败:", err)
 			return nil, err
@@ -101,8 +123,8 @@ func (r *CompanyRepository) FindAll() ([]*model.Company, error) {
 	return companies, nil
 }
 
-// PageQuery 分页查询公司
-func (r *CompanyRepository) PageQuery(page, pageSize int, name, address, administrator, phone string) ([]*model.Company, int64, error) {
+// PageQuery 分页查询公司，includeDeleted为false时不含已删除的公司
+func (r *CompanyRepositoryImpl) PageQuery(page, pageSize int, name, address, administrator, phone string, includeDeleted bool) ([]*model.Company, int64, error) {
 	// 构建查询条件
 	conditions := []string{}
 	args := []interface{}{}
@@ -126,6 +148,7 @@ func (r *CompanyRepository) PageQuery(page, pageSize int, name, address, adminis
 		conditions = append(conditions, "com_phone LIKE ?")
 		args = append(args, "%"+phone+"%")
 	}
+	conditions = softDeleteCondition(conditions, includeDeleted)
 
 	// 构建条件子句
 	whereClause := ""
@@ -144,7 +167,7 @@ func (r *CompanyRepository) PageQuery(page, pageSize int, name, address, adminis
 
 	// 查询当前页数据
 	offset := (page - 1) * pageSize
-	dataQuery := fmt.Sprintf("SELECT com_id, com_name, com_address, com_administrator, com_phone FROM company%s LIMIT ? OFFSET ?", whereClause)
+	dataQuery := fmt.Sprintf("%s%s LIMIT ? OFFSET ?", companySelect, whereClause)
 	queryArgs := append(args, pageSize, offset)
 
 	rows, err := r.DB.Query(dataQuery, queryArgs...)
@@ -156,8 +179,7 @@ func (r *CompanyRepository) PageQuery(page, pageSize int, name, address, adminis
 
 	var companies []*model.Company
 	for rows.Next() {
-		company := &model.Company{}
-		err := rows.Scan(&company.ID, &company.Name, &company.Address, &company.Administrator, &company.Phone)
+		company, err := scanCompany(rows)
 		if err != nil {
 			log.Println("读取公司数据失败:", err)
 			return nil, 0, err
@@ -167,3 +189,19 @@ func (r *CompanyRepository) PageQuery(page, pageSize int, name, address, adminis
 
 	return companies, total, nil
 }
+
+// companySelect 公司查询字段
+const companySelect = "SELECT com_id, com_name, com_address, com_administrator, com_phone, deleted_at, deleted_by FROM company"
+
+// scanCompany 读取一行公司数据
+func scanCompany(row rowScanner) (*model.Company, error) {
+	company := &model.Company{}
+	var deleted softDeleteFields
+	err := row.Scan(&company.ID, &company.Name, &company.Address, &company.Administrator, &company.Phone,
+		&deleted.DeletedAt, &deleted.DeletedBy)
+	if err != nil {
+		return nil, err
+	}
+	deleted.apply(&company.SoftDelete)
+	return company, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+func TestCompanyRepository_CRUD(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewCompanyRepository(db)
+
+	id, err := repo.Save(&model.Company{Name: "顺达物流", Address: "城东", Administrator: "张三", Phone: "13800000000"})
+	mustNoError(t, err)
+
+	got, err := repo.GetByID(id)
+	mustNoError(t, err)
+	if got == nil || got.Name != "顺达物流" || got.Phone != "13800000000" || got.Deleted() {
+		t.Fatalf("GetByID = %+v", got)
+	}
+
+	mustNoError(t, repo.Update(&model.Company{ID: id, Name: "顺达冷链", Address: "城西", Administrator: "李四", Phone: "13900000000"}))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if got.Name != "顺达冷链" || got.Address != "城西" || got.Administrator != "李四" {
+		t.Fatalf("Update后 = %+v", got)
+	}
+
+	// 软删除后仍可按ID查到，列表默认不返回
+	mustNoError(t, repo.Delete(id, testTime, "admin"))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if !got.Deleted() || got.DeletedBy != "admin" {
+		t.Fatalf("Delete后 = %+v", got)
+	}
+	all, err := repo.FindAll(false)
+	mustNoError(t, err)
+	if len(all) != 0 {
+		t.Fatalf("FindAll(false) = %d条, 期望0条", len(all))
+	}
+	all, err = repo.FindAll(true)
+	mustNoError(t, err)
+	if len(all) != 1 {
+		t.Fatalf("FindAll(true) = %d条, 期望1条", len(all))
+	}
+
+	mustNoError(t, repo.Restore(id))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if got.Deleted() || got.DeletedBy != "" {
+		t.Fatalf("Restore后 = %+v", got)
+	}
+
+	missing, err := repo.GetByID(id + 100)
+	mustNoError(t, err)
+	if missing != nil {
+		t.Fatalf("不存在的公司 = %+v", missing)
+	}
+}
+
+func TestCompanyRepository_PageQuery(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewCompanyRepository(db)
+	mustNoError(t, repo.Delete(f.Companies[1], testTime, "admin"))
+
+	tests := []struct {
+		name           string
+		page, size     int
+		companyName    string
+		address        string
+		administrator  string
+		phone          string
+		includeDeleted bool
+		wantTotal      int64
+		wantIDs        []int
+	}{
+		{name: "默认不含已删除", page: 1, size: 10, wantTotal: 1, wantIDs: []int{f.Companies[0]}},
+		{name: "包含已删除", page: 1, size: 10, includeDeleted: true, wantTotal: 2, wantIDs: []int{f.Companies[0], f.Companies[1]}},
+		{name: "按名称模糊查询", page: 1, size: 10, companyName: "物流1", includeDeleted: true, wantTotal: 1, wantIDs: []int{f.Companies[1]}},
+		{name: "按地址", page: 1, size: 10, address: "仓", wantTotal: 1, wantIDs: []int{f.Companies[0]}},
+		{name: "按负责人", page: 1, size: 10, administrator: "经理0", wantTotal: 1, wantIDs: []int{f.Companies[0]}},
+		{name: "按电话无匹配", page: 1, size: 10, phone: "555", wantTotal: 0},
+		{name: "分页", page: 2, size: 1, includeDeleted: true, wantTotal: 2, wantIDs: []int{f.Companies[1]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, total, err := repo.PageQuery(tt.page, tt.size, tt.companyName, tt.address, tt.administrator, tt.phone, tt.includeDeleted)
+			mustNoError(t, err)
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, 期望 %d", total, tt.wantTotal)
+			}
+			assertIDs(t, list, func(c *model.Company) int { return c.ID }, tt.wantIDs)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+)
+
+// 外键约束冲突，由DB.Exec将驱动返回的错误转换而来
+var (
+	// ErrReferenced 删除的记录仍被其他数据引用(ON DELETE RESTRICT)
+	ErrReferenced = errors.New("记录仍被其他数据引用，不能删除")
+	// ErrReferenceNotFound 保存的记录引用了不存在的数据
+	ErrReferenceNotFound = errors.New("引用的数据不存在")
+)
+
+// ConstraintError 外键约束冲突，Kind为ErrReferenced或ErrReferenceNotFound，Err为驱动返回的原始错误
+type ConstraintError struct {
+	Kind error
+	Err  error
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Is 使errors.Is(err, ErrReferenced)等判断成立
+func (e *ConstraintError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// translateConstraintError 将外键约束冲突转换为ConstraintError，其他错误原样返回
+// SQLite不区分冲突的方向，按语句判断：DELETE为记录仍被引用，INSERT/UPDATE为引用的数据不存在
+func translateConstraintError(dialect Dialect, query string, err error) error {
+	if err == nil || !dialect.IsForeignKeyViolation(err) {
+		return err
+	}
+
+	kind := ErrReferenceNotFound
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "DELETE") {
+		kind = ErrReferenced
+	}
+	return &ConstraintError{Kind: kind, Err: err}
+}
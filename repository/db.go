@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"log"
+)
+
+// Executor *sql.DB与*sql.Tx共有的SQL执行方法
+type Executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// DB 携带方言的数据库连接，仓储通过它执行SQL
+// 在事务中创建的DB会把所有SQL发送到该事务
+type DB struct {
+	Executor
+	Dialect Dialect
+
+	pool *sql.DB
+	tx   *sql.Tx
+}
+
+// NewDB 使用指定方言包装数据库连接池
+func NewDB(db *sql.DB, dialect string) (*DB, error) {
+	d, err := LookupDialect(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{Executor: db, Dialect: d, pool: db}, nil
+}
+
+// Exec 执行SQL，外键约束冲突转换为ConstraintError(可用errors.Is判断ErrReferenced、ErrReferenceNotFound)
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	result, err := db.Executor.Exec(query, args...)
+	return result, translateConstraintError(db.Dialect, query, err)
+}
+
+// Pool 底层连接池
+func (db *DB) Pool() *sql.DB {
+	return db.pool
+}
+
+// InTransaction 是否处于事务中
+func (db *DB) InTransaction() bool {
+	return db.tx != nil
+}
+
+// Transaction 在事务中执行fn，fn返回错误或panic时回滚，否则提交
+// 已处于事务中时直接复用当前事务，由最外层负责提交或回滚
+func (db *DB) Transaction(fn func(tx *DB) error) (err error) {
+	if db.tx != nil {
+		return fn(db)
+	}
+
+	tx, err := db.pool.Begin()
+	if err != nil {
+		log.Println("开启事务失败:", err)
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&DB{Executor: tx, Dialect: db.Dialect, pool: db.pool, tx: tx}); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Println("回滚事务失败:", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("提交事务失败:", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// 支持的数据库方言，与database/sql驱动名、迁移文件目录一致
+const (
+	DialectMySQL  = "mysql"
+	DialectSQLite = "sqlite"
+)
+
+// Dialect 数据库方言，屏蔽各数据库SQL写法上的差异
+type Dialect interface {
+	// Name 方言名称
+	Name() string
+	// InsertIgnore 主键或唯一键冲突时忽略本条记录的INSERT语句前缀
+	InsertIgnore() string
+	// ForUpdate 在事务中锁定查询行的SELECT后缀，SQLite的写事务本身串行执行，不需要行锁
+	ForUpdate() string
+	// IsForeignKeyViolation 是否为外键约束冲突的错误
+	IsForeignKeyViolation(err error) bool
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string         { return DialectMySQL }
+func (mysqlDialect) InsertIgnore() string { return "INSERT IGNORE" }
+func (mysqlDialect) ForUpdate() string    { return " FOR UPDATE" }
+
+// IsForeignKeyViolation 1451删除的记录仍被引用，1452引用的记录不存在
+func (mysqlDialect) IsForeignKeyViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && (mysqlErr.Number == 1451 || mysqlErr.Number == 1452)
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string         { return DialectSQLite }
+func (sqliteDialect) InsertIgnore() string { return "INSERT OR IGNORE" }
+func (sqliteDialect) ForUpdate() string    { return "" }
+
+// IsForeignKeyViolation 引用的记录不存在时为SQLITE_CONSTRAINT_FOREIGNKEY，
+// ON DELETE RESTRICT阻止删除时为SQLITE_CONSTRAINT_TRIGGER，需结合错误信息判断
+func (sqliteDialect) IsForeignKeyViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return true
+	case sqlite3.SQLITE_CONSTRAINT_TRIGGER:
+		return strings.Contains(sqliteErr.Error(), "FOREIGN KEY constraint failed")
+	default:
+		return false
+	}
+}
+
+// LookupDialect 根据名称获取方言
+func LookupDialect(name string) (Dialect, error) {
+	switch name {
+	case DialectMySQL:
+		return mysqlDialect{}, nil
+	case DialectSQLite:
+		return sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库方言: %s", name)
+	}
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"database/sql"
+	"log"
+
+	"agricultural_product_gin/model"
+)
+
+// FarmingActivityRepository 农事活动仓库接口
+type FarmingActivityRepository interface {
+	Save(activity *model.FarmingActivity) (int, error)
+	Update(activity *model.FarmingActivity) error
+	Delete(id int) error
+	GetByID(id int) (*model.FarmingActivity, error)
+	FindByProductInfoID(productInfoID int) ([]*model.FarmingActivity, error)
+}
+
+// FarmingActivityRepositoryImpl 农事活动仓库的数据库实现
+type FarmingActivityRepositoryImpl struct {
+	DB *DB
+}
+
+// NewFarmingActivityRepository 创建农事活动仓库
+func NewFarmingActivityRepository(db *DB) FarmingActivityRepository {
+	return &FarmingActivityRepositoryImpl{DB: db}
+}
+
+// farmingActivitySelect 农事活动查询字段，关联生产信息取得所属生产地
+const farmingActivitySelect = `SELECT fa.fa_id, fa.product_info_id, fa.activity_type, COALESCE(fa.material, ''),
+		fa.dosage, COALESCE(fa.dosage_unit, ''), COALESCE(fa.operator, ''), fa.activity_date,
+		fa.withholding_days, COALESCE(fa.remark, ''), pi.product_place_id
+		FROM farming_activity fa
+		JOIN product_info pi ON fa.product_info_id = pi.pi_id`
+
+// Save 保存农事活动
+func (r *FarmingActivityRepositoryImpl) Save(activity *model.FarmingActivity) (int, error) {
+	query := `INSERT INTO farming_activity(product_info_id, activity_type, material, dosage, dosage_unit,
+		operator, activity_date, withholding_days, remark) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.DB.Exec(query, activity.ProductInfoID, activity.ActivityType, activity.Material,
+		activity.Dosage, activity.DosageUnit, activity.Operator, activity.ActivityDate,
+		activity.WithholdingDays, activity.Remark)
+	if err != nil {
+		log.Println("保存农事活动失败:", err)
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		log.Println("获取农事活动ID失败:", err)
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Update 更新农事活动(所属生产信息不可修改)
+func (r *FarmingActivityRepositoryImpl) Update(activity *model.FarmingActivity) error {
+	query := `UPDATE farming_activity SET activity_type = ?, material = ?, dosage = ?, dosage_unit = ?,
+		operator = ?, activity_date = ?, withholding_days = ?, remark = ? WHERE fa_id = ?`
+	_, err := r.DB.Exec(query, activity.ActivityType, activity.Material, activity.Dosage, activity.DosageUnit,
+		activity.Operator, activity.ActivityDate, activity.WithholdingDays, activity.Remark, activity.ID)
+	if err != nil {
+		log.Println("更新农事活动失败:", err)
+		return err
+	}
+	return nil
+}
+
+// Delete 删除农事活动
+func (r *FarmingActivityRepositoryImpl) Delete(id int) error {
+	_, err := r.DB.Exec("DELETE FROM farming_activity WHERE fa_id = ?", id)
+	if err != nil {
+		log.Println("删除农事活动失败:", err)
+		return err
+	}
+	return nil
+}
+
+// GetByID 根据ID获取农事活动
+func (r *FarmingActivityRepositoryImpl) GetByID(id int) (*model.FarmingActivity, error) {
+	activity, err := scanFarmingActivity(r.DB.QueryRow(farmingActivitySelect+" WHERE fa.fa_id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("获取农事活动失败:", err)
+		return nil, err
+	}
+	return activity, nil
+}
+
+// FindByProductInfoID 查询生产信息的农事活动(按作业时间排序)
+func (r *FarmingActivityRepositoryImpl) FindByProductInfoID(productInfoID int) ([]*model.FarmingActivity, error) {
+	query := farmingActivitySelect + " WHERE fa.product_info_id = ? ORDER BY fa.activity_date, fa.fa_id"
+	rows, err := r.DB.Query(query, productInfoID)
+	if err != nil {
+		log.Println("查询农事活动失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	activities := []*model.FarmingActivity{}
+	for rows.Next() {
+		activity, err := scanFarmingActivity(rows)
+		if err != nil {
+			log.Println("读取农事活动数据失败:", err)
+			return nil, err
+		}
+		activities = append(activities, activity)
+	}
+
+	return activities, rows.Err()
+}
+
+// scanFarmingActivity 读取一行农事活动数据
+func scanFarmingActivity(row rowScanner) (*model.FarmingActivity, error) {
+	activity := &model.FarmingActivity{}
+	var dosage sql.NullFloat64
+	var withholdingDays sql.NullInt64
+
+	err := row.Scan(&activity.ID, &activity.ProductInfoID, &activity.ActivityType, &activity.Material,
+		&dosage, &activity.DosageUnit, &activity.Operator, &activity.ActivityDate,
+		&withholdingDays, &activity.Remark, &activity.ProductPlaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if dosage.Valid {
+		activity.Dosage = &dosage.Float64
+	}
+	if withholdingDays.Valid {
+		days := int(withholdingDays.Int64)
+		activity.WithholdingDays = &days
+	}
+	return activity, nil
+}
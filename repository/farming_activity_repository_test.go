@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+func TestFarmingActivityRepository(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewFarmingActivityRepository(db)
+
+	dosage, days := 1.5, 7
+	pesticide, err := repo.Save(&model.FarmingActivity{
+		ProductInfoID: f.Productions[0], ActivityType: model.FarmingActivityPesticide, Material: "吡虫啉",
+		Dosage: &dosage, DosageUnit: "L", Operator: "场长0", ActivityDate: testTime.AddDate(0, 0, -10), WithholdingDays: &days,
+	})
+	mustNoError(t, err)
+	irrigation, err := repo.Save(&model.FarmingActivity{
+		ProductInfoID: f.Productions[0], ActivityType: model.FarmingActivityIrrigation, ActivityDate: testTime.AddDate(0, 0, -20),
+	})
+	mustNoError(t, err)
+
+	got, err := repo.GetByID(pesticide)
+	mustNoError(t, err)
+	if got == nil || got.ProductPlaceID != f.Places[0] || got.Dosage == nil || *got.Dosage != 1.5 ||
+		got.WithholdingDays == nil || *got.WithholdingDays != 7 || got.Material != "吡虫啉" {
+		t.Fatalf("GetByID = %+v", got)
+	}
+	got, err = repo.GetByID(irrigation)
+	mustNoError(t, err)
+	if got.Dosage != nil || got.WithholdingDays != nil || got.Material != "" {
+		t.Fatalf("可选字段为空时GetByID = %+v", got)
+	}
+
+	got.Remark = "滴灌"
+	got.ActivityDate = testTime.AddDate(0, 0, -5)
+	mustNoError(t, repo.Update(got))
+
+	// 按作业时间排序
+	list, err := repo.FindByProductInfoID(f.Productions[0])
+	mustNoError(t, err)
+	assertIDs(t, list, func(a *model.FarmingActivity) int { return a.ID }, []int{pesticide, irrigation})
+	if list[1].Remark != "滴灌" {
+		t.Fatalf("Update后 = %+v", list[1])
+	}
+
+	if _, err := repo.Save(&model.FarmingActivity{ProductInfoID: 999, ActivityType: model.FarmingActivityOther, ActivityDate: testTime}); !errors.Is(err, ErrReferenceNotFound) {
+		t.Fatalf("关联不存在的批次 err = %v, 期望ErrReferenceNotFound", err)
+	}
+
+	mustNoError(t, repo.Delete(pesticide))
+	got, err = repo.GetByID(pesticide)
+	mustNoError(t, err)
+	if got != nil {
+		t.Fatalf("Delete后 = %+v", got)
+	}
+}
@@ -0,0 +1,406 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+// HashChainRepository 哈希链仓库接口，条目由生产、物流、销售仓库在写入时追加，只追加不修改
+type HashChainRepository interface {
+	FindRange(fromSeq, limit int) ([]*model.HashChainEntry, error)
+	FindLatest(entity string, entityID int) (*model.HashChainEntry, error)
+	Payload(entity string, entityID int) (string, bool, error)
+	FindUnchained() ([]*model.HashChainRef, error)
+	Seal() (int, error)
+}
+
+// HashChainRepositoryImpl 哈希链仓库的数据库实现
+type HashChainRepositoryImpl struct {
+	DB *DB
+}
+
+// NewHashChainRepository 创建哈希链仓库
+func NewHashChainRepository(db *DB) HashChainRepository {
+	return &HashChainRepositoryImpl{DB: db}
+}
+
+// hashChainTables 纳入哈希链的数据表及其主键
+var hashChainTables = []struct {
+	Entity   string
+	IDColumn string
+}{
+	{model.HashChainProductInfo, "pi_id"},
+	{model.HashChainLogistics, "log_id"},
+	{model.HashChainSaleInfo, "si_id"},
+}
+
+// hashChainSelect 哈希链条目查询字段
+const hashChainSelect = `SELECT seq, entity, entity_id, action, COALESCE(payload, ''), record_hash, prev_hash, hash, create_time
+		FROM hash_chain`
+
+// FindRange 按顺序查询从fromSeq开始的至多limit个条目
+func (r *HashChainRepositoryImpl) FindRange(fromSeq, limit int) ([]*model.HashChainEntry, error) {
+	rows, err := r.DB.Query(hashChainSelect+" WHERE seq >= ? ORDER BY seq LIMIT ?", fromSeq, limit)
+	if err != nil {
+		log.Println("查询哈希链失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*model.HashChainEntry{}
+	for rows.Next() {
+		entry, err := scanHashChainEntry(rows)
+		if err != nil {
+			log.Println("读取哈希链条目失败:", err)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// FindLatest 查询记录最近一次入链的条目，未入链时返回nil
+func (r *HashChainRepositoryImpl) FindLatest(entity string, entityID int) (*model.HashChainEntry, error) {
+	query := hashChainSelect + " WHERE entity = ? AND entity_id = ? ORDER BY seq DESC LIMIT 1"
+	entry, err := scanHashChainEntry(r.DB.QueryRow(query, entity, entityID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("查询哈希链条目失败:", err)
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Payload 读取记录当前的入链内容，记录不存在时返回false
+func (r *HashChainRepositoryImpl) Payload(entity string, entityID int) (string, bool, error) {
+	return hashChainPayload(r.DB, entity, entityID)
+}
+
+// FindUnchained 查询没有任何入链条目的记录(绕过应用直接写入数据库的记录)
+func (r *HashChainRepositoryImpl) FindUnchained() ([]*model.HashChainRef, error) {
+	refs := []*model.HashChainRef{}
+	for _, table := range hashChainTables {
+		query := fmt.Sprintf(`SELECT t.%[2]s FROM %[1]s t
+			WHERE NOT EXISTS (SELECT 1 FROM hash_chain hc WHERE hc.entity = ? AND hc.entity_id = t.%[2]s)
+			ORDER BY t.%[2]s`, table.Entity, table.IDColumn)
+		ids, err := queryIDs(r.DB, query, table.Entity)
+		if err != nil {
+			log.Println("查询未入链记录失败:", err)
+			return nil, err
+		}
+		for _, id := range ids {
+			refs = append(refs, &model.HashChainRef{Entity: table.Entity, EntityID: id})
+		}
+	}
+	return refs, nil
+}
+
+// Seal 哈希链为空时将已有记录全部入链，返回入链的记录数
+// 用于启用哈希链之前已存在的数据，链不为空时不做任何操作
+func (r *HashChainRepositoryImpl) Seal() (int, error) {
+	sealed := 0
+	err := r.DB.Transaction(func(tx *DB) error {
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM hash_chain").Scan(&count); err != nil {
+			log.Println("查询哈希链长度失败:", err)
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		for _, table := range hashChainTables {
+			query := fmt.Sprintf("SELECT %[2]s FROM %[1]s ORDER BY %[2]s", table.Entity, table.IDColumn)
+			ids, err := queryIDs(tx, query)
+			if err != nil {
+				log.Println("查询待入链记录失败:", err)
+				return err
+			}
+			for _, id := range ids {
+				if err := appendHashChain(tx, table.Entity, id, model.HashChainCreate); err != nil {
+					return err
+				}
+				sealed++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sealed, nil
+}
+
+// appendHashChain 将记录的本次变更追加到哈希链，必须与写入记录的SQL处于同一事务
+// 链头在事务中锁定，保证并发写入时条目按提交顺序首尾相连
+// 新增或更新的记录不存在时(未写入任何数据)不追加条目
+func appendHashChain(tx *DB, entity string, entityID int, action string) error {
+	payload := ""
+	if action != model.HashChainDelete {
+		var found bool
+		var err error
+		payload, found, err = hashChainPayload(tx, entity, entityID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	var headSeq int
+	var headHash string
+	err := tx.QueryRow("SELECT seq, hash FROM hash_chain ORDER BY seq DESC LIMIT 1"+tx.Dialect.ForUpdate()).
+		Scan(&headSeq, &headHash)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("查询哈希链头失败:", err)
+		return err
+	}
+
+	entry := &model.HashChainEntry{
+		HashChainProof: model.HashChainProof{
+			Seq:        headSeq + 1,
+			PrevHash:   headHash,
+			CreateTime: time.Now().UTC().Truncate(time.Second),
+		},
+		Entity:   entity,
+		EntityID: entityID,
+		Action:   action,
+		Payload:  payload,
+	}
+	entry.RecordHash = entry.ComputeRecordHash()
+	entry.Hash = entry.ComputeHash()
+
+	query := `INSERT INTO hash_chain(seq, entity, entity_id, action, payload, record_hash, prev_hash, hash, create_time)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, entry.Seq, entry.Entity, entry.EntityID, entry.Action, nullJSON([]byte(entry.Payload)),
+		entry.RecordHash, entry.PrevHash, entry.Hash, entry.CreateTime)
+	if err != nil {
+		log.Println("追加哈希链条目失败:", err)
+		return err
+	}
+	return nil
+}
+
+// appendHashChainDelete 将删除追加到哈希链，DELETE未删除任何行时不追加
+func appendHashChainDelete(tx *DB, result sql.Result, entity string, entityID int) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		log.Println("获取删除行数失败:", err)
+		return err
+	}
+	if affected == 0 {
+		return nil
+	}
+	return appendHashChain(tx, entity, entityID, model.HashChainDelete)
+}
+
+// productInfoPayload 生产信息的入链内容，字段顺序即JSON键顺序，不可调整
+type productInfoPayload struct {
+	ID             int      `json:"piId"`
+	BatchNo        *string  `json:"batchNo"`
+	ProductID      *int64   `json:"productId"`
+	ProductPlaceID *int64   `json:"productPlaceId"`
+	SeedSource     *string  `json:"seed"`
+	Description    *string  `json:"piDescription"`
+	PlantingDate   *string  `json:"plantingDate"`
+	HarvestDate    *string  `json:"harvestDate"`
+	Quantity       *float64 `json:"quantity"`
+	Unit           *string  `json:"unit"`
+}
+
+// logisticsPayload 物流信息的入链内容，字段顺序即JSON键顺序，不可调整
+// PrevID在其余字段之后追加且为空时省略，已入链的首段记录内容保持不变
+type logisticsPayload struct {
+	ID            int      `json:"logId"`
+	ProductInfoID *int64   `json:"productInfoId"`
+	CompanyID     *int64   `json:"companyId"`
+	Quantity      *float64 `json:"quantity"`
+	StartLocation *string  `json:"startLocation"`
+	Destination   *string  `json:"destination"`
+	StartTime     *string  `json:"startTime"`
+	EndTime       *string  `json:"endTime"`
+	Status        *string  `json:"status"`
+	StatusReason  *string  `json:"statusReason"`
+	StatusTime    *string  `json:"statusTime"`
+	PrevID        *int64   `json:"prevLogId,omitempty"`
+}
+
+// saleInfoPayload 销售信息的入链内容，字段顺序即JSON键顺序，不可调整
+type saleInfoPayload struct {
+	ID          int     `json:"siId"`
+	LogisticsID *int64  `json:"logisticsId"`
+	SalePlaceID *int64  `json:"salePlaceId"`
+	Description *string `json:"siDescription"`
+	SaleTime    *string `json:"saleTime"`
+}
+
+// hashChainPayload 读取记录当前的入链内容(规范化JSON)，记录不存在时返回false
+// 直接读取数据表的原始字段，时间统一转换为UTC，保证写入时与校验时得到相同的内容
+func hashChainPayload(db *DB, entity string, entityID int) (string, bool, error) {
+	var payload interface{}
+	var err error
+	switch entity {
+	case model.HashChainProductInfo:
+		payload, err = readProductInfoPayload(db, entityID)
+	case model.HashChainLogistics:
+		payload, err = readLogisticsPayload(db, entityID)
+	case model.HashChainSaleInfo:
+		payload, err = readSaleInfoPayload(db, entityID)
+	default:
+		return "", false, fmt.Errorf("不支持入链的实体: %s", entity)
+	}
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		log.Println("读取入链内容失败:", err)
+		return "", false, err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
+
+func readProductInfoPayload(db *DB, id int) (*productInfoPayload, error) {
+	query := `SELECT pi_id, batch_no, product_id, product_place_id, seed, pi_description,
+		planting_date, harvest_date, quantity, unit
+		FROM product_info WHERE pi_id = ?`
+	var batchNo, seed, description, unit sql.NullString
+	var productID, productPlaceID sql.NullInt64
+	var plantingDate, harvestDate sql.NullTime
+	var quantity sql.NullFloat64
+
+	payload := &productInfoPayload{}
+	err := db.QueryRow(query, id).Scan(&payload.ID, &batchNo, &productID, &productPlaceID, &seed, &description,
+		&plantingDate, &harvestDate, &quantity, &unit)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.BatchNo = nullString(batchNo)
+	payload.ProductID = nullInt(productID)
+	payload.ProductPlaceID = nullInt(productPlaceID)
+	payload.SeedSource = nullString(seed)
+	payload.Description = nullString(description)
+	payload.PlantingDate = nullTimeText(plantingDate)
+	payload.HarvestDate = nullTimeText(harvestDate)
+	payload.Quantity = nullFloat(quantity)
+	payload.Unit = nullString(unit)
+	return payload, nil
+}
+
+func readLogisticsPayload(db *DB, id int) (*logisticsPayload, error) {
+	query := `SELECT log_id, product_info_id, company_id, quantity, start_location, destination,
+		start_time, end_time, status, status_reason, status_time, prev_log_id
+		FROM logistics WHERE log_id = ?`
+	var productInfoID, companyID, prevID sql.NullInt64
+	var quantity sql.NullFloat64
+	var startLocation, destination, status, statusReason sql.NullString
+	var startTime, endTime, statusTime sql.NullTime
+
+	payload := &logisticsPayload{}
+	err := db.QueryRow(query, id).Scan(&payload.ID, &productInfoID, &companyID, &quantity, &startLocation, &destination,
+		&startTime, &endTime, &status, &statusReason, &statusTime, &prevID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.ProductInfoID = nullInt(productInfoID)
+	payload.CompanyID = nullInt(companyID)
+	payload.Quantity = nullFloat(quantity)
+	payload.StartLocation = nullString(startLocation)
+	payload.Destination = nullString(destination)
+	payload.StartTime = nullTimeText(startTime)
+	payload.EndTime = nullTimeText(endTime)
+	payload.Status = nullString(status)
+	payload.StatusReason = nullString(statusReason)
+	payload.StatusTime = nullTimeText(statusTime)
+	payload.PrevID = nullInt(prevID)
+	return payload, nil
+}
+
+func readSaleInfoPayload(db *DB, id int) (*saleInfoPayload, error) {
+	query := "SELECT si_id, logistics_id, sale_place_id, si_description, sale_time FROM sale_info WHERE si_id = ?"
+	var logisticsID, salePlaceID sql.NullInt64
+	var description sql.NullString
+	var saleTime sql.NullTime
+
+	payload := &saleInfoPayload{}
+	err := db.QueryRow(query, id).Scan(&payload.ID, &logisticsID, &salePlaceID, &description, &saleTime)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.LogisticsID = nullInt(logisticsID)
+	payload.SalePlaceID = nullInt(salePlaceID)
+	payload.Description = nullString(description)
+	payload.SaleTime = nullTimeText(saleTime)
+	return payload, nil
+}
+
+// queryIDs 查询一列ID
+func queryIDs(db *DB, query string, args ...interface{}) ([]int, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// scanHashChainEntry 读取一个哈希链条目
+func scanHashChainEntry(row rowScanner) (*model.HashChainEntry, error) {
+	entry := &model.HashChainEntry{}
+	err := row.Scan(&entry.Seq, &entry.Entity, &entry.EntityID, &entry.Action, &entry.Payload,
+		&entry.RecordHash, &entry.PrevHash, &entry.Hash, &entry.CreateTime)
+	if err != nil {
+		return nil, err
+	}
+	entry.CreateTime = entry.CreateTime.UTC()
+	return entry, nil
+}
+
+// nullString 将可为空的字符串转换为指针
+func nullString(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+// nullInt 将可为空的整数转换为指针
+func nullInt(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
+
+// nullTimeText 将可为空的时间转换为UTC的RFC3339文本
+func nullTimeText(value sql.NullTime) *string {
+	if !value.Valid {
+		return nil
+	}
+	text := value.Time.UTC().Format(time.RFC3339Nano)
+	return &text
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+// assertLinked 校验条目按顺序首尾相连且哈希可复算
+func assertLinked(t *testing.T, entries []*model.HashChainEntry) {
+	t.Helper()
+	prevHash := ""
+	for i, entry := range entries {
+		if entry.Seq != i+1 || entry.PrevHash != prevHash {
+			t.Fatalf("第%d个条目 seq = %d, prevHash = %q, 期望 %d, %q", i, entry.Seq, entry.PrevHash, i+1, prevHash)
+		}
+		if entry.RecordHash != entry.ComputeRecordHash() || entry.Hash != entry.ComputeHash() {
+			t.Fatalf("第%d个条目哈希不一致: %+v", i, entry)
+		}
+		prevHash = entry.Hash
+	}
+}
+
+func TestHashChainRepository(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewHashChainRepository(db)
+
+	// seed按 生产 -> 物流 -> 销售 的顺序写入两套记录
+	entries, err := repo.FindRange(1, 100)
+	mustNoError(t, err)
+	if len(entries) != 6 {
+		t.Fatalf("条目数 = %d, 期望6", len(entries))
+	}
+	assertLinked(t, entries)
+	wantRefs := []model.HashChainRef{
+		{Entity: model.HashChainProductInfo, EntityID: f.Productions[0]},
+		{Entity: model.HashChainLogistics, EntityID: f.Logistics[0]},
+		{Entity: model.HashChainSaleInfo, EntityID: f.SaleInfos[0]},
+	}
+	for i, want := range wantRefs {
+		if entries[i].Entity != want.Entity || entries[i].EntityID != want.EntityID || entries[i].Action != model.HashChainCreate {
+			t.Fatalf("第%d个条目 = %+v, 期望 %+v", i, entries[i], want)
+		}
+	}
+	page, err := repo.FindRange(5, 10)
+	mustNoError(t, err)
+	if len(page) != 2 || page[0].Seq != 5 {
+		t.Fatalf("FindRange(5, 10) = %d个条目", len(page))
+	}
+
+	// 更新后最近的条目内容与记录当前内容一致
+	logistics := NewLogisticsRepository(db)
+	leg, err := logistics.GetByID(f.Logistics[0])
+	mustNoError(t, err)
+	leg.Destination = "超市1"
+	_, err = logistics.Update(leg, leg.Status)
+	mustNoError(t, err)
+	latest, err := repo.FindLatest(model.HashChainLogistics, f.Logistics[0])
+	mustNoError(t, err)
+	payload, found, err := repo.Payload(model.HashChainLogistics, f.Logistics[0])
+	mustNoError(t, err)
+	if latest == nil || latest.Seq != 7 || latest.Action != model.HashChainUpdate || !found || latest.Payload != payload {
+		t.Fatalf("FindLatest = %+v, Payload = %q", latest, payload)
+	}
+
+	// 删除追加空内容的条目，记录不存在时Payload返回false
+	sales := NewSaleInfoRepository(db)
+	mustNoError(t, sales.Delete(f.SaleInfos[1]))
+	latest, err = repo.FindLatest(model.HashChainSaleInfo, f.SaleInfos[1])
+	mustNoError(t, err)
+	if latest.Action != model.HashChainDelete || latest.Payload != "" {
+		t.Fatalf("删除条目 = %+v", latest)
+	}
+	_, found, err = repo.Payload(model.HashChainSaleInfo, f.SaleInfos[1])
+	mustNoError(t, err)
+	if found {
+		t.Fatal("已删除记录的Payload返回found")
+	}
+	// 删除不存在的记录不追加条目
+	mustNoError(t, sales.Delete(f.SaleInfos[1]))
+	entries, err = repo.FindRange(1, 100)
+	mustNoError(t, err)
+	if len(entries) != 8 {
+		t.Fatalf("条目数 = %d, 期望8", len(entries))
+	}
+	assertLinked(t, entries)
+
+	latest, err = repo.FindLatest(model.HashChainSaleInfo, 999)
+	mustNoError(t, err)
+	if latest != nil {
+		t.Fatalf("未入链记录的FindLatest = %+v", latest)
+	}
+	if _, _, err := repo.Payload("product", 1); err == nil {
+		t.Fatal("不入链的实体Payload应返回错误")
+	}
+
+	// 绕过仓库直接写入的记录
+	_, err = db.Exec("INSERT INTO sale_info(logistics_id, sale_place_id, si_description, sale_time) VALUES(?, ?, ?, ?)",
+		f.Logistics[1], f.SalePlaces[1], "直接写入", testTime)
+	mustNoError(t, err)
+	refs, err := repo.FindUnchained()
+	mustNoError(t, err)
+	if len(refs) != 1 || refs[0].Entity != model.HashChainSaleInfo || refs[0].EntityID != f.SaleInfos[1]+1 {
+		t.Fatalf("FindUnchained = %+v", refs)
+	}
+
+	// 链不为空时Seal不做任何操作
+	sealed, err := repo.Seal()
+	mustNoError(t, err)
+	if sealed != 0 {
+		t.Fatalf("链不为空时Seal = %d", sealed)
+	}
+}
+
+func TestHashChainRepository_Seal(t *testing.T) {
+	db := newTestDB(t)
+	seed(t, db)
+	repo := NewHashChainRepository(db)
+
+	// 模拟启用哈希链之前已存在的数据
+	_, err := db.Exec("DELETE FROM hash_chain")
+	mustNoError(t, err)
+
+	sealed, err := repo.Seal()
+	mustNoError(t, err)
+	if sealed != 6 {
+		t.Fatalf("Seal = %d, 期望6", sealed)
+	}
+	refs, err := repo.FindUnchained()
+	mustNoError(t, err)
+	if len(refs) != 0 {
+		t.Fatalf("Seal后FindUnchained = %+v", refs)
+	}
+	entries, err := repo.FindRange(1, 100)
+	mustNoError(t, err)
+	assertLinked(t, entries)
+	// 按表依次入链
+	if entries[0].Entity != model.HashChainProductInfo || entries[5].Entity != model.HashChainSaleInfo {
+		t.Fatalf("Seal入链顺序 = %s ... %s", entries[0].Entity, entries[5].Entity)
+	}
+}
+
+func TestHashChainRepository_LogisticsPrevLeg(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewHashChainRepository(db)
+
+	prevID := f.Logistics[0]
+	next, err := NewLogisticsRepository(db).Save(&model.Logistics{
+		ProductInfoID: f.Productions[0], CompanyID: f.Companies[1], PrevID: &prevID,
+		StartLocation: "中转站", Destination: "超市0", StartTime: testTime, Status: model.LogisticsStatusCreated,
+	})
+	mustNoError(t, err)
+
+	// 首段不含上一运输段，后续运输段的上一段纳入入链内容
+	first, _, err := repo.Payload(model.HashChainLogistics, f.Logistics[0])
+	mustNoError(t, err)
+	if strings.Contains(first, "prevLogId") {
+		t.Fatalf("首段入链内容 = %s", first)
+	}
+	payload, _, err := repo.Payload(model.HashChainLogistics, next)
+	mustNoError(t, err)
+	if !strings.HasSuffix(payload, fmt.Sprintf(`,"prevLogId":%d}`, prevID)) {
+		t.Fatalf("后续运输段入链内容 = %s", payload)
+	}
+
+	// 直接改写上一运输段后与链上的内容不一致
+	_, err = db.Exec("UPDATE logistics SET prev_log_id = ? WHERE log_id = ?", f.Logistics[1], next)
+	mustNoError(t, err)
+	latest, err := repo.FindLatest(model.HashChainLogistics, next)
+	mustNoError(t, err)
+	payload, _, err = repo.Payload(model.HashChainLogistics, next)
+	mustNoError(t, err)
+	if latest.Payload == payload {
+		t.Fatal("改写上一运输段后入链内容未变化")
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"agricultural_product_gin/model"
+)
+
+// rowScanner 兼容*sql.Row与*sql.Rows的扫描接口
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// nullableID 将0值ID转换为NULL
+func nullableID(id int) interface{} {
+	if id <= 0 {
+		return nil
+	}
+	return id
+}
+
+// scopeColumns 数据权限各维度对应的表字段，为空表示该表不按此维度过滤
+// 字段中包含占位符时视为完整的过滤条件(如关联表的子查询)，占位符绑定范围值
+type scopeColumns struct {
+	Company      string
+	ProductPlace string
+	SalePlace    string
+}
+
+// scopeConditions 根据数据权限范围生成过滤条件
+// 未绑定的账号范围值为0，不会匹配任何数据
+func scopeConditions(scope model.DataScope, columns scopeColumns) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if scope.CompanyID != nil && columns.Company != "" {
+		conditions = append(conditions, scopeCondition(columns.Company))
+		args = append(args, *scope.CompanyID)
+	}
+	if scope.ProductPlaceID != nil && columns.ProductPlace != "" {
+		conditions = append(conditions, scopeCondition(columns.ProductPlace))
+		args = append(args, *scope.ProductPlaceID)
+	}
+	if scope.SalePlaceID != nil && columns.SalePlace != "" {
+		conditions = append(conditions, scopeCondition(columns.SalePlace))
+		args = append(args, *scope.SalePlaceID)
+	}
+
+	return conditions, args
+}
+
+// scopeCondition 数据权限字段的过滤条件
+func scopeCondition(column string) string {
+	if strings.Contains(column, "?") {
+		return column
+	}
+	return column + " = ?"
+}
+
+// softDeleteCondition 软删除过滤条件，includeDeleted为false时只保留未删除的记录
+func softDeleteCondition(conditions []string, includeDeleted bool) []string {
+	if includeDeleted {
+		return conditions
+	}
+	return append(conditions, "deleted_at IS NULL")
+}
+
+// softDeleteFields 软删除字段的扫描目标
+type softDeleteFields struct {
+	DeletedAt sql.NullTime
+	DeletedBy sql.NullString
+}
+
+// apply 将读取到的软删除字段写入实体
+func (f *softDeleteFields) apply(target *model.SoftDelete) {
+	if f.DeletedAt.Valid {
+		target.DeletedAt = &f.DeletedAt.Time
+	}
+	target.DeletedBy = f.DeletedBy.String
+}
@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"agricultural_product_gin/model"
+)
+
+// InspectionRepository 质量检测仓库接口
+type InspectionRepository interface {
+	Save(inspection *model.Inspection) (int, error)
+	Update(inspection *model.Inspection) error
+	Delete(id int) error
+	GetByID(id int) (*model.Inspection, error)
+	FindByProductInfoID(productInfoID int) ([]*model.Inspection, error)
+	PageQuery(dto *model.InspectionPageQueryDTO, scope model.DataScope) ([]*model.Inspection, int64, error)
+}
+
+// InspectionRepositoryImpl 质量检测仓库的数据库实现
+type InspectionRepositoryImpl struct {
+	DB *DB
+}
+
+// NewInspectionRepository 创建质量检测仓库
+func NewInspectionRepository(db *DB) InspectionRepository {
+	return &InspectionRepositoryImpl{DB: db}
+}
+
+// inspectionScopeColumns 检测记录的数据权限字段：农场按批次所属生产地，物流公司按抽检环节的承运公司
+var inspectionScopeColumns = scopeColumns{Company: "l.company_id", ProductPlace: "pi.product_place_id"}
+
+// inspectionFrom 检测记录关联的批次、产品及运输环节
+const inspectionFrom = `FROM inspection i
+		JOIN product_info pi ON i.product_info_id = pi.pi_id
+		LEFT JOIN product p ON pi.product_id = p.pd_id
+		LEFT JOIN logistics l ON i.logistics_id = l.log_id`
+
+// inspectionSelect 检测记录查询字段
+const inspectionSelect = `SELECT i.ins_id, i.product_info_id, i.logistics_id, i.inspection_type, i.lab_name,
+		COALESCE(i.report_no, ''), i.inspection_date, i.verdict, COALESCE(i.report_url, ''), COALESCE(i.remark, ''),
+		COALESCE(pi.batch_no, ''), COALESCE(p.pd_name, ''), pi.product_place_id, l.company_id
+		` + inspectionFrom
+
+// Save 在一个事务中保存检测记录及检测项目
+func (r *InspectionRepositoryImpl) Save(inspection *model.Inspection) (int, error) {
+	var id int
+	err := r.DB.Transaction(func(tx *DB) error {
+		query := `INSERT INTO inspection(product_info_id, logistics_id, inspection_type, lab_name, report_no,
+			inspection_date, verdict, report_url, remark) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		result, err := tx.Exec(query, inspection.ProductInfoID, nullableID(inspection.LogisticsID),
+			inspection.InspectionType, inspection.LabName, inspection.ReportNo, inspection.InspectionDate,
+			inspection.Verdict, inspection.ReportURL, inspection.Remark)
+		if err != nil {
+			log.Println("保存检测记录失败:", err)
+			return err
+		}
+
+		insertID, err := result.LastInsertId()
+		if err != nil {
+			log.Println("获取检测记录ID失败:", err)
+			return err
+		}
+		id = int(insertID)
+		return saveInspectionItems(tx, id, inspection.Items)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Update 在一个事务中更新检测记录，检测项目整体替换
+func (r *InspectionRepositoryImpl) Update(inspection *model.Inspection) error {
+	return r.DB.Transaction(func(tx *DB) error {
+		query := `UPDATE inspection SET product_info_id = ?, logistics_id = ?, inspection_type = ?, lab_name = ?,
+			report_no = ?, inspection_date = ?, verdict = ?, report_url = ?, remark = ? WHERE ins_id = ?`
+		_, err := tx.Exec(query, inspection.ProductInfoID, nullableID(inspection.LogisticsID),
+			inspection.InspectionType, inspection.LabName, inspection.ReportNo, inspection.InspectionDate,
+			inspection.Verdict, inspection.ReportURL, inspection.Remark, inspection.ID)
+		if err != nil {
+			log.Println("更新检测记录失败:", err)
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM inspection_item WHERE ins_id = ?", inspection.ID); err != nil {
+			log.Println("删除检测项目失败:", err)
+			return err
+		}
+		return saveInspectionItems(tx, inspection.ID, inspection.Items)
+	})
+}
+
+// Delete 删除检测记录(检测项目级联删除)
+func (r *InspectionRepositoryImpl) Delete(id int) error {
+	_, err := r.DB.Exec("DELETE FROM inspection WHERE ins_id = ?", id)
+	if err != nil {
+		log.Println("删除检测记录失败:", err)
+		return err
+	}
+	return nil
+}
+
+// GetByID 根据ID获取检测记录及检测项目
+func (r *InspectionRepositoryImpl) GetByID(id int) (*model.Inspection, error) {
+	inspection, err := scanInspection(r.DB.QueryRow(inspectionSelect+" WHERE i.ins_id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("获取检测记录失败:", err)
+		return nil, err
+	}
+
+	inspection.Items, err = r.findItems(inspection.ID)
+	if err != nil {
+		return nil, err
+	}
+	return inspection, nil
+}
+
+// FindByProductInfoID 查询批次的检测记录及检测项目(按检测时间排序)
+func (r *InspectionRepositoryImpl) FindByProductInfoID(productInfoID int) ([]*model.Inspection, error) {
+	query := inspectionSelect + " WHERE i.product_info_id = ? ORDER BY i.inspection_date, i.ins_id"
+	inspections, err := r.query(query, productInfoID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inspection := range inspections {
+		inspection.Items, err = r.findItems(inspection.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inspections, nil
+}
+
+// PageQuery 在数据权限范围内分页查询检测记录(不含检测项目)
+func (r *InspectionRepositoryImpl) PageQuery(dto *model.InspectionPageQueryDTO, scope model.DataScope) ([]*model.Inspection, int64, error) {
+	conditions, args := scopeConditions(scope, inspectionScopeColumns)
+
+	if dto.ProductInfoID > 0 {
+		conditions = append(conditions, "i.product_info_id = ?")
+		args = append(args, dto.ProductInfoID)
+	}
+
+	if dto.LogisticsID > 0 {
+		conditions = append(conditions, "i.logistics_id = ?")
+		args = append(args, dto.LogisticsID)
+	}
+
+	if dto.BatchNo != "" {
+		conditions = append(conditions, "pi.batch_no LIKE ?")
+		args = append(args, "%"+dto.BatchNo+"%")
+	}
+
+	if dto.InspectionType != "" {
+		conditions = append(conditions, "i.inspection_type = ?")
+		args = append(args, dto.InspectionType)
+	}
+
+	if dto.Verdict != "" {
+		conditions = append(conditions, "i.verdict = ?")
+		args = append(args, dto.Verdict)
+	}
+
+	if dto.LabName != "" {
+		conditions = append(conditions, "i.lab_name LIKE ?")
+		args = append(args, "%"+dto.LabName+"%")
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	countQuery := fmt.Sprintf("SELECT COUNT(*) %s%s", inspectionFrom, whereClause)
+	if err := r.DB.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		log.Println("查询检测记录总数失败:", err)
+		return nil, 0, err
+	}
+
+	offset := (dto.Page - 1) * dto.Size
+	dataQuery := fmt.Sprintf("%s%s ORDER BY i.inspection_date DESC, i.ins_id DESC LIMIT ? OFFSET ?", inspectionSelect, whereClause)
+	inspections, err := r.query(dataQuery, append(args, dto.Size, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return inspections, total, nil
+}
+
+// query 查询检测记录列表
+func (r *InspectionRepositoryImpl) query(query string, args ...interface{}) ([]*model.Inspection, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		log.Println("查询检测记录失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	inspections := []*model.Inspection{}
+	for rows.Next() {
+		inspection, err := scanInspection(rows)
+		if err != nil {
+			log.Println("读取检测记录失败:", err)
+			return nil, err
+		}
+		inspections = append(inspections, inspection)
+	}
+	return inspections, rows.Err()
+}
+
+// findItems 查询检测记录的检测项目
+func (r *InspectionRepositoryImpl) findItems(inspectionID int) ([]*model.InspectionItem, error) {
+	query := `SELECT item_id, ins_id, item_name, value, COALESCE(result_text, ''), COALESCE(unit, ''),
+		limit_min, limit_max, passed FROM inspection_item WHERE ins_id = ? ORDER BY item_id`
+	rows, err := r.DB.Query(query, inspectionID)
+	if err != nil {
+		log.Println("查询检测项目失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*model.InspectionItem{}
+	for rows.Next() {
+		item := &model.InspectionItem{}
+		var value, limitMin, limitMax sql.NullFloat64
+		if err := rows.Scan(&item.ID, &item.InspectionID, &item.ItemName, &value, &item.ResultText,
+			&item.Unit, &limitMin, &limitMax, &item.Passed); err != nil {
+			log.Println("读取检测项目失败:", err)
+			return nil, err
+		}
+		item.Value = nullFloat(value)
+		item.LimitMin = nullFloat(limitMin)
+		item.LimitMax = nullFloat(limitMax)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// saveInspectionItems 保存检测项目
+func saveInspectionItems(tx *DB, inspectionID int, items []*model.InspectionItem) error {
+	query := `INSERT INTO inspection_item(ins_id, item_name, value, result_text, unit, limit_min, limit_max, passed)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)`
+	for _, item := range items {
+		_, err := tx.Exec(query, inspectionID, item.ItemName, item.Value, item.ResultText, item.Unit,
+			item.LimitMin, item.LimitMax, item.Passed)
+		if err != nil {
+			log.Println("保存检测项目失败:", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// scanInspection 读取一行检测记录
+func scanInspection(row rowScanner) (*model.Inspection, error) {
+	inspection := &model.Inspection{}
+	var logisticsID, companyID sql.NullInt64
+
+	err := row.Scan(&inspection.ID, &inspection.ProductInfoID, &logisticsID, &inspection.InspectionType,
+		&inspection.LabName, &inspection.ReportNo, &inspection.InspectionDate, &inspection.Verdict,
+		&inspection.ReportURL, &inspection.Remark, &inspection.BatchNo, &inspection.ProductName,
+		&inspection.ProductPlaceID, &companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	inspection.LogisticsID = int(logisticsID.Int64)
+	inspection.CompanyID = int(companyID.Int64)
+	return inspection, nil
+}
+
+// nullFloat 将可为空的数值转换为指针
+func nullFloat(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
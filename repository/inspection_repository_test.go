@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+// seedInspections 为两个批次各保存一条检测记录，批次1的记录为运输环节抽检
+func seedInspections(t *testing.T, repo InspectionRepository, f *fixture) [2]int {
+	t.Helper()
+
+	limit := 0.1
+	values := [2]float64{0.05, 0.5}
+	verdicts := [2]string{model.InspectionVerdictPass, model.InspectionVerdictFail}
+	var ids [2]int
+	for i := 0; i < 2; i++ {
+		inspection := &model.Inspection{
+			ProductInfoID:  f.Productions[i],
+			InspectionType: model.InspectionTypeResidue,
+			LabName:        "检测中心",
+			ReportNo:       "R" + f.productNames[i],
+			InspectionDate: testTime.Add(time.Duration(i) * time.Hour),
+			Verdict:        verdicts[i],
+			Items: []*model.InspectionItem{
+				{ItemName: "毒死蜱", Value: &values[i], Unit: "mg/kg", LimitMax: &limit, Passed: i == 0},
+			},
+		}
+		if i == 1 {
+			inspection.LogisticsID = f.Logistics[1]
+		}
+		var err error
+		ids[i], err = repo.Save(inspection)
+		mustNoError(t, err)
+	}
+	return ids
+}
+
+func TestInspectionRepository_CRUD(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewInspectionRepository(db)
+	ids := seedInspections(t, repo, f)
+
+	got, err := repo.GetByID(ids[1])
+	mustNoError(t, err)
+	if got == nil || got.LogisticsID != f.Logistics[1] || got.CompanyID != f.Companies[1] || got.ProductPlaceID != f.Places[1] ||
+		got.BatchNo != "B0001" || got.ProductName != "白菜" || got.Verdict != model.InspectionVerdictFail {
+		t.Fatalf("GetByID = %+v", got)
+	}
+	if len(got.Items) != 1 || got.Items[0].Value == nil || *got.Items[0].Value != 0.5 || got.Items[0].LimitMin != nil || got.Items[0].Passed {
+		t.Fatalf("检测项目 = %+v", got.Items)
+	}
+
+	// 更新时检测项目整体替换
+	got.LogisticsID = 0
+	got.Verdict = model.InspectionVerdictPass
+	got.Items = []*model.InspectionItem{
+		{ItemName: "等级", ResultText: "一级", Passed: true},
+		{ItemName: "糖度", ResultText: "12", Unit: "Brix", Passed: true},
+	}
+	mustNoError(t, repo.Update(got))
+	got, err = repo.GetByID(ids[1])
+	mustNoError(t, err)
+	if got.LogisticsID != 0 || got.CompanyID != 0 || got.Verdict != model.InspectionVerdictPass || len(got.Items) != 2 || got.Items[0].ResultText != "一级" {
+		t.Fatalf("Update后 = %+v, 检测项目 = %+v", got, got.Items)
+	}
+
+	list, err := repo.FindByProductInfoID(f.Productions[1])
+	mustNoError(t, err)
+	assertIDs(t, list, func(i *model.Inspection) int { return i.ID }, []int{ids[1]})
+	if len(list[0].Items) != 2 {
+		t.Fatalf("FindByProductInfoID检测项目 = %+v", list[0].Items)
+	}
+
+	mustNoError(t, repo.Delete(ids[1]))
+	got, err = repo.GetByID(ids[1])
+	mustNoError(t, err)
+	if got != nil {
+		t.Fatalf("Delete后 = %+v", got)
+	}
+	var items int
+	mustNoError(t, db.QueryRow("SELECT COUNT(*) FROM inspection_item WHERE ins_id = ?", ids[1]).Scan(&items))
+	if items != 0 {
+		t.Fatalf("检测项目未级联删除, 剩余 %d 条", items)
+	}
+}
+
+func TestInspectionRepository_PageQuery(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewInspectionRepository(db)
+	ids := seedInspections(t, repo, f)
+
+	tests := []struct {
+		name      string
+		query     model.InspectionPageQueryDTO
+		scope     model.DataScope
+		wantTotal int64
+		wantIDs   []int
+	}{
+		{name: "不限范围按检测时间倒序", wantTotal: 2, wantIDs: []int{ids[1], ids[0]}},
+		{name: "按批次", query: model.InspectionPageQueryDTO{ProductInfoID: f.Productions[0]}, wantTotal: 1, wantIDs: []int{ids[0]}},
+		{name: "按物流", query: model.InspectionPageQueryDTO{LogisticsID: f.Logistics[1]}, wantTotal: 1, wantIDs: []int{ids[1]}},
+		{name: "按批次号", query: model.InspectionPageQueryDTO{BatchNo: "B0000"}, wantTotal: 1, wantIDs: []int{ids[0]}},
+		{name: "按检测类型", query: model.InspectionPageQueryDTO{InspectionType: model.InspectionTypeGrading}, wantTotal: 0},
+		{name: "按结论", query: model.InspectionPageQueryDTO{Verdict: model.InspectionVerdictFail}, wantTotal: 1, wantIDs: []int{ids[1]}},
+		{name: "按检测机构", query: model.InspectionPageQueryDTO{LabName: "检测"}, wantTotal: 2, wantIDs: []int{ids[1], ids[0]}},
+		{name: "分页", query: model.InspectionPageQueryDTO{Page: 2, Size: 1}, wantTotal: 2, wantIDs: []int{ids[0]}},
+		{name: "农场只看到本生产地批次的", scope: productPlaceScope(f.Places[0]), wantTotal: 1, wantIDs: []int{ids[0]}},
+		{name: "物流公司只看到自己承运环节的", scope: companyScope(f.Companies[1]), wantTotal: 1, wantIDs: []int{ids[1]}},
+		{name: "非运输环节的检测对物流公司不可见", scope: companyScope(f.Companies[0]), wantTotal: 0},
+		{name: "销售地范围不过滤检测记录", scope: salePlaceScope(f.SalePlaces[0]), wantTotal: 2, wantIDs: []int{ids[1], ids[0]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			if query.Page == 0 {
+				query.Page, query.Size = 1, 10
+			}
+			list, total, err := repo.PageQuery(&query, tt.scope)
+			mustNoError(t, err)
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, 期望 %d", total, tt.wantTotal)
+			}
+			assertIDs(t, list, func(i *model.Inspection) int { return i.ID }, tt.wantIDs)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"database/sql"
+	"log"
+
+	"agricultural_product_gin/model"
+)
+
+// LogisticsEventRepository 物流事件数据仓库接口
+type LogisticsEventRepository interface {
+	Save(event *model.LogisticsEvent) (int, error)
+	Delete(id int) error
+	GetByID(id int) (*model.LogisticsEvent, error)
+	FindByLogisticsID(logisticsID int) ([]*model.LogisticsEvent, error)
+}
+
+// LogisticsEventRepositoryImpl 物流事件数据仓库的数据库实现
+type LogisticsEventRepositoryImpl struct {
+	DB *DB
+}
+
+// NewLogisticsEventRepository 创建物流事件仓库
+func NewLogisticsEventRepository(db *DB) LogisticsEventRepository {
+	return &LogisticsEventRepositoryImpl{DB: db}
+}
+
+// Save 保存物流事件
+func (r *LogisticsEventRepositoryImpl) Save(event *model.LogisticsEvent) (int, error) {
+	query := "INSERT INTO logistics_event(log_id, event_type, location, company_id, event_time, remark) VALUES(?, ?, ?, ?, ?, ?)"
+	result, err := r.DB.Exec(query, event.LogisticsID, event.EventType, event.Location,
+		nullableID(event.CompanyID), event.EventTime, event.Remark)
+	if err != nil {
+		log.Println("保存物流事件失败:", err)
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		log.Println("获取物流事件ID失败:", err)
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Delete 删除物流事件
+func (r *LogisticsEventRepositoryImpl) Delete(id int) error {
+	query := "DELETE FROM logistics_event WHERE event_id = ?"
+	_, err := r.DB.Exec(query, id)
+	if err != nil {
+		log.Println("删除物流事件失败:", err)
+		return err
+	}
+	return nil
+}
+
+// GetByID 根据ID获取物流事件
+func (r *LogisticsEventRepositoryImpl) GetByID(id int) (*model.LogisticsEvent, error) {
+	query := `SELECT e.event_id, e.log_id, e.event_type, COALESCE(e.location, ''), e.company_id,
+			e.event_time, COALESCE(e.remark, ''), COALESCE(c.com_name, '')
+			FROM logistics_event e
+			LEFT JOIN company c ON e.company_id = c.com_id
+			WHERE e.event_id = ?`
+
+	event, err := scanLogisticsEvent(r.DB.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("获取物流事件失败:", err)
+		return nil, err
+	}
+	return event, nil
+}
+
+// FindByLogisticsID 查询物流记录的事件时间线(按发生时间排序)
+func (r *LogisticsEventRepositoryImpl) FindByLogisticsID(logisticsID int) ([]*model.LogisticsEvent, error) {
+	return findLogisticsEvents(r.DB, logisticsID)
+}
+
+// findLogisticsEvents 查询物流记录的事件时间线，供物流仓库复用
+func findLogisticsEvents(db *DB, logisticsID int) ([]*model.LogisticsEvent, error) {
+	query := `SELECT e.event_id, e.log_id, e.event_type, COALESCE(e.location, ''), e.company_id,
+			e.event_time, COALESCE(e.remark, ''), COALESCE(c.com_name, '')
+			FROM logistics_event e
+			LEFT JOIN company c ON e.company_id = c.com_id
+			WHERE e.log_id = ?
+			ORDER BY e.event_time, e.event_id`
+
+	rows, err := db.Query(query, logisticsID)
+	if err != nil {
+		log.Println("查询物流事件失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*model.LogisticsEvent{}
+	for rows.Next() {
+		event, err := scanLogisticsEvent(rows)
+		if err != nil {
+			log.Println("读取物流事件数据失败:", err)
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// scanLogisticsEvent 读取一行物流事件数据
+func scanLogisticsEvent(row rowScanner) (*model.LogisticsEvent, error) {
+	event := &model.LogisticsEvent{}
+	var companyID sql.NullInt64
+
+	err := row.Scan(&event.ID, &event.LogisticsID, &event.EventType, &event.Location, &companyID,
+		&event.EventTime, &event.Remark, &event.CompanyName)
+	if err != nil {
+		return nil, err
+	}
+
+	event.CompanyID = int(companyID.Int64)
+	return event, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+func TestLogisticsEventRepository(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewLogisticsEventRepository(db)
+
+	handover, err := repo.Save(&model.LogisticsEvent{
+		LogisticsID: f.Logistics[0], EventType: model.LogisticsEventHandover, Location: "中转站",
+		CompanyID: f.Companies[1], EventTime: testTime.Add(3 * time.Hour), Remark: "交接",
+	})
+	mustNoError(t, err)
+	pickup, err := repo.Save(&model.LogisticsEvent{
+		LogisticsID: f.Logistics[0], EventType: model.LogisticsEventPickup, Location: "农场0", EventTime: testTime.Add(time.Hour),
+	})
+	mustNoError(t, err)
+
+	got, err := repo.GetByID(handover)
+	mustNoError(t, err)
+	if got == nil || got.CompanyID != f.Companies[1] || got.CompanyName != "物流1" || got.Remark != "交接" {
+		t.Fatalf("GetByID = %+v", got)
+	}
+	got, err = repo.GetByID(pickup)
+	mustNoError(t, err)
+	if got.CompanyID != 0 || got.CompanyName != "" {
+		t.Fatalf("未指定公司时GetByID = %+v", got)
+	}
+
+	// 按发生时间排序
+	events, err := repo.FindByLogisticsID(f.Logistics[0])
+	mustNoError(t, err)
+	assertIDs(t, events, func(e *model.LogisticsEvent) int { return e.ID }, []int{pickup, handover})
+	events, err = repo.FindByLogisticsID(f.Logistics[1])
+	mustNoError(t, err)
+	if len(events) != 0 {
+		t.Fatalf("其他物流的事件 = %+v", events)
+	}
+
+	mustNoError(t, repo.Delete(handover))
+	got, err = repo.GetByID(handover)
+	mustNoError(t, err)
+	if got != nil {
+		t.Fatalf("Delete后 = %+v", got)
+	}
+}
@@ -9,19 +9,32 @@ import (
 	"time"
 )
 
-// LogisticsRepository 物流数据仓库
-type LogisticsRepository struct {
-	DB *sql.DB
+// LogisticsRepository 物流数据仓库接口
+type LogisticsRepository interface {
+	Save(logistics *model.Logistics) (int, error)
+	Update(logistics *model.Logistics, status string) (bool, error)
+	Delete(id int) error
+	GetByID(id int) (*model.Logistics, error)
+	FindAll(scope model.DataScope) ([]*model.Logistics, error)
+	PageQuery(dto *model.LogisticsPageQueryDTO, scope model.DataScope) ([]*model.Logistics, int64, error)
+	FindByProductInfoID(productInfoID int) ([]*model.Logistics, error)
+	UpdateStatus(id int, fromStatus, status, reason string, statusTime time.Time, endTime *time.Time) (bool, error)
+	ProductPlaceIDOf(productInfoID int) (int, error)
+}
+
+// LogisticsRepositoryImpl 物流数据仓库的数据库实现
+type LogisticsRepositoryImpl struct {
+	DB *DB
 }
 
 // NewLogisticsRepository 创建物流仓库
-func NewLogisticsRepository(db *sql.DB) *LogisticsRepository {
-	return &LogisticsRepository{DB: db}
+func NewLogisticsRepository(db *DB) LogisticsRepository {
+	return &LogisticsRepositoryImpl{DB: db}
 }
 
-// Save 保存物流信息
-func (r *LogisticsRepository) Save(logistics *model.Logistics) (int, error) {
-	query := "INSERT INTO logistics(product_info_id, company_id, start_location, destination, start_time, end_time) VALUES(?, ?, ?, ?, ?, ?)"
+// Save 保存物流信息，并在同一事务中追加到哈希链
+func (r *LogisticsRepositoryImpl) Save(logistics *model.Logistics) (int, error) {
+	query := "INSERT INTO logistics(product_info_id, company_id, prev_log_id, quantity, start_location, destination, start_time, end_time, status, status_time) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 
 	var endTimeValue interface{}
 	if logistics.EndTime != nil {
@@ -30,75 +43,73 @@ func (r *LogisticsRepository) Save(logistics *model.Logistics) (int, error) {
 		endTimeValue = nil
 	}
 
-	result, err := r.DB.Exec(query, logistics.ProductInfoID, logistics.CompanyID, logistics.StartLocation, logistics.Destination, logistics.StartTime, endTimeValue)
-	if err != nil {
-		log.Println("保存物流信息失败:", err)
-		return 0, err
-	}
+	var id int64
+	err := r.DB.Transaction(func(tx *DB) error {
+		result, err := tx.Exec(query, logistics.ProductInfoID, logistics.CompanyID, logistics.PrevID, logistics.Quantity, logistics.StartLocation, logistics.Destination, logistics.StartTime, endTimeValue,
+			logistics.Status, logistics.StatusTime)
+		if err != nil {
+			log.Println("保存物流信息失败:", err)
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			log.Println("获取物流ID失败:", err)
+			return err
+		}
 
-	id, err := result.LastInsertId()
+		return appendHashChain(tx, model.HashChainLogistics, int(id), model.HashChainCreate)
+	})
 	if err != nil {
-		log.Println("获取物流ID失败:", err)
 		return 0, err
 	}
 
 	return int(id), nil
 }
 
-// Update 更新物流信息
-func (r *LogisticsRepository) Update(logistics *model.Logistics) error {
+// Update 在物流状态仍为status时更新物流信息(状态与到达时间只能通过UpdateStatus修改)，并在同一事务中追加到哈希链
+// 返回是否由本次调用更新(状态已被并发修改时不更新)
+func (r *LogisticsRepositoryImpl) Update(logistics *model.Logistics, status string) (bool, error) {
 	query := `UPDATE logistics 
-			SET product_info_id = ?, company_id = ?, start_location = ?, 
-			destination = ?, start_time = ?, end_time = ? 
-			WHERE log_id = ?`
+			SET product_info_id = ?, company_id = ?, prev_log_id = ?, quantity = ?, start_location = ?, 
+			destination = ?, start_time = ? 
+			WHERE log_id = ? AND status = ?`
 
-	var endTimeValue interface{}
-	if logistics.EndTime != nil {
-		endTimeValue = logistics.EndTime
-	} else {
-		endTimeValue = nil
-	}
-
-	_, err := r.DB.Exec(query, logistics.ProductInfoID, logistics.CompanyID, logistics.StartLocation, logistics.Destination, logistics.StartTime, endTimeValue, logistics.ID)
-	if err != nil {
-		log.Println("更新物流信息失败:", err)
-		return err
-	}
-	return nil
+	updated := false
+	err := r.DB.Transaction(func(tx *DB) error {
+		result, err := tx.Exec(query, logistics.ProductInfoID, logistics.CompanyID, logistics.PrevID, logistics.Quantity, logistics.StartLocation, logistics.Destination, logistics.StartTime, logistics.ID, status)
+		if err != nil {
+			log.Println("更新物流信息失败:", err)
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil || affected == 0 {
+			return err
+		}
+		updated = true
+		return appendHashChain(tx, model.HashChainLogistics, logistics.ID, model.HashChainUpdate)
+	})
+	return updated, err
 }
 
-// Delete 删除物流信息
-func (r *LogisticsRepository) Delete(id int) error {
+// Delete 删除物流信息，并在同一事务中追加到哈希链
+func (r *LogisticsRepositoryImpl) Delete(id int) error {
 	query := "DELETE FROM logistics WHERE log_id = ?"
-	_, err := r.DB.Exec(query, id)
-	if err != nil {
-		log.Println("删除物流信息失败:", err)
-		return err
-	}
-	return nil
+	return r.DB.Transaction(func(tx *DB) error {
+		result, err := tx.Exec(query, id)
+		if err != nil {
+			log.Println("删除物流信息失败:", err)
+			return err
+		}
+		return appendHashChainDelete(tx, result, model.HashChainLogistics, id)
+	})
 }
 
 // GetByID 根据ID获取物流信息
-func (r *LogisticsRepository) GetByID(id int) (*model.Logistics, error) {
-	query := `SELECT l.log_id, l.product_info_id, l.company_id, l.start_location, l.destination, 
-			l.start_time, l.end_time, p.pd_name, c.com_name, c.com_administrator, c.com_phone
-			FROM logistics l
-			LEFT JOIN product_info pi ON l.product_info_id = pi.pi_id
-			LEFT JOIN product p ON pi.product_id = p.pd_id
-			LEFT JOIN company c ON l.company_id = c.com_id
-			WHERE l.log_id = ?`
-
-	row := r.DB.QueryRow(query, id)
-
-	logistics := &model.Logistics{}
-	var endTime sql.NullTime
-
-	err := row.Scan(
-		&logistics.ID, &logistics.ProductInfoID, &logistics.CompanyID,
-		&logistics.StartLocation, &logistics.Destination, &logistics.StartTime, &endTime,
-		&logistics.ProductName, &logistics.CompanyName, &logistics.Administrator, &logistics.Phone,
-	)
+func (r *LogisticsRepositoryImpl) GetByID(id int) (*model.Logistics, error) {
+	query := logisticsSelect + " WHERE l.log_id = ?"
 
+	logistics, err := scanLogistics(r.DB.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -107,25 +118,50 @@ func (r *LogisticsRepository) GetByID(id int) (*model.Logistics, error) {
 		return nil, err
 	}
 
-	if endTime.Valid {
-		logistics.EndTime = &endTime.Time
-	} else {
-		logistics.EndTime = nil
+	// 加载运输事件时间线
+	logistics.Events, err = findLogisticsEvents(r.DB, logistics.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logistics.SalePlaceIDs, err = r.findSalePlaceIDs(logistics.ID)
+	if err != nil {
+		return nil, err
 	}
 
 	return logistics, nil
 }
 
-// FindAll 查找所有物流信息
-func (r *LogisticsRepository) FindAll() ([]*model.Logistics, error) {
-	query := `SELECT l.log_id, l.product_info_id, l.company_id, l.start_location, l.destination, 
-			l.start_time, l.end_time, p.pd_name, c.com_name, c.com_administrator, c.com_phone
-			FROM logistics l
-			LEFT JOIN product_info pi ON l.product_info_id = pi.pi_id
-			LEFT JOIN product p ON pi.product_id = p.pd_id
-			LEFT JOIN company c ON l.company_id = c.com_id`
+// findSalePlaceIDs 查询物流关联的销售信息所在的销售地
+func (r *LogisticsRepositoryImpl) findSalePlaceIDs(logisticsID int) ([]int, error) {
+	rows, err := r.DB.Query("SELECT DISTINCT sale_place_id FROM sale_info WHERE logistics_id = ? AND sale_place_id IS NOT NULL", logisticsID)
+	if err != nil {
+		log.Println("查询物流关联的销售地失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
 
-	rows, err := r.DB.Query(query)
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			log.Println("读取销售地数据失败:", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// FindAll 查找数据权限范围内的所有物流信息
+func (r *LogisticsRepositoryImpl) FindAll(scope model.DataScope) ([]*model.Logistics, error) {
+	query := logisticsSelect
+	conditions, args := scopeConditions(scope, logisticsScopeColumns)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := r.DB.Query(query, args...)
 	if err != nil {
 		log.Println("查询物流信息失败:", err)
 		return nil, err
@@ -134,37 +170,21 @@ func (r *LogisticsRepository) FindAll() ([]*model.Logistics, error) {
 
 	var logisticsList []*model.Logistics
 	for rows.Next() {
-		logistics := &model.Logistics{}
-		var endTime sql.NullTime
-
-		err := rows.Scan(
-			&logistics.ID, &logistics.ProductInfoID, &logistics.CompanyID,
-			&logistics.StartLocation, &logistics.Destination, &logistics.StartTime, &endTime,
-			&logistics.ProductName, &logistics.CompanyName, &logistics.Administrator, &logistics.Phone,
-		)
-
+		logistics, err := scanLogistics(rows)
 		if err != nil {
 			log.Println("读取物流数据失败:", err)
 			return nil, err
 		}
-
-		if endTime.Valid {
-			logistics.EndTime = &endTime.Time
-		} else {
-			logistics.EndTime = nil
-		}
-
 		logisticsList = append(logisticsList, logistics)
 	}
 
 	return logisticsList, nil
 }
 
-// PageQuery 分页查询物流信息
-func (r *LogisticsRepository) PageQuery(dto *model.LogisticsPageQueryDTO) ([]*model.Logistics, int64, error) {
+// PageQuery 在数据权限范围内分页查询物流信息
+func (r *LogisticsRepositoryImpl) PageQuery(dto *model.LogisticsPageQueryDTO, scope model.DataScope) ([]*model.Logistics, int64, error) {
 	// 构建查询条件
-	conditions := []string{}
-	args := []interface{}{}
+	conditions, args := scopeConditions(scope, logisticsScopeColumns)
 
 	if dto.LogisticsId > 0 {
 		conditions = append(conditions, "l.log_id = ?")
@@ -191,6 +211,11 @@ func (r *LogisticsRepository) PageQuery(dto *model.LogisticsPageQueryDTO) ([]*mo
 		args = append(args, "%"+dto.Destination+"%")
 	}
 
+	if dto.Status != "" {
+		conditions = append(conditions, "l.status = ?")
+		args = append(args, dto.Status)
+	}
+
 	if dto.Administrator != "" {
 		conditions = append(conditions, "c.com_administrator LIKE ?")
 		args = append(args, "%"+dto.Administrator+"%")
@@ -233,10 +258,8 @@ func (r *LogisticsRepository) PageQuery(dto *model.LogisticsPageQueryDTO) ([]*mo
 
 	// 查询当前页数据
 	offset := (dto.Page - 1) * dto.Size
-	dataQuery := fmt.Sprintf(`SELECT l.log_id, l.product_info_id, l.company_id, l.start_location, l.destination, 
-		l.start_time, l.end_time, p.pd_name, c.com_name, c.com_administrator, c.com_phone
-		%s%s 
-		LIMIT ? OFFSET ?`, baseQuery, whereClause)
+	dataQuery := fmt.Sprintf(`%s%s 
+		LIMIT ? OFFSET ?`, logisticsSelect, whereClause)
 
 	queryArgs := append(args, dto.Size, offset)
 
@@ -249,28 +272,141 @@ func (r *LogisticsRepository) PageQuery(dto *model.LogisticsPageQueryDTO) ([]*mo
 
 	var logisticsList []*model.Logistics
 	for rows.Next() {
-		logistics := &model.Logistics{}
-		var endTime sql.NullTime
+		logistics, err := scanLogistics(rows)
+		if err != nil {
+			log.Println("读取物流数据失败:", err)
+			return nil, 0, err
+		}
+		logisticsList = append(logisticsList, logistics)
+	}
+
+	return logisticsList, total, nil
+}
 
-		err := rows.Scan(
-			&logistics.ID, &logistics.ProductInfoID, &logistics.CompanyID,
-			&logistics.StartLocation, &logistics.Destination, &logistics.StartTime, &endTime,
-			&logistics.ProductName, &logistics.CompanyName, &logistics.Administrator, &logistics.Phone,
-		)
+// FindByProductInfoID 查找某条生产信息的所有物流记录(按出发时间排序)
+func (r *LogisticsRepositoryImpl) FindByProductInfoID(productInfoID int) ([]*model.Logistics, error) {
+	query := logisticsSelect + " WHERE l.product_info_id = ? ORDER BY l.start_time, l.log_id"
 
+	rows, err := r.DB.Query(query, productInfoID)
+	if err != nil {
+		log.Println("查询生产信息的物流记录失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logisticsList []*model.Logistics
+	for rows.Next() {
+		logistics, err := scanLogistics(rows)
 		if err != nil {
 			log.Println("读取物流数据失败:", err)
-			return nil, 0, err
+			return nil, err
 		}
+		logisticsList = append(logisticsList, logistics)
+	}
+	rows.Close()
 
-		if endTime.Valid {
-			logistics.EndTime = &endTime.Time
-		} else {
-			logistics.EndTime = nil
+	// 加载各运输环节的事件时间线
+	for _, logistics := range logisticsList {
+		logistics.Events, err = findLogisticsEvents(r.DB, logistics.ID)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		logisticsList = append(logisticsList, logistics)
+	return logisticsList, nil
+}
+
+// UpdateStatus 将物流状态从fromStatus更新为status，endTime不为nil时同时记录到达时间，并在同一事务中追加到哈希链
+// 返回是否由本次调用更新(状态已被并发修改时不更新)
+func (r *LogisticsRepositoryImpl) UpdateStatus(id int, fromStatus, status, reason string, statusTime time.Time, endTime *time.Time) (bool, error) {
+	query := `UPDATE logistics 
+			SET status = ?, status_reason = ?, status_time = ?, end_time = COALESCE(?, end_time) 
+			WHERE log_id = ? AND status = ?`
+
+	var endTimeValue interface{}
+	if endTime != nil {
+		endTimeValue = *endTime
 	}
 
-	return logisticsList, total, nil
+	updated := false
+	err := r.DB.Transaction(func(tx *DB) error {
+		result, err := tx.Exec(query, status, reason, statusTime, endTimeValue, id, fromStatus)
+		if err != nil {
+			log.Println("更新物流状态失败:", err)
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil || affected == 0 {
+			return err
+		}
+		updated = true
+		return appendHashChain(tx, model.HashChainLogistics, id, model.HashChainUpdate)
+	})
+	return updated, err
+}
+
+// ProductPlaceIDOf 查询生产信息所属的生产地ID，生产信息不存在时返回0
+func (r *LogisticsRepositoryImpl) ProductPlaceIDOf(productInfoID int) (int, error) {
+	var productPlaceID int
+	err := r.DB.QueryRow("SELECT product_place_id FROM product_info WHERE pi_id = ?", productInfoID).Scan(&productPlaceID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		log.Println("查询生产信息所属生产地失败:", err)
+		return 0, err
+	}
+	return productPlaceID, nil
+}
+
+// logisticsScopeColumns 物流信息的数据权限字段，零售商按销售信息关联的销售地
+var logisticsScopeColumns = scopeColumns{
+	Company:      "l.company_id",
+	ProductPlace: "pi.product_place_id",
+	SalePlace:    "l.log_id IN (SELECT logistics_id FROM sale_info WHERE sale_place_id = ?)",
+}
+
+// logisticsSelect 物流信息查询字段及关联表
+const logisticsSelect = `SELECT l.log_id, l.product_info_id, l.company_id, l.prev_log_id, l.quantity, l.start_location, l.destination, 
+			l.start_time, l.end_time, l.status, COALESCE(l.status_reason, ''), l.status_time,
+			COALESCE(p.pd_name, ''), COALESCE(c.com_name, ''),
+			COALESCE(c.com_administrator, ''), COALESCE(c.com_phone, ''), COALESCE(pi.product_place_id, 0)
+			FROM logistics l
+			LEFT JOIN product_info pi ON l.product_info_id = pi.pi_id
+			LEFT JOIN product p ON pi.product_id = p.pd_id
+			LEFT JOIN company c ON l.company_id = c.com_id`
+
+// scanLogistics 读取一行物流信息数据
+func scanLogistics(row rowScanner) (*model.Logistics, error) {
+	logistics := &model.Logistics{}
+	var endTime, statusTime sql.NullTime
+	var quantity sql.NullFloat64
+	var prevID sql.NullInt64
+
+	err := row.Scan(
+		&logistics.ID, &logistics.ProductInfoID, &logistics.CompanyID, &prevID, &quantity,
+		&logistics.StartLocation, &logistics.Destination, &logistics.StartTime, &endTime,
+		&logistics.Status, &logistics.StatusReason, &statusTime,
+		&logistics.ProductName, &logistics.CompanyName, &logistics.Administrator, &logistics.Phone,
+		&logistics.ProductPlaceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if prevID.Valid {
+		id := int(prevID.Int64)
+		logistics.PrevID = &id
+	}
+	if quantity.Valid {
+		logistics.Quantity = &quantity.Float64
+	}
+	if endTime.Valid {
+		logistics.EndTime = &endTime.Time
+	}
+	if statusTime.Valid {
+		logistics.StatusTime = &statusTime.Time
+	}
+
+	return logistics, nil
 }
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+func TestLogisticsRepository_CRUD(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewLogisticsRepository(db)
+
+	got, err := repo.GetByID(f.Logistics[0])
+	mustNoError(t, err)
+	if got == nil || got.CompanyName != "物流0" || got.ProductName != "苹果" || got.ProductPlaceID != f.Places[0] {
+		t.Fatalf("GetByID = %+v", got)
+	}
+	if got.Quantity == nil || *got.Quantity != 40 || got.PrevID != nil || got.Status != model.LogisticsStatusCreated {
+		t.Fatalf("GetByID数量/上一段/状态 = %v, %v, %s", got.Quantity, got.PrevID, got.Status)
+	}
+	if len(got.SalePlaceIDs) != 1 || got.SalePlaceIDs[0] != f.SalePlaces[0] {
+		t.Fatalf("SalePlaceIDs = %v", got.SalePlaceIDs)
+	}
+
+	// 后续运输段：未分配数量，记录上一段
+	prevID := f.Logistics[0]
+	id, err := repo.Save(&model.Logistics{
+		ProductInfoID: f.Productions[0], CompanyID: f.Companies[1], PrevID: &prevID,
+		StartLocation: "中转站", Destination: "超市0", StartTime: testTime.Add(2 * time.Hour), Status: model.LogisticsStatusCreated,
+	})
+	mustNoError(t, err)
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if got.PrevID == nil || *got.PrevID != prevID || got.Quantity != nil || len(got.SalePlaceIDs) != 0 {
+		t.Fatalf("后续运输段 = %+v", got)
+	}
+
+	quantity := 10.0
+	got.Quantity = &quantity
+	got.Destination = "超市1"
+	got.PrevID = nil
+	got.EndTime = &testTime
+	// 状态已变化时不更新
+	updated, err := repo.Update(got, model.LogisticsStatusInTransit)
+	mustNoError(t, err)
+	if updated {
+		t.Fatal("状态不匹配时仍然更新")
+	}
+	updated, err = repo.Update(got, model.LogisticsStatusCreated)
+	mustNoError(t, err)
+	if !updated {
+		t.Fatal("Update未更新")
+	}
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if got.Destination != "超市1" || got.Quantity == nil || *got.Quantity != 10 || got.PrevID != nil || got.EndTime != nil {
+		t.Fatalf("Update后 = %+v", got)
+	}
+	// 内容未变化时仍视为已更新
+	updated, err = repo.Update(got, model.LogisticsStatusCreated)
+	mustNoError(t, err)
+	if !updated {
+		t.Fatal("内容未变化时Update返回未更新")
+	}
+
+	legs, err := repo.FindByProductInfoID(f.Productions[0])
+	mustNoError(t, err)
+	assertIDs(t, legs, func(l *model.Logistics) int { return l.ID }, []int{f.Logistics[0], id})
+
+	// 被销售信息引用时不能删除
+	if err := repo.Delete(f.Logistics[0]); !errors.Is(err, ErrReferenced) {
+		t.Fatalf("删除被引用的物流 err = %v, 期望ErrReferenced", err)
+	}
+	mustNoError(t, repo.Delete(id))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if got != nil {
+		t.Fatalf("Delete后 = %+v", got)
+	}
+
+	placeID, err := repo.ProductPlaceIDOf(f.Productions[1])
+	mustNoError(t, err)
+	if placeID != f.Places[1] {
+		t.Fatalf("ProductPlaceIDOf = %d, 期望 %d", placeID, f.Places[1])
+	}
+	placeID, err = repo.ProductPlaceIDOf(999)
+	mustNoError(t, err)
+	if placeID != 0 {
+		t.Fatalf("不存在的生产信息ProductPlaceIDOf = %d", placeID)
+	}
+}
+
+func TestLogisticsRepository_UpdateStatus(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewLogisticsRepository(db)
+	endTime := testTime.Add(5 * time.Hour)
+
+	tests := []struct {
+		name        string
+		from, to    string
+		endTime     *time.Time
+		wantUpdated bool
+		wantStatus  string
+	}{
+		{name: "已创建到运输中", from: model.LogisticsStatusCreated, to: model.LogisticsStatusInTransit, wantUpdated: true, wantStatus: model.LogisticsStatusInTransit},
+		{name: "原状态已变化时不更新", from: model.LogisticsStatusCreated, to: model.LogisticsStatusCancelled, wantStatus: model.LogisticsStatusInTransit},
+		{name: "送达并记录到达时间", from: model.LogisticsStatusInTransit, to: model.LogisticsStatusDelivered, endTime: &endTime, wantUpdated: true, wantStatus: model.LogisticsStatusDelivered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := repo.UpdateStatus(f.Logistics[0], tt.from, tt.to, "原因", testTime, tt.endTime)
+			mustNoError(t, err)
+			if updated != tt.wantUpdated {
+				t.Errorf("updated = %v, 期望 %v", updated, tt.wantUpdated)
+			}
+			got, err := repo.GetByID(f.Logistics[0])
+			mustNoError(t, err)
+			if got.Status != tt.wantStatus {
+				t.Errorf("status = %s, 期望 %s", got.Status, tt.wantStatus)
+			}
+			if tt.endTime != nil && (got.EndTime == nil || !got.EndTime.Equal(*tt.endTime)) {
+				t.Errorf("endTime = %v, 期望 %v", got.EndTime, tt.endTime)
+			}
+		})
+	}
+}
+
+func TestLogisticsRepository_PageQuery(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewLogisticsRepository(db)
+	_, err := repo.UpdateStatus(f.Logistics[1], model.LogisticsStatusCreated, model.LogisticsStatusInTransit, "", testTime, nil)
+	mustNoError(t, err)
+	unbound := 0
+
+	tests := []struct {
+		name      string
+		query     model.LogisticsPageQueryDTO
+		scope     model.DataScope
+		wantTotal int64
+		wantIDs   []int
+	}{
+		{name: "不限范围", query: model.LogisticsPageQueryDTO{}, wantTotal: 2, wantIDs: f.Logistics[:]},
+		{name: "按ID", query: model.LogisticsPageQueryDTO{LogisticsId: f.Logistics[1]}, wantTotal: 1, wantIDs: []int{f.Logistics[1]}},
+		{name: "按产品名称", query: model.LogisticsPageQueryDTO{ProductName: "白菜"}, wantTotal: 1, wantIDs: []int{f.Logistics[1]}},
+		{name: "按公司名称", query: model.LogisticsPageQueryDTO{CompanyName: "物流0"}, wantTotal: 1, wantIDs: []int{f.Logistics[0]}},
+		{name: "按起点", query: model.LogisticsPageQueryDTO{StartLocation: "农场1"}, wantTotal: 1, wantIDs: []int{f.Logistics[1]}},
+		{name: "按目的地", query: model.LogisticsPageQueryDTO{Destination: "超市0"}, wantTotal: 1, wantIDs: []int{f.Logistics[0]}},
+		{name: "按负责人", query: model.LogisticsPageQueryDTO{Administrator: "经理1"}, wantTotal: 1, wantIDs: []int{f.Logistics[1]}},
+		{name: "按状态", query: model.LogisticsPageQueryDTO{Status: model.LogisticsStatusInTransit}, wantTotal: 1, wantIDs: []int{f.Logistics[1]}},
+		{name: "按出发日期", query: model.LogisticsPageQueryDTO{StartTime: testTime.Format("2006-01-02")}, wantTotal: 2, wantIDs: f.Logistics[:]},
+		{name: "出发日期无匹配", query: model.LogisticsPageQueryDTO{StartTime: "2020-01-01"}, wantTotal: 0},
+		{name: "分页", query: model.LogisticsPageQueryDTO{Page: 2, Size: 1}, wantTotal: 2, wantIDs: []int{f.Logistics[1]}},
+		{name: "物流公司只看到自己承运的", scope: companyScope(f.Companies[0]), wantTotal: 1, wantIDs: []int{f.Logistics[0]}},
+		{name: "农场只看到自己生产地的", scope: productPlaceScope(f.Places[1]), wantTotal: 1, wantIDs: []int{f.Logistics[1]}},
+		{name: "零售商只看到关联到本销售地的", scope: salePlaceScope(f.SalePlaces[0]), wantTotal: 1, wantIDs: []int{f.Logistics[0]}},
+		{name: "范围与条件同时生效", query: model.LogisticsPageQueryDTO{ProductName: "白菜"}, scope: companyScope(f.Companies[0]), wantTotal: 0},
+		{name: "未绑定公司的物流用户", scope: model.DataScope{CompanyID: &unbound}, wantTotal: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			if query.Page == 0 {
+				query.Page, query.Size = 1, 10
+			}
+			list, total, err := repo.PageQuery(&query, tt.scope)
+			mustNoError(t, err)
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, 期望 %d", total, tt.wantTotal)
+			}
+			assertIDs(t, list, func(l *model.Logistics) int { return l.ID }, tt.wantIDs)
+
+			// FindAll与分页查询使用相同的数据权限过滤
+			if query == (model.LogisticsPageQueryDTO{Page: 1, Size: 10}) {
+				all, err := repo.FindAll(tt.scope)
+				mustNoError(t, err)
+				assertIDs(t, all, func(l *model.Logistics) int { return l.ID }, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -5,24 +5,39 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"agricultural_product_gin/model"
 )
 
-// ProductRepository 产品数据仓库
-type ProductRepository struct {
-	DB *sql.DB
+// ProductRepository 产品数据仓库接口
+type ProductRepository interface {
+	Save(product *model.Product) (int, error)
+	Update(product *model.Product) error
+	Delete(id int, deletedAt time.Time, deletedBy string) error
+	Restore(id int) error
+	GetByID(id int) (*model.Product, error)
+	FindAll(includeDeleted bool) ([]*model.Product, error)
+	FindByCondition(name, productType string) ([]*model.Product, error)
+	PageQuery(page, pageSize int, name, productType string, includeDeleted bool) ([]*model.Product, int64, error)
+	GetProductTypes() ([]string, error)
+}
+
+// ProductRepositoryImpl 产品数据仓库的数据库实现
+type ProductRepositoryImpl struct {
+	DB *DB
 }
 
 // NewProductRepository 创建产品仓库
-func NewProductRepository(db *sql.DB) *ProductRepository {
-	return &ProductRepository{DB: db}
+func NewProductRepository(db *DB) ProductRepository {
+	return &ProductRepositoryImpl{DB: db}
 }
 
 // Save 保存产品
-func (r *ProductRepository) Save(product *model.Product) (int, error) {
-	query := "INSERT INTO product(pd_name, type, image, pd_description, unit_price) VALUES(?, ?, ?, ?, ?)"
-	result, err := r.DB.Exec(query, product.Name, product.Type, product.Image, product.Description, product.UnitPrice)
+func (r *ProductRepositoryImpl) Save(product *model.Product) (int, error) {
+	query := "INSERT INTO product(pd_name, type, image, pd_description, unit_price, min_temperature, max_temperature, min_humidity, max_humidity) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := r.DB.Exec(query, product.Name, product.Type, product.Image, product.Description, product.UnitPrice,
+		product.MinTemperature, product.MaxTemperature, product.MinHumidity, product.MaxHumidity)
 	if err != nil {
 		log.Println("保存产品失败:", err)
 		return 0, err
@@ -38,9 +53,11 @@ func (r *ProductRepository) Save(product *model.Product) (int, error) {
 }
 
 // Update 更新产品
-func (r *ProductRepository) Update(product *model.Product) error {
-	query := "UPDATE product SET pd_name = ?, type = ?, image = ?, pd_description = ?, unit_price = ? WHERE pd_id = ?"
-	_, err := r.DB.Exec(query, product.Name, product.Type, product.Image, product.Description, product.UnitPrice, product.ID)
+func (r *ProductRepositoryImpl) Update(product *model.Product) error {
+	query := `UPDATE product SET pd_name = ?, type = ?, image = ?, pd_description = ?, unit_price = ?,
+		min_temperature = ?, max_temperature = ?, min_humidity = ?, max_humidity = ? WHERE pd_id = ?`
+	_, err := r.DB.Exec(query, product.Name, product.Type, product.Image, product.Description, product.UnitPrice,
+		product.MinTemperature, product.MaxTemperature, product.MinHumidity, product.MaxHumidity, product.ID)
 	if err != nil {
 		log.Println("更新产品失败:", err)
 		return err
@@ -48,10 +65,10 @@ func (r *ProductRepository) Update(product *model.Product) error {
 	return nil
 }
 
-// Delete 删除产品
-func (r *ProductRepository) Delete(id int) error {
-	query := "DELETE FROM product WHERE pd_id = ?"
-	_, err := r.DB.Exec(query, id)
+// Delete 软删除产品，记录删除时间及删除人，已有的生产信息仍可关联到该产品
+func (r *ProductRepositoryImpl) Delete(id int, deletedAt time.Time, deletedBy string) error {
+	query := "UPDATE product SET deleted_at = ?, deleted_by = ? WHERE pd_id = ? AND deleted_at IS NULL"
+	_, err := r.DB.Exec(query, deletedAt, deletedBy, id)
 	if err != nil {
 		log.Println("删除产品失败:", err)
 		return err
@@ -59,13 +76,21 @@ func (r *ProductRepository) Delete(id int) error {
 	return nil
 }
 
-// GetByID 根据ID获取产品
-func (r *ProductRepository) GetByID(id int) (*model.Product, error) {
-	query := "SELECT pd_id, pd_name, type, image, pd_description, unit_price FROM product WHERE pd_id = ?"
-	row := r.DB.QueryRow(query, id)
+// Restore 恢复已删除的产品
+func (r *ProductRepositoryImpl) Restore(id int) error {
+	query := "UPDATE product SET deleted_at = NULL, deleted_by = NULL WHERE pd_id = ?"
+	_, err := r.DB.Exec(query, id)
+	if err != nil {
+		log.Println("恢复产品失败:", err)
+		return err
+	}
+	return nil
+}
 
-	product := &model.Product{}
-	err := row.Scan(&product.ID, &product.Name, &product.Type, &product.Image, &product.Description, &product.UnitPrice)
+// GetByID 根据ID获取产品，已删除的产品同样返回(DeletedAt不为空)，供历史溯源使用
+func (r *ProductRepositoryImpl) GetByID(id int) (*model.Product, error) {
+	query := productSelect + " WHERE pd_id = ?"
+	product, err := scanProduct(r.DB.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -77,9 +102,12 @@ func (r *ProductRepository) GetByID(id int) (*model.Product, error) {
 	return product, nil
 }
 
-// FindAll 查找所有产品
-func (r *ProductRepository) FindAll() ([]*model.Product, error) {
-	query := "SELECT pd_id, pd_name, type, image, pd_description, unit_price FROM product"
+// FindAll 查找所有产品，includeDeleted为false时不含已删除的产品
+func (r *ProductRepositoryImpl) FindAll(includeDeleted bool) ([]*model.Product, error) {
+	query := productSelect
+	if !includeDeleted {
+		query += " WHERE deleted_at IS NULL"
+	}
 	rows, err := r.DB.Query(query)
 	if err != nil {
 		log.Println("查询产品失败:", err)
@@ -89,8 +117,7 @@ func (r *ProductRepository) FindAll() ([]*model.Product, error) {
 
 	var products []*model.Product
 	for rows.Next() {
-		product := &model.Product{}
-		err := rows.Scan(&product.ID, &product.Name, &product.Type, &product.Image, &product.Description, &product.UnitPrice)
+		product, err := scanProduct(rows)
 		if err != nil {
 			log.Println("读取产品数据失败:", err)
 			return nil, err
@@ -101,8 +128,8 @@ func (r *ProductRepository) FindAll() ([]*model.Product, error) {
 	return products, nil
 }
 
-// FindByCondition 根据条件查询产品
-func (r *ProductRepository) FindByCondition(name, productType string) ([]*model.Product, error) {
+// FindByCondition 根据条件查询产品，不含已删除的产品
+func (r *ProductRepositoryImpl) FindByCondition(name, productType string) ([]*model.Product, error) {
 	// 构建查询条件
 	conditions := []string{}
 	args := []interface{}{}
@@ -116,9 +143,10 @@ func (r *ProductRepository) FindByCondition(name, productType string) ([]*model.
 		conditions = append(conditions, "type = ?")
 		args = append(args, productType)
 	}
+	conditions = softDeleteCondition(conditions, false)
 
 	// 构建SQL
-	query := "SELECT pd_id, pd_name, type, image, pd_description, unit_price FROM product"
+	query := productSelect
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
@@ -133,8 +161,7 @@ func (r *ProductRepository) FindByCondition(name, productType string) ([]*model.
 
 	var products []*model.Product
 	for rows.Next() {
-		product := &model.Product{}
-		err := rows.Scan(&product.ID, &product.Name, &product.Type, &product.Image, &product.Description, &product.UnitPrice)
+		product, err := scanProduct(rows)
 		if err != nil {
 			log.Println("读取产品数据失败:", err)
 			return nil, err
@@ -145,8 +172,8 @@ func (r *ProductRepository) FindByCondition(name, productType string) ([]*model.
 	return products, nil
 }
 
-// PageQuery 分页查询产品
-func (r *ProductRepository) PageQuery(page, pageSize int, name, productType string) ([]*model.Product, int64, error) {
+// PageQuery 分页查询产品，includeDeleted为false时不含已删除的产品
+func (r *ProductRepositoryImpl) PageQuery(page, pageSize int, name, productType string, includeDeleted bool) ([]*model.Product, int64, error) {
 	// 构建查询条件
 	conditions := []string{}
 	args := []interface{}{}
@@ -160,6 +187,7 @@ func (r *ProductRepository) PageQuery(page, pageSize int, name, productType stri
 		conditions = append(conditions, "type = ?")
 		args = append(args, productType)
 	}
+	conditions = softDeleteCondition(conditions, includeDeleted)
 
 	// 构建条件子句
 	whereClause := ""
@@ -178,7 +206,7 @@ func (r *ProductRepository) PageQuery(page, pageSize int, name, productType stri
 
 	// 查询当前页数据 - 添加 unit_price 字段
 	offset := (page - 1) * pageSize
-	dataQuery := fmt.Sprintf("SELECT pd_id, pd_name, type, image, pd_description, unit_price FROM product%s LIMIT ? OFFSET ?", whereClause)
+	dataQuery := fmt.Sprintf("%s%s LIMIT ? OFFSET ?", productSelect, whereClause)
 	queryArgs := append(args, pageSize, offset)
 
 	rows, err := r.DB.Query(dataQuery, queryArgs...)
@@ -190,9 +218,7 @@ func (r *ProductRepository) PageQuery(page, pageSize int, name, productType stri
 
 	var products []*model.Product
 	for rows.Next() {
-		product := &model.Product{}
-		// 添加 unit_price 字段到 Scan 方法
-		err := rows.Scan(&product.ID, &product.Name, &product.Type, &product.Image, &product.Description, &product.UnitPrice)
+		product, err := scanProduct(rows)
 		if err != nil {
 			log.Println("读取产品数据失败:", err)
 			return nil, 0, err
@@ -203,9 +229,9 @@ func (r *ProductRepository) PageQuery(page, pageSize int, name, productType stri
 	return products, total, nil
 }
 
-// GetProductTypes 获取所有产品类型
-func (r *ProductRepository) GetProductTypes() ([]string, error) {
-	query := "SELECT DISTINCT type FROM product"
+// GetProductTypes 获取所有产品类型，不含仅由已删除产品使用的类型
+func (r *ProductRepositoryImpl) GetProductTypes() ([]string, error) {
+	query := "SELECT DISTINCT type FROM product WHERE deleted_at IS NULL"
 	rows, err := r.DB.Query(query)
 	if err != nil {
 		log.Println("查询产品类型失败:", err)
@@ -226,3 +252,21 @@ func (r *ProductRepository) GetProductTypes() ([]string, error) {
 
 	return types, nil
 }
+
+// productSelect 产品查询字段
+const productSelect = `SELECT pd_id, pd_name, type, image, pd_description, unit_price,
+	min_temperature, max_temperature, min_humidity, max_humidity, deleted_at, deleted_by FROM product`
+
+// scanProduct 读取一行产品数据
+func scanProduct(row rowScanner) (*model.Product, error) {
+	product := &model.Product{}
+	var deleted softDeleteFields
+	err := row.Scan(&product.ID, &product.Name, &product.Type, &product.Image, &product.Description, &product.UnitPrice,
+		&product.MinTemperature, &product.MaxTemperature, &product.MinHumidity, &product.MaxHumidity,
+		&deleted.DeletedAt, &deleted.DeletedBy)
+	if err != nil {
+		return nil, err
+	}
+	deleted.apply(&product.SoftDelete)
+	return product, nil
+}
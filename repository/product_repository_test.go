@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+func TestProductRepository_CRUD(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductRepository(db)
+
+	minTemperature, maxTemperature := 0.0, 4.0
+	id, err := repo.Save(&model.Product{
+		Name: "草莓", Type: "水果", Image: "a.png", Description: "冷藏",
+		UnitPrice:      sql.NullFloat64{Float64: 12.5, Valid: true},
+		MinTemperature: &minTemperature, MaxTemperature: &maxTemperature,
+	})
+	mustNoError(t, err)
+
+	got, err := repo.GetByID(id)
+	mustNoError(t, err)
+	if got == nil || got.Name != "草莓" || got.UnitPrice.Float64 != 12.5 {
+		t.Fatalf("GetByID = %+v", got)
+	}
+	if got.MaxTemperature == nil || *got.MaxTemperature != 4 || got.MinHumidity != nil {
+		t.Fatalf("温湿度阈值 = %v, %v", got.MaxTemperature, got.MinHumidity)
+	}
+
+	mustNoError(t, repo.Update(&model.Product{ID: id, Name: "蓝莓", Type: "浆果", Image: "b.png", Description: "冷藏"}))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if got.Name != "蓝莓" || got.Type != "浆果" || got.UnitPrice.Valid || got.MaxTemperature != nil {
+		t.Fatalf("Update后 = %+v", got)
+	}
+
+	mustNoError(t, repo.Delete(id, testTime, "admin"))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if !got.Deleted() {
+		t.Fatalf("Delete后 = %+v", got)
+	}
+	// 已删除产品的类型不再出现在类型列表中
+	types, err := repo.GetProductTypes()
+	mustNoError(t, err)
+	if len(types) != 0 {
+		t.Fatalf("GetProductTypes = %v", types)
+	}
+
+	mustNoError(t, repo.Restore(id))
+	types, err = repo.GetProductTypes()
+	mustNoError(t, err)
+	if !slices.Equal(types, []string{"浆果"}) {
+		t.Fatalf("Restore后GetProductTypes = %v", types)
+	}
+}
+
+func TestProductRepository_PageQuery(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewProductRepository(db)
+	mustNoError(t, repo.Delete(f.Products[1], testTime, "admin"))
+
+	tests := []struct {
+		name           string
+		page, size     int
+		productName    string
+		productType    string
+		includeDeleted bool
+		wantTotal      int64
+		wantIDs        []int
+	}{
+		{name: "默认不含已删除", page: 1, size: 10, wantTotal: 1, wantIDs: []int{f.Products[0]}},
+		{name: "包含已删除", page: 1, size: 10, includeDeleted: true, wantTotal: 2, wantIDs: []int{f.Products[0], f.Products[1]}},
+		{name: "按名称", page: 1, size: 10, productName: "白", includeDeleted: true, wantTotal: 1, wantIDs: []int{f.Products[1]}},
+		{name: "按类型", page: 1, size: 10, productType: "水果", wantTotal: 1, wantIDs: []int{f.Products[0]}},
+		{name: "已删除的类型", page: 1, size: 10, productType: "蔬菜", wantTotal: 0},
+		{name: "分页", page: 2, size: 1, includeDeleted: true, wantTotal: 2, wantIDs: []int{f.Products[1]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, total, err := repo.PageQuery(tt.page, tt.size, tt.productName, tt.productType, tt.includeDeleted)
+			mustNoError(t, err)
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, 期望 %d", total, tt.wantTotal)
+			}
+			assertIDs(t, list, func(p *model.Product) int { return p.ID }, tt.wantIDs)
+		})
+	}
+}
+
+func TestProductRepository_FindByCondition(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewProductRepository(db)
+
+	list, err := repo.FindByCondition("苹", "水果")
+	mustNoError(t, err)
+	assertIDs(t, list, func(p *model.Product) int { return p.ID }, []int{f.Products[0]})
+
+	list, err = repo.FindByCondition("苹", "蔬菜")
+	mustNoError(t, err)
+	assertIDs(t, list, func(p *model.Product) int { return p.ID }, nil)
+}
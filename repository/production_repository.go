@@ -7,76 +7,101 @@ import (
 	"strings"
 )
 
-// ProductionRepository 生产信息仓库
-type ProductionRepository struct {
-	DB *sql.DB
+// ProductionRepository 生产信息仓库接口
+type ProductionRepository interface {
+	Save(production *model.ProductionInfo) (int, error)
+	Update(production *model.ProductionInfo) error
+	Delete(id int) error
+	GetByID(id int) (*model.ProductionInfoWithDetails, error)
+	PageQuery(page, pageSize int, productInfoID, productName, productPlace, seed, administrator string, scope model.DataScope) ([]*model.ProductionInfoWithDetails, int64, error)
+	GetAll(scope model.DataScope) ([]*model.ProductionInfoWithDetails, error)
+}
+
+// ProductionRepositoryImpl 生产信息仓库的数据库实现
+type ProductionRepositoryImpl struct {
+	DB *DB
 }
 
 // NewProductionRepository 创建生产信息仓库
-func NewProductionRepository(db *sql.DB) *ProductionRepository {
-	return &ProductionRepository{DB: db}
+func NewProductionRepository(db *DB) ProductionRepository {
+	return &ProductionRepositoryImpl{DB: db}
 }
 
-// Save 保存生产信息
-func (r *ProductionRepository) Save(production *model.ProductionInfo) (int, error) {
+// Save 保存生产信息，并在同一事务中追加到哈希链
+func (r *ProductionRepositoryImpl) Save(production *model.ProductionInfo) (int, error) {
 	query := `INSERT INTO product_info (
-        product_id, product_place_id, seed, pi_description, 
-        planting_date, harvest_date
-    ) VALUES (?, ?, ?, ?, ?, ?)`
+        batch_no, product_id, product_place_id, seed, pi_description, 
+        planting_date, harvest_date, quantity, unit
+    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := r.DB.Exec(query,
-		production.ProductID, production.ProductPlaceID, production.SeedSource,
-		production.Description, production.PlantingDate, production.HarvestDate)
-	if err != nil {
-		log.Println("保存生产信息失败:", err)
-		return 0, err
-	}
+	var id int64
+	err := r.DB.Transaction(func(tx *DB) error {
+		result, err := tx.Exec(query,
+			production.BatchNo, production.ProductID, production.ProductPlaceID, production.SeedSource,
+			production.Description, production.PlantingDate, production.HarvestDate,
+			production.Quantity, production.Unit)
+		if err != nil {
+			log.Println("保存生产信息失败:", err)
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			log.Println("获取生产信息ID失败:", err)
+			return err
+		}
 
-	id, err := result.LastInsertId()
+		return appendHashChain(tx, model.HashChainProductInfo, int(id), model.HashChainCreate)
+	})
 	if err != nil {
-		log.Println("获取生产信息ID失败:", err)
 		return 0, err
 	}
 
 	return int(id), nil
 }
 
-// Update 更新生产信息
-func (r *ProductionRepository) Update(production *model.ProductionInfo) error {
+// Update 更新生产信息，并在同一事务中追加到哈希链
+func (r *ProductionRepositoryImpl) Update(production *model.ProductionInfo) error {
 	query := `UPDATE product_info SET 
         product_id = ?, product_place_id = ?, seed = ?, 
-        pi_description = ?, planting_date = ?, harvest_date = ?
+        pi_description = ?, planting_date = ?, harvest_date = ?,
+        quantity = ?, unit = ?
         WHERE pi_id = ?`
 
-	_, err := r.DB.Exec(query,
-		production.ProductID, production.ProductPlaceID, production.SeedSource,
-		production.Description, production.PlantingDate, production.HarvestDate,
-		production.ID)
-	if err != nil {
-		log.Println("更新生产信息失败:", err)
-		return err
-	}
-	return nil
+	return r.DB.Transaction(func(tx *DB) error {
+		_, err := tx.Exec(query,
+			production.ProductID, production.ProductPlaceID, production.SeedSource,
+			production.Description, production.PlantingDate, production.HarvestDate,
+			production.Quantity, production.Unit, production.ID)
+		if err != nil {
+			log.Println("更新生产信息失败:", err)
+			return err
+		}
+		return appendHashChain(tx, model.HashChainProductInfo, production.ID, model.HashChainUpdate)
+	})
 }
 
-// Delete 删除生产信息
-func (r *ProductionRepository) Delete(id int) error {
+// Delete 删除生产信息，并在同一事务中追加到哈希链
+func (r *ProductionRepositoryImpl) Delete(id int) error {
 	query := "DELETE FROM product_info WHERE pi_id = ?"
-	_, err := r.DB.Exec(query, id)
-	if err != nil {
-		log.Println("删除生产信息失败:", err)
-		return err
-	}
-	return nil
+	return r.DB.Transaction(func(tx *DB) error {
+		result, err := tx.Exec(query, id)
+		if err != nil {
+			log.Println("删除生产信息失败:", err)
+			return err
+		}
+		return appendHashChainDelete(tx, result, model.HashChainProductInfo, id)
+	})
 }
 
 // GetByID 根据ID获取生产信息(带详细信息)
-func (r *ProductionRepository) GetByID(id int) (*model.ProductionInfoWithDetails, error) {
+func (r *ProductionRepositoryImpl) GetByID(id int) (*model.ProductionInfoWithDetails, error) {
 	query := `SELECT 
         pi.pi_id, pi.product_id, pi.product_place_id, pi.seed, 
         pi.pi_description, pi.planting_date, pi.harvest_date,
-        pp.pp_administrator, pp.pp_phone,
-        pd.pd_name, pp.pp_address
+        COALESCE(pi.batch_no, ''), pi.quantity, pi.unit,
+        COALESCE(pp.pp_administrator, ''), COALESCE(pp.pp_phone, ''),
+        COALESCE(pd.pd_name, ''), COALESCE(pp.pp_address, '')
     FROM product_info pi
     LEFT JOIN product pd ON pi.product_id = pd.pd_id
     LEFT JOIN product_place pp ON pi.product_place_id = pp.pp_id
@@ -88,6 +113,7 @@ func (r *ProductionRepository) GetByID(id int) (*model.ProductionInfoWithDetails
 	err := row.Scan(
 		&info.ID, &info.ProductID, &info.ProductPlaceID, &info.SeedSource,
 		&info.Description, &info.PlantingDate, &info.HarvestDate,
+		&info.BatchNo, &info.Quantity, &info.Unit,
 		&info.Administrator, &info.Phone,
 		&info.ProductName, &info.ProductionPlace)
 
@@ -102,14 +128,14 @@ func (r *ProductionRepository) GetByID(id int) (*model.ProductionInfoWithDetails
 	return info, nil
 }
 
-// PageQuery 分页查询生产信息
-func (r *ProductionRepository) PageQuery(
+// PageQuery 在数据权限范围内分页查询生产信息
+func (r *ProductionRepositoryImpl) PageQuery(
 	page, pageSize int,
 	productInfoID, productName, productPlace, seed, administrator string,
+	scope model.DataScope,
 ) ([]*model.ProductionInfoWithDetails, int64, error) {
 	// 构建查询条件
-	conditions := []string{}
-	args := []interface{}{}
+	conditions, args := scopeConditions(scope, productionScopeColumns)
 
 	if productInfoID != "" {
 		conditions = append(conditions, "pi.pi_id = ?")
@@ -155,6 +181,7 @@ func (r *ProductionRepository) PageQuery(
 	dataQuery := `SELECT 
         pi.pi_id, pi.product_id, pi.product_place_id, pi.seed, 
         pi.pi_description, pi.planting_date, pi.harvest_date,
+        COALESCE(pi.batch_no, ''), pi.quantity, pi.unit,
         pp.pp_administrator, pp.pp_phone,
         pd.pd_name, pp.pp_address
     FROM product_info pi
@@ -177,6 +204,7 @@ func (r *ProductionRepository) PageQuery(
 		err := rows.Scan(
 			&info.ID, &info.ProductID, &info.ProductPlaceID, &info.SeedSource,
 			&info.Description, &info.PlantingDate, &info.HarvestDate,
+			&info.BatchNo, &info.Quantity, &info.Unit,
 			&info.Administrator, &info.Phone,
 			&info.ProductName, &info.ProductionPlace)
 		if err != nil {
@@ -189,18 +217,24 @@ func (r *ProductionRepository) PageQuery(
 	return productions, total, nil
 }
 
-// GetAll 获取所有生产信息(用于下拉选择等)
-func (r *ProductionRepository) GetAll() ([]*model.ProductionInfoWithDetails, error) {
+// GetAll 获取数据权限范围内的所有生产信息(用于下拉选择等)
+func (r *ProductionRepositoryImpl) GetAll(scope model.DataScope) ([]*model.ProductionInfoWithDetails, error) {
 	query := `SELECT 
         pi.pi_id, pi.product_id, pi.product_place_id, pi.seed, 
         pi.pi_description, pi.planting_date, pi.harvest_date,
+        COALESCE(pi.batch_no, ''), pi.quantity, pi.unit,
         pp.pp_administrator, pp.pp_phone,
         pd.pd_name, pp.pp_address
     FROM product_info pi
     LEFT JOIN product pd ON pi.product_id = pd.pd_id
     LEFT JOIN product_place pp ON pi.product_place_id = pp.pp_id`
 
-	rows, err := r.DB.Query(query)
+	conditions, args := scopeConditions(scope, productionScopeColumns)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := r.DB.Query(query, args...)
 	if err != nil {
 		log.Println("查询所有生产信息失败:", err)
 		return nil, err
@@ -213,6 +247,7 @@ func (r *ProductionRepository) GetAll() ([]*model.ProductionInfoWithDetails, err
 		err := rows.Scan(
 			&info.ID, &info.ProductID, &info.ProductPlaceID, &info.SeedSource,
 			&info.Description, &info.PlantingDate, &info.HarvestDate,
+			&info.BatchNo, &info.Quantity, &info.Unit,
 			&info.Administrator, &info.Phone,
 			&info.ProductName, &info.ProductionPlace)
 		if err != nil {
@@ -224,3 +259,6 @@ func (r *ProductionRepository) GetAll() ([]*model.ProductionInfoWithDetails, err
 
 	return productions, nil
 }
+
+// productionScopeColumns 生产信息的数据权限字段
+var productionScopeColumns = scopeColumns{ProductPlace: "pi.product_place_id"}
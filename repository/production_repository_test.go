@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+func TestProductionRepository_CRUD(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewProductionRepository(db)
+
+	got, err := repo.GetByID(f.Productions[0])
+	mustNoError(t, err)
+	if got == nil || got.BatchNo != "B0000" || got.ProductName != "苹果" || got.ProductionPlace != "农场0" || got.Quantity != 100 {
+		t.Fatalf("GetByID = %+v", got)
+	}
+
+	updated := got.ProductionInfo
+	updated.ProductID = f.Products[1]
+	updated.SeedSource = "自留种"
+	updated.Quantity = 80
+	mustNoError(t, repo.Update(&updated))
+	got, err = repo.GetByID(f.Productions[0])
+	mustNoError(t, err)
+	if got.ProductName != "白菜" || got.SeedSource != "自留种" || got.Quantity != 80 || got.BatchNo != "B0000" {
+		t.Fatalf("Update后 = %+v", got)
+	}
+
+	// 仍被物流引用时外键约束阻止删除
+	err = repo.Delete(f.Productions[0])
+	if !errors.Is(err, ErrReferenced) {
+		t.Fatalf("删除被引用的生产信息 err = %v, 期望ErrReferenced", err)
+	}
+
+	id, err := repo.Save(&model.ProductionInfo{
+		BatchNo: "B9999", ProductID: f.Products[0], ProductPlaceID: f.Places[0],
+		PlantingDate: testTime, HarvestDate: testTime, Quantity: 1, Unit: "kg",
+	})
+	mustNoError(t, err)
+	mustNoError(t, repo.Delete(id))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if got != nil {
+		t.Fatalf("Delete后 = %+v", got)
+	}
+
+	// 引用不存在的产品
+	_, err = repo.Save(&model.ProductionInfo{BatchNo: "B8888", ProductID: 999, ProductPlaceID: f.Places[0], PlantingDate: testTime, HarvestDate: testTime})
+	if !errors.Is(err, ErrReferenceNotFound) {
+		t.Fatalf("引用不存在的产品 err = %v, 期望ErrReferenceNotFound", err)
+	}
+}
+
+func TestProductionRepository_PageQuery(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewProductionRepository(db)
+	unbound := 0
+
+	tests := []struct {
+		name          string
+		page, size    int
+		productInfoID string
+		productName   string
+		productPlace  string
+		seed          string
+		administrator string
+		scope         model.DataScope
+		wantTotal     int64
+		wantIDs       []int
+	}{
+		{name: "不限范围", page: 1, size: 10, wantTotal: 2, wantIDs: f.Productions[:]},
+		{name: "按ID", page: 1, size: 10, productInfoID: strconv.Itoa(f.Productions[1]), wantTotal: 1, wantIDs: []int{f.Productions[1]}},
+		{name: "按产品名称", page: 1, size: 10, productName: "苹果", wantTotal: 1, wantIDs: []int{f.Productions[0]}},
+		{name: "按生产地", page: 1, size: 10, productPlace: "农场1", wantTotal: 1, wantIDs: []int{f.Productions[1]}},
+		{name: "按种子来源", page: 1, size: 10, seed: "种子", wantTotal: 2, wantIDs: f.Productions[:]},
+		{name: "按负责人", page: 1, size: 10, administrator: "场长0", wantTotal: 1, wantIDs: []int{f.Productions[0]}},
+		{name: "分页", page: 2, size: 1, wantTotal: 2, wantIDs: []int{f.Productions[1]}},
+		{name: "农场只看到自己的生产地", page: 1, size: 10, scope: productPlaceScope(f.Places[1]), wantTotal: 1, wantIDs: []int{f.Productions[1]}},
+		{name: "范围与条件同时生效", page: 1, size: 10, productName: "苹果", scope: productPlaceScope(f.Places[1]), wantTotal: 0},
+		{name: "未绑定生产地的农场", page: 1, size: 10, scope: model.DataScope{ProductPlaceID: &unbound}, wantTotal: 0},
+		{name: "物流公司范围不过滤生产信息", page: 1, size: 10, scope: companyScope(f.Companies[0]), wantTotal: 2, wantIDs: f.Productions[:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, total, err := repo.PageQuery(tt.page, tt.size, tt.productInfoID, tt.productName, tt.productPlace, tt.seed, tt.administrator, tt.scope)
+			mustNoError(t, err)
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, 期望 %d", total, tt.wantTotal)
+			}
+			assertIDs(t, list, func(p *model.ProductionInfoWithDetails) int { return p.ID }, tt.wantIDs)
+		})
+	}
+}
+
+func TestProductionRepository_GetAll(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewProductionRepository(db)
+
+	list, err := repo.GetAll(model.DataScope{})
+	mustNoError(t, err)
+	assertIDs(t, list, func(p *model.ProductionInfoWithDetails) int { return p.ID }, f.Productions[:])
+
+	list, err = repo.GetAll(productPlaceScope(f.Places[0]))
+	mustNoError(t, err)
+	assertIDs(t, list, func(p *model.ProductionInfoWithDetails) int { return p.ID }, []int{f.Productions[0]})
+}
@@ -5,22 +5,34 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"agricultural_product_gin/model"
 )
 
-// ProductionPlaceRepository 生产地仓库
-type ProductionPlaceRepository struct {
-	DB *sql.DB
+// ProductionPlaceRepository 生产地仓库接口
+type ProductionPlaceRepository interface {
+	Save(place *model.ProductionPlace) (int, error)
+	Update(place *model.ProductionPlace) error
+	Delete(id int, deletedAt time.Time, deletedBy string) error
+	Restore(id int) error
+	GetByID(id int) (*model.ProductionPlace, error)
+	PageQuery(page, pageSize int, id, address, administrator string, includeDeleted bool) ([]*model.ProductionPlace, int64, error)
+	GetAll(includeDeleted bool) ([]*model.ProductionPlace, error)
+}
+
+// ProductionPlaceRepositoryImpl 生产地仓库的数据库实现
+type ProductionPlaceRepositoryImpl struct {
+	DB *DB
 }
 
 // NewProductionPlaceRepository 创建生产地仓库
-func NewProductionPlaceRepository(db *sql.DB) *ProductionPlaceRepository {
-	return &ProductionPlaceRepository{DB: db}
+func NewProductionPlaceRepository(db *DB) ProductionPlaceRepository {
+	return &ProductionPlaceRepositoryImpl{DB: db}
 }
 
 // Save 保存生产地信息
-func (r *ProductionPlaceRepository) Save(place *model.ProductionPlace) (int, error) {
+func (r *ProductionPlaceRepositoryImpl) Save(place *model.ProductionPlace) (int, error) {
 	query := "INSERT INTO product_place(pp_address, pp_administrator, pp_phone) VALUES(?, ?, ?)"
 	result, err := r.DB.Exec(query, place.Address, place.Administrator, place.Phone)
 	if err != nil {
@@ -38,7 +50,7 @@ func (r *ProductionPlaceRepository) Save(place *model.ProductionPlace) (int, err
 }
 
 // Update 更新生产地信息
-func (r *ProductionPlaceRepository) Update(place *model.ProductionPlace) error {
+func (r *ProductionPlaceRepositoryImpl) Update(place *model.ProductionPlace) error {
 	query := "UPDATE product_place SET pp_address = ?, pp_administrator = ?, pp_phone = ? WHERE pp_id = ?"
 	_, err := r.DB.Exec(query, place.Address, place.Administrator, place.Phone, place.ID)
 	if err != nil {
@@ -48,10 +60,10 @@ func (r *ProductionPlaceRepository) Update(place *model.ProductionPlace) error {
 	return nil
 }
 
-// Delete 删除生产地信息
-func (r *ProductionPlaceRepository) Delete(id int) error {
-	query := "DELETE FROM product_place WHERE pp_id = ?"
-	_, err := r.DB.Exec(query, id)
+// Delete 软删除生产地信息，记录删除时间及删除人，已有的生产信息仍可关联到该生产地
+func (r *ProductionPlaceRepositoryImpl) Delete(id int, deletedAt time.Time, deletedBy string) error {
+	query := "UPDATE product_place SET deleted_at = ?, deleted_by = ? WHERE pp_id = ? AND deleted_at IS NULL"
+	_, err := r.DB.Exec(query, deletedAt, deletedBy, id)
 	if err != nil {
 		log.Println("删除生产地信息失败:", err)
 		return err
@@ -59,13 +71,21 @@ func (r *ProductionPlaceRepository) Delete(id int) error {
 	return nil
 }
 
-// GetByID 根据ID获取生产地信息
-func (r *ProductionPlaceRepository) GetByID(id int) (*model.ProductionPlace, error) {
-	query := "SELECT pp_id, pp_address, pp_administrator, pp_phone FROM product_place WHERE pp_id = ?"
-	row := r.DB.QueryRow(query, id)
+// Restore 恢复已删除的生产地信息
+func (r *ProductionPlaceRepositoryImpl) Restore(id int) error {
+	query := "UPDATE product_place SET deleted_at = NULL, deleted_by = NULL WHERE pp_id = ?"
+	_, err := r.DB.Exec(query, id)
+	if err != nil {
+		log.Println("恢复生产地信息失败:", err)
+		return err
+	}
+	return nil
+}
 
-	place := &model.ProductionPlace{}
-	err := row.Scan(&place.ID, &place.Address, &place.Administrator, &place.Phone)
+// GetByID 根据ID获取生产地信息，已删除的生产地同样返回(DeletedAt不为空)，供历史溯源使用
+func (r *ProductionPlaceRepositoryImpl) GetByID(id int) (*model.ProductionPlace, error) {
+	query := productionPlaceSelect + " WHERE pp_id = ?"
+	place, err := scanProductionPlace(r.DB.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -77,10 +97,11 @@ func (r *ProductionPlaceRepository) GetByID(id int) (*model.ProductionPlace, err
 	return place, nil
 }
 
-// PageQuery 分页查询生产地信息
-func (r *ProductionPlaceRepository) PageQuery(
+// PageQuery 分页查询生产地信息，includeDeleted为false时不含已删除的生产地
+func (r *ProductionPlaceRepositoryImpl) PageQuery(
 	page, pageSize int,
 	id, address, administrator string,
+	includeDeleted bool,
 ) ([]*model.ProductionPlace, int64, error) {
 	// 构建查询条件
 	conditions := []string{}
@@ -98,6 +119,7 @@ func (r *ProductionPlaceRepository) PageQuery(
 		conditions = append(conditions, "pp_administrator LIKE ?")
 		args = append(args, "%"+administrator+"%")
 	}
+	conditions = softDeleteCondition(conditions, includeDeleted)
 
 	// 构建条件子句
 	whereClause := ""
@@ -116,9 +138,7 @@ func (r *ProductionPlaceRepository) PageQuery(
 
 	// 查询当前页数据
 	offset := (page - 1) * pageSize
-	dataQuery := fmt.Sprintf(
-		"SELECT pp_id, pp_address, pp_administrator, pp_phone FROM product_place%s LIMIT ? OFFSET ?",
-		whereClause)
+	dataQuery := fmt.Sprintf("%s%s LIMIT ? OFFSET ?", productionPlaceSelect, whereClause)
 	queryArgs := append(args, pageSize, offset)
 
 	rows, err := r.DB.Query(dataQuery, queryArgs...)
@@ -130,8 +150,7 @@ func (r *ProductionPlaceRepository) PageQuery(
 
 	var places []*model.ProductionPlace
 	for rows.Next() {
-		place := &model.ProductionPlace{}
-		err := rows.Scan(&place.ID, &place.Address, &place.Administrator, &place.Phone)
+		place, err := scanProductionPlace(rows)
 		if err != nil {
 			log.Println("读取生产地数据失败:", err)
 			return nil, 0, err
@@ -142,9 +161,12 @@ func (r *ProductionPlaceRepository) PageQuery(
 	return places, total, nil
 }
 
-// GetAll 获取所有生产地信息
-func (r *ProductionPlaceRepository) GetAll() ([]*model.ProductionPlace, error) {
-	query := "SELECT pp_id, pp_address, pp_administrator, pp_phone FROM product_place"
+// GetAll 获取所有生产地信息，includeDeleted为false时不含已删除的生产地
+func (r *ProductionPlaceRepositoryImpl) GetAll(includeDeleted bool) ([]*model.ProductionPlace, error) {
+	query := productionPlaceSelect
+	if !includeDeleted {
+		query += " WHERE deleted_at IS NULL"
+	}
 	rows, err := r.DB.Query(query)
 	if err != nil {
 		log.Println("查询所有生产地信息失败:", err)
@@ -154,8 +176,7 @@ func (r *ProductionPlaceRepository) GetAll() ([]*model.ProductionPlace, error) {
 
 	var places []*model.ProductionPlace
 	for rows.Next() {
-		place := &model.ProductionPlace{}
-		err := rows.Scan(&place.ID, &place.Address, &place.Administrator, &place.Phone)
+		place, err := scanProductionPlace(rows)
 		if err != nil {
 			log.Println("读取生产地数据失败:", err)
 			return nil, err
@@ -165,3 +186,18 @@ func (r *ProductionPlaceRepository) GetAll() ([]*model.ProductionPlace, error) {
 
 	return places, nil
 }
+
+// productionPlaceSelect 生产地查询字段
+const productionPlaceSelect = "SELECT pp_id, pp_address, pp_administrator, pp_phone, deleted_at, deleted_by FROM product_place"
+
+// scanProductionPlace 读取一行生产地数据
+func scanProductionPlace(row rowScanner) (*model.ProductionPlace, error) {
+	place := &model.ProductionPlace{}
+	var deleted softDeleteFields
+	err := row.Scan(&place.ID, &place.Address, &place.Administrator, &place.Phone, &deleted.DeletedAt, &deleted.DeletedBy)
+	if err != nil {
+		return nil, err
+	}
+	deleted.apply(&place.SoftDelete)
+	return place, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"strconv"
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+func TestProductionPlaceRepository_CRUD(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductionPlaceRepository(db)
+
+	id, err := repo.Save(&model.ProductionPlace{Address: "东山农场", Administrator: "王五", Phone: "13700000000"})
+	mustNoError(t, err)
+
+	got, err := repo.GetByID(id)
+	mustNoError(t, err)
+	if got == nil || got.Address != "东山农场" || got.Deleted() {
+		t.Fatalf("GetByID = %+v", got)
+	}
+
+	mustNoError(t, repo.Update(&model.ProductionPlace{ID: id, Address: "西山农场", Administrator: "赵六", Phone: "13600000000"}))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if got.Address != "西山农场" || got.Administrator != "赵六" || got.Phone != "13600000000" {
+		t.Fatalf("Update后 = %+v", got)
+	}
+
+	mustNoError(t, repo.Delete(id, testTime, "admin"))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if !got.Deleted() || got.DeletedBy != "admin" {
+		t.Fatalf("Delete后 = %+v", got)
+	}
+	all, err := repo.GetAll(false)
+	mustNoError(t, err)
+	if len(all) != 0 {
+		t.Fatalf("GetAll(false) = %d条, 期望0条", len(all))
+	}
+
+	mustNoError(t, repo.Restore(id))
+	all, err = repo.GetAll(false)
+	mustNoError(t, err)
+	if len(all) != 1 {
+		t.Fatalf("Restore后GetAll(false) = %d条, 期望1条", len(all))
+	}
+
+	missing, err := repo.GetByID(id + 100)
+	mustNoError(t, err)
+	if missing != nil {
+		t.Fatalf("不存在的生产地 = %+v", missing)
+	}
+}
+
+func TestProductionPlaceRepository_PageQuery(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewProductionPlaceRepository(db)
+	mustNoError(t, repo.Delete(f.Places[1], testTime, "admin"))
+
+	tests := []struct {
+		name           string
+		page, size     int
+		id             string
+		address        string
+		administrator  string
+		includeDeleted bool
+		wantTotal      int64
+		wantIDs        []int
+	}{
+		{name: "默认不含已删除", page: 1, size: 10, wantTotal: 1, wantIDs: []int{f.Places[0]}},
+		{name: "包含已删除", page: 1, size: 10, includeDeleted: true, wantTotal: 2, wantIDs: []int{f.Places[0], f.Places[1]}},
+		{name: "按ID", page: 1, size: 10, id: strconv.Itoa(f.Places[1]), includeDeleted: true, wantTotal: 1, wantIDs: []int{f.Places[1]}},
+		{name: "按地址", page: 1, size: 10, address: "农场0", wantTotal: 1, wantIDs: []int{f.Places[0]}},
+		{name: "按负责人无匹配", page: 1, size: 10, administrator: "场长1", wantTotal: 0},
+		{name: "分页", page: 2, size: 1, includeDeleted: true, wantTotal: 2, wantIDs: []int{f.Places[1]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, total, err := repo.PageQuery(tt.page, tt.size, tt.id, tt.address, tt.administrator, tt.includeDeleted)
+			mustNoError(t, err)
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, 期望 %d", total, tt.wantTotal)
+			}
+			assertIDs(t, list, func(p *model.ProductionPlace) int { return p.ID }, tt.wantIDs)
+		})
+	}
+}
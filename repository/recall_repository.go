@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+// RecallRepository 产品召回仓库接口
+type RecallRepository interface {
+	Save(recall *model.Recall) (int, error)
+	Close(id int, closeTime time.Time, remark string) error
+	GetByID(id int) (*model.Recall, error)
+	PageQuery(dto *model.RecallPageQueryDTO, scope model.DataScope) ([]*model.Recall, int64, error)
+	FindOpen(productInfoIDs []int, productID int) ([]*model.Recall, error)
+	FindSalePlaces(recallID int) ([]*model.RecallSalePlace, error)
+	AddSalePlaces(recallID int, salePlaceIDs []int, updateTime time.Time) error
+	UpdateSalePlace(salePlace *model.RecallSalePlace) error
+}
+
+// RecallRepositoryImpl 产品召回仓库的数据库实现
+type RecallRepositoryImpl struct {
+	DB *DB
+}
+
+// NewRecallRepository 创建产品召回仓库
+func NewRecallRepository(db *DB) RecallRepository {
+	return &RecallRepositoryImpl{DB: db}
+}
+
+// recallScopeColumns 召回的数据权限字段：农场只能查看本生产地批次的召回
+var recallScopeColumns = scopeColumns{ProductPlace: "pi.product_place_id"}
+
+// recallSelect 召回查询字段
+const recallSelect = `SELECT r.rc_id, r.product_info_id, r.product_id, r.inspection_id, r.reason, r.status,
+		COALESCE(r.opened_by, ''), r.open_time, r.close_time, COALESCE(r.close_remark, ''),
+		COALESCE(pi.batch_no, ''), COALESCE(p.pd_name, ''), pi.product_place_id
+		FROM recall r
+		LEFT JOIN product_info pi ON r.product_info_id = pi.pi_id
+		LEFT JOIN product p ON r.product_id = p.pd_id`
+
+// Save 保存召回
+func (r *RecallRepositoryImpl) Save(recall *model.Recall) (int, error) {
+	query := `INSERT INTO recall(product_info_id, product_id, inspection_id, reason, status, opened_by, open_time)
+		VALUES(?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.DB.Exec(query, nullableID(recall.ProductInfoID), nullableID(recall.ProductID),
+		nullableID(recall.InspectionID), recall.Reason, recall.Status, recall.OpenedBy, recall.OpenTime)
+	if err != nil {
+		log.Println("保存召回失败:", err)
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		log.Println("获取召回ID失败:", err)
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// Close 结束召回
+func (r *RecallRepositoryImpl) Close(id int, closeTime time.Time, remark string) error {
+	query := "UPDATE recall SET status = ?, close_time = ?, close_remark = ? WHERE rc_id = ?"
+	_, err := r.DB.Exec(query, model.RecallStatusClosed, closeTime, remark, id)
+	if err != nil {
+		log.Println("结束召回失败:", err)
+		return err
+	}
+	return nil
+}
+
+// GetByID 根据ID获取召回
+func (r *RecallRepositoryImpl) GetByID(id int) (*model.Recall, error) {
+	recall, err := scanRecall(r.DB.QueryRow(recallSelect+" WHERE r.rc_id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("获取召回失败:", err)
+		return nil, err
+	}
+	return recall, nil
+}
+
+// PageQuery 在数据权限范围内分页查询召回
+func (r *RecallRepositoryImpl) PageQuery(dto *model.RecallPageQueryDTO, scope model.DataScope) ([]*model.Recall, int64, error) {
+	conditions, args := scopeConditions(scope, recallScopeColumns)
+
+	if dto.ProductInfoID > 0 {
+		conditions = append(conditions, "r.product_info_id = ?")
+		args = append(args, dto.ProductInfoID)
+	}
+
+	if dto.ProductID > 0 {
+		conditions = append(conditions, "r.product_id = ?")
+		args = append(args, dto.ProductID)
+	}
+
+	if dto.BatchNo != "" {
+		conditions = append(conditions, "pi.batch_no LIKE ?")
+		args = append(args, "%"+dto.BatchNo+"%")
+	}
+
+	if dto.Status != "" {
+		conditions = append(conditions, "r.status = ?")
+		args = append(args, dto.Status)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM recall r
+		LEFT JOIN product_info pi ON r.product_info_id = pi.pi_id%s`, whereClause)
+	if err := r.DB.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		log.Println("查询召回总数失败:", err)
+		return nil, 0, err
+	}
+
+	offset := (dto.Page - 1) * dto.Size
+	dataQuery := fmt.Sprintf("%s%s ORDER BY r.open_time DESC, r.rc_id DESC LIMIT ? OFFSET ?", recallSelect, whereClause)
+	recalls, err := r.query(dataQuery, append(args, dto.Size, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return recalls, total, nil
+}
+
+// FindOpen 查询涉及任一批次或该产品的进行中召回
+func (r *RecallRepositoryImpl) FindOpen(productInfoIDs []int, productID int) ([]*model.Recall, error) {
+	conditions := []string{"(r.product_info_id IS NULL AND r.product_id = ?)"}
+	args := []interface{}{model.RecallStatusOpen, productID}
+	if len(productInfoIDs) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(productInfoIDs)), ", ")
+		conditions = append(conditions, "r.product_info_id IN ("+placeholders+")")
+		for _, id := range productInfoIDs {
+			args = append(args, id)
+		}
+	}
+
+	query := recallSelect + " WHERE r.status = ? AND (" + strings.Join(conditions, " OR ") + ") ORDER BY r.open_time, r.rc_id"
+	return r.query(query, args...)
+}
+
+// FindSalePlaces 查询召回涉及的销售地及联系方式
+func (r *RecallRepositoryImpl) FindSalePlaces(recallID int) ([]*model.RecallSalePlace, error) {
+	query := `SELECT rs.rc_id, rs.sale_place_id, rs.status, COALESCE(rs.remark, ''), rs.update_time,
+		COALESCE(sp.sp_address, ''), COALESCE(sp.sp_administrator, ''), COALESCE(sp.sp_phone, '')
+		FROM recall_sale_place rs
+		LEFT JOIN sale_place sp ON rs.sale_place_id = sp.sp_id
+		WHERE rs.rc_id = ? ORDER BY rs.sale_place_id`
+	rows, err := r.DB.Query(query, recallID)
+	if err != nil {
+		log.Println("查询召回销售地失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	salePlaces := []*model.RecallSalePlace{}
+	for rows.Next() {
+		salePlace := &model.RecallSalePlace{}
+		var updateTime time.Time
+		if err := rows.Scan(&salePlace.RecallID, &salePlace.SalePlaceID, &salePlace.Status, &salePlace.Remark,
+			&updateTime, &salePlace.Address, &salePlace.Administrator, &salePlace.Phone); err != nil {
+			log.Println("读取召回销售地失败:", err)
+			return nil, err
+		}
+		salePlace.UpdateTime = &updateTime
+		salePlaces = append(salePlaces, salePlace)
+	}
+	return salePlaces, rows.Err()
+}
+
+// AddSalePlaces 登记召回涉及的销售地(待通知)，已登记的销售地保持原状态
+func (r *RecallRepositoryImpl) AddSalePlaces(recallID int, salePlaceIDs []int, updateTime time.Time) error {
+	query := r.DB.Dialect.InsertIgnore() + " INTO recall_sale_place(rc_id, sale_place_id, status, update_time) VALUES(?, ?, ?, ?)"
+	for _, salePlaceID := range salePlaceIDs {
+		if _, err := r.DB.Exec(query, recallID, salePlaceID, model.RecallSalePlacePending, updateTime); err != nil {
+			log.Println("登记召回销售地失败:", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateSalePlace 更新销售地的召回处理状态
+func (r *RecallRepositoryImpl) UpdateSalePlace(salePlace *model.RecallSalePlace) error {
+	query := "UPDATE recall_sale_place SET status = ?, remark = ?, update_time = ? WHERE rc_id = ? AND sale_place_id = ?"
+	_, err := r.DB.Exec(query, salePlace.Status, salePlace.Remark, salePlace.UpdateTime, salePlace.RecallID, salePlace.SalePlaceID)
+	if err != nil {
+		log.Println("更新召回销售地状态失败:", err)
+		return err
+	}
+	return nil
+}
+
+// query 查询召回列表
+func (r *RecallRepositoryImpl) query(query string, args ...interface{}) ([]*model.Recall, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		log.Println("查询召回失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	recalls := []*model.Recall{}
+	for rows.Next() {
+		recall, err := scanRecall(rows)
+		if err != nil {
+			log.Println("读取召回失败:", err)
+			return nil, err
+		}
+		recalls = append(recalls, recall)
+	}
+	return recalls, rows.Err()
+}
+
+// scanRecall 读取一行召回
+func scanRecall(row rowScanner) (*model.Recall, error) {
+	recall := &model.Recall{}
+	var productInfoID, productID, inspectionID, productPlaceID sql.NullInt64
+	var closeTime sql.NullTime
+
+	err := row.Scan(&recall.ID, &productInfoID, &productID, &inspectionID, &recall.Reason, &recall.Status,
+		&recall.OpenedBy, &recall.OpenTime, &closeTime, &recall.CloseRemark,
+		&recall.BatchNo, &recall.ProductName, &productPlaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	recall.ProductInfoID = int(productInfoID.Int64)
+	recall.ProductID = int(productID.Int64)
+	recall.InspectionID = int(inspectionID.Int64)
+	recall.ProductPlaceID = int(productPlaceID.Int64)
+	if closeTime.Valid {
+		recall.CloseTime = &closeTime.Time
+	}
+	return recall, nil
+}
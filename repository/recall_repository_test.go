@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+func TestRecallRepository_CRUD(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewRecallRepository(db)
+
+	batchRecall, err := repo.Save(&model.Recall{
+		ProductInfoID: f.Productions[0], ProductID: f.Products[0], Reason: "农残超标",
+		Status: model.RecallStatusOpen, OpenedBy: "admin", OpenTime: testTime,
+	})
+	mustNoError(t, err)
+	productRecall, err := repo.Save(&model.Recall{
+		ProductID: f.Products[1], Reason: "全部召回", Status: model.RecallStatusOpen, OpenTime: testTime.Add(time.Hour),
+	})
+	mustNoError(t, err)
+
+	got, err := repo.GetByID(batchRecall)
+	mustNoError(t, err)
+	if got == nil || got.BatchNo != "B0000" || got.ProductName != "苹果" || got.ProductPlaceID != f.Places[0] ||
+		got.InspectionID != 0 || got.CloseTime != nil || got.OpenedBy != "admin" {
+		t.Fatalf("GetByID = %+v", got)
+	}
+	got, err = repo.GetByID(productRecall)
+	mustNoError(t, err)
+	if got.ProductInfoID != 0 || got.ProductName != "白菜" || got.ProductPlaceID != 0 {
+		t.Fatalf("按产品召回GetByID = %+v", got)
+	}
+
+	tests := []struct {
+		name           string
+		productInfoIDs []int
+		productID      int
+		wantIDs        []int
+	}{
+		{name: "按批次命中", productInfoIDs: []int{f.Productions[0]}, productID: f.Products[0], wantIDs: []int{batchRecall}},
+		{name: "按产品命中", productInfoIDs: []int{f.Productions[1]}, productID: f.Products[1], wantIDs: []int{productRecall}},
+		{name: "没有批次时只按产品", productID: f.Products[0]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recalls, err := repo.FindOpen(tt.productInfoIDs, tt.productID)
+			mustNoError(t, err)
+			assertIDs(t, recalls, func(r *model.Recall) int { return r.ID }, tt.wantIDs)
+		})
+	}
+
+	mustNoError(t, repo.Close(batchRecall, testTime.Add(48*time.Hour), "已处理"))
+	got, err = repo.GetByID(batchRecall)
+	mustNoError(t, err)
+	if got.Status != model.RecallStatusClosed || got.CloseTime == nil || got.CloseRemark != "已处理" {
+		t.Fatalf("Close后 = %+v", got)
+	}
+	recalls, err := repo.FindOpen([]int{f.Productions[0]}, f.Products[0])
+	mustNoError(t, err)
+	if len(recalls) != 0 {
+		t.Fatalf("已结束的召回仍被FindOpen返回: %+v", recalls)
+	}
+}
+
+func TestRecallRepository_SalePlaces(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewRecallRepository(db)
+
+	id, err := repo.Save(&model.Recall{ProductID: f.Products[0], Reason: "召回", Status: model.RecallStatusOpen, OpenTime: testTime})
+	mustNoError(t, err)
+
+	mustNoError(t, repo.AddSalePlaces(id, []int{f.SalePlaces[1], f.SalePlaces[0]}, testTime))
+	updateTime := testTime.Add(time.Hour)
+	mustNoError(t, repo.UpdateSalePlace(&model.RecallSalePlace{
+		RecallID: id, SalePlaceID: f.SalePlaces[0], Status: model.RecallSalePlaceNotified, Remark: "已电话通知", UpdateTime: &updateTime,
+	}))
+	// 重复登记不覆盖已有的处理状态
+	mustNoError(t, repo.AddSalePlaces(id, []int{f.SalePlaces[0]}, testTime.Add(2*time.Hour)))
+
+	salePlaces, err := repo.FindSalePlaces(id)
+	mustNoError(t, err)
+	assertIDs(t, salePlaces, func(s *model.RecallSalePlace) int { return s.SalePlaceID }, f.SalePlaces[:])
+	if s := salePlaces[0]; s.Status != model.RecallSalePlaceNotified || s.Remark != "已电话通知" || !s.UpdateTime.Equal(updateTime) ||
+		s.Address != "超市0" || s.Phone != "13700000000" {
+		t.Fatalf("销售地0 = %+v", s)
+	}
+	if s := salePlaces[1]; s.Status != model.RecallSalePlacePending || s.Administrator != "店长1" {
+		t.Fatalf("销售地1 = %+v", s)
+	}
+}
+
+func TestRecallRepository_PageQuery(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewRecallRepository(db)
+
+	var ids [2]int
+	for i := 0; i < 2; i++ {
+		var err error
+		ids[i], err = repo.Save(&model.Recall{
+			ProductInfoID: f.Productions[i], ProductID: f.Products[i], Reason: "召回",
+			Status: model.RecallStatusOpen, OpenTime: testTime.Add(time.Duration(i) * time.Hour),
+		})
+		mustNoError(t, err)
+	}
+	mustNoError(t, repo.Close(ids[0], testTime.Add(48*time.Hour), ""))
+
+	tests := []struct {
+		name      string
+		query     model.RecallPageQueryDTO
+		scope     model.DataScope
+		wantTotal int64
+		wantIDs   []int
+	}{
+		{name: "不限范围按发起时间倒序", wantTotal: 2, wantIDs: []int{ids[1], ids[0]}},
+		{name: "按批次", query: model.RecallPageQueryDTO{ProductInfoID: f.Productions[0]}, wantTotal: 1, wantIDs: []int{ids[0]}},
+		{name: "按产品", query: model.RecallPageQueryDTO{ProductID: f.Products[1]}, wantTotal: 1, wantIDs: []int{ids[1]}},
+		{name: "按批次号", query: model.RecallPageQueryDTO{BatchNo: "B0001"}, wantTotal: 1, wantIDs: []int{ids[1]}},
+		{name: "按状态", query: model.RecallPageQueryDTO{Status: model.RecallStatusClosed}, wantTotal: 1, wantIDs: []int{ids[0]}},
+		{name: "分页", query: model.RecallPageQueryDTO{Page: 2, Size: 1}, wantTotal: 2, wantIDs: []int{ids[0]}},
+		{name: "农场只看到本生产地批次的", scope: productPlaceScope(f.Places[1]), wantTotal: 1, wantIDs: []int{ids[1]}},
+		{name: "物流公司范围不过滤召回", scope: companyScope(f.Companies[0]), wantTotal: 2, wantIDs: []int{ids[1], ids[0]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			if query.Page == 0 {
+				query.Page, query.Size = 1, 10
+			}
+			list, total, err := repo.PageQuery(&query, tt.scope)
+			mustNoError(t, err)
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, 期望 %d", total, tt.wantTotal)
+			}
+			assertIDs(t, list, func(r *model.Recall) int { return r.ID }, tt.wantIDs)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"agricultural_product_gin/model"
+)
+
+// maxBlockingIDs 每种阻止删除的引用最多列出的记录ID数
+const maxBlockingIDs = 10
+
+// referencedTable 可被引用的数据表
+type referencedTable struct {
+	idColumn   string
+	softDelete bool // 是否有deleted_at列
+}
+
+// referencedTables 可被引用的数据表，键为数据表名(与model.AuditEntity*一致)
+var referencedTables = map[string]referencedTable{
+	model.AuditEntityCompany:         {idColumn: "com_id", softDelete: true},
+	model.AuditEntityProduct:         {idColumn: "pd_id", softDelete: true},
+	model.AuditEntityProductionPlace: {idColumn: "pp_id", softDelete: true},
+	model.AuditEntitySalePlace:       {idColumn: "sp_id", softDelete: true},
+	model.AuditEntityProduction:      {idColumn: "pi_id"},
+	model.AuditEntityLogistics:       {idColumn: "log_id"},
+	model.AuditEntitySaleInfo:        {idColumn: "si_id"},
+}
+
+// foreignKey 引用方的外键(ON DELETE RESTRICT)，columns中任一列等于被引用的ID即视为引用
+type foreignKey struct {
+	entity   string
+	idColumn string
+	columns  []string
+}
+
+// restrictForeignKeys 会阻止物理删除的外键，键为被引用的数据表名
+// 物流公司、产品、生产地、销售地为逻辑删除，不会触发外键约束，无需检查
+var restrictForeignKeys = map[string][]foreignKey{
+	model.AuditEntityProduction: {
+		{entity: model.AuditEntityLogistics, idColumn: "log_id", columns: []string{"product_info_id"}},
+		{entity: model.AuditEntityTraceCode, idColumn: "tc_id", columns: []string{"product_info_id"}},
+		{entity: model.EntityBatchLineage, idColumn: "id", columns: []string{"parent_id", "child_id"}},
+		{entity: model.AuditEntityInspection, idColumn: "ins_id", columns: []string{"product_info_id"}},
+		{entity: model.AuditEntityRecall, idColumn: "rc_id", columns: []string{"product_info_id"}},
+	},
+	model.AuditEntityLogistics: {
+		{entity: model.AuditEntityLogistics, idColumn: "log_id", columns: []string{"prev_log_id"}},
+		{entity: model.AuditEntitySaleInfo, idColumn: "si_id", columns: []string{"logistics_id"}},
+		{entity: model.AuditEntityInspection, idColumn: "ins_id", columns: []string{"logistics_id"}},
+	},
+	model.AuditEntitySaleInfo: {
+		{entity: model.AuditEntityTraceCode, idColumn: "tc_id", columns: []string{"sale_info_id"}},
+	},
+}
+
+// ReferenceRepository 数据引用关系仓库接口，用于删除、保存前检查外键约束
+type ReferenceRepository interface {
+	// FindBlocking 查询阻止物理删除entity记录的引用，没有引用时返回空列表
+	FindBlocking(entity string, id int) ([]*model.BlockingReference, error)
+	// Lookup 查询被引用的entity记录是否存在、是否已逻辑删除
+	Lookup(entity string, id int) (exists bool, deleted bool, err error)
+}
+
+// ReferenceRepositoryImpl 数据引用关系仓库的数据库实现
+type ReferenceRepositoryImpl struct {
+	DB *DB
+}
+
+// NewReferenceRepository 创建数据引用关系仓库
+func NewReferenceRepository(db *DB) ReferenceRepository {
+	return &ReferenceRepositoryImpl{DB: db}
+}
+
+// FindBlocking 按外键逐表统计引用的记录数，并列出前maxBlockingIDs个记录ID
+func (r *ReferenceRepositoryImpl) FindBlocking(entity string, id int) ([]*model.BlockingReference, error) {
+	refs := []*model.BlockingReference{}
+	for _, fk := range restrictForeignKeys[entity] {
+		where, args := "", []interface{}{}
+		for i, column := range fk.columns {
+			if i > 0 {
+				where += " OR "
+			}
+			where += column + " = ?"
+			args = append(args, id)
+		}
+
+		var count int
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", fk.entity, where)
+		if err := r.DB.QueryRow(query, args...).Scan(&count); err != nil {
+			log.Println("查询引用记录数失败:", err)
+			return nil, err
+		}
+		if count == 0 {
+			continue
+		}
+
+		query = fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT %d",
+			fk.idColumn, fk.entity, where, fk.idColumn, maxBlockingIDs)
+		rows, err := r.DB.Query(query, args...)
+		if err != nil {
+			log.Println("查询引用记录失败:", err)
+			return nil, err
+		}
+		ids := []int{}
+		for rows.Next() {
+			var refID int
+			if err := rows.Scan(&refID); err != nil {
+				rows.Close()
+				log.Println("扫描引用记录失败:", err)
+				return nil, err
+			}
+			ids = append(ids, refID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			log.Println("遍历引用记录失败:", err)
+			return nil, err
+		}
+
+		refs = append(refs, model.NewBlockingReference(fk.entity, count, ids, entity))
+	}
+	return refs, nil
+}
+
+// Lookup 查询被引用的记录，不支持的数据表返回错误
+func (r *ReferenceRepositoryImpl) Lookup(entity string, id int) (bool, bool, error) {
+	table, ok := referencedTables[entity]
+	if !ok {
+		return false, false, fmt.Errorf("不支持的引用数据表: %s", entity)
+	}
+
+	deletedColumn := "NULL"
+	if table.softDelete {
+		deletedColumn = "deleted_at"
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", deletedColumn, entity, table.idColumn)
+	var deletedAt sql.NullTime
+	err := r.DB.QueryRow(query, id).Scan(&deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, false, nil
+	}
+	if err != nil {
+		log.Println("查询引用的数据失败:", err)
+		return false, false, err
+	}
+	return true, deletedAt.Valid, nil
+}
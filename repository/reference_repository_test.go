@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+func TestReferenceRepository_FindBlocking(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewReferenceRepository(db)
+
+	prevID := f.Logistics[0]
+	next, err := NewLogisticsRepository(db).Save(&model.Logistics{
+		ProductInfoID: f.Productions[0], CompanyID: f.Companies[0], PrevID: &prevID,
+		StartLocation: "中转站", Destination: "超市0", StartTime: testTime, Status: model.LogisticsStatusCreated,
+	})
+	mustNoError(t, err)
+	lineage, err := NewBatchRepository(db).SaveLineage(&model.BatchLineage{
+		ParentID: f.Productions[1], ChildID: f.Productions[0], Kind: model.BatchLineageMerge, Quantity: 10, CreateTime: testTime,
+	})
+	mustNoError(t, err)
+
+	tests := []struct {
+		name     string
+		entity   string
+		id       int
+		wantRefs []model.BlockingReference
+	}{
+		{
+			name: "生产信息被物流及谱系引用", entity: model.AuditEntityProduction, id: f.Productions[0],
+			wantRefs: []model.BlockingReference{
+				{Entity: model.AuditEntityLogistics, Count: 2, IDs: []int{f.Logistics[0], next}, Message: "2条物流信息引用了该生产信息"},
+				{Entity: model.EntityBatchLineage, Count: 1, IDs: []int{lineage}, Message: "1条批次拆分/合并记录引用了该生产信息"},
+			},
+		},
+		{
+			name: "物流被后续运输段及销售引用", entity: model.AuditEntityLogistics, id: f.Logistics[0],
+			wantRefs: []model.BlockingReference{
+				{Entity: model.AuditEntityLogistics, Count: 1, IDs: []int{next}, Message: "1条物流信息引用了该物流信息"},
+				{Entity: model.AuditEntitySaleInfo, Count: 1, IDs: []int{f.SaleInfos[0]}, Message: "1条销售信息引用了该物流信息"},
+			},
+		},
+		{name: "没有引用", entity: model.AuditEntityLogistics, id: next},
+		{name: "逻辑删除的实体不检查", entity: model.AuditEntityCompany, id: f.Companies[0]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs, err := repo.FindBlocking(tt.entity, tt.id)
+			mustNoError(t, err)
+			if len(refs) != len(tt.wantRefs) {
+				t.Fatalf("FindBlocking = %d个引用, 期望 %d个", len(refs), len(tt.wantRefs))
+			}
+			for i, want := range tt.wantRefs {
+				got := refs[i]
+				if got.Entity != want.Entity || got.Count != want.Count || got.Message != want.Message {
+					t.Errorf("第%d个引用 = %+v, 期望 %+v", i, got, want)
+				}
+				assertIDs(t, got.IDs, func(id int) int { return id }, want.IDs)
+			}
+		})
+	}
+}
+
+func TestReferenceRepository_Lookup(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewReferenceRepository(db)
+	mustNoError(t, NewCompanyRepository(db).Delete(f.Companies[1], testTime, "admin"))
+
+	tests := []struct {
+		name        string
+		entity      string
+		id          int
+		wantExists  bool
+		wantDeleted bool
+		wantErr     bool
+	}{
+		{name: "存在", entity: model.AuditEntityCompany, id: f.Companies[0], wantExists: true},
+		{name: "已逻辑删除", entity: model.AuditEntityCompany, id: f.Companies[1], wantExists: true, wantDeleted: true},
+		{name: "不存在", entity: model.AuditEntityProduct, id: 999},
+		{name: "没有逻辑删除的数据表", entity: model.AuditEntityLogistics, id: f.Logistics[0], wantExists: true},
+		{name: "不支持的数据表", entity: model.AuditEntityTraceCode, id: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exists, deleted, err := repo.Lookup(tt.entity, tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, 期望出错 %v", err, tt.wantErr)
+			}
+			if exists != tt.wantExists || deleted != tt.wantDeleted {
+				t.Errorf("Lookup = %v, %v, 期望 %v, %v", exists, deleted, tt.wantExists, tt.wantDeleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"agricultural_product_gin/migrations"
+	"agricultural_product_gin/model"
+)
+
+// TestMain 仓储出错时会打印日志，测试中关闭日志输出
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newTestDB 创建执行过全部迁移的内存SQLite数据库，每个测试使用独立的数据库
+// 内存数据库只存在于打开它的连接上，因此连接池固定为一个连接
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	pool, err := sql.Open("sqlite", fmt.Sprintf("file:%s?mode=memory&_pragma=foreign_keys(1)", name))
+	if err != nil {
+		t.Fatalf("打开内存数据库失败: %v", err)
+	}
+	pool.SetMaxOpenConns(1)
+	pool.SetConnMaxLifetime(0)
+	t.Cleanup(func() { pool.Close() })
+
+	migrator, err := migrations.NewMigrator(pool, DialectSQLite)
+	if err != nil {
+		t.Fatalf("加载迁移失败: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+
+	db, err := NewDB(pool, DialectSQLite)
+	if err != nil {
+		t.Fatalf("创建数据库连接失败: %v", err)
+	}
+	return db
+}
+
+// testTime 测试数据使用的固定时间
+var testTime = time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+
+// fixture 两个生产地、物流公司、销售地各自一条完整的 生产 -> 物流 -> 销售 记录
+// 下标0、1分别属于两套互不相关的主体，用于校验数据权限过滤
+type fixture struct {
+	Products     [2]int
+	Places       [2]int
+	Companies    [2]int
+	SalePlaces   [2]int
+	Productions  [2]int
+	Logistics    [2]int
+	SaleInfos    [2]int
+	productNames [2]string
+}
+
+// seed 写入fixture数据
+func seed(t *testing.T, db *DB) *fixture {
+	t.Helper()
+
+	f := &fixture{productNames: [2]string{"苹果", "白菜"}}
+	for i := 0; i < 2; i++ {
+		var err error
+		f.Products[i], err = NewProductRepository(db).Save(&model.Product{
+			Name: f.productNames[i], Type: []string{"水果", "蔬菜"}[i], Image: "p.png", Description: "产品",
+		})
+		mustNoError(t, err)
+		f.Places[i], err = NewProductionPlaceRepository(db).Save(&model.ProductionPlace{
+			Address: fmt.Sprintf("农场%d", i), Administrator: fmt.Sprintf("场长%d", i), Phone: "1380000000" + fmt.Sprint(i),
+		})
+		mustNoError(t, err)
+		f.Companies[i], err = NewCompanyRepository(db).Save(&model.Company{
+			Name: fmt.Sprintf("物流%d", i), Address: "仓库", Administrator: fmt.Sprintf("经理%d", i), Phone: "1390000000" + fmt.Sprint(i),
+		})
+		mustNoError(t, err)
+		f.SalePlaces[i], err = NewSalePlaceRepository(db).Save(&model.SalePlace{
+			Address: fmt.Sprintf("超市%d", i), Administrator: fmt.Sprintf("店长%d", i), Phone: "1370000000" + fmt.Sprint(i),
+		})
+		mustNoError(t, err)
+
+		f.Productions[i], err = NewProductionRepository(db).Save(&model.ProductionInfo{
+			BatchNo:        fmt.Sprintf("B%04d", i),
+			ProductID:      f.Products[i],
+			ProductPlaceID: f.Places[i],
+			SeedSource:     "种子站",
+			Description:    "生产",
+			PlantingDate:   testTime.AddDate(0, -3, 0),
+			HarvestDate:    testTime,
+			Quantity:       100,
+			Unit:           "kg",
+		})
+		mustNoError(t, err)
+
+		quantity := 40.0
+		f.Logistics[i], err = NewLogisticsRepository(db).Save(&model.Logistics{
+			ProductInfoID: f.Productions[i],
+			CompanyID:     f.Companies[i],
+			Quantity:      &quantity,
+			StartLocation: fmt.Sprintf("农场%d", i),
+			Destination:   fmt.Sprintf("超市%d", i),
+			StartTime:     testTime.Add(time.Hour),
+			Status:        model.LogisticsStatusCreated,
+			StatusTime:    &testTime,
+		})
+		mustNoError(t, err)
+
+		f.SaleInfos[i], err = NewSaleInfoRepository(db).Save(&model.SaleInfo{
+			LogisticsID: f.Logistics[i],
+			SalePlaceID: f.SalePlaces[i],
+			Description: "销售",
+			SaleTime:    testTime.Add(24 * time.Hour),
+		})
+		mustNoError(t, err)
+	}
+	return f
+}
+
+// companyScope 限于物流公司的数据权限
+func companyScope(id int) model.DataScope {
+	return model.DataScope{CompanyID: &id}
+}
+
+// productPlaceScope 限于生产地的数据权限
+func productPlaceScope(id int) model.DataScope {
+	return model.DataScope{ProductPlaceID: &id}
+}
+
+// salePlaceScope 限于销售地的数据权限
+func salePlaceScope(id int) model.DataScope {
+	return model.DataScope{SalePlaceID: &id}
+}
+
+// mustNoError 出现错误时立即终止测试
+func mustNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("意外的错误: %v", err)
+	}
+}
+
+// assertIDs 校验查询结果的ID及顺序
+func assertIDs[T any](t *testing.T, list []T, id func(T) int, want []int) {
+	t.Helper()
+	got := make([]int, 0, len(list))
+	for _, item := range list {
+		got = append(got, id(item))
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ID = %v, 期望 %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("ID = %v, 期望 %v", got, want)
+		}
+	}
+}
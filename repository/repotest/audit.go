@@ -0,0 +1,91 @@
+package repotest
+
+import (
+	"sort"
+	"sync"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.AuditRepository = (*AuditRepository)(nil)
+
+// AuditRepository 审计日志仓库的内存实现
+type AuditRepository struct {
+	mu        sync.Mutex
+	nextID    int
+	AuditLogs map[int]*model.AuditLog
+	Err       error
+}
+
+// NewAuditRepository 创建审计日志仓库，可传入初始数据
+func NewAuditRepository(auditLogs ...*model.AuditLog) *AuditRepository {
+	r := &AuditRepository{AuditLogs: make(map[int]*model.AuditLog)}
+	for _, auditLog := range auditLogs {
+		saved := *auditLog
+		saved.ID = nextID(&r.nextID, saved.ID)
+		r.AuditLogs[saved.ID] = &saved
+	}
+	return r
+}
+
+// Save 保存审计日志
+func (r *AuditRepository) Save(auditLog *model.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	saved := *auditLog
+	saved.ID = nextID(&r.nextID, saved.ID)
+	r.AuditLogs[saved.ID] = &saved
+	return nil
+}
+
+// PageQuery 按实体、操作人、操作及时间范围分页查询审计日志(按时间倒序)
+func (r *AuditRepository) PageQuery(dto *model.AuditLogPageQueryDTO) ([]*model.AuditLog, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, 0, r.Err
+	}
+
+	matched := []*model.AuditLog{}
+	for _, auditLog := range values(r.AuditLogs, func(al *model.AuditLog) int { return al.ID }) {
+		if dto.Entity != "" && auditLog.Entity != dto.Entity {
+			continue
+		}
+		if dto.EntityID > 0 && auditLog.EntityID != dto.EntityID {
+			continue
+		}
+		if dto.Actor != "" && auditLog.Actor != dto.Actor {
+			continue
+		}
+		if dto.Action != "" && auditLog.Action != dto.Action {
+			continue
+		}
+		if dto.Start != nil && auditLog.CreateTime.Before(*dto.Start) {
+			continue
+		}
+		if dto.End != nil && !auditLog.CreateTime.Before(*dto.End) {
+			continue
+		}
+		found := *auditLog
+		matched = append(matched, &found)
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].CreateTime.After(matched[j].CreateTime) })
+	return paginate(matched, dto.Page, dto.Size), int64(len(matched)), nil
+}
+
+// snapshot 复制当前的审计日志，返回的函数用于工作单元回滚
+func (r *AuditRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	auditLogs := cloneMap(r.AuditLogs)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.AuditLogs = auditLogs
+	}
+}
@@ -0,0 +1,139 @@
+package repotest
+
+import (
+	"sync"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.BatchRepository = (*BatchRepository)(nil)
+
+// BatchRepository 生产批次仓库的内存实现
+// 批次数量及物流分配从Productions、Logistics中读取，需与其他内存仓库共用同一实例
+type BatchRepository struct {
+	mu          sync.Mutex
+	nextID      int
+	Lineages    map[int]*model.BatchLineage
+	Productions *ProductionRepository
+	Logistics   *LogisticsRepository
+	Err         error
+}
+
+// NewBatchRepository 创建生产批次仓库
+func NewBatchRepository(productions *ProductionRepository, logistics *LogisticsRepository) *BatchRepository {
+	return &BatchRepository{
+		Lineages:    make(map[int]*model.BatchLineage),
+		Productions: productions,
+		Logistics:   logistics,
+	}
+}
+
+// Usage 查询批次数量使用情况，批次不存在时返回nil
+func (r *BatchRepository) Usage(productInfoID, excludeLogisticsID int) (*model.BatchUsage, error) {
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	production, err := r.Productions.GetByID(productInfoID)
+	if err != nil || production == nil {
+		return nil, err
+	}
+	usage := &model.BatchUsage{Quantity: production.Quantity}
+
+	shipments, err := r.Logistics.FindByProductInfoID(productInfoID)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range shipments {
+		if l.Quantity != nil && !releasesAllocation(l.Status) && l.ID != excludeLogisticsID {
+			usage.Allocated += *l.Quantity
+		}
+	}
+
+	children, err := r.FindChildren(productInfoID)
+	if err != nil {
+		return nil, err
+	}
+	for _, lineage := range children {
+		usage.Derived += lineage.Quantity
+	}
+	return usage, nil
+}
+
+// releasesAllocation 已取消和已拒收的物流不再占用批次数量
+func releasesAllocation(status string) bool {
+	return status == model.LogisticsStatusCancelled || status == model.LogisticsStatusRejected
+}
+
+// BatchNoExists 批次号是否已存在
+func (r *BatchRepository) BatchNoExists(batchNo string) (bool, error) {
+	if r.Err != nil {
+		return false, r.Err
+	}
+
+	productions, err := r.Productions.GetAll(model.DataScope{})
+	if err != nil {
+		return false, err
+	}
+	for _, production := range productions {
+		if production.BatchNo == batchNo {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SaveLineage 保存批次谱系
+func (r *BatchRepository) SaveLineage(lineage *model.BatchLineage) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := *lineage
+	saved.ID = nextID(&r.nextID, saved.ID)
+	r.Lineages[saved.ID] = &saved
+	return saved.ID, nil
+}
+
+// FindParents 查询批次的来源批次
+func (r *BatchRepository) FindParents(childID int) ([]*model.BatchLineage, error) {
+	return r.find(func(l *model.BatchLineage) bool { return l.ChildID == childID })
+}
+
+// FindChildren 查询由批次产出的批次
+func (r *BatchRepository) FindChildren(parentID int) ([]*model.BatchLineage, error) {
+	return r.find(func(l *model.BatchLineage) bool { return l.ParentID == parentID })
+}
+
+// find 按条件查询谱系
+func (r *BatchRepository) find(match func(*model.BatchLineage) bool) ([]*model.BatchLineage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	var lineages []*model.BatchLineage
+	for _, lineage := range values(r.Lineages, func(l *model.BatchLineage) int { return l.ID }) {
+		if match(lineage) {
+			found := *lineage
+			lineages = append(lineages, &found)
+		}
+	}
+	return lineages, nil
+}
+
+// snapshot 复制当前的批次谱系，返回的函数用于工作单元回滚
+func (r *BatchRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lineages := cloneMap(r.Lineages)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Lineages = lineages
+	}
+}
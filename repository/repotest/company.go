@@ -0,0 +1,149 @@
+package repotest
+
+import (
+	"sync"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.CompanyRepository = (*CompanyRepository)(nil)
+
+// CompanyRepository 物流公司仓库的内存实现
+type CompanyRepository struct {
+	mu        sync.Mutex
+	nextID    int
+	Companies map[int]*model.Company
+	Err       error
+}
+
+// NewCompanyRepository 创建物流公司仓库，可传入初始数据
+func NewCompanyRepository(companies ...*model.Company) *CompanyRepository {
+	r := &CompanyRepository{Companies: make(map[int]*model.Company)}
+	for _, company := range companies {
+		r.Save(company)
+	}
+	return r
+}
+
+// Save 保存物流公司，ID为0时自动分配
+func (r *CompanyRepository) Save(company *model.Company) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := *company
+	saved.ID = nextID(&r.nextID, saved.ID)
+	r.Companies[saved.ID] = &saved
+	return saved.ID, nil
+}
+
+// Update 更新物流公司
+func (r *CompanyRepository) Update(company *model.Company) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if _, ok := r.Companies[company.ID]; ok {
+		updated := *company
+		r.Companies[company.ID] = &updated
+	}
+	return nil
+}
+
+// Delete 软删除物流公司
+func (r *CompanyRepository) Delete(id int, deletedAt time.Time, deletedBy string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if company, ok := r.Companies[id]; ok && !company.Deleted() {
+		company.DeletedAt = &deletedAt
+		company.DeletedBy = deletedBy
+	}
+	return nil
+}
+
+// Restore 恢复已删除的物流公司
+func (r *CompanyRepository) Restore(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if company, ok := r.Companies[id]; ok {
+		company.SoftDelete = model.SoftDelete{}
+	}
+	return nil
+}
+
+// GetByID 根据ID查询物流公司(含已删除的公司)，不存在时返回nil
+func (r *CompanyRepository) GetByID(id int) (*model.Company, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	company, ok := r.Companies[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *company
+	return &found, nil
+}
+
+// FindAll 查询所有物流公司，includeDeleted为false时不含已删除的公司
+func (r *CompanyRepository) FindAll(includeDeleted bool) ([]*model.Company, error) {
+	return r.find(func(*model.Company) bool { return true }, includeDeleted)
+}
+
+// PageQuery 按名称、地址、负责人、电话(均为模糊匹配)分页查询物流公司
+func (r *CompanyRepository) PageQuery(page, pageSize int, name, address, administrator, phone string, includeDeleted bool) ([]*model.Company, int64, error) {
+	companies, err := r.find(func(c *model.Company) bool {
+		return contains(c.Name, name) && contains(c.Address, address) &&
+			contains(c.Administrator, administrator) && contains(c.Phone, phone)
+	}, includeDeleted)
+	if err != nil {
+		return nil, 0, err
+	}
+	return paginate(companies, page, pageSize), int64(len(companies)), nil
+}
+
+// find 按条件查询物流公司
+func (r *CompanyRepository) find(match func(*model.Company) bool, includeDeleted bool) ([]*model.Company, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	var companies []*model.Company
+	for _, company := range values(r.Companies, func(c *model.Company) int { return c.ID }) {
+		if (includeDeleted || !company.Deleted()) && match(company) {
+			found := *company
+			companies = append(companies, &found)
+		}
+	}
+	return companies, nil
+}
+
+// snapshot 复制当前的物流公司，返回的函数用于工作单元回滚
+func (r *CompanyRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	companies := cloneMap(r.Companies)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Companies = companies
+	}
+}
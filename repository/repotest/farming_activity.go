@@ -0,0 +1,127 @@
+package repotest
+
+import (
+	"maps"
+	"sort"
+	"sync"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.FarmingActivityRepository = (*FarmingActivityRepository)(nil)
+
+// FarmingActivityRepository 农事活动仓库的内存实现
+// ProductPlaces为生产信息ID到生产地ID的映射，用于模拟与product_info的关联
+type FarmingActivityRepository struct {
+	mu            sync.Mutex
+	nextID        int
+	Activities    map[int]*model.FarmingActivity
+	ProductPlaces map[int]int
+	Err           error
+}
+
+// NewFarmingActivityRepository 创建农事活动仓库，可传入初始数据
+func NewFarmingActivityRepository(activities ...*model.FarmingActivity) *FarmingActivityRepository {
+	r := &FarmingActivityRepository{Activities: make(map[int]*model.FarmingActivity), ProductPlaces: make(map[int]int)}
+	for _, activity := range activities {
+		saved := *activity
+		saved.ID = nextID(&r.nextID, saved.ID)
+		r.Activities[saved.ID] = &saved
+	}
+	return r
+}
+
+// Save 保存农事活动，所属生产地根据ProductPlaces填充
+func (r *FarmingActivityRepository) Save(activity *model.FarmingActivity) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := *activity
+	saved.ID = nextID(&r.nextID, saved.ID)
+	saved.ProductPlaceID = r.ProductPlaces[saved.ProductInfoID]
+	r.Activities[saved.ID] = &saved
+	return saved.ID, nil
+}
+
+// Update 更新农事活动(所属生产信息不可修改)
+func (r *FarmingActivityRepository) Update(activity *model.FarmingActivity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if existing, ok := r.Activities[activity.ID]; ok {
+		updated := *activity
+		updated.ProductInfoID = existing.ProductInfoID
+		updated.ProductPlaceID = existing.ProductPlaceID
+		r.Activities[activity.ID] = &updated
+	}
+	return nil
+}
+
+// Delete 删除农事活动
+func (r *FarmingActivityRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	delete(r.Activities, id)
+	return nil
+}
+
+// GetByID 根据ID查询农事活动，不存在时返回nil
+func (r *FarmingActivityRepository) GetByID(id int) (*model.FarmingActivity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	activity, ok := r.Activities[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *activity
+	return &found, nil
+}
+
+// FindByProductInfoID 查询生产信息的农事活动(按作业时间排序)
+func (r *FarmingActivityRepository) FindByProductInfoID(productInfoID int) ([]*model.FarmingActivity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	activities := []*model.FarmingActivity{}
+	for _, activity := range values(r.Activities, func(a *model.FarmingActivity) int { return a.ID }) {
+		if activity.ProductInfoID == productInfoID {
+			found := *activity
+			activities = append(activities, &found)
+		}
+	}
+	sort.SliceStable(activities, func(i, j int) bool {
+		return activities[i].ActivityDate.Before(activities[j].ActivityDate)
+	})
+	return activities, nil
+}
+
+// snapshot 复制当前的农事记录，返回的函数用于工作单元回滚
+func (r *FarmingActivityRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	activities := cloneMap(r.Activities)
+	productPlaces := maps.Clone(r.ProductPlaces)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Activities, r.ProductPlaces = activities, productPlaces
+	}
+}
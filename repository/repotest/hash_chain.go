@@ -0,0 +1,167 @@
+package repotest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.HashChainRepository = (*HashChainRepository)(nil)
+
+// HashChainRepository 哈希链仓库的内存实现
+// Payloads为记录当前的入链内容，用于模拟数据表；Append按数据库实现的规则追加条目
+type HashChainRepository struct {
+	mu       sync.Mutex
+	Entries  []*model.HashChainEntry
+	Payloads map[model.HashChainRef]string
+	Err      error
+}
+
+// NewHashChainRepository 创建哈希链仓库
+func NewHashChainRepository() *HashChainRepository {
+	return &HashChainRepository{Payloads: make(map[model.HashChainRef]string)}
+}
+
+// Append 写入记录的当前内容并追加条目，删除时移除记录
+func (r *HashChainRepository) Append(entity string, entityID int, action, payload string) *model.HashChainEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ref := model.HashChainRef{Entity: entity, EntityID: entityID}
+	if action == model.HashChainDelete {
+		delete(r.Payloads, ref)
+		payload = ""
+	} else {
+		r.Payloads[ref] = payload
+	}
+	return r.append(entity, entityID, action, payload)
+}
+
+// FindRange 按顺序查询从fromSeq开始的至多limit个条目
+func (r *HashChainRepository) FindRange(fromSeq, limit int) ([]*model.HashChainEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	entries := []*model.HashChainEntry{}
+	for _, entry := range r.Entries {
+		if entry.Seq >= fromSeq && len(entries) < limit {
+			found := *entry
+			entries = append(entries, &found)
+		}
+	}
+	return entries, nil
+}
+
+// FindLatest 查询记录最近一次入链的条目，未入链时返回nil
+func (r *HashChainRepository) FindLatest(entity string, entityID int) (*model.HashChainEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	for i := len(r.Entries) - 1; i >= 0; i-- {
+		if entry := r.Entries[i]; entry.Entity == entity && entry.EntityID == entityID {
+			found := *entry
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+// Payload 读取记录当前的入链内容，记录不存在时返回false
+func (r *HashChainRepository) Payload(entity string, entityID int) (string, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return "", false, r.Err
+	}
+
+	payload, ok := r.Payloads[model.HashChainRef{Entity: entity, EntityID: entityID}]
+	return payload, ok, nil
+}
+
+// FindUnchained 查询没有任何入链条目的记录
+func (r *HashChainRepository) FindUnchained() ([]*model.HashChainRef, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	chained := make(map[model.HashChainRef]bool, len(r.Entries))
+	for _, entry := range r.Entries {
+		chained[model.HashChainRef{Entity: entry.Entity, EntityID: entry.EntityID}] = true
+	}
+
+	refs := []*model.HashChainRef{}
+	for _, ref := range r.sortedRefs() {
+		if !chained[ref] {
+			found := ref
+			refs = append(refs, &found)
+		}
+	}
+	return refs, nil
+}
+
+// Seal 哈希链为空时将已有记录全部入链，返回入链的记录数
+func (r *HashChainRepository) Seal() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+	if len(r.Entries) > 0 {
+		return 0, nil
+	}
+
+	refs := r.sortedRefs()
+	for _, ref := range refs {
+		r.append(ref.Entity, ref.EntityID, model.HashChainCreate, r.Payloads[ref])
+	}
+	return len(refs), nil
+}
+
+// append 追加条目，调用方需持有锁
+func (r *HashChainRepository) append(entity string, entityID int, action, payload string) *model.HashChainEntry {
+	entry := &model.HashChainEntry{
+		HashChainProof: model.HashChainProof{
+			Seq:        len(r.Entries) + 1,
+			CreateTime: time.Now().UTC().Truncate(time.Second),
+		},
+		Entity:   entity,
+		EntityID: entityID,
+		Action:   action,
+		Payload:  payload,
+	}
+	if len(r.Entries) > 0 {
+		entry.PrevHash = r.Entries[len(r.Entries)-1].Hash
+	}
+	entry.RecordHash = entry.ComputeRecordHash()
+	entry.Hash = entry.ComputeHash()
+	r.Entries = append(r.Entries, entry)
+
+	found := *entry
+	return &found
+}
+
+// sortedRefs 按实体、ID排序的记录，调用方需持有锁
+func (r *HashChainRepository) sortedRefs() []model.HashChainRef {
+	refs := make([]model.HashChainRef, 0, len(r.Payloads))
+	for ref := range r.Payloads {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Entity != refs[j].Entity {
+			return refs[i].Entity < refs[j].Entity
+		}
+		return refs[i].EntityID < refs[j].EntityID
+	})
+	return refs
+}
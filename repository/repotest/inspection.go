@@ -0,0 +1,185 @@
+package repotest
+
+import (
+	"maps"
+	"sort"
+	"sync"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.InspectionRepository = (*InspectionRepository)(nil)
+
+// InspectionRepository 质量检测仓库的内存实现
+// ProductPlaces为生产信息ID到生产地ID的映射，Companies为物流信息ID到承运公司ID的映射，用于模拟关联查询
+type InspectionRepository struct {
+	mu            sync.Mutex
+	nextID        int
+	nextItemID    int
+	Inspections   map[int]*model.Inspection
+	ProductPlaces map[int]int
+	Companies     map[int]int
+	Err           error
+}
+
+// NewInspectionRepository 创建质量检测仓库，可传入初始数据
+func NewInspectionRepository(inspections ...*model.Inspection) *InspectionRepository {
+	r := &InspectionRepository{
+		Inspections:   make(map[int]*model.Inspection),
+		ProductPlaces: make(map[int]int),
+		Companies:     make(map[int]int),
+	}
+	for _, inspection := range inspections {
+		saved := r.copy(inspection)
+		saved.ID = nextID(&r.nextID, saved.ID)
+		r.Inspections[saved.ID] = saved
+	}
+	return r
+}
+
+// Save 保存检测记录及检测项目
+func (r *InspectionRepository) Save(inspection *model.Inspection) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := r.copy(inspection)
+	saved.ID = nextID(&r.nextID, saved.ID)
+	r.link(saved)
+	r.Inspections[saved.ID] = saved
+	return saved.ID, nil
+}
+
+// Update 更新检测记录，检测项目整体替换
+func (r *InspectionRepository) Update(inspection *model.Inspection) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if _, ok := r.Inspections[inspection.ID]; ok {
+		updated := r.copy(inspection)
+		r.link(updated)
+		r.Inspections[inspection.ID] = updated
+	}
+	return nil
+}
+
+// Delete 删除检测记录
+func (r *InspectionRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	delete(r.Inspections, id)
+	return nil
+}
+
+// GetByID 根据ID查询检测记录，不存在时返回nil
+func (r *InspectionRepository) GetByID(id int) (*model.Inspection, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	inspection, ok := r.Inspections[id]
+	if !ok {
+		return nil, nil
+	}
+	return r.copy(inspection), nil
+}
+
+// FindByProductInfoID 查询批次的检测记录(按检测时间排序)
+func (r *InspectionRepository) FindByProductInfoID(productInfoID int) ([]*model.Inspection, error) {
+	inspections, err := r.find(func(i *model.Inspection) bool { return i.ProductInfoID == productInfoID })
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(inspections, func(i, j int) bool {
+		return inspections[i].InspectionDate.Before(inspections[j].InspectionDate)
+	})
+	return inspections, nil
+}
+
+// PageQuery 在数据权限范围内分页查询检测记录(不含检测项目)
+func (r *InspectionRepository) PageQuery(dto *model.InspectionPageQueryDTO, scope model.DataScope) ([]*model.Inspection, int64, error) {
+	matched, err := r.find(func(i *model.Inspection) bool {
+		return scope.AllowCompany(i.CompanyID) && scope.AllowProductPlace(i.ProductPlaceID) &&
+			(dto.ProductInfoID <= 0 || i.ProductInfoID == dto.ProductInfoID) &&
+			(dto.LogisticsID <= 0 || i.LogisticsID == dto.LogisticsID) &&
+			(dto.InspectionType == "" || i.InspectionType == dto.InspectionType) &&
+			(dto.Verdict == "" || i.Verdict == dto.Verdict) &&
+			contains(i.BatchNo, dto.BatchNo) && contains(i.LabName, dto.LabName)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 与数据库实现一致，按检测时间倒序
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].InspectionDate.After(matched[j].InspectionDate)
+	})
+	for _, inspection := range matched {
+		inspection.Items = nil
+	}
+	return paginate(matched, dto.Page, dto.Size), int64(len(matched)), nil
+}
+
+// find 按条件查询检测记录
+func (r *InspectionRepository) find(match func(*model.Inspection) bool) ([]*model.Inspection, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	inspections := []*model.Inspection{}
+	for _, inspection := range values(r.Inspections, func(i *model.Inspection) int { return i.ID }) {
+		if match(inspection) {
+			inspections = append(inspections, r.copy(inspection))
+		}
+	}
+	return inspections, nil
+}
+
+// link 根据映射填充所属生产地和承运公司，并为新的检测项目分配ID，调用方需持有锁
+func (r *InspectionRepository) link(inspection *model.Inspection) {
+	inspection.ProductPlaceID = r.ProductPlaces[inspection.ProductInfoID]
+	inspection.CompanyID = r.Companies[inspection.LogisticsID]
+	for _, item := range inspection.Items {
+		item.ID = nextID(&r.nextItemID, 0)
+		item.InspectionID = inspection.ID
+	}
+}
+
+// copy 复制检测记录及检测项目，避免调用方修改内存中的数据
+func (r *InspectionRepository) copy(inspection *model.Inspection) *model.Inspection {
+	copied := *inspection
+	copied.Items = make([]*model.InspectionItem, 0, len(inspection.Items))
+	for _, item := range inspection.Items {
+		itemCopy := *item
+		copied.Items = append(copied.Items, &itemCopy)
+	}
+	return &copied
+}
+
+// snapshot 复制当前的质检报告，返回的函数用于工作单元回滚
+func (r *InspectionRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inspections := cloneMap(r.Inspections)
+	productPlaces := maps.Clone(r.ProductPlaces)
+	companies := maps.Clone(r.Companies)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Inspections, r.ProductPlaces, r.Companies = inspections, productPlaces, companies
+	}
+}
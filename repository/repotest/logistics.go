@@ -0,0 +1,203 @@
+package repotest
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.LogisticsRepository = (*LogisticsRepository)(nil)
+
+// LogisticsRepository 物流仓库的内存实现
+// ProductPlaces为生产信息ID到生产地ID的映射，用于模拟与product_info的关联
+type LogisticsRepository struct {
+	mu            sync.Mutex
+	nextID        int
+	Logistics     map[int]*model.Logistics
+	ProductPlaces map[int]int
+	Err           error
+}
+
+// NewLogisticsRepository 创建物流仓库，可传入初始数据
+func NewLogisticsRepository(logistics ...*model.Logistics) *LogisticsRepository {
+	r := &LogisticsRepository{Logistics: make(map[int]*model.Logistics), ProductPlaces: make(map[int]int)}
+	for _, item := range logistics {
+		saved := *item
+		saved.ID = nextID(&r.nextID, saved.ID)
+		r.Logistics[saved.ID] = &saved
+	}
+	return r
+}
+
+// Save 保存物流信息，所属生产地根据ProductPlaces填充
+func (r *LogisticsRepository) Save(logistics *model.Logistics) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := *logistics
+	saved.ID = nextID(&r.nextID, saved.ID)
+	saved.ProductPlaceID = r.ProductPlaces[saved.ProductInfoID]
+	r.Logistics[saved.ID] = &saved
+	return saved.ID, nil
+}
+
+// Update 在物流状态仍为status时更新物流信息，不修改状态与到达时间
+func (r *LogisticsRepository) Update(logistics *model.Logistics, status string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return false, r.Err
+	}
+
+	existing, ok := r.Logistics[logistics.ID]
+	if !ok || existing.Status != status {
+		return false, nil
+	}
+	existing.ProductInfoID = logistics.ProductInfoID
+	existing.CompanyID = logistics.CompanyID
+	existing.PrevID = logistics.PrevID
+	existing.Quantity = logistics.Quantity
+	existing.StartLocation = logistics.StartLocation
+	existing.Destination = logistics.Destination
+	existing.StartTime = logistics.StartTime
+	existing.ProductPlaceID = r.ProductPlaces[logistics.ProductInfoID]
+	return true, nil
+}
+
+// Delete 删除物流信息
+func (r *LogisticsRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	delete(r.Logistics, id)
+	return nil
+}
+
+// GetByID 根据ID查询物流信息，不存在时返回nil
+func (r *LogisticsRepository) GetByID(id int) (*model.Logistics, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	logistics, ok := r.Logistics[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *logistics
+	return &found, nil
+}
+
+// FindAll 查询数据权限范围内的所有物流信息
+func (r *LogisticsRepository) FindAll(scope model.DataScope) ([]*model.Logistics, error) {
+	return r.find(func(l *model.Logistics) bool {
+		return scope.AllowCompany(l.CompanyID) && scope.AllowProductPlace(l.ProductPlaceID) &&
+			(scope.SalePlaceID == nil || slices.Contains(l.SalePlaceIDs, *scope.SalePlaceID))
+	})
+}
+
+// PageQuery 在数据权限范围内分页查询物流信息
+func (r *LogisticsRepository) PageQuery(dto *model.LogisticsPageQueryDTO, scope model.DataScope) ([]*model.Logistics, int64, error) {
+	logistics, err := r.FindAll(scope)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	startDate, dateErr := time.Parse("2006-01-02", dto.StartTime)
+	var matched []*model.Logistics
+	for _, l := range logistics {
+		if dto.LogisticsId > 0 && l.ID != dto.LogisticsId {
+			continue
+		}
+		if dto.Status != "" && l.Status != dto.Status {
+			continue
+		}
+		if dto.StartTime != "" && dateErr == nil && l.StartTime.Format("2006-01-02") != startDate.Format("2006-01-02") {
+			continue
+		}
+		if contains(l.ProductName, dto.ProductName) && contains(l.CompanyName, dto.CompanyName) &&
+			contains(l.StartLocation, dto.StartLocation) && contains(l.Destination, dto.Destination) &&
+			contains(l.Administrator, dto.Administrator) {
+			matched = append(matched, l)
+		}
+	}
+	return paginate(matched, dto.Page, dto.Size), int64(len(matched)), nil
+}
+
+// FindByProductInfoID 查询生产信息对应的所有物流信息
+func (r *LogisticsRepository) FindByProductInfoID(productInfoID int) ([]*model.Logistics, error) {
+	return r.find(func(l *model.Logistics) bool { return l.ProductInfoID == productInfoID })
+}
+
+// UpdateStatus 将物流状态从fromStatus更新为status，endTime不为nil时同时记录到达时间，返回是否更新
+func (r *LogisticsRepository) UpdateStatus(id int, fromStatus, status, reason string, statusTime time.Time, endTime *time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return false, r.Err
+	}
+
+	existing, ok := r.Logistics[id]
+	if !ok || existing.Status != fromStatus {
+		return false, nil
+	}
+	existing.Status = status
+	existing.StatusReason = reason
+	existing.StatusTime = &statusTime
+	if endTime != nil {
+		existing.EndTime = endTime
+	}
+	return true, nil
+}
+
+// ProductPlaceIDOf 查询生产信息所属的生产地ID，未登记时返回0
+func (r *LogisticsRepository) ProductPlaceIDOf(productInfoID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+	return r.ProductPlaces[productInfoID], nil
+}
+
+// find 按条件查询物流信息
+func (r *LogisticsRepository) find(match func(*model.Logistics) bool) ([]*model.Logistics, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	var logistics []*model.Logistics
+	for _, l := range values(r.Logistics, func(l *model.Logistics) int { return l.ID }) {
+		if match(l) {
+			found := *l
+			logistics = append(logistics, &found)
+		}
+	}
+	return logistics, nil
+}
+
+// snapshot 复制当前的物流信息，返回的函数用于工作单元回滚
+func (r *LogisticsRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	logistics := cloneMap(r.Logistics)
+	productPlaces := maps.Clone(r.ProductPlaces)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Logistics, r.ProductPlaces = logistics, productPlaces
+	}
+}
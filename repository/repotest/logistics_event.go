@@ -0,0 +1,101 @@
+package repotest
+
+import (
+	"sort"
+	"sync"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.LogisticsEventRepository = (*LogisticsEventRepository)(nil)
+
+// LogisticsEventRepository 物流事件仓库的内存实现
+type LogisticsEventRepository struct {
+	mu     sync.Mutex
+	nextID int
+	Events map[int]*model.LogisticsEvent
+	Err    error
+}
+
+// NewLogisticsEventRepository 创建物流事件仓库，可传入初始数据
+func NewLogisticsEventRepository(events ...*model.LogisticsEvent) *LogisticsEventRepository {
+	r := &LogisticsEventRepository{Events: make(map[int]*model.LogisticsEvent)}
+	for _, event := range events {
+		r.Save(event)
+	}
+	return r
+}
+
+// Save 保存物流事件
+func (r *LogisticsEventRepository) Save(event *model.LogisticsEvent) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := *event
+	saved.ID = nextID(&r.nextID, saved.ID)
+	r.Events[saved.ID] = &saved
+	return saved.ID, nil
+}
+
+// Delete 删除物流事件
+func (r *LogisticsEventRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	delete(r.Events, id)
+	return nil
+}
+
+// GetByID 根据ID查询物流事件，不存在时返回nil
+func (r *LogisticsEventRepository) GetByID(id int) (*model.LogisticsEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	event, ok := r.Events[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *event
+	return &found, nil
+}
+
+// FindByLogisticsID 按发生时间顺序查询物流信息的事件时间线
+func (r *LogisticsEventRepository) FindByLogisticsID(logisticsID int) ([]*model.LogisticsEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	var events []*model.LogisticsEvent
+	for _, event := range values(r.Events, func(e *model.LogisticsEvent) int { return e.ID }) {
+		if event.LogisticsID == logisticsID {
+			found := *event
+			events = append(events, &found)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].EventTime.Before(events[j].EventTime) })
+	return events, nil
+}
+
+// snapshot 复制当前的物流事件，返回的函数用于工作单元回滚
+func (r *LogisticsEventRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := cloneMap(r.Events)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Events = events
+	}
+}
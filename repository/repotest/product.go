@@ -0,0 +1,176 @@
+package repotest
+
+import (
+	"sync"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.ProductRepository = (*ProductRepository)(nil)
+
+// ProductRepository 产品仓库的内存实现
+type ProductRepository struct {
+	mu       sync.Mutex
+	nextID   int
+	Products map[int]*model.Product
+	Err      error
+}
+
+// NewProductRepository 创建产品仓库，可传入初始数据
+func NewProductRepository(products ...*model.Product) *ProductRepository {
+	r := &ProductRepository{Products: make(map[int]*model.Product)}
+	for _, product := range products {
+		r.Save(product)
+	}
+	return r
+}
+
+// Save 保存产品，ID为0时自动分配
+func (r *ProductRepository) Save(product *model.Product) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := *product
+	saved.ID = nextID(&r.nextID, saved.ID)
+	r.Products[saved.ID] = &saved
+	return saved.ID, nil
+}
+
+// Update 更新产品
+func (r *ProductRepository) Update(product *model.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if _, ok := r.Products[product.ID]; ok {
+		updated := *product
+		r.Products[product.ID] = &updated
+	}
+	return nil
+}
+
+// Delete 软删除产品
+func (r *ProductRepository) Delete(id int, deletedAt time.Time, deletedBy string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if product, ok := r.Products[id]; ok && !product.Deleted() {
+		product.DeletedAt = &deletedAt
+		product.DeletedBy = deletedBy
+	}
+	return nil
+}
+
+// Restore 恢复已删除的产品
+func (r *ProductRepository) Restore(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if product, ok := r.Products[id]; ok {
+		product.SoftDelete = model.SoftDelete{}
+	}
+	return nil
+}
+
+// GetByID 根据ID查询产品(含已删除的产品)，不存在时返回nil
+func (r *ProductRepository) GetByID(id int) (*model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	product, ok := r.Products[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *product
+	return &found, nil
+}
+
+// FindAll 查询所有产品，includeDeleted为false时不含已删除的产品
+func (r *ProductRepository) FindAll(includeDeleted bool) ([]*model.Product, error) {
+	return r.find("", "", includeDeleted)
+}
+
+// FindByCondition 按名称(模糊)和类别查询未删除的产品
+func (r *ProductRepository) FindByCondition(name, productType string) ([]*model.Product, error) {
+	return r.find(name, productType, false)
+}
+
+// find 按名称(模糊)和类别查询产品
+func (r *ProductRepository) find(name, productType string, includeDeleted bool) ([]*model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	var products []*model.Product
+	for _, product := range r.sorted() {
+		if (includeDeleted || !product.Deleted()) &&
+			contains(product.Name, name) && (productType == "" || product.Type == productType) {
+			found := *product
+			products = append(products, &found)
+		}
+	}
+	return products, nil
+}
+
+// PageQuery 分页查询产品
+func (r *ProductRepository) PageQuery(page, pageSize int, name, productType string, includeDeleted bool) ([]*model.Product, int64, error) {
+	products, err := r.find(name, productType, includeDeleted)
+	if err != nil {
+		return nil, 0, err
+	}
+	return paginate(products, page, pageSize), int64(len(products)), nil
+}
+
+// GetProductTypes 查询未删除产品的所有类别
+func (r *ProductRepository) GetProductTypes() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	var types []string
+	seen := make(map[string]bool)
+	for _, product := range r.sorted() {
+		if !product.Deleted() && !seen[product.Type] {
+			seen[product.Type] = true
+			types = append(types, product.Type)
+		}
+	}
+	return types, nil
+}
+
+// sorted 按ID排序的产品列表，调用方需持有锁
+func (r *ProductRepository) sorted() []*model.Product {
+	return values(r.Products, func(p *model.Product) int { return p.ID })
+}
+
+// snapshot 复制当前的产品，返回的函数用于工作单元回滚
+func (r *ProductRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	products := cloneMap(r.Products)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Products = products
+	}
+}
@@ -0,0 +1,142 @@
+package repotest
+
+import (
+	"strconv"
+	"sync"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.ProductionRepository = (*ProductionRepository)(nil)
+
+// ProductionRepository 生产信息仓库的内存实现
+type ProductionRepository struct {
+	mu          sync.Mutex
+	nextID      int
+	Productions map[int]*model.ProductionInfoWithDetails
+	Err         error
+}
+
+// NewProductionRepository 创建生产信息仓库，可传入初始数据(含产品名称、生产地等展示字段)
+func NewProductionRepository(productions ...*model.ProductionInfoWithDetails) *ProductionRepository {
+	r := &ProductionRepository{Productions: make(map[int]*model.ProductionInfoWithDetails)}
+	for _, production := range productions {
+		saved := *production
+		saved.ID = nextID(&r.nextID, saved.ID)
+		r.Productions[saved.ID] = &saved
+	}
+	return r
+}
+
+// Save 保存生产信息
+func (r *ProductionRepository) Save(production *model.ProductionInfo) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := &model.ProductionInfoWithDetails{ProductionInfo: *production}
+	saved.ID = nextID(&r.nextID, saved.ID)
+	r.Productions[saved.ID] = saved
+	return saved.ID, nil
+}
+
+// Update 更新生产信息，保留已有的展示字段
+func (r *ProductionRepository) Update(production *model.ProductionInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if existing, ok := r.Productions[production.ID]; ok {
+		updated := *existing
+		updated.ProductionInfo = *production
+		r.Productions[production.ID] = &updated
+	}
+	return nil
+}
+
+// Delete 删除生产信息
+func (r *ProductionRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	delete(r.Productions, id)
+	return nil
+}
+
+// GetByID 根据ID查询生产信息，不存在时返回nil
+func (r *ProductionRepository) GetByID(id int) (*model.ProductionInfoWithDetails, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	production, ok := r.Productions[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *production
+	return &found, nil
+}
+
+// PageQuery 在数据权限范围内分页查询生产信息
+func (r *ProductionRepository) PageQuery(
+	page, pageSize int,
+	productInfoID, productName, productPlace, seed, administrator string,
+	scope model.DataScope,
+) ([]*model.ProductionInfoWithDetails, int64, error) {
+	productions, err := r.GetAll(scope)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matched []*model.ProductionInfoWithDetails
+	for _, production := range productions {
+		if productInfoID != "" && strconv.Itoa(production.ID) != productInfoID {
+			continue
+		}
+		if contains(production.ProductName, productName) && contains(production.ProductionPlace, productPlace) &&
+			contains(production.SeedSource, seed) && contains(production.Administrator, administrator) {
+			matched = append(matched, production)
+		}
+	}
+	return paginate(matched, page, pageSize), int64(len(matched)), nil
+}
+
+// GetAll 查询数据权限范围内的所有生产信息
+func (r *ProductionRepository) GetAll(scope model.DataScope) ([]*model.ProductionInfoWithDetails, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	var productions []*model.ProductionInfoWithDetails
+	for _, production := range values(r.Productions, func(p *model.ProductionInfoWithDetails) int { return p.ID }) {
+		if scope.AllowProductPlace(production.ProductPlaceID) {
+			found := *production
+			productions = append(productions, &found)
+		}
+	}
+	return productions, nil
+}
+
+// snapshot 复制当前的生产信息，返回的函数用于工作单元回滚
+func (r *ProductionRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	productions := cloneMap(r.Productions)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Productions = productions
+	}
+}
@@ -0,0 +1,149 @@
+package repotest
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.ProductionPlaceRepository = (*ProductionPlaceRepository)(nil)
+
+// ProductionPlaceRepository 生产地仓库的内存实现
+type ProductionPlaceRepository struct {
+	mu     sync.Mutex
+	nextID int
+	Places map[int]*model.ProductionPlace
+	Err    error
+}
+
+// NewProductionPlaceRepository 创建生产地仓库，可传入初始数据
+func NewProductionPlaceRepository(places ...*model.ProductionPlace) *ProductionPlaceRepository {
+	r := &ProductionPlaceRepository{Places: make(map[int]*model.ProductionPlace)}
+	for _, place := range places {
+		r.Save(place)
+	}
+	return r
+}
+
+// Save 保存生产地，ID为0时自动分配
+func (r *ProductionPlaceRepository) Save(place *model.ProductionPlace) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := *place
+	saved.ID = nextID(&r.nextID, saved.ID)
+	r.Places[saved.ID] = &saved
+	return saved.ID, nil
+}
+
+// Update 更新生产地
+func (r *ProductionPlaceRepository) Update(place *model.ProductionPlace) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if _, ok := r.Places[place.ID]; ok {
+		updated := *place
+		r.Places[place.ID] = &updated
+	}
+	return nil
+}
+
+// Delete 软删除生产地
+func (r *ProductionPlaceRepository) Delete(id int, deletedAt time.Time, deletedBy string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if place, ok := r.Places[id]; ok && !place.Deleted() {
+		place.DeletedAt = &deletedAt
+		place.DeletedBy = deletedBy
+	}
+	return nil
+}
+
+// Restore 恢复已删除的生产地
+func (r *ProductionPlaceRepository) Restore(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if place, ok := r.Places[id]; ok {
+		place.SoftDelete = model.SoftDelete{}
+	}
+	return nil
+}
+
+// GetByID 根据ID查询生产地(含已删除的生产地)，不存在时返回nil
+func (r *ProductionPlaceRepository) GetByID(id int) (*model.ProductionPlace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	place, ok := r.Places[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *place
+	return &found, nil
+}
+
+// PageQuery 按ID(精确)、地址、负责人(模糊)分页查询生产地
+func (r *ProductionPlaceRepository) PageQuery(page, pageSize int, id, address, administrator string, includeDeleted bool) ([]*model.ProductionPlace, int64, error) {
+	places, err := r.find(func(p *model.ProductionPlace) bool {
+		return (id == "" || strconv.Itoa(p.ID) == id) && contains(p.Address, address) && contains(p.Administrator, administrator)
+	}, includeDeleted)
+	if err != nil {
+		return nil, 0, err
+	}
+	return paginate(places, page, pageSize), int64(len(places)), nil
+}
+
+// GetAll 查询所有生产地，includeDeleted为false时不含已删除的生产地
+func (r *ProductionPlaceRepository) GetAll(includeDeleted bool) ([]*model.ProductionPlace, error) {
+	return r.find(func(*model.ProductionPlace) bool { return true }, includeDeleted)
+}
+
+// find 按条件查询生产地
+func (r *ProductionPlaceRepository) find(match func(*model.ProductionPlace) bool, includeDeleted bool) ([]*model.ProductionPlace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	var places []*model.ProductionPlace
+	for _, place := range values(r.Places, func(p *model.ProductionPlace) int { return p.ID }) {
+		if (includeDeleted || !place.Deleted()) && match(place) {
+			found := *place
+			places = append(places, &found)
+		}
+	}
+	return places, nil
+}
+
+// snapshot 复制当前的生产地，返回的函数用于工作单元回滚
+func (r *ProductionPlaceRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	places := cloneMap(r.Places)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Places = places
+	}
+}
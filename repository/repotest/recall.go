@@ -0,0 +1,236 @@
+package repotest
+
+import (
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.RecallRepository = (*RecallRepository)(nil)
+
+// RecallRepository 产品召回仓库的内存实现
+// ProductPlaces为生产信息ID到生产地ID的映射，SalePlaces为销售地联系方式，用于模拟关联查询
+type RecallRepository struct {
+	mu            sync.Mutex
+	nextID        int
+	Recalls       map[int]*model.Recall
+	Notices       map[int][]*model.RecallSalePlace // 召回ID -> 销售地处理状态
+	ProductPlaces map[int]int
+	SalePlaces    map[int]*model.SalePlace
+	Err           error
+}
+
+// NewRecallRepository 创建产品召回仓库，可传入初始数据
+func NewRecallRepository(recalls ...*model.Recall) *RecallRepository {
+	r := &RecallRepository{
+		Recalls:       make(map[int]*model.Recall),
+		Notices:       make(map[int][]*model.RecallSalePlace),
+		ProductPlaces: make(map[int]int),
+		SalePlaces:    make(map[int]*model.SalePlace),
+	}
+	for _, recall := range recalls {
+		saved := *recall
+		saved.ID = nextID(&r.nextID, saved.ID)
+		r.Recalls[saved.ID] = &saved
+	}
+	return r
+}
+
+// Save 保存召回
+func (r *RecallRepository) Save(recall *model.Recall) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := *recall
+	saved.ID = nextID(&r.nextID, saved.ID)
+	if saved.ProductInfoID > 0 {
+		saved.ProductPlaceID = r.ProductPlaces[saved.ProductInfoID]
+	}
+	r.Recalls[saved.ID] = &saved
+	return saved.ID, nil
+}
+
+// Close 结束召回
+func (r *RecallRepository) Close(id int, closeTime time.Time, remark string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if recall, ok := r.Recalls[id]; ok {
+		recall.Status = model.RecallStatusClosed
+		recall.CloseTime = &closeTime
+		recall.CloseRemark = remark
+	}
+	return nil
+}
+
+// GetByID 根据ID查询召回，不存在时返回nil
+func (r *RecallRepository) GetByID(id int) (*model.Recall, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	recall, ok := r.Recalls[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *recall
+	return &found, nil
+}
+
+// PageQuery 在数据权限范围内分页查询召回(按发起时间倒序)
+func (r *RecallRepository) PageQuery(dto *model.RecallPageQueryDTO, scope model.DataScope) ([]*model.Recall, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, 0, r.Err
+	}
+
+	var matched []*model.Recall
+	for _, recall := range values(r.Recalls, func(rc *model.Recall) int { return rc.ID }) {
+		if scope.ProductPlaceID != nil && !scope.AllowProductPlace(recall.ProductPlaceID) {
+			continue
+		}
+		if dto.ProductInfoID > 0 && recall.ProductInfoID != dto.ProductInfoID {
+			continue
+		}
+		if dto.ProductID > 0 && recall.ProductID != dto.ProductID {
+			continue
+		}
+		if dto.Status != "" && recall.Status != dto.Status {
+			continue
+		}
+		if contains(recall.BatchNo, dto.BatchNo) {
+			found := *recall
+			matched = append(matched, &found)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].OpenTime.After(matched[j].OpenTime) })
+	return paginate(matched, dto.Page, dto.Size), int64(len(matched)), nil
+}
+
+// FindOpen 查询涉及任一批次或该产品的进行中召回
+func (r *RecallRepository) FindOpen(productInfoIDs []int, productID int) ([]*model.Recall, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	ids := make(map[int]bool, len(productInfoIDs))
+	for _, id := range productInfoIDs {
+		ids[id] = true
+	}
+
+	recalls := []*model.Recall{}
+	for _, recall := range values(r.Recalls, func(rc *model.Recall) int { return rc.ID }) {
+		if recall.Status != model.RecallStatusOpen {
+			continue
+		}
+		if ids[recall.ProductInfoID] || (recall.ProductInfoID == 0 && recall.ProductID == productID) {
+			found := *recall
+			recalls = append(recalls, &found)
+		}
+	}
+	return recalls, nil
+}
+
+// FindSalePlaces 查询召回涉及的销售地及联系方式
+func (r *RecallRepository) FindSalePlaces(recallID int) ([]*model.RecallSalePlace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	salePlaces := []*model.RecallSalePlace{}
+	for _, notice := range r.Notices[recallID] {
+		found := *notice
+		if salePlace, ok := r.SalePlaces[found.SalePlaceID]; ok {
+			found.Address = salePlace.Address
+			found.Administrator = salePlace.Administrator
+			found.Phone = salePlace.Phone
+		}
+		salePlaces = append(salePlaces, &found)
+	}
+	sort.Slice(salePlaces, func(i, j int) bool { return salePlaces[i].SalePlaceID < salePlaces[j].SalePlaceID })
+	return salePlaces, nil
+}
+
+// AddSalePlaces 登记召回涉及的销售地(待通知)，已登记的销售地保持原状态
+func (r *RecallRepository) AddSalePlaces(recallID int, salePlaceIDs []int, updateTime time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	for _, salePlaceID := range salePlaceIDs {
+		if r.notice(recallID, salePlaceID) != nil {
+			continue
+		}
+		t := updateTime
+		r.Notices[recallID] = append(r.Notices[recallID], &model.RecallSalePlace{
+			RecallID:    recallID,
+			SalePlaceID: salePlaceID,
+			Status:      model.RecallSalePlacePending,
+			UpdateTime:  &t,
+		})
+	}
+	return nil
+}
+
+// UpdateSalePlace 更新销售地的召回处理状态
+func (r *RecallRepository) UpdateSalePlace(salePlace *model.RecallSalePlace) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if notice := r.notice(salePlace.RecallID, salePlace.SalePlaceID); notice != nil {
+		notice.Status = salePlace.Status
+		notice.Remark = salePlace.Remark
+		notice.UpdateTime = salePlace.UpdateTime
+	}
+	return nil
+}
+
+// notice 查找销售地的处理状态，调用方需持有锁
+func (r *RecallRepository) notice(recallID, salePlaceID int) *model.RecallSalePlace {
+	for _, notice := range r.Notices[recallID] {
+		if notice.SalePlaceID == salePlaceID {
+			return notice
+		}
+	}
+	return nil
+}
+
+// snapshot 复制当前的召回及销售地处理状态，返回的函数用于工作单元回滚
+func (r *RecallRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recalls := cloneMap(r.Recalls)
+	notices := make(map[int][]*model.RecallSalePlace, len(r.Notices))
+	for id, list := range r.Notices {
+		notices[id] = cloneSlice(list)
+	}
+	productPlaces := maps.Clone(r.ProductPlaces)
+	salePlaces := cloneMap(r.SalePlaces)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Recalls, r.Notices, r.ProductPlaces, r.SalePlaces = recalls, notices, productPlaces, salePlaces
+	}
+}
@@ -0,0 +1,75 @@
+package repotest
+
+import (
+	"fmt"
+	"sync"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.ReferenceRepository = (*ReferenceRepository)(nil)
+
+// ReferenceRepository 数据引用关系仓库的内存实现，引用关系与记录状态由测试预先写入
+type ReferenceRepository struct {
+	mu       sync.Mutex
+	Blocking map[string][]*model.BlockingReference // "实体:ID" -> 阻止删除的引用
+	Existing map[string]bool                       // "实体:ID" -> 是否已逻辑删除，不在其中的记录视为不存在
+	Err      error
+}
+
+// NewReferenceRepository 创建数据引用关系仓库
+func NewReferenceRepository() *ReferenceRepository {
+	return &ReferenceRepository{
+		Blocking: make(map[string][]*model.BlockingReference),
+		Existing: make(map[string]bool),
+	}
+}
+
+// referenceKey 引用关系的键
+func referenceKey(entity string, id int) string {
+	return fmt.Sprintf("%s:%d", entity, id)
+}
+
+// AddBlocking 添加阻止删除entity记录的引用
+func (r *ReferenceRepository) AddBlocking(entity string, id int, ref *model.BlockingReference) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := referenceKey(entity, id)
+	r.Blocking[key] = append(r.Blocking[key], ref)
+}
+
+// AddExisting 添加存在的记录，deleted表示已逻辑删除
+func (r *ReferenceRepository) AddExisting(entity string, id int, deleted bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Existing[referenceKey(entity, id)] = deleted
+}
+
+// FindBlocking 查询阻止删除的引用
+func (r *ReferenceRepository) FindBlocking(entity string, id int) ([]*model.BlockingReference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	refs := []*model.BlockingReference{}
+	for _, ref := range r.Blocking[referenceKey(entity, id)] {
+		saved := *ref
+		refs = append(refs, &saved)
+	}
+	return refs, nil
+}
+
+// Lookup 查询被引用的记录是否存在、是否已逻辑删除
+func (r *ReferenceRepository) Lookup(entity string, id int) (bool, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return false, false, r.Err
+	}
+
+	deleted, ok := r.Existing[referenceKey(entity, id)]
+	return ok, deleted, nil
+}
@@ -0,0 +1,82 @@
+// Package repotest 仓储接口的内存实现，用于服务层和控制器的单元测试
+//
+// 每个仓储的数据保存在内存中，ID从1开始自增；设置Err字段后所有方法都返回该错误，用于模拟数据库故障。
+// 关联表的展示字段(如产品名称、公司名称)不会自动填充，需要时由测试预先写入。
+package repotest
+
+import (
+	"sort"
+	"strings"
+)
+
+// paginate 按页码(从1开始)截取当前页数据
+func paginate[T any](items []T, page, pageSize int) []T {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		return nil
+	}
+	start := (page - 1) * pageSize
+	if start >= len(items) {
+		return nil
+	}
+	end := start + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+// contains 模拟SQL的LIKE '%keyword%'，关键字为空时匹配所有数据
+func contains(value, keyword string) bool {
+	return keyword == "" || strings.Contains(value, keyword)
+}
+
+// values 按ID升序返回map中的数据，调用方需持有锁
+func values[T any](items map[int]*T, id func(*T) int) []*T {
+	list := make([]*T, 0, len(items))
+	for _, item := range items {
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return id(list[i]) < id(list[j]) })
+	return list
+}
+
+// nextID 分配自增ID，指定了ID时同步推进计数器
+func nextID(counter *int, id int) int {
+	if id == 0 {
+		*counter++
+		return *counter
+	}
+	if id > *counter {
+		*counter = id
+	}
+	return id
+}
+
+// snapshotter 支持工作单元回滚的内存仓储
+type snapshotter interface {
+	// snapshot 复制当前数据，返回把数据恢复到复制时状态的函数
+	snapshot() func()
+}
+
+// cloneMap 逐条复制记录，事务中修改记录不影响副本
+func cloneMap[K comparable, T any](items map[K]*T) map[K]*T {
+	clone := make(map[K]*T, len(items))
+	for key, item := range items {
+		copied := *item
+		clone[key] = &copied
+	}
+	return clone
+}
+
+// cloneSlice 逐条复制记录
+func cloneSlice[T any](items []*T) []*T {
+	clone := make([]*T, len(items))
+	for i, item := range items {
+		copied := *item
+		clone[i] = &copied
+	}
+	return clone
+}
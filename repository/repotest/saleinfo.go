@@ -0,0 +1,195 @@
+package repotest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.SaleInfoRepository = (*SaleInfoRepository)(nil)
+
+// SaleInfoRepository 销售信息仓库的内存实现
+// Supplies为销售信息ID到上游来源的映射，用于模拟反向溯源的关联查询
+type SaleInfoRepository struct {
+	mu        sync.Mutex
+	nextID    int
+	SaleInfos map[int]*model.SaleInfoVO
+	Supplies  map[int]*model.SaleSupply
+	Err       error
+}
+
+// NewSaleInfoRepository 创建销售信息仓库，可传入初始数据(含产品名称、销售地等展示字段)
+func NewSaleInfoRepository(saleInfos ...*model.SaleInfoVO) *SaleInfoRepository {
+	r := &SaleInfoRepository{
+		SaleInfos: make(map[int]*model.SaleInfoVO),
+		Supplies:  make(map[int]*model.SaleSupply),
+	}
+	for _, saleInfo := range saleInfos {
+		saved := *saleInfo
+		saved.ID = nextID(&r.nextID, saved.ID)
+		r.SaleInfos[saved.ID] = &saved
+	}
+	return r
+}
+
+// Save 保存销售信息
+func (r *SaleInfoRepository) Save(saleInfo *model.SaleInfo) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := &model.SaleInfoVO{
+		ID:          nextID(&r.nextID, saleInfo.ID),
+		LogisticsID: saleInfo.LogisticsID,
+		SalePlaceID: saleInfo.SalePlaceID,
+		Description: saleInfo.Description,
+		SaleTime:    saleInfo.SaleTime,
+	}
+	r.SaleInfos[saved.ID] = saved
+	return saved.ID, nil
+}
+
+// Update 更新销售信息，保留已有的展示字段
+func (r *SaleInfoRepository) Update(saleInfo *model.SaleInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if existing, ok := r.SaleInfos[saleInfo.ID]; ok {
+		existing.LogisticsID = saleInfo.LogisticsID
+		existing.SalePlaceID = saleInfo.SalePlaceID
+		existing.Description = saleInfo.Description
+		existing.SaleTime = saleInfo.SaleTime
+	}
+	return nil
+}
+
+// Delete 删除销售信息
+func (r *SaleInfoRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	delete(r.SaleInfos, id)
+	return nil
+}
+
+// GetByID 根据ID查询销售信息，不存在时返回nil
+func (r *SaleInfoRepository) GetByID(id int) (*model.SaleInfoVO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	saleInfo, ok := r.SaleInfos[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *saleInfo
+	return &found, nil
+}
+
+// FindAll 查询数据权限范围内的所有销售信息
+func (r *SaleInfoRepository) FindAll(scope model.DataScope) ([]*model.SaleInfoVO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	var saleInfos []*model.SaleInfoVO
+	for _, saleInfo := range values(r.SaleInfos, func(s *model.SaleInfoVO) int { return s.ID }) {
+		if scope.AllowSalePlace(saleInfo.SalePlaceID) {
+			found := *saleInfo
+			saleInfos = append(saleInfos, &found)
+		}
+	}
+	return saleInfos, nil
+}
+
+// PageQuery 在数据权限范围内分页查询销售信息
+func (r *SaleInfoRepository) PageQuery(query *model.SaleInfoPageQuery, scope model.DataScope) ([]*model.SaleInfoVO, int64, error) {
+	saleInfos, err := r.FindAll(scope)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matched []*model.SaleInfoVO
+	for _, saleInfo := range saleInfos {
+		if query.SaleInfoID > 0 && saleInfo.ID != query.SaleInfoID {
+			continue
+		}
+		if !query.SaleTime.IsZero() &&
+			(saleInfo.SaleTime.Before(query.SaleTime) || saleInfo.SaleTime.After(query.SaleTime.Add(24*time.Hour))) {
+			continue
+		}
+		if contains(saleInfo.ProductName, query.ProductName) && contains(saleInfo.SalePlace, query.SalePlace) {
+			matched = append(matched, saleInfo)
+		}
+	}
+	return paginate(matched, query.Page, query.Size), int64(len(matched)), nil
+}
+
+// FindByLogisticsID 查询经该物流送达的销售信息(按销售时间排序)
+func (r *SaleInfoRepository) FindByLogisticsID(logisticsID int) ([]*model.SaleInfoVO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	saleInfos := []*model.SaleInfoVO{}
+	for _, saleInfo := range values(r.SaleInfos, func(s *model.SaleInfoVO) int { return s.ID }) {
+		if saleInfo.LogisticsID == logisticsID {
+			found := *saleInfo
+			saleInfos = append(saleInfos, &found)
+		}
+	}
+	sort.SliceStable(saleInfos, func(i, j int) bool { return saleInfos[i].SaleTime.Before(saleInfos[j].SaleTime) })
+	return saleInfos, nil
+}
+
+// FindSupplies 查询销售地在[start, end)内每条销售记录的来源，未登记来源的销售记录只含物流ID
+func (r *SaleInfoRepository) FindSupplies(salePlaceID int, start, end time.Time) ([]*model.SaleSupply, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	supplies := []*model.SaleSupply{}
+	for _, saleInfo := range values(r.SaleInfos, func(s *model.SaleInfoVO) int { return s.ID }) {
+		if saleInfo.SalePlaceID != salePlaceID || saleInfo.SaleTime.Before(start) || !saleInfo.SaleTime.Before(end) {
+			continue
+		}
+		supply := model.SaleSupply{SaleInfoID: saleInfo.ID, LogisticsID: saleInfo.LogisticsID}
+		if found, ok := r.Supplies[saleInfo.ID]; ok {
+			supply = *found
+		}
+		supplies = append(supplies, &supply)
+	}
+	return supplies, nil
+}
+
+// snapshot 复制当前的销售信息，返回的函数用于工作单元回滚
+func (r *SaleInfoRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saleInfos := cloneMap(r.SaleInfos)
+	supplies := cloneMap(r.Supplies)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.SaleInfos, r.Supplies = saleInfos, supplies
+	}
+}
@@ -0,0 +1,150 @@
+package repotest
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.SalePlaceRepository = (*SalePlaceRepository)(nil)
+
+// SalePlaceRepository 销售地仓库的内存实现
+type SalePlaceRepository struct {
+	mu         sync.Mutex
+	nextID     int
+	SalePlaces map[int]*model.SalePlace
+	Err        error
+}
+
+// NewSalePlaceRepository 创建销售地仓库，可传入初始数据
+func NewSalePlaceRepository(salePlaces ...*model.SalePlace) *SalePlaceRepository {
+	r := &SalePlaceRepository{SalePlaces: make(map[int]*model.SalePlace)}
+	for _, salePlace := range salePlaces {
+		r.Save(salePlace)
+	}
+	return r
+}
+
+// Save 保存销售地，ID为0时自动分配
+func (r *SalePlaceRepository) Save(salePlace *model.SalePlace) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := *salePlace
+	saved.ID = nextID(&r.nextID, saved.ID)
+	r.SalePlaces[saved.ID] = &saved
+	return saved.ID, nil
+}
+
+// Update 更新销售地
+func (r *SalePlaceRepository) Update(salePlace *model.SalePlace) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if _, ok := r.SalePlaces[salePlace.ID]; ok {
+		updated := *salePlace
+		r.SalePlaces[salePlace.ID] = &updated
+	}
+	return nil
+}
+
+// Delete 软删除销售地
+func (r *SalePlaceRepository) Delete(id int, deletedAt time.Time, deletedBy string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if salePlace, ok := r.SalePlaces[id]; ok && !salePlace.Deleted() {
+		salePlace.DeletedAt = &deletedAt
+		salePlace.DeletedBy = deletedBy
+	}
+	return nil
+}
+
+// Restore 恢复已删除的销售地
+func (r *SalePlaceRepository) Restore(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if salePlace, ok := r.SalePlaces[id]; ok {
+		salePlace.SoftDelete = model.SoftDelete{}
+	}
+	return nil
+}
+
+// GetByID 根据ID查询销售地(含已删除的销售地)，不存在时返回nil
+func (r *SalePlaceRepository) GetByID(id int) (*model.SalePlace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	salePlace, ok := r.SalePlaces[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *salePlace
+	return &found, nil
+}
+
+// FindAll 查询所有销售地，includeDeleted为false时不含已删除的销售地
+func (r *SalePlaceRepository) FindAll(includeDeleted bool) ([]*model.SalePlace, error) {
+	return r.find(func(*model.SalePlace) bool { return true }, includeDeleted)
+}
+
+// PageQuery 按ID(精确)、地址、负责人、电话(模糊)分页查询销售地
+func (r *SalePlaceRepository) PageQuery(page, pageSize int, id, address, administrator, phone string, includeDeleted bool) ([]*model.SalePlace, int64, error) {
+	salePlaces, err := r.find(func(s *model.SalePlace) bool {
+		return (id == "" || strconv.Itoa(s.ID) == id) && contains(s.Address, address) &&
+			contains(s.Administrator, administrator) && contains(s.Phone, phone)
+	}, includeDeleted)
+	if err != nil {
+		return nil, 0, err
+	}
+	return paginate(salePlaces, page, pageSize), int64(len(salePlaces)), nil
+}
+
+// find 按条件查询销售地
+func (r *SalePlaceRepository) find(match func(*model.SalePlace) bool, includeDeleted bool) ([]*model.SalePlace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	var salePlaces []*model.SalePlace
+	for _, salePlace := range values(r.SalePlaces, func(s *model.SalePlace) int { return s.ID }) {
+		if (includeDeleted || !salePlace.Deleted()) && match(salePlace) {
+			found := *salePlace
+			salePlaces = append(salePlaces, &found)
+		}
+	}
+	return salePlaces, nil
+}
+
+// snapshot 复制当前的销售地，返回的函数用于工作单元回滚
+func (r *SalePlaceRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	salePlaces := cloneMap(r.SalePlaces)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.SalePlaces = salePlaces
+	}
+}
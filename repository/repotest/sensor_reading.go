@@ -0,0 +1,165 @@
+package repotest
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.SensorReadingRepository = (*SensorReadingRepository)(nil)
+
+// SensorReadingRepository 冷链读数仓库的内存实现
+type SensorReadingRepository struct {
+	mu       sync.Mutex
+	nextID   int64
+	Readings []*model.SensorReading
+	Err      error
+}
+
+// NewSensorReadingRepository 创建冷链读数仓库，可传入初始数据
+func NewSensorReadingRepository(readings ...*model.SensorReading) *SensorReadingRepository {
+	r := &SensorReadingRepository{}
+	r.SaveBatch(readings)
+	return r
+}
+
+// SaveBatch 批量保存读数，出错时不保存任何读数
+func (r *SensorReadingRepository) SaveBatch(readings []*model.SensorReading) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	for _, reading := range readings {
+		saved := *reading
+		r.nextID++
+		saved.ID = r.nextID
+		r.Readings = append(r.Readings, &saved)
+	}
+	return nil
+}
+
+// Summarize 统计物流记录的读数(不含分桶序列)，没有读数时返回nil
+func (r *SensorReadingRepository) Summarize(logisticsID int) (*model.ColdChainSummary, error) {
+	readings, err := r.find(logisticsID)
+	if err != nil || len(readings) == 0 {
+		return nil, err
+	}
+
+	summary := &model.ColdChainSummary{
+		Count:     len(readings),
+		FirstTime: readings[0].ReadingTime,
+		LastTime:  readings[len(readings)-1].ReadingTime,
+	}
+	for _, reading := range readings {
+		if reading.Excursion {
+			summary.Excursions++
+		}
+	}
+	summary.Temperature, summary.Humidity = readingStats(readings)
+	return summary, nil
+}
+
+// Series 按时间分桶汇总读数，starts为各桶的起始时间(升序)
+// 早于第二个桶的读数归入第一个桶，不早于最后一个起始时间的读数都归入最后一个桶；只返回有读数的桶
+func (r *SensorReadingRepository) Series(logisticsID int, starts []time.Time) ([]*model.ColdChainPoint, error) {
+	readings, err := r.find(logisticsID)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([][]*model.SensorReading, len(starts))
+	for _, reading := range readings {
+		index := sort.Search(len(starts), func(i int) bool { return starts[i].After(reading.ReadingTime) }) - 1
+		if index < 0 {
+			index = 0
+		}
+		buckets[index] = append(buckets[index], reading)
+	}
+
+	series := []*model.ColdChainPoint{}
+	for i, bucket := range buckets {
+		if len(bucket) == 0 {
+			continue
+		}
+		temperature, humidity := readingStats(bucket)
+		point := &model.ColdChainPoint{
+			Time:           starts[i],
+			Count:          len(bucket),
+			MinTemperature: temperature.Min,
+			MaxTemperature: temperature.Max,
+			AvgTemperature: temperature.Avg,
+		}
+		if humidity != nil {
+			point.AvgHumidity = &humidity.Avg
+		}
+		for _, reading := range bucket {
+			point.Excursion = point.Excursion || reading.Excursion
+		}
+		series = append(series, point)
+	}
+	return series, nil
+}
+
+// find 查询物流记录的所有读数(按采集时间排序)
+func (r *SensorReadingRepository) find(logisticsID int) ([]*model.SensorReading, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	readings := []*model.SensorReading{}
+	for _, reading := range r.Readings {
+		if reading.LogisticsID == logisticsID {
+			readings = append(readings, reading)
+		}
+	}
+	sort.SliceStable(readings, func(i, j int) bool { return readings[i].ReadingTime.Before(readings[j].ReadingTime) })
+	return readings, nil
+}
+
+// readingStats 统计读数的温度与湿度，没有湿度读数时湿度为nil
+func readingStats(readings []*model.SensorReading) (temperature, humidity *model.ColdChainStat) {
+	var temperatures, humidities []float64
+	for _, reading := range readings {
+		temperatures = append(temperatures, reading.Temperature)
+		if reading.Humidity != nil {
+			humidities = append(humidities, *reading.Humidity)
+		}
+	}
+	return stat(temperatures), stat(humidities)
+}
+
+// stat 计算最小、最大、平均值，没有数据时返回nil
+func stat(values []float64) *model.ColdChainStat {
+	if len(values) == 0 {
+		return nil
+	}
+	result := &model.ColdChainStat{Min: values[0], Max: values[0]}
+	sum := 0.0
+	for _, v := range values {
+		result.Min = math.Min(result.Min, v)
+		result.Max = math.Max(result.Max, v)
+		sum += v
+	}
+	result.Avg = sum / float64(len(values))
+	return result
+}
+
+// snapshot 复制当前的传感器读数，返回的函数用于工作单元回滚
+func (r *SensorReadingRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	readings := cloneSlice(r.Readings)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Readings = readings
+	}
+}
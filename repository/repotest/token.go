@@ -0,0 +1,149 @@
+package repotest
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.TokenRepository = (*TokenRepository)(nil)
+
+// TokenRepository 令牌仓库的内存实现，令牌版本从关联的用户仓库读取
+type TokenRepository struct {
+	mu            sync.Mutex
+	nextID        int
+	Users         *UserRepository
+	RefreshTokens map[int]*model.RefreshToken
+	RevokedJTIs   map[string]time.Time // 访问令牌ID -> 原过期时间
+	Err           error
+}
+
+// NewTokenRepository 创建令牌仓库
+func NewTokenRepository(users *UserRepository) *TokenRepository {
+	return &TokenRepository{
+		Users:         users,
+		RefreshTokens: make(map[int]*model.RefreshToken),
+		RevokedJTIs:   make(map[string]time.Time),
+	}
+}
+
+// SaveRefreshToken 保存刷新令牌
+func (r *TokenRepository) SaveRefreshToken(token *model.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	saved := *token
+	saved.ID = nextID(&r.nextID, saved.ID)
+	r.RefreshTokens[saved.ID] = &saved
+	return nil
+}
+
+// FindRefreshToken 根据哈希值查询刷新令牌，不存在时返回nil
+func (r *TokenRepository) FindRefreshToken(tokenHash string) (*model.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	for _, token := range r.RefreshTokens {
+		if token.TokenHash == tokenHash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+// RevokeRefreshToken 吊销刷新令牌，返回是否由本次调用吊销
+func (r *TokenRepository) RevokeRefreshToken(id int, revokedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return false, r.Err
+	}
+
+	token, ok := r.RefreshTokens[id]
+	if !ok || token.RevokedAt != nil {
+		return false, nil
+	}
+	token.RevokedAt = &revokedAt
+	return true, nil
+}
+
+// RevokeRefreshTokenByAccessJTI 吊销与访问令牌配对的刷新令牌
+func (r *TokenRepository) RevokeRefreshTokenByAccessJTI(accessJTI string, revokedAt time.Time) error {
+	return r.revokeRefreshTokens(func(token *model.RefreshToken) bool { return token.AccessJTI == accessJTI }, revokedAt)
+}
+
+// RevokeUserRefreshTokens 吊销用户的所有刷新令牌
+func (r *TokenRepository) RevokeUserRefreshTokens(userID int, revokedAt time.Time) error {
+	return r.revokeRefreshTokens(func(token *model.RefreshToken) bool { return token.UserID == userID }, revokedAt)
+}
+
+// RevokeAccessToken 将访问令牌加入吊销列表
+func (r *TokenRepository) RevokeAccessToken(jti string, userID int, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if _, ok := r.RevokedJTIs[jti]; !ok {
+		r.RevokedJTIs[jti] = expiresAt
+	}
+	return nil
+}
+
+// AccessTokenState 查询访问令牌是否已吊销，以及用户当前的令牌版本
+func (r *TokenRepository) AccessTokenState(jti string, userID int) (revoked bool, tokenVersion int, exists bool, err error) {
+	r.mu.Lock()
+	if r.Err != nil {
+		r.mu.Unlock()
+		return false, 0, false, r.Err
+	}
+	_, revoked = r.RevokedJTIs[jti]
+	r.mu.Unlock()
+
+	tokenVersion, exists = r.Users.tokenVersion(userID)
+	if !exists {
+		return false, 0, false, nil
+	}
+	return revoked, tokenVersion, true, nil
+}
+
+// revokeRefreshTokens 吊销所有满足条件且未吊销的刷新令牌
+func (r *TokenRepository) revokeRefreshTokens(match func(*model.RefreshToken) bool, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	for _, token := range r.RefreshTokens {
+		if token.RevokedAt == nil && match(token) {
+			at := revokedAt
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+// snapshot 复制当前的刷新令牌和吊销记录，返回的函数用于工作单元回滚
+func (r *TokenRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refreshTokens := cloneMap(r.RefreshTokens)
+	revokedJTIs := maps.Clone(r.RevokedJTIs)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.RefreshTokens, r.RevokedJTIs = refreshTokens, revokedJTIs
+	}
+}
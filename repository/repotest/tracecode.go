@@ -0,0 +1,140 @@
+package repotest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.TraceCodeRepository = (*TraceCodeRepository)(nil)
+
+// TraceCodeRepository 溯源码仓库的内存实现
+type TraceCodeRepository struct {
+	mu         sync.Mutex
+	nextID     int
+	TraceCodes map[int]*model.TraceCode
+	Err        error
+}
+
+// NewTraceCodeRepository 创建溯源码仓库，可传入初始数据
+func NewTraceCodeRepository(traceCodes ...*model.TraceCode) *TraceCodeRepository {
+	r := &TraceCodeRepository{TraceCodes: make(map[int]*model.TraceCode)}
+	for _, traceCode := range traceCodes {
+		r.Save(traceCode)
+	}
+	return r
+}
+
+// Save 保存溯源码
+func (r *TraceCodeRepository) Save(traceCode *model.TraceCode) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	saved := *traceCode
+	saved.ID = nextID(&r.nextID, saved.ID)
+	r.TraceCodes[saved.ID] = &saved
+	return saved.ID, nil
+}
+
+// Revoke 作废溯源码
+func (r *TraceCodeRepository) Revoke(id int, reason string, revokeTime time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if traceCode, ok := r.TraceCodes[id]; ok {
+		traceCode.Revoked = true
+		traceCode.RevokeTime = &revokeTime
+		traceCode.RevokeReason = reason
+	}
+	return nil
+}
+
+// GetByID 根据ID获取溯源码，不存在时返回nil
+func (r *TraceCodeRepository) GetByID(id int) (*model.TraceCode, error) {
+	return r.first(func(tc *model.TraceCode) bool { return tc.ID == id })
+}
+
+// GetByCode 根据公开溯源码获取，不存在时返回nil
+func (r *TraceCodeRepository) GetByCode(code string) (*model.TraceCode, error) {
+	return r.first(func(tc *model.TraceCode) bool { return tc.Code == code })
+}
+
+// FindActive 查找销售信息或生产批次(未关联销售信息)最新的有效溯源码
+func (r *TraceCodeRepository) FindActive(saleInfoID, productInfoID int) (*model.TraceCode, error) {
+	traceCodes, err := r.find(func(tc *model.TraceCode) bool {
+		if tc.Revoked {
+			return false
+		}
+		if saleInfoID > 0 {
+			return tc.SaleInfoID == saleInfoID
+		}
+		return tc.ProductInfoID == productInfoID && tc.SaleInfoID == 0
+	})
+	if err != nil || len(traceCodes) == 0 {
+		return nil, err
+	}
+	return traceCodes[0], nil
+}
+
+// PageQuery 分页查询溯源码(按ID倒序)
+func (r *TraceCodeRepository) PageQuery(query *model.TraceCodePageQuery) ([]*model.TraceCode, int64, error) {
+	traceCodes, err := r.find(func(tc *model.TraceCode) bool {
+		return contains(tc.Code, query.Code) &&
+			(query.SaleInfoID <= 0 || tc.SaleInfoID == query.SaleInfoID) &&
+			(query.ProductInfoID <= 0 || tc.ProductInfoID == query.ProductInfoID) &&
+			(query.Revoked == nil || tc.Revoked == *query.Revoked)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return paginate(traceCodes, query.Page, query.Size), int64(len(traceCodes)), nil
+}
+
+// first 查询第一个满足条件的溯源码
+func (r *TraceCodeRepository) first(match func(*model.TraceCode) bool) (*model.TraceCode, error) {
+	traceCodes, err := r.find(match)
+	if err != nil || len(traceCodes) == 0 {
+		return nil, err
+	}
+	return traceCodes[0], nil
+}
+
+// find 按条件查询溯源码(按ID倒序)
+func (r *TraceCodeRepository) find(match func(*model.TraceCode) bool) ([]*model.TraceCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	var traceCodes []*model.TraceCode
+	for _, traceCode := range values(r.TraceCodes, func(tc *model.TraceCode) int { return tc.ID }) {
+		if match(traceCode) {
+			found := *traceCode
+			traceCodes = append(traceCodes, &found)
+		}
+	}
+	sort.SliceStable(traceCodes, func(i, j int) bool { return traceCodes[i].ID > traceCodes[j].ID })
+	return traceCodes, nil
+}
+
+// snapshot 复制当前的溯源码，返回的函数用于工作单元回滚
+func (r *TraceCodeRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	traceCodes := cloneMap(r.TraceCodes)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.TraceCodes = traceCodes
+	}
+}
@@ -0,0 +1,57 @@
+package repotest
+
+import (
+	"sync"
+
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.UnitOfWork = (*UnitOfWork)(nil)
+
+// UnitOfWork 工作单元的内存实现
+// 事务之间串行执行；fn返回错误时把Repos中的内存仓储恢复到事务开始前的数据，与数据库一样不回退自增ID
+type UnitOfWork struct {
+	mu    sync.Mutex
+	Repos *repository.Repositories
+	Err   error // 模拟开启或提交事务失败
+}
+
+// NewUnitOfWork 创建工作单元
+func NewUnitOfWork(repos *repository.Repositories) *UnitOfWork {
+	return &UnitOfWork{Repos: repos}
+}
+
+// Do 执行fn，fn返回错误时丢弃其中的写入
+func (u *UnitOfWork) Do(fn func(repos *repository.Repositories) error) error {
+	if u.Err != nil {
+		return u.Err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	restores := u.snapshot()
+	if err := fn(u.Repos); err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		return err
+	}
+	return nil
+}
+
+// snapshot 复制Repos中所有内存仓储的数据
+func (u *UnitOfWork) snapshot() []func() {
+	r := u.Repos
+	repos := []any{
+		r.Company, r.Product, r.ProductionPlace, r.SalePlace, r.Production, r.Batch, r.FarmingActivity,
+		r.Inspection, r.Recall, r.Logistics, r.LogisticsEvent, r.SensorReading, r.SaleInfo, r.TraceCode,
+		r.User, r.Token, r.Audit,
+	}
+	restores := make([]func(), 0, len(repos))
+	for _, repo := range repos {
+		if s, ok := repo.(snapshotter); ok {
+			restores = append(restores, s.snapshot())
+		}
+	}
+	return restores
+}
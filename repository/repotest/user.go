@@ -0,0 +1,198 @@
+package repotest
+
+import (
+	"sync"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var _ repository.UserRepository = (*UserRepository)(nil)
+
+// UserRepository 用户仓库的内存实现
+type UserRepository struct {
+	mu     sync.Mutex
+	nextID int
+	Users  map[int]*model.User
+	Err    error
+}
+
+// NewUserRepository 创建用户仓库，可传入初始数据
+func NewUserRepository(users ...*model.User) *UserRepository {
+	r := &UserRepository{Users: make(map[int]*model.User)}
+	for _, user := range users {
+		saved := *user
+		saved.ID = nextID(&r.nextID, saved.ID)
+		r.Users[saved.ID] = &saved
+	}
+	return r
+}
+
+// FindByUsername 根据用户名查询用户，不存在时返回nil
+func (r *UserRepository) FindByUsername(username string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	for _, user := range r.Users {
+		if user.Username == username {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+// Save 保存用户
+func (r *UserRepository) Save(username, password, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	id := nextID(&r.nextID, 0)
+	r.Users[id] = &model.User{ID: id, Username: username, Password: password, Role: role}
+	return nil
+}
+
+// Update 更新用户基本信息，空值字段保持不变
+func (r *UserRepository) Update(user *model.User) error {
+	return r.update(user.ID, func(existing *model.User) {
+		if user.Username != "" {
+			existing.Username = user.Username
+		}
+		if user.Sex.Valid {
+			existing.Sex = user.Sex
+		}
+		if user.Name.Valid {
+			existing.Name = user.Name
+		}
+		if user.Phone.Valid {
+			existing.Phone = user.Phone
+		}
+	})
+}
+
+// UpdatePassword 更新密码
+func (r *UserRepository) UpdatePassword(userID int, newPassword string) error {
+	return r.update(userID, func(existing *model.User) { existing.Password = newPassword })
+}
+
+// GetByID 根据ID查询用户，不存在时返回nil
+func (r *UserRepository) GetByID(id int) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	user, ok := r.Users[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *user
+	return &found, nil
+}
+
+// UpdateRole 更新用户角色
+func (r *UserRepository) UpdateRole(userID int, role string) error {
+	return r.update(userID, func(existing *model.User) { existing.Role = role })
+}
+
+// UpdateBinding 更新用户绑定的公司、生产地、销售地
+func (r *UserRepository) UpdateBinding(userID, companyID, productPlaceID, salePlaceID int) error {
+	return r.update(userID, func(existing *model.User) {
+		existing.CompanyID = companyID
+		existing.ProductPlaceID = productPlaceID
+		existing.SalePlaceID = salePlaceID
+	})
+}
+
+// IncrementTokenVersion 递增令牌版本
+func (r *UserRepository) IncrementTokenVersion(userID int) error {
+	return r.update(userID, func(existing *model.User) { existing.TokenVersion++ })
+}
+
+// Count 用户总数
+func (r *UserRepository) Count() (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+	return int64(len(r.Users)), nil
+}
+
+// CountByRole 指定角色的用户数
+func (r *UserRepository) CountByRole(role string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+	var total int64
+	for _, user := range r.Users {
+		if user.Role == role {
+			total++
+		}
+	}
+	return total, nil
+}
+
+// FindAll 查询所有用户(不含密码)
+func (r *UserRepository) FindAll() ([]*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	var users []*model.User
+	for _, user := range values(r.Users, func(u *model.User) int { return u.ID }) {
+		found := *user
+		found.Password = ""
+		users = append(users, &found)
+	}
+	return users, nil
+}
+
+// tokenVersion 查询用户的令牌版本，供TokenRepository使用
+func (r *UserRepository) tokenVersion(userID int) (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.Users[userID]
+	if !ok {
+		return 0, false
+	}
+	return user.TokenVersion, true
+}
+
+// update 修改指定用户，用户不存在时不做任何操作
+func (r *UserRepository) update(userID int, apply func(*model.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+
+	if existing, ok := r.Users[userID]; ok {
+		apply(existing)
+	}
+	return nil
+}
+
+// snapshot 复制当前的用户，返回的函数用于工作单元回滚
+func (r *UserRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users := cloneMap(r.Users)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Users = users
+	}
+}
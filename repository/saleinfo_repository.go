@@ -10,63 +10,88 @@ import (
 	"agricultural_product_gin/model"
 )
 
-// SaleInfoRepository 销售信息数据仓库
-type SaleInfoRepository struct {
-	DB *sql.DB
+// SaleInfoRepository 销售信息数据仓库接口
+type SaleInfoRepository interface {
+	Save(saleInfo *model.SaleInfo) (int, error)
+	Update(saleInfo *model.SaleInfo) error
+	Delete(id int) error
+	GetByID(id int) (*model.SaleInfoVO, error)
+	FindAll(scope model.DataScope) ([]*model.SaleInfoVO, error)
+	PageQuery(query *model.SaleInfoPageQuery, scope model.DataScope) ([]*model.SaleInfoVO, int64, error)
+	FindByLogisticsID(logisticsID int) ([]*model.SaleInfoVO, error)
+	FindSupplies(salePlaceID int, start, end time.Time) ([]*model.SaleSupply, error)
+}
+
+// SaleInfoRepositoryImpl 销售信息数据仓库的数据库实现
+type SaleInfoRepositoryImpl struct {
+	DB *DB
 }
 
 // NewSaleInfoRepository 创建销售信息仓库
-func NewSaleInfoRepository(db *sql.DB) *SaleInfoRepository {
-	return &SaleInfoRepository{DB: db}
+func NewSaleInfoRepository(db *DB) SaleInfoRepository {
+	return &SaleInfoRepositoryImpl{DB: db}
 }
 
-// Save 保存销售信息
-func (r *SaleInfoRepository) Save(saleInfo *model.SaleInfo) (int, error) {
+// Save 保存销售信息，并在同一事务中追加到哈希链
+func (r *SaleInfoRepositoryImpl) Save(saleInfo *model.SaleInfo) (int, error) {
 	query := "INSERT INTO sale_info(logistics_id, sale_place_id, si_description, sale_time) VALUES(?, ?, ?, ?)"
-	result, err := r.DB.Exec(query, saleInfo.LogisticsID, saleInfo.SalePlaceID, saleInfo.Description, saleInfo.SaleTime)
-	if err != nil {
-		log.Println("保存销售信息失败:", err)
-		return 0, err
-	}
 
-	id, err := result.LastInsertId()
+	var id int64
+	err := r.DB.Transaction(func(tx *DB) error {
+		result, err := tx.Exec(query, saleInfo.LogisticsID, saleInfo.SalePlaceID, saleInfo.Description, saleInfo.SaleTime)
+		if err != nil {
+			log.Println("保存销售信息失败:", err)
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			log.Println("获取销售信息ID失败:", err)
+			return err
+		}
+
+		return appendHashChain(tx, model.HashChainSaleInfo, int(id), model.HashChainCreate)
+	})
 	if err != nil {
-		log.Println("获取销售信息ID失败:", err)
 		return 0, err
 	}
 
 	return int(id), nil
 }
 
-// Update 更新销售信息
-func (r *SaleInfoRepository) Update(saleInfo *model.SaleInfo) error {
+// Update 更新销售信息，并在同一事务中追加到哈希链
+func (r *SaleInfoRepositoryImpl) Update(saleInfo *model.SaleInfo) error {
 	query := "UPDATE sale_info SET logistics_id = ?, sale_place_id = ?, si_description = ?, sale_time = ? WHERE si_id = ?"
-	_, err := r.DB.Exec(query, saleInfo.LogisticsID, saleInfo.SalePlaceID, saleInfo.Description, saleInfo.SaleTime, saleInfo.ID)
-	if err != nil {
-		log.Println("更新销售信息失败:", err)
-		return err
-	}
-	return nil
+	return r.DB.Transaction(func(tx *DB) error {
+		_, err := tx.Exec(query, saleInfo.LogisticsID, saleInfo.SalePlaceID, saleInfo.Description, saleInfo.SaleTime, saleInfo.ID)
+		if err != nil {
+			log.Println("更新销售信息失败:", err)
+			return err
+		}
+		return appendHashChain(tx, model.HashChainSaleInfo, saleInfo.ID, model.HashChainUpdate)
+	})
 }
 
-// Delete 删除销售信息
-func (r *SaleInfoRepository) Delete(id int) error {
+// Delete 删除销售信息，并在同一事务中追加到哈希链
+func (r *SaleInfoRepositoryImpl) Delete(id int) error {
 	query := "DELETE FROM sale_info WHERE si_id = ?"
-	_, err := r.DB.Exec(query, id)
-	if err != nil {
-		log.Println("删除销售信息失败:", err)
-		return err
-	}
-	return nil
+	return r.DB.Transaction(func(tx *DB) error {
+		result, err := tx.Exec(query, id)
+		if err != nil {
+			log.Println("删除销售信息失败:", err)
+			return err
+		}
+		return appendHashChainDelete(tx, result, model.HashChainSaleInfo, id)
+	})
 }
 
 // GetByID 根据ID获取销售信息
-func (r *SaleInfoRepository) GetByID(id int) (*model.SaleInfoVO, error) {
+func (r *SaleInfoRepositoryImpl) GetByID(id int) (*model.SaleInfoVO, error) {
 	query := `
         SELECT 
             si.si_id, si.logistics_id, si.sale_place_id, si.si_description, si.sale_time,
-            pd.pd_name, sp.sp_address, sp.sp_administrator,
-            log.start_location, log.destination
+            COALESCE(pd.pd_name, ''), COALESCE(sp.sp_address, ''), COALESCE(sp.sp_administrator, ''),
+            COALESCE(log.start_location, ''), COALESCE(log.destination, '')
         FROM sale_info si
         LEFT JOIN sale_place sp ON sp.sp_id = si.sale_place_id
         LEFT JOIN logistics log ON log.log_id = si.logistics_id
@@ -94,8 +119,8 @@ func (r *SaleInfoRepository) GetByID(id int) (*model.SaleInfoVO, error) {
 	return saleInfo, nil
 }
 
-// FindAll 查找所有销售信息
-func (r *SaleInfoRepository) FindAll() ([]*model.SaleInfoVO, error) {
+// FindAll 查找数据权限范围内的所有销售信息
+func (r *SaleInfoRepositoryImpl) FindAll(scope model.DataScope) ([]*model.SaleInfoVO, error) {
 	query := `
         SELECT 
             si.si_id, si.logistics_id, si.sale_place_id, si.si_description, si.sale_time,
@@ -107,7 +132,12 @@ func (r *SaleInfoRepository) FindAll() ([]*model.SaleInfoVO, error) {
         LEFT JOIN product_info pi ON pi.pi_id = log.product_info_id
         LEFT JOIN product pd ON pd.pd_id = pi.product_id`
 
-	rows, err := r.DB.Query(query)
+	conditions, args := scopeConditions(scope, saleInfoScopeColumns)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := r.DB.Query(query, args...)
 	if err != nil {
 		log.Println("查询销售信息失败:", err)
 		return nil, err
@@ -132,11 +162,10 @@ func (r *SaleInfoRepository) FindAll() ([]*model.SaleInfoVO, error) {
 	return saleInfos, nil
 }
 
-// PageQuery 分页查询销售信息
-func (r *SaleInfoRepository) PageQuery(query *model.SaleInfoPageQuery) ([]*model.SaleInfoVO, int64, error) {
+// PageQuery 在数据权限范围内分页查询销售信息
+func (r *SaleInfoRepositoryImpl) PageQuery(query *model.SaleInfoPageQuery, scope model.DataScope) ([]*model.SaleInfoVO, int64, error) {
 	// 构建查询条件
-	conditions := []string{}
-	args := []interface{}{}
+	conditions, args := scopeConditions(scope, saleInfoScopeColumns)
 
 	if query.SaleInfoID > 0 {
 		conditions = append(conditions, "si.si_id = ?")
@@ -224,3 +253,89 @@ func (r *SaleInfoRepository) PageQuery(query *model.SaleInfoPageQuery) ([]*model
 
 	return saleInfos, total, nil
 }
+
+// FindByLogisticsID 查询经该物流送达的销售信息(按销售时间排序)
+func (r *SaleInfoRepositoryImpl) FindByLogisticsID(logisticsID int) ([]*model.SaleInfoVO, error) {
+	query := `
+        SELECT 
+            si.si_id, si.logistics_id, si.sale_place_id, si.si_description, si.sale_time,
+            COALESCE(pd.pd_name, ''), COALESCE(sp.sp_address, ''), COALESCE(sp.sp_administrator, ''),
+            COALESCE(log.start_location, ''), COALESCE(log.destination, '')
+        FROM sale_info si
+        LEFT JOIN sale_place sp ON sp.sp_id = si.sale_place_id
+        LEFT JOIN logistics log ON log.log_id = si.logistics_id
+        LEFT JOIN product_info pi ON pi.pi_id = log.product_info_id
+        LEFT JOIN product pd ON pd.pd_id = pi.product_id
+        WHERE si.logistics_id = ?
+        ORDER BY si.sale_time, si.si_id`
+
+	rows, err := r.DB.Query(query, logisticsID)
+	if err != nil {
+		log.Println("查询物流的销售信息失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	saleInfos := []*model.SaleInfoVO{}
+	for rows.Next() {
+		saleInfo := &model.SaleInfoVO{}
+		err := rows.Scan(
+			&saleInfo.ID, &saleInfo.LogisticsID, &saleInfo.SalePlaceID, &saleInfo.Description, &saleInfo.SaleTime,
+			&saleInfo.ProductName, &saleInfo.SalePlace, &saleInfo.Administrator,
+			&saleInfo.StartLocation, &saleInfo.Destination,
+		)
+		if err != nil {
+			log.Println("读取销售信息数据失败:", err)
+			return nil, err
+		}
+		saleInfos = append(saleInfos, saleInfo)
+	}
+
+	return saleInfos, rows.Err()
+}
+
+// FindSupplies 沿 销售 -> 物流 -> 生产 反向查询销售地在[start, end)内每条销售记录的来源
+func (r *SaleInfoRepositoryImpl) FindSupplies(salePlaceID int, start, end time.Time) ([]*model.SaleSupply, error) {
+	query := `
+        SELECT 
+            si.si_id, COALESCE(log.log_id, 0), COALESCE(log.company_id, 0),
+            COALESCE(com.com_name, ''), COALESCE(com.com_phone, ''),
+            COALESCE(pi.pi_id, 0), COALESCE(pi.product_place_id, 0),
+            COALESCE(pp.pp_address, ''), COALESCE(pp.pp_administrator, ''), COALESCE(pp.pp_phone, ''),
+            COALESCE(pi.product_id, 0), COALESCE(pd.pd_name, ''), COALESCE(pd.type, '')
+        FROM sale_info si
+        LEFT JOIN logistics log ON log.log_id = si.logistics_id
+        LEFT JOIN company com ON com.com_id = log.company_id
+        LEFT JOIN product_info pi ON pi.pi_id = log.product_info_id
+        LEFT JOIN product_place pp ON pp.pp_id = pi.product_place_id
+        LEFT JOIN product pd ON pd.pd_id = pi.product_id
+        WHERE si.sale_place_id = ? AND si.sale_time >= ? AND si.sale_time < ?
+        ORDER BY si.sale_time, si.si_id`
+
+	rows, err := r.DB.Query(query, salePlaceID, start, end)
+	if err != nil {
+		log.Println("反向查询销售来源失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	supplies := []*model.SaleSupply{}
+	for rows.Next() {
+		supply := &model.SaleSupply{}
+		err := rows.Scan(
+			&supply.SaleInfoID, &supply.LogisticsID, &supply.CompanyID, &supply.CompanyName, &supply.CompanyPhone,
+			&supply.ProductInfoID, &supply.ProductPlaceID, &supply.PPAddress, &supply.PPAdmin, &supply.PPPhone,
+			&supply.ProductID, &supply.ProductName, &supply.ProductType,
+		)
+		if err != nil {
+			log.Println("读取销售来源失败:", err)
+			return nil, err
+		}
+		supplies = append(supplies, supply)
+	}
+
+	return supplies, rows.Err()
+}
+
+// saleInfoScopeColumns 销售信息的数据权限字段
+var saleInfoScopeColumns = scopeColumns{SalePlace: "si.sale_place_id"}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+func TestSaleInfoRepository_CRUD(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewSaleInfoRepository(db)
+
+	got, err := repo.GetByID(f.SaleInfos[1])
+	mustNoError(t, err)
+	if got == nil || got.ProductName != "白菜" || got.SalePlace != "超市1" || got.Administrator != "店长1" ||
+		got.StartLocation != "农场1" || got.Destination != "超市1" || !got.SaleTime.Equal(testTime.Add(24*time.Hour)) {
+		t.Fatalf("GetByID = %+v", got)
+	}
+
+	id, err := repo.Save(&model.SaleInfo{
+		LogisticsID: f.Logistics[0], SalePlaceID: f.SalePlaces[1], Description: "补货", SaleTime: testTime.Add(48 * time.Hour),
+	})
+	mustNoError(t, err)
+
+	mustNoError(t, repo.Update(&model.SaleInfo{
+		ID: id, LogisticsID: f.Logistics[0], SalePlaceID: f.SalePlaces[0], Description: "改为超市0", SaleTime: testTime.Add(48 * time.Hour),
+	}))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if got.SalePlaceID != f.SalePlaces[0] || got.Description != "改为超市0" {
+		t.Fatalf("Update后 = %+v", got)
+	}
+
+	sales, err := repo.FindByLogisticsID(f.Logistics[0])
+	mustNoError(t, err)
+	assertIDs(t, sales, func(s *model.SaleInfoVO) int { return s.ID }, []int{f.SaleInfos[0], id})
+
+	if _, err := repo.Save(&model.SaleInfo{LogisticsID: 999, SalePlaceID: f.SalePlaces[0], SaleTime: testTime}); !errors.Is(err, ErrReferenceNotFound) {
+		t.Fatalf("关联不存在的物流 err = %v, 期望ErrReferenceNotFound", err)
+	}
+
+	mustNoError(t, repo.Delete(id))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if got != nil {
+		t.Fatalf("Delete后 = %+v", got)
+	}
+}
+
+func TestSaleInfoRepository_PageQuery(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewSaleInfoRepository(db)
+	unbound := 0
+
+	tests := []struct {
+		name      string
+		query     model.SaleInfoPageQuery
+		scope     model.DataScope
+		wantTotal int64
+		wantIDs   []int
+	}{
+		{name: "不限范围", wantTotal: 2, wantIDs: f.SaleInfos[:]},
+		{name: "按ID", query: model.SaleInfoPageQuery{SaleInfoID: f.SaleInfos[1]}, wantTotal: 1, wantIDs: []int{f.SaleInfos[1]}},
+		{name: "按产品名称", query: model.SaleInfoPageQuery{ProductName: "苹果"}, wantTotal: 1, wantIDs: []int{f.SaleInfos[0]}},
+		{name: "按销售地", query: model.SaleInfoPageQuery{SalePlace: "超市1"}, wantTotal: 1, wantIDs: []int{f.SaleInfos[1]}},
+		{name: "按销售日期", query: model.SaleInfoPageQuery{SaleTime: testTime.Add(24 * time.Hour)}, wantTotal: 2, wantIDs: f.SaleInfos[:]},
+		{name: "销售日期无匹配", query: model.SaleInfoPageQuery{SaleTime: testTime.AddDate(0, 1, 0)}, wantTotal: 0},
+		{name: "分页", query: model.SaleInfoPageQuery{Page: 2, Size: 1}, wantTotal: 2, wantIDs: []int{f.SaleInfos[1]}},
+		{name: "零售商只看到本销售地的", scope: salePlaceScope(f.SalePlaces[1]), wantTotal: 1, wantIDs: []int{f.SaleInfos[1]}},
+		{name: "范围与条件同时生效", query: model.SaleInfoPageQuery{ProductName: "苹果"}, scope: salePlaceScope(f.SalePlaces[1]), wantTotal: 0},
+		{name: "未绑定销售地的零售商", scope: model.DataScope{SalePlaceID: &unbound}, wantTotal: 0},
+		{name: "物流公司范围不过滤销售信息", scope: companyScope(f.Companies[0]), wantTotal: 2, wantIDs: f.SaleInfos[:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			if query.Page == 0 {
+				query.Page, query.Size = 1, 10
+			}
+			list, total, err := repo.PageQuery(&query, tt.scope)
+			mustNoError(t, err)
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, 期望 %d", total, tt.wantTotal)
+			}
+			assertIDs(t, list, func(s *model.SaleInfoVO) int { return s.ID }, tt.wantIDs)
+
+			if query == (model.SaleInfoPageQuery{Page: 1, Size: 10}) {
+				all, err := repo.FindAll(tt.scope)
+				mustNoError(t, err)
+				assertIDs(t, all, func(s *model.SaleInfoVO) int { return s.ID }, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestSaleInfoRepository_FindSupplies(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewSaleInfoRepository(db)
+
+	supplies, err := repo.FindSupplies(f.SalePlaces[0], testTime, testTime.AddDate(0, 0, 7))
+	mustNoError(t, err)
+	if len(supplies) != 1 {
+		t.Fatalf("supplies = %d条, 期望1条", len(supplies))
+	}
+	s := supplies[0]
+	if s.SaleInfoID != f.SaleInfos[0] || s.LogisticsID != f.Logistics[0] || s.CompanyName != "物流0" ||
+		s.ProductInfoID != f.Productions[0] || s.ProductPlaceID != f.Places[0] || s.PPAddress != "农场0" ||
+		s.ProductID != f.Products[0] || s.ProductType != "水果" {
+		t.Fatalf("supply = %+v", s)
+	}
+
+	// 时间段为左闭右开
+	supplies, err = repo.FindSupplies(f.SalePlaces[0], testTime, testTime.Add(24*time.Hour))
+	mustNoError(t, err)
+	if len(supplies) != 0 {
+		t.Fatalf("时间段外的supplies = %+v", supplies)
+	}
+}
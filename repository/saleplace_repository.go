@@ -5,22 +5,34 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"agricultural_product_gin/model"
 )
 
-// SalePlaceRepository 销售地数据仓库
-type SalePlaceRepository struct {
-	DB *sql.DB
+// SalePlaceRepository 销售地数据仓库接口
+type SalePlaceRepository interface {
+	Save(salePlace *model.SalePlace) (int, error)
+	Update(salePlace *model.SalePlace) error
+	Delete(id int, deletedAt time.Time, deletedBy string) error
+	Restore(id int) error
+	GetByID(id int) (*model.SalePlace, error)
+	FindAll(includeDeleted bool) ([]*model.SalePlace, error)
+	PageQuery(page, pageSize int, id, address, administrator, phone string, includeDeleted bool) ([]*model.SalePlace, int64, error)
+}
+
+// SalePlaceRepositoryImpl 销售地数据仓库的数据库实现
+type SalePlaceRepositoryImpl struct {
+	DB *DB
 }
 
 // NewSalePlaceRepository 创建销售地仓库
-func NewSalePlaceRepository(db *sql.DB) *SalePlaceRepository {
-	return &SalePlaceRepository{DB: db}
+func NewSalePlaceRepository(db *DB) SalePlaceRepository {
+	return &SalePlaceRepositoryImpl{DB: db}
 }
 
 // Save 保存销售地
-func (r *SalePlaceRepository) Save(salePlace *model.SalePlace) (int, error) {
+func (r *SalePlaceRepositoryImpl) Save(salePlace *model.SalePlace) (int, error) {
 	query := "INSERT INTO sale_place(sp_address, sp_administrator, sp_phone) VALUES(?, ?, ?)"
 	result, err := r.DB.Exec(query, salePlace.Address, salePlace.Administrator, salePlace.Phone)
 	if err != nil {
@@ -38,7 +50,7 @@ func (r *SalePlaceRepository) Save(salePlace *model.SalePlace) (int, error) {
 }
 
 // Update 更新销售地
-func (r *SalePlaceRepository) Update(salePlace *model.SalePlace) error {
+func (r *SalePlaceRepositoryImpl) Update(salePlace *model.SalePlace) error {
 	query := "UPDATE sale_place SET sp_address = ?, sp_administrator = ?, sp_phone = ? WHERE sp_id = ?"
 	_, err := r.DB.Exec(query, salePlace.Address, salePlace.Administrator, salePlace.Phone, salePlace.ID)
 	if err != nil {
@@ -48,10 +60,10 @@ func (r *SalePlaceRepository) Update(salePlace *model.SalePlace) error {
 	return nil
 }
 
-// Delete 删除销售地
-func (r *SalePlaceRepository) Delete(id int) error {
-	query := "DELETE FROM sale_place WHERE sp_id = ?"
-	_, err := r.DB.Exec(query, id)
+// Delete 软删除销售地，记录删除时间及删除人，已有的销售记录仍可关联到该销售地
+func (r *SalePlaceRepositoryImpl) Delete(id int, deletedAt time.Time, deletedBy string) error {
+	query := "UPDATE sale_place SET deleted_at = ?, deleted_by = ? WHERE sp_id = ? AND deleted_at IS NULL"
+	_, err := r.DB.Exec(query, deletedAt, deletedBy, id)
 	if err != nil {
 		log.Println("删除销售地失败:", err)
 		return err
@@ -59,13 +71,21 @@ func (r *SalePlaceRepository) Delete(id int) error {
 	return nil
 }
 
-// GetByID 根据ID获取销售地
-func (r *SalePlaceRepository) GetByID(id int) (*model.SalePlace, error) {
-	query := "SELECT sp_id, sp_address, sp_administrator, sp_phone FROM sale_place WHERE sp_id = ?"
-	row := r.DB.QueryRow(query, id)
+// Restore 恢复已删除的销售地
+func (r *SalePlaceRepositoryImpl) Restore(id int) error {
+	query := "UPDATE sale_place SET deleted_at = NULL, deleted_by = NULL WHERE sp_id = ?"
+	_, err := r.DB.Exec(query, id)
+	if err != nil {
+		log.Println("恢复销售地失败:", err)
+		return err
+	}
+	return nil
+}
 
-	salePlace := &model.SalePlace{}
-	err := row.Scan(&salePlace.ID, &salePlace.Address, &salePlace.Administrator, &salePlace.Phone)
+// GetByID 根据ID获取销售地，已删除的销售地同样返回(DeletedAt不为空)，供历史溯源使用
+func (r *SalePlaceRepositoryImpl) GetByID(id int) (*model.SalePlace, error) {
+	query := salePlaceSelect + " WHERE sp_id = ?"
+	salePlace, err := scanSalePlace(r.DB.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -77,9 +97,12 @@ func (r *SalePlaceRepository) GetByID(id int) (*model.SalePlace, error) {
 	return salePlace, nil
 }
 
-// FindAll 查找所有销售地
-func (r *SalePlaceRepository) FindAll() ([]*model.SalePlace, error) {
-	query := "SELECT sp_id, sp_address, sp_administrator, sp_phone FROM sale_place"
+// FindAll 查找所有销售地，includeDeleted为false时不含已删除的销售地
+func (r *SalePlaceRepositoryImpl) FindAll(includeDeleted bool) ([]*model.SalePlace, error) {
+	query := salePlaceSelect
+	if !includeDeleted {
+		query += " WHERE deleted_at IS NULL"
+	}
 	rows, err := r.DB.Query(query)
 	if err != nil {
 		log.Println("查询销售地失败:", err)
@@ -89,8 +112,7 @@ func (r *SalePlaceRepository) FindAll() ([]*model.SalePlace, error) {
 
 	var salePlaces []*model.SalePlace
 	for rows.Next() {
-		salePlace := &model.SalePlace{}
-		err := rows.Scan(&salePlace.ID, &salePlace.Address, &salePlace.Administrator, &salePlace.Phone)
+		salePlace, err := scanSalePlace(rows)
 		if err != nil {
 			log.Println("读取销售地数据失败:", err)
 			return nil, err
@@ -101,8 +123,8 @@ func (r *SalePlaceRepository) FindAll() ([]*model.SalePlace, error) {
 	return salePlaces, nil
 }
 
-// PageQuery 分页查询销售地
-func (r *SalePlaceRepository) PageQuery(page, pageSize int, id, address, administrator, phone string) ([]*model.SalePlace, int64, error) {
+// PageQuery 分页查询销售地，includeDeleted为false时不含已删除的销售地
+func (r *SalePlaceRepositoryImpl) PageQuery(page, pageSize int, id, address, administrator, phone string, includeDeleted bool) ([]*model.SalePlace, int64, error) {
 	// 构建查询条件
 	conditions := []string{}
 	args := []interface{}{}
@@ -126,6 +148,7 @@ func (r *SalePlaceRepository) PageQuery(page, pageSize int, id, address, adminis
 		conditions = append(conditions, "sp_phone LIKE ?")
 		args = append(args, "%"+phone+"%")
 	}
+	conditions = softDeleteCondition(conditions, includeDeleted)
 
 	// 构建条件子句
 	whereClause := ""
@@ -144,7 +167,7 @@ func (r *SalePlaceRepository) PageQuery(page, pageSize int, id, address, adminis
 
 	// 查询当前页数据
 	offset := (page - 1) * pageSize
-	dataQuery := fmt.Sprintf("SELECT sp_id, sp_address, sp_administrator, sp_phone FROM sale_place%s LIMIT ? OFFSET ?", whereClause)
+	dataQuery := fmt.Sprintf("%s%s LIMIT ? OFFSET ?", salePlaceSelect, whereClause)
 	queryArgs := append(args, pageSize, offset)
 
 	rows, err := r.DB.Query(dataQuery, queryArgs...)
@@ -156,8 +179,7 @@ func (r *SalePlaceRepository) PageQuery(page, pageSize int, id, address, adminis
 
 	var salePlaces []*model.SalePlace
 	for rows.Next() {
-		salePlace := &model.SalePlace{}
-		err := rows.Scan(&salePlace.ID, &salePlace.Address, &salePlace.Administrator, &salePlace.Phone)
+		salePlace, err := scanSalePlace(rows)
 		if err != nil {
 			log.Println("读取销售地数据失败:", err)
 			return nil, 0, err
@@ -167,3 +189,19 @@ func (r *SalePlaceRepository) PageQuery(page, pageSize int, id, address, adminis
 
 	return salePlaces, total, nil
 }
+
+// salePlaceSelect 销售地查询字段
+const salePlaceSelect = "SELECT sp_id, sp_address, sp_administrator, sp_phone, deleted_at, deleted_by FROM sale_place"
+
+// scanSalePlace 读取一行销售地数据
+func scanSalePlace(row rowScanner) (*model.SalePlace, error) {
+	salePlace := &model.SalePlace{}
+	var deleted softDeleteFields
+	err := row.Scan(&salePlace.ID, &salePlace.Address, &salePlace.Administrator, &salePlace.Phone,
+		&deleted.DeletedAt, &deleted.DeletedBy)
+	if err != nil {
+		return nil, err
+	}
+	deleted.apply(&salePlace.SoftDelete)
+	return salePlace, nil
+}
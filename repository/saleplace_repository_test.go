@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"strconv"
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+func TestSalePlaceRepository_CRUD(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewSalePlaceRepository(db)
+
+	id, err := repo.Save(&model.SalePlace{Address: "中心超市", Administrator: "钱七", Phone: "13500000000"})
+	mustNoError(t, err)
+
+	got, err := repo.GetByID(id)
+	mustNoError(t, err)
+	if got == nil || got.Address != "中心超市" || got.Deleted() {
+		t.Fatalf("GetByID = %+v", got)
+	}
+
+	mustNoError(t, repo.Update(&model.SalePlace{ID: id, Address: "东门超市", Administrator: "孙八", Phone: "13400000000"}))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if got.Address != "东门超市" || got.Administrator != "孙八" || got.Phone != "13400000000" {
+		t.Fatalf("Update后 = %+v", got)
+	}
+
+	mustNoError(t, repo.Delete(id, testTime, "admin"))
+	got, err = repo.GetByID(id)
+	mustNoError(t, err)
+	if !got.Deleted() || got.DeletedBy != "admin" {
+		t.Fatalf("Delete后 = %+v", got)
+	}
+	all, err := repo.FindAll(false)
+	mustNoError(t, err)
+	if len(all) != 0 {
+		t.Fatalf("FindAll(false) = %d条, 期望0条", len(all))
+	}
+
+	mustNoError(t, repo.Restore(id))
+	all, err = repo.FindAll(false)
+	mustNoError(t, err)
+	if len(all) != 1 {
+		t.Fatalf("Restore后FindAll(false) = %d条, 期望1条", len(all))
+	}
+
+	missing, err := repo.GetByID(id + 100)
+	mustNoError(t, err)
+	if missing != nil {
+		t.Fatalf("不存在的销售地 = %+v", missing)
+	}
+}
+
+func TestSalePlaceRepository_PageQuery(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewSalePlaceRepository(db)
+	mustNoError(t, repo.Delete(f.SalePlaces[1], testTime, "admin"))
+
+	tests := []struct {
+		name           string
+		page, size     int
+		id             string
+		address        string
+		administrator  string
+		phone          string
+		includeDeleted bool
+		wantTotal      int64
+		wantIDs        []int
+	}{
+		{name: "默认不含已删除", page: 1, size: 10, wantTotal: 1, wantIDs: []int{f.SalePlaces[0]}},
+		{name: "包含已删除", page: 1, size: 10, includeDeleted: true, wantTotal: 2, wantIDs: []int{f.SalePlaces[0], f.SalePlaces[1]}},
+		{name: "按ID", page: 1, size: 10, id: strconv.Itoa(f.SalePlaces[0]), wantTotal: 1, wantIDs: []int{f.SalePlaces[0]}},
+		{name: "按地址", page: 1, size: 10, address: "超市1", includeDeleted: true, wantTotal: 1, wantIDs: []int{f.SalePlaces[1]}},
+		{name: "按负责人", page: 1, size: 10, administrator: "店长0", wantTotal: 1, wantIDs: []int{f.SalePlaces[0]}},
+		{name: "按电话", page: 1, size: 10, phone: "13700000001", includeDeleted: true, wantTotal: 1, wantIDs: []int{f.SalePlaces[1]}},
+		{name: "分页", page: 2, size: 1, includeDeleted: true, wantTotal: 2, wantIDs: []int{f.SalePlaces[1]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, total, err := repo.PageQuery(tt.page, tt.size, tt.id, tt.address, tt.administrator, tt.phone, tt.includeDeleted)
+			mustNoError(t, err)
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, 期望 %d", total, tt.wantTotal)
+			}
+			assertIDs(t, list, func(p *model.SalePlace) int { return p.ID }, tt.wantIDs)
+		})
+	}
+}
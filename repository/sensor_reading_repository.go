@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+// 批量插入时每条SQL包含的读数条数
+const sensorReadingChunkSize = 500
+
+// SensorReadingRepository 冷链传感器读数数据仓库接口
+type SensorReadingRepository interface {
+	SaveBatch(readings []*model.SensorReading) error
+	Summarize(logisticsID int) (*model.ColdChainSummary, error)
+	Series(logisticsID int, starts []time.Time) ([]*model.ColdChainPoint, error)
+}
+
+// SensorReadingRepositoryImpl 冷链传感器读数数据仓库的数据库实现
+type SensorReadingRepositoryImpl struct {
+	DB *DB
+}
+
+// NewSensorReadingRepository 创建冷链传感器读数仓库
+func NewSensorReadingRepository(db *DB) SensorReadingRepository {
+	return &SensorReadingRepositoryImpl{DB: db}
+}
+
+// SaveBatch 在一个事务中分块批量保存读数
+func (r *SensorReadingRepositoryImpl) SaveBatch(readings []*model.SensorReading) error {
+	return r.DB.Transaction(func(tx *DB) error {
+		for start := 0; start < len(readings); start += sensorReadingChunkSize {
+			end := start + sensorReadingChunkSize
+			if end > len(readings) {
+				end = len(readings)
+			}
+			chunk := readings[start:end]
+
+			placeholders := make([]string, 0, len(chunk))
+			args := make([]interface{}, 0, len(chunk)*7)
+			for _, reading := range chunk {
+				placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?)")
+				args = append(args, reading.LogisticsID, reading.ReadingTime, reading.Temperature,
+					reading.Humidity, reading.Latitude, reading.Longitude, reading.Excursion)
+			}
+
+			query := "INSERT INTO sensor_reading(log_id, reading_time, temperature, humidity, latitude, longitude, excursion) VALUES " +
+				strings.Join(placeholders, ", ")
+			if _, err := tx.Exec(query, args...); err != nil {
+				log.Println("批量保存冷链读数失败:", err)
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Summarize 在数据库中统计物流记录的读数(不含分桶序列)，没有读数时返回nil
+func (r *SensorReadingRepositoryImpl) Summarize(logisticsID int) (*model.ColdChainSummary, error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(excursion), 0), MIN(temperature), MAX(temperature), AVG(temperature),
+			MIN(humidity), MAX(humidity), AVG(humidity)
+			FROM sensor_reading WHERE log_id = ?`
+
+	summary := &model.ColdChainSummary{}
+	var minTemperature, maxTemperature, avgTemperature sql.NullFloat64
+	var minHumidity, maxHumidity, avgHumidity sql.NullFloat64
+	err := r.DB.QueryRow(query, logisticsID).Scan(&summary.Count, &summary.Excursions,
+		&minTemperature, &maxTemperature, &avgTemperature, &minHumidity, &maxHumidity, &avgHumidity)
+	if err != nil {
+		log.Println("统计冷链读数失败:", err)
+		return nil, err
+	}
+	if summary.Count == 0 {
+		return nil, nil
+	}
+	summary.Temperature = coldChainStat(minTemperature, maxTemperature, avgTemperature)
+	summary.Humidity = coldChainStat(minHumidity, maxHumidity, avgHumidity)
+
+	// 首末读数时间沿索引读取，不依赖各数据库对时间列聚合结果的类型处理
+	query = "SELECT reading_time FROM sensor_reading WHERE log_id = ? ORDER BY reading_time, reading_id LIMIT 1"
+	if err := r.DB.QueryRow(query, logisticsID).Scan(&summary.FirstTime); err != nil {
+		log.Println("查询首条冷链读数时间失败:", err)
+		return nil, err
+	}
+	query = "SELECT reading_time FROM sensor_reading WHERE log_id = ? ORDER BY reading_time DESC, reading_id DESC LIMIT 1"
+	if err := r.DB.QueryRow(query, logisticsID).Scan(&summary.LastTime); err != nil {
+		log.Println("查询末条冷链读数时间失败:", err)
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// Series 在数据库中按时间分桶汇总读数，starts为各桶的起始时间(升序)
+// 早于第二个桶的读数归入第一个桶，不早于最后一个起始时间的读数都归入最后一个桶；只返回有读数的桶
+func (r *SensorReadingRepositoryImpl) Series(logisticsID int, starts []time.Time) ([]*model.ColdChainPoint, error) {
+	bucket := "0"
+	args := make([]interface{}, 0, len(starts)+1)
+	if len(starts) > 1 {
+		cases := make([]string, 0, len(starts)-1)
+		for i, start := range starts[1:] {
+			cases = append(cases, fmt.Sprintf("WHEN reading_time < ? THEN %d", i))
+			args = append(args, start)
+		}
+		bucket = fmt.Sprintf("CASE %s ELSE %d END", strings.Join(cases, " "), len(starts)-1)
+	}
+	args = append(args, logisticsID)
+
+	query := "SELECT " + bucket + ` AS bucket_index, COUNT(*), MIN(temperature), MAX(temperature), AVG(temperature),
+			AVG(humidity), MAX(excursion)
+			FROM sensor_reading WHERE log_id = ? GROUP BY bucket_index ORDER BY bucket_index`
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		log.Println("分桶汇总冷链读数失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := []*model.ColdChainPoint{}
+	for rows.Next() {
+		point := &model.ColdChainPoint{}
+		var index, excursion int
+		var humidity sql.NullFloat64
+		err := rows.Scan(&index, &point.Count, &point.MinTemperature, &point.MaxTemperature, &point.AvgTemperature,
+			&humidity, &excursion)
+		if err != nil {
+			log.Println("读取冷链读数分桶失败:", err)
+			return nil, err
+		}
+		if index < 0 || index >= len(starts) {
+			return nil, fmt.Errorf("冷链读数分桶序号越界: %d", index)
+		}
+		point.Time = starts[index]
+		if humidity.Valid {
+			point.AvgHumidity = &humidity.Float64
+		}
+		point.Excursion = excursion > 0
+		series = append(series, point)
+	}
+
+	return series, rows.Err()
+}
+
+// coldChainStat 由最小、最大、平均值组成统计，没有数据(聚合结果为NULL)时返回nil
+func coldChainStat(minimum, maximum, average sql.NullFloat64) *model.ColdChainStat {
+	if !minimum.Valid {
+		return nil
+	}
+	return &model.ColdChainStat{Min: minimum.Float64, Max: maximum.Float64, Avg: average.Float64}
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+func TestSensorReadingRepository(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewSensorReadingRepository(db)
+
+	// 超过一个分块的读数，倒序写入以校验按采集时间统计首末时间；温度在2与4之间交替
+	count := sensorReadingChunkSize + 10
+	humidity := 85.0
+	readings := make([]*model.SensorReading, 0, count)
+	for i := count - 1; i >= 0; i-- {
+		reading := &model.SensorReading{
+			LogisticsID: f.Logistics[0],
+			ReadingTime: testTime.Add(time.Duration(i) * time.Minute),
+			Temperature: float64(2 + i%2*2),
+			Excursion:   i == 0,
+		}
+		if i == 0 {
+			reading.Humidity = &humidity
+		}
+		readings = append(readings, reading)
+	}
+	mustNoError(t, repo.SaveBatch(readings))
+
+	summary, err := repo.Summarize(f.Logistics[0])
+	mustNoError(t, err)
+	if summary == nil || summary.Count != count || summary.Excursions != 1 || !summary.FirstTime.Equal(testTime) ||
+		!summary.LastTime.Equal(testTime.Add(time.Duration(count-1)*time.Minute)) {
+		t.Fatalf("Summarize = %+v", summary)
+	}
+	if *summary.Temperature != (model.ColdChainStat{Min: 2, Max: 4, Avg: 3}) ||
+		summary.Humidity == nil || *summary.Humidity != (model.ColdChainStat{Min: 85, Max: 85, Avg: 85}) {
+		t.Fatalf("温湿度统计 = %+v, %+v", summary.Temperature, summary.Humidity)
+	}
+
+	// 第三个桶之后的读数都归入最后一个桶
+	starts := []time.Time{testTime, testTime.Add(100 * time.Minute), testTime.Add(200 * time.Minute)}
+	series, err := repo.Series(f.Logistics[0], starts)
+	mustNoError(t, err)
+	if len(series) != 3 {
+		t.Fatalf("Series = %d个桶, 期望 3个", len(series))
+	}
+	for i, wantCount := range []int{100, 100, count - 200} {
+		point := series[i]
+		if !point.Time.Equal(starts[i]) || point.Count != wantCount || point.MinTemperature != 2 ||
+			point.MaxTemperature != 4 || point.AvgTemperature != 3 || point.Excursion != (i == 0) ||
+			(point.AvgHumidity != nil) != (i == 0) {
+			t.Fatalf("第%d个桶 = %+v", i, point)
+		}
+	}
+	series, err = repo.Series(f.Logistics[0], starts[:1])
+	mustNoError(t, err)
+	if len(series) != 1 || series[0].Count != count {
+		t.Fatalf("只有一个桶时Series = %+v", series)
+	}
+
+	// 后一个分块失败时前面已写入的分块一并回滚
+	readings = readings[:0]
+	for i := 0; i < sensorReadingChunkSize; i++ {
+		readings = append(readings, &model.SensorReading{LogisticsID: f.Logistics[1], ReadingTime: testTime, Temperature: 4})
+	}
+	readings = append(readings, &model.SensorReading{LogisticsID: 999, ReadingTime: testTime, Temperature: 4})
+	if err := repo.SaveBatch(readings); err == nil {
+		t.Fatal("关联不存在的物流时SaveBatch应失败")
+	}
+	summary, err = repo.Summarize(f.Logistics[1])
+	mustNoError(t, err)
+	if summary != nil {
+		t.Fatalf("失败后仍写入了 %d 条读数", summary.Count)
+	}
+}
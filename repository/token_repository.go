@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"agricultural_product_gin/model"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// TokenRepository 刷新令牌及访问令牌吊销列表仓库接口
+type TokenRepository interface {
+	SaveRefreshToken(token *model.RefreshToken) error
+	FindRefreshToken(tokenHash string) (*model.RefreshToken, error)
+	RevokeRefreshToken(id int, revokedAt time.Time) (bool, error)
+	RevokeRefreshTokenByAccessJTI(accessJTI string, revokedAt time.Time) error
+	RevokeUserRefreshTokens(userID int, revokedAt time.Time) error
+	RevokeAccessToken(jti string, userID int, expiresAt time.Time) error
+	AccessTokenState(jti string, userID int) (revoked bool, tokenVersion int, exists bool, err error)
+}
+
+// TokenRepositoryImpl 刷新令牌及访问令牌吊销列表仓库的数据库实现
+type TokenRepositoryImpl struct {
+	DB *DB
+}
+
+// NewTokenRepository 创建令牌仓库
+func NewTokenRepository(db *DB) TokenRepository {
+	return &TokenRepositoryImpl{DB: db}
+}
+
+// SaveRefreshToken 保存刷新令牌
+func (r *TokenRepositoryImpl) SaveRefreshToken(token *model.RefreshToken) error {
+	query := "INSERT INTO refresh_token(user_id, token_hash, access_jti, expires_at, created_at) VALUES(?, ?, ?, ?, ?)"
+	_, err := r.DB.Exec(query, token.UserID, token.TokenHash, token.AccessJTI, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		log.Println("保存刷新令牌失败:", err)
+		return err
+	}
+	return nil
+}
+
+// FindRefreshToken 根据令牌哈希查找刷新令牌
+func (r *TokenRepositoryImpl) FindRefreshToken(tokenHash string) (*model.RefreshToken, error) {
+	query := `SELECT id, user_id, token_hash, access_jti, expires_at, revoked_at, created_at 
+              FROM refresh_token WHERE token_hash = ?`
+
+	token := &model.RefreshToken{}
+	var revokedAt sql.NullTime
+	err := r.DB.QueryRow(query, tokenHash).Scan(&token.ID, &token.UserID, &token.TokenHash, &token.AccessJTI,
+		&token.ExpiresAt, &revokedAt, &token.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("查询刷新令牌失败:", err)
+		return nil, err
+	}
+
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+// RevokeRefreshToken 吊销刷新令牌，返回是否由本次调用吊销(并发轮换时只有一个请求成功)
+func (r *TokenRepositoryImpl) RevokeRefreshToken(id int, revokedAt time.Time) (bool, error) {
+	result, err := r.DB.Exec("UPDATE refresh_token SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", revokedAt, id)
+	if err != nil {
+		log.Println("吊销刷新令牌失败:", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// RevokeRefreshTokenByAccessJTI 吊销与访问令牌配对的刷新令牌
+func (r *TokenRepositoryImpl) RevokeRefreshTokenByAccessJTI(accessJTI string, revokedAt time.Time) error {
+	_, err := r.DB.Exec("UPDATE refresh_token SET revoked_at = ? WHERE access_jti = ? AND revoked_at IS NULL", revokedAt, accessJTI)
+	if err != nil {
+		log.Println("吊销刷新令牌失败:", err)
+		return err
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens 吊销用户的所有刷新令牌
+func (r *TokenRepositoryImpl) RevokeUserRefreshTokens(userID int, revokedAt time.Time) error {
+	_, err := r.DB.Exec("UPDATE refresh_token SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", revokedAt, userID)
+	if err != nil {
+		log.Println("吊销用户刷新令牌失败:", err)
+		return err
+	}
+	return nil
+}
+
+// RevokeAccessToken 将访问令牌加入吊销列表，并顺带清理已过期的记录
+func (r *TokenRepositoryImpl) RevokeAccessToken(jti string, userID int, expiresAt time.Time) error {
+	_, err := r.DB.Exec(r.DB.Dialect.InsertIgnore()+" INTO revoked_token(jti, user_id, expires_at) VALUES(?, ?, ?)", jti, userID, expiresAt)
+	if err != nil {
+		log.Println("吊销访问令牌失败:", err)
+		return err
+	}
+
+	_, err = r.DB.Exec("DELETE FROM revoked_token WHERE expires_at < ?", time.Now())
+	if err != nil {
+		log.Println("清理过期吊销记录失败:", err)
+	}
+	return nil
+}
+
+// AccessTokenState 查询访问令牌是否在吊销列表中，以及用户当前的令牌版本
+// 用户不存在时exists返回false
+func (r *TokenRepositoryImpl) AccessTokenState(jti string, userID int) (revoked bool, tokenVersion int, exists bool, err error) {
+	query := `SELECT u.token_version, EXISTS(SELECT 1 FROM revoked_token WHERE jti = ?) 
+              FROM user u WHERE u.id = ?`
+
+	err = r.DB.QueryRow(query, jti, userID).Scan(&tokenVersion, &revoked)
+	if err == sql.ErrNoRows {
+		return false, 0, false, nil
+	}
+	if err != nil {
+		log.Println("查询令牌状态失败:", err)
+		return false, 0, false, err
+	}
+	return revoked, tokenVersion, true, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+func TestTokenRepository(t *testing.T) {
+	db := newTestDB(t)
+	users := NewUserRepository(db)
+	mustNoError(t, users.Save("farmer", "hash", model.RoleFarmer))
+	user, err := users.FindByUsername("farmer")
+	mustNoError(t, err)
+
+	repo := NewTokenRepository(db)
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, jti := range []string{"jti-1", "jti-2", "jti-3"} {
+		mustNoError(t, repo.SaveRefreshToken(&model.RefreshToken{
+			UserID: user.ID, TokenHash: "hash-" + jti, AccessJTI: jti, ExpiresAt: now.Add(time.Duration(i+1) * time.Hour), CreatedAt: now,
+		}))
+	}
+
+	token, err := repo.FindRefreshToken("hash-jti-1")
+	mustNoError(t, err)
+	if token == nil || token.UserID != user.ID || token.AccessJTI != "jti-1" || token.RevokedAt != nil || !token.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("FindRefreshToken = %+v", token)
+	}
+
+	// 同一刷新令牌只能吊销一次
+	revoked, err := repo.RevokeRefreshToken(token.ID, now)
+	mustNoError(t, err)
+	if !revoked {
+		t.Fatal("首次吊销应返回true")
+	}
+	revoked, err = repo.RevokeRefreshToken(token.ID, now)
+	mustNoError(t, err)
+	if revoked {
+		t.Fatal("重复吊销应返回false")
+	}
+
+	mustNoError(t, repo.RevokeRefreshTokenByAccessJTI("jti-2", now))
+	token, err = repo.FindRefreshToken("hash-jti-2")
+	mustNoError(t, err)
+	if token.RevokedAt == nil {
+		t.Fatal("按访问令牌ID吊销后RevokedAt为空")
+	}
+	token, err = repo.FindRefreshToken("hash-jti-3")
+	mustNoError(t, err)
+	if token.RevokedAt != nil {
+		t.Fatal("未吊销的刷新令牌被吊销")
+	}
+	mustNoError(t, repo.RevokeUserRefreshTokens(user.ID, now))
+	token, err = repo.FindRefreshToken("hash-jti-3")
+	mustNoError(t, err)
+	if token.RevokedAt == nil {
+		t.Fatal("吊销用户全部刷新令牌后RevokedAt为空")
+	}
+
+	tests := []struct {
+		name        string
+		jti         string
+		userID      int
+		wantRevoked bool
+		wantVersion int
+		wantExists  bool
+	}{
+		{name: "吊销前", jti: "jti-1", userID: user.ID, wantExists: true},
+		{name: "用户不存在", jti: "jti-1", userID: 999},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked, version, exists, err := repo.AccessTokenState(tt.jti, tt.userID)
+			mustNoError(t, err)
+			if revoked != tt.wantRevoked || version != tt.wantVersion || exists != tt.wantExists {
+				t.Errorf("AccessTokenState = %v, %d, %v", revoked, version, exists)
+			}
+		})
+	}
+
+	// 重复吊销同一访问令牌不报错，并清理已过期的吊销记录
+	mustNoError(t, repo.RevokeAccessToken("jti-old", user.ID, now.Add(-time.Hour)))
+	mustNoError(t, repo.RevokeAccessToken("jti-1", user.ID, now.Add(time.Hour)))
+	mustNoError(t, repo.RevokeAccessToken("jti-1", user.ID, now.Add(time.Hour)))
+	mustNoError(t, users.IncrementTokenVersion(user.ID))
+	revoked, version, exists, err := repo.AccessTokenState("jti-1", user.ID)
+	mustNoError(t, err)
+	if !revoked || version != 1 || !exists {
+		t.Fatalf("吊销后AccessTokenState = %v, %d, %v", revoked, version, exists)
+	}
+	var count int
+	mustNoError(t, db.QueryRow("SELECT COUNT(*) FROM revoked_token").Scan(&count))
+	if count != 1 {
+		t.Fatalf("吊销记录 = %d条, 期望过期记录被清理后剩1条", count)
+	}
+}
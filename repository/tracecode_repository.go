@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+// TraceCodeRepository 溯源码数据仓库接口
+type TraceCodeRepository interface {
+	Save(traceCode *model.TraceCode) (int, error)
+	Revoke(id int, reason string, revokeTime time.Time) error
+	GetByID(id int) (*model.TraceCode, error)
+	GetByCode(code string) (*model.TraceCode, error)
+	FindActive(saleInfoID, productInfoID int) (*model.TraceCode, error)
+	PageQuery(query *model.TraceCodePageQuery) ([]*model.TraceCode, int64, error)
+}
+
+// TraceCodeRepositoryImpl 溯源码数据仓库的数据库实现
+type TraceCodeRepositoryImpl struct {
+	DB *DB
+}
+
+// NewTraceCodeRepository 创建溯源码仓库
+func NewTraceCodeRepository(db *DB) TraceCodeRepository {
+	return &TraceCodeRepositoryImpl{DB: db}
+}
+
+const traceCodeColumns = "tc_id, code, sale_info_id, product_info_id, revoked, create_time, revoke_time, revoke_reason"
+
+// Save 保存溯源码
+func (r *TraceCodeRepositoryImpl) Save(traceCode *model.TraceCode) (int, error) {
+	query := "INSERT INTO trace_code(code, sale_info_id, product_info_id, revoked, create_time) VALUES(?, ?, ?, ?, ?)"
+	result, err := r.DB.Exec(query, traceCode.Code, nullableID(traceCode.SaleInfoID), nullableID(traceCode.ProductInfoID),
+		traceCode.Revoked, traceCode.CreateTime)
+	if err != nil {
+		log.Println("保存溯源码失败:", err)
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		log.Println("获取溯源码ID失败:", err)
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Revoke 作废溯源码
+func (r *TraceCodeRepositoryImpl) Revoke(id int, reason string, revokeTime time.Time) error {
+	query := "UPDATE trace_code SET revoked = ?, revoke_time = ?, revoke_reason = ? WHERE tc_id = ?"
+	_, err := r.DB.Exec(query, true, revokeTime, reason, id)
+	if err != nil {
+		log.Println("作废溯源码失败:", err)
+		return err
+	}
+	return nil
+}
+
+// GetByID 根据ID获取溯源码
+func (r *TraceCodeRepositoryImpl) GetByID(id int) (*model.TraceCode, error) {
+	query := "SELECT " + traceCodeColumns + " FROM trace_code WHERE tc_id = ?"
+	traceCode, err := scanTraceCode(r.DB.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("获取溯源码失败:", err)
+		return nil, err
+	}
+	return traceCode, nil
+}
+
+// GetByCode 根据公开溯源码获取
+func (r *TraceCodeRepositoryImpl) GetByCode(code string) (*model.TraceCode, error) {
+	query := "SELECT " + traceCodeColumns + " FROM trace_code WHERE code = ?"
+	traceCode, err := scanTraceCode(r.DB.QueryRow(query, code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("获取溯源码失败:", err)
+		return nil, err
+	}
+	return traceCode, nil
+}
+
+// FindActive 查找销售信息或生产批次当前有效的溯源码
+// 在事务中调用时会锁定该销售信息或生产批次，直到事务结束，保证查询与生成之间不会并发生成另一个有效溯源码
+func (r *TraceCodeRepositoryImpl) FindActive(saleInfoID, productInfoID int) (*model.TraceCode, error) {
+	var lockQuery, query string
+	var arg int
+	if saleInfoID > 0 {
+		lockQuery = "SELECT si_id FROM sale_info WHERE si_id = ?"
+		query = "SELECT " + traceCodeColumns + " FROM trace_code WHERE sale_info_id = ? AND revoked = ? ORDER BY tc_id DESC LIMIT 1"
+		arg = saleInfoID
+	} else {
+		lockQuery = "SELECT pi_id FROM product_info WHERE pi_id = ?"
+		query = "SELECT " + traceCodeColumns + " FROM trace_code WHERE product_info_id = ? AND sale_info_id IS NULL AND revoked = ? ORDER BY tc_id DESC LIMIT 1"
+		arg = productInfoID
+	}
+
+	if r.DB.InTransaction() {
+		var id int
+		err := r.DB.QueryRow(lockQuery+r.DB.Dialect.ForUpdate(), arg).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			log.Println("锁定溯源码关联记录失败:", err)
+			return nil, err
+		}
+	}
+
+	traceCode, err := scanTraceCode(r.DB.QueryRow(query, arg, false))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("查询有效溯源码失败:", err)
+		return nil, err
+	}
+	return traceCode, nil
+}
+
+// PageQuery 分页查询溯源码
+func (r *TraceCodeRepositoryImpl) PageQuery(query *model.TraceCodePageQuery) ([]*model.TraceCode, int64, error) {
+	// 构建查询条件
+	conditions := []string{}
+	args := []interface{}{}
+
+	if query.Code != "" {
+		conditions = append(conditions, "code LIKE ?")
+		args = append(args, "%"+query.Code+"%")
+	}
+	if query.SaleInfoID > 0 {
+		conditions = append(conditions, "sale_info_id = ?")
+		args = append(args, query.SaleInfoID)
+	}
+	if query.ProductInfoID > 0 {
+		conditions = append(conditions, "product_info_id = ?")
+		args = append(args, query.ProductInfoID)
+	}
+	if query.Revoked != nil {
+		conditions = append(conditions, "revoked = ?")
+		args = append(args, *query.Revoked)
+	}
+
+	// 构建条件子句
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// 查询总记录数
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM trace_code%s", whereClause)
+	var total int64
+	err := r.DB.QueryRow(countQuery, args...).Scan(&total)
+	if err != nil {
+		log.Println("查询溯源码总数失败:", err)
+		return nil, 0, err
+	}
+
+	// 查询当前页数据
+	offset := (query.Page - 1) * query.Size
+	dataQuery := fmt.Sprintf("SELECT %s FROM trace_code%s ORDER BY tc_id DESC LIMIT ? OFFSET ?", traceCodeColumns, whereClause)
+	queryArgs := append(args, query.Size, offset)
+
+	rows, err := r.DB.Query(dataQuery, queryArgs...)
+	if err != nil {
+		log.Println("分页查询溯源码失败:", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var traceCodes []*model.TraceCode
+	for rows.Next() {
+		traceCode, err := scanTraceCode(rows)
+		if err != nil {
+			log.Println("读取溯源码数据失败:", err)
+			return nil, 0, err
+		}
+		traceCodes = append(traceCodes, traceCode)
+	}
+
+	return traceCodes, total, nil
+}
+
+// scanTraceCode 读取一行溯源码数据
+func scanTraceCode(row rowScanner) (*model.TraceCode, error) {
+	traceCode := &model.TraceCode{}
+	var saleInfoID, productInfoID sql.NullInt64
+	var revokeTime sql.NullTime
+	var revokeReason sql.NullString
+
+	err := row.Scan(&traceCode.ID, &traceCode.Code, &saleInfoID, &productInfoID, &traceCode.Revoked,
+		&traceCode.CreateTime, &revokeTime, &revokeReason)
+	if err != nil {
+		return nil, err
+	}
+
+	traceCode.SaleInfoID = int(saleInfoID.Int64)
+	traceCode.ProductInfoID = int(productInfoID.Int64)
+	if revokeTime.Valid {
+		traceCode.RevokeTime = &revokeTime.Time
+	}
+	traceCode.RevokeReason = revokeReason.String
+	return traceCode, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"agricultural_product_gin/model"
+)
+
+func TestTraceCodeRepository(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewTraceCodeRepository(db)
+
+	saleCode, err := repo.Save(&model.TraceCode{Code: "SALE0001", SaleInfoID: f.SaleInfos[0], ProductInfoID: f.Productions[0], CreateTime: testTime})
+	mustNoError(t, err)
+	batchCode, err := repo.Save(&model.TraceCode{Code: "BATCH0001", ProductInfoID: f.Productions[0], CreateTime: testTime})
+	mustNoError(t, err)
+
+	got, err := repo.GetByCode("SALE0001")
+	mustNoError(t, err)
+	if got == nil || got.ID != saleCode || got.SaleInfoID != f.SaleInfos[0] || got.Revoked || got.RevokeTime != nil {
+		t.Fatalf("GetByCode = %+v", got)
+	}
+	got, err = repo.GetByCode("NONE")
+	mustNoError(t, err)
+	if got != nil {
+		t.Fatalf("不存在的溯源码GetByCode = %+v", got)
+	}
+
+	// 按生产批次查找时不返回已关联销售信息的溯源码
+	active, err := repo.FindActive(0, f.Productions[0])
+	mustNoError(t, err)
+	if active == nil || active.ID != batchCode {
+		t.Fatalf("FindActive(批次) = %+v", active)
+	}
+	active, err = repo.FindActive(f.SaleInfos[0], 0)
+	mustNoError(t, err)
+	if active == nil || active.ID != saleCode {
+		t.Fatalf("FindActive(销售) = %+v", active)
+	}
+
+	// 事务中先锁定关联记录，关联记录不存在时没有有效溯源码
+	mustNoError(t, db.Transaction(func(tx *DB) error {
+		txRepo := NewTraceCodeRepository(tx)
+		active, err := txRepo.FindActive(f.SaleInfos[0], 0)
+		mustNoError(t, err)
+		if active == nil || active.ID != saleCode {
+			t.Fatalf("事务中FindActive(销售) = %+v", active)
+		}
+		active, err = txRepo.FindActive(0, 9999)
+		mustNoError(t, err)
+		if active != nil {
+			t.Fatalf("批次不存在时FindActive = %+v", active)
+		}
+		return nil
+	}))
+
+	mustNoError(t, repo.Revoke(saleCode, "标签损坏", testTime.Add(time.Hour)))
+	got, err = repo.GetByID(saleCode)
+	mustNoError(t, err)
+	if !got.Revoked || got.RevokeTime == nil || got.RevokeReason != "标签损坏" {
+		t.Fatalf("Revoke后 = %+v", got)
+	}
+	active, err = repo.FindActive(f.SaleInfos[0], 0)
+	mustNoError(t, err)
+	if active != nil {
+		t.Fatalf("作废后FindActive = %+v", active)
+	}
+
+	revoked, notRevoked := true, false
+	tests := []struct {
+		name      string
+		query     model.TraceCodePageQuery
+		wantTotal int64
+		wantIDs   []int
+	}{
+		{name: "全部按ID倒序", wantTotal: 2, wantIDs: []int{batchCode, saleCode}},
+		{name: "按溯源码", query: model.TraceCodePageQuery{Code: "BATCH"}, wantTotal: 1, wantIDs: []int{batchCode}},
+		{name: "按销售信息", query: model.TraceCodePageQuery{SaleInfoID: f.SaleInfos[0]}, wantTotal: 1, wantIDs: []int{saleCode}},
+		{name: "按生产批次", query: model.TraceCodePageQuery{ProductInfoID: f.Productions[0]}, wantTotal: 2, wantIDs: []int{batchCode, saleCode}},
+		{name: "已作废", query: model.TraceCodePageQuery{Revoked: &revoked}, wantTotal: 1, wantIDs: []int{saleCode}},
+		{name: "未作废", query: model.TraceCodePageQuery{Revoked: &notRevoked}, wantTotal: 1, wantIDs: []int{batchCode}},
+		{name: "分页", query: model.TraceCodePageQuery{Page: 2, Size: 1}, wantTotal: 2, wantIDs: []int{saleCode}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			if query.Page == 0 {
+				query.Page, query.Size = 1, 10
+			}
+			list, total, err := repo.PageQuery(&query)
+			mustNoError(t, err)
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, 期望 %d", total, tt.wantTotal)
+			}
+			assertIDs(t, list, func(c *model.TraceCode) int { return c.ID }, tt.wantIDs)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package repository
+
+// Repositories 绑定到同一数据库连接或事务的仓储集合
+type Repositories struct {
+	Company         CompanyRepository
+	Product         ProductRepository
+	ProductionPlace ProductionPlaceRepository
+	SalePlace       SalePlaceRepository
+	Production      ProductionRepository
+	Batch           BatchRepository
+	FarmingActivity FarmingActivityRepository
+	Inspection      InspectionRepository
+	Recall          RecallRepository
+	Logistics       LogisticsRepository
+	LogisticsEvent  LogisticsEventRepository
+	SensorReading   SensorReadingRepository
+	SaleInfo        SaleInfoRepository
+	TraceCode       TraceCodeRepository
+	User            UserRepository
+	Token           TokenRepository
+	Audit           AuditRepository
+	Reference       ReferenceRepository
+}
+
+// NewRepositories 创建绑定到db的仓储集合
+func NewRepositories(db *DB) *Repositories {
+	return &Repositories{
+		Company:         NewCompanyRepository(db),
+		Product:         NewProductRepository(db),
+		ProductionPlace: NewProductionPlaceRepository(db),
+		SalePlace:       NewSalePlaceRepository(db),
+		Production:      NewProductionRepository(db),
+		Batch:           NewBatchRepository(db),
+		FarmingActivity: NewFarmingActivityRepository(db),
+		Inspection:      NewInspectionRepository(db),
+		Recall:          NewRecallRepository(db),
+		Logistics:       NewLogisticsRepository(db),
+		LogisticsEvent:  NewLogisticsEventRepository(db),
+		SensorReading:   NewSensorReadingRepository(db),
+		SaleInfo:        NewSaleInfoRepository(db),
+		TraceCode:       NewTraceCodeRepository(db),
+		User:            NewUserRepository(db),
+		Token:           NewTokenRepository(db),
+		Audit:           NewAuditRepository(db),
+		Reference:       NewReferenceRepository(db),
+	}
+}
+
+// UnitOfWork 工作单元，在同一事务中执行多个仓储操作
+type UnitOfWork interface {
+	// Do 在事务中执行fn，fn返回错误时回滚其中的所有操作
+	Do(fn func(repos *Repositories) error) error
+}
+
+// UnitOfWorkImpl 基于数据库事务的工作单元
+type UnitOfWorkImpl struct {
+	DB *DB
+}
+
+// NewUnitOfWork 创建工作单元
+func NewUnitOfWork(db *DB) UnitOfWork {
+	return &UnitOfWorkImpl{DB: db}
+}
+
+// Do 在事务中执行fn
+func (u *UnitOfWorkImpl) Do(fn func(repos *Repositories) error) error {
+	return u.DB.Transaction(func(tx *DB) error {
+		return fn(NewRepositories(tx))
+	})
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	uow := NewUnitOfWork(db)
+	errAbort := errors.New("abort")
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCount int
+	}{
+		{name: "出错时回滚全部操作", err: errAbort, wantCount: 0},
+		{name: "成功时提交", wantCount: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := uow.Do(func(repos *Repositories) error {
+				// 仓储内部的事务复用工作单元的事务
+				if _, err := repos.SaleInfo.Save(&model.SaleInfo{
+					LogisticsID: f.Logistics[0], SalePlaceID: f.SalePlaces[1], Description: tt.name, SaleTime: testTime,
+				}); err != nil {
+					return err
+				}
+				return tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Do err = %v, 期望 %v", err, tt.err)
+			}
+
+			var count, chained int
+			mustNoError(t, db.QueryRow("SELECT COUNT(*) FROM sale_info WHERE si_description = ?", tt.name).Scan(&count))
+			mustNoError(t, db.QueryRow("SELECT COUNT(*) FROM hash_chain WHERE entity = ? AND entity_id > ?",
+				model.HashChainSaleInfo, f.SaleInfos[1]).Scan(&chained))
+			if count != tt.wantCount || chained != tt.wantCount {
+				t.Errorf("销售信息 = %d条, 哈希链条目 = %d个, 期望 %d", count, chained, tt.wantCount)
+			}
+		})
+	}
+}
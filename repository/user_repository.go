@@ -7,27 +7,46 @@ import (
 	"agricultural_product_gin/model"
 )
 
-// UserRepository 用户数据仓库
-type UserRepository struct {
-	DB *sql.DB
+// UserRepository 用户数据仓库接口
+type UserRepository interface {
+	FindByUsername(username string) (*model.User, error)
+	Save(username, password, role string) error
+	Update(user *model.User) error
+	UpdatePassword(userID int, newPassword string) error
+	GetByID(id int) (*model.User, error)
+	UpdateRole(userID int, role string) error
+	UpdateBinding(userID, companyID, productPlaceID, salePlaceID int) error
+	IncrementTokenVersion(userID int) error
+	Count() (int64, error)
+	CountByRole(role string) (int64, error)
+	FindAll() ([]*model.User, error)
+}
+
+// UserRepositoryImpl 用户数据仓库的数据库实现
+type UserRepositoryImpl struct {
+	DB *DB
 }
 
 // NewUserRepository 创建用户仓库
-func NewUserRepository(db *sql.DB) *UserRepository {
-	return &UserRepository{DB: db}
+func NewUserRepository(db *DB) UserRepository {
+	return &UserRepositoryImpl{DB: db}
 }
 
 // FindByUsername 根据用户名查找用户
-func (r *UserRepository) FindByUsername(username string) (*model.User, error) {
+func (r *UserRepositoryImpl) FindByUsername(username string) (*model.User, error) {
 	query := `SELECT id, username, password, 
               sex, 
               COALESCE(name, '') as name, 
-              COALESCE(phone, '') as phone 
+              COALESCE(phone, '') as phone,
+              role,
+              COALESCE(company_id, 0), COALESCE(product_place_id, 0), COALESCE(sale_place_id, 0),
+              token_version
               FROM user WHERE username = ?`
 	row := r.DB.QueryRow(query, username)
 
 	user := &model.User{}
-	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Sex, &user.Name, &user.Phone)
+	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Sex, &user.Name, &user.Phone, &user.Role,
+		&user.CompanyID, &user.ProductPlaceID, &user.SalePlaceID, &user.TokenVersion)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -40,9 +59,9 @@ func (r *UserRepository) FindByUsername(username string) (*model.User, error) {
 }
 
 // Save 保存用户
-func (r *UserRepository) Save(username, password string) error {
-	query := "INSERT INTO user(username, password) VALUES(?, ?)"
-	_, err := r.DB.Exec(query, username, password)
+func (r *UserRepositoryImpl) Save(username, password, role string) error {
+	query := "INSERT INTO user(username, password, role) VALUES(?, ?, ?)"
+	_, err := r.DB.Exec(query, username, password, role)
 	if err != nil {
 		log.Println("保存用户失败:", err)
 		return err
@@ -51,7 +70,7 @@ func (r *UserRepository) Save(username, password string) error {
 }
 
 // Update 更新用户信息
-func (r *UserRepository) Update(user *model.User) error {
+func (r *UserRepositoryImpl) Update(user *model.User) error {
 	query := `UPDATE user SET 
               username = CASE WHEN ? != '' THEN ? ELSE username END,
               sex = CASE WHEN ? IS NOT NULL THEN ? ELSE sex END,
@@ -74,7 +93,7 @@ func (r *UserRepository) Update(user *model.User) error {
 }
 
 // UpdatePassword 更新密码
-func (r *UserRepository) UpdatePassword(userID int, newPassword string) error {
+func (r *UserRepositoryImpl) UpdatePassword(userID int, newPassword string) error {
 	query := "UPDATE user SET password = ? WHERE id = ?"
 	_, err := r.DB.Exec(query, newPassword, userID)
 	if err != nil {
@@ -85,12 +104,16 @@ func (r *UserRepository) UpdatePassword(userID int, newPassword string) error {
 }
 
 // GetByID 根据ID获取用户
-func (r *UserRepository) GetByID(id int) (*model.User, error) {
-	query := "SELECT id, username, password, sex, name, phone FROM user WHERE id = ?"
+func (r *UserRepositoryImpl) GetByID(id int) (*model.User, error) {
+	query := `SELECT id, username, password, sex, name, phone, role,
+              COALESCE(company_id, 0), COALESCE(product_place_id, 0), COALESCE(sale_place_id, 0),
+              token_version
+              FROM user WHERE id = ?`
 	row := r.DB.QueryRow(query, id)
 
 	user := &model.User{}
-	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Sex, &user.Name, &user.Phone)
+	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Sex, &user.Name, &user.Phone, &user.Role,
+		&user.CompanyID, &user.ProductPlaceID, &user.SalePlaceID, &user.TokenVersion)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -101,3 +124,84 @@ func (r *UserRepository) GetByID(id int) (*model.User, error) {
 
 	return user, nil
 }
+
+// UpdateRole 更新用户角色
+func (r *UserRepositoryImpl) UpdateRole(userID int, role string) error {
+	query := "UPDATE user SET role = ? WHERE id = ?"
+	_, err := r.DB.Exec(query, role, userID)
+	if err != nil {
+		log.Println("更新用户角色失败:", err)
+		return err
+	}
+	return nil
+}
+
+// UpdateBinding 更新用户绑定的公司、生产地、销售地(0表示解除绑定)
+func (r *UserRepositoryImpl) UpdateBinding(userID, companyID, productPlaceID, salePlaceID int) error {
+	query := "UPDATE user SET company_id = ?, product_place_id = ?, sale_place_id = ? WHERE id = ?"
+	_, err := r.DB.Exec(query, nullableID(companyID), nullableID(productPlaceID), nullableID(salePlaceID), userID)
+	if err != nil {
+		log.Println("更新用户绑定失败:", err)
+		return err
+	}
+	return nil
+}
+
+// IncrementTokenVersion 递增用户令牌版本，使已签发的访问令牌全部失效
+func (r *UserRepositoryImpl) IncrementTokenVersion(userID int) error {
+	_, err := r.DB.Exec("UPDATE user SET token_version = token_version + 1 WHERE id = ?", userID)
+	if err != nil {
+		log.Println("更新令牌版本失败:", err)
+		return err
+	}
+	return nil
+}
+
+// Count 统计用户总数
+func (r *UserRepositoryImpl) Count() (int64, error) {
+	var total int64
+	err := r.DB.QueryRow("SELECT COUNT(*) FROM user").Scan(&total)
+	if err != nil {
+		log.Println("统计用户数失败:", err)
+		return 0, err
+	}
+	return total, nil
+}
+
+// CountByRole 统计指定角色的用户数，在事务中锁定统计的行，避免并发修改角色时同时通过检查
+func (r *UserRepositoryImpl) CountByRole(role string) (int64, error) {
+	var total int64
+	err := r.DB.QueryRow("SELECT COUNT(*) FROM user WHERE role = ?"+r.DB.Dialect.ForUpdate(), role).Scan(&total)
+	if err != nil {
+		log.Println("统计用户数失败:", err)
+		return 0, err
+	}
+	return total, nil
+}
+
+// FindAll 查找所有用户(不含密码)
+func (r *UserRepositoryImpl) FindAll() ([]*model.User, error) {
+	query := `SELECT id, username, COALESCE(sex, ''), COALESCE(name, ''), COALESCE(phone, ''), role,
+              COALESCE(company_id, 0), COALESCE(product_place_id, 0), COALESCE(sale_place_id, 0)
+              FROM user`
+	rows, err := r.DB.Query(query)
+	if err != nil {
+		log.Println("查询用户失败:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*model.User
+	for rows.Next() {
+		user := &model.User{}
+		err := rows.Scan(&user.ID, &user.Username, &user.Sex, &user.Name, &user.Phone, &user.Role,
+			&user.CompanyID, &user.ProductPlaceID, &user.SalePlaceID)
+		if err != nil {
+			log.Println("读取用户数据失败:", err)
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
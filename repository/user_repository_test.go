@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"agricultural_product_gin/model"
+)
+
+func TestUserRepository(t *testing.T) {
+	db := newTestDB(t)
+	f := seed(t, db)
+	repo := NewUserRepository(db)
+
+	mustNoError(t, repo.Save("admin", "hash-admin", model.RoleAdmin))
+	mustNoError(t, repo.Save("farmer", "hash-farmer", model.RoleFarmer))
+
+	user, err := repo.FindByUsername("farmer")
+	mustNoError(t, err)
+	if user == nil || user.Password != "hash-farmer" || user.Role != model.RoleFarmer || user.Name.String != "" || user.ProductPlaceID != 0 {
+		t.Fatalf("FindByUsername = %+v", user)
+	}
+	missing, err := repo.FindByUsername("nobody")
+	mustNoError(t, err)
+	if missing != nil {
+		t.Fatalf("不存在的用户FindByUsername = %+v", missing)
+	}
+
+	// 只更新非空字段
+	mustNoError(t, repo.Update(&model.User{ID: user.ID, Name: sql.NullString{String: "张三", Valid: true}}))
+	mustNoError(t, repo.UpdatePassword(user.ID, "hash-new"))
+	mustNoError(t, repo.UpdateRole(user.ID, model.RoleLogistics))
+	mustNoError(t, repo.UpdateBinding(user.ID, f.Companies[1], 0, 0))
+	mustNoError(t, repo.IncrementTokenVersion(user.ID))
+	mustNoError(t, repo.IncrementTokenVersion(user.ID))
+
+	got, err := repo.GetByID(user.ID)
+	mustNoError(t, err)
+	if got.Username != "farmer" || got.Name.String != "张三" || got.Phone.Valid || got.Password != "hash-new" ||
+		got.Role != model.RoleLogistics || got.CompanyID != f.Companies[1] || got.ProductPlaceID != 0 || got.TokenVersion != 2 {
+		t.Fatalf("更新后GetByID = %+v", got)
+	}
+
+	// 解除绑定
+	mustNoError(t, repo.UpdateBinding(user.ID, 0, 0, 0))
+	got, err = repo.GetByID(user.ID)
+	mustNoError(t, err)
+	if got.CompanyID != 0 {
+		t.Fatalf("解除绑定后CompanyID = %d", got.CompanyID)
+	}
+
+	total, err := repo.Count()
+	mustNoError(t, err)
+	admins, err := repo.CountByRole(model.RoleAdmin)
+	mustNoError(t, err)
+	farmers, err := repo.CountByRole(model.RoleFarmer)
+	mustNoError(t, err)
+	if total != 2 || admins != 1 || farmers != 0 {
+		t.Fatalf("Count = %d, admin = %d, farmer = %d", total, admins, farmers)
+	}
+
+	users, err := repo.FindAll()
+	mustNoError(t, err)
+	assertIDs(t, users, func(u *model.User) int { return u.ID }, []int{users[0].ID, user.ID})
+	if users[1].Name.String != "张三" || users[1].Password != "" {
+		t.Fatalf("FindAll = %+v", users[1])
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+// AuditService 审计日志服务
+type AuditService struct {
+	AuditRepo repository.AuditRepository
+}
+
+// NewAuditService 创建审计日志服务
+func NewAuditService(auditRepo repository.AuditRepository) *AuditService {
+	return &AuditService{AuditRepo: auditRepo}
+}
+
+// PageQuery 按实体、操作人、操作及时间范围分页查询审计日志
+func (s *AuditService) PageQuery(queryDTO *model.AuditLogPageQueryDTO) *dto.Result {
+	if queryDTO.Page <= 0 {
+		queryDTO.Page = 1
+	}
+	if queryDTO.Size <= 0 {
+		queryDTO.Size = 10
+	}
+	if queryDTO.Start != nil && queryDTO.End != nil && !queryDTO.Start.Before(*queryDTO.End) {
+		return errorResult(400, "开始时间必须早于结束时间")
+	}
+
+	auditLogs, total, err := s.AuditRepo.PageQuery(queryDTO)
+	if err != nil {
+		log.Println("分页查询审计日志失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	return successResult("查询成功", dto.NewPageResult(total, auditLogs, queryDTO.Page, queryDTO.Size))
+}
+
+// recordAudit 记录一次数据变更，before/after为变更前后的数据快照，nil表示没有
+// 在事务中调用时审计日志与业务数据一起提交或回滚
+func recordAudit(repo repository.AuditRepository, actor model.Actor, entity string, entityID int, action string,
+	before, after interface{}) error {
+	auditLog := &model.AuditLog{
+		ActorID:    actor.UserID,
+		Actor:      actor.Username,
+		Entity:     entity,
+		EntityID:   entityID,
+		Action:     action,
+		CreateTime: time.Now(),
+	}
+
+	var err error
+	if auditLog.Before, err = auditSnapshot(before); err != nil {
+		return err
+	}
+	if auditLog.After, err = auditSnapshot(after); err != nil {
+		return err
+	}
+	return repo.Save(auditLog)
+}
+
+// auditSnapshot 将数据快照序列化为JSON，nil(含nil指针)返回空
+func auditSnapshot(data interface{}) (json.RawMessage, error) {
+	if data == nil {
+		return nil, nil
+	}
+	snapshot, err := json.Marshal(data)
+	if err != nil || bytes.Equal(snapshot, []byte("null")) {
+		return nil, err
+	}
+	return snapshot, nil
+}
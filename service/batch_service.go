@@ -0,0 +1,447 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+	"agricultural_product_gin/utils"
+)
+
+var (
+	ErrBatchNotFound         = errors.New("生产批次不存在")
+	ErrBatchForbidden        = errors.New("无权操作该生产批次")
+	ErrBatchQuantityInvalid  = errors.New("数量必须大于0")
+	ErrBatchQuantityExceeded = errors.New("超出批次剩余可分配数量")
+	ErrBatchQuantityBelow    = errors.New("批次数量不能小于已分配及已拆分/合并的数量")
+	ErrBatchNoExists         = errors.New("批次号已存在")
+	ErrBatchMergeSources     = errors.New("合并至少需要两个不同的来源批次")
+	ErrBatchMergeMismatch    = errors.New("只能合并同一产品、同一生产地、同一单位的批次")
+)
+
+// BatchService 生产批次服务，负责批次的拆分、合并及谱系查询
+type BatchService struct {
+	repos *repository.Repositories
+	uow   repository.UnitOfWork
+}
+
+// NewBatchService 创建生产批次服务
+func NewBatchService(repos *repository.Repositories, uow repository.UnitOfWork) *BatchService {
+	return &BatchService{repos: repos, uow: uow}
+}
+
+// Split 将批次拆分为多个新批次，新批次沿用来源批次的产品、生产地、种子来源和日期
+// 拆分数量之和不能超过来源批次的剩余可分配数量
+func (s *BatchService) Split(scope model.DataScope, id int, splitDTO *dto.BatchSplitDTO) *dto.Result {
+	var batches []*dto.BatchVO
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		parent, err := getBatch(repos, scope, id)
+		if err != nil {
+			return err
+		}
+
+		total := 0.0
+		for _, child := range splitDTO.Children {
+			if child.Quantity <= 0 {
+				return ErrBatchQuantityInvalid
+			}
+			total += child.Quantity
+		}
+		if err := checkBatchAllocation(repos.Batch, id, &total, 0); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, child := range splitDTO.Children {
+			description := child.Description
+			if description == "" {
+				description = parent.Description
+			}
+			production := &model.ProductionInfo{
+				BatchNo:        child.BatchNo,
+				ProductID:      parent.ProductID,
+				ProductPlaceID: parent.ProductPlaceID,
+				SeedSource:     parent.SeedSource,
+				Description:    description,
+				PlantingDate:   parent.PlantingDate,
+				HarvestDate:    parent.HarvestDate,
+				Quantity:       child.Quantity,
+				Unit:           parent.Unit,
+			}
+			batch, err := saveDerivedBatch(repos, scope.Actor, model.AuditActionSplit, production, []*model.BatchLineage{
+				{ParentID: id, Kind: model.BatchLineageSplit, Quantity: child.Quantity, CreateTime: now},
+			})
+			if err != nil {
+				return err
+			}
+			batches = append(batches, batch)
+		}
+		return nil
+	})
+	if err != nil {
+		return batchErrorResult(err, "拆分批次失败")
+	}
+
+	return successResult("拆分成功", batches)
+}
+
+// Merge 将多个批次合并为一个新批次，新批次数量为各来源批次转入数量之和
+// 来源批次须为同一产品、同一生产地、同一单位，未指定转入数量时转入全部剩余数量
+func (s *BatchService) Merge(scope model.DataScope, mergeDTO *dto.BatchMergeDTO) *dto.Result {
+	// 按ID顺序锁定来源批次，避免并发合并时互相等待
+	sources := append([]dto.BatchMergeSourceDTO(nil), mergeDTO.Sources...)
+	sort.Slice(sources, func(i, j int) bool { return sources[i].ProductInfoID < sources[j].ProductInfoID })
+	for i := 1; i < len(sources); i++ {
+		if sources[i].ProductInfoID == sources[i-1].ProductInfoID {
+			return errorResult(400, ErrBatchMergeSources.Error())
+		}
+	}
+	if len(sources) < 2 {
+		return errorResult(400, ErrBatchMergeSources.Error())
+	}
+
+	var batch *dto.BatchVO
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		now := time.Now()
+		var merged *model.ProductionInfo
+		var seeds []string
+		var lineages []*model.BatchLineage
+		for _, source := range sources {
+			parent, err := getBatch(repos, scope, source.ProductInfoID)
+			if err != nil {
+				return err
+			}
+
+			usage, err := repos.Batch.Usage(parent.ID, 0)
+			if err != nil {
+				return err
+			}
+			if usage == nil {
+				return ErrBatchNotFound
+			}
+			quantity := usage.Available()
+			if source.Quantity != nil {
+				quantity = *source.Quantity
+			}
+			if quantity <= 0 {
+				return ErrBatchQuantityInvalid
+			}
+			if !usage.CanUse(quantity) {
+				return fmt.Errorf("%w: 批次%s剩余%.3f%s", ErrBatchQuantityExceeded, parent.BatchNo, usage.Available(), parent.Unit)
+			}
+
+			if merged == nil {
+				merged = &model.ProductionInfo{
+					BatchNo:        mergeDTO.BatchNo,
+					ProductID:      parent.ProductID,
+					ProductPlaceID: parent.ProductPlaceID,
+					Description:    mergeDTO.Description,
+					PlantingDate:   parent.PlantingDate,
+					HarvestDate:    parent.HarvestDate,
+					Unit:           parent.Unit,
+				}
+			} else if parent.ProductID != merged.ProductID || parent.ProductPlaceID != merged.ProductPlaceID ||
+				parent.Unit != merged.Unit {
+				return ErrBatchMergeMismatch
+			}
+
+			// 合并后的批次取最早的播种时间和最晚的收获时间
+			if parent.PlantingDate.Before(merged.PlantingDate) {
+				merged.PlantingDate = parent.PlantingDate
+			}
+			if parent.HarvestDate.After(merged.HarvestDate) {
+				merged.HarvestDate = parent.HarvestDate
+			}
+			if !containsString(seeds, parent.SeedSource) {
+				seeds = append(seeds, parent.SeedSource)
+			}
+			merged.Quantity += quantity
+			lineages = append(lineages, &model.BatchLineage{
+				ParentID: parent.ID, Kind: model.BatchLineageMerge, Quantity: quantity, CreateTime: now,
+			})
+		}
+		merged.SeedSource = strings.Join(seeds, "、")
+
+		var err error
+		batch, err = saveDerivedBatch(repos, scope.Actor, model.AuditActionMerge, merged, lineages)
+		return err
+	})
+	if err != nil {
+		return batchErrorResult(err, "合并批次失败")
+	}
+
+	return successResult("合并成功", batch)
+}
+
+// Lineage 查询批次的完整谱系：来源批次、产出批次、数量使用情况及分配了数量的物流
+func (s *BatchService) Lineage(scope model.DataScope, id int) *dto.Result {
+	batch, err := s.repos.Production.GetByID(id)
+	if err != nil {
+		log.Println("查询生产批次失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if batch == nil || !scope.AllowProductPlace(batch.ProductPlaceID) {
+		return errorResult(404, ErrBatchNotFound.Error())
+	}
+
+	view, err := s.buildLineage(batch)
+	if err != nil {
+		log.Println("查询批次谱系失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	return successResult("查询成功", view)
+}
+
+// buildLineage 构建批次谱系视图
+func (s *BatchService) buildLineage(batch *model.ProductionInfoWithDetails) (*model.BatchLineageView, error) {
+	view := &model.BatchLineageView{Batch: batch, Shipments: []*model.Logistics{}}
+
+	usage, err := s.repos.Batch.Usage(batch.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if usage != nil {
+		view.Usage = usage
+		view.Available = usage.Available()
+	}
+
+	view.Ancestors, err = batchAncestors(s.repos.Batch, s.repos.Production, batch.ID, map[int]bool{batch.ID: true})
+	if err != nil {
+		return nil, err
+	}
+	view.Descendants, err = batchDescendants(s.repos.Batch, s.repos.Production, batch.ID, map[int]bool{batch.ID: true})
+	if err != nil {
+		return nil, err
+	}
+
+	shipments, err := s.repos.Logistics.FindByProductInfoID(batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, shipment := range shipments {
+		if shipment.Quantity != nil {
+			view.Shipments = append(view.Shipments, shipment)
+		}
+	}
+	return view, nil
+}
+
+// getBatch 获取批次并校验数据权限
+func getBatch(repos *repository.Repositories, scope model.DataScope, id int) (*model.ProductionInfoWithDetails, error) {
+	batch, err := repos.Production.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, ErrBatchNotFound
+	}
+	if !scope.AllowProductPlace(batch.ProductPlaceID) {
+		return nil, ErrBatchForbidden
+	}
+	return batch, nil
+}
+
+// saveDerivedBatch 保存拆分/合并产生的批次及其谱系，并以新批次记录审计日志
+func saveDerivedBatch(repos *repository.Repositories, actor model.Actor, action string, production *model.ProductionInfo,
+	lineages []*model.BatchLineage) (*dto.BatchVO, error) {
+	if err := assignBatchNo(repos.Batch, production); err != nil {
+		return nil, err
+	}
+	id, err := repos.Production.Save(production)
+	if err != nil {
+		return nil, err
+	}
+	for _, lineage := range lineages {
+		lineage.ChildID = id
+		if _, err := repos.Batch.SaveLineage(lineage); err != nil {
+			return nil, err
+		}
+	}
+
+	production.ID = id
+	snapshot := struct {
+		*model.ProductionInfo
+		Lineages []*model.BatchLineage `json:"lineages"`
+	}{production, lineages}
+	if err := recordAudit(repos.Audit, actor, model.AuditEntityProduction, id, action, nil, snapshot); err != nil {
+		return nil, err
+	}
+	return &dto.BatchVO{ProductInfoID: id, BatchNo: production.BatchNo, Quantity: production.Quantity, Unit: production.Unit}, nil
+}
+
+// assignBatchNo 校验指定的批次号未被占用，未指定时按收获日期生成
+func assignBatchNo(batches repository.BatchRepository, production *model.ProductionInfo) error {
+	if production.Unit == "" {
+		production.Unit = model.DefaultBatchUnit
+	}
+	if production.BatchNo != "" {
+		exists, err := batches.BatchNoExists(production.BatchNo)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrBatchNoExists
+		}
+		return nil
+	}
+
+	batchNo, err := utils.GenerateBatchNo(production.HarvestDate)
+	if err != nil {
+		return err
+	}
+	production.BatchNo = batchNo
+	return nil
+}
+
+// checkBatchAllocation 校验批次剩余数量足够分配，quantity为空表示不分配，不做校验
+// 在事务中调用时批次会被锁定到事务结束，校验后的写入不会与其他分配冲突
+func checkBatchAllocation(batches repository.BatchRepository, productInfoID int, quantity *float64, excludeLogisticsID int) error {
+	if quantity == nil {
+		return nil
+	}
+	if *quantity <= 0 {
+		return ErrBatchQuantityInvalid
+	}
+
+	usage, err := batches.Usage(productInfoID, excludeLogisticsID)
+	if err != nil {
+		return err
+	}
+	if usage == nil {
+		return ErrBatchNotFound
+	}
+	if !usage.CanUse(*quantity) {
+		return fmt.Errorf("%w: 剩余%.3f", ErrBatchQuantityExceeded, usage.Available())
+	}
+	return nil
+}
+
+// batchAncestors 逐层向上查询来源批次，visited防止异常数据形成环时无限递归
+func batchAncestors(batches repository.BatchRepository, productions repository.ProductionRepository, id int, visited map[int]bool) ([]*model.BatchNode, error) {
+	lineages, err := batches.FindParents(id)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []*model.BatchNode{}
+	for _, lineage := range lineages {
+		if visited[lineage.ParentID] {
+			continue
+		}
+		visited[lineage.ParentID] = true
+
+		node, err := batchNode(productions, lineage.ParentID, lineage)
+		if err != nil {
+			return nil, err
+		}
+		if node.Parents, err = batchAncestors(batches, productions, lineage.ParentID, visited); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// batchDescendants 逐层向下查询产出批次
+func batchDescendants(batches repository.BatchRepository, productions repository.ProductionRepository, id int, visited map[int]bool) ([]*model.BatchNode, error) {
+	lineages, err := batches.FindChildren(id)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []*model.BatchNode{}
+	for _, lineage := range lineages {
+		if visited[lineage.ChildID] {
+			continue
+		}
+		visited[lineage.ChildID] = true
+
+		node, err := batchNode(productions, lineage.ChildID, lineage)
+		if err != nil {
+			return nil, err
+		}
+		if node.Children, err = batchDescendants(batches, productions, lineage.ChildID, visited); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// batchNode 构建谱系节点，批次已被删除时只保留ID和转移数量
+func batchNode(productions repository.ProductionRepository, id int, lineage *model.BatchLineage) (*model.BatchNode, error) {
+	node := &model.BatchNode{ProductInfoID: id, Kind: lineage.Kind, LinkQuantity: lineage.Quantity}
+	batch, err := productions.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if batch != nil {
+		node.BatchNo = batch.BatchNo
+		node.ProductName = batch.ProductName
+		node.ProductionPlace = batch.ProductionPlace
+		node.Quantity = batch.Quantity
+		node.Unit = batch.Unit
+	}
+	return node, nil
+}
+
+// batchNodeIDs 谱系中所有批次的ID
+func batchNodeIDs(nodes []*model.BatchNode) []int {
+	var ids []int
+	for _, node := range nodes {
+		ids = append(ids, node.ProductInfoID)
+		ids = append(ids, batchNodeIDs(node.Parents)...)
+		ids = append(ids, batchNodeIDs(node.Children)...)
+	}
+	return ids
+}
+
+// batchErrorResult 将批次相关错误转换为响应结果
+func batchErrorResult(err error, msg string) *dto.Result {
+	switch {
+	case errors.Is(err, ErrBatchNotFound):
+		return errorResult(404, err.Error())
+	case errors.Is(err, ErrBatchForbidden):
+		return errorResult(403, err.Error())
+	case errors.Is(err, ErrBatchQuantityExceeded), errors.Is(err, ErrBatchQuantityBelow), errors.Is(err, ErrBatchNoExists):
+		return errorResult(409, err.Error())
+	case errors.Is(err, ErrBatchQuantityInvalid), errors.Is(err, ErrBatchMergeSources), errors.Is(err, ErrBatchMergeMismatch):
+		return errorResult(400, err.Error())
+	default:
+		if result := referenceErrorResult(err); result != nil {
+			return result
+		}
+		log.Println(msg+":", err)
+		return errorResult(500, msg)
+	}
+}
+
+// isBatchError 是否为批次校验产生的错误(而非数据库错误)
+func isBatchError(err error) bool {
+	for _, target := range []error{
+		ErrBatchNotFound, ErrBatchForbidden, ErrBatchQuantityInvalid, ErrBatchQuantityExceeded,
+		ErrBatchQuantityBelow, ErrBatchNoExists, ErrBatchMergeSources, ErrBatchMergeMismatch,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString 切片中是否包含指定字符串
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
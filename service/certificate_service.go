@@ -0,0 +1,304 @@
+package service
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/utils"
+)
+
+// 溯源证书相关错误
+var (
+	ErrCertificateNotFound   = errors.New("销售信息不存在")
+	ErrCertificateIncomplete = errors.New("溯源链不完整，无法签发证书")
+	ErrCertificateFormat     = errors.New("证书格式错误")
+	ErrCertificateAlgorithm  = errors.New("不支持的签名算法")
+	ErrCertificateKeyID      = errors.New("证书不是由该公钥签发的")
+	ErrCertificateSignature  = errors.New("证书签名无效，内容可能已被篡改")
+	ErrCertificateTampered   = errors.New("溯源记录与哈希链不一致，无法签发证书")
+)
+
+// certificateTimeLayout 证书PDF中的时间格式
+const certificateTimeLayout = "2006-01-02 15:04"
+
+// CertificateService 溯源证书服务：签发带Ed25519签名的溯源证书
+type CertificateService struct {
+	TraceService *TraceabilityService
+	SigningKey   ed25519.PrivateKey
+	Issuer       string
+}
+
+// NewCertificateService 创建溯源证书服务，issuer为签发方的对外访问地址
+func NewCertificateService(traceService *TraceabilityService, signingKey ed25519.PrivateKey, issuer string) *CertificateService {
+	return &CertificateService{
+		TraceService: traceService,
+		SigningKey:   signingKey,
+		Issuer:       issuer,
+	}
+}
+
+// PublicKey 查询校验证书所用的公钥
+func (s *CertificateService) PublicKey() *dto.Result {
+	publicKey := s.SigningKey.Public().(ed25519.PublicKey)
+	pemData, err := utils.EncodePublicKeyPEM(publicKey)
+	if err != nil {
+		log.Println("编码公钥失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	return successResult("查询成功", &model.CertificatePublicKey{
+		Algorithm: model.CertificateAlgorithm,
+		KeyID:     utils.SigningKeyID(publicKey),
+		PublicKey: base64.StdEncoding.EncodeToString(publicKey),
+		PEM:       string(pemData),
+	})
+}
+
+// Issue 签发销售记录的溯源证书(JSON)
+func (s *CertificateService) Issue(saleInfoID int) ([]byte, error) {
+	signed, _, err := s.issue(saleInfoID)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(signed, "", "  ")
+}
+
+// IssuePDF 签发销售记录的溯源证书并生成可打印的PDF，PDF中附有证书编号及签名，真伪以JSON证书校验为准
+func (s *CertificateService) IssuePDF(saleInfoID int) ([]byte, error) {
+	signed, certificate, err := s.issue(saleInfoID)
+	if err != nil {
+		return nil, err
+	}
+	return renderCertificatePDF(certificate, &signed.Signature), nil
+}
+
+// issue 构建溯源链并签名
+func (s *CertificateService) issue(saleInfoID int) (*model.SignedCertificate, *model.Certificate, error) {
+	chain, err := s.TraceService.BuildChain(saleInfoID)
+	if err != nil {
+		log.Println("构建溯源链失败:", err)
+		return nil, nil, err
+	}
+	if chain == nil {
+		return nil, nil, ErrCertificateNotFound
+	}
+	if len(chain.Missing) > 0 || chain.Production == nil {
+		return nil, nil, ErrCertificateIncomplete
+	}
+	if err := s.checkHashChain(chain); err != nil {
+		return nil, nil, err
+	}
+
+	certificate := &model.Certificate{
+		Version:    model.CertificateVersion,
+		SerialNo:   strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")),
+		Issuer:     s.Issuer,
+		IssuedAt:   time.Now().UTC().Truncate(time.Second),
+		SaleInfoID: saleInfoID,
+		Chain:      chain,
+	}
+	data, err := json.Marshal(certificate)
+	if err != nil {
+		log.Println("序列化证书失败:", err)
+		return nil, nil, err
+	}
+
+	content, err := canonicalJSON(data)
+	if err != nil {
+		log.Println("规范化证书失败:", err)
+		return nil, nil, err
+	}
+
+	publicKey := s.SigningKey.Public().(ed25519.PublicKey)
+	signed := &model.SignedCertificate{
+		Certificate: data,
+		Signature: model.CertificateSignature{
+			Algorithm: model.CertificateAlgorithm,
+			KeyID:     utils.SigningKeyID(publicKey),
+			Value:     base64.StdEncoding.EncodeToString(ed25519.Sign(s.SigningKey, content)),
+		},
+	}
+	return signed, certificate, nil
+}
+
+// checkHashChain 签名前逐条核对证书中的销售、物流、生产记录与其最近一次入链的内容，不一致时返回ErrCertificateTampered
+func (s *CertificateService) checkHashChain(chain *model.TraceabilityChain) error {
+	refs := []model.HashChainRef{{Entity: model.HashChainSaleInfo, EntityID: chain.Sale.Info.ID}}
+	for _, leg := range chain.Transport {
+		refs = append(refs, model.HashChainRef{Entity: model.HashChainLogistics, EntityID: leg.Logistics.ID})
+	}
+	refs = append(refs, model.HashChainRef{Entity: model.HashChainProductInfo, EntityID: chain.Production.Info.ID})
+
+	for _, ref := range refs {
+		reason, err := s.TraceService.HashChainService.CheckRecord(ref.Entity, ref.EntityID)
+		if err != nil {
+			log.Println("核对哈希链失败:", err)
+			return err
+		}
+		if reason != "" {
+			return fmt.Errorf("%w: %s(ID %d)%s", ErrCertificateTampered, ref.Entity, ref.EntityID, reason)
+		}
+	}
+	return nil
+}
+
+// VerifyCertificate 使用公钥校验证书文件，通过时返回证书内容
+// 签名针对certificate字段的规范化JSON，证书文件重新排版(缩进、键顺序、字符转义)不影响校验
+func VerifyCertificate(publicKey ed25519.PublicKey, data []byte) (*model.Certificate, error) {
+	var signed model.SignedCertificate
+	if err := json.Unmarshal(data, &signed); err != nil || len(signed.Certificate) == 0 {
+		return nil, ErrCertificateFormat
+	}
+	if signed.Signature.Algorithm != model.CertificateAlgorithm {
+		return nil, fmt.Errorf("%w: %s", ErrCertificateAlgorithm, signed.Signature.Algorithm)
+	}
+	if signed.Signature.KeyID != utils.SigningKeyID(publicKey) {
+		return nil, ErrCertificateKeyID
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(signed.Signature.Value)
+	if err != nil {
+		return nil, ErrCertificateFormat
+	}
+	content, err := canonicalJSON(signed.Certificate)
+	if err != nil {
+		return nil, ErrCertificateFormat
+	}
+	if !ed25519.Verify(publicKey, content, signature) {
+		return nil, ErrCertificateSignature
+	}
+
+	var certificate model.Certificate
+	if err := json.Unmarshal(signed.Certificate, &certificate); err != nil {
+		return nil, ErrCertificateFormat
+	}
+	return &certificate, nil
+}
+
+// canonicalJSON 证书的规范化JSON：对象的键按字典序排列，去除空白，数字保持原文
+func canonicalJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// renderCertificatePDF 生成证书的打印版本
+func renderCertificatePDF(certificate *model.Certificate, signature *model.CertificateSignature) []byte {
+	chain := certificate.Chain
+	doc := utils.NewPDFDocument()
+
+	doc.Title("农产品溯源证书")
+	doc.Field("证书编号", certificate.SerialNo)
+	doc.Field("签发方", certificate.Issuer)
+	doc.Field("签发时间", certificate.IssuedAt.Format(time.RFC3339))
+
+	if len(chain.Recalls) > 0 {
+		doc.Heading("召回警示")
+		for _, recall := range chain.Recalls {
+			doc.Item(fmt.Sprintf("%s 发起召回：%s", recall.OpenTime.Format(certificateTimeLayout), recall.Reason))
+		}
+	}
+
+	production := chain.Production
+	info := production.Info
+	doc.Heading("一、生产环节")
+	if production.Product != nil {
+		doc.Field("产品", production.Product.Name+" ("+production.Product.Type+")")
+	}
+	doc.Field("批次号", info.BatchNo)
+	doc.Field("批次数量", fmt.Sprintf("%g %s", info.Quantity, info.Unit))
+	doc.Field("种子来源", info.SeedSource)
+	doc.Field("播种时间", info.PlantingDate.Format(certificateTimeLayout))
+	doc.Field("收获时间", info.HarvestDate.Format(certificateTimeLayout))
+	doc.Field("生产说明", info.Description)
+	if place := production.ProductionPlace; place != nil {
+		doc.Field("生产地", place.Address)
+		doc.Field("负责人", strings.TrimSpace(place.Administrator+" "+place.Phone))
+	}
+	for _, node := range production.Ancestors {
+		doc.Item(fmt.Sprintf("来源批次 %s (%s)：%g %s", node.BatchNo, node.Kind, node.LinkQuantity, node.Unit))
+	}
+	for _, activity := range production.Activities {
+		text := fmt.Sprintf("%s %s %s", activity.ActivityDate.Format(certificateTimeLayout), activity.ActivityType, activity.Material)
+		if activity.Dosage != nil {
+			text += fmt.Sprintf(" %g%s", *activity.Dosage, activity.DosageUnit)
+		}
+		doc.Item(strings.TrimSpace(text))
+	}
+	writeCertificateInspections(doc, production.Inspections)
+	writeCertificateProof(doc, info.Proof)
+
+	doc.Heading("二、运输环节")
+	for i, leg := range chain.Transport {
+		logistics := leg.Logistics
+		doc.Text(fmt.Sprintf("%d. %s → %s", i+1, logistics.StartLocation, logistics.Destination))
+		if leg.Company != nil {
+			doc.Field("物流公司", leg.Company.Name)
+		}
+		doc.Field("出发时间", logistics.StartTime.Format(certificateTimeLayout))
+		if logistics.EndTime != nil {
+			doc.Field("到达时间", logistics.EndTime.Format(certificateTimeLayout))
+		}
+		doc.Field("运输状态", logistics.Status)
+		if cold := logistics.ColdChain; cold != nil && cold.Temperature != nil {
+			doc.Field("冷链温度", fmt.Sprintf("%.1f ~ %.1f ℃，超限读数%d/%d",
+				cold.Temperature.Min, cold.Temperature.Max, cold.Excursions, cold.Count))
+		}
+		for _, event := range logistics.Events {
+			doc.Item(fmt.Sprintf("%s %s %s %s", event.EventTime.Format(certificateTimeLayout), event.EventType,
+				event.Location, event.CompanyName))
+		}
+		writeCertificateInspections(doc, leg.Inspections)
+		writeCertificateProof(doc, logistics.Proof)
+	}
+
+	doc.Heading("三、销售环节")
+	sale := chain.Sale
+	if sale.SalePlace != nil {
+		doc.Field("销售地", sale.SalePlace.Address)
+		doc.Field("负责人", strings.TrimSpace(sale.SalePlace.Administrator+" "+sale.SalePlace.Phone))
+	}
+	doc.Field("销售时间", sale.Info.SaleTime.Format(certificateTimeLayout))
+	doc.Field("销售说明", sale.Info.Description)
+	writeCertificateProof(doc, sale.Info.Proof)
+
+	doc.Heading("签名")
+	doc.Field("算法", signature.Algorithm)
+	doc.Field("公钥指纹", signature.KeyID)
+	doc.Field("签名值", signature.Value)
+	doc.Space(6)
+	doc.Text("本文件为溯源证书的打印版本。证书真伪请使用同一证书编号的JSON证书及签发方公开的公钥校验。")
+
+	return doc.Bytes()
+}
+
+// writeCertificateInspections 输出检测记录
+func writeCertificateInspections(doc *utils.PDFDocument, inspections []*model.Inspection) {
+	for _, inspection := range inspections {
+		doc.Item(fmt.Sprintf("检测 %s %s %s：%s (报告编号%s)", inspection.InspectionDate.Format(certificateTimeLayout),
+			inspection.LabName, inspection.InspectionType, inspection.Verdict, inspection.ReportNo))
+	}
+}
+
+// writeCertificateProof 输出记录在哈希链中的位置
+func writeCertificateProof(doc *utils.PDFDocument, proof *model.HashChainProof) {
+	if proof == nil {
+		return
+	}
+	doc.Field("哈希链", fmt.Sprintf("#%d %s", proof.Seq, proof.Hash))
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+const (
+	// 单次JSON上报的最大读数条数
+	maxReadingsPerBatch = 5000
+	// 汇总序列的最大时间桶数
+	coldChainSeriesBuckets = 48
+)
+
+var (
+	ErrReadingsEmpty    = errors.New("读数不能为空")
+	ErrReadingsTooMany  = fmt.Errorf("单次最多上报%d条读数", maxReadingsPerBatch)
+	ErrReadingTimeEmpty = errors.New("读数采集时间不能为空")
+)
+
+// ReadingFormatError 读数格式错误(CSV行号从1开始，包含表头)
+type ReadingFormatError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ReadingFormatError) Error() string {
+	return fmt.Sprintf("第%d行: %s", e.Line, e.Msg)
+}
+
+// ColdChainService 冷链监测服务
+type ColdChainService struct {
+	ReadingRepo    repository.SensorReadingRepository
+	LogisticsRepo  repository.LogisticsRepository
+	ProductionRepo repository.ProductionRepository
+	ProductRepo    repository.ProductRepository
+	uow            repository.UnitOfWork
+}
+
+// NewColdChainService 创建冷链监测服务
+func NewColdChainService(
+	readingRepo repository.SensorReadingRepository,
+	logisticsRepo repository.LogisticsRepository,
+	productionRepo repository.ProductionRepository,
+	productRepo repository.ProductRepository,
+	uow repository.UnitOfWork,
+) *ColdChainService {
+	return &ColdChainService{
+		ReadingRepo:    readingRepo,
+		LogisticsRepo:  logisticsRepo,
+		ProductionRepo: productionRepo,
+		ProductRepo:    productRepo,
+		uow:            uow,
+	}
+}
+
+// Ingest 批量写入物流记录的传感器读数，并按产品阈值标记超限读数
+// 返回写入条数和超限条数；审计日志按物流记录汇总记录本次导入，不逐条记录读数
+func (s *ColdChainService) Ingest(scope model.DataScope, logisticsID int, readings []*model.SensorReading) (int, int, error) {
+	logistics, err := s.writableLogistics(scope, logisticsID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return s.ingest(scope, logistics, readings)
+}
+
+// IngestJSON 写入JSON上报的读数(限制单次条数)
+func (s *ColdChainService) IngestJSON(scope model.DataScope, logisticsID int, readings []*model.SensorReading) (int, int, error) {
+	if len(readings) > maxReadingsPerBatch {
+		return 0, 0, ErrReadingsTooMany
+	}
+	return s.Ingest(scope, logisticsID, readings)
+}
+
+// IngestCSV 解析温湿度记录仪导出的CSV并写入(限制单次行数)
+// 表头需包含 time(或timestamp) 与 temperature(或temp) 列，可选 humidity、latitude(lat)、longitude(lng/lon)
+func (s *ColdChainService) IngestCSV(scope model.DataScope, logisticsID int, reader io.Reader) (int, int, error) {
+	logistics, err := s.writableLogistics(scope, logisticsID)
+	if err != nil {
+		return 0, 0, err
+	}
+	readings, err := parseReadingsCSV(reader, maxReadingsPerBatch)
+	if err != nil {
+		return 0, 0, err
+	}
+	return s.ingest(scope, logistics, readings)
+}
+
+// ingest 写入已校验权限的物流记录的读数
+func (s *ColdChainService) ingest(scope model.DataScope, logistics *model.Logistics, readings []*model.SensorReading) (int, int, error) {
+	if len(readings) == 0 {
+		return 0, 0, ErrReadingsEmpty
+	}
+
+	thresholds, err := s.thresholds(logistics)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	excursions := 0
+	for _, reading := range readings {
+		if reading.ReadingTime.IsZero() {
+			return 0, 0, ErrReadingTimeEmpty
+		}
+		reading.LogisticsID = logistics.ID
+		reading.Excursion = isExcursion(reading, thresholds)
+		if reading.Excursion {
+			excursions++
+		}
+	}
+
+	imported := struct {
+		LogisticsID int `json:"logId"`
+		Count       int `json:"count"`
+		Excursions  int `json:"excursions"`
+	}{logistics.ID, len(readings), excursions}
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.SensorReading.SaveBatch(readings); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntitySensorReading, logistics.ID, model.AuditActionImport, nil, imported)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(readings), excursions, nil
+}
+
+// ScopedSummary 汇总数据权限范围内的物流记录的冷链读数，超出范围时视为不存在
+func (s *ColdChainService) ScopedSummary(scope model.DataScope, logisticsID int) (*model.ColdChainSummary, error) {
+	logistics, err := s.LogisticsRepo.GetByID(logisticsID)
+	if err != nil {
+		return nil, err
+	}
+	if logistics == nil || !allowLogistics(scope, logistics) {
+		return nil, ErrLogisticsNotFound
+	}
+	return s.Summary(logistics)
+}
+
+// Summary 汇总物流记录的冷链读数，没有读数时返回nil；不校验数据权限，供溯源查询及已校验权限的调用方使用
+// 统计与分桶都在数据库中完成，不加载读数明细
+func (s *ColdChainService) Summary(logistics *model.Logistics) (*model.ColdChainSummary, error) {
+	summary, err := s.ReadingRepo.Summarize(logistics.ID)
+	if err != nil || summary == nil {
+		return nil, err
+	}
+	summary.Series, err = s.ReadingRepo.Series(logistics.ID, seriesBucketStarts(summary.FirstTime, summary.LastTime))
+	if err != nil {
+		return nil, err
+	}
+	if summary.Thresholds, err = s.thresholds(logistics); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// writableLogistics 获取可写入读数的物流记录：须在数据权限范围内且未结束
+func (s *ColdChainService) writableLogistics(scope model.DataScope, logisticsID int) (*model.Logistics, error) {
+	logistics, err := s.LogisticsRepo.GetByID(logisticsID)
+	if err != nil {
+		return nil, err
+	}
+	if logistics == nil {
+		return nil, ErrLogisticsNotFound
+	}
+	if !allowLogistics(scope, logistics) {
+		return nil, ErrLogisticsForbidden
+	}
+	if isFinalized(logistics) {
+		return nil, ErrLogisticsFinalized
+	}
+	return logistics, nil
+}
+
+// thresholds 通过 物流 -> 生产信息 -> 产品 获取冷链阈值
+func (s *ColdChainService) thresholds(logistics *model.Logistics) (*model.ColdChainThresholds, error) {
+	production, err := s.ProductionRepo.GetByID(logistics.ProductInfoID)
+	if err != nil {
+		return nil, err
+	}
+	if production == nil {
+		return &model.ColdChainThresholds{}, nil
+	}
+
+	product, err := s.ProductRepo.GetByID(production.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return &model.ColdChainThresholds{}, nil
+	}
+
+	return product.Thresholds(), nil
+}
+
+// isExcursion 读数是否超出阈值
+func isExcursion(reading *model.SensorReading, t *model.ColdChainThresholds) bool {
+	if t.MinTemperature != nil && reading.Temperature < *t.MinTemperature {
+		return true
+	}
+	if t.MaxTemperature != nil && reading.Temperature > *t.MaxTemperature {
+		return true
+	}
+	if reading.Humidity != nil {
+		if t.MinHumidity != nil && *reading.Humidity < *t.MinHumidity {
+			return true
+		}
+		if t.MaxHumidity != nil && *reading.Humidity > *t.MaxHumidity {
+			return true
+		}
+	}
+	return false
+}
+
+// seriesBucketStarts 把first到last的时间段等分为不超过coldChainSeriesBuckets个桶(桶宽不小于1分钟)，返回各桶的起始时间
+// 桶宽向下取整后末尾剩余的不完整时间段不再单独成桶，由最后一个桶包含
+func seriesBucketStarts(first, last time.Time) []time.Time {
+	bucketWidth := last.Sub(first) / coldChainSeriesBuckets
+	if bucketWidth < time.Minute {
+		bucketWidth = time.Minute
+	}
+
+	starts := []time.Time{first}
+	for i := 1; i < coldChainSeriesBuckets; i++ {
+		start := first.Add(time.Duration(i) * bucketWidth)
+		if start.After(last) {
+			break
+		}
+		starts = append(starts, start)
+	}
+	return starts
+}
+
+// CSV中支持的时间格式
+var readingTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006/01/02 15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04",
+}
+
+// parseReadingsCSV 解析记录仪导出的CSV，数据行超过maxRows时返回ErrReadingsTooMany(不再继续读取)
+func parseReadingsCSV(reader io.Reader, maxRows int) ([]*model.SensorReading, error) {
+	r := csv.NewReader(reader)
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, ErrReadingsEmpty
+	}
+	if err != nil {
+		return nil, &ReadingFormatError{Line: 1, Msg: err.Error()}
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		switch name {
+		case "time", "timestamp":
+			columns["time"] = i
+		case "temperature", "temp":
+			columns["temperature"] = i
+		case "humidity":
+			columns["humidity"] = i
+		case "latitude", "lat":
+			columns["latitude"] = i
+		case "longitude", "lng", "lon":
+			columns["longitude"] = i
+		}
+	}
+	if _, ok := columns["time"]; !ok {
+		return nil, &ReadingFormatError{Line: 1, Msg: "缺少time列"}
+	}
+	if _, ok := columns["temperature"]; !ok {
+		return nil, &ReadingFormatError{Line: 1, Msg: "缺少temperature列"}
+	}
+
+	var readings []*model.SensorReading
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &ReadingFormatError{Line: line, Msg: err.Error()}
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		// 跳过空行
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		readingTime, err := parseReadingTime(field("time"))
+		if err != nil {
+			return nil, &ReadingFormatError{Line: line, Msg: "时间格式错误"}
+		}
+		temperature, err := strconv.ParseFloat(field("temperature"), 64)
+		if err != nil {
+			return nil, &ReadingFormatError{Line: line, Msg: "温度格式错误"}
+		}
+
+		reading := &model.SensorReading{ReadingTime: readingTime, Temperature: temperature}
+		for name, dest := range map[string]**float64{
+			"humidity":  &reading.Humidity,
+			"latitude":  &reading.Latitude,
+			"longitude": &reading.Longitude,
+		} {
+			value := field(name)
+			if value == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, &ReadingFormatError{Line: line, Msg: name + "格式错误"}
+			}
+			*dest = &v
+		}
+
+		if len(readings) >= maxRows {
+			return nil, ErrReadingsTooMany
+		}
+		readings = append(readings, reading)
+	}
+
+	if len(readings) == 0 {
+		return nil, ErrReadingsEmpty
+	}
+	return readings, nil
+}
+
+// parseReadingTime 解析读数时间，支持常见日期格式与Unix秒时间戳
+func parseReadingTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	for _, layout := range readingTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("无法解析的时间")
+}
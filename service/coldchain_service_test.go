@@ -0,0 +1,207 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository/repotest"
+)
+
+var readingTime = time.Date(2024, 6, 21, 8, 0, 0, 0, time.UTC)
+
+// newColdChainRepos 产品1阈值0~8℃、湿度不超过90%；公司1的物流1(运输中)与物流2(已送达)，公司2的物流3
+func newColdChainRepos() *testRepos {
+	repos := newTestRepos()
+	repos.Product = repotest.NewProductRepository(&model.Product{
+		ID: 1, Name: "草莓", Type: "水果", MinTemperature: ptr(0.0), MaxTemperature: ptr(8.0), MaxHumidity: ptr(90.0),
+	})
+	repos.Production = repotest.NewProductionRepository(&model.ProductionInfoWithDetails{ProductionInfo: model.ProductionInfo{
+		ID: 1, ProductID: 1, ProductPlaceID: 2, Quantity: 100, Unit: "kg",
+	}})
+	repos.Logistics = repotest.NewLogisticsRepository(
+		&model.Logistics{ID: 1, ProductInfoID: 1, CompanyID: 1, Status: model.LogisticsStatusInTransit},
+		&model.Logistics{ID: 2, ProductInfoID: 1, CompanyID: 1, Status: model.LogisticsStatusDelivered},
+		&model.Logistics{ID: 3, ProductInfoID: 1, CompanyID: 2, Status: model.LogisticsStatusCreated},
+	)
+	return repos
+}
+
+func newColdChainService(repos *testRepos) *ColdChainService {
+	return NewColdChainService(repos.SensorReading, repos.Logistics, repos.Production, repos.Product, repos.uow())
+}
+
+// reading 采集时间为readingTime之后minutes分钟的读数
+func reading(minutes int, temperature float64, humidity *float64) *model.SensorReading {
+	return &model.SensorReading{
+		ReadingTime: readingTime.Add(time.Duration(minutes) * time.Minute), Temperature: temperature, Humidity: humidity,
+	}
+}
+
+func TestColdChainService_Ingest(t *testing.T) {
+	tests := []struct {
+		name           string
+		scope          model.DataScope
+		logisticsID    int
+		readings       []*model.SensorReading
+		auditErr       error
+		wantErr        error
+		wantCount      int
+		wantExcursions int
+	}{
+		{
+			name: "按产品阈值标记超限", scope: companyScope(1), logisticsID: 1,
+			readings: []*model.SensorReading{
+				reading(0, 4, ptr(80.0)), reading(10, 9.5, nil), reading(20, -1, nil), reading(30, 6, ptr(95.0)), reading(40, 8, ptr(90.0)),
+			},
+			wantCount: 5, wantExcursions: 3,
+		},
+		{name: "读数为空", scope: adminScope, logisticsID: 1, wantErr: ErrReadingsEmpty},
+		{
+			name: "缺少采集时间", scope: adminScope, logisticsID: 1,
+			readings: []*model.SensorReading{reading(0, 4, nil), {Temperature: 4}},
+			wantErr:  ErrReadingTimeEmpty,
+		},
+		{name: "物流不存在", scope: adminScope, logisticsID: 99, readings: []*model.SensorReading{reading(0, 4, nil)}, wantErr: ErrLogisticsNotFound},
+		{name: "其他公司的物流", scope: companyScope(1), logisticsID: 3, readings: []*model.SensorReading{reading(0, 4, nil)}, wantErr: ErrLogisticsForbidden},
+		{name: "物流已结束", scope: adminScope, logisticsID: 2, readings: []*model.SensorReading{reading(0, 4, nil)}, wantErr: ErrLogisticsFinalized},
+		{name: "审计日志写入失败时回滚", scope: adminScope, logisticsID: 1, readings: []*model.SensorReading{reading(0, 4, nil)}, auditErr: errFake, wantErr: errFake},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newColdChainRepos()
+			repos.Audit.Err = tt.auditErr
+			s := newColdChainService(repos)
+
+			count, excursions, err := s.Ingest(tt.scope, tt.logisticsID, tt.readings)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, 期望 %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repos.SensorReading.Readings) != 0 {
+					t.Fatalf("失败后仍写入了读数: %d条", len(repos.SensorReading.Readings))
+				}
+				assertAudits(t, repos)
+				return
+			}
+
+			if count != tt.wantCount || excursions != tt.wantExcursions {
+				t.Fatalf("写入 %d 条、超限 %d 条, 期望 %d、%d", count, excursions, tt.wantCount, tt.wantExcursions)
+			}
+			for _, saved := range repos.SensorReading.Readings {
+				if saved.LogisticsID != tt.logisticsID {
+					t.Fatalf("读数的物流ID = %d", saved.LogisticsID)
+				}
+			}
+			// 审计日志按物流记录汇总一条
+			assertAudits(t, repos, auditKey(model.AuditEntitySensorReading, tt.logisticsID, model.AuditActionImport))
+		})
+	}
+}
+
+func TestColdChainService_IngestCSV(t *testing.T) {
+	repos := newColdChainRepos()
+	s := newColdChainService(repos)
+
+	csv := "time,temperature,humidity\n2024-06-21 08:00:00,3.5,70\n2024-06-21 08:10:00,10,71\n"
+	count, excursions, err := s.IngestCSV(adminScope, 1, strings.NewReader(csv))
+	mustNoError(t, err)
+	if count != 2 || excursions != 1 {
+		t.Fatalf("写入 %d 条、超限 %d 条", count, excursions)
+	}
+
+	_, _, err = s.IngestCSV(adminScope, 1, strings.NewReader("time,temperature\n2024-06-21 08:00:00,abc\n"))
+	var formatErr *ReadingFormatError
+	if !errors.As(err, &formatErr) || formatErr.Line != 2 {
+		t.Fatalf("格式错误 err = %v", err)
+	}
+}
+
+func TestColdChainService_Summary(t *testing.T) {
+	repos := newColdChainRepos()
+	s := newColdChainService(repos)
+	_, _, err := s.Ingest(adminScope, 1, []*model.SensorReading{
+		reading(20, 9, nil), reading(0, 2, ptr(60.0)), reading(10, 4, ptr(80.0)),
+	})
+	mustNoError(t, err)
+
+	summary, err := s.ScopedSummary(companyScope(1), 1)
+	mustNoError(t, err)
+	if summary.Count != 3 || summary.Excursions != 1 || !summary.FirstTime.Equal(readingTime) ||
+		!summary.LastTime.Equal(readingTime.Add(20*time.Minute)) {
+		t.Fatalf("汇总 = %+v", summary)
+	}
+	if summary.Temperature.Min != 2 || summary.Temperature.Max != 9 || summary.Temperature.Avg != 5 {
+		t.Fatalf("温度统计 = %+v", summary.Temperature)
+	}
+	if summary.Humidity == nil || summary.Humidity.Avg != 70 {
+		t.Fatalf("湿度统计 = %+v", summary.Humidity)
+	}
+	if summary.Thresholds == nil || *summary.Thresholds.MaxTemperature != 8 {
+		t.Fatalf("阈值 = %+v", summary.Thresholds)
+	}
+
+	// 超出数据权限时视为不存在，没有读数时返回nil
+	if _, err := s.ScopedSummary(companyScope(2), 1); !errors.Is(err, ErrLogisticsNotFound) {
+		t.Fatalf("其他公司 err = %v", err)
+	}
+	if summary, err := s.Summary(&model.Logistics{ID: 3, ProductInfoID: 1}); err != nil || summary != nil {
+		t.Fatalf("没有读数 = %+v, %v", summary, err)
+	}
+}
+
+func TestColdChainService_Summary_Series(t *testing.T) {
+	repos := newColdChainRepos()
+	s := newColdChainService(repos)
+	_, _, err := s.Ingest(adminScope, 1, []*model.SensorReading{
+		reading(0, 2, nil), reading(30, 4, nil), reading(47*60+30, 6, nil), reading(48*60, 10, nil),
+	})
+	mustNoError(t, err)
+
+	// 时间跨度48小时，桶宽1小时；最后一条读数并入第48个桶，不单独成桶
+	summary, err := s.ScopedSummary(adminScope, 1)
+	mustNoError(t, err)
+	if len(summary.Series) != 2 {
+		t.Fatalf("汇总序列 = %d个桶, 期望 2个", len(summary.Series))
+	}
+	first, last := summary.Series[0], summary.Series[1]
+	if !first.Time.Equal(readingTime) || first.Count != 2 || first.AvgTemperature != 3 || first.Excursion {
+		t.Fatalf("第一个桶 = %+v", first)
+	}
+	if !last.Time.Equal(readingTime.Add(47*time.Hour)) || last.Count != 2 || last.MinTemperature != 6 ||
+		last.MaxTemperature != 10 || !last.Excursion {
+		t.Fatalf("最后一个桶 = %+v", last)
+	}
+}
+
+func TestSeriesBucketStarts(t *testing.T) {
+	tests := []struct {
+		name      string
+		span      time.Duration
+		wantLen   int
+		wantWidth time.Duration
+	}{
+		{name: "只有一个时间点", span: 0, wantLen: 1},
+		{name: "不足1分钟", span: 30 * time.Second, wantLen: 1},
+		{name: "桶宽不小于1分钟", span: 20 * time.Minute, wantLen: 21, wantWidth: time.Minute},
+		{name: "正好等分", span: 48 * time.Hour, wantLen: coldChainSeriesBuckets, wantWidth: time.Hour},
+		{name: "略大于最小桶宽", span: 49 * time.Minute, wantLen: coldChainSeriesBuckets, wantWidth: 49 * time.Minute / 48},
+		{name: "桶宽向下取整后有剩余", span: 48*time.Hour + 47, wantLen: coldChainSeriesBuckets, wantWidth: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			starts := seriesBucketStarts(readingTime, readingTime.Add(tt.span))
+			if len(starts) != tt.wantLen || !starts[0].Equal(readingTime) {
+				t.Fatalf("starts = %v", starts)
+			}
+			if len(starts) > 1 && starts[1].Sub(starts[0]) != tt.wantWidth {
+				t.Fatalf("桶宽 = %v, 期望 %v", starts[1].Sub(starts[0]), tt.wantWidth)
+			}
+			if starts[len(starts)-1].After(readingTime.Add(tt.span)) {
+				t.Fatalf("最后一个桶 %v 晚于最后一条读数", starts[len(starts)-1])
+			}
+		})
+	}
+}
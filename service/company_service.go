@@ -2,6 +2,7 @@ package service
 
 import (
 	"log"
+	"time"
 
 	"agricultural_product_gin/dto"
 	"agricultural_product_gin/model"
@@ -10,16 +11,17 @@ import (
 
 // CompanyService 公司服务
 type CompanyService struct {
-	CompanyRepo *repository.CompanyRepository
+	CompanyRepo repository.CompanyRepository
+	uow         repository.UnitOfWork
 }
 
 // NewCompanyService 创建公司服务
-func NewCompanyService(companyRepo *repository.CompanyRepository) *CompanyService {
-	return &CompanyService{CompanyRepo: companyRepo}
+func NewCompanyService(companyRepo repository.CompanyRepository, uow repository.UnitOfWork) *CompanyService {
+	return &CompanyService{CompanyRepo: companyRepo, uow: uow}
 }
 
 // CreateCompany 创建公司
-func (s *CompanyService) CreateCompany(companyDTO *dto.CompanyDTO) *dto.Result {
+func (s *CompanyService) CreateCompany(scope model.DataScope, companyDTO *dto.CompanyDTO) *dto.Result {
 	// 转换DTO为模型
 	company := &model.Company{
 		Name:          companyDTO.Name,
@@ -29,7 +31,15 @@ func (s *CompanyService) CreateCompany(companyDTO *dto.CompanyDTO) *dto.Result {
 	}
 
 	// 保存公司
-	id, err := s.CompanyRepo.Save(company)
+	var id int
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		var err error
+		if id, err = repos.Company.Save(company); err != nil {
+			return err
+		}
+		company.ID = id
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityCompany, id, model.AuditActionCreate, nil, company)
+	})
 	if err != nil {
 		log.Println("创建公司失败:", err)
 		return errorResult(500, "创建公司失败")
@@ -40,7 +50,7 @@ func (s *CompanyService) CreateCompany(companyDTO *dto.CompanyDTO) *dto.Result {
 }
 
 // UpdateCompany 更新公司
-func (s *CompanyService) UpdateCompany(companyDTO *dto.CompanyDTO) *dto.Result {
+func (s *CompanyService) UpdateCompany(scope model.DataScope, companyDTO *dto.CompanyDTO) *dto.Result {
 	// 检查公司是否存在
 	existingCompany, err := s.CompanyRepo.GetByID(companyDTO.ID)
 	if err != nil {
@@ -51,6 +61,12 @@ func (s *CompanyService) UpdateCompany(companyDTO *dto.CompanyDTO) *dto.Result {
 	if existingCompany == nil {
 		return errorResult(404, "公司不存在")
 	}
+	if !scope.AllowCompany(existingCompany.ID) {
+		return errorResult(403, "只能修改绑定的公司")
+	}
+	if existingCompany.Deleted() {
+		return errorResult(409, "公司已删除，请先恢复")
+	}
 
 	// 转换DTO为模型
 	company := &model.Company{
@@ -62,7 +78,12 @@ func (s *CompanyService) UpdateCompany(companyDTO *dto.CompanyDTO) *dto.Result {
 	}
 
 	// 更新公司
-	err = s.CompanyRepo.Update(company)
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.Company.Update(company); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityCompany, company.ID, model.AuditActionUpdate, existingCompany, company)
+	})
 	if err != nil {
 		log.Println("更新公司失败:", err)
 		return errorResult(500, "更新失败")
@@ -71,8 +92,8 @@ func (s *CompanyService) UpdateCompany(companyDTO *dto.CompanyDTO) *dto.Result {
 	return successResult("更新成功", nil)
 }
 
-// DeleteCompany 删除公司
-func (s *CompanyService) DeleteCompany(id int) *dto.Result {
+// DeleteCompany 删除公司(软删除)，已有物流记录中的公司信息仍可用于溯源
+func (s *CompanyService) DeleteCompany(scope model.DataScope, id int) *dto.Result {
 	// 检查公司是否存在
 	existingCompany, err := s.CompanyRepo.GetByID(id)
 	if err != nil {
@@ -83,9 +104,20 @@ func (s *CompanyService) DeleteCompany(id int) *dto.Result {
 	if existingCompany == nil {
 		return errorResult(404, "公司不存在")
 	}
+	if !scope.AllowCompany(existingCompany.ID) {
+		return errorResult(403, "只能删除绑定的公司")
+	}
+	if existingCompany.Deleted() {
+		return errorResult(409, "公司已删除")
+	}
 
 	// 删除公司
-	err = s.CompanyRepo.Delete(id)
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.Company.Delete(id, time.Now(), scope.Actor.Username); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityCompany, id, model.AuditActionDelete, existingCompany, nil)
+	})
 	if err != nil {
 		log.Println("删除公司失败:", err)
 		return errorResult(500, "删除失败")
@@ -94,7 +126,38 @@ func (s *CompanyService) DeleteCompany(id int) *dto.Result {
 	return successResult("删除成功", nil)
 }
 
-// GetCompanyByID 根据ID获取公司
+// RestoreCompany 恢复已删除的公司
+func (s *CompanyService) RestoreCompany(scope model.DataScope, id int) *dto.Result {
+	existingCompany, err := s.CompanyRepo.GetByID(id)
+	if err != nil {
+		log.Println("查询公司失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	if existingCompany == nil {
+		return errorResult(404, "公司不存在")
+	}
+	if !existingCompany.Deleted() {
+		return errorResult(409, "公司未删除")
+	}
+
+	restored := *existingCompany
+	restored.SoftDelete = model.SoftDelete{}
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.Company.Restore(id); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityCompany, id, model.AuditActionRestore, existingCompany, &restored)
+	})
+	if err != nil {
+		log.Println("恢复公司失败:", err)
+		return errorResult(500, "恢复失败")
+	}
+
+	return successResult("恢复成功", nil)
+}
+
+// GetCompanyByID 根据ID获取公司，已删除的公司同样返回(含删除时间及删除人)
 func (s *CompanyService) GetCompanyByID(id int) *dto.Result {
 	company, err := s.CompanyRepo.GetByID(id)
 	if err != nil {
@@ -113,9 +176,9 @@ func (s *CompanyService) GetCompanyByID(id int) *dto.Result {
 	}
 }
 
-// GetAllCompanies 获取所有公司
-func (s *CompanyService) GetAllCompanies() *dto.Result {
-	companies, err := s.CompanyRepo.FindAll()
+// GetAllCompanies 获取所有公司，includeDeleted为false时不含已删除的公司
+func (s *CompanyService) GetAllCompanies(includeDeleted bool) *dto.Result {
+	companies, err := s.CompanyRepo.FindAll(includeDeleted)
 	if err != nil {
 		log.Println("获取所有公司失败:", err)
 		return errorResult(500, "系统错误")
@@ -146,6 +209,7 @@ func (s *CompanyService) PageQueryCompanies(queryDTO *dto.CompanyPageQueryDTO) *
 		queryDTO.Address,
 		queryDTO.Administrator,
 		queryDTO.Phone,
+		queryDTO.IncludeDeleted,
 	)
 	if err != nil {
 		log.Println("分页查询公司失败:", err)
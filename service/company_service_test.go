@@ -0,0 +1,150 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository/repotest"
+)
+
+func TestCompanyService_Lifecycle(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	const (
+		active  = 1
+		deleted = 2
+		missing = 99
+	)
+
+	tests := []struct {
+		name      string
+		run       func(s *CompanyService) *dto.Result
+		auditErr  error
+		wantCode  int
+		wantMsg   string
+		wantAudit string
+	}{
+		{
+			name: "创建",
+			run: func(s *CompanyService) *dto.Result {
+				return s.CreateCompany(adminScope, &dto.CompanyDTO{Name: "冷链物流", Address: "上海"})
+			},
+			wantCode: 200, wantAudit: auditKey(model.AuditEntityCompany, 3, model.AuditActionCreate),
+		},
+		{
+			name: "物流用户更新本公司",
+			run: func(s *CompanyService) *dto.Result {
+				return s.UpdateCompany(companyScope(active), &dto.CompanyDTO{ID: active, Name: "顺达物流"})
+			},
+			wantCode: 200, wantAudit: auditKey(model.AuditEntityCompany, active, model.AuditActionUpdate),
+		},
+		{
+			name: "物流用户更新其他公司",
+			run: func(s *CompanyService) *dto.Result {
+				return s.UpdateCompany(companyScope(deleted), &dto.CompanyDTO{ID: active, Name: "顺达物流"})
+			},
+			wantCode: 403, wantMsg: "只能修改绑定的公司",
+		},
+		{
+			name: "更新已删除的公司",
+			run: func(s *CompanyService) *dto.Result {
+				return s.UpdateCompany(adminScope, &dto.CompanyDTO{ID: deleted, Name: "顺达物流"})
+			},
+			wantCode: 409, wantMsg: "公司已删除，请先恢复",
+		},
+		{
+			name: "更新不存在的公司",
+			run: func(s *CompanyService) *dto.Result {
+				return s.UpdateCompany(adminScope, &dto.CompanyDTO{ID: missing, Name: "顺达物流"})
+			},
+			wantCode: 404, wantMsg: "公司不存在",
+		},
+		{
+			name:     "删除",
+			run:      func(s *CompanyService) *dto.Result { return s.DeleteCompany(adminScope, active) },
+			wantCode: 200, wantAudit: auditKey(model.AuditEntityCompany, active, model.AuditActionDelete),
+		},
+		{
+			name:     "物流用户删除其他公司",
+			run:      func(s *CompanyService) *dto.Result { return s.DeleteCompany(companyScope(deleted), active) },
+			wantCode: 403, wantMsg: "只能删除绑定的公司",
+		},
+		{
+			name:     "重复删除",
+			run:      func(s *CompanyService) *dto.Result { return s.DeleteCompany(adminScope, deleted) },
+			wantCode: 409, wantMsg: "公司已删除",
+		},
+		{
+			name:     "恢复",
+			run:      func(s *CompanyService) *dto.Result { return s.RestoreCompany(adminScope, deleted) },
+			wantCode: 200, wantAudit: auditKey(model.AuditEntityCompany, deleted, model.AuditActionRestore),
+		},
+		{
+			name:     "恢复未删除的公司",
+			run:      func(s *CompanyService) *dto.Result { return s.RestoreCompany(adminScope, active) },
+			wantCode: 409, wantMsg: "公司未删除",
+		},
+		{
+			name:     "查询已删除的公司",
+			run:      func(s *CompanyService) *dto.Result { return s.GetCompanyByID(deleted) },
+			wantCode: 200,
+		},
+		{
+			name:     "审计日志写入失败时不删除",
+			run:      func(s *CompanyService) *dto.Result { return s.DeleteCompany(adminScope, active) },
+			auditErr: errFake, wantCode: 500, wantMsg: "删除失败",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestRepos()
+			repos.Company = repotest.NewCompanyRepository(
+				&model.Company{ID: active, Name: "顺丰物流", Address: "北京"},
+				&model.Company{ID: deleted, Name: "停运物流", Address: "天津",
+					SoftDelete: model.SoftDelete{DeletedAt: &deletedAt, DeletedBy: "admin"}},
+			)
+			s := NewCompanyService(repos.Company, repos.uow())
+
+			repos.Audit.Err = tt.auditErr
+			assertResult(t, tt.run(s), tt.wantCode, tt.wantMsg)
+			if tt.auditErr != nil && repos.Company.Companies[active].Deleted() {
+				t.Fatal("审计日志写入失败后仍删除了公司")
+			}
+			if tt.wantAudit == "" {
+				assertAudits(t, repos)
+			} else {
+				assertAudits(t, repos, tt.wantAudit)
+			}
+		})
+	}
+}
+
+func TestCompanyService_Query(t *testing.T) {
+	repos := newTestRepos()
+	repos.Company = repotest.NewCompanyRepository(
+		&model.Company{Name: "顺丰物流", Address: "北京"},
+		&model.Company{Name: "冷链物流", Address: "上海"},
+		&model.Company{Name: "德邦物流", Address: "上海"},
+	)
+	s := NewCompanyService(repos.Company, repos.uow())
+	assertResult(t, s.DeleteCompany(adminScope, 3), 200, "删除成功")
+	if deleted := repos.Company.Companies[3]; !deleted.Deleted() || deleted.DeletedBy != "admin" {
+		t.Fatalf("删除后 = %+v", deleted)
+	}
+
+	if list := s.GetAllCompanies(false).Data.([]*model.Company); len(list) != 2 {
+		t.Fatalf("GetAllCompanies(false) = %+v", list)
+	}
+	if list := s.GetAllCompanies(true).Data.([]*model.Company); len(list) != 3 {
+		t.Fatalf("GetAllCompanies(true) = %+v", list)
+	}
+	page := s.PageQueryCompanies(&dto.CompanyPageQueryDTO{Address: "上海", IncludeDeleted: true}).Data.(*dto.PageResult)
+	if page.Total != 2 || page.Page != 1 || page.PageSize != 10 {
+		t.Fatalf("PageQueryCompanies = %+v", page)
+	}
+
+	repos.Company.Err = errFake
+	assertResult(t, s.CreateCompany(adminScope, &dto.CompanyDTO{Name: "新公司"}), 500, "创建公司失败")
+	assertResult(t, s.GetCompanyByID(1), 500, "系统错误")
+}
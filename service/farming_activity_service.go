@@ -0,0 +1,243 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+// FarmingActivityForbidden 超出数据权限范围的提示
+const FarmingActivityForbidden = "无权操作该农事活动"
+
+var (
+	ErrFarmingActivityNotFound = errors.New("农事活动不存在")
+	ErrFarmingActivityType     = errors.New("无效的农事活动类型")
+	ErrFarmingActivityMaterial = errors.New("施药记录必须填写农药名称")
+	ErrFarmingActivityDosage   = errors.New("用量不能为负数")
+	ErrFarmingActivityInterval = errors.New("安全间隔期不能为负数")
+	ErrWithholdingPeriod       = errors.New("收获时间未满足农药安全间隔期")
+)
+
+// FarmingActivityService 农事活动服务
+type FarmingActivityService struct {
+	repo           repository.FarmingActivityRepository
+	productionRepo repository.ProductionRepository
+	uow            repository.UnitOfWork
+}
+
+// NewFarmingActivityService 创建农事活动服务
+func NewFarmingActivityService(
+	repo repository.FarmingActivityRepository,
+	productionRepo repository.ProductionRepository,
+	uow repository.UnitOfWork,
+) *FarmingActivityService {
+	return &FarmingActivityService{repo: repo, productionRepo: productionRepo, uow: uow}
+}
+
+// Save 新增农事活动，所属生产信息的收获时间须满足该活动的安全间隔期
+func (s *FarmingActivityService) Save(scope model.DataScope, activityDTO *dto.FarmingActivityDTO) *dto.Result {
+	activity := toFarmingActivity(activityDTO)
+	if err := validateFarmingActivity(activity); err != nil {
+		return errorResult(400, err.Error())
+	}
+
+	var id int
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		if err := checkActivityHarvest(repos, scope, activity); err != nil {
+			return err
+		}
+		var err error
+		if id, err = repos.FarmingActivity.Save(activity); err != nil {
+			return err
+		}
+		created, err := repos.FarmingActivity.GetByID(id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityFarmingActivity, id, model.AuditActionCreate, nil, created)
+	})
+	if err != nil {
+		return farmingActivityErrorResult(err, "保存农事活动失败")
+	}
+
+	return successResult("添加成功", id)
+}
+
+// Update 修改农事活动，所属生产信息不可修改
+func (s *FarmingActivityService) Update(scope model.DataScope, activityDTO *dto.FarmingActivityDTO) *dto.Result {
+	activity := toFarmingActivity(activityDTO)
+	if err := validateFarmingActivity(activity); err != nil {
+		return errorResult(400, err.Error())
+	}
+
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		existing, err := repos.FarmingActivity.GetByID(activity.ID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return ErrFarmingActivityNotFound
+		}
+		activity.ProductInfoID = existing.ProductInfoID
+		if err := checkActivityHarvest(repos, scope, activity); err != nil {
+			return err
+		}
+		if err := repos.FarmingActivity.Update(activity); err != nil {
+			return err
+		}
+		updated, err := repos.FarmingActivity.GetByID(activity.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityFarmingActivity, activity.ID, model.AuditActionUpdate, existing, updated)
+	})
+	if err != nil {
+		return farmingActivityErrorResult(err, "更新农事活动失败")
+	}
+
+	return successResult("更新成功", nil)
+}
+
+// Delete 删除农事活动
+func (s *FarmingActivityService) Delete(scope model.DataScope, id int) *dto.Result {
+	existing, err := s.repo.GetByID(id)
+	if err != nil {
+		log.Println("查询农事活动失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if existing == nil {
+		return errorResult(404, ErrFarmingActivityNotFound.Error())
+	}
+	if !scope.AllowProductPlace(existing.ProductPlaceID) {
+		return errorResult(403, FarmingActivityForbidden)
+	}
+
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.FarmingActivity.Delete(id); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityFarmingActivity, id, model.AuditActionDelete, existing, nil)
+	})
+	if err != nil {
+		log.Println("删除农事活动失败:", err)
+		return errorResult(500, "删除失败")
+	}
+
+	return successResult("删除成功", nil)
+}
+
+// GetByID 根据ID获取农事活动，超出数据权限范围时视为不存在
+func (s *FarmingActivityService) GetByID(scope model.DataScope, id int) *dto.Result {
+	activity, err := s.repo.GetByID(id)
+	if err != nil {
+		log.Println("查询农事活动失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if activity == nil || !scope.AllowProductPlace(activity.ProductPlaceID) {
+		return errorResult(404, ErrFarmingActivityNotFound.Error())
+	}
+
+	return successResult("查询成功", activity)
+}
+
+// ListByProductInfo 查询生产信息的农事活动(按作业时间排序)
+func (s *FarmingActivityService) ListByProductInfo(scope model.DataScope, productInfoID int) *dto.Result {
+	production, err := s.productionRepo.GetByID(productInfoID)
+	if err != nil {
+		log.Println("查询生产信息失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if production == nil || !scope.AllowProductPlace(production.ProductPlaceID) {
+		return errorResult(404, "生产信息不存在")
+	}
+
+	activities, err := s.repo.FindByProductInfoID(productInfoID)
+	if err != nil {
+		log.Println("查询农事活动失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	return successResult("查询成功", activities)
+}
+
+// checkActivityHarvest 校验生产信息在数据权限范围内，且其收获时间满足该活动的安全间隔期
+func checkActivityHarvest(repos *repository.Repositories, scope model.DataScope, activity *model.FarmingActivity) error {
+	production, err := repos.Production.GetByID(activity.ProductInfoID)
+	if err != nil {
+		return err
+	}
+	if production == nil {
+		return ErrBatchNotFound
+	}
+	if !scope.AllowProductPlace(production.ProductPlaceID) {
+		return ErrBatchForbidden
+	}
+	return checkWithholding([]*model.FarmingActivity{activity}, production.HarvestDate)
+}
+
+// checkWithholding 校验收获时间满足所有农事活动的安全间隔期
+func checkWithholding(activities []*model.FarmingActivity, harvestDate time.Time) error {
+	for _, activity := range activities {
+		if !activity.AllowsHarvest(harvestDate) {
+			return fmt.Errorf("%w: %s于%s施用，安全间隔期%d天，最早可于%s收获", ErrWithholdingPeriod,
+				activity.Material, activity.ActivityDate.Format("2006-01-02"), *activity.WithholdingDays,
+				activity.EarliestHarvest().Format("2006-01-02"))
+		}
+	}
+	return nil
+}
+
+// validateFarmingActivity 校验农事活动字段
+func validateFarmingActivity(activity *model.FarmingActivity) error {
+	if !containsString(model.FarmingActivityTypes, activity.ActivityType) {
+		return ErrFarmingActivityType
+	}
+	if activity.ActivityType == model.FarmingActivityPesticide && activity.Material == "" {
+		return ErrFarmingActivityMaterial
+	}
+	if activity.Dosage != nil && *activity.Dosage < 0 {
+		return ErrFarmingActivityDosage
+	}
+	if activity.WithholdingDays != nil && *activity.WithholdingDays < 0 {
+		return ErrFarmingActivityInterval
+	}
+	return nil
+}
+
+// toFarmingActivity 转换DTO为模型
+func toFarmingActivity(activityDTO *dto.FarmingActivityDTO) *model.FarmingActivity {
+	return &model.FarmingActivity{
+		ID:              activityDTO.ID,
+		ProductInfoID:   activityDTO.ProductInfoID,
+		ActivityType:    activityDTO.ActivityType,
+		Material:        activityDTO.Material,
+		Dosage:          activityDTO.Dosage,
+		DosageUnit:      activityDTO.DosageUnit,
+		Operator:        activityDTO.Operator,
+		ActivityDate:    activityDTO.ActivityDate,
+		WithholdingDays: activityDTO.WithholdingDays,
+		Remark:          activityDTO.Remark,
+	}
+}
+
+// farmingActivityErrorResult 将农事活动相关错误转换为响应结果
+func farmingActivityErrorResult(err error, msg string) *dto.Result {
+	switch {
+	case errors.Is(err, ErrFarmingActivityNotFound):
+		return errorResult(404, err.Error())
+	case errors.Is(err, ErrBatchNotFound):
+		return errorResult(404, "生产信息不存在")
+	case errors.Is(err, ErrBatchForbidden):
+		return errorResult(403, FarmingActivityForbidden)
+	case errors.Is(err, ErrWithholdingPeriod):
+		return errorResult(400, err.Error())
+	default:
+		log.Println(msg+":", err)
+		return errorResult(500, msg)
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"log"
+	"sort"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+// hashChainVerifyBatch 校验哈希链时每次读取的条目数
+const hashChainVerifyBatch = 500
+
+// hashChainProofMaxLimit 公开查询链上条目时每次最多返回的条目数
+const hashChainProofMaxLimit = 500
+
+// HashChainService 哈希链服务：校验生产、物流、销售记录入链后是否被改写
+type HashChainService struct {
+	HashChainRepo repository.HashChainRepository
+}
+
+// NewHashChainService 创建哈希链服务
+func NewHashChainService(hashChainRepo repository.HashChainRepository) *HashChainService {
+	return &HashChainService{HashChainRepo: hashChainRepo}
+}
+
+// Verify 按顺序复算整条哈希链，并将每条记录最近一次入链的内容与数据库中的当前数据比对，报告第一个断裂处
+func (s *HashChainService) Verify() *dto.Result {
+	verification, err := s.verify()
+	if err != nil {
+		log.Println("校验哈希链失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	return successResult("校验完成", verification)
+}
+
+// Proofs 按顺序查询从fromSeq开始的链上条目(不含记录内容)，供第三方离线核对相邻条目的哈希
+func (s *HashChainService) Proofs(fromSeq, limit int) *dto.Result {
+	if fromSeq <= 0 {
+		fromSeq = 1
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > hashChainProofMaxLimit {
+		limit = hashChainProofMaxLimit
+	}
+
+	entries, err := s.HashChainRepo.FindRange(fromSeq, limit)
+	if err != nil {
+		log.Println("查询哈希链失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	proofs := make([]*model.HashChainProof, 0, len(entries))
+	for _, entry := range entries {
+		proof := entry.HashChainProof
+		proofs = append(proofs, &proof)
+	}
+	return successResult("查询成功", proofs)
+}
+
+// Proof 查询记录最近一次入链的位置，未入链时返回nil
+func (s *HashChainService) Proof(entity string, entityID int) (*model.HashChainProof, error) {
+	entry, err := s.HashChainRepo.FindLatest(entity, entityID)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	return &entry.HashChainProof, nil
+}
+
+// CheckRecord 将记录的当前数据与其最近一次入链的内容比对(与Verify的比对方式相同)，一致时返回空字符串
+func (s *HashChainService) CheckRecord(entity string, entityID int) (string, error) {
+	entry, err := s.HashChainRepo.FindLatest(entity, entityID)
+	if err != nil {
+		return "", err
+	}
+	if entry == nil {
+		return "记录未入链", nil
+	}
+	if entry.ComputeRecordHash() != entry.RecordHash {
+		return "记录哈希与入链内容不符", nil
+	}
+	return s.checkCurrent(entry)
+}
+
+// verify 校验哈希链
+func (s *HashChainService) verify() (*model.HashChainVerification, error) {
+	verification := &model.HashChainVerification{}
+	latest := make(map[model.HashChainRef]*model.HashChainEntry)
+
+	// 逐个条目核对序号、前一条目哈希、记录哈希及条目哈希，遇到第一个断裂处即停止
+	var broken *model.HashChainBreak
+	for broken == nil {
+		entries, err := s.HashChainRepo.FindRange(verification.Length+1, hashChainVerifyBatch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.Seq != verification.Length+1 {
+				broken = &model.HashChainBreak{Seq: verification.Length + 1, Reason: "条目缺失"}
+				break
+			}
+			if reason := checkHashChainEntry(entry, verification.Head); reason != "" {
+				broken = &model.HashChainBreak{Seq: entry.Seq, Entity: entry.Entity, EntityID: entry.EntityID, Reason: reason}
+				break
+			}
+
+			verification.Length++
+			verification.Head = entry.Hash
+			entry.Payload = ""
+			latest[model.HashChainRef{Entity: entry.Entity, EntityID: entry.EntityID}] = entry
+		}
+
+		if len(entries) < hashChainVerifyBatch {
+			break
+		}
+	}
+
+	// 已核对的条目中，记录的当前数据必须与其最近一次入链的内容一致
+	entries := make([]*model.HashChainEntry, 0, len(latest))
+	for _, entry := range latest {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Seq < entries[j].Seq })
+
+	for _, entry := range entries {
+		if broken != nil && entry.Seq >= broken.Seq {
+			break
+		}
+
+		reason, err := s.checkCurrent(entry)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			broken = &model.HashChainBreak{Seq: entry.Seq, Entity: entry.Entity, EntityID: entry.EntityID, Reason: reason}
+			break
+		}
+	}
+
+	// 链完整时，数据库中不应存在未入链的记录
+	if broken == nil {
+		unchained, err := s.HashChainRepo.FindUnchained()
+		if err != nil {
+			return nil, err
+		}
+		if len(unchained) > 0 {
+			broken = &model.HashChainBreak{Entity: unchained[0].Entity, EntityID: unchained[0].EntityID, Reason: "记录未入链"}
+		}
+	}
+
+	verification.Broken = broken
+	verification.Valid = broken == nil
+	return verification, nil
+}
+
+// checkCurrent 比对记录的当前数据与其最近一次入链的内容，一致时返回空字符串
+func (s *HashChainService) checkCurrent(entry *model.HashChainEntry) (string, error) {
+	payload, found, err := s.HashChainRepo.Payload(entry.Entity, entry.EntityID)
+	if err != nil {
+		return "", err
+	}
+
+	if entry.Action == model.HashChainDelete {
+		if found {
+			return "记录已删除但数据库中仍存在", nil
+		}
+		return "", nil
+	}
+
+	if !found {
+		return "记录在链外被删除", nil
+	}
+	if model.HashChainRecordHash(entry.Entity, entry.EntityID, entry.Action, payload) != entry.RecordHash {
+		return "记录在入链后被修改", nil
+	}
+	return "", nil
+}
+
+// checkHashChainEntry 核对单个条目，prevHash为前一条目的哈希，通过时返回空字符串
+func checkHashChainEntry(entry *model.HashChainEntry, prevHash string) string {
+	if entry.PrevHash != prevHash {
+		return "前一条目哈希不匹配"
+	}
+	if entry.ComputeRecordHash() != entry.RecordHash {
+		return "记录哈希与入链内容不符"
+	}
+	if entry.ComputeHash() != entry.Hash {
+		return "条目哈希不符"
+	}
+	return ""
+}
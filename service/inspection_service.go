@@ -0,0 +1,294 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var (
+	ErrInspectionNotFound  = errors.New("检测记录不存在")
+	ErrInspectionForbidden = errors.New("无权操作该检测记录")
+	ErrInspectionTarget    = errors.New("请指定被检的生产信息或物流信息")
+	ErrInspectionMismatch  = errors.New("物流信息运输的不是该生产批次")
+	ErrInspectionType      = errors.New("无效的检测类型")
+	ErrInspectionVerdict   = errors.New("没有检测项目时必须填写检测结论(pass/fail)")
+	ErrInspectionItem      = errors.New("检测项目需填写检测值或判定结果")
+	ErrInspectionReportPDF = errors.New("检测报告附件必须为PDF文件")
+	ErrInspectionReportURL = errors.New("检测报告附件须通过文件上传接口上传")
+)
+
+// InspectionService 质量检测服务
+type InspectionService struct {
+	repo           repository.InspectionRepository
+	productionRepo repository.ProductionRepository
+	logisticsRepo  repository.LogisticsRepository
+	uow            repository.UnitOfWork
+	uploadBaseURL  string // 上传文件的对外访问地址前缀，检测报告须为已上传的文件
+}
+
+// NewInspectionService 创建质量检测服务
+func NewInspectionService(
+	repo repository.InspectionRepository,
+	productionRepo repository.ProductionRepository,
+	logisticsRepo repository.LogisticsRepository,
+	uow repository.UnitOfWork,
+	uploadBaseURL string,
+) *InspectionService {
+	return &InspectionService{
+		repo:           repo,
+		productionRepo: productionRepo,
+		logisticsRepo:  logisticsRepo,
+		uow:            uow,
+		uploadBaseURL:  uploadBaseURL,
+	}
+}
+
+// Save 新增检测记录
+func (s *InspectionService) Save(scope model.DataScope, inspectionDTO *dto.InspectionDTO) *dto.Result {
+	inspection, err := s.toInspection(scope, inspectionDTO)
+	if err != nil {
+		return inspectionErrorResult(err, "保存检测记录失败")
+	}
+
+	var id int
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		var err error
+		if id, err = repos.Inspection.Save(inspection); err != nil {
+			return err
+		}
+		inspection.ID = id
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityInspection, id, model.AuditActionCreate, nil, inspection)
+	})
+	if err != nil {
+		log.Println("保存检测记录失败:", err)
+		return errorResult(500, "保存检测记录失败")
+	}
+
+	return successResult("添加成功", id)
+}
+
+// Update 修改检测记录，检测项目整体替换
+func (s *InspectionService) Update(scope model.DataScope, inspectionDTO *dto.InspectionDTO) *dto.Result {
+	existing, err := s.get(scope, inspectionDTO.ID)
+	if err != nil {
+		return inspectionErrorResult(err, "更新检测记录失败")
+	}
+
+	inspection, err := s.toInspection(scope, inspectionDTO)
+	if err != nil {
+		return inspectionErrorResult(err, "更新检测记录失败")
+	}
+
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.Inspection.Update(inspection); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityInspection, inspection.ID, model.AuditActionUpdate, existing, inspection)
+	})
+	if err != nil {
+		log.Println("更新检测记录失败:", err)
+		return errorResult(500, "更新失败")
+	}
+
+	return successResult("更新成功", nil)
+}
+
+// Delete 删除检测记录
+func (s *InspectionService) Delete(scope model.DataScope, id int) *dto.Result {
+	existing, err := s.get(scope, id)
+	if err != nil {
+		return inspectionErrorResult(err, "删除检测记录失败")
+	}
+
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.Inspection.Delete(id); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityInspection, id, model.AuditActionDelete, existing, nil)
+	})
+	if err != nil {
+		log.Println("删除检测记录失败:", err)
+		return errorResult(500, "删除失败")
+	}
+
+	return successResult("删除成功", nil)
+}
+
+// GetByID 根据ID获取检测记录及检测项目，超出数据权限范围时视为不存在
+func (s *InspectionService) GetByID(scope model.DataScope, id int) *dto.Result {
+	inspection, err := s.repo.GetByID(id)
+	if err != nil {
+		log.Println("查询检测记录失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if inspection == nil || !allowInspection(scope, inspection) {
+		return errorResult(404, ErrInspectionNotFound.Error())
+	}
+
+	return successResult("查询成功", inspection)
+}
+
+// PageQuery 在数据权限范围内分页查询检测记录
+func (s *InspectionService) PageQuery(scope model.DataScope, queryDTO *model.InspectionPageQueryDTO) *dto.Result {
+	if queryDTO.Page <= 0 {
+		queryDTO.Page = 1
+	}
+	if queryDTO.Size <= 0 {
+		queryDTO.Size = 10
+	}
+
+	inspections, total, err := s.repo.PageQuery(queryDTO, scope)
+	if err != nil {
+		log.Println("分页查询检测记录失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	return successResult("查询成功", dto.NewPageResult(total, inspections, queryDTO.Page, queryDTO.Size))
+}
+
+// get 获取检测记录并校验数据权限
+func (s *InspectionService) get(scope model.DataScope, id int) (*model.Inspection, error) {
+	inspection, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if inspection == nil {
+		return nil, ErrInspectionNotFound
+	}
+	if !allowInspection(scope, inspection) {
+		return nil, ErrInspectionForbidden
+	}
+	return inspection, nil
+}
+
+// toInspection 校验DTO并转换为模型：确定被检批次、校验数据权限、按检测项目判定结论
+func (s *InspectionService) toInspection(scope model.DataScope, inspectionDTO *dto.InspectionDTO) (*model.Inspection, error) {
+	if !containsString(model.InspectionTypes, inspectionDTO.InspectionType) {
+		return nil, ErrInspectionType
+	}
+	if err := s.checkReportURL(inspectionDTO.ReportURL); err != nil {
+		return nil, err
+	}
+
+	inspection := &model.Inspection{
+		ID:             inspectionDTO.ID,
+		ProductInfoID:  inspectionDTO.ProductInfoID,
+		LogisticsID:    inspectionDTO.LogisticsID,
+		InspectionType: inspectionDTO.InspectionType,
+		LabName:        inspectionDTO.LabName,
+		ReportNo:       inspectionDTO.ReportNo,
+		InspectionDate: inspectionDTO.InspectionDate,
+		Verdict:        inspectionDTO.Verdict,
+		ReportURL:      inspectionDTO.ReportURL,
+		Remark:         inspectionDTO.Remark,
+	}
+
+	// 运输环节抽检时，被检批次为该物流运输的批次
+	if inspection.LogisticsID > 0 {
+		logistics, err := s.logisticsRepo.GetByID(inspection.LogisticsID)
+		if err != nil {
+			return nil, err
+		}
+		if logistics == nil {
+			return nil, ErrLogisticsNotFound
+		}
+		if inspection.ProductInfoID > 0 && inspection.ProductInfoID != logistics.ProductInfoID {
+			return nil, ErrInspectionMismatch
+		}
+		inspection.ProductInfoID = logistics.ProductInfoID
+		inspection.CompanyID = logistics.CompanyID
+	}
+	if inspection.ProductInfoID <= 0 {
+		return nil, ErrInspectionTarget
+	}
+
+	production, err := s.productionRepo.GetByID(inspection.ProductInfoID)
+	if err != nil {
+		return nil, err
+	}
+	if production == nil {
+		return nil, ErrBatchNotFound
+	}
+	inspection.ProductPlaceID = production.ProductPlaceID
+	if !allowInspection(scope, inspection) {
+		return nil, ErrInspectionForbidden
+	}
+
+	// 有检测项目时，任一项目不合格即判定为不合格
+	for _, itemDTO := range inspectionDTO.Items {
+		item := &model.InspectionItem{
+			ItemName:   itemDTO.ItemName,
+			Value:      itemDTO.Value,
+			ResultText: itemDTO.ResultText,
+			Unit:       itemDTO.Unit,
+			LimitMin:   itemDTO.LimitMin,
+			LimitMax:   itemDTO.LimitMax,
+		}
+		switch {
+		case item.Value != nil:
+			item.Passed = item.WithinLimits()
+		case itemDTO.Passed != nil:
+			item.Passed = *itemDTO.Passed
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInspectionItem, item.ItemName)
+		}
+		inspection.Items = append(inspection.Items, item)
+	}
+	if len(inspection.Items) > 0 {
+		inspection.Verdict = model.InspectionVerdictPass
+		for _, item := range inspection.Items {
+			if !item.Passed {
+				inspection.Verdict = model.InspectionVerdictFail
+			}
+		}
+	}
+	if inspection.Verdict != model.InspectionVerdictPass && inspection.Verdict != model.InspectionVerdictFail {
+		return nil, ErrInspectionVerdict
+	}
+
+	return inspection, nil
+}
+
+// checkReportURL 检测报告须为通过上传接口上传的PDF文件
+func (s *InspectionService) checkReportURL(reportURL string) error {
+	if reportURL == "" {
+		return nil
+	}
+	if !strings.HasPrefix(reportURL, s.uploadBaseURL) {
+		return ErrInspectionReportURL
+	}
+	if !strings.HasSuffix(strings.ToLower(reportURL), ".pdf") {
+		return ErrInspectionReportPDF
+	}
+	return nil
+}
+
+// allowInspection 检测记录是否在数据权限范围内(农场按批次所属生产地，物流公司按抽检环节的承运公司)
+func allowInspection(scope model.DataScope, inspection *model.Inspection) bool {
+	return scope.AllowProductPlace(inspection.ProductPlaceID) && scope.AllowCompany(inspection.CompanyID)
+}
+
+// inspectionErrorResult 将检测记录相关错误转换为响应结果
+func inspectionErrorResult(err error, msg string) *dto.Result {
+	switch {
+	case errors.Is(err, ErrInspectionNotFound), errors.Is(err, ErrLogisticsNotFound):
+		return errorResult(404, err.Error())
+	case errors.Is(err, ErrBatchNotFound):
+		return errorResult(404, "生产信息不存在")
+	case errors.Is(err, ErrInspectionForbidden):
+		return errorResult(403, err.Error())
+	case errors.Is(err, ErrInspectionTarget), errors.Is(err, ErrInspectionMismatch), errors.Is(err, ErrInspectionType),
+		errors.Is(err, ErrInspectionVerdict), errors.Is(err, ErrInspectionItem),
+		errors.Is(err, ErrInspectionReportPDF), errors.Is(err, ErrInspectionReportURL):
+		return errorResult(400, err.Error())
+	default:
+		log.Println(msg+":", err)
+		return errorResult(500, msg)
+	}
+}
@@ -3,46 +3,162 @@ package service
 import (
 	"agricultural_product_gin/model"
 	"agricultural_product_gin/repository"
+	"errors"
 	"log"
+	"time"
+)
+
+var (
+	ErrLogisticsNotFound      = errors.New("物流信息不存在")
+	ErrLogisticsFinalized     = errors.New("物流已结束(送达/拒收/取消)，不能再变更")
+	ErrLogisticsStatus        = errors.New("无效的物流状态")
+	ErrLogisticsTransition    = errors.New("不允许的物流状态流转")
+	ErrLogisticsReason        = errors.New("拒收或取消必须填写原因")
+	ErrLogisticsEventNotFound = errors.New("物流事件不存在")
+	ErrLogisticsEventType     = errors.New("无效的物流事件类型")
+	ErrLogisticsEventDelivery = errors.New("送达事件只能通过确认收货产生")
+	ErrLogisticsEventCompany  = errors.New("交接事件必须指定接收公司")
+	ErrLogisticsForbidden     = errors.New("无权操作该物流信息")
+	ErrLogisticsConflict      = errors.New("物流状态已被其他操作修改，请刷新后重试")
+	ErrLogisticsPrev          = errors.New("上一运输段必须是同一生产批次的其他物流，且不能形成循环")
 )
 
 // LogisticsService 物流服务
 type LogisticsService struct {
-	repo *repository.LogisticsRepository
+	repo      repository.LogisticsRepository
+	eventRepo repository.LogisticsEventRepository
+	uow       repository.UnitOfWork
 }
 
 // NewLogisticsService 创建物流服务
-func NewLogisticsService(repo *repository.LogisticsRepository) *LogisticsService {
-	return &LogisticsService{repo: repo}
+func NewLogisticsService(repo repository.LogisticsRepository, eventRepo repository.LogisticsEventRepository, uow repository.UnitOfWork) *LogisticsService {
+	return &LogisticsService{repo: repo, eventRepo: eventRepo, uow: uow}
 }
 
-// Save 保存物流信息
-func (s *LogisticsService) Save(logistics *model.Logistics) (int, error) {
-	return s.repo.Save(logistics)
+// Save 保存物流信息，新物流为已创建状态；补录时已填写到达时间的视为已送达
+// 物流用户未指定公司时默认为其绑定的公司；分配了数量时不能超过批次剩余数量
+func (s *LogisticsService) Save(scope model.DataScope, logistics *model.Logistics) (int, error) {
+	if scope.CompanyID != nil && logistics.CompanyID <= 0 {
+		logistics.CompanyID = *scope.CompanyID
+	}
+	if err := checkLogisticsScope(s.repo, scope, logistics); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	logistics.Status = model.LogisticsStatusCreated
+	if logistics.EndTime != nil {
+		logistics.Status = model.LogisticsStatusDelivered
+	}
+	logistics.StatusTime = &now
+
+	var id int
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		err := checkReferences(repos.Reference,
+			reference("productInfoId", model.AuditEntityProduction, logistics.ProductInfoID),
+			reference("companyId", model.AuditEntityCompany, logistics.CompanyID),
+			reference("prevLogId", model.AuditEntityLogistics, prevLogisticsID(logistics)))
+		if err != nil {
+			return err
+		}
+		if err := checkPrevLeg(repos.Logistics, logistics); err != nil {
+			return err
+		}
+		if err := checkBatchAllocation(repos.Batch, logistics.ProductInfoID, logistics.Quantity, 0); err != nil {
+			return err
+		}
+		if id, err = repos.Logistics.Save(logistics); err != nil {
+			return err
+		}
+		created, err := repos.Logistics.GetByID(id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityLogistics, id, model.AuditActionCreate, nil, created)
+	})
+	return id, err
 }
 
-// Update 更新物流信息
-func (s *LogisticsService) Update(logistics *model.Logistics) error {
-	return s.repo.Update(logistics)
+// Update 更新物流信息，状态与到达时间只能通过状态流转修改，修改分配数量时重新校验批次剩余数量
+// 以读取到的状态为条件更新，期间状态被并发流转时返回ErrLogisticsConflict
+func (s *LogisticsService) Update(scope model.DataScope, logistics *model.Logistics) error {
+	return s.uow.Do(func(repos *repository.Repositories) error {
+		existing, err := getLogistics(repos.Logistics, scope, logistics.ID)
+		if err != nil {
+			return err
+		}
+		if isFinalized(existing) {
+			return ErrLogisticsFinalized
+		}
+		// 修改后的公司、生产信息同样需要在数据权限范围内
+		if err := checkLogisticsScope(repos.Logistics, scope, logistics); err != nil {
+			return err
+		}
+
+		err = checkReferences(repos.Reference,
+			changedReference("productInfoId", model.AuditEntityProduction, logistics.ProductInfoID, existing.ProductInfoID),
+			changedReference("companyId", model.AuditEntityCompany, logistics.CompanyID, existing.CompanyID),
+			changedReference("prevLogId", model.AuditEntityLogistics, prevLogisticsID(logistics), prevLogisticsID(existing)))
+		if err != nil {
+			return err
+		}
+		if err := checkPrevLeg(repos.Logistics, logistics); err != nil {
+			return err
+		}
+		err = checkBatchAllocation(repos.Batch, logistics.ProductInfoID, logistics.Quantity, logistics.ID)
+		if err != nil {
+			return err
+		}
+		updated, err := repos.Logistics.Update(logistics, existing.Status)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return ErrLogisticsConflict
+		}
+		return recordLogisticsUpdate(repos, scope.Actor, existing)
+	})
 }
 
-// Delete 删除物流信息
-func (s *LogisticsService) Delete(id int) error {
-	return s.repo.Delete(id)
+// Delete 删除物流信息，已结束的物流不能删除，仍被销售信息、质量检测记录引用时返回ReferencedError
+func (s *LogisticsService) Delete(scope model.DataScope, id int) error {
+	return s.uow.Do(func(repos *repository.Repositories) error {
+		existing, err := getLogistics(repos.Logistics, scope, id)
+		if err != nil {
+			return err
+		}
+		if isFinalized(existing) {
+			return ErrLogisticsFinalized
+		}
+		if err := checkDeletable(repos.Reference, model.AuditEntityLogistics, id); err != nil {
+			return err
+		}
+		if err := repos.Logistics.Delete(id); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityLogistics, id, model.AuditActionDelete, existing, nil)
+	})
 }
 
-// GetByID 根据ID获取物流信息
-func (s *LogisticsService) GetByID(id int) (*model.Logistics, error) {
-	return s.repo.GetByID(id)
+// GetByID 根据ID获取物流信息，超出数据权限范围时视为不存在
+func (s *LogisticsService) GetByID(scope model.DataScope, id int) (*model.Logistics, error) {
+	logistics, err := s.repo.GetByID(id)
+	if err != nil || logistics == nil {
+		return nil, err
+	}
+	if !allowLogistics(scope, logistics) {
+		return nil, nil
+	}
+	return logistics, nil
 }
 
-// FindAll 查找所有物流信息
-func (s *LogisticsService) FindAll() ([]*model.Logistics, error) {
-	return s.repo.FindAll()
+// FindAll 查找数据权限范围内的所有物流信息
+func (s *LogisticsService) FindAll(scope model.DataScope) ([]*model.Logistics, error) {
+	return s.repo.FindAll(scope)
 }
 
-// PageQuery 分页查询物流信息
-func (s *LogisticsService) PageQuery(dto *model.LogisticsPageQueryDTO) (*model.LogisticsPageResult, error) {
+// PageQuery 在数据权限范围内分页查询物流信息
+func (s *LogisticsService) PageQuery(scope model.DataScope, dto *model.LogisticsPageQueryDTO) (*model.LogisticsPageResult, error) {
 	// 验证分页参数
 	if dto.Page <= 0 {
 		dto.Page = 1
@@ -52,7 +168,7 @@ func (s *LogisticsService) PageQuery(dto *model.LogisticsPageQueryDTO) (*model.L
 		dto.Size = 10
 	}
 
-	records, total, err := s.repo.PageQuery(dto)
+	records, total, err := s.repo.PageQuery(dto, scope)
 	if err != nil {
 		log.Println("分页查询物流信息失败:", err)
 		return nil, err
@@ -63,3 +179,292 @@ func (s *LogisticsService) PageQuery(dto *model.LogisticsPageQueryDTO) (*model.L
 		Records: records,
 	}, nil
 }
+
+// ConfirmReceipt 确认收货，即流转到已送达状态
+func (s *LogisticsService) ConfirmReceipt(scope model.DataScope, id int, remark string) error {
+	return s.Transition(scope, id, model.LogisticsStatusDelivered, remark)
+}
+
+// Transition 物流状态流转
+// 送达时记录到达时间并追加终止的送达事件
+func (s *LogisticsService) Transition(scope model.DataScope, id int, status, reason string) error {
+	if status != model.LogisticsStatusInTransit && !isFinalStatus(status) {
+		return ErrLogisticsStatus
+	}
+	if (status == model.LogisticsStatusRejected || status == model.LogisticsStatusCancelled) && reason == "" {
+		return ErrLogisticsReason
+	}
+
+	logistics, err := getLogistics(s.repo, scope, id)
+	if err != nil {
+		return err
+	}
+	if isFinalized(logistics) {
+		return ErrLogisticsFinalized
+	}
+	if !canTransition(logistics.Status, status) {
+		return ErrLogisticsTransition
+	}
+
+	now := time.Now()
+	var endTime *time.Time
+	if status == model.LogisticsStatusDelivered {
+		endTime = &now
+	}
+	// 以读取到的状态为条件更新，并发流转时只有一个请求成功
+	return s.uow.Do(func(repos *repository.Repositories) error {
+		updated, err := repos.Logistics.UpdateStatus(id, logistics.Status, status, reason, now, endTime)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return ErrLogisticsConflict
+		}
+
+		if status == model.LogisticsStatusDelivered {
+			_, err := repos.LogisticsEvent.Save(&model.LogisticsEvent{
+				LogisticsID: id,
+				EventType:   model.LogisticsEventDelivered,
+				Location:    logistics.Destination,
+				CompanyID:   logistics.CompanyID,
+				EventTime:   now,
+				Remark:      reason,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return recordLogisticsUpdate(repos, scope.Actor, logistics)
+	})
+}
+
+// AddEvent 为物流记录追加运输事件
+func (s *LogisticsService) AddEvent(scope model.DataScope, event *model.LogisticsEvent) (int, error) {
+	if !isValidEventType(event.EventType) {
+		return 0, ErrLogisticsEventType
+	}
+	if event.EventType == model.LogisticsEventDelivered {
+		return 0, ErrLogisticsEventDelivery
+	}
+	if event.EventType == model.LogisticsEventHandover && event.CompanyID <= 0 {
+		return 0, ErrLogisticsEventCompany
+	}
+
+	logistics, err := getLogistics(s.repo, scope, event.LogisticsID)
+	if err != nil {
+		return 0, err
+	}
+	if isFinalized(logistics) {
+		return 0, ErrLogisticsFinalized
+	}
+
+	if event.EventTime.IsZero() {
+		event.EventTime = time.Now()
+	}
+	if event.CompanyID <= 0 {
+		event.CompanyID = logistics.CompanyID
+	}
+
+	var id int
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		// 接收公司须存在且未删除，沿用物流公司时不再检查
+		err := checkReferences(repos.Reference,
+			changedReference("companyId", model.AuditEntityCompany, event.CompanyID, logistics.CompanyID))
+		if err != nil {
+			return err
+		}
+		if id, err = repos.LogisticsEvent.Save(event); err != nil {
+			return err
+		}
+		event.ID = id
+		if err := recordAudit(repos.Audit, scope.Actor, model.AuditEntityLogisticsEvent, id, model.AuditActionCreate, nil, event); err != nil {
+			return err
+		}
+
+		// 已创建的物流产生首个运输事件后进入运输中
+		if logistics.Status != model.LogisticsStatusCreated {
+			return nil
+		}
+		updated, err := repos.Logistics.UpdateStatus(logistics.ID, model.LogisticsStatusCreated,
+			model.LogisticsStatusInTransit, "", event.EventTime, nil)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			// 已被并发的事件或流转修改：已结束的物流不能再追加事件，已在运输中则无需再流转
+			current, err := repos.Logistics.GetByID(logistics.ID)
+			if err != nil {
+				return err
+			}
+			if current == nil {
+				return ErrLogisticsNotFound
+			}
+			if isFinalized(current) {
+				return ErrLogisticsFinalized
+			}
+			return nil
+		}
+		return recordLogisticsUpdate(repos, scope.Actor, logistics)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// DeleteEvent 删除运输事件(已送达的物流不允许修改时间线)
+func (s *LogisticsService) DeleteEvent(scope model.DataScope, id int) error {
+	event, err := s.eventRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return ErrLogisticsEventNotFound
+	}
+
+	logistics, err := getLogistics(s.repo, scope, event.LogisticsID)
+	if err != nil {
+		return err
+	}
+	if isFinalized(logistics) {
+		return ErrLogisticsFinalized
+	}
+
+	return s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.LogisticsEvent.Delete(id); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityLogisticsEvent, id, model.AuditActionDelete, event, nil)
+	})
+}
+
+// ListEvents 查询物流记录的事件时间线
+func (s *LogisticsService) ListEvents(scope model.DataScope, logisticsID int) ([]*model.LogisticsEvent, error) {
+	logistics, err := getLogistics(s.repo, scope, logisticsID)
+	if err != nil {
+		return nil, err
+	}
+	return logistics.Events, nil
+}
+
+// getLogistics 获取物流记录并校验数据权限
+func getLogistics(repo repository.LogisticsRepository, scope model.DataScope, id int) (*model.Logistics, error) {
+	logistics, err := repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if logistics == nil {
+		return nil, ErrLogisticsNotFound
+	}
+	if !allowLogistics(scope, logistics) {
+		return nil, ErrLogisticsForbidden
+	}
+	return logistics, nil
+}
+
+// recordLogisticsUpdate 以变更前的物流记录和重新查询的当前记录记录审计日志
+func recordLogisticsUpdate(repos *repository.Repositories, actor model.Actor, before *model.Logistics) error {
+	after, err := repos.Logistics.GetByID(before.ID)
+	if err != nil {
+		return err
+	}
+	return recordAudit(repos.Audit, actor, model.AuditEntityLogistics, before.ID, model.AuditActionUpdate, before, after)
+}
+
+// checkLogisticsScope 校验待保存的物流记录(公司、所属生产地)是否在数据权限范围内
+func checkLogisticsScope(repo repository.LogisticsRepository, scope model.DataScope, logistics *model.Logistics) error {
+	if scope.ProductPlaceID != nil {
+		productPlaceID, err := repo.ProductPlaceIDOf(logistics.ProductInfoID)
+		if err != nil {
+			return err
+		}
+		logistics.ProductPlaceID = productPlaceID
+	}
+	if !allowLogistics(scope, logistics) {
+		return ErrLogisticsForbidden
+	}
+	return nil
+}
+
+// prevLogisticsID 上一运输段的物流ID，首段返回0
+func prevLogisticsID(logistics *model.Logistics) int {
+	if logistics.PrevID == nil {
+		return 0
+	}
+	return *logistics.PrevID
+}
+
+// checkPrevLeg 上一运输段须属于同一生产批次，沿上一段回溯不能回到本段
+// 上一段是否存在由checkReferences检查
+func checkPrevLeg(repo repository.LogisticsRepository, logistics *model.Logistics) error {
+	visited := map[int]bool{}
+	for id := prevLogisticsID(logistics); id > 0; {
+		if (logistics.ID > 0 && id == logistics.ID) || visited[id] {
+			return ErrLogisticsPrev
+		}
+		visited[id] = true
+		prev, err := repo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if prev == nil {
+			return nil
+		}
+		if prev.ProductInfoID != logistics.ProductInfoID {
+			return ErrLogisticsPrev
+		}
+		id = prevLogisticsID(prev)
+	}
+	return nil
+}
+
+// allowLogistics 物流记录是否在数据权限范围内(物流公司按承运公司，农场按产品所属生产地，零售商按销售信息关联的销售地)
+func allowLogistics(scope model.DataScope, logistics *model.Logistics) bool {
+	if !scope.AllowCompany(logistics.CompanyID) || !scope.AllowProductPlace(logistics.ProductPlaceID) {
+		return false
+	}
+	if scope.SalePlaceID == nil {
+		return true
+	}
+	for _, salePlaceID := range logistics.SalePlaceIDs {
+		if scope.AllowSalePlace(salePlaceID) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFinalized 物流是否已处于终止状态
+func isFinalized(logistics *model.Logistics) bool {
+	return isFinalStatus(logistics.Status)
+}
+
+// isFinalStatus 是否为终止状态(没有后续可流转的状态)
+func isFinalStatus(status string) bool {
+	switch status {
+	case model.LogisticsStatusDelivered, model.LogisticsStatusRejected, model.LogisticsStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// canTransition 校验状态流转是否合法
+func canTransition(from, to string) bool {
+	for _, next := range model.LogisticsStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidEventType 校验物流事件类型
+func isValidEventType(eventType string) bool {
+	for _, t := range model.LogisticsEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,468 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+	"agricultural_product_gin/repository/repotest"
+)
+
+// newLogisticsRepos 批次1(生产地2，数量100)由公司1承运60(物流1，已创建)、公司2已送达(物流2)，
+// 批次2(生产地4)由公司1运输中(物流3)；公司3已删除
+func newLogisticsRepos() *testRepos {
+	repos := newTestRepos()
+	repos.Production = repotest.NewProductionRepository(
+		&model.ProductionInfoWithDetails{ProductionInfo: model.ProductionInfo{ID: 1, ProductID: 1, ProductPlaceID: 2, Quantity: 100, Unit: "kg"}},
+		&model.ProductionInfoWithDetails{ProductionInfo: model.ProductionInfo{ID: 2, ProductID: 1, ProductPlaceID: 4, Quantity: 10, Unit: "kg"}},
+	)
+	repos.Logistics = repotest.NewLogisticsRepository(
+		&model.Logistics{ID: 1, ProductInfoID: 1, CompanyID: 1, Quantity: ptr(60.0), Status: model.LogisticsStatusCreated,
+			Destination: "超市1", ProductPlaceID: 2, SalePlaceIDs: []int{5}},
+		&model.Logistics{ID: 2, ProductInfoID: 1, CompanyID: 2, Status: model.LogisticsStatusDelivered, ProductPlaceID: 2},
+		&model.Logistics{ID: 3, ProductInfoID: 2, CompanyID: 1, Status: model.LogisticsStatusInTransit, ProductPlaceID: 4},
+	)
+	repos.Logistics.ProductPlaces[1] = 2
+	repos.Logistics.ProductPlaces[2] = 4
+	repos.Batch = repotest.NewBatchRepository(repos.Production, repos.Logistics)
+	repos.exist(model.AuditEntityProduction, 1, 2)
+	repos.exist(model.AuditEntityCompany, 1, 2)
+	repos.exist(model.AuditEntityLogistics, 1, 2, 3)
+	repos.Reference.AddExisting(model.AuditEntityCompany, 3, true)
+	return repos
+}
+
+func newLogisticsService(repos *testRepos) *LogisticsService {
+	return NewLogisticsService(repos.Logistics, repos.LogisticsEvent, repos.uow())
+}
+
+func TestLogisticsService_Save(t *testing.T) {
+	endTime := time.Date(2024, 6, 21, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		scope       model.DataScope
+		logistics   model.Logistics
+		wantErr     error
+		wantCompany int
+		wantStatus  string
+	}{
+		{
+			name: "物流用户默认本公司", scope: companyScope(1),
+			logistics:   model.Logistics{ProductInfoID: 1, Quantity: ptr(40.0)},
+			wantCompany: 1, wantStatus: model.LogisticsStatusCreated,
+		},
+		{
+			name: "补录已送达的物流", scope: adminScope,
+			logistics:   model.Logistics{ProductInfoID: 1, CompanyID: 2, EndTime: &endTime},
+			wantCompany: 2, wantStatus: model.LogisticsStatusDelivered,
+		},
+		{
+			name: "同一批次的上一运输段", scope: adminScope,
+			logistics:   model.Logistics{ProductInfoID: 1, CompanyID: 2, PrevID: ptr(1)},
+			wantCompany: 2, wantStatus: model.LogisticsStatusCreated,
+		},
+		{
+			name: "物流用户指定其他公司", scope: companyScope(1),
+			logistics: model.Logistics{ProductInfoID: 1, CompanyID: 2},
+			wantErr:   ErrLogisticsForbidden,
+		},
+		{
+			name: "农场用户运输其他生产地的批次", scope: productPlaceScope(4),
+			logistics: model.Logistics{ProductInfoID: 1, CompanyID: 1},
+			wantErr:   ErrLogisticsForbidden,
+		},
+		{
+			name: "公司已删除", scope: adminScope,
+			logistics: model.Logistics{ProductInfoID: 1, CompanyID: 3},
+			wantErr:   ErrReferenceNotFound,
+		},
+		{
+			name: "上一运输段不存在", scope: adminScope,
+			logistics: model.Logistics{ProductInfoID: 1, CompanyID: 1, PrevID: ptr(99)},
+			wantErr:   ErrReferenceNotFound,
+		},
+		{
+			name: "上一运输段属于其他批次", scope: adminScope,
+			logistics: model.Logistics{ProductInfoID: 1, CompanyID: 1, PrevID: ptr(3)},
+			wantErr:   ErrLogisticsPrev,
+		},
+		{
+			name: "超出批次剩余数量", scope: adminScope,
+			logistics: model.Logistics{ProductInfoID: 1, CompanyID: 1, Quantity: ptr(40.5)},
+			wantErr:   ErrBatchQuantityExceeded,
+		},
+		{
+			name: "分配数量不是正数", scope: adminScope,
+			logistics: model.Logistics{ProductInfoID: 1, CompanyID: 1, Quantity: ptr(0.0)},
+			wantErr:   ErrBatchQuantityInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newLogisticsRepos()
+			s := newLogisticsService(repos)
+
+			id, err := s.Save(tt.scope, &tt.logistics)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, 期望 %v", err, tt.wantErr)
+				}
+				if len(repos.Logistics.Logistics) != 3 {
+					t.Fatalf("失败后仍保存了物流信息: %d条", len(repos.Logistics.Logistics))
+				}
+				assertAudits(t, repos)
+				return
+			}
+			mustNoError(t, err)
+
+			saved := repos.Logistics.Logistics[id]
+			if saved == nil || saved.CompanyID != tt.wantCompany || saved.Status != tt.wantStatus ||
+				saved.StatusTime == nil || saved.ProductPlaceID != 2 {
+				t.Fatalf("保存的物流信息 = %+v", saved)
+			}
+			assertAudits(t, repos, auditKey(model.AuditEntityLogistics, id, model.AuditActionCreate))
+		})
+	}
+}
+
+func TestLogisticsService_Update(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     model.DataScope
+		logistics model.Logistics
+		wantErr   error
+	}{
+		{
+			name: "分配数量不计本物流已分配的数量，不修改到达时间", scope: companyScope(1),
+			logistics: model.Logistics{ID: 1, ProductInfoID: 1, CompanyID: 1, Quantity: ptr(100.0), Destination: "超市2", EndTime: ptr(time.Now())},
+		},
+		{
+			name: "超出批次数量", scope: adminScope,
+			logistics: model.Logistics{ID: 1, ProductInfoID: 1, CompanyID: 1, Quantity: ptr(100.5)},
+			wantErr:   ErrBatchQuantityExceeded,
+		},
+		{
+			name: "改为其他公司承运", scope: companyScope(1),
+			logistics: model.Logistics{ID: 1, ProductInfoID: 1, CompanyID: 2},
+			wantErr:   ErrLogisticsForbidden,
+		},
+		{
+			name: "上一运输段是自身", scope: adminScope,
+			logistics: model.Logistics{ID: 1, ProductInfoID: 1, CompanyID: 1, PrevID: ptr(1)},
+			wantErr:   ErrLogisticsPrev,
+		},
+		{
+			name: "已送达", scope: adminScope,
+			logistics: model.Logistics{ID: 2, ProductInfoID: 1, CompanyID: 2},
+			wantErr:   ErrLogisticsFinalized,
+		},
+		{
+			name: "不存在", scope: adminScope,
+			logistics: model.Logistics{ID: 99, ProductInfoID: 1, CompanyID: 1},
+			wantErr:   ErrLogisticsNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newLogisticsRepos()
+			s := newLogisticsService(repos)
+
+			err := s.Update(tt.scope, &tt.logistics)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, 期望 %v", err, tt.wantErr)
+				}
+				assertAudits(t, repos)
+				return
+			}
+			mustNoError(t, err)
+
+			saved := repos.Logistics.Logistics[tt.logistics.ID]
+			if *saved.Quantity != *tt.logistics.Quantity || saved.Destination != tt.logistics.Destination ||
+				saved.Status != model.LogisticsStatusCreated || saved.EndTime != nil {
+				t.Fatalf("更新后 = %+v", saved)
+			}
+			assertAudits(t, repos, auditKey(model.AuditEntityLogistics, tt.logistics.ID, model.AuditActionUpdate))
+		})
+	}
+}
+
+// staleLogisticsRepository 模拟读取后状态被并发流转：GetByID返回的状态固定为status
+type staleLogisticsRepository struct {
+	*repotest.LogisticsRepository
+	status string
+}
+
+func (r *staleLogisticsRepository) GetByID(id int) (*model.Logistics, error) {
+	logistics, err := r.LogisticsRepository.GetByID(id)
+	if logistics != nil {
+		logistics.Status = r.status
+	}
+	return logistics, err
+}
+
+func TestLogisticsService_Update_Conflict(t *testing.T) {
+	// 物流3读取时为已创建，写入前已被流转为运输中
+	repos := newLogisticsRepos()
+	stale := &staleLogisticsRepository{LogisticsRepository: repos.Logistics, status: model.LogisticsStatusCreated}
+	uow := repotest.NewUnitOfWork(&repository.Repositories{
+		Logistics: stale, Batch: repos.Batch, Audit: repos.Audit, Reference: repos.Reference,
+	})
+	s := NewLogisticsService(stale, repos.LogisticsEvent, uow)
+
+	err := s.Update(adminScope, &model.Logistics{ID: 3, ProductInfoID: 2, CompanyID: 1, Destination: "超市2"})
+	if !errors.Is(err, ErrLogisticsConflict) {
+		t.Fatalf("err = %v, 期望 %v", err, ErrLogisticsConflict)
+	}
+	if saved := repos.Logistics.Logistics[3]; saved.Destination == "超市2" || saved.Status != model.LogisticsStatusInTransit {
+		t.Fatalf("冲突后物流被修改: %+v", saved)
+	}
+	assertAudits(t, repos)
+}
+
+func TestLogisticsService_Transition(t *testing.T) {
+	tests := []struct {
+		name       string
+		scope      model.DataScope
+		id         int
+		status     string
+		reason     string
+		wantErr    error
+		wantEvents int
+	}{
+		{name: "开始运输", scope: companyScope(1), id: 1, status: model.LogisticsStatusInTransit},
+		{name: "确认送达", scope: companyScope(1), id: 1, status: model.LogisticsStatusDelivered, reason: "签收", wantEvents: 1},
+		{name: "带原因取消", scope: adminScope, id: 1, status: model.LogisticsStatusCancelled, reason: "订单取消"},
+		{name: "取消未填写原因", scope: adminScope, id: 1, status: model.LogisticsStatusCancelled, wantErr: ErrLogisticsReason},
+		{name: "无效的状态", scope: adminScope, id: 1, status: "lost", wantErr: ErrLogisticsStatus},
+		{name: "已创建不能直接拒收", scope: adminScope, id: 1, status: model.LogisticsStatusRejected, reason: "破损", wantErr: ErrLogisticsTransition},
+		{name: "已送达不能再流转", scope: adminScope, id: 2, status: model.LogisticsStatusInTransit, wantErr: ErrLogisticsFinalized},
+		{name: "不存在", scope: adminScope, id: 99, status: model.LogisticsStatusInTransit, wantErr: ErrLogisticsNotFound},
+		{name: "其他公司的物流", scope: companyScope(2), id: 1, status: model.LogisticsStatusInTransit, wantErr: ErrLogisticsForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newLogisticsRepos()
+			s := newLogisticsService(repos)
+
+			err := s.Transition(tt.scope, tt.id, tt.status, tt.reason)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, 期望 %v", err, tt.wantErr)
+				}
+				assertAudits(t, repos)
+				return
+			}
+			mustNoError(t, err)
+
+			saved := repos.Logistics.Logistics[tt.id]
+			if saved.Status != tt.status || saved.StatusReason != tt.reason {
+				t.Fatalf("流转后 = %+v", saved)
+			}
+			if (saved.EndTime != nil) != (tt.status == model.LogisticsStatusDelivered) {
+				t.Fatalf("到达时间 = %v", saved.EndTime)
+			}
+			events, err := repos.LogisticsEvent.FindByLogisticsID(tt.id)
+			mustNoError(t, err)
+			if len(events) != tt.wantEvents {
+				t.Fatalf("物流事件 = %+v, 期望%d条", events, tt.wantEvents)
+			}
+			if tt.wantEvents > 0 && (events[0].EventType != model.LogisticsEventDelivered || events[0].Location != "超市1") {
+				t.Fatalf("送达事件 = %+v", events[0])
+			}
+			assertAudits(t, repos, auditKey(model.AuditEntityLogistics, tt.id, model.AuditActionUpdate))
+		})
+	}
+}
+
+func TestLogisticsService_RejectedReleasesAllocation(t *testing.T) {
+	repos := newLogisticsRepos()
+	s := newLogisticsService(repos)
+
+	// 物流1分配了60，拒收前批次剩余40
+	_, err := s.Save(adminScope, &model.Logistics{ProductInfoID: 1, CompanyID: 1, Quantity: ptr(100.0)})
+	if !errors.Is(err, ErrBatchQuantityExceeded) {
+		t.Fatalf("拒收前 err = %v, 期望 %v", err, ErrBatchQuantityExceeded)
+	}
+
+	mustNoError(t, s.Transition(adminScope, 1, model.LogisticsStatusInTransit, ""))
+	mustNoError(t, s.Transition(adminScope, 1, model.LogisticsStatusRejected, "破损"))
+	_, err = s.Save(adminScope, &model.Logistics{ProductInfoID: 1, CompanyID: 1, Quantity: ptr(100.0)})
+	mustNoError(t, err)
+}
+
+func TestLogisticsService_AddEvent(t *testing.T) {
+	tests := []struct {
+		name       string
+		scope      model.DataScope
+		event      model.LogisticsEvent
+		wantErr    error
+		wantStatus string
+	}{
+		{
+			name: "首个事件开始运输", scope: companyScope(1),
+			event:      model.LogisticsEvent{LogisticsID: 1, EventType: model.LogisticsEventPickup, Location: "农场"},
+			wantStatus: model.LogisticsStatusInTransit,
+		},
+		{
+			name: "运输中追加交接事件", scope: adminScope,
+			event:      model.LogisticsEvent{LogisticsID: 3, EventType: model.LogisticsEventHandover, CompanyID: 2},
+			wantStatus: model.LogisticsStatusInTransit,
+		},
+		{
+			name: "交接给已删除的公司", scope: adminScope,
+			event:   model.LogisticsEvent{LogisticsID: 3, EventType: model.LogisticsEventHandover, CompanyID: 3},
+			wantErr: ErrReferenceNotFound,
+		},
+		{
+			name: "交接未指定公司", scope: adminScope,
+			event:   model.LogisticsEvent{LogisticsID: 3, EventType: model.LogisticsEventHandover},
+			wantErr: ErrLogisticsEventCompany,
+		},
+		{
+			name: "手动添加送达事件", scope: adminScope,
+			event:   model.LogisticsEvent{LogisticsID: 1, EventType: model.LogisticsEventDelivered},
+			wantErr: ErrLogisticsEventDelivery,
+		},
+		{
+			name: "无效的事件类型", scope: adminScope,
+			event:   model.LogisticsEvent{LogisticsID: 1, EventType: "lost"},
+			wantErr: ErrLogisticsEventType,
+		},
+		{
+			name: "已送达的物流", scope: adminScope,
+			event:   model.LogisticsEvent{LogisticsID: 2, EventType: model.LogisticsEventPickup},
+			wantErr: ErrLogisticsFinalized,
+		},
+		{
+			name: "其他公司的物流", scope: companyScope(2),
+			event:   model.LogisticsEvent{LogisticsID: 1, EventType: model.LogisticsEventPickup},
+			wantErr: ErrLogisticsForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newLogisticsRepos()
+			s := newLogisticsService(repos)
+
+			id, err := s.AddEvent(tt.scope, &tt.event)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, 期望 %v", err, tt.wantErr)
+				}
+				if len(repos.LogisticsEvent.Events) != 0 {
+					t.Fatalf("失败后仍保存了事件: %+v", repos.LogisticsEvent.Events)
+				}
+				return
+			}
+			mustNoError(t, err)
+
+			event, err := repos.LogisticsEvent.GetByID(id)
+			mustNoError(t, err)
+			if event == nil || event.EventTime.IsZero() || event.CompanyID <= 0 {
+				t.Fatalf("保存的事件 = %+v", event)
+			}
+			if status := repos.Logistics.Logistics[tt.event.LogisticsID].Status; status != tt.wantStatus {
+				t.Fatalf("物流状态 = %s, 期望 %s", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestLogisticsService_Delete(t *testing.T) {
+	repos := newLogisticsRepos()
+	repos.Reference.AddBlocking(model.AuditEntityLogistics, 1,
+		model.NewBlockingReference(model.AuditEntitySaleInfo, 1, []int{1}, model.AuditEntityLogistics))
+	s := newLogisticsService(repos)
+
+	var referenced *ReferencedError
+	if err := s.Delete(adminScope, 1); !errors.As(err, &referenced) || len(referenced.References) != 1 {
+		t.Fatalf("删除仍被引用的物流 err = %v", err)
+	}
+	if err := s.Delete(companyScope(2), 3); !errors.Is(err, ErrLogisticsForbidden) {
+		t.Fatalf("删除其他公司的物流 err = %v", err)
+	}
+	if err := s.Delete(adminScope, 99); !errors.Is(err, ErrLogisticsNotFound) {
+		t.Fatalf("删除不存在的物流 err = %v", err)
+	}
+	if err := s.Delete(adminScope, 2); !errors.Is(err, ErrLogisticsFinalized) {
+		t.Fatalf("删除已送达的物流 err = %v", err)
+	}
+	mustNoError(t, s.Delete(companyScope(1), 3))
+	if _, ok := repos.Logistics.Logistics[3]; ok {
+		t.Fatal("删除后物流信息仍存在")
+	}
+	assertAudits(t, repos, auditKey(model.AuditEntityLogistics, 3, model.AuditActionDelete))
+}
+
+func TestLogisticsService_DeleteEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		scope   model.DataScope
+		id      int
+		wantErr error
+	}{
+		{name: "删除运输中的事件", scope: companyScope(1), id: 1},
+		{name: "其他公司的物流", scope: companyScope(2), id: 1, wantErr: ErrLogisticsForbidden},
+		{name: "物流已送达", scope: adminScope, id: 2, wantErr: ErrLogisticsFinalized},
+		{name: "物流已不存在", scope: adminScope, id: 3, wantErr: ErrLogisticsNotFound},
+		{name: "事件不存在", scope: adminScope, id: 99, wantErr: ErrLogisticsEventNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newLogisticsRepos()
+			repos.LogisticsEvent = repotest.NewLogisticsEventRepository(
+				&model.LogisticsEvent{ID: 1, LogisticsID: 3, EventType: model.LogisticsEventPickup, CompanyID: 1},
+				&model.LogisticsEvent{ID: 2, LogisticsID: 2, EventType: model.LogisticsEventPickup, CompanyID: 2},
+				&model.LogisticsEvent{ID: 3, LogisticsID: 99, EventType: model.LogisticsEventPickup, CompanyID: 1},
+			)
+			s := newLogisticsService(repos)
+
+			err := s.DeleteEvent(tt.scope, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, 期望 %v", err, tt.wantErr)
+			}
+			if _, exists := repos.LogisticsEvent.Events[tt.id]; exists != (tt.wantErr != nil && tt.id != 99) {
+				t.Fatalf("删除后事件是否存在 = %v", exists)
+			}
+			if tt.wantErr != nil {
+				assertAudits(t, repos)
+				return
+			}
+			assertAudits(t, repos, auditKey(model.AuditEntityLogisticsEvent, tt.id, model.AuditActionDelete))
+		})
+	}
+}
+
+func TestLogisticsService_GetByID(t *testing.T) {
+	repos := newLogisticsRepos()
+	s := newLogisticsService(repos)
+
+	tests := []struct {
+		name  string
+		scope model.DataScope
+		id    int
+		want  bool
+	}{
+		{name: "管理员", scope: adminScope, id: 1, want: true},
+		{name: "承运公司", scope: companyScope(1), id: 1, want: true},
+		{name: "其他公司", scope: companyScope(2), id: 1},
+		{name: "批次所属生产地", scope: productPlaceScope(2), id: 1, want: true},
+		{name: "其他生产地", scope: productPlaceScope(4), id: 1},
+		{name: "销售信息关联的销售地", scope: salePlaceScope(5), id: 1, want: true},
+		{name: "无关的销售地", scope: salePlaceScope(6), id: 1},
+		{name: "不存在", scope: adminScope, id: 99},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetByID(tt.scope, tt.id)
+			mustNoError(t, err)
+			if (got != nil) != tt.want {
+				t.Fatalf("GetByID = %+v, 期望存在: %v", got, tt.want)
+			}
+		})
+	}
+}
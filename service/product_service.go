@@ -2,6 +2,7 @@ package service
 
 import (
 	"log"
+	"time"
 
 	"agricultural_product_gin/dto"
 	"agricultural_product_gin/model"
@@ -15,6 +16,11 @@ type FrontendProduct struct {
 	Image       string  `json:"image"`
 	Description string  `json:"pdDescription"`
 	UnitPrice   float64 `json:"unitPrice"`
+
+	MinTemperature *float64 `json:"minTemperature"`
+	MaxTemperature *float64 `json:"maxTemperature"`
+	MinHumidity    *float64 `json:"minHumidity"`
+	MaxHumidity    *float64 `json:"maxHumidity"`
 }
 
 func convertProductForFrontend(p *model.Product) *FrontendProduct {
@@ -29,31 +35,54 @@ func convertProductForFrontend(p *model.Product) *FrontendProduct {
 		Image:       p.Image,
 		Description: p.Description,
 		UnitPrice:   price,
+
+		MinTemperature: p.MinTemperature,
+		MaxTemperature: p.MaxTemperature,
+		MinHumidity:    p.MinHumidity,
+		MaxHumidity:    p.MaxHumidity,
 	}
 }
 
 // ProductService 产品服务
 type ProductService struct {
-	ProductRepo *repository.ProductRepository
+	ProductRepo repository.ProductRepository
+	uow         repository.UnitOfWork
 }
 
 // NewProductService 创建产品服务
-func NewProductService(productRepo *repository.ProductRepository) *ProductService {
-	return &ProductService{ProductRepo: productRepo}
+func NewProductService(productRepo repository.ProductRepository, uow repository.UnitOfWork) *ProductService {
+	return &ProductService{ProductRepo: productRepo, uow: uow}
 }
 
 // CreateProduct 创建产品
-func (s *ProductService) CreateProduct(productDTO *dto.ProductDTO) *dto.Result {
+func (s *ProductService) CreateProduct(scope model.DataScope, productDTO *dto.ProductDTO) *dto.Result {
+	if msg := validateThresholds(productDTO); msg != "" {
+		return errorResult(400, msg)
+	}
+
 	product := &model.Product{
 		Name:        productDTO.Name,
 		Type:        productDTO.Type,
 		Image:       productDTO.Image,
 		Description: productDTO.Description,
 		UnitPrice:   productDTO.UnitPrice,
+
+		MinTemperature: productDTO.MinTemperature,
+		MaxTemperature: productDTO.MaxTemperature,
+		MinHumidity:    productDTO.MinHumidity,
+		MaxHumidity:    productDTO.MaxHumidity,
 	}
 
 	// 保存产品
-	id, err := s.ProductRepo.Save(product)
+	var id int
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		var err error
+		if id, err = repos.Product.Save(product); err != nil {
+			return err
+		}
+		product.ID = id
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityProduct, id, model.AuditActionCreate, nil, product)
+	})
 	if err != nil {
 		log.Println("创建产品失败:", err)
 		return errorResult(500, "创建产品失败")
@@ -64,7 +93,11 @@ func (s *ProductService) CreateProduct(productDTO *dto.ProductDTO) *dto.Result {
 }
 
 // UpdateProduct 更新产品
-func (s *ProductService) UpdateProduct(productDTO *dto.ProductDTO) *dto.Result {
+func (s *ProductService) UpdateProduct(scope model.DataScope, productDTO *dto.ProductDTO) *dto.Result {
+	if msg := validateThresholds(productDTO); msg != "" {
+		return errorResult(400, msg)
+	}
+
 	// 检查产品是否存在
 	existingProduct, err := s.ProductRepo.GetByID(productDTO.ID)
 	if err != nil {
@@ -75,6 +108,9 @@ func (s *ProductService) UpdateProduct(productDTO *dto.ProductDTO) *dto.Result {
 	if existingProduct == nil {
 		return errorResult(404, "产品不存在")
 	}
+	if existingProduct.Deleted() {
+		return errorResult(409, "产品已删除，请先恢复")
+	}
 
 	// 转换DTO为模型
 	product := &model.Product{
@@ -84,9 +120,19 @@ func (s *ProductService) UpdateProduct(productDTO *dto.ProductDTO) *dto.Result {
 		Image:       productDTO.Image,
 		Description: productDTO.Description,
 		UnitPrice:   productDTO.UnitPrice,
+
+		MinTemperature: productDTO.MinTemperature,
+		MaxTemperature: productDTO.MaxTemperature,
+		MinHumidity:    productDTO.MinHumidity,
+		MaxHumidity:    productDTO.MaxHumidity,
 	}
 	// 更新产品
-	err = s.ProductRepo.Update(product)
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.Product.Update(product); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityProduct, product.ID, model.AuditActionUpdate, existingProduct, product)
+	})
 	if err != nil {
 		log.Println("更新产品失败:", err)
 		return errorResult(500, "更新失败")
@@ -95,8 +141,8 @@ func (s *ProductService) UpdateProduct(productDTO *dto.ProductDTO) *dto.Result {
 	return successResult("更新成功", nil)
 }
 
-// DeleteProduct 删除产品
-func (s *ProductService) DeleteProduct(id int) *dto.Result {
+// DeleteProduct 删除产品(软删除)，已有生产信息中的产品仍可用于溯源
+func (s *ProductService) DeleteProduct(scope model.DataScope, id int) *dto.Result {
 	// 检查产品是否存在
 	existingProduct, err := s.ProductRepo.GetByID(id)
 	if err != nil {
@@ -107,9 +153,17 @@ func (s *ProductService) DeleteProduct(id int) *dto.Result {
 	if existingProduct == nil {
 		return errorResult(404, "产品不存在")
 	}
+	if existingProduct.Deleted() {
+		return errorResult(409, "产品已删除")
+	}
 
 	// 删除产品
-	err = s.ProductRepo.Delete(id)
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.Product.Delete(id, time.Now(), scope.Actor.Username); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityProduct, id, model.AuditActionDelete, existingProduct, nil)
+	})
 	if err != nil {
 		log.Println("删除产品失败:", err)
 		return errorResult(500, "删除失败")
@@ -118,7 +172,38 @@ func (s *ProductService) DeleteProduct(id int) *dto.Result {
 	return successResult("删除成功", nil)
 }
 
-// GetProductByID 根据ID获取产品
+// RestoreProduct 恢复已删除的产品
+func (s *ProductService) RestoreProduct(scope model.DataScope, id int) *dto.Result {
+	existingProduct, err := s.ProductRepo.GetByID(id)
+	if err != nil {
+		log.Println("查询产品失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	if existingProduct == nil {
+		return errorResult(404, "产品不存在")
+	}
+	if !existingProduct.Deleted() {
+		return errorResult(409, "产品未删除")
+	}
+
+	restored := *existingProduct
+	restored.SoftDelete = model.SoftDelete{}
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.Product.Restore(id); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityProduct, id, model.AuditActionRestore, existingProduct, &restored)
+	})
+	if err != nil {
+		log.Println("恢复产品失败:", err)
+		return errorResult(500, "恢复失败")
+	}
+
+	return successResult("恢复成功", nil)
+}
+
+// GetProductByID 根据ID获取产品，已删除的产品同样返回(含删除时间及删除人)
 func (s *ProductService) GetProductByID(id int) *dto.Result {
 	product, err := s.ProductRepo.GetByID(id)
 	if err != nil {
@@ -137,9 +222,9 @@ func (s *ProductService) GetProductByID(id int) *dto.Result {
 	}
 }
 
-// GetAllProducts 获取所有产品
-func (s *ProductService) GetAllProducts() *dto.Result {
-	products, err := s.ProductRepo.FindAll()
+// GetAllProducts 获取所有产品，includeDeleted为false时不含已删除的产品
+func (s *ProductService) GetAllProducts(includeDeleted bool) *dto.Result {
+	products, err := s.ProductRepo.FindAll(includeDeleted)
 	if err != nil {
 		log.Println("获取所有产品失败:", err)
 		return errorResult(500, "系统错误")
@@ -183,7 +268,8 @@ func (s *ProductService) PageQueryProducts(queryDTO *dto.ProductPageQueryDTO) *d
 	}
 
 	// 分页查询
-	products, total, err := s.ProductRepo.PageQuery(queryDTO.Page, queryDTO.PageSize, queryDTO.Name, queryDTO.Type)
+	products, total, err := s.ProductRepo.PageQuery(queryDTO.Page, queryDTO.PageSize, queryDTO.Name, queryDTO.Type,
+		queryDTO.IncludeDeleted)
 	if err != nil {
 		log.Println("分页查询产品失败:", err)
 		return errorResult(500, "系统错误")
@@ -213,3 +299,16 @@ func (s *ProductService) GetProductTypes() *dto.Result {
 		Data: types,
 	}
 }
+
+// validateThresholds 校验冷链阈值上下限，返回错误信息
+func validateThresholds(productDTO *dto.ProductDTO) string {
+	if productDTO.MinTemperature != nil && productDTO.MaxTemperature != nil &&
+		*productDTO.MinTemperature > *productDTO.MaxTemperature {
+		return "最低温度不能高于最高温度"
+	}
+	if productDTO.MinHumidity != nil && productDTO.MaxHumidity != nil &&
+		*productDTO.MinHumidity > *productDTO.MaxHumidity {
+		return "最低湿度不能高于最高湿度"
+	}
+	return ""
+}
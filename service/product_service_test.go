@@ -0,0 +1,197 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository/repotest"
+)
+
+func TestProductService_CreateProduct(t *testing.T) {
+	tests := []struct {
+		name     string
+		product  dto.ProductDTO
+		repoErr  error
+		auditErr error
+		wantCode int
+		wantMsg  string
+	}{
+		{name: "创建成功", product: dto.ProductDTO{Name: "苹果", Type: "水果"}, wantCode: 200, wantMsg: "添加成功"},
+		{
+			name:     "温度上下限相同",
+			product:  dto.ProductDTO{Name: "苹果", Type: "水果", MinTemperature: ptr(4.0), MaxTemperature: ptr(4.0)},
+			wantCode: 200,
+		},
+		{
+			name:     "最低温度高于最高温度",
+			product:  dto.ProductDTO{Name: "苹果", Type: "水果", MinTemperature: ptr(8.0), MaxTemperature: ptr(2.0)},
+			wantCode: 400, wantMsg: "最低温度不能高于最高温度",
+		},
+		{
+			name:     "最低湿度高于最高湿度",
+			product:  dto.ProductDTO{Name: "苹果", Type: "水果", MinHumidity: ptr(90.0), MaxHumidity: ptr(60.0)},
+			wantCode: 400, wantMsg: "最低湿度不能高于最高湿度",
+		},
+		{name: "保存失败", product: dto.ProductDTO{Name: "苹果", Type: "水果"}, repoErr: errFake, wantCode: 500},
+		{name: "审计日志写入失败时回滚", product: dto.ProductDTO{Name: "苹果", Type: "水果"}, auditErr: errFake, wantCode: 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestRepos()
+			repos.Product.Err = tt.repoErr
+			repos.Audit.Err = tt.auditErr
+			s := NewProductService(repos.Product, repos.uow())
+
+			result := s.CreateProduct(adminScope, &tt.product)
+			assertResult(t, result, tt.wantCode, tt.wantMsg)
+			if tt.wantCode != 200 {
+				if len(repos.Product.Products) != 0 {
+					t.Fatalf("失败后仍保存了产品: %d个", len(repos.Product.Products))
+				}
+				assertAudits(t, repos)
+				return
+			}
+
+			id := result.Data.(int)
+			saved := repos.Product.Products[id]
+			if saved == nil || saved.Name != tt.product.Name || saved.Type != tt.product.Type {
+				t.Fatalf("保存的产品 = %+v", saved)
+			}
+			assertAudits(t, repos, auditKey(model.AuditEntityProduct, id, model.AuditActionCreate))
+		})
+	}
+}
+
+func TestProductService_Lifecycle(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	const (
+		active  = 1
+		deleted = 2
+		missing = 99
+	)
+
+	tests := []struct {
+		name      string
+		run       func(s *ProductService) *dto.Result
+		wantCode  int
+		wantMsg   string
+		wantAudit string
+	}{
+		{
+			name: "更新",
+			run: func(s *ProductService) *dto.Result {
+				return s.UpdateProduct(adminScope, &dto.ProductDTO{ID: active, Name: "红富士", Type: "水果"})
+			},
+			wantCode: 200, wantAudit: auditKey(model.AuditEntityProduct, active, model.AuditActionUpdate),
+		},
+		{
+			name: "更新不存在的产品",
+			run: func(s *ProductService) *dto.Result {
+				return s.UpdateProduct(adminScope, &dto.ProductDTO{ID: missing, Name: "红富士", Type: "水果"})
+			},
+			wantCode: 404, wantMsg: "产品不存在",
+		},
+		{
+			name: "更新已删除的产品",
+			run: func(s *ProductService) *dto.Result {
+				return s.UpdateProduct(adminScope, &dto.ProductDTO{ID: deleted, Name: "白菜", Type: "蔬菜"})
+			},
+			wantCode: 409, wantMsg: "产品已删除，请先恢复",
+		},
+		{
+			name: "更新时阈值无效",
+			run: func(s *ProductService) *dto.Result {
+				return s.UpdateProduct(adminScope, &dto.ProductDTO{ID: active, Name: "苹果", Type: "水果",
+					MinTemperature: ptr(10.0), MaxTemperature: ptr(0.0)})
+			},
+			wantCode: 400, wantMsg: "最低温度不能高于最高温度",
+		},
+		{
+			name:     "删除",
+			run:      func(s *ProductService) *dto.Result { return s.DeleteProduct(adminScope, active) },
+			wantCode: 200, wantAudit: auditKey(model.AuditEntityProduct, active, model.AuditActionDelete),
+		},
+		{
+			name:     "删除不存在的产品",
+			run:      func(s *ProductService) *dto.Result { return s.DeleteProduct(adminScope, missing) },
+			wantCode: 404, wantMsg: "产品不存在",
+		},
+		{
+			name:     "重复删除",
+			run:      func(s *ProductService) *dto.Result { return s.DeleteProduct(adminScope, deleted) },
+			wantCode: 409, wantMsg: "产品已删除",
+		},
+		{
+			name:     "恢复",
+			run:      func(s *ProductService) *dto.Result { return s.RestoreProduct(adminScope, deleted) },
+			wantCode: 200, wantAudit: auditKey(model.AuditEntityProduct, deleted, model.AuditActionRestore),
+		},
+		{
+			name:     "恢复未删除的产品",
+			run:      func(s *ProductService) *dto.Result { return s.RestoreProduct(adminScope, active) },
+			wantCode: 409, wantMsg: "产品未删除",
+		},
+		{
+			name:     "查询已删除的产品",
+			run:      func(s *ProductService) *dto.Result { return s.GetProductByID(deleted) },
+			wantCode: 200,
+		},
+		{
+			name:     "查询不存在的产品",
+			run:      func(s *ProductService) *dto.Result { return s.GetProductByID(missing) },
+			wantCode: 404, wantMsg: "产品不存在",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestRepos()
+			repos.Product = repotest.NewProductRepository(
+				&model.Product{ID: active, Name: "苹果", Type: "水果"},
+				&model.Product{ID: deleted, Name: "白菜", Type: "蔬菜",
+					SoftDelete: model.SoftDelete{DeletedAt: &deletedAt, DeletedBy: "admin"}},
+			)
+			s := NewProductService(repos.Product, repos.uow())
+
+			assertResult(t, tt.run(s), tt.wantCode, tt.wantMsg)
+			if tt.wantAudit == "" {
+				assertAudits(t, repos)
+			} else {
+				assertAudits(t, repos, tt.wantAudit)
+			}
+		})
+	}
+}
+
+func TestProductService_DeleteAndRestore(t *testing.T) {
+	repos := newTestRepos()
+	repos.Product = repotest.NewProductRepository(&model.Product{Name: "苹果", Type: "水果"}, &model.Product{Name: "白菜", Type: "蔬菜"})
+	s := NewProductService(repos.Product, repos.uow())
+
+	assertResult(t, s.DeleteProduct(adminScope, 1), 200, "删除成功")
+	deleted := repos.Product.Products[1]
+	if !deleted.Deleted() || deleted.DeletedBy != "admin" {
+		t.Fatalf("删除后 = %+v", deleted)
+	}
+
+	// 已删除的产品不出现在列表中，管理员可以查询包含已删除的列表
+	if list := s.GetAllProducts(false).Data.([]*FrontendProduct); len(list) != 1 || list[0].ID != 2 {
+		t.Fatalf("GetAllProducts(false) = %+v", list)
+	}
+	if list := s.GetAllProducts(true).Data.([]*FrontendProduct); len(list) != 2 {
+		t.Fatalf("GetAllProducts(true) = %+v", list)
+	}
+	page := s.PageQueryProducts(&dto.ProductPageQueryDTO{}).Data.(*dto.PageResult)
+	if page.Total != 1 || page.Page != 1 || page.PageSize != 10 {
+		t.Fatalf("PageQueryProducts = %+v", page)
+	}
+
+	assertResult(t, s.RestoreProduct(adminScope, 1), 200, "恢复成功")
+	if repos.Product.Products[1].Deleted() {
+		t.Fatalf("恢复后仍为已删除: %+v", repos.Product.Products[1])
+	}
+	if list := s.GetAllProducts(false).Data.([]*FrontendProduct); len(list) != 2 {
+		t.Fatalf("恢复后GetAllProducts(false) = %+v", list)
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"log"
 
 	"agricultural_product_gin/dto"
@@ -8,40 +9,76 @@ import (
 	"agricultural_product_gin/repository"
 )
 
+// ProductionForbidden 超出数据权限范围的提示
+const ProductionForbidden = "无权操作该生产信息"
+
 // ProductionService 生产信息服务
 type ProductionService struct {
-	ProductionRepo *repository.ProductionRepository
+	ProductionRepo repository.ProductionRepository
+	uow            repository.UnitOfWork
 }
 
 // NewProductionService 创建生产信息服务
-func NewProductionService(repo *repository.ProductionRepository) *ProductionService {
-	return &ProductionService{ProductionRepo: repo}
+func NewProductionService(repo repository.ProductionRepository, uow repository.UnitOfWork) *ProductionService {
+	return &ProductionService{ProductionRepo: repo, uow: uow}
 }
 
-// CreateProduction 创建生产信息
-func (s *ProductionService) CreateProduction(dto *dto.ProductionDTO) *dto.Result {
+// CreateProduction 创建生产信息，农场用户未指定生产地时默认为其绑定的生产地
+func (s *ProductionService) CreateProduction(scope model.DataScope, dto *dto.ProductionDTO) *dto.Result {
+	if scope.ProductPlaceID != nil && dto.ProductPlaceID <= 0 {
+		dto.ProductPlaceID = *scope.ProductPlaceID
+	}
+	if !scope.AllowProductPlace(dto.ProductPlaceID) {
+		return errorResult(403, ProductionForbidden)
+	}
+
+	if dto.Quantity < 0 {
+		return errorResult(400, "批次数量不能为负数")
+	}
+
 	// 转换DTO为模型
 	production := &model.ProductionInfo{
+		BatchNo:        dto.BatchNo,
 		ProductID:      dto.ProductID,
 		ProductPlaceID: dto.ProductPlaceID,
 		SeedSource:     dto.SeedSource,
 		Description:    dto.Description,
 		PlantingDate:   dto.PlantingDate,
 		HarvestDate:    dto.HarvestDate,
+		Quantity:       dto.Quantity,
+		Unit:           dto.Unit,
 	}
 
-	// 保存生产信息
-	id, err := s.ProductionRepo.Save(production)
+	// 分配批次号并保存生产信息
+	var id int
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		err := checkReferences(repos.Reference,
+			reference("productId", model.AuditEntityProduct, production.ProductID),
+			reference("productPlaceId", model.AuditEntityProductionPlace, production.ProductPlaceID))
+		if err != nil {
+			return err
+		}
+		if err := assignBatchNo(repos.Batch, production); err != nil {
+			return err
+		}
+		if id, err = repos.Production.Save(production); err != nil {
+			return err
+		}
+		created, err := repos.Production.GetByID(id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityProduction, id, model.AuditActionCreate, nil, created)
+	})
 	if err != nil {
-		log.Println("创建生产信息失败:", err)
-		return errorResult(500, "创建生产信息失败")
+		return batchErrorResult(err, "创建生产信息失败")
 	}
 
 	return successResult("添加成功", id)
 }
 
 // UpdateProduction 更新生产信息
-func (s *ProductionService) UpdateProduction(dto *dto.ProductionDTO) *dto.Result {
+func (s *ProductionService) UpdateProduction(scope model.DataScope, dto *dto.ProductionDTO) *dto.Result {
 	// 检查生产信息是否存在
 	existing, err := s.ProductionRepo.GetByID(dto.ID)
 	if err != nil {
@@ -51,6 +88,16 @@ func (s *ProductionService) UpdateProduction(dto *dto.ProductionDTO) *dto.Result
 	if existing == nil {
 		return errorResult(404, "生产信息不存在")
 	}
+	if !scope.AllowProductPlace(existing.ProductPlaceID) || !scope.AllowProductPlace(dto.ProductPlaceID) {
+		return errorResult(403, ProductionForbidden)
+	}
+
+	if dto.Quantity < 0 {
+		return errorResult(400, "批次数量不能为负数")
+	}
+	if dto.Unit == "" {
+		dto.Unit = existing.Unit
+	}
 
 	// 转换DTO为模型
 	production := &model.ProductionInfo{
@@ -61,20 +108,64 @@ func (s *ProductionService) UpdateProduction(dto *dto.ProductionDTO) *dto.Result
 		Description:    dto.Description,
 		PlantingDate:   dto.PlantingDate,
 		HarvestDate:    dto.HarvestDate,
+		Quantity:       dto.Quantity,
+		Unit:           dto.Unit,
 	}
 
-	// 更新生产信息
-	err = s.ProductionRepo.Update(production)
+	// 批次数量不能小于已分配给物流及已拆分/合并出去的数量，收获时间须满足农药安全间隔期
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		err := checkReferences(repos.Reference,
+			changedReference("productId", model.AuditEntityProduct, production.ProductID, existing.ProductID),
+			changedReference("productPlaceId", model.AuditEntityProductionPlace, production.ProductPlaceID, existing.ProductPlaceID))
+		if err != nil {
+			return err
+		}
+		usage, err := repos.Batch.Usage(dto.ID, 0)
+		if err != nil {
+			return err
+		}
+		if usage == nil {
+			return ErrBatchNotFound
+		}
+		if !usage.CanResize(dto.Quantity) {
+			return ErrBatchQuantityBelow
+		}
+
+		// 收获时间不能早于本批次及来源批次施药记录的安全间隔期
+		ancestors, err := batchAncestors(repos.Batch, repos.Production, dto.ID, map[int]bool{dto.ID: true})
+		if err != nil {
+			return err
+		}
+		for _, id := range append([]int{dto.ID}, batchNodeIDs(ancestors)...) {
+			activities, err := repos.FarmingActivity.FindByProductInfoID(id)
+			if err != nil {
+				return err
+			}
+			if err := checkWithholding(activities, dto.HarvestDate); err != nil {
+				return err
+			}
+		}
+		if err := repos.Production.Update(production); err != nil {
+			return err
+		}
+		updated, err := repos.Production.GetByID(dto.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityProduction, dto.ID, model.AuditActionUpdate, existing, updated)
+	})
+	if errors.Is(err, ErrWithholdingPeriod) {
+		return errorResult(400, err.Error())
+	}
 	if err != nil {
-		log.Println("更新生产信息失败:", err)
-		return errorResult(500, "更新失败")
+		return batchErrorResult(err, "更新失败")
 	}
 
 	return successResult("更新成功", nil)
 }
 
 // DeleteProduction 删除生产信息
-func (s *ProductionService) DeleteProduction(id int) *dto.Result {
+func (s *ProductionService) DeleteProduction(scope model.DataScope, id int) *dto.Result {
 	// 检查生产信息是否存在
 	existing, err := s.ProductionRepo.GetByID(id)
 	if err != nil {
@@ -84,9 +175,23 @@ func (s *ProductionService) DeleteProduction(id int) *dto.Result {
 	if existing == nil {
 		return errorResult(404, "生产信息不存在")
 	}
+	if !scope.AllowProductPlace(existing.ProductPlaceID) {
+		return errorResult(403, ProductionForbidden)
+	}
 
-	// 删除生产信息
-	err = s.ProductionRepo.Delete(id)
+	// 删除生产信息，仍被物流、溯源码等引用时不能删除
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := checkDeletable(repos.Reference, model.AuditEntityProduction, id); err != nil {
+			return err
+		}
+		if err := repos.Production.Delete(id); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityProduction, id, model.AuditActionDelete, existing, nil)
+	})
+	if result := referenceErrorResult(err); result != nil {
+		return result
+	}
 	if err != nil {
 		log.Println("删除生产信息失败:", err)
 		return errorResult(500, "删除失败")
@@ -96,14 +201,14 @@ func (s *ProductionService) DeleteProduction(id int) *dto.Result {
 }
 
 // GetProductionByID 根据ID获取生产信息
-func (s *ProductionService) GetProductionByID(id int) *dto.Result {
+func (s *ProductionService) GetProductionByID(scope model.DataScope, id int) *dto.Result {
 	production, err := s.ProductionRepo.GetByID(id)
 	if err != nil {
 		log.Println("获取生产信息失败:", err)
 		return errorResult(500, "系统错误")
 	}
 
-	if production == nil {
+	if production == nil || !scope.AllowProductPlace(production.ProductPlaceID) {
 		return errorResult(404, "生产信息不存在")
 	}
 
@@ -114,7 +219,7 @@ func (s *ProductionService) GetProductionByID(id int) *dto.Result {
 }
 
 // PageQueryProductions 分页查询生产信息
-func (s *ProductionService) PageQueryProductions(queryDTO *dto.ProductionPageQueryDTO) *dto.Result {
+func (s *ProductionService) PageQueryProductions(scope model.DataScope, queryDTO *dto.ProductionPageQueryDTO) *dto.Result {
 	// 参数校验
 	if queryDTO.Page <= 0 {
 		queryDTO.Page = 1
@@ -127,7 +232,8 @@ func (s *ProductionService) PageQueryProductions(queryDTO *dto.ProductionPageQue
 	productions, total, err := s.ProductionRepo.PageQuery(
 		queryDTO.Page, queryDTO.PageSize,
 		queryDTO.ProductInfoID, queryDTO.ProductName,
-		queryDTO.ProductPlace, queryDTO.Seed, queryDTO.Administrator)
+		queryDTO.ProductPlace, queryDTO.Seed, queryDTO.Administrator,
+		scope)
 	if err != nil {
 		log.Println("分页查询生产信息失败:", err)
 		return errorResult(500, "系统错误")
@@ -142,9 +248,9 @@ func (s *ProductionService) PageQueryProductions(queryDTO *dto.ProductionPageQue
 	}
 }
 
-// GetAllProductions 获取所有生产信息
-func (s *ProductionService) GetAllProductions() *dto.Result {
-	productions, err := s.ProductionRepo.GetAll()
+// GetAllProductions 获取数据权限范围内的所有生产信息
+func (s *ProductionService) GetAllProductions(scope model.DataScope) *dto.Result {
+	productions, err := s.ProductionRepo.GetAll(scope)
 	if err != nil {
 		log.Println("获取所有生产信息失败:", err)
 		return errorResult(500, "系统错误")
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository/repotest"
+)
+
+var harvestDate = time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)
+
+// newProductionRepos 生产地2上的批次1(数量100，已分配60给物流1)，生产地3已删除
+func newProductionRepos() *testRepos {
+	repos := newTestRepos()
+	repos.Production = repotest.NewProductionRepository(&model.ProductionInfoWithDetails{ProductionInfo: model.ProductionInfo{
+		ID: 1, BatchNo: "B001", ProductID: 1, ProductPlaceID: 2, SeedSource: "自留种",
+		PlantingDate: harvestDate.AddDate(0, -3, 0), HarvestDate: harvestDate, Quantity: 100, Unit: "kg",
+	}})
+	repos.Logistics = repotest.NewLogisticsRepository(&model.Logistics{
+		ID: 1, ProductInfoID: 1, CompanyID: 1, Quantity: ptr(60.0), Status: model.LogisticsStatusCreated,
+	})
+	repos.Batch = repotest.NewBatchRepository(repos.Production, repos.Logistics)
+	repos.exist(model.AuditEntityProduct, 1)
+	repos.exist(model.AuditEntityProductionPlace, 2)
+	repos.Reference.AddExisting(model.AuditEntityProductionPlace, 3, true)
+	return repos
+}
+
+func TestProductionService_CreateProduction(t *testing.T) {
+	production := func(productPlaceID int) dto.ProductionDTO {
+		return dto.ProductionDTO{
+			ProductID: 1, ProductPlaceID: productPlaceID, SeedSource: "自留种",
+			PlantingDate: harvestDate.AddDate(0, -3, 0), HarvestDate: harvestDate, Quantity: 50,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		scope         model.DataScope
+		production    dto.ProductionDTO
+		modify        func(p *dto.ProductionDTO)
+		uowErr        error
+		auditErr      error
+		wantCode      int
+		wantMsg       string
+		wantPlace     int
+		wantBatchNo   string
+		wantGenerated bool
+	}{
+		{name: "管理员指定生产地", scope: adminScope, production: production(2), wantCode: 200, wantPlace: 2, wantGenerated: true},
+		{name: "农场用户默认本生产地", scope: productPlaceScope(2), production: production(0), wantCode: 200, wantPlace: 2, wantGenerated: true},
+		{
+			name: "指定批次号", scope: adminScope, production: production(2),
+			modify:   func(p *dto.ProductionDTO) { p.BatchNo = "B002" },
+			wantCode: 200, wantPlace: 2, wantBatchNo: "B002",
+		},
+		{name: "农场用户指定其他生产地", scope: productPlaceScope(2), production: production(4), wantCode: 403, wantMsg: ProductionForbidden},
+		{
+			name: "数量为负数", scope: adminScope, production: production(2),
+			modify:   func(p *dto.ProductionDTO) { p.Quantity = -1 },
+			wantCode: 400, wantMsg: "批次数量不能为负数",
+		},
+		{
+			name: "产品不存在", scope: adminScope, production: production(2),
+			modify:   func(p *dto.ProductionDTO) { p.ProductID = 99 },
+			wantCode: 422,
+		},
+		{name: "生产地已删除", scope: adminScope, production: production(3), wantCode: 422},
+		{
+			name: "批次号已存在", scope: adminScope, production: production(2),
+			modify:   func(p *dto.ProductionDTO) { p.BatchNo = "B001" },
+			wantCode: 409, wantMsg: ErrBatchNoExists.Error(),
+		},
+		{name: "事务失败", scope: adminScope, production: production(2), uowErr: errFake, wantCode: 500, wantMsg: "创建生产信息失败"},
+		{name: "审计日志写入失败时回滚", scope: adminScope, production: production(2), auditErr: errFake, wantCode: 500, wantMsg: "创建生产信息失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newProductionRepos()
+			uow := repos.uow()
+			uow.Err = tt.uowErr
+			repos.Audit.Err = tt.auditErr
+			s := NewProductionService(repos.Production, uow)
+			if tt.modify != nil {
+				tt.modify(&tt.production)
+			}
+
+			result := s.CreateProduction(tt.scope, &tt.production)
+			assertResult(t, result, tt.wantCode, tt.wantMsg)
+			if tt.wantCode != 200 {
+				if len(repos.Production.Productions) != 1 {
+					t.Fatalf("失败后仍保存了生产信息: %d条", len(repos.Production.Productions))
+				}
+				assertAudits(t, repos)
+				return
+			}
+
+			id := result.Data.(int)
+			saved := repos.Production.Productions[id]
+			if saved == nil || saved.ProductPlaceID != tt.wantPlace || saved.Unit != model.DefaultBatchUnit {
+				t.Fatalf("保存的生产信息 = %+v", saved)
+			}
+			if tt.wantGenerated && (saved.BatchNo == "" || saved.BatchNo == "B001") {
+				t.Fatalf("生成的批次号 = %q", saved.BatchNo)
+			}
+			if tt.wantBatchNo != "" && saved.BatchNo != tt.wantBatchNo {
+				t.Fatalf("批次号 = %q, 期望 %q", saved.BatchNo, tt.wantBatchNo)
+			}
+			assertAudits(t, repos, auditKey(model.AuditEntityProduction, id, model.AuditActionCreate))
+		})
+	}
+}
+
+func TestProductionService_UpdateProduction(t *testing.T) {
+	update := func(modify func(p *dto.ProductionDTO)) dto.ProductionDTO {
+		p := dto.ProductionDTO{
+			ID: 1, ProductID: 1, ProductPlaceID: 2, SeedSource: "外购种苗",
+			PlantingDate: harvestDate.AddDate(0, -3, 0), HarvestDate: harvestDate, Quantity: 80,
+		}
+		if modify != nil {
+			modify(&p)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name       string
+		scope      model.DataScope
+		production dto.ProductionDTO
+		activity   *model.FarmingActivity
+		wantCode   int
+		wantMsg    string
+	}{
+		{name: "更新成功", scope: productPlaceScope(2), production: update(nil), wantCode: 200},
+		{name: "数量等于已分配数量", scope: adminScope, production: update(func(p *dto.ProductionDTO) { p.Quantity = 60 }), wantCode: 200},
+		{
+			name: "数量小于已分配数量", scope: adminScope,
+			production: update(func(p *dto.ProductionDTO) { p.Quantity = 50 }),
+			wantCode:   409, wantMsg: ErrBatchQuantityBelow.Error(),
+		},
+		{
+			name: "数量为负数", scope: adminScope,
+			production: update(func(p *dto.ProductionDTO) { p.Quantity = -1 }),
+			wantCode:   400,
+		},
+		{
+			name: "生产信息不存在", scope: adminScope,
+			production: update(func(p *dto.ProductionDTO) { p.ID = 99 }),
+			wantCode:   404, wantMsg: "生产信息不存在",
+		},
+		{name: "其他生产地的农场用户", scope: productPlaceScope(4), production: update(nil), wantCode: 403, wantMsg: ProductionForbidden},
+		{
+			name: "移到范围外的生产地", scope: productPlaceScope(2),
+			production: update(func(p *dto.ProductionDTO) { p.ProductPlaceID = 4 }),
+			wantCode:   403, wantMsg: ProductionForbidden,
+		},
+		{
+			name: "改为已删除的生产地", scope: adminScope,
+			production: update(func(p *dto.ProductionDTO) { p.ProductPlaceID = 3 }),
+			wantCode:   422,
+		},
+		{
+			name: "收获时间在安全间隔期内", scope: adminScope, production: update(nil),
+			activity: &model.FarmingActivity{
+				ProductInfoID: 1, ActivityType: model.FarmingActivityPesticide, Material: "吡虫啉",
+				ActivityDate: harvestDate.AddDate(0, 0, -3), WithholdingDays: ptr(7),
+			},
+			wantCode: 400,
+		},
+		{
+			name: "安全间隔期已过", scope: adminScope, production: update(nil),
+			activity: &model.FarmingActivity{
+				ProductInfoID: 1, ActivityType: model.FarmingActivityPesticide, Material: "吡虫啉",
+				ActivityDate: harvestDate.AddDate(0, 0, -10), WithholdingDays: ptr(7),
+			},
+			wantCode: 200,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newProductionRepos()
+			if tt.activity != nil {
+				repos.FarmingActivity = repotest.NewFarmingActivityRepository(tt.activity)
+			}
+			s := NewProductionService(repos.Production, repos.uow())
+
+			assertResult(t, s.UpdateProduction(tt.scope, &tt.production), tt.wantCode, tt.wantMsg)
+			saved := repos.Production.Productions[1]
+			if tt.wantCode != 200 {
+				if saved.SeedSource != "自留种" || saved.Quantity != 100 {
+					t.Fatalf("失败后生产信息被修改: %+v", saved)
+				}
+				assertAudits(t, repos)
+				return
+			}
+			if saved.SeedSource != "外购种苗" || saved.Quantity != tt.production.Quantity || saved.Unit != "kg" {
+				t.Fatalf("更新后 = %+v", saved)
+			}
+			assertAudits(t, repos, auditKey(model.AuditEntityProduction, 1, model.AuditActionUpdate))
+		})
+	}
+}
+
+func TestProductionService_DeleteProduction(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    model.DataScope
+		id       int
+		blocked  bool
+		wantCode int
+	}{
+		{name: "删除成功", scope: productPlaceScope(2), id: 1, wantCode: 200},
+		{name: "仍被物流引用", scope: adminScope, id: 1, blocked: true, wantCode: 409},
+		{name: "不存在", scope: adminScope, id: 99, wantCode: 404},
+		{name: "超出数据权限", scope: productPlaceScope(4), id: 1, wantCode: 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newProductionRepos()
+			if tt.blocked {
+				repos.Reference.AddBlocking(model.AuditEntityProduction, 1,
+					model.NewBlockingReference(model.AuditEntityLogistics, 1, []int{1}, model.AuditEntityProduction))
+			}
+			s := NewProductionService(repos.Production, repos.uow())
+
+			result := s.DeleteProduction(tt.scope, tt.id)
+			assertResult(t, result, tt.wantCode, "")
+			_, exists := repos.Production.Productions[1]
+			if exists == (tt.wantCode == 200) {
+				t.Fatalf("删除后生产信息是否存在 = %v", exists)
+			}
+			if tt.blocked {
+				if refs, ok := result.Data.([]*model.BlockingReference); !ok || len(refs) != 1 {
+					t.Fatalf("阻止删除的引用 = %+v", result.Data)
+				}
+			}
+		})
+	}
+}
+
+func TestProductionService_Scope(t *testing.T) {
+	repos := newProductionRepos()
+	repos.Production = repotest.NewProductionRepository(
+		&model.ProductionInfoWithDetails{ProductionInfo: model.ProductionInfo{ID: 1, ProductID: 1, ProductPlaceID: 2}},
+		&model.ProductionInfoWithDetails{ProductionInfo: model.ProductionInfo{ID: 2, ProductID: 1, ProductPlaceID: 4}},
+	)
+	s := NewProductionService(repos.Production, repos.uow())
+
+	assertResult(t, s.GetProductionByID(productPlaceScope(2), 1), 200, "")
+	assertResult(t, s.GetProductionByID(productPlaceScope(2), 2), 404, "生产信息不存在")
+	assertResult(t, s.GetProductionByID(adminScope, 2), 200, "")
+
+	all := s.GetAllProductions(productPlaceScope(4)).Data.([]*model.ProductionInfoWithDetails)
+	if len(all) != 1 || all[0].ID != 2 {
+		t.Fatalf("GetAllProductions = %+v", all)
+	}
+	page := s.PageQueryProductions(productPlaceScope(2), &dto.ProductionPageQueryDTO{}).Data.(*dto.PageResult)
+	if page.Total != 1 || page.Page != 1 || page.PageSize != 10 {
+		t.Fatalf("PageQueryProductions = %+v", page)
+	}
+}
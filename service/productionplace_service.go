@@ -2,6 +2,7 @@ package service
 
 import (
 	"log"
+	"time"
 
 	"agricultural_product_gin/dto"
 	"agricultural_product_gin/model"
@@ -10,16 +11,17 @@ import (
 
 // ProductionPlaceService 生产地信息服务
 type ProductionPlaceService struct {
-	ProductionPlaceRepo *repository.ProductionPlaceRepository
+	ProductionPlaceRepo repository.ProductionPlaceRepository
+	uow                 repository.UnitOfWork
 }
 
 // NewProductionPlaceService 创建生产地信息服务
-func NewProductionPlaceService(repo *repository.ProductionPlaceRepository) *ProductionPlaceService {
-	return &ProductionPlaceService{ProductionPlaceRepo: repo}
+func NewProductionPlaceService(repo repository.ProductionPlaceRepository, uow repository.UnitOfWork) *ProductionPlaceService {
+	return &ProductionPlaceService{ProductionPlaceRepo: repo, uow: uow}
 }
 
 // CreateProductionPlace 创建生产地信息
-func (s *ProductionPlaceService) CreateProductionPlace(dto *dto.ProductionPlaceDTO) *dto.Result {
+func (s *ProductionPlaceService) CreateProductionPlace(scope model.DataScope, dto *dto.ProductionPlaceDTO) *dto.Result {
 	// 转换DTO为模型
 	place := &model.ProductionPlace{
 		Address:       dto.Address,
@@ -28,7 +30,15 @@ func (s *ProductionPlaceService) CreateProductionPlace(dto *dto.ProductionPlaceD
 	}
 
 	// 保存生产地信息
-	id, err := s.ProductionPlaceRepo.Save(place)
+	var id int
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		var err error
+		if id, err = repos.ProductionPlace.Save(place); err != nil {
+			return err
+		}
+		place.ID = id
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityProductionPlace, id, model.AuditActionCreate, nil, place)
+	})
 	if err != nil {
 		log.Println("创建生产地信息失败:", err)
 		return errorResult(500, "创建生产地信息失败")
@@ -38,7 +48,7 @@ func (s *ProductionPlaceService) CreateProductionPlace(dto *dto.ProductionPlaceD
 }
 
 // UpdateProductionPlace 更新生产地信息
-func (s *ProductionPlaceService) UpdateProductionPlace(dto *dto.ProductionPlaceDTO) *dto.Result {
+func (s *ProductionPlaceService) UpdateProductionPlace(scope model.DataScope, dto *dto.ProductionPlaceDTO) *dto.Result {
 	// 检查生产地信息是否存在
 	existing, err := s.ProductionPlaceRepo.GetByID(dto.ID)
 	if err != nil {
@@ -48,6 +58,12 @@ func (s *ProductionPlaceService) UpdateProductionPlace(dto *dto.ProductionPlaceD
 	if existing == nil {
 		return errorResult(404, "生产地信息不存在")
 	}
+	if !scope.AllowProductPlace(existing.ID) {
+		return errorResult(403, "只能修改绑定的生产地")
+	}
+	if existing.Deleted() {
+		return errorResult(409, "生产地信息已删除，请先恢复")
+	}
 
 	// 转换DTO为模型
 	place := &model.ProductionPlace{
@@ -58,7 +74,12 @@ func (s *ProductionPlaceService) UpdateProductionPlace(dto *dto.ProductionPlaceD
 	}
 
 	// 更新生产地信息
-	err = s.ProductionPlaceRepo.Update(place)
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.ProductionPlace.Update(place); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityProductionPlace, place.ID, model.AuditActionUpdate, existing, place)
+	})
 	if err != nil {
 		log.Println("更新生产地信息失败:", err)
 		return errorResult(500, "更新失败")
@@ -67,8 +88,8 @@ func (s *ProductionPlaceService) UpdateProductionPlace(dto *dto.ProductionPlaceD
 	return successResult("更新成功", nil)
 }
 
-// DeleteProductionPlace 删除生产地信息
-func (s *ProductionPlaceService) DeleteProductionPlace(id int) *dto.Result {
+// DeleteProductionPlace 删除生产地信息(软删除)，已有生产信息中的生产地仍可用于溯源
+func (s *ProductionPlaceService) DeleteProductionPlace(scope model.DataScope, id int) *dto.Result {
 	// 检查生产地信息是否存在
 	existing, err := s.ProductionPlaceRepo.GetByID(id)
 	if err != nil {
@@ -78,9 +99,20 @@ func (s *ProductionPlaceService) DeleteProductionPlace(id int) *dto.Result {
 	if existing == nil {
 		return errorResult(404, "生产地信息不存在")
 	}
+	if !scope.AllowProductPlace(existing.ID) {
+		return errorResult(403, "只能删除绑定的生产地")
+	}
+	if existing.Deleted() {
+		return errorResult(409, "生产地信息已删除")
+	}
 
 	// 删除生产地信息
-	err = s.ProductionPlaceRepo.Delete(id)
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.ProductionPlace.Delete(id, time.Now(), scope.Actor.Username); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityProductionPlace, id, model.AuditActionDelete, existing, nil)
+	})
 	if err != nil {
 		log.Println("删除生产地信息失败:", err)
 		return errorResult(500, "删除失败")
@@ -89,7 +121,37 @@ func (s *ProductionPlaceService) DeleteProductionPlace(id int) *dto.Result {
 	return successResult("删除成功", nil)
 }
 
-// GetProductionPlaceByID 根据ID获取生产地信息
+// RestoreProductionPlace 恢复已删除的生产地信息
+func (s *ProductionPlaceService) RestoreProductionPlace(scope model.DataScope, id int) *dto.Result {
+	existing, err := s.ProductionPlaceRepo.GetByID(id)
+	if err != nil {
+		log.Println("查询生产地信息失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if existing == nil {
+		return errorResult(404, "生产地信息不存在")
+	}
+	if !existing.Deleted() {
+		return errorResult(409, "生产地信息未删除")
+	}
+
+	restored := *existing
+	restored.SoftDelete = model.SoftDelete{}
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.ProductionPlace.Restore(id); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityProductionPlace, id, model.AuditActionRestore, existing, &restored)
+	})
+	if err != nil {
+		log.Println("恢复生产地信息失败:", err)
+		return errorResult(500, "恢复失败")
+	}
+
+	return successResult("恢复成功", nil)
+}
+
+// GetProductionPlaceByID 根据ID获取生产地信息，已删除的生产地同样返回(含删除时间及删除人)
 func (s *ProductionPlaceService) GetProductionPlaceByID(id int) *dto.Result {
 	place, err := s.ProductionPlaceRepo.GetByID(id)
 	if err != nil {
@@ -120,7 +182,7 @@ func (s *ProductionPlaceService) PageQueryProductionPlaces(queryDTO *dto.Product
 	// 分页查询
 	places, total, err := s.ProductionPlaceRepo.PageQuery(
 		queryDTO.Page, queryDTO.PageSize,
-		queryDTO.ID, queryDTO.Address, queryDTO.Administrator)
+		queryDTO.ID, queryDTO.Address, queryDTO.Administrator, queryDTO.IncludeDeleted)
 	if err != nil {
 		log.Println("分页查询生产地信息失败:", err)
 		return errorResult(500, "系统错误")
@@ -135,9 +197,9 @@ func (s *ProductionPlaceService) PageQueryProductionPlaces(queryDTO *dto.Product
 	}
 }
 
-// GetAllProductionPlaces 获取所有生产地信息
-func (s *ProductionPlaceService) GetAllProductionPlaces() *dto.Result {
-	places, err := s.ProductionPlaceRepo.GetAll()
+// GetAllProductionPlaces 获取所有生产地信息，includeDeleted为false时不含已删除的生产地
+func (s *ProductionPlaceService) GetAllProductionPlaces(includeDeleted bool) *dto.Result {
+	places, err := s.ProductionPlaceRepo.GetAll(includeDeleted)
 	if err != nil {
 		log.Println("获取所有生产地信息失败:", err)
 		return errorResult(500, "系统错误")
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository/repotest"
+)
+
+func TestProductionPlaceService_Lifecycle(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		run       func(s *ProductionPlaceService) *dto.Result
+		auditErr  error
+		wantCode  int
+		wantMsg   string
+		wantAudit string
+	}{
+		{
+			name: "创建",
+			run: func(s *ProductionPlaceService) *dto.Result {
+				return s.CreateProductionPlace(adminScope, &dto.ProductionPlaceDTO{Address: "山东烟台", Administrator: "李四", Phone: "13800000000"})
+			},
+			wantCode: 200, wantAudit: auditKey(model.AuditEntityProductionPlace, 3, model.AuditActionCreate),
+		},
+		{
+			name: "农场用户更新本生产地",
+			run: func(s *ProductionPlaceService) *dto.Result {
+				return s.UpdateProductionPlace(productPlaceScope(1), &dto.ProductionPlaceDTO{ID: 1, Address: "山东栖霞"})
+			},
+			wantCode: 200, wantAudit: auditKey(model.AuditEntityProductionPlace, 1, model.AuditActionUpdate),
+		},
+		{
+			name: "农场用户更新其他生产地",
+			run: func(s *ProductionPlaceService) *dto.Result {
+				return s.UpdateProductionPlace(productPlaceScope(2), &dto.ProductionPlaceDTO{ID: 1, Address: "山东栖霞"})
+			},
+			wantCode: 403, wantMsg: "只能修改绑定的生产地",
+		},
+		{
+			name: "更新已删除的生产地",
+			run: func(s *ProductionPlaceService) *dto.Result {
+				return s.UpdateProductionPlace(adminScope, &dto.ProductionPlaceDTO{ID: 2, Address: "山东栖霞"})
+			},
+			wantCode: 409, wantMsg: "生产地信息已删除，请先恢复",
+		},
+		{
+			name:     "删除",
+			run:      func(s *ProductionPlaceService) *dto.Result { return s.DeleteProductionPlace(productPlaceScope(1), 1) },
+			wantCode: 200, wantAudit: auditKey(model.AuditEntityProductionPlace, 1, model.AuditActionDelete),
+		},
+		{
+			name:     "删除不存在的生产地",
+			run:      func(s *ProductionPlaceService) *dto.Result { return s.DeleteProductionPlace(adminScope, 99) },
+			wantCode: 404, wantMsg: "生产地信息不存在",
+		},
+		{
+			name:     "恢复",
+			run:      func(s *ProductionPlaceService) *dto.Result { return s.RestoreProductionPlace(adminScope, 2) },
+			wantCode: 200, wantAudit: auditKey(model.AuditEntityProductionPlace, 2, model.AuditActionRestore),
+		},
+		{
+			name:     "恢复未删除的生产地",
+			run:      func(s *ProductionPlaceService) *dto.Result { return s.RestoreProductionPlace(adminScope, 1) },
+			wantCode: 409, wantMsg: "生产地信息未删除",
+		},
+		{
+			name:     "审计日志写入失败时不删除",
+			run:      func(s *ProductionPlaceService) *dto.Result { return s.DeleteProductionPlace(adminScope, 1) },
+			auditErr: errFake, wantCode: 500, wantMsg: "删除失败",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestRepos()
+			repos.ProductionPlace = repotest.NewProductionPlaceRepository(
+				&model.ProductionPlace{ID: 1, Address: "山东烟台", Administrator: "张三"},
+				&model.ProductionPlace{ID: 2, Address: "陕西洛川", Administrator: "王五",
+					SoftDelete: model.SoftDelete{DeletedAt: &deletedAt, DeletedBy: "admin"}},
+			)
+			s := NewProductionPlaceService(repos.ProductionPlace, repos.uow())
+
+			repos.Audit.Err = tt.auditErr
+			assertResult(t, tt.run(s), tt.wantCode, tt.wantMsg)
+			if tt.auditErr != nil && repos.ProductionPlace.Places[1].Deleted() {
+				t.Fatal("审计日志写入失败后仍删除了生产地")
+			}
+			if tt.wantAudit == "" {
+				assertAudits(t, repos)
+			} else {
+				assertAudits(t, repos, tt.wantAudit)
+			}
+		})
+	}
+}
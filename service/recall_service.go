@@ -0,0 +1,444 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+var (
+	ErrRecallNotFound          = errors.New("召回不存在")
+	ErrRecallForbidden         = errors.New("无权操作该召回")
+	ErrRecallTarget            = errors.New("生产批次与产品必须且只能填写一个")
+	ErrRecallProductForbidden  = errors.New("只有管理员可以按产品发起召回")
+	ErrRecallProductNotFound   = errors.New("产品不存在")
+	ErrRecallInspection        = errors.New("检测记录与召回批次不一致")
+	ErrRecallExists            = errors.New("该批次或产品已有进行中的召回")
+	ErrRecallClosed            = errors.New("召回已结束")
+	ErrRecallPending           = errors.New("仍有销售地未完成召回处理")
+	ErrRecallSalePlaceStatus   = errors.New("无效的召回处理状态")
+	ErrRecallSalePlaceNotFound = errors.New("该销售地未收到召回批次")
+)
+
+// RecallService 产品召回服务，沿 批次 -> 拆分/合并产出批次 -> 物流 -> 销售 -> 销售地 计算召回范围
+type RecallService struct {
+	repos *repository.Repositories
+	uow   repository.UnitOfWork
+}
+
+// NewRecallService 创建产品召回服务
+func NewRecallService(repos *repository.Repositories, uow repository.UnitOfWork) *RecallService {
+	return &RecallService{repos: repos, uow: uow}
+}
+
+// Open 发起召回，并登记当前已收到召回批次的销售地
+func (s *RecallService) Open(identity *model.Identity, recallDTO *dto.RecallDTO) *dto.Result {
+	scope := identity.Scope()
+	var impact *model.RecallImpact
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		recall, err := toRecall(repos, scope, recallDTO)
+		if err != nil {
+			return err
+		}
+
+		// 同一批次或产品同时只能有一个进行中的召回
+		open, err := repos.Recall.FindOpen([]int{recall.ProductInfoID}, recall.ProductID)
+		if err != nil {
+			return err
+		}
+		for _, existing := range open {
+			if existing.ProductInfoID == recall.ProductInfoID {
+				return ErrRecallExists
+			}
+		}
+
+		if identity != nil {
+			recall.OpenedBy = identity.Username
+		}
+		recall.Status = model.RecallStatusOpen
+		recall.OpenTime = time.Now()
+		if recall.ID, err = repos.Recall.Save(recall); err != nil {
+			return err
+		}
+		if recall, err = repos.Recall.GetByID(recall.ID); err != nil {
+			return err
+		}
+		if err := recordAudit(repos.Audit, identity.Actor(), model.AuditEntityRecall, recall.ID, model.AuditActionCreate, nil, recall); err != nil {
+			return err
+		}
+
+		impact, err = recallImpact(repos, recall)
+		if err != nil {
+			return err
+		}
+		var salePlaceIDs []int
+		for _, salePlace := range impact.SalePlaces {
+			salePlaceIDs = append(salePlaceIDs, salePlace.SalePlaceID)
+		}
+		if err := repos.Recall.AddSalePlaces(recall.ID, salePlaceIDs, recall.OpenTime); err != nil {
+			return err
+		}
+		impact.SalePlaces, err = recallSalePlaces(repos, recall, impact.Sales)
+		return err
+	})
+	if err != nil {
+		return recallErrorResult(err, "发起召回失败")
+	}
+
+	return successResult("召回已发起", impact)
+}
+
+// GetImpact 查询召回的影响范围，物流公司和销售地只能看到与自己相关的记录
+func (s *RecallService) GetImpact(scope model.DataScope, id int) *dto.Result {
+	recall, err := s.repos.Recall.GetByID(id)
+	if err != nil {
+		log.Println("查询召回失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if recall == nil || !scope.AllowProductPlace(recall.ProductPlaceID) {
+		return errorResult(404, ErrRecallNotFound.Error())
+	}
+
+	impact, err := recallImpact(s.repos, recall)
+	if err != nil {
+		log.Println("计算召回范围失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	logistics := []*model.Logistics{}
+	for _, leg := range impact.Logistics {
+		if scope.AllowCompany(leg.CompanyID) {
+			logistics = append(logistics, leg)
+		}
+	}
+	sales := []*model.SaleInfoVO{}
+	for _, sale := range impact.Sales {
+		if scope.AllowSalePlace(sale.SalePlaceID) {
+			sales = append(sales, sale)
+		}
+	}
+	salePlaces := []*model.RecallSalePlace{}
+	for _, salePlace := range impact.SalePlaces {
+		if scope.AllowSalePlace(salePlace.SalePlaceID) {
+			salePlaces = append(salePlaces, salePlace)
+		}
+	}
+	impact.Logistics, impact.Sales, impact.SalePlaces = logistics, sales, salePlaces
+
+	return successResult("查询成功", impact)
+}
+
+// PageQuery 在数据权限范围内分页查询召回
+func (s *RecallService) PageQuery(scope model.DataScope, queryDTO *model.RecallPageQueryDTO) *dto.Result {
+	if queryDTO.Page <= 0 {
+		queryDTO.Page = 1
+	}
+	if queryDTO.Size <= 0 {
+		queryDTO.Size = 10
+	}
+
+	recalls, total, err := s.repos.Recall.PageQuery(queryDTO, scope)
+	if err != nil {
+		log.Println("分页查询召回失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	return successResult("查询成功", dto.NewPageResult(total, recalls, queryDTO.Page, queryDTO.Size))
+}
+
+// UpdateSalePlace 更新销售地的召回处理状态，零售商只能更新本销售地
+func (s *RecallService) UpdateSalePlace(scope model.DataScope, id, salePlaceID int, statusDTO *dto.RecallSalePlaceDTO) *dto.Result {
+	if !containsString(model.RecallSalePlaceStatuses, statusDTO.Status) {
+		return errorResult(400, ErrRecallSalePlaceStatus.Error())
+	}
+
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		recall, err := getOpenRecall(repos, scope, id)
+		if err != nil {
+			return err
+		}
+		if !scope.AllowSalePlace(salePlaceID) {
+			return ErrRecallForbidden
+		}
+
+		// 召回发起后才收到批次的销售地在此补登记
+		impact, err := recallImpact(repos, recall)
+		if err != nil {
+			return err
+		}
+		var before *model.RecallSalePlace
+		for _, salePlace := range impact.SalePlaces {
+			if salePlace.SalePlaceID == salePlaceID {
+				before = salePlace
+			}
+		}
+		if before == nil {
+			return ErrRecallSalePlaceNotFound
+		}
+
+		now := time.Now()
+		if err := repos.Recall.AddSalePlaces(id, []int{salePlaceID}, now); err != nil {
+			return err
+		}
+		after := *before
+		after.Status, after.Remark, after.UpdateTime = statusDTO.Status, statusDTO.Remark, &now
+		if err := repos.Recall.UpdateSalePlace(&after); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityRecall, id, model.AuditActionUpdate, before, &after)
+	})
+	if err != nil {
+		return recallErrorResult(err, "更新召回处理状态失败")
+	}
+
+	return successResult("更新成功", nil)
+}
+
+// Close 结束召回，所有销售地须已完成召回处理
+func (s *RecallService) Close(scope model.DataScope, id int, closeDTO *dto.RecallCloseDTO) *dto.Result {
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		recall, err := getOpenRecall(repos, scope, id)
+		if err != nil {
+			return err
+		}
+		impact, err := recallImpact(repos, recall)
+		if err != nil {
+			return err
+		}
+
+		pending := 0
+		for _, salePlace := range impact.SalePlaces {
+			if salePlace.Status != model.RecallSalePlaceCompleted {
+				pending++
+			}
+		}
+		if pending > 0 {
+			return fmt.Errorf("%w: %d个销售地", ErrRecallPending, pending)
+		}
+
+		if err := repos.Recall.Close(id, time.Now(), closeDTO.Remark); err != nil {
+			return err
+		}
+		closed, err := repos.Recall.GetByID(id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityRecall, id, model.AuditActionUpdate, recall, closed)
+	})
+	if err != nil {
+		return recallErrorResult(err, "结束召回失败")
+	}
+
+	return successResult("召回已结束", nil)
+}
+
+// toRecall 校验召回对象并转换为模型，按批次召回时召回的产品为批次所属产品
+func toRecall(repos *repository.Repositories, scope model.DataScope, recallDTO *dto.RecallDTO) (*model.Recall, error) {
+	recall := &model.Recall{
+		ProductInfoID: recallDTO.ProductInfoID,
+		ProductID:     recallDTO.ProductID,
+		InspectionID:  recallDTO.InspectionID,
+		Reason:        recallDTO.Reason,
+	}
+
+	if recall.InspectionID > 0 {
+		inspection, err := repos.Inspection.GetByID(recall.InspectionID)
+		if err != nil {
+			return nil, err
+		}
+		if inspection == nil {
+			return nil, ErrInspectionNotFound
+		}
+		if recall.ProductInfoID == 0 && recall.ProductID == 0 {
+			recall.ProductInfoID = inspection.ProductInfoID
+		}
+		if recall.ProductInfoID > 0 && recall.ProductInfoID != inspection.ProductInfoID {
+			return nil, ErrRecallInspection
+		}
+	}
+	if (recall.ProductInfoID > 0) == (recall.ProductID > 0) {
+		return nil, ErrRecallTarget
+	}
+
+	if recall.ProductInfoID > 0 {
+		batch, err := getBatch(repos, scope, recall.ProductInfoID)
+		if err != nil {
+			return nil, err
+		}
+		recall.ProductID = batch.ProductID
+		return recall, nil
+	}
+
+	// 按产品召回涉及所有生产地，只有不受生产地限制的账号可以发起
+	if scope.ProductPlaceID != nil {
+		return nil, ErrRecallProductForbidden
+	}
+	product, err := repos.Product.GetByID(recall.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrRecallProductNotFound
+	}
+	return recall, nil
+}
+
+// getOpenRecall 获取进行中的召回并校验数据权限
+func getOpenRecall(repos *repository.Repositories, scope model.DataScope, id int) (*model.Recall, error) {
+	recall, err := repos.Recall.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if recall == nil || !scope.AllowProductPlace(recall.ProductPlaceID) {
+		return nil, ErrRecallNotFound
+	}
+	if recall.Status != model.RecallStatusOpen {
+		return nil, ErrRecallClosed
+	}
+	return recall, nil
+}
+
+// recallImpact 计算召回范围：召回批次及其拆分/合并产出的批次，运输这些批次的物流(不含已取消)，
+// 经这些物流送达的销售记录，以及销售地的联系方式和处理状态
+func recallImpact(repos *repository.Repositories, recall *model.Recall) (*model.RecallImpact, error) {
+	impact := &model.RecallImpact{
+		Recall:    recall,
+		Batches:   []*model.BatchNode{},
+		Logistics: []*model.Logistics{},
+		Sales:     []*model.SaleInfoVO{},
+	}
+
+	var roots []*model.ProductionInfoWithDetails
+	if recall.ProductInfoID > 0 {
+		batch, err := repos.Production.GetByID(recall.ProductInfoID)
+		if err != nil {
+			return nil, err
+		}
+		if batch != nil {
+			roots = append(roots, batch)
+		}
+	} else {
+		productions, err := repos.Production.GetAll(model.DataScope{})
+		if err != nil {
+			return nil, err
+		}
+		for _, production := range productions {
+			if production.ProductID == recall.ProductID {
+				roots = append(roots, production)
+			}
+		}
+	}
+
+	visited := make(map[int]bool)
+	for _, root := range roots {
+		if visited[root.ID] {
+			continue
+		}
+		visited[root.ID] = true
+
+		node := &model.BatchNode{
+			ProductInfoID:   root.ID,
+			BatchNo:         root.BatchNo,
+			ProductName:     root.ProductName,
+			ProductionPlace: root.ProductionPlace,
+			Quantity:        root.Quantity,
+			Unit:            root.Unit,
+		}
+		var err error
+		if node.Children, err = batchDescendants(repos.Batch, repos.Production, root.ID, visited); err != nil {
+			return nil, err
+		}
+		impact.Batches = append(impact.Batches, node)
+	}
+
+	for _, batchID := range batchNodeIDs(impact.Batches) {
+		legs, err := repos.Logistics.FindByProductInfoID(batchID)
+		if err != nil {
+			return nil, err
+		}
+		for _, leg := range legs {
+			if leg.Status == model.LogisticsStatusCancelled {
+				continue
+			}
+			impact.Logistics = append(impact.Logistics, leg)
+
+			sales, err := repos.SaleInfo.FindByLogisticsID(leg.ID)
+			if err != nil {
+				return nil, err
+			}
+			impact.Sales = append(impact.Sales, sales...)
+		}
+	}
+
+	var err error
+	impact.SalePlaces, err = recallSalePlaces(repos, recall, impact.Sales)
+	if err != nil {
+		return nil, err
+	}
+	return impact, nil
+}
+
+// recallSalePlaces 汇总销售记录涉及的销售地，合并已登记的处理状态；
+// 召回发起后才收到批次、尚未登记的销售地视为待通知
+func recallSalePlaces(repos *repository.Repositories, recall *model.Recall, sales []*model.SaleInfoVO) ([]*model.RecallSalePlace, error) {
+	registered, err := repos.Recall.FindSalePlaces(recall.ID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]*model.RecallSalePlace, len(registered))
+	for _, salePlace := range registered {
+		byID[salePlace.SalePlaceID] = salePlace
+	}
+
+	salePlaces := registered
+	for _, sale := range sales {
+		salePlace, ok := byID[sale.SalePlaceID]
+		if !ok {
+			salePlace = &model.RecallSalePlace{
+				RecallID:    recall.ID,
+				SalePlaceID: sale.SalePlaceID,
+				Status:      model.RecallSalePlacePending,
+			}
+			place, err := repos.SalePlace.GetByID(sale.SalePlaceID)
+			if err != nil {
+				return nil, err
+			}
+			if place != nil {
+				salePlace.Address = place.Address
+				salePlace.Administrator = place.Administrator
+				salePlace.Phone = place.Phone
+			}
+			byID[sale.SalePlaceID] = salePlace
+			salePlaces = append(salePlaces, salePlace)
+		}
+		salePlace.SaleCount++
+	}
+	return salePlaces, nil
+}
+
+// recallErrorResult 将召回相关错误转换为响应结果
+func recallErrorResult(err error, msg string) *dto.Result {
+	switch {
+	case errors.Is(err, ErrRecallNotFound), errors.Is(err, ErrRecallProductNotFound),
+		errors.Is(err, ErrRecallSalePlaceNotFound), errors.Is(err, ErrInspectionNotFound):
+		return errorResult(404, err.Error())
+	case errors.Is(err, ErrBatchNotFound):
+		return errorResult(404, "生产信息不存在")
+	case errors.Is(err, ErrRecallForbidden), errors.Is(err, ErrRecallProductForbidden):
+		return errorResult(403, err.Error())
+	case errors.Is(err, ErrBatchForbidden):
+		return errorResult(403, ErrRecallForbidden.Error())
+	case errors.Is(err, ErrRecallExists), errors.Is(err, ErrRecallClosed), errors.Is(err, ErrRecallPending):
+		return errorResult(409, err.Error())
+	case errors.Is(err, ErrRecallTarget), errors.Is(err, ErrRecallInspection), errors.Is(err, ErrRecallSalePlaceStatus):
+		return errorResult(400, err.Error())
+	default:
+		log.Println(msg+":", err)
+		return errorResult(500, msg)
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+// 数据库外键约束冲突(预检查之后并发修改导致)，供控制器判断，不需要依赖仓储层
+var (
+	ErrReferenced        = repository.ErrReferenced
+	ErrReferenceNotFound = repository.ErrReferenceNotFound
+)
+
+// ReferencedError 删除的记录仍被其他数据引用，References列出阻止删除的引用
+type ReferencedError struct {
+	Entity     string
+	ID         int
+	References []*model.BlockingReference
+}
+
+func (e *ReferencedError) Error() string {
+	return model.EntityNames[e.Entity] + "仍被其他数据引用，不能删除"
+}
+
+// Is 使errors.Is(err, ErrReferenced)成立，与数据库约束冲突统一处理
+func (e *ReferencedError) Is(target error) bool {
+	return target == ErrReferenced
+}
+
+// InvalidReferenceError 保存的记录引用了不存在或已删除的数据
+type InvalidReferenceError struct {
+	References []*model.InvalidReference
+}
+
+func (e *InvalidReferenceError) Error() string {
+	messages := make([]string, 0, len(e.References))
+	for _, ref := range e.References {
+		messages = append(messages, ref.Message)
+	}
+	return strings.Join(messages, "；")
+}
+
+// Is 使errors.Is(err, ErrReferenceNotFound)成立，与数据库约束冲突统一处理
+func (e *InvalidReferenceError) Is(target error) bool {
+	return target == ErrReferenceNotFound
+}
+
+// referenceField 保存时需要检查的引用字段
+type referenceField struct {
+	field  string
+	entity string
+	id     int
+}
+
+// reference 创建引用字段，id<=0表示未引用，不检查
+func reference(field, entity string, id int) referenceField {
+	return referenceField{field: field, entity: entity, id: id}
+}
+
+// changedReference 更新时只检查修改过的引用，保留原有引用(即使已逻辑删除)不视为错误
+func changedReference(field, entity string, id, previous int) referenceField {
+	if id == previous {
+		id = 0
+	}
+	return reference(field, entity, id)
+}
+
+// checkReferences 检查引用的数据是否存在且未被逻辑删除，返回所有无效的引用
+func checkReferences(refs repository.ReferenceRepository, fields ...referenceField) error {
+	invalid := []*model.InvalidReference{}
+	for _, f := range fields {
+		if f.id <= 0 {
+			continue
+		}
+		exists, deleted, err := refs.Lookup(f.entity, f.id)
+		if err != nil {
+			return err
+		}
+		if !exists || deleted {
+			invalid = append(invalid, model.NewInvalidReference(f.field, f.entity, f.id, deleted))
+		}
+	}
+	if len(invalid) > 0 {
+		return &InvalidReferenceError{References: invalid}
+	}
+	return nil
+}
+
+// checkDeletable 检查记录是否仍被其他数据引用，避免删除时触发外键约束
+func checkDeletable(refs repository.ReferenceRepository, entity string, id int) error {
+	blocking, err := refs.FindBlocking(entity, id)
+	if err != nil {
+		return err
+	}
+	if len(blocking) > 0 {
+		return &ReferencedError{Entity: entity, ID: id, References: blocking}
+	}
+	return nil
+}
+
+// referenceErrorResult 引用冲突的结果：仍被引用时返回409，引用无效时返回422，Data为引用列表
+// 预检查之后并发产生的数据库约束冲突同样返回409/422；不是引用冲突时返回nil
+func referenceErrorResult(err error) *dto.Result {
+	var referenced *ReferencedError
+	var invalid *InvalidReferenceError
+	switch {
+	case errors.As(err, &referenced):
+		return &dto.Result{Code: 409, Msg: referenced.Error(), Data: referenced.References}
+	case errors.As(err, &invalid):
+		return &dto.Result{Code: 422, Msg: invalid.Error(), Data: invalid.References}
+	case errors.Is(err, ErrReferenced):
+		return errorResult(409, ErrReferenced.Error())
+	case errors.Is(err, ErrReferenceNotFound):
+		return errorResult(422, ErrReferenceNotFound.Error())
+	default:
+		return nil
+	}
+}
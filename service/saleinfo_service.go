@@ -2,33 +2,47 @@ package service
 
 import (
 	"log"
+	"sort"
+	"time"
 
 	"agricultural_product_gin/dto"
 	"agricultural_product_gin/model"
 	"agricultural_product_gin/repository"
 )
 
-// SaleInfoService 销售信息服务接口
+// SaleInfoForbidden 超出数据权限范围的提示
+const SaleInfoForbidden = "无权操作该销售信息"
+
+// SaleInfoService 销售信息服务接口，scope为当前用户的数据权限范围
 type SaleInfoService interface {
-	Save(saleInfoDTO *dto.SaleInfoDTO) *dto.Result
-	Update(saleInfoDTO *dto.SaleInfoDTO) *dto.Result
-	Delete(id int) *dto.Result
-	GetByID(id int) *dto.Result
-	GetAll() *dto.Result
-	PageQuery(queryDTO *dto.SaleInfoPageQueryDTO) *dto.Result
+	Save(scope model.DataScope, saleInfoDTO *dto.SaleInfoDTO) *dto.Result
+	Update(scope model.DataScope, saleInfoDTO *dto.SaleInfoDTO) *dto.Result
+	Delete(scope model.DataScope, id int) *dto.Result
+	GetByID(scope model.DataScope, id int) *dto.Result
+	GetAll(scope model.DataScope) *dto.Result
+	PageQuery(scope model.DataScope, queryDTO *dto.SaleInfoPageQueryDTO) *dto.Result
+	ReverseTrace(scope model.DataScope, salePlaceID int, start, end time.Time) *dto.Result
 }
 
 // SaleInfoServiceImpl 销售信息服务实现
 type SaleInfoServiceImpl struct {
-	repo *repository.SaleInfoRepository // 改为指针类型
+	repo repository.SaleInfoRepository
+	uow  repository.UnitOfWork
 }
 
-func NewSaleInfoService(repo *repository.SaleInfoRepository) SaleInfoService {
-	return &SaleInfoServiceImpl{repo: repo}
+func NewSaleInfoService(repo repository.SaleInfoRepository, uow repository.UnitOfWork) SaleInfoService {
+	return &SaleInfoServiceImpl{repo: repo, uow: uow}
 }
 
-// Save 保存销售信息
-func (s *SaleInfoServiceImpl) Save(saleInfoDTO *dto.SaleInfoDTO) *dto.Result {
+// Save 保存销售信息，零售商未指定销售地时默认为其绑定的销售地
+func (s *SaleInfoServiceImpl) Save(scope model.DataScope, saleInfoDTO *dto.SaleInfoDTO) *dto.Result {
+	if scope.SalePlaceID != nil && saleInfoDTO.SalePlaceID <= 0 {
+		saleInfoDTO.SalePlaceID = *scope.SalePlaceID
+	}
+	if !scope.AllowSalePlace(saleInfoDTO.SalePlaceID) {
+		return errorResult(403, SaleInfoForbidden)
+	}
+
 	// 转换DTO为模型
 	saleInfo := &model.SaleInfo{
 		LogisticsID: saleInfoDTO.LogisticsID,
@@ -37,7 +51,24 @@ func (s *SaleInfoServiceImpl) Save(saleInfoDTO *dto.SaleInfoDTO) *dto.Result {
 		SaleTime:    saleInfoDTO.SaleTime,
 	}
 
-	id, err := s.repo.Save(saleInfo)
+	// 物流信息、销售地须存在且未删除
+	var id int
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		err := checkReferences(repos.Reference,
+			reference("logisticsId", model.AuditEntityLogistics, saleInfo.LogisticsID),
+			reference("salePlaceId", model.AuditEntitySalePlace, saleInfo.SalePlaceID))
+		if err != nil {
+			return err
+		}
+		if id, err = repos.SaleInfo.Save(saleInfo); err != nil {
+			return err
+		}
+		saleInfo.ID = id
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntitySaleInfo, id, model.AuditActionCreate, nil, saleInfo)
+	})
+	if result := referenceErrorResult(err); result != nil {
+		return result
+	}
 	if err != nil {
 		log.Println("保存销售信息失败:", err)
 		return errorResult(500, "保存失败")
@@ -47,7 +78,7 @@ func (s *SaleInfoServiceImpl) Save(saleInfoDTO *dto.SaleInfoDTO) *dto.Result {
 }
 
 // Update 更新销售信息
-func (s *SaleInfoServiceImpl) Update(saleInfoDTO *dto.SaleInfoDTO) *dto.Result {
+func (s *SaleInfoServiceImpl) Update(scope model.DataScope, saleInfoDTO *dto.SaleInfoDTO) *dto.Result {
 	// 检查销售信息是否存在
 	existingSaleInfo, err := s.repo.GetByID(saleInfoDTO.ID)
 	if err != nil {
@@ -58,6 +89,9 @@ func (s *SaleInfoServiceImpl) Update(saleInfoDTO *dto.SaleInfoDTO) *dto.Result {
 	if existingSaleInfo == nil {
 		return errorResult(404, "销售信息不存在")
 	}
+	if !scope.AllowSalePlace(existingSaleInfo.SalePlaceID) || !scope.AllowSalePlace(saleInfoDTO.SalePlaceID) {
+		return errorResult(403, SaleInfoForbidden)
+	}
 
 	// 转换DTO为模型
 	saleInfo := &model.SaleInfo{
@@ -68,8 +102,22 @@ func (s *SaleInfoServiceImpl) Update(saleInfoDTO *dto.SaleInfoDTO) *dto.Result {
 		SaleTime:    saleInfoDTO.SaleTime,
 	}
 
-	// 更新销售信息
-	err = s.repo.Update(saleInfo)
+	// 更新销售信息，修改后的物流信息、销售地须存在且未删除
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		err := checkReferences(repos.Reference,
+			changedReference("logisticsId", model.AuditEntityLogistics, saleInfo.LogisticsID, existingSaleInfo.LogisticsID),
+			changedReference("salePlaceId", model.AuditEntitySalePlace, saleInfo.SalePlaceID, existingSaleInfo.SalePlaceID))
+		if err != nil {
+			return err
+		}
+		if err := repos.SaleInfo.Update(saleInfo); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntitySaleInfo, saleInfo.ID, model.AuditActionUpdate, existingSaleInfo, saleInfo)
+	})
+	if result := referenceErrorResult(err); result != nil {
+		return result
+	}
 	if err != nil {
 		log.Println("更新销售信息失败:", err)
 		return errorResult(500, "更新失败")
@@ -79,7 +127,7 @@ func (s *SaleInfoServiceImpl) Update(saleInfoDTO *dto.SaleInfoDTO) *dto.Result {
 }
 
 // Delete 删除销售信息
-func (s *SaleInfoServiceImpl) Delete(id int) *dto.Result {
+func (s *SaleInfoServiceImpl) Delete(scope model.DataScope, id int) *dto.Result {
 	// 检查销售信息是否存在
 	existingSaleInfo, err := s.repo.GetByID(id)
 	if err != nil {
@@ -90,9 +138,23 @@ func (s *SaleInfoServiceImpl) Delete(id int) *dto.Result {
 	if existingSaleInfo == nil {
 		return errorResult(404, "销售信息不存在")
 	}
+	if !scope.AllowSalePlace(existingSaleInfo.SalePlaceID) {
+		return errorResult(403, SaleInfoForbidden)
+	}
 
-	// 删除销售信息
-	err = s.repo.Delete(id)
+	// 删除销售信息，已生成溯源码的不能删除
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := checkDeletable(repos.Reference, model.AuditEntitySaleInfo, id); err != nil {
+			return err
+		}
+		if err := repos.SaleInfo.Delete(id); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntitySaleInfo, id, model.AuditActionDelete, existingSaleInfo, nil)
+	})
+	if result := referenceErrorResult(err); result != nil {
+		return result
+	}
 	if err != nil {
 		log.Println("删除销售信息失败:", err)
 		return errorResult(500, "删除失败")
@@ -102,23 +164,23 @@ func (s *SaleInfoServiceImpl) Delete(id int) *dto.Result {
 }
 
 // GetByID 根据ID获取销售信息
-func (s *SaleInfoServiceImpl) GetByID(id int) *dto.Result {
+func (s *SaleInfoServiceImpl) GetByID(scope model.DataScope, id int) *dto.Result {
 	saleInfo, err := s.repo.GetByID(id)
 	if err != nil {
 		log.Println("获取销售信息失败:", err)
 		return errorResult(500, "系统错误")
 	}
 
-	if saleInfo == nil {
+	if saleInfo == nil || !scope.AllowSalePlace(saleInfo.SalePlaceID) {
 		return errorResult(404, "销售信息不存在")
 	}
 
 	return successResult("查询成功", saleInfo)
 }
 
-// GetAll 获取所有销售信息
-func (s *SaleInfoServiceImpl) GetAll() *dto.Result {
-	saleInfos, err := s.repo.FindAll()
+// GetAll 获取数据权限范围内的所有销售信息
+func (s *SaleInfoServiceImpl) GetAll(scope model.DataScope) *dto.Result {
+	saleInfos, err := s.repo.FindAll(scope)
 	if err != nil {
 		log.Println("获取所有销售信息失败:", err)
 		return errorResult(500, "系统错误")
@@ -128,7 +190,7 @@ func (s *SaleInfoServiceImpl) GetAll() *dto.Result {
 }
 
 // PageQuery 分页查询销售信息
-func (s *SaleInfoServiceImpl) PageQuery(queryDTO *dto.SaleInfoPageQueryDTO) *dto.Result {
+func (s *SaleInfoServiceImpl) PageQuery(scope model.DataScope, queryDTO *dto.SaleInfoPageQueryDTO) *dto.Result {
 	// 参数校验
 	if queryDTO.Page <= 0 {
 		queryDTO.Page = 1
@@ -148,7 +210,7 @@ func (s *SaleInfoServiceImpl) PageQuery(queryDTO *dto.SaleInfoPageQueryDTO) *dto
 	}
 
 	// 分页查询
-	saleInfos, total, err := s.repo.PageQuery(query)
+	saleInfos, total, err := s.repo.PageQuery(query, scope)
 	if err != nil {
 		log.Println("分页查询销售信息失败:", err)
 		return errorResult(500, "系统错误")
@@ -159,3 +221,116 @@ func (s *SaleInfoServiceImpl) PageQuery(queryDTO *dto.SaleInfoPageQueryDTO) *dto
 
 	return successResult("查询成功", pageResult)
 }
+
+// ReverseTrace 反向溯源：统计销售地在[start, end)内的供货生产地、产品及物流公司，
+// 零售商未指定销售地时默认为其绑定的销售地
+func (s *SaleInfoServiceImpl) ReverseTrace(scope model.DataScope, salePlaceID int, start, end time.Time) *dto.Result {
+	if !end.After(start) {
+		return errorResult(400, "结束时间必须晚于开始时间")
+	}
+	if scope.SalePlaceID != nil && salePlaceID <= 0 {
+		salePlaceID = *scope.SalePlaceID
+	}
+	if salePlaceID <= 0 {
+		return errorResult(400, "销售地ID不能为空")
+	}
+	if !scope.AllowSalePlace(salePlaceID) {
+		return errorResult(403, SaleInfoForbidden)
+	}
+
+	supplies, err := s.repo.FindSupplies(salePlaceID, start, end)
+	if err != nil {
+		log.Println("反向溯源失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	return successResult("查询成功", buildReverseTrace(salePlaceID, start, end, supplies))
+}
+
+// buildReverseTrace 按生产地、产品、物流公司去重计数，按销售记录数从多到少排序
+func buildReverseTrace(salePlaceID int, start, end time.Time, supplies []*model.SaleSupply) *model.ReverseTrace {
+	trace := &model.ReverseTrace{
+		SalePlaceID:      salePlaceID,
+		Start:            start,
+		End:              end,
+		SaleCount:        len(supplies),
+		ProductionPlaces: []*model.ReverseTraceProductionPlace{},
+		Products:         []*model.ReverseTraceProduct{},
+		Companies:        []*model.ReverseTraceCompany{},
+	}
+
+	places := make(map[int]*model.ReverseTraceProductionPlace)
+	products := make(map[int]*model.ReverseTraceProduct)
+	companies := make(map[int]*model.ReverseTraceCompany)
+	placeBatches := make(map[[2]int]bool)   // 生产地ID, 批次ID
+	productBatches := make(map[[2]int]bool) // 产品ID, 批次ID
+	companyLegs := make(map[[2]int]bool)    // 公司ID, 物流ID
+
+	for _, supply := range supplies {
+		if supply.ProductPlaceID > 0 {
+			place, ok := places[supply.ProductPlaceID]
+			if !ok {
+				place = &model.ReverseTraceProductionPlace{
+					ProductPlaceID: supply.ProductPlaceID,
+					Address:        supply.PPAddress,
+					Administrator:  supply.PPAdmin,
+					Phone:          supply.PPPhone,
+				}
+				places[supply.ProductPlaceID] = place
+				trace.ProductionPlaces = append(trace.ProductionPlaces, place)
+			}
+			place.SaleCount++
+			if key := [2]int{supply.ProductPlaceID, supply.ProductInfoID}; !placeBatches[key] {
+				placeBatches[key] = true
+				place.BatchCount++
+			}
+		}
+
+		if supply.ProductID > 0 {
+			product, ok := products[supply.ProductID]
+			if !ok {
+				product = &model.ReverseTraceProduct{
+					ProductID: supply.ProductID,
+					Name:      supply.ProductName,
+					Type:      supply.ProductType,
+				}
+				products[supply.ProductID] = product
+				trace.Products = append(trace.Products, product)
+			}
+			product.SaleCount++
+			if key := [2]int{supply.ProductID, supply.ProductInfoID}; !productBatches[key] {
+				productBatches[key] = true
+				product.BatchCount++
+			}
+		}
+
+		if supply.CompanyID > 0 {
+			company, ok := companies[supply.CompanyID]
+			if !ok {
+				company = &model.ReverseTraceCompany{
+					CompanyID: supply.CompanyID,
+					Name:      supply.CompanyName,
+					Phone:     supply.CompanyPhone,
+				}
+				companies[supply.CompanyID] = company
+				trace.Companies = append(trace.Companies, company)
+			}
+			company.SaleCount++
+			if key := [2]int{supply.CompanyID, supply.LogisticsID}; !companyLegs[key] {
+				companyLegs[key] = true
+				company.LogisticsCount++
+			}
+		}
+	}
+
+	sort.SliceStable(trace.ProductionPlaces, func(i, j int) bool {
+		return trace.ProductionPlaces[i].SaleCount > trace.ProductionPlaces[j].SaleCount
+	})
+	sort.SliceStable(trace.Products, func(i, j int) bool {
+		return trace.Products[i].SaleCount > trace.Products[j].SaleCount
+	})
+	sort.SliceStable(trace.Companies, func(i, j int) bool {
+		return trace.Companies[i].SaleCount > trace.Companies[j].SaleCount
+	})
+	return trace
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository/repotest"
+)
+
+var saleTime = time.Date(2024, 6, 22, 9, 0, 0, 0, time.UTC)
+
+// newSaleInfoRepos 销售地5的销售信息1(物流1)，销售地6的销售信息2(物流2)；物流3、销售地7已删除
+func newSaleInfoRepos() *testRepos {
+	repos := newTestRepos()
+	repos.SaleInfo = repotest.NewSaleInfoRepository(
+		&model.SaleInfoVO{ID: 1, LogisticsID: 1, SalePlaceID: 5, Description: "上架", SaleTime: saleTime},
+		&model.SaleInfoVO{ID: 2, LogisticsID: 2, SalePlaceID: 6, SaleTime: saleTime.Add(time.Hour)},
+	)
+	repos.exist(model.AuditEntityLogistics, 1, 2)
+	repos.exist(model.AuditEntitySalePlace, 5, 6)
+	repos.Reference.AddExisting(model.AuditEntityLogistics, 3, true)
+	repos.Reference.AddExisting(model.AuditEntitySalePlace, 7, true)
+	return repos
+}
+
+func newSaleInfoService(repos *testRepos) SaleInfoService {
+	return NewSaleInfoService(repos.SaleInfo, repos.uow())
+}
+
+func TestSaleInfoService_Save(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     model.DataScope
+		saleInfo  dto.SaleInfoDTO
+		auditErr  error
+		wantCode  int
+		wantMsg   string
+		wantPlace int
+		wantRefs  []string
+	}{
+		{name: "零售商默认本销售地", scope: salePlaceScope(5), saleInfo: dto.SaleInfoDTO{LogisticsID: 1}, wantCode: 200, wantPlace: 5},
+		{name: "管理员指定销售地", scope: adminScope, saleInfo: dto.SaleInfoDTO{LogisticsID: 2, SalePlaceID: 6}, wantCode: 200, wantPlace: 6},
+		{
+			name: "零售商指定其他销售地", scope: salePlaceScope(5), saleInfo: dto.SaleInfoDTO{LogisticsID: 1, SalePlaceID: 6},
+			wantCode: 403, wantMsg: SaleInfoForbidden,
+		},
+		{
+			name: "物流已删除", scope: adminScope, saleInfo: dto.SaleInfoDTO{LogisticsID: 3, SalePlaceID: 5},
+			wantCode: 422, wantRefs: []string{"logisticsId"},
+		},
+		{
+			name: "物流与销售地都无效", scope: adminScope, saleInfo: dto.SaleInfoDTO{LogisticsID: 99, SalePlaceID: 7},
+			wantCode: 422, wantRefs: []string{"logisticsId", "salePlaceId"},
+		},
+		{
+			name: "审计日志写入失败时回滚", scope: adminScope, saleInfo: dto.SaleInfoDTO{LogisticsID: 1, SalePlaceID: 5},
+			auditErr: errFake, wantCode: 500, wantMsg: "保存失败",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newSaleInfoRepos()
+			repos.Audit.Err = tt.auditErr
+			s := newSaleInfoService(repos)
+			tt.saleInfo.SaleTime = saleTime
+
+			result := s.Save(tt.scope, &tt.saleInfo)
+			assertResult(t, result, tt.wantCode, tt.wantMsg)
+			if tt.wantRefs != nil {
+				assertInvalidReferences(t, result, tt.wantRefs...)
+			}
+			if tt.wantCode != 200 {
+				if len(repos.SaleInfo.SaleInfos) != 2 {
+					t.Fatalf("失败后仍保存了销售信息: %d条", len(repos.SaleInfo.SaleInfos))
+				}
+				assertAudits(t, repos)
+				return
+			}
+
+			id := result.Data.(int)
+			saved := repos.SaleInfo.SaleInfos[id]
+			if saved == nil || saved.SalePlaceID != tt.wantPlace || saved.LogisticsID != tt.saleInfo.LogisticsID {
+				t.Fatalf("保存的销售信息 = %+v", saved)
+			}
+			assertAudits(t, repos, auditKey(model.AuditEntitySaleInfo, id, model.AuditActionCreate))
+		})
+	}
+}
+
+func TestSaleInfoService_Update(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    model.DataScope
+		saleInfo dto.SaleInfoDTO
+		wantCode int
+	}{
+		{name: "更新说明", scope: salePlaceScope(5), saleInfo: dto.SaleInfoDTO{ID: 1, LogisticsID: 1, SalePlaceID: 5, Description: "促销"}, wantCode: 200},
+		{name: "不存在", scope: adminScope, saleInfo: dto.SaleInfoDTO{ID: 99, LogisticsID: 1, SalePlaceID: 5}, wantCode: 404},
+		{name: "其他销售地的销售信息", scope: salePlaceScope(5), saleInfo: dto.SaleInfoDTO{ID: 2, LogisticsID: 2, SalePlaceID: 6}, wantCode: 403},
+		{name: "移到其他销售地", scope: salePlaceScope(5), saleInfo: dto.SaleInfoDTO{ID: 1, LogisticsID: 1, SalePlaceID: 6}, wantCode: 403},
+		{name: "改为已删除的销售地", scope: adminScope, saleInfo: dto.SaleInfoDTO{ID: 1, LogisticsID: 1, SalePlaceID: 7}, wantCode: 422},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newSaleInfoRepos()
+			s := newSaleInfoService(repos)
+			tt.saleInfo.SaleTime = saleTime
+
+			assertResult(t, s.Update(tt.scope, &tt.saleInfo), tt.wantCode, "")
+			saved := repos.SaleInfo.SaleInfos[1]
+			if tt.wantCode != 200 {
+				if saved.Description != "上架" || saved.SalePlaceID != 5 {
+					t.Fatalf("失败后销售信息被修改: %+v", saved)
+				}
+				assertAudits(t, repos)
+				return
+			}
+			if saved.Description != "促销" {
+				t.Fatalf("更新后 = %+v", saved)
+			}
+			assertAudits(t, repos, auditKey(model.AuditEntitySaleInfo, 1, model.AuditActionUpdate))
+		})
+	}
+}
+
+func TestSaleInfoService_Update_KeepsDeletedReference(t *testing.T) {
+	// 原有引用的物流已删除时，只修改说明不应被引用检查拒绝
+	repos := newSaleInfoRepos()
+	repos.Reference.AddExisting(model.AuditEntityLogistics, 1, true)
+	s := newSaleInfoService(repos)
+
+	result := s.Update(salePlaceScope(5), &dto.SaleInfoDTO{ID: 1, LogisticsID: 1, SalePlaceID: 5, Description: "促销", SaleTime: saleTime})
+	assertResult(t, result, 200, "更新成功")
+}
+
+func TestSaleInfoService_Delete(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    model.DataScope
+		id       int
+		blocked  bool
+		auditErr error
+		wantCode int
+	}{
+		{name: "删除成功", scope: salePlaceScope(5), id: 1, wantCode: 200},
+		{name: "审计日志写入失败时不删除", scope: adminScope, id: 1, auditErr: errFake, wantCode: 500},
+		{name: "已生成溯源码", scope: adminScope, id: 1, blocked: true, wantCode: 409},
+		{name: "其他销售地", scope: salePlaceScope(6), id: 1, wantCode: 403},
+		{name: "不存在", scope: adminScope, id: 99, wantCode: 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newSaleInfoRepos()
+			if tt.blocked {
+				repos.Reference.AddBlocking(model.AuditEntitySaleInfo, 1,
+					model.NewBlockingReference(model.AuditEntityTraceCode, 1, []int{1}, model.AuditEntitySaleInfo))
+			}
+			repos.Audit.Err = tt.auditErr
+			s := newSaleInfoService(repos)
+
+			assertResult(t, s.Delete(tt.scope, tt.id), tt.wantCode, "")
+			_, exists := repos.SaleInfo.SaleInfos[1]
+			if exists == (tt.wantCode == 200) {
+				t.Fatalf("删除后销售信息是否存在 = %v", exists)
+			}
+		})
+	}
+}
+
+func TestSaleInfoService_Scope(t *testing.T) {
+	repos := newSaleInfoRepos()
+	s := newSaleInfoService(repos)
+
+	assertResult(t, s.GetByID(salePlaceScope(5), 1), 200, "")
+	assertResult(t, s.GetByID(salePlaceScope(5), 2), 404, "销售信息不存在")
+	assertResult(t, s.GetByID(adminScope, 2), 200, "")
+
+	all := s.GetAll(salePlaceScope(6)).Data.([]*model.SaleInfoVO)
+	if len(all) != 1 || all[0].ID != 2 {
+		t.Fatalf("GetAll = %+v", all)
+	}
+	page := s.PageQuery(adminScope, &dto.SaleInfoPageQueryDTO{}).Data.(*dto.PageResult)
+	if page.Total != 2 || page.Page != 1 || page.PageSize != 10 {
+		t.Fatalf("PageQuery = %+v", page)
+	}
+}
+
+func TestSaleInfoService_ReverseTrace(t *testing.T) {
+	repos := newSaleInfoRepos()
+	repos.SaleInfo = repotest.NewSaleInfoRepository(
+		&model.SaleInfoVO{ID: 1, LogisticsID: 1, SalePlaceID: 5, SaleTime: saleTime},
+		&model.SaleInfoVO{ID: 2, LogisticsID: 1, SalePlaceID: 5, SaleTime: saleTime.Add(time.Hour)},
+		&model.SaleInfoVO{ID: 3, LogisticsID: 2, SalePlaceID: 5, SaleTime: saleTime.Add(2 * time.Hour)},
+		&model.SaleInfoVO{ID: 4, LogisticsID: 2, SalePlaceID: 5, SaleTime: saleTime.AddDate(0, 1, 0)},
+	)
+	supply := func(saleInfoID, logisticsID, productInfoID, companyID int) *model.SaleSupply {
+		return &model.SaleSupply{
+			SaleInfoID: saleInfoID, LogisticsID: logisticsID, CompanyID: companyID,
+			ProductInfoID: productInfoID, ProductPlaceID: 2, ProductID: 1, ProductName: "苹果",
+		}
+	}
+	repos.SaleInfo.Supplies[1] = supply(1, 1, 1, 1)
+	repos.SaleInfo.Supplies[2] = supply(2, 1, 1, 1)
+	repos.SaleInfo.Supplies[3] = supply(3, 2, 2, 2)
+	s := newSaleInfoService(repos)
+	start, end := saleTime.Add(-time.Hour), saleTime.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name        string
+		scope       model.DataScope
+		salePlaceID int
+		start, end  time.Time
+		wantCode    int
+	}{
+		{name: "零售商默认本销售地", scope: salePlaceScope(5), start: start, end: end, wantCode: 200},
+		{name: "结束时间不晚于开始时间", scope: adminScope, salePlaceID: 5, start: end, end: start, wantCode: 400},
+		{name: "未指定销售地", scope: adminScope, start: start, end: end, wantCode: 400},
+		{name: "其他销售地", scope: salePlaceScope(6), salePlaceID: 5, start: start, end: end, wantCode: 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.ReverseTrace(tt.scope, tt.salePlaceID, tt.start, tt.end)
+			assertResult(t, result, tt.wantCode, "")
+			if tt.wantCode != 200 {
+				return
+			}
+
+			trace := result.Data.(*model.ReverseTrace)
+			if trace.SalePlaceID != 5 || trace.SaleCount != 3 {
+				t.Fatalf("反向溯源 = %+v", trace)
+			}
+			if len(trace.ProductionPlaces) != 1 || trace.ProductionPlaces[0].SaleCount != 3 || trace.ProductionPlaces[0].BatchCount != 2 {
+				t.Fatalf("供货生产地 = %+v", trace.ProductionPlaces)
+			}
+			if len(trace.Companies) != 2 || trace.Companies[0].CompanyID != 1 || trace.Companies[0].SaleCount != 2 {
+				t.Fatalf("物流公司 = %+v", trace.Companies)
+			}
+		})
+	}
+}
+
+// assertInvalidReferences 校验422结果中列出的无效引用字段
+func assertInvalidReferences(t *testing.T, result *dto.Result, fields ...string) {
+	t.Helper()
+	refs, ok := result.Data.([]*model.InvalidReference)
+	if !ok || len(refs) != len(fields) {
+		t.Fatalf("无效引用 = %+v, 期望 %v", result.Data, fields)
+	}
+	for i, ref := range refs {
+		if ref.Field != fields[i] {
+			t.Fatalf("无效引用 = %+v, 期望 %v", refs, fields)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"log"
+	"time"
 
 	"agricultural_product_gin/dto"
 	"agricultural_product_gin/model"
@@ -10,16 +11,17 @@ import (
 
 // SalePlaceService 销售地服务
 type SalePlaceService struct {
-	SalePlaceRepo *repository.SalePlaceRepository
+	SalePlaceRepo repository.SalePlaceRepository
+	uow           repository.UnitOfWork
 }
 
 // NewSalePlaceService 创建销售地服务
-func NewSalePlaceService(salePlaceRepo *repository.SalePlaceRepository) *SalePlaceService {
-	return &SalePlaceService{SalePlaceRepo: salePlaceRepo}
+func NewSalePlaceService(salePlaceRepo repository.SalePlaceRepository, uow repository.UnitOfWork) *SalePlaceService {
+	return &SalePlaceService{SalePlaceRepo: salePlaceRepo, uow: uow}
 }
 
 // CreateSalePlace 创建销售地
-func (s *SalePlaceService) CreateSalePlace(salePlaceDTO *dto.SalePlaceDTO) *dto.Result {
+func (s *SalePlaceService) CreateSalePlace(scope model.DataScope, salePlaceDTO *dto.SalePlaceDTO) *dto.Result {
 	// 转换DTO为模型
 	salePlace := &model.SalePlace{
 		Address:       salePlaceDTO.Address,
@@ -28,7 +30,15 @@ func (s *SalePlaceService) CreateSalePlace(salePlaceDTO *dto.SalePlaceDTO) *dto.
 	}
 
 	// 保存销售地
-	id, err := s.SalePlaceRepo.Save(salePlace)
+	var id int
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		var err error
+		if id, err = repos.SalePlace.Save(salePlace); err != nil {
+			return err
+		}
+		salePlace.ID = id
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntitySalePlace, id, model.AuditActionCreate, nil, salePlace)
+	})
 	if err != nil {
 		log.Println("创建销售地失败:", err)
 		return errorResult(500, "创建销售地失败")
@@ -39,7 +49,7 @@ func (s *SalePlaceService) CreateSalePlace(salePlaceDTO *dto.SalePlaceDTO) *dto.
 }
 
 // UpdateSalePlace 更新销售地
-func (s *SalePlaceService) UpdateSalePlace(salePlaceDTO *dto.SalePlaceDTO) *dto.Result {
+func (s *SalePlaceService) UpdateSalePlace(scope model.DataScope, salePlaceDTO *dto.SalePlaceDTO) *dto.Result {
 	// 检查销售地是否存在
 	existingSalePlace, err := s.SalePlaceRepo.GetByID(salePlaceDTO.ID)
 	if err != nil {
@@ -50,6 +60,12 @@ func (s *SalePlaceService) UpdateSalePlace(salePlaceDTO *dto.SalePlaceDTO) *dto.
 	if existingSalePlace == nil {
 		return errorResult(404, "销售地不存在")
 	}
+	if !scope.AllowSalePlace(existingSalePlace.ID) {
+		return errorResult(403, "只能修改绑定的销售地")
+	}
+	if existingSalePlace.Deleted() {
+		return errorResult(409, "销售地已删除，请先恢复")
+	}
 
 	// 转换DTO为模型
 	salePlace := &model.SalePlace{
@@ -60,7 +76,12 @@ func (s *SalePlaceService) UpdateSalePlace(salePlaceDTO *dto.SalePlaceDTO) *dto.
 	}
 
 	// 更新销售地
-	err = s.SalePlaceRepo.Update(salePlace)
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.SalePlace.Update(salePlace); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntitySalePlace, salePlace.ID, model.AuditActionUpdate, existingSalePlace, salePlace)
+	})
 	if err != nil {
 		log.Println("更新销售地失败:", err)
 		return errorResult(500, "更新失败")
@@ -69,8 +90,8 @@ func (s *SalePlaceService) UpdateSalePlace(salePlaceDTO *dto.SalePlaceDTO) *dto.
 	return successResult("更新成功", nil)
 }
 
-// DeleteSalePlace 删除销售地
-func (s *SalePlaceService) DeleteSalePlace(id int) *dto.Result {
+// DeleteSalePlace 删除销售地(软删除)，已有销售记录中的销售地仍可用于溯源
+func (s *SalePlaceService) DeleteSalePlace(scope model.DataScope, id int) *dto.Result {
 	// 检查销售地是否存在
 	existingSalePlace, err := s.SalePlaceRepo.GetByID(id)
 	if err != nil {
@@ -81,9 +102,20 @@ func (s *SalePlaceService) DeleteSalePlace(id int) *dto.Result {
 	if existingSalePlace == nil {
 		return errorResult(404, "销售地不存在")
 	}
+	if !scope.AllowSalePlace(existingSalePlace.ID) {
+		return errorResult(403, "只能删除绑定的销售地")
+	}
+	if existingSalePlace.Deleted() {
+		return errorResult(409, "销售地已删除")
+	}
 
 	// 删除销售地
-	err = s.SalePlaceRepo.Delete(id)
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.SalePlace.Delete(id, time.Now(), scope.Actor.Username); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntitySalePlace, id, model.AuditActionDelete, existingSalePlace, nil)
+	})
 	if err != nil {
 		log.Println("删除销售地失败:", err)
 		return errorResult(500, "删除失败")
@@ -92,7 +124,38 @@ func (s *SalePlaceService) DeleteSalePlace(id int) *dto.Result {
 	return successResult("删除成功", nil)
 }
 
-// GetSalePlaceByID 根据ID获取销售地
+// RestoreSalePlace 恢复已删除的销售地
+func (s *SalePlaceService) RestoreSalePlace(scope model.DataScope, id int) *dto.Result {
+	existingSalePlace, err := s.SalePlaceRepo.GetByID(id)
+	if err != nil {
+		log.Println("查询销售地失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	if existingSalePlace == nil {
+		return errorResult(404, "销售地不存在")
+	}
+	if !existingSalePlace.Deleted() {
+		return errorResult(409, "销售地未删除")
+	}
+
+	restored := *existingSalePlace
+	restored.SoftDelete = model.SoftDelete{}
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.SalePlace.Restore(id); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntitySalePlace, id, model.AuditActionRestore, existingSalePlace, &restored)
+	})
+	if err != nil {
+		log.Println("恢复销售地失败:", err)
+		return errorResult(500, "恢复失败")
+	}
+
+	return successResult("恢复成功", nil)
+}
+
+// GetSalePlaceByID 根据ID获取销售地，已删除的销售地同样返回(含删除时间及删除人)
 func (s *SalePlaceService) GetSalePlaceByID(id int) *dto.Result {
 	salePlace, err := s.SalePlaceRepo.GetByID(id)
 	if err != nil {
@@ -111,9 +174,9 @@ func (s *SalePlaceService) GetSalePlaceByID(id int) *dto.Result {
 	}
 }
 
-// GetAllSalePlaces 获取所有销售地
-func (s *SalePlaceService) GetAllSalePlaces() *dto.Result {
-	salePlaces, err := s.SalePlaceRepo.FindAll()
+// GetAllSalePlaces 获取所有销售地，includeDeleted为false时不含已删除的销售地
+func (s *SalePlaceService) GetAllSalePlaces(includeDeleted bool) *dto.Result {
+	salePlaces, err := s.SalePlaceRepo.FindAll(includeDeleted)
 	if err != nil {
 		log.Println("获取所有销售地失败:", err)
 		return errorResult(500, "系统错误")
@@ -144,6 +207,7 @@ func (s *SalePlaceService) PageQuerySalePlaces(queryDTO *dto.SalePlacePageQueryD
 		queryDTO.Address,
 		queryDTO.Administrator,
 		queryDTO.Phone,
+		queryDTO.IncludeDeleted,
 	)
 	if err != nil {
 		log.Println("分页查询销售地失败:", err)
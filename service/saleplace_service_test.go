@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository/repotest"
+)
+
+func TestSalePlaceService_Lifecycle(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		run       func(s *SalePlaceService) *dto.Result
+		auditErr  error
+		wantCode  int
+		wantMsg   string
+		wantAudit string
+	}{
+		{
+			name: "创建",
+			run: func(s *SalePlaceService) *dto.Result {
+				return s.CreateSalePlace(adminScope, &dto.SalePlaceDTO{Address: "超市3"})
+			},
+			wantCode: 200, wantAudit: auditKey(model.AuditEntitySalePlace, 3, model.AuditActionCreate),
+		},
+		{
+			name: "零售商更新本销售地",
+			run: func(s *SalePlaceService) *dto.Result {
+				return s.UpdateSalePlace(salePlaceScope(1), &dto.SalePlaceDTO{ID: 1, Address: "超市1(新址)"})
+			},
+			wantCode: 200, wantAudit: auditKey(model.AuditEntitySalePlace, 1, model.AuditActionUpdate),
+		},
+		{
+			name: "零售商更新其他销售地",
+			run: func(s *SalePlaceService) *dto.Result {
+				return s.UpdateSalePlace(salePlaceScope(2), &dto.SalePlaceDTO{ID: 1, Address: "超市1(新址)"})
+			},
+			wantCode: 403, wantMsg: "只能修改绑定的销售地",
+		},
+		{
+			name:     "零售商删除其他销售地",
+			run:      func(s *SalePlaceService) *dto.Result { return s.DeleteSalePlace(salePlaceScope(2), 1) },
+			wantCode: 403, wantMsg: "只能删除绑定的销售地",
+		},
+		{
+			name:     "删除",
+			run:      func(s *SalePlaceService) *dto.Result { return s.DeleteSalePlace(adminScope, 1) },
+			wantCode: 200, wantAudit: auditKey(model.AuditEntitySalePlace, 1, model.AuditActionDelete),
+		},
+		{
+			name:     "重复删除",
+			run:      func(s *SalePlaceService) *dto.Result { return s.DeleteSalePlace(adminScope, 2) },
+			wantCode: 409, wantMsg: "销售地已删除",
+		},
+		{
+			name:     "恢复",
+			run:      func(s *SalePlaceService) *dto.Result { return s.RestoreSalePlace(adminScope, 2) },
+			wantCode: 200, wantAudit: auditKey(model.AuditEntitySalePlace, 2, model.AuditActionRestore),
+		},
+		{
+			name:     "查询不存在的销售地",
+			run:      func(s *SalePlaceService) *dto.Result { return s.GetSalePlaceByID(99) },
+			wantCode: 404, wantMsg: "销售地不存在",
+		},
+		{
+			name:     "审计日志写入失败时不删除",
+			run:      func(s *SalePlaceService) *dto.Result { return s.DeleteSalePlace(adminScope, 1) },
+			auditErr: errFake, wantCode: 500, wantMsg: "删除失败",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestRepos()
+			repos.SalePlace = repotest.NewSalePlaceRepository(
+				&model.SalePlace{ID: 1, Address: "超市1"},
+				&model.SalePlace{ID: 2, Address: "超市2",
+					SoftDelete: model.SoftDelete{DeletedAt: &deletedAt, DeletedBy: "admin"}},
+			)
+			s := NewSalePlaceService(repos.SalePlace, repos.uow())
+
+			repos.Audit.Err = tt.auditErr
+			assertResult(t, tt.run(s), tt.wantCode, tt.wantMsg)
+			if tt.auditErr != nil && repos.SalePlace.SalePlaces[1].Deleted() {
+				t.Fatal("审计日志写入失败后仍删除了销售地")
+			}
+			if tt.wantAudit == "" {
+				assertAudits(t, repos)
+			} else {
+				assertAudits(t, repos, tt.wantAudit)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+	"agricultural_product_gin/repository/repotest"
+)
+
+// TestMain 服务出错时会打印日志，测试中关闭日志输出
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testRepos 服务测试使用的内存仓储，批次仓库与生产信息、物流仓库共用数据
+type testRepos struct {
+	Company         *repotest.CompanyRepository
+	Product         *repotest.ProductRepository
+	ProductionPlace *repotest.ProductionPlaceRepository
+	SalePlace       *repotest.SalePlaceRepository
+	Production      *repotest.ProductionRepository
+	Batch           *repotest.BatchRepository
+	FarmingActivity *repotest.FarmingActivityRepository
+	Inspection      *repotest.InspectionRepository
+	Recall          *repotest.RecallRepository
+	Logistics       *repotest.LogisticsRepository
+	LogisticsEvent  *repotest.LogisticsEventRepository
+	SensorReading   *repotest.SensorReadingRepository
+	SaleInfo        *repotest.SaleInfoRepository
+	TraceCode       *repotest.TraceCodeRepository
+	User            *repotest.UserRepository
+	Token           *repotest.TokenRepository
+	Audit           *repotest.AuditRepository
+	Reference       *repotest.ReferenceRepository
+}
+
+// newTestRepos 创建空的内存仓储
+func newTestRepos() *testRepos {
+	productions := repotest.NewProductionRepository()
+	logistics := repotest.NewLogisticsRepository()
+	users := repotest.NewUserRepository()
+	return &testRepos{
+		Company:         repotest.NewCompanyRepository(),
+		Product:         repotest.NewProductRepository(),
+		ProductionPlace: repotest.NewProductionPlaceRepository(),
+		SalePlace:       repotest.NewSalePlaceRepository(),
+		Production:      productions,
+		Batch:           repotest.NewBatchRepository(productions, logistics),
+		FarmingActivity: repotest.NewFarmingActivityRepository(),
+		Inspection:      repotest.NewInspectionRepository(),
+		Recall:          repotest.NewRecallRepository(),
+		Logistics:       logistics,
+		LogisticsEvent:  repotest.NewLogisticsEventRepository(),
+		SensorReading:   repotest.NewSensorReadingRepository(),
+		SaleInfo:        repotest.NewSaleInfoRepository(),
+		TraceCode:       repotest.NewTraceCodeRepository(),
+		User:            users,
+		Token:           repotest.NewTokenRepository(users),
+		Audit:           repotest.NewAuditRepository(),
+		Reference:       repotest.NewReferenceRepository(),
+	}
+}
+
+// uow 在内存仓储上执行的工作单元
+func (r *testRepos) uow() *repotest.UnitOfWork {
+	return repotest.NewUnitOfWork(&repository.Repositories{
+		Company:         r.Company,
+		Product:         r.Product,
+		ProductionPlace: r.ProductionPlace,
+		SalePlace:       r.SalePlace,
+		Production:      r.Production,
+		Batch:           r.Batch,
+		FarmingActivity: r.FarmingActivity,
+		Inspection:      r.Inspection,
+		Recall:          r.Recall,
+		Logistics:       r.Logistics,
+		LogisticsEvent:  r.LogisticsEvent,
+		SensorReading:   r.SensorReading,
+		SaleInfo:        r.SaleInfo,
+		TraceCode:       r.TraceCode,
+		User:            r.User,
+		Token:           r.Token,
+		Audit:           r.Audit,
+		Reference:       r.Reference,
+	})
+}
+
+// exist 登记引用检查时存在且未删除的记录
+func (r *testRepos) exist(entity string, ids ...int) {
+	for _, id := range ids {
+		r.Reference.AddExisting(entity, id, false)
+	}
+}
+
+// audits 按写入顺序返回审计日志的"实体:ID:操作"
+func (r *testRepos) audits() []string {
+	ids := make([]int, 0, len(r.Audit.AuditLogs))
+	for id := range r.Audit.AuditLogs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	list := make([]string, 0, len(ids))
+	for _, id := range ids {
+		auditLog := r.Audit.AuditLogs[id]
+		list = append(list, auditKey(auditLog.Entity, auditLog.EntityID, auditLog.Action))
+	}
+	return list
+}
+
+// auditKey 审计日志的"实体:ID:操作"
+func auditKey(entity string, id int, action string) string {
+	return entity + ":" + strconv.Itoa(id) + ":" + action
+}
+
+// companyScope 限于物流公司的数据权限
+func companyScope(id int) model.DataScope {
+	return model.DataScope{CompanyID: &id, Actor: model.Actor{UserID: 2, Username: "logistics"}}
+}
+
+// productPlaceScope 限于生产地的数据权限
+func productPlaceScope(id int) model.DataScope {
+	return model.DataScope{ProductPlaceID: &id, Actor: model.Actor{UserID: 3, Username: "farmer"}}
+}
+
+// salePlaceScope 限于销售地的数据权限
+func salePlaceScope(id int) model.DataScope {
+	return model.DataScope{SalePlaceID: &id, Actor: model.Actor{UserID: 4, Username: "retailer"}}
+}
+
+// errFake 模拟的数据库错误
+var errFake = errors.New("模拟的数据库错误")
+
+// adminScope 管理员不限数据权限
+var adminScope = model.DataScope{Actor: model.Actor{UserID: 1, Username: "admin"}}
+
+// mustNoError 出现错误时立即终止测试
+func mustNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("意外的错误: %v", err)
+	}
+}
+
+// assertResult 校验服务返回的状态码，wantMsg为空时不校验提示信息
+func assertResult(t *testing.T, result *dto.Result, wantCode int, wantMsg string) {
+	t.Helper()
+	if result == nil {
+		t.Fatalf("结果为nil, 期望 %d", wantCode)
+	}
+	if result.Code != wantCode || (wantMsg != "" && result.Msg != wantMsg) {
+		t.Fatalf("结果 = %d %q, 期望 %d %q", result.Code, result.Msg, wantCode, wantMsg)
+	}
+}
+
+// assertAudits 校验写入的审计日志
+func assertAudits(t *testing.T, repos *testRepos, want ...string) {
+	t.Helper()
+	got := repos.audits()
+	if len(got) != len(want) {
+		t.Fatalf("审计日志 = %v, 期望 %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("审计日志 = %v, 期望 %v", got, want)
+		}
+	}
+}
+
+// ptr 返回值的指针
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+	"agricultural_product_gin/utils"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("无效的刷新令牌")
+	ErrRefreshTokenExpired = errors.New("刷新令牌已过期，请重新登录")
+	ErrRefreshTokenReused  = errors.New("刷新令牌已被使用，已注销该账号的所有会话")
+)
+
+// TokenService 令牌服务：签发、轮换与吊销
+type TokenService struct {
+	tokenRepo repository.TokenRepository
+	userRepo  repository.UserRepository
+}
+
+// NewTokenService 创建令牌服务
+func NewTokenService(tokenRepo repository.TokenRepository, userRepo repository.UserRepository) *TokenService {
+	return &TokenService{tokenRepo: tokenRepo, userRepo: userRepo}
+}
+
+// Issue 为用户签发访问令牌和刷新令牌
+func (s *TokenService) Issue(user *model.User) (*dto.TokenDTO, error) {
+	accessToken, claims, err := utils.GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.tokenRepo.SaveRefreshToken(&model.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(refreshToken),
+		AccessJTI: claims.Id,
+		ExpiresAt: now.Add(utils.RefreshTokenExpire),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TokenDTO{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(utils.AccessTokenExpire.Seconds()),
+	}, nil
+}
+
+// Refresh 使用刷新令牌换取新的令牌对，旧刷新令牌随即失效
+// 已失效的刷新令牌被再次使用说明可能已泄露，此时注销该账号的所有会话
+func (s *TokenService) Refresh(refreshToken string) (*dto.TokenDTO, error) {
+	stored, err := s.tokenRepo.FindRefreshToken(utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if stored.RevokedAt != nil {
+		return nil, s.revokeOnReuse(stored.UserID)
+	}
+
+	now := time.Now()
+	if now.After(stored.ExpiresAt) {
+		return nil, ErrRefreshTokenExpired
+	}
+
+	// 并发轮换时只有一个请求能成功吊销旧令牌
+	revoked, err := s.tokenRepo.RevokeRefreshToken(stored.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, s.revokeOnReuse(stored.UserID)
+	}
+
+	// 重新读取用户，使角色和绑定的变更在刷新后生效
+	user, err := s.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	return s.Issue(user)
+}
+
+// Revoke 吊销当前会话的访问令牌及与之配对的刷新令牌
+func (s *TokenService) Revoke(caller *model.Identity) error {
+	if err := s.tokenRepo.RevokeAccessToken(caller.TokenID, caller.UserID, caller.TokenExpiresAt); err != nil {
+		return err
+	}
+	return s.tokenRepo.RevokeRefreshTokenByAccessJTI(caller.TokenID, time.Now())
+}
+
+// RevokeAll 注销用户的所有会话：吊销全部刷新令牌，并递增令牌版本使已签发的访问令牌失效
+func (s *TokenService) RevokeAll(userID int) error {
+	return revokeUserTokens(s.tokenRepo, s.userRepo, userID)
+}
+
+// revokeUserTokens 注销用户的所有会话，传入事务中的仓储时与密码、角色等变更一起提交
+func revokeUserTokens(tokens repository.TokenRepository, users repository.UserRepository, userID int) error {
+	if err := tokens.RevokeUserRefreshTokens(userID, time.Now()); err != nil {
+		return err
+	}
+	return users.IncrementTokenVersion(userID)
+}
+
+// IsRevoked 检查访问令牌是否已被吊销，供JWTMiddleware使用
+func (s *TokenService) IsRevoked(claims *utils.Claims) (bool, error) {
+	revoked, tokenVersion, exists, err := s.tokenRepo.AccessTokenState(claims.Id, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	return revoked || !exists || tokenVersion != claims.TokenVersion, nil
+}
+
+// revokeOnReuse 刷新令牌被重复使用时注销该用户的所有会话
+func (s *TokenService) revokeOnReuse(userID int) error {
+	log.Println("检测到刷新令牌重复使用，注销用户所有会话:", userID)
+	if err := s.RevokeAll(userID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
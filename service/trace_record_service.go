@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+// ErrTraceRecordNoLogistics 完整溯源记录缺少物流运输段，销售信息无法关联物流
+var ErrTraceRecordNoLogistics = errors.New("至少需要一段物流运输")
+
+// TraceRecordService 完整溯源记录服务，一次性创建生产 -> 物流 -> 销售的整条记录
+type TraceRecordService struct {
+	uow repository.UnitOfWork
+}
+
+// NewTraceRecordService 创建完整溯源记录服务
+func NewTraceRecordService(uow repository.UnitOfWork) *TraceRecordService {
+	return &TraceRecordService{uow: uow}
+}
+
+// Create 在一个事务中创建生产信息、各物流运输段及销售信息，任一步失败则全部回滚
+// 未指定生产地、物流公司、销售地时默认为当前用户绑定的主体
+func (s *TraceRecordService) Create(scope model.DataScope, recordDTO *dto.TraceRecordDTO) *dto.Result {
+	// 不经过请求绑定调用时同样要求至少一段物流，销售信息关联最后一段
+	if len(recordDTO.Logistics) == 0 {
+		return errorResult(422, ErrTraceRecordNoLogistics.Error())
+	}
+
+	production := &recordDTO.Production
+	if scope.ProductPlaceID != nil && production.ProductPlaceID <= 0 {
+		production.ProductPlaceID = *scope.ProductPlaceID
+	}
+	if !scope.AllowProductPlace(production.ProductPlaceID) {
+		return errorResult(403, ProductionForbidden)
+	}
+	if production.Quantity < 0 {
+		return errorResult(400, "批次数量不能为负数")
+	}
+
+	for i := range recordDTO.Logistics {
+		leg := &recordDTO.Logistics[i]
+		if scope.CompanyID != nil && leg.CompanyID <= 0 {
+			leg.CompanyID = *scope.CompanyID
+		}
+		if !scope.AllowCompany(leg.CompanyID) {
+			return errorResult(403, ErrLogisticsForbidden.Error())
+		}
+	}
+
+	sale := &recordDTO.Sale
+	if scope.SalePlaceID != nil && sale.SalePlaceID <= 0 {
+		sale.SalePlaceID = *scope.SalePlaceID
+	}
+	if !scope.AllowSalePlace(sale.SalePlaceID) {
+		return errorResult(403, SaleInfoForbidden)
+	}
+
+	record := &dto.TraceRecordVO{}
+	err := s.uow.Do(func(repos *repository.Repositories) error {
+		// 与单独新增时一样先检查引用的数据，一次返回所有无效的引用
+		refs := []referenceField{
+			reference("production.productId", model.AuditEntityProduct, production.ProductID),
+			reference("production.productPlaceId", model.AuditEntityProductionPlace, production.ProductPlaceID),
+		}
+		for i, leg := range recordDTO.Logistics {
+			refs = append(refs, reference(fmt.Sprintf("logistics[%d].companyId", i), model.AuditEntityCompany, leg.CompanyID))
+		}
+		refs = append(refs, reference("sale.salePlaceId", model.AuditEntitySalePlace, sale.SalePlaceID))
+		if err := checkReferences(repos.Reference, refs...); err != nil {
+			return err
+		}
+
+		info := &model.ProductionInfo{
+			BatchNo:        production.BatchNo,
+			ProductID:      production.ProductID,
+			ProductPlaceID: production.ProductPlaceID,
+			SeedSource:     production.SeedSource,
+			Description:    production.Description,
+			PlantingDate:   production.PlantingDate,
+			HarvestDate:    production.HarvestDate,
+			Quantity:       production.Quantity,
+			Unit:           production.Unit,
+		}
+		if err := assignBatchNo(repos.Batch, info); err != nil {
+			return err
+		}
+		var err error
+		record.ProductInfoID, err = repos.Production.Save(info)
+		if err != nil {
+			return err
+		}
+		info.ID = record.ProductInfoID
+		if err := recordAudit(repos.Audit, scope.Actor, model.AuditEntityProduction, info.ID, model.AuditActionCreate, nil, info); err != nil {
+			return err
+		}
+
+		// 各运输段的状态与单独新增物流时一致：已填写到达时间的视为已送达
+		// 后一段记录上一段，销售的溯源链据此回溯
+		now := time.Now()
+		var prevID *int
+		record.LogisticsIDs = make([]int, 0, len(recordDTO.Logistics))
+		for _, leg := range recordDTO.Logistics {
+			status := model.LogisticsStatusCreated
+			if leg.EndTime != nil {
+				status = model.LogisticsStatusDelivered
+			}
+			// 各运输段分配的数量依次校验，合计不能超过批次数量
+			if err := checkBatchAllocation(repos.Batch, record.ProductInfoID, leg.Quantity, 0); err != nil {
+				return err
+			}
+			logistics := &model.Logistics{
+				ProductInfoID: record.ProductInfoID,
+				CompanyID:     leg.CompanyID,
+				PrevID:        prevID,
+				Quantity:      leg.Quantity,
+				StartLocation: leg.StartLocation,
+				Destination:   leg.Destination,
+				StartTime:     leg.StartTime,
+				EndTime:       leg.EndTime,
+				Status:        status,
+				StatusTime:    &now,
+			}
+			id, err := repos.Logistics.Save(logistics)
+			if err != nil {
+				return err
+			}
+			logistics.ID = id
+			if err := recordAudit(repos.Audit, scope.Actor, model.AuditEntityLogistics, id, model.AuditActionCreate, nil, logistics); err != nil {
+				return err
+			}
+			record.LogisticsIDs = append(record.LogisticsIDs, id)
+			prevID = &logistics.ID
+		}
+
+		saleInfo := &model.SaleInfo{
+			LogisticsID: record.LogisticsIDs[len(record.LogisticsIDs)-1],
+			SalePlaceID: sale.SalePlaceID,
+			Description: sale.Description,
+			SaleTime:    sale.SaleTime,
+		}
+		if record.SaleInfoID, err = repos.SaleInfo.Save(saleInfo); err != nil {
+			return err
+		}
+		saleInfo.ID = record.SaleInfoID
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntitySaleInfo, saleInfo.ID, model.AuditActionCreate, nil, saleInfo)
+	})
+	if isBatchError(err) {
+		return batchErrorResult(err, "创建溯源记录失败")
+	}
+	if result := referenceErrorResult(err); result != nil {
+		return result
+	}
+	if err != nil {
+		log.Println("创建完整溯源记录失败，已回滚:", err)
+		return errorResult(500, "创建溯源记录失败")
+	}
+
+	return successResult("创建成功", record)
+}
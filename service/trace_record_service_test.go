@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+)
+
+func newTraceRecordRepos() *testRepos {
+	repos := newTestRepos()
+	repos.exist(model.AuditEntityProduct, 1)
+	repos.exist(model.AuditEntityProductionPlace, 2)
+	repos.exist(model.AuditEntityCompany, 1)
+	repos.exist(model.AuditEntitySalePlace, 5)
+	return repos
+}
+
+func TestTraceRecordService_Create(t *testing.T) {
+	record := func(legs int) dto.TraceRecordDTO {
+		recordDTO := dto.TraceRecordDTO{
+			Production: dto.ProductionDTO{
+				ProductID: 1, ProductPlaceID: 2, PlantingDate: harvestDate.AddDate(0, -3, 0), HarvestDate: harvestDate, Quantity: 100,
+			},
+			Sale: dto.TraceRecordSaleInfoDTO{SalePlaceID: 5, SaleTime: saleTime},
+		}
+		for i := 0; i < legs; i++ {
+			recordDTO.Logistics = append(recordDTO.Logistics, dto.TraceRecordLegDTO{
+				CompanyID: 1, StartLocation: "产地", Destination: "仓库", StartTime: harvestDate,
+			})
+		}
+		return recordDTO
+	}
+
+	tests := []struct {
+		name      string
+		record    dto.TraceRecordDTO
+		auditErr  error
+		wantCode  int
+		wantMsg   string
+		wantLinks int
+	}{
+		{name: "两段物流", record: record(2), wantCode: 200, wantLinks: 2},
+		{name: "缺少物流运输段", record: record(0), wantCode: 422, wantMsg: ErrTraceRecordNoLogistics.Error()},
+		{name: "审计日志写入失败时回滚", record: record(2), auditErr: errFake, wantCode: 500, wantMsg: "创建溯源记录失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTraceRecordRepos()
+			repos.Audit.Err = tt.auditErr
+			s := NewTraceRecordService(repos.uow())
+
+			result := s.Create(adminScope, &tt.record)
+			assertResult(t, result, tt.wantCode, tt.wantMsg)
+			if tt.wantCode != 200 {
+				if len(repos.Production.Productions) != 0 || len(repos.Logistics.Logistics) != 0 || len(repos.SaleInfo.SaleInfos) != 0 {
+					t.Fatal("失败后仍写入了溯源记录")
+				}
+				assertAudits(t, repos)
+				return
+			}
+
+			vo := result.Data.(*dto.TraceRecordVO)
+			if len(vo.LogisticsIDs) != tt.wantLinks {
+				t.Fatalf("物流ID = %v", vo.LogisticsIDs)
+			}
+			last := vo.LogisticsIDs[len(vo.LogisticsIDs)-1]
+			if saleInfo := repos.SaleInfo.SaleInfos[vo.SaleInfoID]; saleInfo == nil || saleInfo.LogisticsID != last {
+				t.Fatalf("销售信息 = %+v, 期望关联物流 %d", saleInfo, last)
+			}
+			if prev := repos.Logistics.Logistics[last].PrevID; prev == nil || *prev != vo.LogisticsIDs[0] {
+				t.Fatalf("最后一段的上一段 = %v", prev)
+			}
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package service
+
+import (
+	"log"
+	"slices"
+	"sort"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+)
+
+// TraceabilityService 溯源服务
+type TraceabilityService struct {
+	SaleInfoRepo        repository.SaleInfoRepository
+	SalePlaceRepo       repository.SalePlaceRepository
+	LogisticsRepo       repository.LogisticsRepository
+	CompanyRepo         repository.CompanyRepository
+	ProductionRepo      repository.ProductionRepository
+	ProductionPlaceRepo repository.ProductionPlaceRepository
+	ProductRepo         repository.ProductRepository
+	BatchRepo           repository.BatchRepository
+	FarmingActivityRepo repository.FarmingActivityRepository
+	InspectionRepo      repository.InspectionRepository
+	RecallRepo          repository.RecallRepository
+	ColdChainService    *ColdChainService
+	HashChainService    *HashChainService
+}
+
+// NewTraceabilityService 创建溯源服务
+func NewTraceabilityService(
+	saleInfoRepo repository.SaleInfoRepository,
+	salePlaceRepo repository.SalePlaceRepository,
+	logisticsRepo repository.LogisticsRepository,
+	companyRepo repository.CompanyRepository,
+	productionRepo repository.ProductionRepository,
+	productionPlaceRepo repository.ProductionPlaceRepository,
+	productRepo repository.ProductRepository,
+	batchRepo repository.BatchRepository,
+	farmingActivityRepo repository.FarmingActivityRepository,
+	inspectionRepo repository.InspectionRepository,
+	recallRepo repository.RecallRepository,
+	coldChainService *ColdChainService,
+	hashChainService *HashChainService,
+) *TraceabilityService {
+	return &TraceabilityService{
+		SaleInfoRepo:        saleInfoRepo,
+		SalePlaceRepo:       salePlaceRepo,
+		LogisticsRepo:       logisticsRepo,
+		CompanyRepo:         companyRepo,
+		ProductionRepo:      productionRepo,
+		ProductionPlaceRepo: productionPlaceRepo,
+		ProductRepo:         productRepo,
+		BatchRepo:           batchRepo,
+		FarmingActivityRepo: farmingActivityRepo,
+		InspectionRepo:      inspectionRepo,
+		RecallRepo:          recallRepo,
+		ColdChainService:    coldChainService,
+		HashChainService:    hashChainService,
+	}
+}
+
+// GetChain 获取单条销售记录的完整溯源链
+func (s *TraceabilityService) GetChain(saleInfoID int) *dto.Result {
+	chain, err := s.BuildChain(saleInfoID)
+	if err != nil {
+		log.Println("构建溯源链失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	if chain == nil {
+		return errorResult(404, "销售信息不存在")
+	}
+
+	return successResult("查询成功", chain)
+}
+
+// BuildChain 沿 销售 -> 物流 -> 生产 -> 产品/生产地 构建溯源链
+// 销售记录不存在时返回nil；中间环节缺失时记录在Missing中，不中断构建
+func (s *TraceabilityService) BuildChain(saleInfoID int) (*model.TraceabilityChain, error) {
+	saleInfo, err := s.SaleInfoRepo.GetByID(saleInfoID)
+	if err != nil {
+		return nil, err
+	}
+	if saleInfo == nil {
+		return nil, nil
+	}
+
+	chain := &model.TraceabilityChain{
+		Transport: []*model.ChainTransport{},
+		Missing:   []*model.ChainMissing{},
+		Recalls:   []*model.Recall{},
+	}
+
+	// 销售环节
+	saleInfo.Proof, err = s.HashChainService.Proof(model.HashChainSaleInfo, saleInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+	chain.Sale = &model.ChainSale{Info: saleInfo}
+	salePlace, err := s.SalePlaceRepo.GetByID(saleInfo.SalePlaceID)
+	if err != nil {
+		return nil, err
+	}
+	if salePlace == nil {
+		chain.AddMissing(model.ChainLinkSalePlace, saleInfo.SalePlaceID, "销售地不存在")
+	}
+	chain.Sale.SalePlace = salePlace
+
+	// 物流环节
+	saleLeg, err := s.LogisticsRepo.GetByID(saleInfo.LogisticsID)
+	if err != nil {
+		return nil, err
+	}
+	if saleLeg == nil {
+		chain.AddMissing(model.ChainLinkLogistics, saleInfo.LogisticsID, "物流信息不存在")
+		return chain, nil
+	}
+
+	legs, err := s.salePath(saleLeg)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillBatch(chain, saleLeg.ProductInfoID, legs, saleLeg); err != nil {
+		return nil, err
+	}
+
+	return chain, nil
+}
+
+// salePath 从销售关联的物流沿上一运输段回溯，按运输顺序返回销售自身的物流路径
+// 同一批次发往其他买家的物流不在路径上，不会出现在溯源链和证书中
+func (s *TraceabilityService) salePath(saleLeg *model.Logistics) ([]*model.Logistics, error) {
+	legs := []*model.Logistics{saleLeg}
+	visited := map[int]bool{saleLeg.ID: true}
+	for leg := saleLeg; leg.PrevID != nil && !visited[*leg.PrevID]; {
+		visited[*leg.PrevID] = true
+		prev, err := s.LogisticsRepo.GetByID(*leg.PrevID)
+		if err != nil {
+			return nil, err
+		}
+		if prev == nil || prev.ProductInfoID != saleLeg.ProductInfoID {
+			break
+		}
+		legs = append(legs, prev)
+		leg = prev
+	}
+	slices.Reverse(legs)
+	return legs, nil
+}
+
+// BuildBatchChain 构建生产批次的溯源链(不含销售环节)
+// 生产信息不存在时返回nil
+func (s *TraceabilityService) BuildBatchChain(productInfoID int) (*model.TraceabilityChain, error) {
+	production, err := s.ProductionRepo.GetByID(productInfoID)
+	if err != nil {
+		return nil, err
+	}
+	if production == nil {
+		return nil, nil
+	}
+
+	chain := &model.TraceabilityChain{
+		Transport: []*model.ChainTransport{},
+		Missing:   []*model.ChainMissing{},
+		Recalls:   []*model.Recall{},
+	}
+	legs, err := s.LogisticsRepo.FindByProductInfoID(productInfoID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillBatch(chain, productInfoID, legs, nil); err != nil {
+		return nil, err
+	}
+
+	return chain, nil
+}
+
+// fillBatch 填充生产批次的运输与生产环节，legs为要展示的运输段，saleLeg为销售记录直接关联的物流(可为nil)
+func (s *TraceabilityService) fillBatch(chain *model.TraceabilityChain, productInfoID int, legs []*model.Logistics, saleLeg *model.Logistics) error {
+	var err error
+	for _, leg := range legs {
+		leg.ColdChain, err = s.ColdChainService.Summary(leg)
+		if err != nil {
+			return err
+		}
+		leg.Proof, err = s.HashChainService.Proof(model.HashChainLogistics, leg.ID)
+		if err != nil {
+			return err
+		}
+
+		company, err := s.CompanyRepo.GetByID(leg.CompanyID)
+		if err != nil {
+			return err
+		}
+		if company == nil {
+			chain.AddMissing(model.ChainLinkCompany, leg.CompanyID, "物流公司不存在")
+		}
+		chain.Transport = append(chain.Transport, &model.ChainTransport{
+			Logistics: leg,
+			Company:   company,
+			SaleLeg:   saleLeg != nil && leg.ID == saleLeg.ID,
+		})
+	}
+
+	// 生产环节
+	production, err := s.ProductionRepo.GetByID(productInfoID)
+	if err != nil {
+		return err
+	}
+	if production == nil {
+		chain.AddMissing(model.ChainLinkProductionInfo, productInfoID, "生产信息不存在")
+		return nil
+	}
+	production.Proof, err = s.HashChainService.Proof(model.HashChainProductInfo, productInfoID)
+	if err != nil {
+		return err
+	}
+	chain.Production = &model.ChainProduction{Info: production}
+
+	product, err := s.ProductRepo.GetByID(production.ProductID)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		chain.AddMissing(model.ChainLinkProduct, production.ProductID, "产品不存在")
+	}
+	chain.Production.Product = product
+
+	place, err := s.ProductionPlaceRepo.GetByID(production.ProductPlaceID)
+	if err != nil {
+		return err
+	}
+	if place == nil {
+		chain.AddMissing(model.ChainLinkProductionPlace, production.ProductPlaceID, "生产地不存在")
+	}
+	chain.Production.ProductionPlace = place
+
+	// 拆分/合并产生的批次追溯到最初的来源批次
+	chain.Production.Ancestors, err = batchAncestors(s.BatchRepo, s.ProductionRepo, productInfoID, map[int]bool{productInfoID: true})
+	if err != nil {
+		return err
+	}
+
+	// 农事活动记录在最初的批次上，拆分/合并产生的批次一并展示来源批次的记录
+	chain.Production.Activities = []*model.FarmingActivity{}
+	for _, id := range append([]int{productInfoID}, batchNodeIDs(chain.Production.Ancestors)...) {
+		activities, err := s.FarmingActivityRepo.FindByProductInfoID(id)
+		if err != nil {
+			return err
+		}
+		chain.Production.Activities = append(chain.Production.Activities, activities...)
+	}
+	sort.SliceStable(chain.Production.Activities, func(i, j int) bool {
+		return chain.Production.Activities[i].ActivityDate.Before(chain.Production.Activities[j].ActivityDate)
+	})
+
+	if err := s.fillInspections(chain, productInfoID); err != nil {
+		return err
+	}
+
+	// 本批次、来源批次或所属产品处于召回中时标记为召回
+	ids := append([]int{productInfoID}, batchNodeIDs(chain.Production.Ancestors)...)
+	chain.Recalls, err = s.RecallRepo.FindOpen(ids, production.ProductID)
+	return err
+}
+
+// fillInspections 填充检测记录：产地检测随批次展示(含来源批次)，运输环节抽检随对应的运输环节展示
+func (s *TraceabilityService) fillInspections(chain *model.TraceabilityChain, productInfoID int) error {
+	legs := make(map[int]*model.ChainTransport, len(chain.Transport))
+	for _, leg := range chain.Transport {
+		leg.Inspections = []*model.Inspection{}
+		legs[leg.Logistics.ID] = leg
+	}
+
+	chain.Production.Inspections = []*model.Inspection{}
+	for _, id := range append([]int{productInfoID}, batchNodeIDs(chain.Production.Ancestors)...) {
+		inspections, err := s.InspectionRepo.FindByProductInfoID(id)
+		if err != nil {
+			return err
+		}
+		for _, inspection := range inspections {
+			if inspection.LogisticsID == 0 {
+				chain.Production.Inspections = append(chain.Production.Inspections, inspection)
+			} else if leg, ok := legs[inspection.LogisticsID]; ok {
+				leg.Inspections = append(leg.Inspections, inspection)
+			}
+		}
+	}
+	sort.SliceStable(chain.Production.Inspections, func(i, j int) bool {
+		return chain.Production.Inspections[i].InspectionDate.Before(chain.Production.Inspections[j].InspectionDate)
+	})
+	return nil
+}
+
+// ToPublicTrace 将溯源链转换为不含内部ID的公开溯源信息
+func ToPublicTrace(code string, chain *model.TraceabilityChain) *model.PublicTrace {
+	trace := &model.PublicTrace{
+		Code:      code,
+		Recalls:   []*model.PublicRecall{},
+		Transport: []*model.PublicTransport{},
+		Missing:   []string{},
+	}
+
+	for _, recall := range chain.Recalls {
+		trace.Recalled = true
+		trace.Recalls = append(trace.Recalls, &model.PublicRecall{
+			BatchNo:  recall.BatchNo,
+			Reason:   recall.Reason,
+			OpenTime: recall.OpenTime,
+		})
+	}
+
+	if chain.Production != nil {
+		if p := chain.Production.Product; p != nil {
+			trace.Product = &model.PublicProduct{
+				Name:        p.Name,
+				Type:        p.Type,
+				Image:       p.Image,
+				Description: p.Description,
+			}
+		}
+		info := chain.Production.Info
+		trace.Production = &model.PublicProduction{
+			BatchNo:      info.BatchNo,
+			Quantity:     info.Quantity,
+			Unit:         info.Unit,
+			SeedSource:   info.SeedSource,
+			Description:  info.Description,
+			PlantingDate: info.PlantingDate,
+			HarvestDate:  info.HarvestDate,
+			Proof:        info.Proof,
+		}
+		if place := chain.Production.ProductionPlace; place != nil {
+			trace.Production.Address = place.Address
+			trace.Production.Administrator = place.Administrator
+			trace.Production.Phone = place.Phone
+		}
+		trace.Production.Sources = toPublicBatchNodes(chain.Production.Ancestors)
+		trace.Production.Activities = []*model.PublicFarmingActivity{}
+		for _, activity := range chain.Production.Activities {
+			trace.Production.Activities = append(trace.Production.Activities, &model.PublicFarmingActivity{
+				ActivityType:    activity.ActivityType,
+				Material:        activity.Material,
+				Dosage:          activity.Dosage,
+				DosageUnit:      activity.DosageUnit,
+				Operator:        activity.Operator,
+				ActivityDate:    activity.ActivityDate,
+				WithholdingDays: activity.WithholdingDays,
+			})
+		}
+		trace.Production.Inspections = toPublicInspections(chain.Production.Inspections)
+	}
+
+	for _, leg := range chain.Transport {
+		transport := &model.PublicTransport{
+			StartLocation: leg.Logistics.StartLocation,
+			Destination:   leg.Logistics.Destination,
+			StartTime:     leg.Logistics.StartTime,
+			EndTime:       leg.Logistics.EndTime,
+			Status:        leg.Logistics.Status,
+			ColdChain:     leg.Logistics.ColdChain,
+			Events:        []*model.PublicTransportEvent{},
+			Inspections:   toPublicInspections(leg.Inspections),
+			Proof:         leg.Logistics.Proof,
+		}
+		for _, event := range leg.Logistics.Events {
+			transport.Events = append(transport.Events, &model.PublicTransportEvent{
+				EventType:   event.EventType,
+				Location:    event.Location,
+				CompanyName: event.CompanyName,
+				EventTime:   event.EventTime,
+				Remark:      event.Remark,
+			})
+		}
+		if leg.Company != nil {
+			transport.CompanyName = leg.Company.Name
+			transport.Administrator = leg.Company.Administrator
+			transport.Phone = leg.Company.Phone
+		}
+		trace.Transport = append(trace.Transport, transport)
+	}
+
+	if chain.Sale != nil {
+		trace.Sale = &model.PublicSale{
+			Description: chain.Sale.Info.Description,
+			SaleTime:    chain.Sale.Info.SaleTime,
+			Proof:       chain.Sale.Info.Proof,
+		}
+		if sp := chain.Sale.SalePlace; sp != nil {
+			trace.Sale.Address = sp.Address
+			trace.Sale.Administrator = sp.Administrator
+			trace.Sale.Phone = sp.Phone
+		}
+	}
+
+	for _, missing := range chain.Missing {
+		trace.Missing = append(trace.Missing, missing.Link)
+	}
+
+	return trace
+}
+
+// toPublicInspections 将检测记录转换为不含内部ID的公开信息
+func toPublicInspections(inspections []*model.Inspection) []*model.PublicInspection {
+	public := []*model.PublicInspection{}
+	for _, inspection := range inspections {
+		publicInspection := &model.PublicInspection{
+			InspectionType: inspection.InspectionType,
+			LabName:        inspection.LabName,
+			ReportNo:       inspection.ReportNo,
+			InspectionDate: inspection.InspectionDate,
+			Verdict:        inspection.Verdict,
+			ReportURL:      inspection.ReportURL,
+			Items:          []*model.PublicInspectionItem{},
+		}
+		for _, item := range inspection.Items {
+			publicInspection.Items = append(publicInspection.Items, &model.PublicInspectionItem{
+				ItemName:   item.ItemName,
+				Value:      item.Value,
+				ResultText: item.ResultText,
+				Unit:       item.Unit,
+				LimitMin:   item.LimitMin,
+				LimitMax:   item.LimitMax,
+				Passed:     item.Passed,
+			})
+		}
+		public = append(public, publicInspection)
+	}
+	return public
+}
+
+// toPublicBatchNodes 将来源批次转换为不含内部ID的公开信息
+func toPublicBatchNodes(nodes []*model.BatchNode) []*model.PublicBatchNode {
+	public := []*model.PublicBatchNode{}
+	for _, node := range nodes {
+		publicNode := &model.PublicBatchNode{
+			BatchNo:     node.BatchNo,
+			ProductName: node.ProductName,
+			Address:     node.ProductionPlace,
+			Kind:        node.Kind,
+			Quantity:    node.LinkQuantity,
+			Unit:        node.Unit,
+		}
+		if len(node.Parents) > 0 {
+			publicNode.Parents = toPublicBatchNodes(node.Parents)
+		}
+		public = append(public, publicNode)
+	}
+	return public
+}
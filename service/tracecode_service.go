@@ -0,0 +1,292 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+	"agricultural_product_gin/utils"
+)
+
+// 二维码尺寸范围
+const (
+	defaultQRCodeSize = 256
+	maxQRCodeSize     = 1024
+)
+
+var (
+	ErrTraceCodeNotFound  = errors.New("溯源码不存在")
+	ErrTraceCodeRevoked   = errors.New("溯源码已作废")
+	ErrTraceCodeForbidden = errors.New("无权操作该记录的溯源码")
+)
+
+// TraceCodeService 溯源码服务
+type TraceCodeService struct {
+	TraceCodeRepo  repository.TraceCodeRepository
+	SaleInfoRepo   repository.SaleInfoRepository
+	ProductionRepo repository.ProductionRepository
+	TraceService   *TraceabilityService
+	uow            repository.UnitOfWork
+
+	// 公开溯源地址前缀
+	traceURLPrefix string
+}
+
+// NewTraceCodeService 创建溯源码服务，traceURLPrefix为公开溯源地址前缀
+func NewTraceCodeService(
+	traceCodeRepo repository.TraceCodeRepository,
+	saleInfoRepo repository.SaleInfoRepository,
+	productionRepo repository.ProductionRepository,
+	traceService *TraceabilityService,
+	uow repository.UnitOfWork,
+	traceURLPrefix string,
+) *TraceCodeService {
+	return &TraceCodeService{
+		TraceCodeRepo:  traceCodeRepo,
+		SaleInfoRepo:   saleInfoRepo,
+		ProductionRepo: productionRepo,
+		TraceService:   traceService,
+		uow:            uow,
+		traceURLPrefix: traceURLPrefix,
+	}
+}
+
+// Generate 为销售信息或生产批次生成溯源码，已存在有效溯源码时直接返回
+func (s *TraceCodeService) Generate(scope model.DataScope, codeDTO *dto.TraceCodeDTO) *dto.Result {
+	if (codeDTO.SaleInfoID > 0) == (codeDTO.ProductInfoID > 0) {
+		return errorResult(400, "销售信息ID与生产批次ID必须且只能填写一个")
+	}
+	if result := s.checkTarget(scope, codeDTO.SaleInfoID, codeDTO.ProductInfoID); result != nil {
+		return result
+	}
+
+	code, err := utils.GenerateTraceCode()
+	if err != nil {
+		log.Println("生成溯源码失败:", err)
+		return errorResult(500, "生成溯源码失败")
+	}
+
+	// 查询与保存在同一事务中，并发生成时只保留一个有效溯源码
+	var traceCode *model.TraceCode
+	created := false
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		existing, err := repos.TraceCode.FindActive(codeDTO.SaleInfoID, codeDTO.ProductInfoID)
+		if err != nil || existing != nil {
+			traceCode = existing
+			return err
+		}
+
+		traceCode = &model.TraceCode{
+			Code:          code,
+			SaleInfoID:    codeDTO.SaleInfoID,
+			ProductInfoID: codeDTO.ProductInfoID,
+			CreateTime:    time.Now(),
+		}
+		if traceCode.ID, err = repos.TraceCode.Save(traceCode); err != nil {
+			return err
+		}
+		created = true
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityTraceCode, traceCode.ID, model.AuditActionCreate, nil, traceCode)
+	})
+	if err != nil {
+		log.Println("生成溯源码失败:", err)
+		return errorResult(500, "生成溯源码失败")
+	}
+
+	traceCode.URL = s.traceURLPrefix + traceCode.Code
+	if !created {
+		return successResult("溯源码已存在", traceCode)
+	}
+	return successResult("生成成功", traceCode)
+}
+
+// Revoke 作废溯源码
+func (s *TraceCodeService) Revoke(scope model.DataScope, id int, revokeDTO *dto.TraceCodeRevokeDTO) *dto.Result {
+	traceCode, err := s.TraceCodeRepo.GetByID(id)
+	if err != nil {
+		log.Println("查询溯源码失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if traceCode == nil {
+		return errorResult(404, ErrTraceCodeNotFound.Error())
+	}
+	if result := s.checkTarget(scope, traceCode.SaleInfoID, traceCode.ProductInfoID); result != nil {
+		return result
+	}
+	if traceCode.Revoked {
+		return errorResult(400, ErrTraceCodeRevoked.Error())
+	}
+
+	now := time.Now()
+	revoked := *traceCode
+	revoked.Revoked, revoked.RevokeTime, revoked.RevokeReason = true, &now, revokeDTO.Reason
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.TraceCode.Revoke(id, revokeDTO.Reason, now); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, scope.Actor, model.AuditEntityTraceCode, id, model.AuditActionUpdate, traceCode, &revoked)
+	})
+	if err != nil {
+		log.Println("作废溯源码失败:", err)
+		return errorResult(500, "作废失败")
+	}
+
+	return successResult("作废成功", nil)
+}
+
+// checkTarget 检查溯源码关联的销售信息或生产批次存在且在数据权限范围内，通过时返回nil
+// 销售信息按销售地、生产批次按生产地判断；农场只能操作生产批次的溯源码，零售商只能操作销售信息的溯源码
+func (s *TraceCodeService) checkTarget(scope model.DataScope, saleInfoID, productInfoID int) *dto.Result {
+	if saleInfoID > 0 {
+		saleInfo, err := s.SaleInfoRepo.GetByID(saleInfoID)
+		if err != nil {
+			log.Println("查询销售信息失败:", err)
+			return errorResult(500, "系统错误")
+		}
+		if saleInfo == nil {
+			return errorResult(404, "销售信息不存在")
+		}
+		if scope.ProductPlaceID != nil || !scope.AllowSalePlace(saleInfo.SalePlaceID) {
+			return errorResult(403, ErrTraceCodeForbidden.Error())
+		}
+		return nil
+	}
+
+	production, err := s.ProductionRepo.GetByID(productInfoID)
+	if err != nil {
+		log.Println("查询生产信息失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if production == nil {
+		return errorResult(404, "生产信息不存在")
+	}
+	if scope.SalePlaceID != nil || !scope.AllowProductPlace(production.ProductPlaceID) {
+		return errorResult(403, ErrTraceCodeForbidden.Error())
+	}
+	return nil
+}
+
+// GetByID 根据ID获取溯源码
+func (s *TraceCodeService) GetByID(id int) *dto.Result {
+	traceCode, err := s.TraceCodeRepo.GetByID(id)
+	if err != nil {
+		log.Println("获取溯源码失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if traceCode == nil {
+		return errorResult(404, ErrTraceCodeNotFound.Error())
+	}
+
+	traceCode.URL = s.traceURLPrefix + traceCode.Code
+	return successResult("查询成功", traceCode)
+}
+
+// PageQuery 分页查询溯源码
+func (s *TraceCodeService) PageQuery(queryDTO *dto.TraceCodePageQueryDTO) *dto.Result {
+	// 参数校验
+	if queryDTO.Page <= 0 {
+		queryDTO.Page = 1
+	}
+	if queryDTO.Size <= 0 {
+		queryDTO.Size = 10
+	}
+
+	traceCodes, total, err := s.TraceCodeRepo.PageQuery(&model.TraceCodePageQuery{
+		Page:          queryDTO.Page,
+		Size:          queryDTO.Size,
+		Code:          queryDTO.Code,
+		SaleInfoID:    queryDTO.SaleInfoID,
+		ProductInfoID: queryDTO.ProductInfoID,
+		Revoked:       queryDTO.Revoked,
+	})
+	if err != nil {
+		log.Println("分页查询溯源码失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	for _, traceCode := range traceCodes {
+		traceCode.URL = s.traceURLPrefix + traceCode.Code
+	}
+
+	pageResult := dto.NewPageResult(total, traceCodes, queryDTO.Page, queryDTO.Size)
+	return successResult("查询成功", pageResult)
+}
+
+// Resolve 解析公开溯源码，返回不含内部ID的溯源信息
+func (s *TraceCodeService) Resolve(code string) *dto.Result {
+	traceCode, err := s.getActive(code)
+	if errors.Is(err, ErrTraceCodeNotFound) {
+		return errorResult(404, err.Error())
+	}
+	if errors.Is(err, ErrTraceCodeRevoked) {
+		return errorResult(410, err.Error())
+	}
+	if err != nil {
+		return errorResult(500, "系统错误")
+	}
+
+	var chain *model.TraceabilityChain
+	if traceCode.SaleInfoID > 0 {
+		chain, err = s.TraceService.BuildChain(traceCode.SaleInfoID)
+	} else {
+		chain, err = s.TraceService.BuildBatchChain(traceCode.ProductInfoID)
+	}
+	if err != nil {
+		log.Println("构建溯源链失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if chain == nil {
+		return errorResult(404, "溯源信息不存在")
+	}
+
+	return successResult("查询成功", ToPublicTrace(traceCode.Code, chain))
+}
+
+// QRCode 生成指向公开溯源地址的二维码，format为png或svg
+func (s *TraceCodeService) QRCode(code, format string, size int) ([]byte, string, error) {
+	traceCode, err := s.getActive(code)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if size <= 0 {
+		size = defaultQRCodeSize
+	}
+	if size > maxQRCodeSize {
+		size = maxQRCodeSize
+	}
+
+	content := s.traceURLPrefix + traceCode.Code
+	if format == "svg" {
+		svg, err := utils.QRCodeSVG(content, size)
+		if err != nil {
+			return nil, "", err
+		}
+		return []byte(svg), "image/svg+xml", nil
+	}
+
+	png, err := utils.QRCodePNG(content, size)
+	if err != nil {
+		return nil, "", err
+	}
+	return png, "image/png", nil
+}
+
+// getActive 获取有效的溯源码
+func (s *TraceCodeService) getActive(code string) (*model.TraceCode, error) {
+	traceCode, err := s.TraceCodeRepo.GetByCode(code)
+	if err != nil {
+		log.Println("查询溯源码失败:", err)
+		return nil, err
+	}
+	if traceCode == nil {
+		return nil, ErrTraceCodeNotFound
+	}
+	if traceCode.Revoked {
+		return nil, ErrTraceCodeRevoked
+	}
+	return traceCode, nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"testing"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository/repotest"
+)
+
+const traceURLPrefix = "https://trace.example.com/t/"
+
+// newTraceCodeRepos 销售信息1、生产批次1；销售信息1已有溯源码ABC(作废的OLD不计)
+func newTraceCodeRepos() *testRepos {
+	repos := newTestRepos()
+	repos.SaleInfo = repotest.NewSaleInfoRepository(&model.SaleInfoVO{ID: 1, LogisticsID: 1, SalePlaceID: 5, SaleTime: saleTime})
+	repos.Production = repotest.NewProductionRepository(&model.ProductionInfoWithDetails{ProductionInfo: model.ProductionInfo{
+		ID: 1, ProductID: 1, ProductPlaceID: 2,
+	}})
+	repos.TraceCode = repotest.NewTraceCodeRepository(
+		&model.TraceCode{ID: 1, Code: "OLD", SaleInfoID: 1, Revoked: true},
+		&model.TraceCode{ID: 2, Code: "ABC", SaleInfoID: 1},
+	)
+	return repos
+}
+
+func newTraceCodeService(repos *testRepos) *TraceCodeService {
+	return NewTraceCodeService(repos.TraceCode, repos.SaleInfo, repos.Production, nil, repos.uow(), traceURLPrefix)
+}
+
+func TestTraceCodeService_Generate(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    model.DataScope
+		code     dto.TraceCodeDTO
+		auditErr error
+		wantCode int
+		wantMsg  string
+		wantID   int
+	}{
+		{name: "生产批次生成溯源码", scope: adminScope, code: dto.TraceCodeDTO{ProductInfoID: 1}, wantCode: 200, wantMsg: "生成成功", wantID: 3},
+		{name: "农场用户为本生产地的批次生成", scope: productPlaceScope(2), code: dto.TraceCodeDTO{ProductInfoID: 1}, wantCode: 200, wantMsg: "生成成功", wantID: 3},
+		{name: "已存在有效溯源码", scope: adminScope, code: dto.TraceCodeDTO{SaleInfoID: 1}, wantCode: 200, wantMsg: "溯源码已存在", wantID: 2},
+		{name: "零售商查询本销售地的溯源码", scope: salePlaceScope(5), code: dto.TraceCodeDTO{SaleInfoID: 1}, wantCode: 200, wantMsg: "溯源码已存在", wantID: 2},
+		{name: "同时填写", scope: adminScope, code: dto.TraceCodeDTO{SaleInfoID: 1, ProductInfoID: 1}, wantCode: 400},
+		{name: "都未填写", scope: adminScope, wantCode: 400},
+		{name: "销售信息不存在", scope: adminScope, code: dto.TraceCodeDTO{SaleInfoID: 99}, wantCode: 404, wantMsg: "销售信息不存在"},
+		{name: "生产信息不存在", scope: adminScope, code: dto.TraceCodeDTO{ProductInfoID: 99}, wantCode: 404, wantMsg: "生产信息不存在"},
+		{
+			name: "其他生产地的批次", scope: productPlaceScope(4), code: dto.TraceCodeDTO{ProductInfoID: 1},
+			wantCode: 403, wantMsg: ErrTraceCodeForbidden.Error(),
+		},
+		{
+			name: "其他销售地的销售信息", scope: salePlaceScope(6), code: dto.TraceCodeDTO{SaleInfoID: 1},
+			wantCode: 403, wantMsg: ErrTraceCodeForbidden.Error(),
+		},
+		{
+			name: "农场用户为销售信息生成", scope: productPlaceScope(2), code: dto.TraceCodeDTO{SaleInfoID: 1},
+			wantCode: 403, wantMsg: ErrTraceCodeForbidden.Error(),
+		},
+		{
+			name: "零售商为生产批次生成", scope: salePlaceScope(5), code: dto.TraceCodeDTO{ProductInfoID: 1},
+			wantCode: 403, wantMsg: ErrTraceCodeForbidden.Error(),
+		},
+		{
+			name: "审计日志写入失败时回滚", scope: adminScope, code: dto.TraceCodeDTO{ProductInfoID: 1}, auditErr: errFake,
+			wantCode: 500, wantMsg: "生成溯源码失败",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTraceCodeRepos()
+			repos.Audit.Err = tt.auditErr
+			s := newTraceCodeService(repos)
+
+			result := s.Generate(tt.scope, &tt.code)
+			assertResult(t, result, tt.wantCode, tt.wantMsg)
+			if tt.wantCode != 200 {
+				if len(repos.TraceCode.TraceCodes) != 2 {
+					t.Fatalf("失败后仍生成了溯源码: %d个", len(repos.TraceCode.TraceCodes))
+				}
+				return
+			}
+
+			traceCode := result.Data.(*model.TraceCode)
+			if traceCode.ID != tt.wantID || traceCode.URL != traceURLPrefix+traceCode.Code {
+				t.Fatalf("溯源码 = %+v", traceCode)
+			}
+			if tt.wantID == 2 {
+				assertAudits(t, repos)
+				return
+			}
+			if saved := repos.TraceCode.TraceCodes[traceCode.ID]; saved == nil || saved.Code == "" {
+				t.Fatalf("保存的溯源码 = %+v", saved)
+			}
+			assertAudits(t, repos, auditKey(model.AuditEntityTraceCode, traceCode.ID, model.AuditActionCreate))
+		})
+	}
+}
+
+func TestTraceCodeService_Revoke(t *testing.T) {
+	repos := newTraceCodeRepos()
+	s := newTraceCodeService(repos)
+
+	assertResult(t, s.Revoke(adminScope, 99, &dto.TraceCodeRevokeDTO{}), 404, ErrTraceCodeNotFound.Error())
+	assertResult(t, s.Revoke(adminScope, 1, &dto.TraceCodeRevokeDTO{}), 400, ErrTraceCodeRevoked.Error())
+	assertResult(t, s.Revoke(salePlaceScope(6), 2, &dto.TraceCodeRevokeDTO{}), 403, ErrTraceCodeForbidden.Error())
+	assertResult(t, s.Revoke(productPlaceScope(2), 2, &dto.TraceCodeRevokeDTO{}), 403, ErrTraceCodeForbidden.Error())
+
+	// 审计日志写入失败时不作废
+	repos.Audit.Err = errFake
+	assertResult(t, s.Revoke(adminScope, 2, &dto.TraceCodeRevokeDTO{Reason: "标签印错"}), 500, "作废失败")
+	if repos.TraceCode.TraceCodes[2].Revoked {
+		t.Fatal("审计日志写入失败后溯源码仍被作废")
+	}
+	repos.Audit.Err = nil
+
+	assertResult(t, s.Revoke(salePlaceScope(5), 2, &dto.TraceCodeRevokeDTO{Reason: "标签印错"}), 200, "作废成功")
+	if revoked := repos.TraceCode.TraceCodes[2]; !revoked.Revoked || revoked.RevokeReason != "标签印错" || revoked.RevokeTime == nil {
+		t.Fatalf("作废后 = %+v", revoked)
+	}
+	assertAudits(t, repos, auditKey(model.AuditEntityTraceCode, 2, model.AuditActionUpdate))
+
+	// 作废后公开访问返回410，再次生成时签发新的溯源码
+	assertResult(t, s.Resolve("ABC"), 410, ErrTraceCodeRevoked.Error())
+	assertResult(t, s.Resolve("NONE"), 404, ErrTraceCodeNotFound.Error())
+	if _, _, err := s.QRCode("ABC", "png", 0); err != ErrTraceCodeRevoked {
+		t.Fatalf("QRCode err = %v", err)
+	}
+	result := s.Generate(adminScope, &dto.TraceCodeDTO{SaleInfoID: 1})
+	assertResult(t, result, 200, "生成成功")
+	if code := result.Data.(*model.TraceCode).Code; code == "ABC" || code == "OLD" {
+		t.Fatalf("作废后重新生成的溯源码 = %q", code)
+	}
+
+	revoked := true
+	page := s.PageQuery(&dto.TraceCodePageQueryDTO{SaleInfoID: 1, Revoked: &revoked}).Data.(*dto.PageResult)
+	if page.Total != 2 || page.Page != 1 || page.PageSize != 10 {
+		t.Fatalf("PageQuery = %+v", page)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
 
 	"agricultural_product_gin/dto"
@@ -18,16 +19,28 @@ const (
 	PasswordInvalid     = "密码错误"
 	PasswordEditInvalid = "密码参数不完整"
 	PasswordError       = "两次输入的密码不一致"
+	RoleInvalid         = "无效的角色"
+	NotLoggedIn         = "未登录"
+	PasswordUnchanged   = "新密码不能与旧密码相同"
+	LastAdmin           = "不能移除最后一个管理员的管理员角色"
 )
 
+// ErrLastAdmin 修改角色后系统中将没有管理员
+var ErrLastAdmin = errors.New(LastAdmin)
+
+// cliActor 命令行操作在审计日志中的操作人
+var cliActor = model.Actor{Username: "cli"}
+
 // UserService 用户服务
 type UserService struct {
-	UserRepo *repository.UserRepository
+	UserRepo     repository.UserRepository
+	TokenService *TokenService
+	uow          repository.UnitOfWork
 }
 
 // NewUserService 创建用户服务
-func NewUserService(userRepo *repository.UserRepository) *UserService {
-	return &UserService{UserRepo: userRepo}
+func NewUserService(userRepo repository.UserRepository, tokenService *TokenService, uow repository.UnitOfWork) *UserService {
+	return &UserService{UserRepo: userRepo, TokenService: tokenService, uow: uow}
 }
 
 // Register 用户注册
@@ -47,11 +60,34 @@ func (s *UserService) Register(dto *dto.UserRegAndLoginDTO) *dto.Result {
 		return errorResult(400, UsernameError)
 	}
 
+	// 校验密码强度
+	if err := utils.ValidatePasswordStrength(password, username); err != nil {
+		return errorResult(400, err.Error())
+	}
+
 	// 加密密码
-	encryptedPassword := utils.EncryptPassword(password)
+	encryptedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		log.Println("密码加密失败:", err)
+		return errorResult(500, "注册失败")
+	}
 
-	// 保存用户
-	err = s.UserRepo.Save(username, encryptedPassword)
+	// 保存用户，注册的用户没有角色，需由管理员分配(管理员通过user promote子命令指定)
+	// 注册时尚未登录，审计日志的操作人记为注册的用户本人
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.User.Save(username, encryptedPassword, ""); err != nil {
+			return err
+		}
+		user, err := repos.User.FindByUsername(username)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.New("注册的用户不存在")
+		}
+		actor := model.Actor{UserID: user.ID, Username: user.Username}
+		return recordAudit(repos.Audit, actor, model.AuditEntityUser, user.ID, model.AuditActionCreate, nil, auditUser(user))
+	})
 	if err != nil {
 		log.Println("保存用户失败:", err)
 		return errorResult(500, "注册失败")
@@ -77,51 +113,98 @@ func (s *UserService) Login(dto *dto.UserRegAndLoginDTO) *dto.Result {
 	}
 
 	// 验证密码
-	encryptedPassword := utils.EncryptPassword(password)
-	if encryptedPassword != user.Password {
+	ok, needsRehash, err := utils.VerifyPassword(password, user.Password)
+	if err != nil {
+		log.Println("校验密码失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if !ok {
 		return errorResult(400, PasswordInvalid)
 	}
 
-	// 生成Token
-	token, err := utils.GenerateToken(user.ID, user.Username)
+	// 旧版MD5或参数过低的哈希在登录成功后升级，失败不影响本次登录
+	if needsRehash {
+		s.rehashPassword(user.ID, password)
+	}
+
+	// 签发访问令牌和刷新令牌
+	tokens, err := s.TokenService.Issue(user)
 	if err != nil {
 		log.Println("生成Token失败:", err)
 		return errorResult(500, "登录失败")
 	}
 
-	return successResult("登录成功", token)
+	return successResult("登录成功", tokens)
 }
 
-// Logout 退出登录
-func (s *UserService) Logout() *dto.Result {
-	// 清除ThreadLocal数据
-	threadLocal := utils.GetUserLocal()
-	threadLocal.Remove("userID")
-	threadLocal.Remove("username")
+// Refresh 使用刷新令牌换取新的令牌对
+func (s *UserService) Refresh(refreshDTO *dto.RefreshTokenDTO) *dto.Result {
+	tokens, err := s.TokenService.Refresh(refreshDTO.RefreshToken)
+	switch {
+	case errors.Is(err, ErrRefreshTokenInvalid), errors.Is(err, ErrRefreshTokenExpired), errors.Is(err, ErrRefreshTokenReused):
+		return errorResult(401, err.Error())
+	case err != nil:
+		log.Println("刷新令牌失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	return successResult("刷新成功", tokens)
+}
+
+// Logout 退出登录，吊销当前会话的令牌
+func (s *UserService) Logout(caller *model.Identity) *dto.Result {
+	if caller == nil {
+		return errorResult(401, NotLoggedIn)
+	}
+
+	if err := s.TokenService.Revoke(caller); err != nil {
+		log.Println("吊销令牌失败:", err)
+		return errorResult(500, "退出失败")
+	}
 
 	return successResult("退出成功", nil)
 }
 
-// GetUserInfo 获取用户信息
-func (s *UserService) GetUserInfo() (*model.User, error) {
-	threadLocal := utils.GetUserLocal()
-	userID, ok := threadLocal.Get("userID").(int)
-	if !ok {
-		return nil, errors.New("未登录")
+// LogoutAll 退出所有会话，当前用户已签发的令牌全部失效
+func (s *UserService) LogoutAll(caller *model.Identity) *dto.Result {
+	if caller == nil {
+		return errorResult(401, NotLoggedIn)
+	}
+
+	if err := s.TokenService.RevokeAll(caller.UserID); err != nil {
+		log.Println("吊销令牌失败:", err)
+		return errorResult(500, "退出失败")
+	}
+
+	return successResult("已退出所有会话", nil)
+}
+
+// GetUserInfo 获取当前用户信息
+func (s *UserService) GetUserInfo(caller *model.Identity) (*model.User, error) {
+	if caller == nil {
+		return nil, errors.New(NotLoggedIn)
 	}
 
-	user, err := s.UserRepo.GetByID(userID)
+	user, err := s.UserRepo.GetByID(caller.UserID)
 	if err != nil {
 		return nil, err
 	}
+	if user == nil {
+		return nil, errors.New(UsernameInvalid)
+	}
 
 	// 隐藏密码
 	user.Password = "******"
 	return user, nil
 }
 
-// Update 更新用户信息
-func (s *UserService) Update(userDTO *dto.UserDTO) *dto.Result {
+// Update 更新当前用户信息，只能修改调用者本人的账号
+func (s *UserService) Update(caller *model.Identity, userDTO *dto.UserDTO) *dto.Result {
+	if caller == nil {
+		return errorResult(401, NotLoggedIn)
+	}
+	userDTO.ID = caller.UserID
+
 	// 检查用户名是否已存在（但排除当前用户）
 	existingUser, err := s.UserRepo.FindByUsername(userDTO.Username)
 	if err != nil {
@@ -133,6 +216,15 @@ func (s *UserService) Update(userDTO *dto.UserDTO) *dto.Result {
 		return errorResult(400, UsernameError)
 	}
 
+	before, err := s.UserRepo.GetByID(userDTO.ID)
+	if err != nil {
+		log.Println("获取用户失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if before == nil {
+		return errorResult(404, UsernameInvalid)
+	}
+
 	// 更新用户信息
 	user := &model.User{
 		ID:       userDTO.ID,
@@ -142,7 +234,12 @@ func (s *UserService) Update(userDTO *dto.UserDTO) *dto.Result {
 		Phone:    sql.NullString{String: userDTO.Phone, Valid: userDTO.Phone != ""},
 	}
 
-	err = s.UserRepo.Update(user)
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.User.Update(user); err != nil {
+			return err
+		}
+		return recordUserUpdate(repos, caller.Actor(), before)
+	})
 	if err != nil {
 		log.Println("更新用户失败:", err)
 		return errorResult(500, "更新失败")
@@ -151,8 +248,8 @@ func (s *UserService) Update(userDTO *dto.UserDTO) *dto.Result {
 	return successResult("更新成功", nil)
 }
 
-// EditPassword 修改密码
-func (s *UserService) EditPassword(dto *dto.UserEditPasswordDTO) *dto.Result {
+// EditPassword 修改当前用户的密码
+func (s *UserService) EditPassword(caller *model.Identity, dto *dto.UserEditPasswordDTO) *dto.Result {
 	oldPassword := dto.OldPassword
 	newPassword := dto.NewPassword
 	confirmPassword := dto.ConfirmPassword
@@ -163,21 +260,26 @@ func (s *UserService) EditPassword(dto *dto.UserEditPasswordDTO) *dto.Result {
 	}
 
 	// 获取当前用户
-	threadLocal := utils.GetUserLocal()
-	username, ok := threadLocal.Get("username").(string)
-	if !ok {
-		return errorResult(401, "未登录")
+	if caller == nil {
+		return errorResult(401, NotLoggedIn)
 	}
 
-	user, err := s.UserRepo.FindByUsername(username)
+	user, err := s.UserRepo.GetByID(caller.UserID)
 	if err != nil {
 		log.Println("获取用户失败:", err)
 		return errorResult(500, "系统错误")
 	}
+	if user == nil {
+		return errorResult(404, UsernameInvalid)
+	}
 
 	// 验证旧密码
-	encryptedOldPassword := utils.EncryptPassword(oldPassword)
-	if encryptedOldPassword != user.Password {
+	ok, _, err := utils.VerifyPassword(oldPassword, user.Password)
+	if err != nil {
+		log.Println("校验密码失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if !ok {
 		return errorResult(400, PasswordInvalid)
 	}
 
@@ -186,13 +288,186 @@ func (s *UserService) EditPassword(dto *dto.UserEditPasswordDTO) *dto.Result {
 		return errorResult(400, PasswordError)
 	}
 
+	// 校验新密码强度
+	if newPassword == oldPassword {
+		return errorResult(400, PasswordUnchanged)
+	}
+	if err := utils.ValidatePasswordStrength(newPassword, user.Username); err != nil {
+		return errorResult(400, err.Error())
+	}
+
 	// 更新密码
-	encryptedNewPassword := utils.EncryptPassword(newPassword)
-	err = s.UserRepo.UpdatePassword(user.ID, encryptedNewPassword)
+	encryptedNewPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		log.Println("密码加密失败:", err)
+		return errorResult(500, "修改密码失败")
+	}
+	// 修改密码后所有会话需重新登录，密码与令牌吊销在同一事务中提交
+	// 审计日志不记录密码哈希，只以掩码标记密码已修改
+	after := auditUser(user)
+	after.Password = "******"
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.User.UpdatePassword(user.ID, encryptedNewPassword); err != nil {
+			return err
+		}
+		if err := revokeUserTokens(repos.Token, repos.User, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(repos.Audit, caller.Actor(), model.AuditEntityUser, user.ID, model.AuditActionUpdate, auditUser(user), after)
+	})
 	if err != nil {
-		log.Println("更新密码失败:", err)
+		log.Println("修改密码失败:", err)
 		return errorResult(500, "修改密码失败")
 	}
 
-	return successResult("修改密码成功", newPassword)
+	return successResult("修改密码成功", nil)
+}
+
+// rehashPassword 使用当前默认算法重新计算并保存密码哈希
+func (s *UserService) rehashPassword(userID int, password string) {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		log.Println("密码重新加密失败:", err)
+		return
+	}
+	if err := s.UserRepo.UpdatePassword(userID, hash); err != nil {
+		log.Println("升级密码哈希失败:", err)
+	}
+}
+
+// AssignRole 分配用户角色(管理员)
+func (s *UserService) AssignRole(caller *model.Identity, roleDTO *dto.UserRoleDTO) *dto.Result {
+	if !model.IsValidRole(roleDTO.Role) {
+		return errorResult(400, RoleInvalid)
+	}
+
+	user, err := s.UserRepo.GetByID(roleDTO.UserID)
+	if err != nil {
+		log.Println("获取用户失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if user == nil {
+		return errorResult(404, UsernameInvalid)
+	}
+
+	// 角色写在访问令牌中，变更后吊销该用户的所有会话，使新角色在重新登录后生效
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		if user.Role == model.RoleAdmin && roleDTO.Role != model.RoleAdmin {
+			admins, err := repos.User.CountByRole(model.RoleAdmin)
+			if err != nil {
+				return err
+			}
+			if admins <= 1 {
+				return ErrLastAdmin
+			}
+		}
+		if err := repos.User.UpdateRole(user.ID, roleDTO.Role); err != nil {
+			return err
+		}
+		if err := revokeUserTokens(repos.Token, repos.User, user.ID); err != nil {
+			return err
+		}
+		return recordUserUpdate(repos, caller.Actor(), user)
+	})
+	if errors.Is(err, ErrLastAdmin) {
+		return errorResult(409, LastAdmin)
+	}
+	if err != nil {
+		log.Println("更新用户角色失败:", err)
+		return errorResult(500, "分配角色失败")
+	}
+
+	return successResult("分配角色成功", nil)
+}
+
+// PromoteAdmin 将用户设为管理员(命令行user promote)，用于升级前已有用户、没有管理员的数据库
+func (s *UserService) PromoteAdmin(username string) error {
+	user, err := s.UserRepo.FindByUsername(username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("%s: %s", UsernameInvalid, username)
+	}
+
+	return s.uow.Do(func(repos *repository.Repositories) error {
+		if err := repos.User.UpdateRole(user.ID, model.RoleAdmin); err != nil {
+			return err
+		}
+		if err := revokeUserTokens(repos.Token, repos.User, user.ID); err != nil {
+			return err
+		}
+		return recordUserUpdate(repos, cliActor, user)
+	})
+}
+
+// ListUsers 查询所有用户(管理员)
+func (s *UserService) ListUsers() *dto.Result {
+	users, err := s.UserRepo.FindAll()
+	if err != nil {
+		log.Println("查询用户失败:", err)
+		return errorResult(500, "系统错误")
+	}
+
+	return successResult("查询成功", users)
+}
+
+// BindUser 绑定用户所属的公司、生产地、销售地(管理员)
+func (s *UserService) BindUser(caller *model.Identity, bindingDTO *dto.UserBindingDTO) *dto.Result {
+	user, err := s.UserRepo.GetByID(bindingDTO.UserID)
+	if err != nil {
+		log.Println("获取用户失败:", err)
+		return errorResult(500, "系统错误")
+	}
+	if user == nil {
+		return errorResult(404, UsernameInvalid)
+	}
+
+	// 绑定的公司、生产地、销售地须存在且未删除，保留原有绑定不再检查
+	// 绑定写在访问令牌中(数据权限范围)，变更后吊销该用户的所有会话
+	err = s.uow.Do(func(repos *repository.Repositories) error {
+		err := checkReferences(repos.Reference,
+			changedReference("companyId", model.AuditEntityCompany, bindingDTO.CompanyID, user.CompanyID),
+			changedReference("productPlaceId", model.AuditEntityProductionPlace, bindingDTO.ProductPlaceID, user.ProductPlaceID),
+			changedReference("salePlaceId", model.AuditEntitySalePlace, bindingDTO.SalePlaceID, user.SalePlaceID))
+		if err != nil {
+			return err
+		}
+		err = repos.User.UpdateBinding(user.ID, bindingDTO.CompanyID, bindingDTO.ProductPlaceID, bindingDTO.SalePlaceID)
+		if err != nil {
+			return err
+		}
+		if err := revokeUserTokens(repos.Token, repos.User, user.ID); err != nil {
+			return err
+		}
+		return recordUserUpdate(repos, caller.Actor(), user)
+	})
+	if result := referenceErrorResult(err); result != nil {
+		return result
+	}
+	if err != nil {
+		log.Println("更新用户绑定失败:", err)
+		return errorResult(500, "绑定失败")
+	}
+
+	return successResult("绑定成功", nil)
+}
+
+// recordUserUpdate 在事务中以修改前的账号和重新查询的当前账号记录审计日志
+func recordUserUpdate(repos *repository.Repositories, actor model.Actor, before *model.User) error {
+	after, err := repos.User.GetByID(before.ID)
+	if err != nil {
+		return err
+	}
+	return recordAudit(repos.Audit, actor, model.AuditEntityUser, before.ID, model.AuditActionUpdate, auditUser(before), auditUser(after))
+}
+
+// auditUser 用于审计日志的账号快照，不含密码哈希
+func auditUser(user *model.User) *model.User {
+	if user == nil {
+		return nil
+	}
+	snapshot := *user
+	snapshot.Password = ""
+	return &snapshot
 }
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"agricultural_product_gin/dto"
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository/repotest"
+	"agricultural_product_gin/utils"
+)
+
+// passwordHashes 已计算的密码哈希，避免每个用例重复计算Argon2id
+var passwordHashes = map[string]string{}
+
+// hashPassword 计算密码哈希
+func hashPassword(t *testing.T, password string) string {
+	t.Helper()
+	if hash, ok := passwordHashes[password]; ok {
+		return hash
+	}
+	hash, err := utils.HashPassword(password)
+	mustNoError(t, err)
+	passwordHashes[password] = hash
+	return hash
+}
+
+// newUserRepos 管理员admin(1)和绑定生产地2的农场用户farmer(2)；公司3已删除
+func newUserRepos(t *testing.T) *testRepos {
+	repos := newTestRepos()
+	repos.User = repotest.NewUserRepository(
+		&model.User{ID: 1, Username: "admin", Password: hashPassword(t, "admin123"), Role: model.RoleAdmin},
+		&model.User{ID: 2, Username: "farmer", Password: hashPassword(t, "farmer123"), Role: model.RoleFarmer, ProductPlaceID: 2},
+	)
+	repos.Token = repotest.NewTokenRepository(repos.User)
+	repos.exist(model.AuditEntityCompany, 1)
+	repos.exist(model.AuditEntityProductionPlace, 2)
+	repos.Reference.AddExisting(model.AuditEntityCompany, 3, true)
+	return repos
+}
+
+func newUserService(repos *testRepos) *UserService {
+	return NewUserService(repos.User, NewTokenService(repos.Token, repos.User), repos.uow())
+}
+
+// login 登录并返回令牌及访问令牌中的身份
+func login(t *testing.T, s *UserService, username, password string) (*dto.TokenDTO, *model.Identity) {
+	t.Helper()
+	result := s.Login(&dto.UserRegAndLoginDTO{Username: username, Password: password})
+	assertResult(t, result, 200, "登录成功")
+	tokens := result.Data.(*dto.TokenDTO)
+	claims, err := utils.ParseToken(tokens.Token)
+	mustNoError(t, err)
+	return tokens, claims.Identity()
+}
+
+func TestUserService_Register(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+		user     dto.UserRegAndLoginDTO
+		auditErr error
+		wantCode int
+		wantMsg  string
+	}{
+		{name: "首个用户不会成为管理员", user: dto.UserRegAndLoginDTO{Username: "alice", Password: "alice2024"}, wantCode: 200},
+		{name: "其余用户待分配角色", existing: true, user: dto.UserRegAndLoginDTO{Username: "alice", Password: "alice2024"}, wantCode: 200},
+		{name: "用户名已被占用", existing: true, user: dto.UserRegAndLoginDTO{Username: "farmer", Password: "farmer2024"}, wantCode: 400, wantMsg: UsernameError},
+		{name: "密码过短", user: dto.UserRegAndLoginDTO{Username: "alice", Password: "a1"}, wantCode: 400, wantMsg: utils.ErrPasswordTooShort.Error()},
+		{name: "密码只有字母", user: dto.UserRegAndLoginDTO{Username: "alice", Password: "abcdefgh"}, wantCode: 400, wantMsg: utils.ErrPasswordTooSimple.Error()},
+		{name: "密码与用户名相同", user: dto.UserRegAndLoginDTO{Username: "alice2024", Password: "Alice2024"}, wantCode: 400, wantMsg: utils.ErrPasswordSameAsUser.Error()},
+		{name: "审计日志写入失败时回滚", user: dto.UserRegAndLoginDTO{Username: "alice", Password: "alice2024"}, auditErr: errFake, wantCode: 500, wantMsg: "注册失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestRepos()
+			if tt.existing {
+				repos = newUserRepos(t)
+			}
+			repos.Audit.Err = tt.auditErr
+			s := newUserService(repos)
+
+			assertResult(t, s.Register(&tt.user), tt.wantCode, tt.wantMsg)
+			if tt.wantCode != 200 {
+				if user, _ := repos.User.FindByUsername(tt.user.Username); user != nil && !tt.existing {
+					t.Fatalf("失败后仍保存了用户: %+v", user)
+				}
+				assertAudits(t, repos)
+				return
+			}
+
+			user, err := repos.User.FindByUsername(tt.user.Username)
+			mustNoError(t, err)
+			if user == nil || user.Role != "" || user.Password == tt.user.Password {
+				t.Fatalf("注册的用户 = %+v", user)
+			}
+			if ok, _, err := utils.VerifyPassword(tt.user.Password, user.Password); err != nil || !ok {
+				t.Fatalf("密码哈希校验失败: %v", err)
+			}
+			assertAudits(t, repos, auditKey(model.AuditEntityUser, user.ID, model.AuditActionCreate))
+		})
+	}
+}
+
+func TestUserService_Login(t *testing.T) {
+	repos := newUserRepos(t)
+	legacy := md5.Sum([]byte("legacy123"))
+	repos.User.Users[3] = &model.User{ID: 3, Username: "legacy", Password: hex.EncodeToString(legacy[:]), Role: model.RoleRetailer, SalePlaceID: 5}
+	s := newUserService(repos)
+
+	tests := []struct {
+		name     string
+		user     dto.UserRegAndLoginDTO
+		wantCode int
+		wantMsg  string
+		want     model.Identity
+	}{
+		{
+			name: "登录成功", user: dto.UserRegAndLoginDTO{Username: "farmer", Password: "farmer123"}, wantCode: 200,
+			want: model.Identity{UserID: 2, Username: "farmer", Role: model.RoleFarmer, ProductPlaceID: 2},
+		},
+		{
+			name: "旧版MD5密码登录", user: dto.UserRegAndLoginDTO{Username: "legacy", Password: "legacy123"}, wantCode: 200,
+			want: model.Identity{UserID: 3, Username: "legacy", Role: model.RoleRetailer, SalePlaceID: 5},
+		},
+		{name: "用户名不存在", user: dto.UserRegAndLoginDTO{Username: "nobody", Password: "farmer123"}, wantCode: 400, wantMsg: UsernameInvalid},
+		{name: "密码错误", user: dto.UserRegAndLoginDTO{Username: "farmer", Password: "farmer124"}, wantCode: 400, wantMsg: PasswordInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.Login(&tt.user)
+			assertResult(t, result, tt.wantCode, tt.wantMsg)
+			if tt.wantCode != 200 {
+				return
+			}
+
+			tokens := result.Data.(*dto.TokenDTO)
+			claims, err := utils.ParseToken(tokens.Token)
+			mustNoError(t, err)
+			got := claims.Identity()
+			if got.UserID != tt.want.UserID || got.Username != tt.want.Username || got.Role != tt.want.Role ||
+				got.ProductPlaceID != tt.want.ProductPlaceID || got.SalePlaceID != tt.want.SalePlaceID {
+				t.Fatalf("令牌中的身份 = %+v, 期望 %+v", got, tt.want)
+			}
+			if tokens.RefreshToken == "" || tokens.ExpiresIn <= 0 {
+				t.Fatalf("令牌 = %+v", tokens)
+			}
+		})
+	}
+
+	// 旧版MD5哈希在登录成功后升级为默认算法
+	if ok, needsRehash, err := utils.VerifyPassword("legacy123", repos.User.Users[3].Password); err != nil || !ok || needsRehash {
+		t.Fatalf("旧版密码未升级: ok=%v needsRehash=%v err=%v", ok, needsRehash, err)
+	}
+}
+
+func TestUserService_Refresh(t *testing.T) {
+	repos := newUserRepos(t)
+	s := newUserService(repos)
+	tokens, _ := login(t, s, "farmer", "farmer123")
+
+	result := s.Refresh(&dto.RefreshTokenDTO{RefreshToken: tokens.RefreshToken})
+	assertResult(t, result, 200, "刷新成功")
+	refreshed := result.Data.(*dto.TokenDTO)
+	if refreshed.RefreshToken == tokens.RefreshToken {
+		t.Fatal("刷新后刷新令牌未轮换")
+	}
+
+	assertResult(t, s.Refresh(&dto.RefreshTokenDTO{RefreshToken: "invalid"}), 401, ErrRefreshTokenInvalid.Error())
+
+	// 已轮换的刷新令牌再次使用时注销该用户的所有会话
+	assertResult(t, s.Refresh(&dto.RefreshTokenDTO{RefreshToken: tokens.RefreshToken}), 401, ErrRefreshTokenReused.Error())
+	if repos.User.Users[2].TokenVersion != 1 {
+		t.Fatalf("令牌版本 = %d, 期望 1", repos.User.Users[2].TokenVersion)
+	}
+	assertResult(t, s.Refresh(&dto.RefreshTokenDTO{RefreshToken: refreshed.RefreshToken}), 401, ErrRefreshTokenReused.Error())
+}
+
+func TestUserService_Logout(t *testing.T) {
+	repos := newUserRepos(t)
+	s := newUserService(repos)
+	revoked := func(token string) bool {
+		t.Helper()
+		claims, err := utils.ParseToken(token)
+		mustNoError(t, err)
+		revoked, err := s.TokenService.IsRevoked(claims)
+		mustNoError(t, err)
+		return revoked
+	}
+
+	first, caller := login(t, s, "farmer", "farmer123")
+	second, _ := login(t, s, "farmer", "farmer123")
+	other, _ := login(t, s, "admin", "admin123")
+
+	assertResult(t, s.Logout(nil), 401, NotLoggedIn)
+	assertResult(t, s.Logout(caller), 200, "退出成功")
+	if !revoked(first.Token) || revoked(second.Token) || revoked(other.Token) {
+		t.Fatal("退出登录只应吊销当前会话")
+	}
+	assertResult(t, s.Refresh(&dto.RefreshTokenDTO{RefreshToken: first.RefreshToken}), 401, "")
+
+	assertResult(t, s.LogoutAll(caller), 200, "已退出所有会话")
+	if !revoked(second.Token) || revoked(other.Token) {
+		t.Fatal("退出所有会话应吊销本人的全部令牌")
+	}
+}
+
+func TestUserService_EditPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		caller   *model.Identity
+		password dto.UserEditPasswordDTO
+		wantCode int
+		wantMsg  string
+	}{
+		{
+			name: "修改成功", caller: &model.Identity{UserID: 2, Username: "farmer"},
+			password: dto.UserEditPasswordDTO{OldPassword: "farmer123", NewPassword: "farmer456", ConfirmPassword: "farmer456"},
+			wantCode: 200,
+		},
+		{
+			name: "参数不完整", caller: &model.Identity{UserID: 2},
+			password: dto.UserEditPasswordDTO{OldPassword: "farmer123", NewPassword: "farmer456"},
+			wantCode: 400, wantMsg: PasswordEditInvalid,
+		},
+		{
+			name:     "未登录",
+			password: dto.UserEditPasswordDTO{OldPassword: "farmer123", NewPassword: "farmer456", ConfirmPassword: "farmer456"},
+			wantCode: 401, wantMsg: NotLoggedIn,
+		},
+		{
+			name: "旧密码错误", caller: &model.Identity{UserID: 2},
+			password: dto.UserEditPasswordDTO{OldPassword: "farmer000", NewPassword: "farmer456", ConfirmPassword: "farmer456"},
+			wantCode: 400, wantMsg: PasswordInvalid,
+		},
+		{
+			name: "两次密码不一致", caller: &model.Identity{UserID: 2},
+			password: dto.UserEditPasswordDTO{OldPassword: "farmer123", NewPassword: "farmer456", ConfirmPassword: "farmer789"},
+			wantCode: 400, wantMsg: PasswordError,
+		},
+		{
+			name: "新密码与旧密码相同", caller: &model.Identity{UserID: 2},
+			password: dto.UserEditPasswordDTO{OldPassword: "farmer123", NewPassword: "farmer123", ConfirmPassword: "farmer123"},
+			wantCode: 400, wantMsg: PasswordUnchanged,
+		},
+		{
+			name: "新密码强度不足", caller: &model.Identity{UserID: 2},
+			password: dto.UserEditPasswordDTO{OldPassword: "farmer123", NewPassword: "12345678", ConfirmPassword: "12345678"},
+			wantCode: 400, wantMsg: utils.ErrPasswordTooSimple.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newUserRepos(t)
+			s := newUserService(repos)
+			tokens, _ := login(t, s, "farmer", "farmer123")
+
+			assertResult(t, s.EditPassword(tt.caller, &tt.password), tt.wantCode, tt.wantMsg)
+			user := repos.User.Users[2]
+			if tt.wantCode != 200 {
+				if user.TokenVersion != 0 {
+					t.Fatalf("失败后令牌版本 = %d", user.TokenVersion)
+				}
+				assertAudits(t, repos)
+				return
+			}
+
+			if ok, _, err := utils.VerifyPassword(tt.password.NewPassword, user.Password); err != nil || !ok {
+				t.Fatalf("新密码校验失败: %v", err)
+			}
+			// 修改密码后所有会话失效，审计日志不含密码哈希
+			if user.TokenVersion != 1 {
+				t.Fatalf("令牌版本 = %d, 期望 1", user.TokenVersion)
+			}
+			assertResult(t, s.Refresh(&dto.RefreshTokenDTO{RefreshToken: tokens.RefreshToken}), 401, "")
+			assertAudits(t, repos, auditKey(model.AuditEntityUser, 2, model.AuditActionUpdate))
+			for _, auditLog := range repos.Audit.AuditLogs {
+				if string(auditLog.After) == "" || strings.Contains(string(auditLog.Before)+string(auditLog.After), user.Password) {
+					t.Fatalf("审计日志包含密码哈希: %s", auditLog.After)
+				}
+			}
+		})
+	}
+}
+
+func TestUserService_AssignRole(t *testing.T) {
+	admin := &model.Identity{UserID: 1, Username: "admin", Role: model.RoleAdmin}
+
+	tests := []struct {
+		name      string
+		role      dto.UserRoleDTO
+		wantCode  int
+		wantMsg   string
+		wantRole  string
+		wantAdmin bool
+	}{
+		{name: "分配角色", role: dto.UserRoleDTO{UserID: 2, Role: model.RoleRetailer}, wantCode: 200, wantRole: model.RoleRetailer},
+		{name: "提升为管理员", role: dto.UserRoleDTO{UserID: 2, Role: model.RoleAdmin}, wantCode: 200, wantRole: model.RoleAdmin},
+		{name: "无效的角色", role: dto.UserRoleDTO{UserID: 2, Role: "root"}, wantCode: 400, wantMsg: RoleInvalid},
+		{name: "用户不存在", role: dto.UserRoleDTO{UserID: 99, Role: model.RoleFarmer}, wantCode: 404, wantMsg: UsernameInvalid},
+		{name: "移除最后一个管理员", role: dto.UserRoleDTO{UserID: 1, Role: model.RoleAuditor}, wantCode: 409, wantMsg: LastAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newUserRepos(t)
+			s := newUserService(repos)
+
+			assertResult(t, s.AssignRole(admin, &tt.role), tt.wantCode, tt.wantMsg)
+			if tt.wantCode != 200 {
+				if repos.User.Users[1].Role != model.RoleAdmin || repos.User.Users[2].Role != model.RoleFarmer {
+					t.Fatal("失败后角色被修改")
+				}
+				assertAudits(t, repos)
+				return
+			}
+
+			// 角色写在令牌中，变更后该用户需重新登录
+			user := repos.User.Users[tt.role.UserID]
+			if user.Role != tt.wantRole || user.TokenVersion != 1 {
+				t.Fatalf("分配角色后 = %+v", user)
+			}
+			assertAudits(t, repos, auditKey(model.AuditEntityUser, tt.role.UserID, model.AuditActionUpdate))
+		})
+	}
+}
+
+func TestUserService_BindUser(t *testing.T) {
+	admin := &model.Identity{UserID: 1, Username: "admin", Role: model.RoleAdmin}
+
+	tests := []struct {
+		name     string
+		binding  dto.UserBindingDTO
+		deleted  bool
+		wantCode int
+	}{
+		{name: "绑定公司", binding: dto.UserBindingDTO{UserID: 2, CompanyID: 1, ProductPlaceID: 2}, wantCode: 200},
+		{name: "解除绑定", binding: dto.UserBindingDTO{UserID: 2}, wantCode: 200},
+		{name: "保留已删除的原有绑定", binding: dto.UserBindingDTO{UserID: 2, CompanyID: 1, ProductPlaceID: 2}, deleted: true, wantCode: 200},
+		{name: "绑定已删除的公司", binding: dto.UserBindingDTO{UserID: 2, CompanyID: 3, ProductPlaceID: 2}, wantCode: 422},
+		{name: "绑定不存在的销售地", binding: dto.UserBindingDTO{UserID: 2, ProductPlaceID: 2, SalePlaceID: 9}, wantCode: 422},
+		{name: "用户不存在", binding: dto.UserBindingDTO{UserID: 99}, wantCode: 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newUserRepos(t)
+			if tt.deleted {
+				repos.Reference.AddExisting(model.AuditEntityProductionPlace, 2, true)
+			}
+			s := newUserService(repos)
+
+			assertResult(t, s.BindUser(admin, &tt.binding), tt.wantCode, "")
+			user := repos.User.Users[2]
+			if tt.wantCode != 200 {
+				if user.CompanyID != 0 || user.ProductPlaceID != 2 || user.TokenVersion != 0 {
+					t.Fatalf("失败后绑定被修改: %+v", user)
+				}
+				return
+			}
+			if user.CompanyID != tt.binding.CompanyID || user.ProductPlaceID != tt.binding.ProductPlaceID ||
+				user.SalePlaceID != tt.binding.SalePlaceID || user.TokenVersion != 1 {
+				t.Fatalf("绑定后 = %+v", user)
+			}
+		})
+	}
+}
+
+func TestUserService_UpdateAndGetUserInfo(t *testing.T) {
+	repos := newUserRepos(t)
+	s := newUserService(repos)
+	caller := &model.Identity{UserID: 2, Username: "farmer", Role: model.RoleFarmer}
+
+	if _, err := s.GetUserInfo(nil); err == nil || err.Error() != NotLoggedIn {
+		t.Fatalf("未登录 err = %v", err)
+	}
+
+	// 只能修改调用者本人，请求中的ID被忽略
+	assertResult(t, s.Update(caller, &dto.UserDTO{ID: 1, Username: "admin"}), 400, UsernameError)
+	assertResult(t, s.Update(caller, &dto.UserDTO{ID: 1, Username: "farmer2", Name: "张三"}), 200, "更新成功")
+	if repos.User.Users[1].Username != "admin" {
+		t.Fatalf("修改了其他用户: %+v", repos.User.Users[1])
+	}
+
+	user, err := s.GetUserInfo(caller)
+	mustNoError(t, err)
+	if user.Username != "farmer2" || user.Name.String != "张三" || user.Password != "******" {
+		t.Fatalf("GetUserInfo = %+v", user)
+	}
+	assertAudits(t, repos, auditKey(model.AuditEntityUser, 2, model.AuditActionUpdate))
+
+	if _, err := s.GetUserInfo(&model.Identity{UserID: 99}); err == nil || err.Error() != UsernameInvalid {
+		t.Fatalf("用户不存在 err = %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"agricultural_product_gin/model"
+	"agricultural_product_gin/repository"
+	"agricultural_product_gin/service"
+)
+
+// userUsage user子命令用法
+const userUsage = `用法:
+  user promote 用户名    将已注册的用户设为管理员(初始化系统或系统中没有管理员时使用)`
+
+// runUser 执行user子命令
+func runUser(db *repository.DB, args []string) error {
+	if len(args) != 2 || args[0] != "promote" {
+		return errors.New(userUsage)
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	tokenService := service.NewTokenService(repository.NewTokenRepository(db), userRepo)
+	userService := service.NewUserService(userRepo, tokenService, repository.NewUnitOfWork(db))
+	if err := userService.PromoteAdmin(args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("已将用户%s设为管理员，请重新登录\n", args[1])
+	return nil
+}
+
+// warnNoAdmin 已有用户但没有管理员时提示(注册的用户不会自动成为管理员)
+func warnNoAdmin(db *repository.DB) {
+	userRepo := repository.NewUserRepository(db)
+	admins, err := userRepo.CountByRole(model.RoleAdmin)
+	if err != nil {
+		log.Println("查询管理员失败:", err)
+		return
+	}
+	if admins > 0 {
+		return
+	}
+	if total, err := userRepo.Count(); err == nil && total > 0 {
+		log.Println("警告：系统中没有管理员，请运行 user promote 用户名 指定管理员")
+	}
+}
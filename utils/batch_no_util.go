@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"crypto/rand"
+	"time"
+)
+
+// 批次号随机部分的字符集(去掉易混淆的0/O、1/I)
+const batchNoAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// 批次号随机部分长度
+const batchNoRandomLen = 6
+
+// GenerateBatchNo 生成批次号，格式为 B + 日期(yyyyMMdd) + 6位随机字符，如 B20240501K7Q2XM
+func GenerateBatchNo(date time.Time) (string, error) {
+	buf := make([]byte, batchNoRandomLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = batchNoAlphabet[int(b)%len(batchNoAlphabet)]
+	}
+	return "B" + date.Format("20060102") + string(buf), nil
+}
@@ -1,46 +1,113 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+
+	"agricultural_product_gin/model"
 )
 
-const (
-	// 密钥
-	secretKey = "123456"
-	// token有效期（12小时）
-	tokenExpire = time.Hour * 12
+// 刷新令牌随机字节数
+const refreshTokenBytes = 32
+
+var (
+	// 密钥，启动时由配置设置
+	secretKey = []byte("123456")
+	// AccessTokenExpire 访问令牌有效期(默认15分钟)，过期后使用刷新令牌换取新令牌
+	AccessTokenExpire = time.Minute * 15
+	// RefreshTokenExpire 刷新令牌有效期(默认7天)
+	RefreshTokenExpire = time.Hour * 24 * 7
 )
 
+// InitJWT 设置签名密钥及令牌有效期
+func InitJWT(secret string, accessTokenExpire, refreshTokenExpire time.Duration) {
+	secretKey = []byte(secret)
+	AccessTokenExpire = accessTokenExpire
+	RefreshTokenExpire = refreshTokenExpire
+}
+
 // 自定义Claims
 type Claims struct {
 	UserID   int    `json:"id"`
 	Username string `json:"username"`
+	Role     string `json:"role"`
+
+	// 账号绑定的业务主体，变更后需重新登录生效
+	CompanyID      int `json:"companyId,omitempty"`
+	ProductPlaceID int `json:"productPlaceId,omitempty"`
+	SalePlaceID    int `json:"salePlaceId,omitempty"`
+
+	// 签发时用户的令牌版本，与当前版本不一致说明已退出所有会话
+	TokenVersion int `json:"ver"`
 	jwt.StandardClaims
 }
 
-// GenerateToken 生成token
-func GenerateToken(userID int, username string) (string, error) {
-	claims := Claims{
-		UserID:   userID,
-		Username: username,
+// Identity 转换为当前用户身份
+func (c *Claims) Identity() *model.Identity {
+	return &model.Identity{
+		UserID:         c.UserID,
+		Username:       c.Username,
+		Role:           c.Role,
+		CompanyID:      c.CompanyID,
+		ProductPlaceID: c.ProductPlaceID,
+		SalePlaceID:    c.SalePlaceID,
+		TokenID:        c.Id,
+		TokenExpiresAt: time.Unix(c.ExpiresAt, 0),
+	}
+}
+
+// GenerateToken 生成访问令牌，返回令牌及其声明(含令牌ID)
+func GenerateToken(user *model.User) (string, *Claims, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:         user.ID,
+		Username:       user.Username,
+		Role:           user.Role,
+		CompanyID:      user.CompanyID,
+		ProductPlaceID: user.ProductPlaceID,
+		SalePlaceID:    user.SalePlaceID,
+		TokenVersion:   user.TokenVersion,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(tokenExpire).Unix(),
-			IssuedAt:  time.Now().Unix(),
+			Id:        uuid.New().String(),
+			ExpiresAt: now.Add(AccessTokenExpire).Unix(),
+			IssuedAt:  now.Unix(),
 		},
 	}
 
 	// 生成token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secretKey))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secretKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// GenerateRefreshToken 生成随机刷新令牌
+func GenerateRefreshToken() (string, error) {
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken 计算令牌的SHA-256哈希，服务端只保存哈希值
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // ParseToken 解析token
 func ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
+		return secretKey, nil
 	})
 
 	if err != nil {
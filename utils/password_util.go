@@ -2,12 +2,232 @@ package utils
 
 import (
 	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrPasswordHashFormat = errors.New("无法识别的密码哈希格式")
+	ErrPasswordTooShort   = errors.New("密码长度不能少于8位")
+	ErrPasswordTooLong    = errors.New("密码长度不能超过64位")
+	ErrPasswordTooSimple  = errors.New("密码必须同时包含字母和数字")
+	ErrPasswordSameAsUser = errors.New("密码不能与用户名相同")
 )
 
-// EncryptPassword 密码加密
-func EncryptPassword(password string) string {
-	h := md5.New()
-	h.Write([]byte(password))
-	return hex.EncodeToString(h.Sum(nil))
+// 密码长度限制(bcrypt最多使用72字节)
+const (
+	passwordMinLength = 8
+	passwordMaxLength = 64
+)
+
+// PasswordHasher 密码哈希算法，算法参数编码在哈希值中
+type PasswordHasher interface {
+	// Hash 计算密码哈希(每次随机加盐)
+	Hash(password string) (string, error)
+	// Verify 校验密码是否与哈希匹配
+	Verify(password, encoded string) (bool, error)
+	// Supports 是否为本算法生成的哈希
+	Supports(encoded string) bool
+	// NeedsRehash 哈希参数是否低于当前配置
+	NeedsRehash(encoded string) bool
+}
+
+// DefaultPasswordHasher 新密码使用的哈希算法
+var DefaultPasswordHasher PasswordHasher = NewArgon2idHasher()
+
+// passwordHashers 可校验的哈希算法(含默认算法)
+var passwordHashers = []PasswordHasher{NewArgon2idHasher(), NewBcryptHasher()}
+
+// HashPassword 使用默认算法计算密码哈希
+func HashPassword(password string) (string, error) {
+	return DefaultPasswordHasher.Hash(password)
+}
+
+// VerifyPassword 按哈希格式选择算法校验密码
+// needsRehash为true表示密码正确但哈希应升级为默认算法(包括旧版MD5哈希)
+func VerifyPassword(password, encoded string) (ok bool, needsRehash bool, err error) {
+	if isLegacyMD5(encoded) {
+		sum := md5.Sum([]byte(password))
+		ok = subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(strings.ToLower(encoded))) == 1
+		return ok, ok, nil
+	}
+
+	for _, hasher := range passwordHashers {
+		if !hasher.Supports(encoded) {
+			continue
+		}
+		ok, err = hasher.Verify(password, encoded)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		needsRehash = !DefaultPasswordHasher.Supports(encoded) || DefaultPasswordHasher.NeedsRehash(encoded)
+		return true, needsRehash, nil
+	}
+
+	return false, false, ErrPasswordHashFormat
+}
+
+// ValidatePasswordStrength 校验密码强度：8-64位，同时包含字母和数字，且不能与用户名相同
+func ValidatePasswordStrength(password, username string) error {
+	length := len([]rune(password))
+	if length < passwordMinLength {
+		return ErrPasswordTooShort
+	}
+	if length > passwordMaxLength {
+		return ErrPasswordTooLong
+	}
+	if strings.EqualFold(password, username) {
+		return ErrPasswordSameAsUser
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return ErrPasswordTooSimple
+	}
+	return nil
+}
+
+// isLegacyMD5 是否为旧版无盐MD5哈希(32位十六进制)
+func isLegacyMD5(encoded string) bool {
+	if len(encoded) != md5.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(encoded)
+	return err == nil
+}
+
+// BcryptHasher bcrypt哈希，代价因子编码在哈希中
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher 创建bcrypt哈希算法
+func NewBcryptHasher() *BcryptHasher {
+	return &BcryptHasher{Cost: 12}
+}
+
+// Hash 计算密码哈希
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify 校验密码
+func (h *BcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Supports 是否为bcrypt哈希
+func (h *BcryptHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// NeedsRehash 代价因子低于当前配置时需要升级
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < h.Cost
+}
+
+// Argon2idHasher argon2id哈希，格式为 $argon2id$v=19$m=内存KiB,t=迭代次数,p=并行度$盐$哈希
+type Argon2idHasher struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+// NewArgon2idHasher 创建argon2id哈希算法(64MiB内存，3次迭代)
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{Time: 3, Memory: 64 * 1024, Threads: 2, KeyLen: 32, SaltLen: 16}
+}
+
+// Hash 计算密码哈希
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify 使用哈希中记录的参数校验密码
+func (h *Argon2idHasher) Verify(password, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(actual, key) == 1, nil
+}
+
+// Supports 是否为argon2id哈希
+func (h *Argon2idHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+// NeedsRehash 任一参数低于当前配置时需要升级
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Time < h.Time || params.Memory < h.Memory || params.Threads < h.Threads || uint32(len(key)) < h.KeyLen
+}
+
+// decodeArgon2id 解析argon2id哈希中的参数、盐和哈希值
+func decodeArgon2id(encoded string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+
+	return params, salt, key, nil
 }
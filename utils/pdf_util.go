@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// A4纸张尺寸及页边距(单位：点)
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+)
+
+// PDFDocument 纯文本PDF文档，按行自上而下排版，超出页面时自动换页
+// 使用PDF阅读器内置的中文字体STSong-Light(UniGB-UCS2-H编码)，不嵌入字体文件
+type PDFDocument struct {
+	pages   []*bytes.Buffer
+	current *bytes.Buffer
+	y       float64
+}
+
+// NewPDFDocument 创建PDF文档
+func NewPDFDocument() *PDFDocument {
+	d := &PDFDocument{}
+	d.newPage()
+	return d
+}
+
+// Title 标题
+func (d *PDFDocument) Title(text string) {
+	d.write(text, 18, 0)
+	d.Rule()
+}
+
+// Heading 小节标题
+func (d *PDFDocument) Heading(text string) {
+	d.Space(6)
+	d.write(text, 13, 0)
+}
+
+// Text 正文，过长时自动折行
+func (d *PDFDocument) Text(text string) {
+	d.write(text, 10, 0)
+}
+
+// Field 带缩进的"名称：值"，值为空时不输出
+func (d *PDFDocument) Field(name, value string) {
+	if value == "" {
+		return
+	}
+	d.write(name+"："+value, 10, 12)
+}
+
+// Item 带缩进的列表项
+func (d *PDFDocument) Item(text string) {
+	d.write("· "+text, 10, 12)
+}
+
+// Rule 分隔线
+func (d *PDFDocument) Rule() {
+	d.ensure(8)
+	d.y -= 4
+	fmt.Fprintf(d.current, "0.5 w %.1f %.1f m %.1f %.1f l S\n", pdfMargin, d.y, pdfPageWidth-pdfMargin, d.y)
+	d.y -= 4
+}
+
+// Space 空白
+func (d *PDFDocument) Space(height float64) {
+	d.y -= height
+}
+
+// Bytes 生成PDF文件内容
+func (d *PDFDocument) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// 对象编号：1目录 2页面树 3字体 4CID字体 5字体描述，之后每页依次为页面和内容流
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+i*2)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light-UniGB-UCS2-H /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>")
+	object("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>")
+	object("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 7+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// write 输出一段文字，indent为左缩进，超出行宽时折行
+func (d *PDFDocument) write(text string, size, indent float64) {
+	width := pdfPageWidth - 2*pdfMargin - indent
+	for _, line := range wrapPDFText(text, size, width) {
+		d.ensure(size * 1.6)
+		d.y -= size * 1.6
+		fmt.Fprintf(d.current, "BT /F1 %.1f Tf %.1f %.1f Td <%s> Tj ET\n", size, pdfMargin+indent, d.y, encodePDFText(line))
+	}
+}
+
+// ensure 剩余高度不足时换页
+func (d *PDFDocument) ensure(height float64) {
+	if d.y-height < pdfMargin {
+		d.newPage()
+	}
+}
+
+func (d *PDFDocument) newPage() {
+	d.current = &bytes.Buffer{}
+	d.pages = append(d.pages, d.current)
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// wrapPDFText 按行宽折行：ASCII字符宽度为半个字号，其余字符为一个字号
+func wrapPDFText(text string, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		var line strings.Builder
+		lineWidth := 0.0
+		for _, r := range paragraph {
+			w := size
+			if r < utf8.RuneSelf {
+				w = size / 2
+			}
+			if lineWidth+w > width && line.Len() > 0 {
+				lines = append(lines, line.String())
+				line.Reset()
+				lineWidth = 0
+			}
+			line.WriteRune(r)
+			lineWidth += w
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// encodePDFText 将文字编码为UCS-2(大端)十六进制串，基本多文种平面以外的字符替换为?
+func encodePDFText(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if r > 0xFFFF || r < 0x20 {
+			r = '?'
+		}
+		fmt.Fprintf(&sb, "%04X", r)
+	}
+	return sb.String()
+}
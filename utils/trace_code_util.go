@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+)
+
+// 溯源码随机字节数(128位，不可猜测)
+const traceCodeBytes = 16
+
+var traceCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTraceCode 生成公开溯源码
+func GenerateTraceCode() (string, error) {
+	buf := make([]byte, traceCodeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return strings.ToLower(traceCodeEncoding.EncodeToString(buf)), nil
+}